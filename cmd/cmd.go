@@ -41,6 +41,7 @@ func Run() error {
 
 	// Define flags and configuration settings
 	rootCmd.PersistentFlags().String("log-level", "info", "stdout log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("log-format", "text", "stdout and stderr log format (text, json)")
 	rootCmd.PersistentFlags().String("config-file", "", "config file (default is $HOME/zeno-config.yaml)")
 	rootCmd.PersistentFlags().Bool("no-stdout-log", false, "disable stdout logging.")
 	rootCmd.PersistentFlags().Bool("no-stderr-log", false, "disable stderr logging.")
@@ -52,5 +53,8 @@ func Run() error {
 	getCmd := getCMDs()
 	rootCmd.AddCommand(getCmd)
 
+	// Add frontier subcommands
+	rootCmd.AddCommand(frontierCMDs())
+
 	return rootCmd.Execute()
 }
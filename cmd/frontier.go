@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+	"github.com/spf13/cobra"
+)
+
+var frontierDumpCmd = &cobra.Command{
+	Use:   "dump [job]",
+	Short: "Write the pending URLs of a job's local queue as JSON lines on stdout",
+	Long: `Write the pending URLs of a job's local queue as JSON lines on stdout.
+The job is either a job name, read from the jobs directory, or the path of a job directory.
+The crawl should be stopped. The dump can be enqueued in another crawl with --load-frontier.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		jobPath := args[0]
+		if info, err := os.Stat(jobPath); err != nil || !info.IsDir() {
+			jobPath = path.Join("jobs", args[0])
+		}
+
+		count, err := lq.Dump(context.Background(), jobPath, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("error dumping frontier: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%d URLs dumped\n", count)
+
+		return nil
+	},
+}
+
+func frontierCMDs() *cobra.Command {
+	frontierCmd := &cobra.Command{
+		Use:   "frontier",
+		Short: "Manage the local queue of a job",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	frontierCmd.AddCommand(frontierDumpCmd)
+
+	return frontierCmd
+}
@@ -19,67 +19,198 @@ func getCMDs() *cobra.Command {
 
 	getCMDsFlags(getCmd)
 	getHQCmdFlags(getHQCmd)
+	getKafkaCmdFlags(getKafkaCmd)
+	getRedisCmdFlags(getRedisCmd)
+	getListCmdFlags(getListCmd)
 
 	getCmd.AddCommand(getURLCmd)
+	getCmd.AddCommand(getListCmd)
 	getCmd.AddCommand(getHQCmd)
+	getCmd.AddCommand(getKafkaCmd)
+	getCmd.AddCommand(getRedisCmd)
 
 	return getCmd
 }
 
 func getCMDsFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("user-agent", "", "User agent to use when requesting URLs.")
+	getCmd.PersistentFlags().StringArray("user-agent-pool", []string{}, "User-Agent to rotate among instead of --user-agent, repeat the flag to build the pool. Redirections keep the User-Agent of the request they come from.")
+	getCmd.PersistentFlags().StringToString("host-auth", map[string]string{}, "Credentials sent to a host, e.g. example.com=basic:user:password or example.com=bearer:token. Only sent to that exact host, redirections to other hosts or from https to http don't get them. The Authorization header is archived in the WARC request records. Can be repeated.")
+	getCmd.PersistentFlags().StringArray("header", []string{}, "Header sent with every request, e.g. \"X-Archive: zeno\". Replaces the User-Agent, Referer and the other headers set by Zeno. The headers of the seed list and the site-specific ones take precedence. Host can't be set. Can be repeated.")
+	getCmd.PersistentFlags().StringArray("host-header", []string{}, "Header sent with the requests to a host, e.g. \"example.org|X-Api-Key: value\". Only sent to that exact host, it takes precedence over --header. The headers of the seed list and the site-specific ones take precedence. Can be repeated.")
+	getCmd.PersistentFlags().String("referer-policy", "no-referrer", "Referer sent with the requests, after the browsers' Referrer-Policy values: no-referrer, unsafe-url (the URL of the page the URL was found on), origin, same-origin (the page URL, only on the same host), strict-origin or no-referrer-when-downgrade. Redirections keep the Referer of the request they come from, seeds are sent the page their source found them on.")
+	getCmd.PersistentFlags().String("accept", "", "Accept header sent with the requests, instead of the one built from --fetch-content-type-allow. The headers of the seed list and the site-specific ones take precedence.")
+	getCmd.PersistentFlags().String("accept-language", "", "Accept-Language header sent with the requests, e.g. fr-FR,fr;q=0.9. The headers of the seed list and the site-specific ones take precedence.")
+	getCmd.PersistentFlags().StringArray("accept-language-variants", []string{}, "Other Accept-Language values to capture the pages in, repeat the flag for each language. When the response to a page varies on Accept-Language, the page is requested again with each of them and every variant is written to the WARC. The variants aren't used for extraction.")
+	getCmd.PersistentFlags().String("user-agent-rotation", "per-host", "User-Agent rotation strategy when --user-agent-pool is set: per-host (a host always gets the same User-Agent) or per-request.")
 	getCmd.PersistentFlags().String("job", "", "Job name to use, will determine the path for the persistent queue, seencheck database, and WARC files.")
 	getCmd.PersistentFlags().IntP("workers", "w", 1, "Number of concurrent workers to run.")
+	getCmd.PersistentFlags().Duration("worker-stuck-threshold", 5*time.Minute, "Time after which a worker in the same state (fetching, extracting or writing) is flagged as stuck by the API and the metrics.")
 	getCmd.PersistentFlags().Int("max-concurrent-assets", 1, "Max number of concurrent assets to fetch PER worker. E.g. if you have 100 workers and this setting at 8, Zeno could do up to 800 concurrent requests at any time.")
+	getCmd.PersistentFlags().Int("max-concurrent-assets-total", 0, "Max number of concurrent assets to fetch across all workers, pages aren't counted so that asset-heavy pages don't starve page crawling. 0 means no limit.")
+	getCmd.PersistentFlags().Int("max-concurrent-assets-per-host", 0, "Max number of concurrent assets to fetch from a single host across all workers. 0 means no limit.")
+	getCmd.PersistentFlags().Int("max-concurrent-requests-per-domain", 0, "Maximum number of pages requested at the same time on a host, 0 means no limit. Workers archive the seeds of other hosts meanwhile instead of waiting. Assets are bounded by --max-concurrent-assets-per-host.")
+	getCmd.PersistentFlags().Int("host-breaker-threshold", 0, "Number of failed captures (connection errors or 5xx once retried) within --host-breaker-window after which a host that fails most of its requests isn't crawled for --host-breaker-cooldown. Its seeds are deferred, then one of them tests if it recovered. 0 disables it.")
+	getCmd.PersistentFlags().Duration("host-breaker-window", time.Minute, "Window the failures of a host are counted in for --host-breaker-threshold.")
+	getCmd.PersistentFlags().Duration("host-breaker-cooldown", 5*time.Minute, "Time a host tripped by --host-breaker-threshold isn't crawled before being tested again.")
+	getCmd.PersistentFlags().String("frontier-strategy", "host-fair", "Order the local queue serves the URLs in: host-fair (hosts in turn, the URLs of each host by hops), bfs (the lowest hops first) or dfs (the highest hops first).")
+	getCmd.PersistentFlags().Int("frontier-host-memory", 100, "Maximum number of pending URLs of a host held in memory by the local queue, the rest stays on disk until the host's turn comes. 0 reads the URLs one at a time.")
+	getCmd.PersistentFlags().Int("frontier-max-memory", 100_000, "Maximum number of pending URLs held in memory by the local queue across all hosts. 0 reads the URLs one at a time.")
+	getCmd.PersistentFlags().String("load-frontier", "", "Frontier dump written by 'Zeno frontier dump' to enqueue before the seeds. URLs already in the local queue are skipped.")
+	getCmd.PersistentFlags().Duration("source-flush-timeout", time.Minute, "Maximum time spent, when stopping, reporting to the source (HQ, local queue, Kafka or Redis) the finished and discovered items left. Items still not reported then are abandoned.")
+	getCmd.PersistentFlags().Duration("stop-timeout", 0, "Maximum time a graceful stop (SIGINT, SIGTERM or the API) waits for the in-flight requests, they are then canceled like with a stop now. What was captured is still written. 0 waits for them.")
 	getCmd.PersistentFlags().Int("max-hops", 0, "Maximum number of hops to execute.")
 	getCmd.PersistentFlags().String("cookies", "", "File containing cookies that will be used for requests.")
 	getCmd.PersistentFlags().Bool("disable-seencheck", false, "Disable the (remote or local) seencheck that avoid re-crawling of URIs.")
+	getCmd.PersistentFlags().Bool("seencheck-bloom", false, "Use bloom filters for the local seencheck instead of the database. Uses much less memory but a small fraction of never seen URLs will be skipped.")
+	getCmd.PersistentFlags().Uint("seencheck-bloom-items", 10_000_000, "Number of URLs the seencheck bloom filters are sized for. Going over it increases the false positive rate.")
+	getCmd.PersistentFlags().Int("queue-dedupe-window", 0, "Number of recently queued outlinks remembered to drop the duplicates found on other pages before they reach the seencheck and the queue. 0 disables it.")
+	getCmd.PersistentFlags().Int("outlinks-spill-memory", 0, "Memory used by Zeno, in MB, over which the outlinks are spilled on disk in the job directory instead of being queued, until the memory pressure drops. -1 uses 90% of GOMEMLIMIT, 0 disables it.")
+	getCmd.PersistentFlags().Float64("seencheck-bloom-fp-rate", 0.000001, "Target false positive rate of the seencheck bloom filters, i.e. the fraction of never seen URLs that will be skipped.")
 	getCmd.PersistentFlags().Bool("api", false, "Enable API")
 	getCmd.PersistentFlags().Int("api-port", 9090, "Port to listen on for the API.")
+	getCmd.PersistentFlags().String("api-host", "", "Address to listen on for the API, all the interfaces by default.")
+	getCmd.PersistentFlags().String("api-token", "", "Token required as \"Authorization: Bearer <token>\" on every API endpoint but /healthz, /readyz and /metrics. Can be set with the ZENO_API_TOKEN environment variable.")
+	getCmd.PersistentFlags().String("api-metrics-token", "", "Token required as \"Authorization: Bearer <token>\" on /metrics. Can be set with the ZENO_API_METRICS_TOKEN environment variable.")
+	getCmd.PersistentFlags().Int("health-port", 0, "Port of a listener serving only /healthz and /readyz, without token, for the orchestrators. It runs even without --api, 0 disables it.")
+	getCmd.PersistentFlags().Duration("health-warc-stall-timeout", 2*time.Minute, "Time after which /healthz fails if records wait for the WARC writer without any byte being written.")
 	getCmd.PersistentFlags().Int("max-redirect", 20, "Specifies the maximum number of redirections to follow for a resource.")
-	getCmd.PersistentFlags().Int("max-retry", 5, "Number of retry if error happen when executing HTTP request.")
+	getCmd.PersistentFlags().Int("feed-max-entries", 1000, "Maximum number of entries of a RSS or Atom feed to extract links from, 0 means no limit.")
+	getCmd.PersistentFlags().Bool("seed-from-feed", false, "Fetch the seeds before the crawl begins and, for the ones that are RSS or Atom feeds, add the links of their entries as seeds.")
+	getCmd.PersistentFlags().Int("meta-refresh-max-delay", 10, "Maximum delay in seconds of a meta refresh redirection to follow it, -1 disables following them.")
+	getCmd.PersistentFlags().String("canonical", "off", "What to do with the pages whose <link rel=\"canonical\"> is another URL of the same host: off ignores it, log logs the canonical URL and follow also queues it and skips the outlinks of the page. The page itself is always captured.")
+	getCmd.PersistentFlags().Int("max-retry", 5, "Number of retry if error happen when executing HTTP request. The transient network errors requeue the URL with its seed and a backoff instead, up to as many times.")
 	getCmd.PersistentFlags().Int("http-timeout", -1, "Number of seconds to wait before timing out a request. Note: this will CANCEL large files download.")
 	getCmd.PersistentFlags().Int("http-read-deadline", 60, "Number of seconds to wait before timing out a (blocking) read.")
+	getCmd.PersistentFlags().Duration("connect-timeout", 10*time.Second, "Time to wait for a connection to be established, TLS handshake included.")
+	getCmd.PersistentFlags().Duration("response-header-timeout", 0, "Time to wait for the response headers once the request is written, 0 disables it.")
+	getCmd.PersistentFlags().Duration("read-idle-timeout", 0, "Time without any byte of the response body received after which the download is aborted, 0 disables it. Unlike --http-timeout, a slow download that keeps progressing isn't aborted.")
 	getCmd.PersistentFlags().StringSlice("domains-crawl", []string{}, "Naive domains, full URLs or regexp to match against any URL to determine hop behaviour for outlinks. If an outlink URL is matched it will be queued to crawl with a hop of 0. This flag helps crawling entire domains while doing non-focused crawls.")
 	getCmd.PersistentFlags().StringSlice("disable-html-tag", []string{}, "Specify HTML tag to not extract assets from")
+	getCmd.PersistentFlags().StringSlice("lazy-load-attributes", []string{"data-src", "data-srcset", "data-original", "data-lazy-src", "data-lazy-srcset", "data-lazy", "data-hi-res-src"}, "Attributes of img, source, iframe and video elements holding lazy-loaded URLs, captured along with src. Attributes named like *srcset are parsed as srcset.")
+	getCmd.PersistentFlags().Int("hls-max-segments", 1000, "Maximum number of segments captured from a HLS playlist, 0 means no limit.")
+	getCmd.PersistentFlags().Int("hls-max-size", 2048, "Maximum size in MB of the segments captured for a HLS playlist, 0 means no limit.")
+	getCmd.PersistentFlags().Int("dash-max-segments", 1000, "Maximum number of media segments captured for each Representation of a DASH manifest, 0 means no limit.")
+	getCmd.PersistentFlags().Bool("dash-capture-live", false, "Capture the segments currently advertised by dynamic (live) DASH manifests instead of skipping them.")
+	getCmd.PersistentFlags().Bool("js-extraction", false, "Extract the URLs found in the string literals of inline scripts and JavaScript assets. Expect false positives.")
+	getCmd.PersistentFlags().Bool("js-extraction-same-host", false, "Only keep the URLs extracted from JavaScript that are on the same host as the page.")
+	getCmd.PersistentFlags().Int("js-extraction-max-urls", 100, "Maximum number of URLs extracted from the JavaScript of a page, 0 means no limit.")
+	getCmd.PersistentFlags().Bool("websocket-discovery", false, "Log the WebSocket endpoints (ws:// and wss:// URLs) found in the string literals of inline scripts and JavaScript assets.")
+	getCmd.PersistentFlags().Bool("websocket-handshake", false, "Capture the HTTP upgrade handshake of the WebSocket endpoints found by --websocket-discovery. The connection is closed once upgraded, no message is captured.")
 	getCmd.PersistentFlags().Bool("capture-alternate-pages", false, "If turned on, <link> HTML tags with \"alternate\" values for their \"rel\" attribute will be archived.")
+	getCmd.PersistentFlags().Bool("inline-iframe-srcdoc", false, "Extract the assets and outlinks of the documents inlined in the srcdoc attribute of <iframe> tags, their relative URLs are resolved against the page.")
+	getCmd.PersistentFlags().Bool("html-goquery-extraction", false, "Extract the URLs of the HTML pages from a goquery document of the whole page, like the previous releases, instead of streaming the page. Deprecated, it will be removed in the next release.")
+	getCmd.PersistentFlags().Bool("capture-favicon", false, "Capture the legacy /favicon.ico of the seeds that don't declare an icon with a <link>.")
+	getCmd.PersistentFlags().Bool("soft-404-detection", false, "Probe each host once with a bogus path, and skip the extraction of the pages matching the error page it returns with a 200. The probes and the matching pages are still archived.")
+	getCmd.PersistentFlags().Int("soft-404-max-hosts", 10000, "Maximum number of hosts whose soft 404 signature is kept in memory, the least recently seen hosts are probed again if they come back.")
 	getCmd.PersistentFlags().StringSlice("exclude-host", []string{}, "Exclude a specific host from the crawl, note that it will not exclude the domain if it is encountered as an asset for another web page.")
 	getCmd.PersistentFlags().StringSlice("include-host", []string{}, "Only crawl specific hosts, note that it will not include the domain if it is encountered as an asset for another web page.")
 	getCmd.PersistentFlags().StringSlice("include-string", []string{}, "Only crawl URLs containing this string.")
 	getCmd.PersistentFlags().Int("crawl-time-limit", 0, "Number of seconds until the crawl will automatically set itself into the finished state.")
 	getCmd.PersistentFlags().Int("crawl-max-time-limit", 0, "Number of seconds until the crawl will automatically panic itself. Default to crawl-time-limit + (crawl-time-limit / 10)")
+	getCmd.PersistentFlags().Int("max-crawled-items", 0, "Number of URLs crawled after which the crawl stops, the captures in progress are completed and the remaining URLs are given back to the source. 0 means no limit.")
+	getCmd.PersistentFlags().Int("max-total-warc-size", 0, "Size in MB of the WARC files of the job on disk, compressed if they are, after which the crawl stops like with --max-crawled-items. The records of the captures in progress are still written, the files are then finalized. 0 means no limit.")
+	getCmd.PersistentFlags().String("max-crawled-items-scope", "all", "URLs counted against --max-crawled-items: all, seeds (pages, redirections included) or assets.")
 	getCmd.PersistentFlags().StringSlice("exclude-string", []string{}, "Discard any (discovered) URLs containing this string.")
 	getCmd.PersistentFlags().StringSlice("exclusion-file", []string{}, "File containing regex to apply on URLs for exclusion. If the path start with http or https, it will be treated as a URL of a file to download.")
 	getCmd.PersistentFlags().Float64("min-space-required", 0, "Minimum space required in GB to continue the crawl. Default will be 50GB * (total disk space / 256GB) if total disk space is less than 256GB, else 50GB.")
+	getCmd.PersistentFlags().Float64("min-space-resume-margin", 10, "Percentage of --min-space-required over it the free space must be back to resume a crawl paused for lack of disk space, so that it doesn't pause and resume over and over.")
+	getCmd.PersistentFlags().Duration("disk-check-interval", 5*time.Second, "Interval between the checks of the free space of the job's disk and of the WARC temp dir's one.")
+	getCmd.PersistentFlags().StringSlice("crawl-window", []string{}, "Only crawl during this daily time range, formatted as HH:MM-HH:MM (e.g. 01:00-06:00, ranges can span over midnight). Can be repeated. Outside of the windows, the pipeline is paused.")
+	getCmd.PersistentFlags().String("crawl-window-timezone", "Local", "IANA timezone in which the crawl windows are expressed (e.g. Europe/Paris, UTC).")
 
 	// Network flags
-	getCmd.PersistentFlags().String("proxy", "", "Proxy to use when requesting pages.")
+	getCmd.PersistentFlags().String("proxy", "", "SOCKS5 proxy to use when requesting pages, socks5://[user:pass@]host:port or socks5h://. With both schemes the host names are passed to the proxy, which resolves them, they are resolved locally too for the DNS records of the WARC files.")
+	getCmd.PersistentFlags().StringSlice("proxy-pool", []string{}, "SOCKS5 proxies to rotate among when requesting pages, in the format of --proxy, can't be used with --proxy. Each proxy writes its own WARC files.")
+	getCmd.PersistentFlags().String("proxy-file", "", "File of the proxies of the pool, one per line in the format of --proxy, added to --proxy-pool. Blank lines and lines starting with # are ignored. The file is read again on SIGHUP and every --proxy-file-reload: the proxies removed from it are closed, the new ones are added to the rotation.")
+	getCmd.PersistentFlags().Duration("proxy-file-reload", 0, "Interval at which --proxy-file is read again, 0 to only read it again on SIGHUP.")
+	getCmd.PersistentFlags().String("proxy-rotation", "per-request", "How the proxies of --proxy-pool are picked: per-request (round-robin) or per-host (a host always goes through the same proxy while it's healthy).")
+	getCmd.PersistentFlags().Int("proxy-max-failures", 3, "Number of consecutive failed requests after which a proxy of --proxy-pool is taken out of the rotation.")
+	getCmd.PersistentFlags().Duration("proxy-cooldown", 5*time.Minute, "Time a failing proxy of --proxy-pool stays out of the rotation before being re-admitted.")
 	getCmd.PersistentFlags().Bool("random-local-ip", false, "Use random local IP for requests. (will be ignored if a proxy is set)")
+	getCmd.PersistentFlags().String("local-address", "", "Local IP address all the connections are made from, the ones to the proxies too. It must be assigned to an interface.")
+	getCmd.PersistentFlags().String("local-cidr", "", "Prefix of the local IP addresses the connections are made from, the ones to the proxies too, e.g. 192.0.2.8/29. Each connection is made from the next address of the prefix assigned to an interface, logged at debug level.")
 	getCmd.PersistentFlags().Bool("disable-ipv4", false, "Disable IPv4 for requests.")
 	getCmd.PersistentFlags().Bool("disable-ipv6", false, "Disable IPv6 for requests.")
 	getCmd.PersistentFlags().Bool("ipv6-anyip", false, "Use AnyIP kernel feature for requests. (only IPv6, need --random-local-ip)")
+	getCmd.PersistentFlags().Duration("dns-cache-ttl", 5*time.Minute, "Time the addresses resolved by the WARC client are cached before being resolved again. The connections are made to the cached addresses, no DNS record is written while they're cached. 0 to disable the cache.")
+	getCmd.PersistentFlags().Duration("dns-cache-stale", 0, "Time the addresses are still connected to after --dns-cache-ttl, while the host is resolved again in the background. 0 to resolve it before connecting.")
+	getCmd.PersistentFlags().StringSlice("dns-no-cache-hosts", []string{}, "Hosts, and their subdomains, whose addresses aren't cached, they're resolved for each connection. For the hosts behind round-robin DNS.")
+	getCmd.PersistentFlags().Int("dns-cache-size", 10_000, "Maximum number of hosts whose resolved addresses are cached, and of hosts remembered by --dns-negative-cache-ttl.")
+	getCmd.PersistentFlags().StringSlice("dns-servers", []string{}, "IP addresses of the DNS servers the WARC client resolves the hosts with, the next ones are used if the first fails. The connections are made to the addresses written in the DNS records. Default is the servers of /etc/resolv.conf.")
+	getCmd.PersistentFlags().Duration("dns-timeout", 5*time.Second, "Maximum time to resolve a host.")
+	getCmd.PersistentFlags().Duration("dns-negative-cache-ttl", 30*time.Second, "Time the hosts that failed to resolve are remembered, their URLs fail without being resolved again. 0 to disable it.")
+	getCmd.PersistentFlags().Bool("prefer-ipv4", false, "Connect to the IPv4 address of the hosts that have one, the IPv6 one otherwise. By default the IPv6 address is tried first, then the IPv4 one if the connection fails.")
+	getCmd.PersistentFlags().Bool("prefer-ipv6", false, "Connect to the IPv6 address of the hosts that have one, the IPv4 one otherwise, without falling back on it if the connection fails.")
+	getCmd.PersistentFlags().StringSlice("resolve", []string{}, "Connect to the given address for a host and port instead of the resolved ones, in the format host:port:address (e.g. example.com:443:192.0.2.1). Can be repeated. Not applied to the proxied requests. The pinned hosts aren't resolved, no DNS record is written for them.")
+
+	// Headless flags
+	getCmd.PersistentFlags().Bool("headless", false, "Render seeds in a headless browser, archiving every request it makes and extracting outlinks from the rendered page.")
+	getCmd.PersistentFlags().Int("headless-tabs", 4, "Number of browser tabs used concurrently to render pages.")
+	getCmd.PersistentFlags().Duration("headless-page-timeout", 30*time.Second, "Maximum time to wait for a page to render before falling back to a plain HTTP capture.")
+	getCmd.PersistentFlags().String("headless-browser-path", "", "Path to the Chromium/Chrome executable to use in headless mode. Default is to look it up in the PATH.")
+	getCmd.PersistentFlags().StringSlice("headless-hosts", []string{}, "Render the seeds of these hosts (and their subdomains) in a headless browser, the others are captured over plain HTTP. Can be repeated.")
+	getCmd.PersistentFlags().Bool("headless-fallback", false, "Render again in a headless browser the seeds captured over plain HTTP whose HTML looks rendered by JavaScript (see --headless-fallback-max-text and --headless-fallback-min-scripts).")
+	getCmd.PersistentFlags().Int("headless-fallback-max-text", 500, "A page looks rendered by JavaScript when it has at most this many characters of visible text...")
+	getCmd.PersistentFlags().Int("headless-fallback-min-scripts", 3, "...and at least this many <script> elements.")
+	getCmd.PersistentFlags().Bool("headless-behaviors", false, "Scroll down rendered pages (and click on --headless-click-selector elements) to trigger the loading of lazy content.")
+	getCmd.PersistentFlags().Duration("headless-behaviors-timeout", 20*time.Second, "Maximum time spent running behaviors on a single page.")
+	getCmd.PersistentFlags().Duration("headless-scroll-delay", 500*time.Millisecond, "Delay between two scrolls when running behaviors.")
+	getCmd.PersistentFlags().StringSlice("headless-click-selector", []string{}, "CSS selector of elements to click on when running behaviors, e.g. \"load more\" buttons or cookie banners. Can be repeated.")
+	getCmd.PersistentFlags().Bool("screenshot", false, "Take a screenshot of the rendered pages and write it in a WARC resource record with urn:screenshot:<url> as target URI. (needs --headless, --headless-hosts or --headless-fallback)")
+	getCmd.PersistentFlags().String("screenshot-format", "png", "Format of the screenshots: png or jpeg.")
+	getCmd.PersistentFlags().Int("screenshot-quality", 80, "Quality of the screenshots, from 0 to 100. (jpeg only)")
+	getCmd.PersistentFlags().Int("screenshot-width", 1920, "Width in pixels of the browser viewport when taking screenshots.")
+	getCmd.PersistentFlags().Int("screenshot-max-height", 20000, "Maximum height in pixels of the screenshots, taller pages are cropped. 0 means no limit.")
 
 	// Rate limiting flags
 	getCmd.PersistentFlags().Bool("disable-rate-limit", false, "Disable the Token Bucket rate limiting.")
 	getCmd.PersistentFlags().Float64("rate-limit-capacity", 150, "Bucket capacity for each host.")
 	getCmd.PersistentFlags().Float64("rate-limit-refill-rate", 50, "Ideal requests per second for each host.")
+	getCmd.PersistentFlags().StringToString("host-delay", map[string]string{}, "Delay between two requests to a host, e.g. example.com=2s, instead of the token bucket rate limiting. Applies to the host's subdomains too, the most specific host wins. Can be repeated.")
+	getCmd.PersistentFlags().Float64("global-max-qps", 0, "Maximum number of requests per second across the whole crawl, whatever their host. 0 means no limit.")
 	getCmd.PersistentFlags().Duration("rate-limit-cleanup-frequency", time.Duration(5*time.Minute), "How often to run cleanup of stale buckets that are not accessed in the duration.")
 
 	// WARC flags
 	getCmd.PersistentFlags().String("warc-prefix", "ZENO", "Prefix to use when naming the WARC files.")
+	getCmd.PersistentFlags().String("warc-compression", "gzip", "Compression of the WARC files: gzip compresses each record in its own gzip member (.warc.gz), none writes plain .warc files, trading disk space for CPU.")
 	getCmd.PersistentFlags().String("warc-operator", "", "Contact informations of the crawl operator to write in the Warc-Info record in each WARC file.")
 	getCmd.PersistentFlags().String("warc-cdx-dedupe-server", "", "Identify the server to use CDX deduplication. This also activates CDX deduplication on.")
 	getCmd.PersistentFlags().Bool("warc-on-disk", false, "Do not use RAM to store payloads when recording traffic to WARCs, everything will happen on disk (usually used to reduce memory usage).")
 	getCmd.PersistentFlags().Int("warc-pool-size", 1, "Number of concurrent WARC files to write.")
 	getCmd.PersistentFlags().Int("warc-queue-size", -1, "Number of WARC records to queue before blocking the workers. Default is the --warc-pool-size.")
 	getCmd.PersistentFlags().String("warc-temp-dir", "", "Custom directory to use for WARC temporary files.")
+	getCmd.PersistentFlags().Bool("clean-temp-on-start", false, "Remove the temporary files left in --warc-temp-dir by previous runs that crashed. Skipped if another instance is using the directory.")
+	getCmd.PersistentFlags().Duration("clean-temp-min-age", time.Hour, "Minimum age of the temporary files removed by --clean-temp-on-start.")
 	getCmd.PersistentFlags().Bool("disable-local-dedupe", false, "Disable local URL agnostic deduplication.")
 	getCmd.PersistentFlags().Bool("cert-validation", false, "Enables certificate validation on HTTPS requests.")
 	getCmd.PersistentFlags().Bool("disable-assets-capture", false, "Disable assets capture.")
+	getCmd.PersistentFlags().StringSlice("exclude-asset-extensions", []string{}, "Don't capture the assets whose URL path ends with one of these extensions (e.g. mp4,zip,iso), the query string is ignored. Pages are still captured.")
 	getCmd.PersistentFlags().Int("warc-dedupe-size", 1024, "Minimum size to deduplicate WARC records with revisit records.")
+	getCmd.PersistentFlags().Int("max-in-memory-response-size", 2097152, "Maximum size in bytes of a response body kept in memory for post-processing, bigger bodies are spooled to a temporary file in --warc-temp-dir.")
+	getCmd.PersistentFlags().Int64("max-response-body-size", 0, "Maximum size in bytes of a response body, bigger bodies are truncated: the connection is closed and the part read is written to a WARC record flagged with WARC-Truncated: length. 0 means no limit.")
+	getCmd.PersistentFlags().StringSlice("extract-content-types", []string{"text/*", "application/xhtml+xml", "application/xml", "image/svg+xml", "application/pdf", "application/vnd.apple.mpegurl", "application/x-mpegurl", "application/dash+xml"}, "Content types of the response bodies kept for assets and outlinks extraction, matched against the detected MIME type and the Content-Type header. Wildcards are supported on the subtype (e.g. text/*).")
+	getCmd.PersistentFlags().StringSlice("fetch-content-type-allow", []string{}, "Only download the bodies of the responses with one of these content types (e.g. text/html, image/*), the others are closed as soon as their headers are received and aren't written to the WARC. Also sent as the Accept header. Responses without Content-Type are always downloaded.")
+	getCmd.PersistentFlags().StringSlice("fetch-content-type-deny", []string{}, "Don't download the bodies of the responses with one of these content types (e.g. video/*), they are closed as soon as their headers are received and aren't written to the WARC. Takes precedence over --fetch-content-type-allow.")
+	getCmd.PersistentFlags().Bool("warc-record-error-responses", true, "Write the 4xx and 5xx responses to the WARC. If turned off, they are discarded like the status codes of --warc-discard-status.")
+	getCmd.PersistentFlags().Bool("extract-only-on-2xx", true, "Only extract assets and outlinks from the 2xx responses. If turned off, the error pages are extracted too.")
+	getCmd.PersistentFlags().Int("max-outlinks-per-page", 0, "Maximum number of outlinks queued from a single page, the others are dropped and the page is logged. 0 means no limit.")
+	getCmd.PersistentFlags().Int("max-assets-per-page", 0, "Maximum number of assets captured for a single page, the others are dropped and the page is logged. 0 means no limit.")
+	getCmd.PersistentFlags().String("max-links-selection", "first", "Links kept when a page is over --max-outlinks-per-page or --max-assets-per-page: first (in the order of the page) or sample (a random subset, in the order of the page).")
 	getCmd.PersistentFlags().String("warc-cdx-cookie", "", "Pass custom cookie during CDX requests. Example: 'cdx_auth_token=test_value'")
-	getCmd.PersistentFlags().Int("warc-size", 1024, "Size of the WARC files in MB.")
-	getCmd.PersistentFlags().IntSlice("warc-discard-status", []int{429}, "HTTP status codes to discard from WARC files. By default, 429 is always discarded.")
+	getCmd.PersistentFlags().String("warc-dedupe-index", "", "Path or URL of a CDX/CDXJ index (optionally gzipped) of prior captures to preload at startup. Responses matching the URL and payload digest of a capture are written as revisit records. Misses are checked against --warc-cdx-dedupe-server, if set.")
+	getCmd.PersistentFlags().Int("warc-size", 1024, "Size of the WARC files in MB, the current files are finalized and new ones are started once it's reached.")
+	getCmd.PersistentFlags().Bool("incremental", false, "Remember the ETag and Last-Modified of the captured URLs in the job's directory and send conditional requests for them in the next runs. A 304 Not Modified answer is written as a revisit record and no outlinks are extracted from it.")
+	getCmd.PersistentFlags().Bool("track-changes", false, "Remember the payload digest of the captured URLs in the job's directory and log the ones that changed since the previous run. The change (new, changed or unchanged) is also written to the crawl logs.")
+	getCmd.PersistentFlags().Duration("warc-max-age", 0, "Maximum time a WARC file is written to before being finalized and a new one started (e.g. 1h), 0 disables time based rotation. The local dedupe table is reset at each rotation.")
+	getCmd.PersistentFlags().String("crawl-log-jsonl", "", "Path of a JSON lines file to write one line per capture attempt to, failures included with the negative status code of the error like in crawl.log (url, status, content type, bytes, duration, hops, discovery path, parent, worker, digest, annotations). Rotated along with the WARC files by --warc-max-age.")
+	getCmd.PersistentFlags().Bool("crawl-log", false, "Write one line per capture attempt, failures included, to crawl.log in the job's directory, with the columns of the Heritrix crawl.log. See --crawl-log-jsonl for JSON lines.")
+	getCmd.PersistentFlags().Int("crawl-log-max-size", 1024, "Size in MB after which crawl.log is rotated, 0 disables the rotation.")
+	getCmd.PersistentFlags().Bool("failure-report", false, "Append the URLs that permanently failed, once their retries are exhausted, and why (dns, timeout, connect, connection, http_status, body, too_large for the truncated responses or excluded for the seeds excluded by the filters) to failures.jsonl in the job's directory.")
+	getCmd.PersistentFlags().IntSlice("warc-discard-status", []int{429}, "HTTP status codes to discard from WARC files, their bodies aren't processed. By default, 429 is always discarded.")
+	getCmd.PersistentFlags().Bool("warc-outlinks-metadata", false, "Write a WARC metadata record listing the outlinks and assets discovered on each page. Inflates WARC size.")
+	getCmd.PersistentFlags().String("warc-outlinks-metadata-format", "heritrix", "Format of the outlinks metadata records: heritrix (outlink: <url> <path> <rel> lines) or json.")
+	getCmd.PersistentFlags().Bool("warc-connection-metadata", false, "Write a WARC metadata record with the IP address and the TLS version and cipher suite of the connection of each capture, with the WARC-IP-Address header. Through a proxy, the proxy's address is recorded instead as the target's address isn't known.")
 	getCmd.PersistentFlags().Bool("async-warc-write", false, "Write WARC records asynchronously. EXPERIMENTAL - may cause OOMs, lost data, or other unknown/unpredicted issues. No support will be provided for this feature.")
 
 	// Logging flags
@@ -89,6 +220,20 @@ func getCMDsFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("log-file-output-dir", "", "Directory to write log files to.")
 	getCmd.PersistentFlags().String("log-file-prefix", "ZENO", "Prefix to use when naming the log files. Default is : `ZENO`, without '-'")
 	getCmd.PersistentFlags().String("log-file-level", "info", "Log level for the log file.")
+	getCmd.PersistentFlags().String("log-file-format", "text", "Format of the log file: text or json, independently of --log-format.")
+	getCmd.PersistentFlags().Int("log-max-size", 0, "Size in MB after which the log file is rotated, on top of --log-file-rotation. The rotated files are gzipped. 0 disables it.")
+	getCmd.PersistentFlags().Int("log-max-files", 0, "Number of gzipped rotated log files kept, the oldest ones are removed. With it, the files rotated by --log-file-rotation are gzipped too. 0 keeps them all.")
+	getCmd.PersistentFlags().Int("log-sample-info", 0, "Number of identical info messages (same message and host) logged per minute, the next ones are replaced by a summary line at the end of the minute. 0 disables the sampling.")
+	getCmd.PersistentFlags().Int("log-sample-warn", 0, "Number of identical warnings (same message and host) logged per minute, the next ones are replaced by a summary line at the end of the minute. 0 disables the sampling.")
+	getCmd.PersistentFlags().Int("log-sample-error", 0, "Number of identical errors (same message and host) logged per minute, the next ones are replaced by a summary line at the end of the minute. 0 disables the sampling.")
+	getCmd.PersistentFlags().StringSlice("log-es-urls", []string{}, "Elasticsearch URLs to index the logs in, the next one is tried when one fails. Logging to Elasticsearch is disabled without it.")
+	getCmd.PersistentFlags().String("log-es-user", "", "Username for Elasticsearch logging.")
+	getCmd.PersistentFlags().String("log-es-password", "", "Password for Elasticsearch logging. Can be set with the ZENO_LOG_ES_PASSWORD environment variable.")
+	getCmd.PersistentFlags().String("log-es-index-prefix", "zeno", "Prefix of the Elasticsearch indices, the logs are indexed in <prefix>-YYYY.MM.DD.")
+	getCmd.PersistentFlags().String("log-es-level", "info", "Log level for Elasticsearch.")
+	getCmd.PersistentFlags().Int("log-es-bulk-size", 500, "Number of log records sent in each Elasticsearch bulk request.")
+	getCmd.PersistentFlags().Duration("log-es-flush-interval", time.Second, "Interval at which the buffered log records are sent to Elasticsearch, even if there are less than --log-es-bulk-size.")
+	getCmd.PersistentFlags().Int("log-es-buffer-size", 10000, "Number of log records buffered while Elasticsearch is slow or unreachable. Once it's full the debug and info records are dropped first, then the new warnings and errors.")
 	getCmd.PersistentFlags().String("log-file-rotation", "1h", "Log file rotation period. Default is : `1h`. Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm', 'h'.")
 
 	// Profiling flags
@@ -97,6 +242,7 @@ func getCMDsFlags(getCmd *cobra.Command) {
 	// Prometheus and metrics flags
 	getCmd.PersistentFlags().Bool("prometheus", false, "Export metrics in Prometheus format. (implies --api)")
 	getCmd.PersistentFlags().String("prometheus-prefix", "zeno_", "String used as a prefix for the exported Prometheus metrics.")
+	getCmd.PersistentFlags().Int("prometheus-hosts", 0, "Export the HTTP responses per host for up to this many hosts, the first ones seen, the others are counted under the \"other\" host. 0 disables the per-host metrics.")
 
 	// Consul flags
 	getCmd.PersistentFlags().String("consul-address", "", "Consul address to use for service registration.")
@@ -105,6 +251,10 @@ func getCMDsFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Bool("consul-register", false, "Register Zeno in Consul via the API. (useful when Zeno is running on host and not containerized)")
 	getCmd.PersistentFlags().StringSlice("consul-register-tags", []string{}, "Tags to use when registering Zeno in Consul with `--consul-register`.")
 
+	// Webhook flags
+	getCmd.PersistentFlags().String("webhook-url", "", "URL to POST a JSON summary of the crawl to when it finishes.")
+	getCmd.PersistentFlags().String("webhook-auth", "", "Value of the Authorization header sent with the webhook request. Example: 'Bearer mytoken'")
+
 	// Alias support
 	// As cobra doesn't support aliases natively (couldn't find a way to do it), we have to do it manually
 	// This is a workaround to allow users to use `--hops` instead of `--max-hops` for example
@@ -30,44 +30,7 @@ var getHQCmd = &cobra.Command{
 
 		cfg.UseHQ = true
 
-		if cfg.PyroscopeAddress != "" {
-			runtime.SetMutexProfileFraction(5)
-			runtime.SetBlockProfileRate(5)
-
-			// Get the hostname via env or via command
-			hostname, err := os.Hostname()
-			if err != nil {
-				return fmt.Errorf("error getting hostname for Pyroscope: %w", err)
-			}
-
-			Version := utils.GetVersion()
-
-			_, err = pyroscope.Start(pyroscope.Config{
-				ApplicationName: fmt.Sprintf("zeno"),
-				ServerAddress:   cfg.PyroscopeAddress,
-				Logger:          nil,
-				Tags:            map[string]string{"hostname": hostname, "job": cfg.Job, "version": Version.Version, "goVersion": Version.GoVersion, "uuid": uuid.New().String()[:5]},
-				UploadRate:      15 * time.Second,
-				ProfileTypes: []pyroscope.ProfileType{
-					pyroscope.ProfileCPU,
-					pyroscope.ProfileAllocObjects,
-					pyroscope.ProfileAllocSpace,
-					pyroscope.ProfileInuseObjects,
-					pyroscope.ProfileInuseSpace,
-					pyroscope.ProfileGoroutines,
-					pyroscope.ProfileMutexCount,
-					pyroscope.ProfileMutexDuration,
-					pyroscope.ProfileBlockCount,
-					pyroscope.ProfileBlockDuration,
-				},
-			})
-
-			if err != nil {
-				panic(fmt.Errorf("error starting pyroscope: %w", err))
-			}
-		}
-
-		return nil
+		return startPyroscope()
 	},
 	RunE: func(_ *cobra.Command, _ []string) error {
 		controler.Start()
@@ -92,6 +55,7 @@ func getHQCmdFlags(getHQCmd *cobra.Command) {
 	getHQCmd.PersistentFlags().String("hq-project", "", "Crawl HQ project.")
 	getHQCmd.PersistentFlags().Int("hq-batch-size", 500, "Crawl HQ feeding batch size.")
 	getHQCmd.PersistentFlags().Int("hq-batch-concurrency", 1, "Number of concurrent requests to do to get the --hq-batch-size, if batch size is 300 and batch-concurrency is 10, 30 requests will be done concurrently.")
+	getHQCmd.PersistentFlags().Int("hq-spool-max-size", 512, "Maximum size in MB of the URLs spooled in the job directory while crawl HQ is unreachable, they are sent in order once it is back. 0 disables spooling.")
 	getHQCmd.PersistentFlags().Bool("hq-rate-limiting-send-back", false, "If turned on, the crawler will send back URLs that hit a rate limit to crawl HQ.")
 
 	getHQCmd.MarkPersistentFlagRequired("hq-address")
@@ -99,3 +63,45 @@ func getHQCmdFlags(getHQCmd *cobra.Command) {
 	getHQCmd.MarkPersistentFlagRequired("hq-secret")
 	getHQCmd.MarkPersistentFlagRequired("hq-project")
 }
+
+// startPyroscope starts the profiling of the crawl if --pyroscope-address is set
+func startPyroscope() error {
+	if cfg.PyroscopeAddress != "" {
+		runtime.SetMutexProfileFraction(5)
+		runtime.SetBlockProfileRate(5)
+
+		// Get the hostname via env or via command
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("error getting hostname for Pyroscope: %w", err)
+		}
+
+		Version := utils.GetVersion()
+
+		_, err = pyroscope.Start(pyroscope.Config{
+			ApplicationName: fmt.Sprintf("zeno"),
+			ServerAddress:   cfg.PyroscopeAddress,
+			Logger:          nil,
+			Tags:            map[string]string{"hostname": hostname, "job": cfg.Job, "version": Version.Version, "goVersion": Version.GoVersion, "uuid": uuid.New().String()[:5]},
+			UploadRate:      15 * time.Second,
+			ProfileTypes: []pyroscope.ProfileType{
+				pyroscope.ProfileCPU,
+				pyroscope.ProfileAllocObjects,
+				pyroscope.ProfileAllocSpace,
+				pyroscope.ProfileInuseObjects,
+				pyroscope.ProfileInuseSpace,
+				pyroscope.ProfileGoroutines,
+				pyroscope.ProfileMutexCount,
+				pyroscope.ProfileMutexDuration,
+				pyroscope.ProfileBlockCount,
+				pyroscope.ProfileBlockDuration,
+			},
+		})
+
+		if err != nil {
+			panic(fmt.Errorf("error starting pyroscope: %w", err))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/controler"
+	"github.com/internetarchive/Zeno/internal/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var getKafkaCmd = &cobra.Command{
+	Use:   "kafka",
+	Short: "Start crawling with the seeds consumed from Kafka.",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if cfg == nil {
+			return fmt.Errorf("viper config is nil")
+		}
+
+		err := config.GenerateCrawlConfig()
+		if err != nil {
+			return err
+		}
+
+		cfg.UseKafka = true
+
+		return startPyroscope()
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		controler.Start()
+		if config.Get().TUI {
+			tui := ui.New()
+			err := tui.Start()
+			if err != nil {
+				return fmt.Errorf("error starting TUI: %w", err)
+			}
+		} else {
+			controler.WatchSignals()
+		}
+		return nil
+	},
+}
+
+func getKafkaCmdFlags(getKafkaCmd *cobra.Command) {
+	// Kafka flags
+	getKafkaCmd.PersistentFlags().StringSlice("kafka-brokers", []string{}, "Kafka brokers to connect to, comma separated.")
+	getKafkaCmd.PersistentFlags().String("kafka-seeds-topic", "", "Kafka topic to consume the seeds from, either JSON messages ({\"url\": ..., \"via\": ..., \"hops\": ...}) or plain URLs.")
+	getKafkaCmd.PersistentFlags().String("kafka-consumer-group", "", "Kafka consumer group, the seeds topic is shared among the crawlers of the same group.")
+	getKafkaCmd.PersistentFlags().String("kafka-outlinks-topic", "", "Kafka topic to produce the discovered outlinks to, in the same JSON format as the seeds.")
+	getKafkaCmd.PersistentFlags().String("kafka-results-topic", "", "Kafka topic to produce the capture results to (URL, status, digest). If empty, no results are produced.")
+	getKafkaCmd.PersistentFlags().Int("kafka-batch-size", 500, "Maximum number of seeds consumed at once.")
+	getKafkaCmd.PersistentFlags().Int("kafka-produce-batch-size", 100, "Number of outlinks produced at once.")
+	getKafkaCmd.PersistentFlags().Int("kafka-max-in-flight", 10000, "Maximum number of seeds being crawled, no seed is consumed above it. 0 means no limit.")
+	getKafkaCmd.PersistentFlags().Bool("kafka-tls", false, "Connect to the Kafka brokers with TLS.")
+	getKafkaCmd.PersistentFlags().String("kafka-tls-ca", "", "PEM file of the CA to verify the Kafka brokers with, instead of the system ones.")
+	getKafkaCmd.PersistentFlags().String("kafka-sasl-mechanism", "", "SASL mechanism to authenticate with: plain, scram-sha-256 or scram-sha-512.")
+	getKafkaCmd.PersistentFlags().String("kafka-sasl-username", "", "SASL username.")
+	getKafkaCmd.PersistentFlags().String("kafka-sasl-password", "", "SASL password.")
+
+	getKafkaCmd.MarkPersistentFlagRequired("kafka-brokers")
+	getKafkaCmd.MarkPersistentFlagRequired("kafka-seeds-topic")
+	getKafkaCmd.MarkPersistentFlagRequired("kafka-consumer-group")
+	getKafkaCmd.MarkPersistentFlagRequired("kafka-outlinks-topic")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/controler"
+	"github.com/spf13/cobra"
+)
+
+var getListCmd = &cobra.Command{
+	Use:   "list [FILE|URL...]",
+	Short: "Archive the seeds of seed lists, local files or HTTP(S) URLs",
+	Long: `Archive the seeds of seed lists, local files or HTTP(S) URLs, in one of these formats:
+  - txt: one URL per line, the empty lines and the ones starting with # are ignored
+  - jsonl: one JSON object per line, {"url": "...", "hops": 1, "max_hops": 3, "headers": {"Name": "value"}}
+  - csv: the URL in the first column, or a header row naming the url, hops, max_hops and header:<Name> columns
+
+The headers of a seed are sent with its requests and the ones of its assets and redirections.
+The max hops of a seed replaces --max-hops for it and the outlinks found from it, the hops of its
+outlinks start from the hops of the seed. The assets are captured whatever the hops.
+The invalid seeds are skipped with a warning.`,
+	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(_ *cobra.Command, args []string) error {
+		if cfg == nil {
+			return fmt.Errorf("viper config is nil")
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("no seed list provided")
+		}
+
+		return nil
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		config.Get().InputSeedLists = append(config.Get().InputSeedLists, args...)
+
+		err := config.GenerateCrawlConfig()
+		if err != nil {
+			return err
+		}
+
+		controler.Start()
+		controler.WatchSignals()
+		return nil
+	},
+}
+
+func getListCmdFlags(getListCmd *cobra.Command) {
+	getListCmd.PersistentFlags().String("seed-list-format", "auto", "Format of the seed lists: txt, jsonl, csv, or auto to pick it from the extension or the content type of each list.")
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
@@ -26,15 +27,36 @@ func Start() error {
 	var done bool
 
 	once.Do(func() {
+		startTime = time.Now()
+
+		hash, err := config.Hash()
+		if err != nil {
+			log.Printf("Error computing the config hash: %v", err)
+		}
+		configHash = hash
+
 		mux := http.NewServeMux()
 
 		if config.Get().Prometheus {
-			mux.Handle("/metrics", stats.PrometheusHandler())
+			mux.Handle(metricsPath, stats.PrometheusHandler())
+		}
+
+		registerFrontierHandlers(mux)
+		registerHostsHandlers(mux)
+		registerFailuresHandlers(mux)
+		registerStatusHandlers(mux)
+		registerWorkersHandlers(mux)
+		registerStopHandlers(mux)
+		registerSeedsHandlers(mux)
+		registerHealthHandlers(mux)
+
+		if config.Get().APIToken == "" && !isLoopback(config.Get().APIHost) {
+			log.Printf("Warning: the API is reachable from the network without token, see --api-token")
 		}
 
 		server = &http.Server{
-			Addr:    ":" + strconv.Itoa(config.Get().APIPort),
-			Handler: mux,
+			Addr:    net.JoinHostPort(config.Get().APIHost, strconv.Itoa(config.Get().APIPort)),
+			Handler: requireToken(mux, config.Get().APIToken, config.Get().APIMetricsToken),
 		}
 
 		go func() {
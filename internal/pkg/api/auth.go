@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const metricsPath = "/metrics"
+
+// publicPaths are served without token, so that the orchestrators can probe the crawler
+var publicPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// requireToken returns a handler requiring the token as "Authorization: Bearer <token>" on every endpoint
+// but the public ones, and the metrics token on the metrics endpoint. An empty token doesn't require any.
+func requireToken(next http.Handler, token, metricsToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := token
+		if r.URL.Path == metricsPath {
+			expected = metricsToken
+		} else if publicPaths[r.URL.Path] {
+			expected = ""
+		}
+
+		if expected != "" && !validToken(r.Header.Get("Authorization"), expected) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken compares the bearer token of the Authorization header in constant time
+func validToken(authorization, expected string) bool {
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(expected)) == 1
+}
+
+// isLoopback tells if the API only listens on the loopback interface, an empty host listens on all of them
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	IP := net.ParseIP(host)
+
+	return IP != nil && IP.IsLoopback()
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, path := range []string{"/status", "/healthz", metricsPath} {
+		mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		metricsToken  string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{"no token required", "", "", "/status", "", http.StatusOK},
+		{"missing token", "secret", "", "/status", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "", "/status", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "secret", "", "/status", "Basic secret", http.StatusUnauthorized},
+		{"prefix of the token", "secret", "", "/status", "Bearer secre", http.StatusUnauthorized},
+		{"valid token", "secret", "", "/status", "Bearer secret", http.StatusOK},
+		{"case insensitive scheme", "secret", "", "/status", "bearer secret", http.StatusOK},
+		{"public path", "secret", "", "/healthz", "", http.StatusOK},
+		{"metrics without metrics token", "secret", "", metricsPath, "", http.StatusOK},
+		{"metrics missing metrics token", "secret", "metrics", metricsPath, "Bearer secret", http.StatusUnauthorized},
+		{"metrics valid metrics token", "secret", "metrics", metricsPath, "Bearer metrics", http.StatusOK},
+		{"metrics token elsewhere", "secret", "metrics", "/status", "Bearer metrics", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			requireToken(mux, tt.token, tt.metricsToken).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			if rec.Code == http.StatusUnauthorized {
+				if rec.Body.Len() != 0 {
+					t.Errorf("expected no body on 401, got %q", rec.Body.String())
+				}
+
+				if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+					t.Errorf("expected a WWW-Authenticate header, got %q", rec.Header().Get("WWW-Authenticate"))
+				}
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for host, want := range map[string]bool{
+		"":          false,
+		"0.0.0.0":   false,
+		"10.0.0.1":  false,
+		"127.0.0.1": true,
+		"::1":       true,
+		"localhost": true,
+	} {
+		if got := isLoopback(host); got != want {
+			t.Errorf("isLoopback(%q) = %t, want %t", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+)
+
+// defaultFailuresLimit is the number of failures returned without limit
+const defaultFailuresLimit = 100
+
+// registerFailuresHandlers registers the debugging endpoint of the permanent failures:
+//   - GET /archiver/failures?limit=N: the most recent URLs that permanently failed and why, the newest first
+func registerFailuresHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /archiver/failures", failuresHandler)
+}
+
+func failuresHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultFailuresLimit
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", value))
+			return
+		}
+	}
+
+	failures := archiver.GetRecentFailures(limit)
+	if failures == nil {
+		failures = []archiver.Failure{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"failures": failures,
+		"count":    len(failures),
+	})
+}
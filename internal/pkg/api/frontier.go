@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+const (
+	// largestHostQueues is the number of host queues detailed by GET /frontier
+	largestHostQueues = 5
+	// defaultFrontierLimit is the number of entries returned by the frontier endpoints when no limit is given
+	defaultFrontierLimit = 100
+	// maxFrontierLimit bounds the number of entries returned by the frontier endpoints
+	maxFrontierLimit = 1000
+	// maxHostSampleLimit bounds the number of URLs sampled by GET /frontier/host/{host}
+	maxHostSampleLimit = 100
+)
+
+// registerFrontierHandlers registers the read-only endpoints exposing the content of the local queue:
+//   - GET /frontier: number of queued URLs per status, number of items in flight in the pipeline,
+//     number of host queues with the largest ones, age of the oldest pending URL and dequeue rate
+//   - GET /frontier/pending?limit=&offset=: page of the pending URLs
+//   - GET /frontier/hosts?limit=: hosts with the most pending URLs
+//   - GET /frontier/host/{host}?limit=: number of pending URLs of a host and a sample of them
+func registerFrontierHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /frontier", frontierHandler)
+	mux.HandleFunc("GET /frontier/pending", frontierPendingHandler)
+	mux.HandleFunc("GET /frontier/hosts", frontierHostsHandler)
+	mux.HandleFunc("GET /frontier/host/{host}", frontierHostHandler)
+}
+
+func frontierHandler(w http.ResponseWriter, r *http.Request) {
+	if !frontierAvailable(w) {
+		return
+	}
+
+	counts, err := lq.FrontierCounts(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	active, err := lq.FrontierHostQueues()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	largest, err := lq.FrontierHosts(largestHostQueues)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	queued, err := lq.FrontierQueued()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	oldest, err := lq.FrontierOldestQueued(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var oldestAge int64
+	if !oldest.IsZero() {
+		oldestAge = int64(time.Since(oldest).Seconds())
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"queue":     counts,
+		"queued":    queued,
+		"in_flight": len(reactor.GetStateTable()),
+		"host_queues": map[string]any{
+			"active":  active,
+			"largest": largest,
+		},
+		"oldest_queued_age_seconds": oldestAge,
+		"dequeued_per_second":       stats.FrontierDequeuedGet(),
+	})
+}
+
+func frontierPendingHandler(w http.ResponseWriter, r *http.Request) {
+	if !frontierAvailable(w) {
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := lq.FrontierPending(r.Context(), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"limit":  limit,
+		"offset": offset,
+		"items":  items,
+	})
+}
+
+func frontierHostsHandler(w http.ResponseWriter, r *http.Request) {
+	if !frontierAvailable(w) {
+		return
+	}
+
+	limit, _, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hosts, err := lq.FrontierHosts(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"limit": limit,
+		"hosts": hosts,
+	})
+}
+
+func frontierHostHandler(w http.ResponseWriter, r *http.Request) {
+	if !frontierAvailable(w) {
+		return
+	}
+
+	limit, _, err := parsePagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit = min(limit, maxHostSampleLimit)
+
+	host := strings.ToLower(r.PathValue("host"))
+
+	pending, items, err := lq.FrontierHostSample(host, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"host":    host,
+		"pending": pending,
+		"limit":   limit,
+		"items":   items,
+	})
+}
+
+// frontierAvailable writes an error and returns false if the frontier can't be read from this instance
+func frontierAvailable(w http.ResponseWriter) bool {
+	if config.Get().UseHQ {
+		writeError(w, http.StatusNotImplemented, "the frontier is managed by HQ")
+		return false
+	}
+
+	if config.Get().UseKafka {
+		writeError(w, http.StatusNotImplemented, "the frontier is managed by Kafka")
+		return false
+	}
+
+	if config.Get().UseRedis {
+		writeError(w, http.StatusNotImplemented, "the frontier is managed by Redis")
+		return false
+	}
+
+	return true
+}
+
+// parsePagination reads the limit and offset query parameters, the limit is capped to maxFrontierLimit
+func parsePagination(r *http.Request) (limit, offset int, err error) {
+	limit = defaultFrontierLimit
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("invalid limit %q", value)
+		}
+
+		limit = min(limit, maxFrontierLimit)
+	}
+
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", value)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
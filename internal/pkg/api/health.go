@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/controler/watchers"
+	zlog "github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// healthServer is the lightweight listener of --health-port, serving only the probes
+var healthServer *http.Server
+
+// healthCheck is a check of a probe, it returns why it fails
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// registerHealthHandlers registers the probes meant for the orchestrators:
+//   - GET /healthz: the process is live, it fails if the WARC writer is stalled for --health-warc-stall-timeout,
+//     if every worker is stuck or if the logging system is down; the instance should be restarted then
+//   - GET /readyz: the crawl is ready, it fails until the frontier is started, while HQ is unreachable when
+//     used, while the disk space is low and once the crawl is stopping
+//
+// Both answer 200 or 503 with the outcome of each check.
+func registerHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", probeHandler(livenessChecks))
+	mux.HandleFunc("GET /readyz", probeHandler(readinessChecks))
+}
+
+func livenessChecks() []healthCheck {
+	return []healthCheck{
+		{"warc_writer", func() error {
+			if stalled := watchers.WARCWriterStalledFor(); stalled >= config.Get().HealthWARCStallTimeout {
+				return fmt.Errorf("no record written for %s while records are waiting", stalled.Truncate(time.Second))
+			}
+			return nil
+		}},
+		{"workers", func() error {
+			if stuck := stats.StuckWorkersGet(); config.Get().WorkersCount > 0 && stuck >= int64(config.Get().WorkersCount) {
+				return fmt.Errorf("all %d workers are stuck", stuck)
+			}
+			return nil
+		}},
+		{"log", zlog.Healthy},
+	}
+}
+
+func readinessChecks() []healthCheck {
+	checks := []healthCheck{
+		{"frontier", func() error {
+			if !source.Started() {
+				return errors.New("not started")
+			}
+			return nil
+		}},
+		{"disk", func() error {
+			if watchers.DiskLow() {
+				return errors.New("free disk space below the threshold")
+			}
+			return nil
+		}},
+		{"stopping", func() error {
+			if stats.StoppingGet() {
+				return errors.New("crawl stopping")
+			}
+			return nil
+		}},
+	}
+
+	if config.Get().UseHQ {
+		checks = append(checks, healthCheck{"hq", func() error {
+			if unreachable := stats.HQUnreachableSecondsGet(); unreachable > 0 {
+				return fmt.Errorf("unreachable for %ds", unreachable)
+			}
+			return nil
+		}})
+	}
+
+	return checks
+}
+
+// probeHandler runs the checks on every request, the probe fails if any of them does
+func probeHandler(checks func() []healthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := make(map[string]string)
+		failing := false
+
+		for _, c := range checks() {
+			if err := c.check(); err != nil {
+				results[c.name] = err.Error()
+				failing = true
+			} else {
+				results[c.name] = "ok"
+			}
+		}
+
+		if failing {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "failing", "checks": results})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "checks": results})
+	}
+}
+
+// StartHealth begins serving the probes on --health-port in a separate goroutine, without token.
+// It runs independently of the API server, which serves them too.
+func StartHealth() {
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux)
+
+	healthServer = &http.Server{
+		Addr:    net.JoinHostPort(config.Get().APIHost, strconv.Itoa(config.Get().HealthPort)),
+		Handler: mux,
+	}
+
+	go func() {
+		log.Printf("Starting health server on %s", healthServer.Addr)
+		if err := healthServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("ListenAndServe error: %v", err)
+		}
+	}()
+}
+
+// StopHealth gracefully shuts down the health server within the provided timeout.
+func StopHealth(timeout time.Duration) error {
+	if healthServer == nil {
+		return nil
+	}
+
+	log.Printf("Stopping health server on %s", healthServer.Addr)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return healthServer.Shutdown(ctx)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []healthCheck
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		{
+			name: "all checks pass",
+			checks: []healthCheck{
+				{"a", func() error { return nil }},
+				{"b", func() error { return nil }},
+			},
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"a": "ok", "b": "ok"},
+		},
+		{
+			name: "one check fails",
+			checks: []healthCheck{
+				{"a", func() error { return nil }},
+				{"b", func() error { return errors.New("broken") }},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"a": "ok", "b": "broken"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			probeHandler(func() []healthCheck { return tt.checks })(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var body struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+
+			for name, want := range tt.wantChecks {
+				if body.Checks[name] != want {
+					t.Errorf("check %q = %q, want %q", name, body.Checks[name], want)
+				}
+			}
+
+			wantStatus := "ok"
+			if tt.wantStatus != http.StatusOK {
+				wantStatus = "failing"
+			}
+			if body.Status != wantStatus {
+				t.Errorf("status field = %q, want %q", body.Status, wantStatus)
+			}
+		})
+	}
+}
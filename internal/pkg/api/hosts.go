@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+)
+
+// registerHostsHandlers registers the debugging endpoints of the per-host concurrency limit and circuit breaker:
+//   - GET /archiver/hosts: pages being requested and waiting for a slot, per host
+//   - GET /archiver/breakers: state of the circuit of the hosts captured recently, with their deferred seeds
+func registerHostsHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /archiver/hosts", hostsHandler)
+	mux.HandleFunc("GET /archiver/breakers", breakersHandler)
+}
+
+func hostsHandler(w http.ResponseWriter, r *http.Request) {
+	activity := archiver.GetHostsActivity()
+	if activity == nil {
+		writeError(w, http.StatusNotFound, "the number of concurrent requests per host isn't limited, see --max-concurrent-requests-per-domain")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, activity)
+}
+
+func breakersHandler(w http.ResponseWriter, r *http.Request) {
+	breakers := archiver.GetHostBreakers()
+	if breakers == nil {
+		writeError(w, http.StatusNotFound, "the circuit breaker is disabled, see --host-breaker-threshold")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, breakers)
+}
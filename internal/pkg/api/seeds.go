@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/preprocessor"
+	"github.com/internetarchive/Zeno/internal/pkg/preprocessor/seencheck"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+const (
+	// maxInjectedSeeds bounds the number of seeds injected by a POST /seeds request
+	maxInjectedSeeds = 1000
+	// maxSeedsBodySize bounds the size of the body of a POST /seeds request
+	maxSeedsBodySize = 1 << 20
+)
+
+// injectedSeed is a seed of a POST /seeds request, either a URL or {"url": "...", "hops": 1, "max_hops": 3}
+type injectedSeed struct {
+	URL     string `json:"url"`
+	Hops    int    `json:"hops"`
+	MaxHops *int   `json:"max_hops"`
+}
+
+func (s *injectedSeed) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, &s.URL)
+	}
+
+	type seed injectedSeed
+	return json.Unmarshal(data, (*seed)(s))
+}
+
+// rejectedSeed is a seed that wasn't queued and why: invalid, filtered, seen or queued
+type rejectedSeed struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// registerSeedsHandlers registers the endpoint to add seeds to the running crawl:
+//   - POST /seeds: queues the seeds of the JSON list of the body in the local queue. They are normalized
+//     and filtered like the other seeds, the ones already crawled or queued are rejected.
+func registerSeedsHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /seeds", seedsHandler)
+}
+
+func seedsHandler(w http.ResponseWriter, r *http.Request) {
+	if !frontierAvailable(w) {
+		return
+	}
+
+	var seeds []injectedSeed
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSeedsBodySize)).Decode(&seeds); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid seeds: "+err.Error())
+		return
+	}
+
+	if len(seeds) > maxInjectedSeeds {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("too many seeds, at most %d per request", maxInjectedSeeds))
+		return
+	}
+
+	var (
+		items    []*models.Item
+		rejected []rejectedSeed
+		known    = make(map[string]bool, len(seeds))
+	)
+
+	for _, seed := range seeds {
+		item, reason := injectedItem(seed)
+		if item == nil {
+			rejected = append(rejected, rejectedSeed{URL: seed.URL, Reason: reason})
+			continue
+		}
+
+		// Repeated in the request
+		if known[item.GetURL().Raw] {
+			rejected = append(rejected, rejectedSeed{URL: seed.URL, Reason: "queued"})
+			continue
+		}
+
+		known[item.GetURL().Raw] = true
+		items = append(items, item)
+	}
+
+	added, err := lq.FrontierAdd(r.Context(), items)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, lq.ErrLQNotStarted) {
+			status = http.StatusServiceUnavailable
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	for _, URL := range added {
+		delete(known, URL)
+	}
+
+	// The seeds left were already in the queue
+	for _, item := range items {
+		if known[item.GetURL().Raw] {
+			rejected = append(rejected, rejectedSeed{URL: item.GetURL().Raw, Reason: "queued"})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"accepted": len(added),
+		"rejected": len(rejected),
+		"seeds":    rejected,
+	})
+}
+
+// injectedItem returns the seed to queue, or nil and why it's rejected
+func injectedItem(seed injectedSeed) (item *models.Item, reason string) {
+	if seed.URL == "" || seed.Hops < 0 || (seed.MaxHops != nil && *seed.MaxHops < 0) {
+		return nil, "invalid"
+	}
+
+	URL := &models.URL{Raw: seed.URL, Hops: seed.Hops}
+	if err := URL.Parse(); err != nil {
+		return nil, "invalid"
+	}
+
+	if err := preprocessor.NormalizeURL(URL, nil); err != nil {
+		return nil, "invalid"
+	}
+
+	if excluded, _ := preprocessor.Excluded(URL); excluded {
+		return nil, "filtered"
+	}
+
+	if seencheck.IsSeen(URL.String()) {
+		return nil, "seen"
+	}
+
+	item = models.NewItem(uuid.New().String(), URL, "")
+	if seed.MaxHops != nil {
+		item.SetMaxHops(*seed.MaxHops)
+	}
+
+	return item, ""
+}
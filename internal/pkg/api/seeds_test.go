@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+)
+
+func TestSeedsHandler(t *testing.T) {
+	config.InitConfig()
+	config.Get().JobPath = t.TempDir()
+
+	defer func(excludeHosts []string) { config.Get().ExcludeHosts = excludeHosts }(config.Get().ExcludeHosts)
+	config.Get().ExcludeHosts = []string{"excluded.example.com"}
+
+	if err := lq.Start(); err != nil {
+		t.Fatalf("lq.Start() error = %v", err)
+	}
+	defer lq.Stop()
+
+	post := func(body string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		seedsHandler(rec, httptest.NewRequest(http.MethodPost, "/seeds", strings.NewReader(body)))
+
+		var response map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		return rec, response
+	}
+
+	rec, response := post(`[
+		"https://example.com/a",
+		{"url": "https://example.com/b", "hops": 1, "max_hops": 2},
+		"https://example.com/a#fragment",
+		"not a url",
+		{"url": "https://example.com/c", "hops": -1},
+		"https://excluded.example.com/"
+	]`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /seeds status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	if response["accepted"] != float64(2) || response["rejected"] != float64(4) {
+		t.Errorf("POST /seeds = %s, want 2 accepted and 4 rejected", rec.Body.String())
+	}
+
+	reasons := map[string]string{}
+	for _, seed := range response["seeds"].([]any) {
+		seed := seed.(map[string]any)
+		reasons[seed["url"].(string)] = seed["reason"].(string)
+	}
+
+	want := map[string]string{
+		"https://example.com/a#fragment": "queued",
+		"not a url":                      "invalid",
+		"https://example.com/c":          "invalid",
+		"https://excluded.example.com/":  "filtered",
+	}
+	for URL, reason := range want {
+		if reasons[URL] != reason {
+			t.Errorf("%s rejected as %q, want %q", URL, reasons[URL], reason)
+		}
+	}
+
+	items, err := lq.FrontierPending(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("FrontierPending() error = %v", err)
+	}
+
+	if len(items) != 2 || items[1].URL != "https://example.com/b" || items[1].Hops != 1 || items[1].MaxHops == nil || *items[1].MaxHops != 2 {
+		t.Errorf("queued seeds = %+v", items)
+	}
+
+	// The seeds already queued are rejected
+	_, response = post(`["https://example.com/b"]`)
+	if response["accepted"] != float64(0) || response["rejected"] != float64(1) {
+		t.Errorf("POST /seeds of a queued seed = %v", response)
+	}
+
+	if rec, _ := post(`{"url": "https://example.com/"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /seeds of an object status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+var (
+	// startTime is the time the API server, and so the crawl, started
+	startTime time.Time
+	// configHash identifies the effective configuration of the crawl, computed once at start
+	configHash string
+)
+
+// registerStatusHandlers registers the status endpoint meant for the monitoring of the crawl:
+//   - GET /status: job, version, uptime, crawled URLs, crawl rates, workers, frontier depth,
+//     WARC data written, disk space, paused, stopping and finished flags, and HQ connectivity when HQ is used
+//
+// It only reads counters maintained by the crawl, it doesn't wait on any lock or I/O.
+func registerStatusHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /status", statusHandler)
+}
+
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	diskTotal, diskFree := stats.DiskSpaceGet()
+
+	status := map[string]any{
+		"job":            config.Get().Job,
+		"version":        utils.GetVersion().Version,
+		"config_hash":    configHash,
+		"start_time":     startTime.UTC().Format(time.RFC3339),
+		"uptime_seconds": int64(time.Since(startTime).Seconds()),
+		"crawled": map[string]any{
+			"total":  stats.URLsCrawledTotal(),
+			"seeds":  stats.SeedsCrawledTotal(),
+			"assets": stats.AssetsCrawledTotal(),
+		},
+		"urls_per_second": map[string]any{
+			"1s": stats.URLsCrawledGet(),
+			"1m": stats.URLsCrawledPerSecondLastMinute(),
+		},
+		"workers": map[string]any{
+			"active": stats.ActiveWorkersGet(),
+			"total":  config.Get().WorkersCount,
+		},
+		"frontier_pending":   stats.FrontierPendingGet(),
+		"warc_bytes_written": stats.WARCBytesWrittenGet(),
+		"disk": map[string]any{
+			"path":        config.Get().JobPath,
+			"total_bytes": diskTotal,
+			"free_bytes":  diskFree,
+		},
+		"paused":   stats.PausedGet(),
+		"stopping": stats.StoppingGet(),
+		"finished": isFinished(),
+	}
+
+	// Why the crawl is paused, e.g. the disk space or the crawl windows
+	if reason := pause.GetMessage(); reason != "" {
+		status["paused_reason"] = reason
+	}
+
+	if config.Get().MaxCrawledItems > 0 {
+		status["crawl_limit"] = map[string]any{
+			"max":   config.Get().MaxCrawledItems,
+			"scope": config.Get().MaxCrawledItemsScope,
+			"count": stats.CrawlLimitItemsGet(),
+		}
+	}
+
+	if config.Get().MaxTotalWARCSize > 0 {
+		status["warc_size_limit"] = map[string]any{
+			"max_bytes":     int64(config.Get().MaxTotalWARCSize) * 1024 * 1024,
+			"bytes_on_disk": stats.WARCBytesOnDiskGet(),
+		}
+	}
+
+	if config.Get().UseHQ {
+		unreachable := stats.HQUnreachableSecondsGet()
+		status["hq"] = map[string]any{
+			"reachable":           unreachable == 0,
+			"unreachable_seconds": unreachable,
+			"spooled_urls":        stats.HQSpooledItemsGet(),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// isFinished returns true once the crawl reached its end, the --max-crawled-items or --max-total-warc-size limit
+func isFinished() bool {
+	select {
+	case <-archiver.LimitReached():
+		return true
+	default:
+		return false
+	}
+}
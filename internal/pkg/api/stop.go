@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+)
+
+const (
+	// StopGraceful stops dequeuing, lets the in-flight items finish and flushes the WARC writer and the source
+	StopGraceful = "graceful"
+	// StopNow cancels the in-flight requests, what was captured is still flushed
+	StopNow = "now"
+)
+
+// stopRequests receives the modes of the stops requested, a few are buffered so that
+// a stop now requested while stopping gracefully isn't lost
+var stopRequests = make(chan string, 4)
+
+// registerStopHandlers registers the endpoint to stop the crawl:
+//   - POST /stop?mode=graceful|now: requests the stop of the crawl and returns right away,
+//     GET /status reports the crawl as stopping then. The mode defaults to graceful.
+func registerStopHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /stop", stopHandler)
+}
+
+func stopHandler(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = StopGraceful
+	}
+
+	if mode != StopGraceful && mode != StopNow {
+		writeError(w, http.StatusBadRequest, "invalid mode "+mode+", must be graceful or now")
+		return
+	}
+
+	select {
+	case stopRequests <- mode:
+	default:
+		// Enough stops are already requested
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status": "stopping",
+		"mode":   mode,
+	})
+}
+
+// StopRequests returns the channel receiving the modes of the stops requested through the API
+func StopRequests() <-chan string {
+	return stopRequests
+}
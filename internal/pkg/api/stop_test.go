@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+func TestStopHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantMode   string
+	}{
+		{"default mode", "/stop", http.StatusAccepted, StopGraceful},
+		{"graceful", "/stop?mode=graceful", http.StatusAccepted, StopGraceful},
+		{"now", "/stop?mode=now", http.StatusAccepted, StopNow},
+		{"invalid mode", "/stop?mode=later", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			stopHandler(rec, httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if tt.wantMode == "" {
+				select {
+				case mode := <-StopRequests():
+					t.Errorf("expected no stop requested, got %q", mode)
+				default:
+				}
+				return
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+
+			if body["status"] != "stopping" || body["mode"] != tt.wantMode {
+				t.Errorf("body = %v, want stopping in mode %q", body, tt.wantMode)
+			}
+
+			select {
+			case mode := <-StopRequests():
+				if mode != tt.wantMode {
+					t.Errorf("stop requested in mode %q, want %q", mode, tt.wantMode)
+				}
+			default:
+				t.Error("expected the stop to be requested")
+			}
+		})
+	}
+}
+
+func TestStopHandlerBuffered(t *testing.T) {
+	// The stops requested beyond the buffer are dropped instead of blocking the handler
+	for range cap(stopRequests) + 2 {
+		rec := httptest.NewRecorder()
+		stopHandler(rec, httptest.NewRequest(http.MethodPost, "/stop?mode=now", nil))
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+		}
+	}
+
+	for len(stopRequests) > 0 {
+		<-stopRequests
+	}
+}
+
+func TestStatusHandlerStopping(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	stopping := func() any {
+		rec := httptest.NewRecorder()
+		statusHandler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+
+		return body["stopping"]
+	}
+
+	if got := stopping(); got != false {
+		t.Errorf("stopping = %v before the stop, want false", got)
+	}
+
+	stats.StoppingSet()
+
+	if got := stopping(); got != true {
+		t.Errorf("stopping = %v once stopping, want true", got)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// registerWorkersHandlers registers the endpoints exposing what the archiver workers are doing:
+//   - GET /workers: state of every worker, the ones in the same state for longer than
+//     --worker-stuck-threshold are flagged as stuck
+//   - GET /workers/{id}: state of a worker
+func registerWorkersHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /workers", workersHandler)
+	mux.HandleFunc("GET /workers/{id}", workerHandler)
+}
+
+func workersHandler(w http.ResponseWriter, r *http.Request) {
+	workers := archiver.GetWorkersActivity()
+
+	var stuck int
+	for i := range workers {
+		if workers[i].Stuck {
+			stuck++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"workers":                 workers,
+		"stuck":                   stuck,
+		"stuck_threshold_seconds": config.Get().WorkerStuckThreshold.Seconds(),
+	})
+}
+
+func workerHandler(w http.ResponseWriter, r *http.Request) {
+	worker, found := archiver.GetWorkerActivity(r.PathValue("id"))
+	if !found {
+		writeError(w, http.StatusNotFound, "no such worker")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, worker)
+}
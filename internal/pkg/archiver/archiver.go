@@ -2,17 +2,24 @@ package archiver
 
 import (
 	"context"
+	"crypto/sha1"
 	"fmt"
-	"io"
+	"hash"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/CorentinB/warc"
 	"github.com/dustin/go-humanize"
 	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver/headless"
 	"github.com/internetarchive/Zeno/internal/pkg/archiver/ratelimiter"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver/soft404"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
@@ -35,13 +42,42 @@ type archiver struct {
 	inputCh  chan *models.Item
 	outputCh chan *models.Item
 
-	Client          *warc.CustomHTTPClient
-	ClientWithProxy *warc.CustomHTTPClient
+	// Canceled to abort the in-flight requests when stopping right away
+	requestsCtx    context.Context
+	cancelRequests context.CancelFunc
+
+	// The clients are replaced when the WARC files are rotated and when --proxy-file is reloaded,
+	// one replacement at a time
+	replaceMu            sync.Mutex
+	clientsMu            sync.RWMutex
+	Client               *warc.CustomHTTPClient
+	ClientWithProxy      *warc.CustomHTTPClient
+	ClientsWithProxyPool []*warc.CustomHTTPClient
+
+	// The captures holding each client, by *warc.CustomHTTPClient. The WaitGroup of a client is the size of
+	// its writing queue, the holds are counted apart.
+	holds sync.Map
+
+	// The proxy address of each client going through a proxy, by *warc.CustomHTTPClient, kept until the
+	// client is closed so that the outcome of its requests is reported after it's replaced too
+	proxies sync.Map
+
+	// Replaces the WARC writers of the clients for the records built by Zeno if set
+	records RecordWriter
 }
 
 var (
 	globalArchiver      *archiver
 	globalBucketManager *ratelimiter.BucketManager
+	globalLimiter       *ratelimiter.GlobalLimiter
+	globalHostDelays    *ratelimiter.HostDelays
+	globalAssetLimiter  *assetLimiter
+	globalProxyPool     *proxyPool
+	globalHostLimiter   *hostLimiter
+	globalHostBreaker   *hostBreaker
+	globalDNSFailures   *dnsNegativeCache
+	globalCrawlLimit    *crawlLimit
+	globalWorkers       workersActivity
 	once                sync.Once
 	logger              *log.FieldedLogger
 )
@@ -59,11 +95,14 @@ func Start(inputChan, outputChan chan *models.Item) error {
 
 	once.Do(func() {
 		ctx, cancel := context.WithCancel(context.Background())
+		requestsCtx, cancelRequests := context.WithCancel(context.Background())
 		globalArchiver = &archiver{
-			ctx:      ctx,
-			cancel:   cancel,
-			inputCh:  inputChan,
-			outputCh: outputChan,
+			ctx:            ctx,
+			cancel:         cancel,
+			inputCh:        inputChan,
+			outputCh:       outputChan,
+			requestsCtx:    requestsCtx,
+			cancelRequests: cancelRequests,
 		}
 		if !config.Get().DisableRateLimit {
 			globalBucketManager = ratelimiter.NewBucketManager(ctx,
@@ -74,6 +113,21 @@ func Start(inputChan, outputChan chan *models.Item) error {
 			)
 			logger.Info("bucket manager started")
 		}
+		globalHostDelays = ratelimiter.NewHostDelays(config.Get().HostDelays)
+		globalLimiter = ratelimiter.NewGlobalLimiter(config.Get().GlobalMaxQPS)
+		if globalLimiter != nil {
+			logger.Info("global rate limit enabled", "max_qps", config.Get().GlobalMaxQPS)
+		}
+		globalAssetLimiter = newAssetLimiter(config.Get().MaxConcurrentAssetsTotal, config.Get().MaxConcurrentAssetsPerHost)
+		// The reactor doesn't hand out more seeds than workers, more deferred seeds would be a leak
+		globalHostLimiter = newHostLimiter(config.Get().MaxConcurrentRequestsPerDomain, config.Get().WorkersCount)
+		globalHostBreaker = newHostBreaker(config.Get().HostBreakerThreshold, config.Get().HostBreakerWindow, config.Get().HostBreakerCooldown, config.Get().WorkersCount)
+		globalDNSFailures = newDNSNegativeCache(config.Get().DNSNegativeCacheTTL, config.Get().DNSCacheSize)
+		globalRequeue = newRequeue()
+		globalCrawlLimit = newCrawlLimit(int64(config.Get().MaxCrawledItems), config.Get().MaxCrawledItemsScope)
+		if config.Get().Soft404Detection {
+			globalSoft404 = soft404.NewDetector(config.Get().Soft404MaxHosts)
+		}
 		logger.Debug("initialized")
 
 		// Setup WARC writing HTTP clients
@@ -81,6 +135,80 @@ func Start(inputChan, outputChan chan *models.Item) error {
 
 		logger.Debug("WARC writer started")
 
+		if config.Get().CrawlLogJSONL != "" {
+			var err error
+			globalCrawlEventLog, err = openCrawlEventLog(config.Get().CrawlLogJSONL)
+			if err != nil {
+				logger.Error("unable to open JSON lines crawl log", "err", err.Error(), "path", config.Get().CrawlLogJSONL)
+				os.Exit(1)
+			}
+		}
+
+		if config.Get().CrawlLog {
+			var err error
+			globalCrawlLog, err = openCrawlLog(filepath.Join(config.Get().JobPath, "crawl.log"), int64(config.Get().CrawlLogMaxSize)*1024*1024)
+			if err != nil {
+				logger.Error("unable to open crawl log", "err", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		var failureReportPath string
+		if config.Get().FailureReport {
+			failureReportPath = filepath.Join(config.Get().JobPath, "failures.jsonl")
+		}
+		var err error
+		globalFailures, err = newFailureReport(failureReportPath, maxRecentFailures)
+		if err != nil {
+			logger.Error("unable to open failure report", "err", err.Error())
+			os.Exit(1)
+		}
+
+		if config.Get().Incremental {
+			var err error
+			globalValidators, err = openValidatorStore(config.Get().JobPath)
+			if err != nil {
+				logger.Error("unable to open validators database", "err", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if config.Get().TrackChanges {
+			var err error
+			globalChanges, err = openChangeStore(config.Get().JobPath)
+			if err != nil {
+				logger.Error("unable to open digests database", "err", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if config.Get().MaxTotalWARCSize > 0 {
+			globalWARCSizeLimit = newWARCSizeLimit(int64(config.Get().MaxTotalWARCSize)*1024*1024, filepath.Join(config.Get().JobPath, "warcs"))
+			globalArchiver.wg.Add(1)
+			go globalArchiver.warcSizeWatcher(time.Second)
+		}
+
+		if config.Get().WARCMaxAge > 0 {
+			globalArchiver.wg.Add(1)
+			go globalArchiver.warcRotator(config.Get().WARCMaxAge)
+		}
+
+		if config.Get().ProxyFile != "" {
+			globalArchiver.wg.Add(1)
+			go globalArchiver.proxyReloader(config.Get().ProxyFile, config.Get().ProxyFileReload)
+		}
+
+		// Start the headless browser, if it can't be started we keep going with the plain HTTP path
+		if headlessWanted() {
+			if err := headless.Start(); err != nil {
+				logger.Error("unable to start headless browser, falling back to plain HTTP captures", "err", err.Error())
+			}
+		}
+
+		globalWorkers = newWorkersActivity(config.Get().WorkersCount)
+		globalArchiver.wg.Add(1)
+		go globalArchiver.stuckWorkersMonitor()
+
 		for i := 0; i < config.Get().WorkersCount; i++ {
 			globalArchiver.wg.Add(1)
 			go globalArchiver.worker(strconv.Itoa(i))
@@ -97,12 +225,20 @@ func Start(inputChan, outputChan chan *models.Item) error {
 	return nil
 }
 
-// Stop stops the archiver routines and the WARC writer
+// Stop stops the archiver routines and waits for the WARC records to be written
 func Stop() {
 	if globalArchiver != nil {
 		globalArchiver.cancel()
 		globalArchiver.wg.Wait()
 
+		// The deferred seeds are still claimed in the source, it resets them like the seeds being archived
+		if deferred := globalHostLimiter.drain() + globalHostBreaker.drain(); deferred > 0 {
+			logger.Info("seeds deferred when stopping, given back to the source", "seeds", deferred)
+		}
+
+		// Close the browser before the WARC writers, all its requests are done by now
+		headless.Stop()
+
 		// Wait for the WARC writing to finish
 		stopLocalWatcher := make(chan struct{})
 		go func() {
@@ -115,17 +251,57 @@ func Stop() {
 				}
 			}
 		}()
-		globalArchiver.Client.WaitGroup.Wait()
+		clients := GetClients()
+		for _, client := range clients {
+			client.WaitGroup.Wait()
+		}
 		stopLocalWatcher <- struct{}{}
 		logger.Debug("WARC writing finished")
-		globalArchiver.Client.Close()
-		if globalArchiver.ClientWithProxy != nil {
-			globalArchiver.ClientWithProxy.WaitGroup.Wait()
-			globalArchiver.ClientWithProxy.Close()
-		}
 
 		logger.Info("stopped")
 	}
+}
+
+// CancelRequests aborts the in-flight requests, to be called before Stop to stop right away
+// instead of waiting for the downloads to finish. What was captured is still written.
+func CancelRequests() {
+	if globalArchiver != nil {
+		logger.Info("canceling in-flight requests")
+		globalArchiver.cancelRequests()
+	}
+}
+
+// Close closes the WARC writer, it is done once the items archived are reported to the source
+func Close() {
+	if globalArchiver != nil {
+		for _, client := range GetClients() {
+			globalArchiver.closeClient(client)
+		}
+
+		stopCDXIndexServer()
+
+		if err := globalCrawlEventLog.close(); err != nil {
+			logger.Error("unable to close JSON lines crawl log", "err", err.Error())
+		}
+
+		if err := globalFailures.close(); err != nil {
+			logger.Error("unable to close failure report", "err", err.Error())
+		}
+
+		if err := globalCrawlLog.close(); err != nil {
+			logger.Error("unable to close crawl log", "err", err.Error())
+		}
+
+		if err := globalValidators.close(); err != nil {
+			logger.Error("unable to close validators database", "err", err.Error())
+		}
+
+		if err := globalChanges.close(); err != nil {
+			logger.Error("unable to close digests database", "err", err.Error())
+		}
+
+		logger.Info("closed")
+	}
 	if globalBucketManager != nil {
 		logger.Debug("closing bucket manager")
 		globalBucketManager.Close()
@@ -138,7 +314,7 @@ func (a *archiver) worker(workerID string) {
 
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "archiver.worker",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	defer logger.Debug("worker stopped")
@@ -150,7 +326,17 @@ func (a *archiver) worker(workerID string) {
 	stats.ArchiverRoutinesIncr()
 	defer stats.ArchiverRoutinesDecr()
 
+	activity := globalWorkers.get(workerID)
+
 	for {
+		activity.set(workerIdle, nil)
+
+		// No new seed is taken while the deferred ones are at their cap, until one of them is resumed
+		inputCh := a.inputCh
+		if globalHostLimiter.full() || globalHostBreaker.full() {
+			inputCh = nil
+		}
+
 		select {
 		case <-a.ctx.Done():
 			logger.Debug("shutting down")
@@ -159,40 +345,146 @@ func (a *archiver) worker(workerID string) {
 			logger.Debug("received pause event")
 			controlChans.ResumeCh <- struct{}{}
 			logger.Debug("received resume event")
-		case seed, ok := <-a.inputCh:
+		case seed, ok := <-inputCh:
 			if ok {
-				logger.Debug("received seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops())
+				logger.Debug("received seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hop", seed.GetURL().GetHops())
 
 				if err := seed.CheckConsistency(); err != nil {
 					panic(fmt.Sprintf("seed consistency check failed with err: %s, seed id %s", err.Error(), seed.GetShortID()))
 				}
 
-				if seed.GetStatus() != models.ItemPreProcessed && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
-					logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops(), "status", seed.GetStatus().String())
-				} else {
-					archive(workerID, seed)
+				// The seeds of the hosts failing most of their captures wait for the host to be tested again
+				host := limitedHost(seed)
+				if host != "" && !globalHostBreaker.allow(host) {
+					logger.Debug("host circuit open, deferring seed", "seed", seed.GetShortID(), "host", host)
+					globalHostBreaker.deferItem(host, seed)
+					continue
 				}
 
-				select {
-				case <-a.ctx.Done():
-					logger.Debug("aborting seed due to stop", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops())
+				// Instead of waiting for a slot of a saturated host, the worker moves on to the next seed
+				if host != "" && !globalHostLimiter.tryAcquire(host) {
+					logger.Debug("host saturated, deferring seed", "seed", seed.GetShortID(), "host", host)
+					globalHostLimiter.deferItem(host, seed)
+					continue
+				}
+
+				if !a.archiveSeed(workerID, seed, host, logger) {
 					return
-				case a.outputCh <- seed:
 				}
 			}
+		case <-globalHostLimiter.ready():
+			seed, host := globalHostLimiter.popReady()
+			if seed == nil {
+				continue
+			}
+
+			logger.Debug("resuming deferred seed", "seed", seed.GetShortID(), "host", host)
+
+			if !a.archiveSeed(workerID, seed, host, logger) {
+				return
+			}
+		case <-globalHostBreaker.ready():
+			seed, host := globalHostBreaker.popReady()
+			if seed == nil {
+				continue
+			}
+
+			logger.Debug("resuming seed deferred by the host circuit", "seed", seed.GetShortID(), "host", host)
+
+			if !globalHostLimiter.tryAcquire(host) {
+				globalHostLimiter.deferItem(host, seed)
+				continue
+			}
+
+			if !a.archiveSeed(workerID, seed, host, logger) {
+				return
+			}
+		case <-globalRequeue.ready():
+			seed := globalRequeue.popReady()
+			if seed == nil {
+				continue
+			}
+
+			logger.Debug("resuming requeued seed", "seed", seed.GetShortID())
+
+			host := limitedHost(seed)
+			if host != "" && !globalHostLimiter.tryAcquire(host) {
+				globalHostLimiter.deferItem(host, seed)
+				continue
+			}
+
+			if !a.archiveSeed(workerID, seed, host, logger) {
+				return
+			}
+		}
+	}
+}
+
+// archiveSeed archives the seed and passes it to the next stage, the slot of the host taken
+// for it, if any, is released once archived. It returns false if the archiver is stopping.
+func (a *archiver) archiveSeed(workerID string, seed *models.Item, host string, logger *log.FieldedLogger) bool {
+	// Once a crawl limit is reached, the seeds are held until the archiver is stopped and given back to the source
+	if globalCrawlLimit.isReached() || globalWARCSizeLimit.isReached() {
+		if host != "" {
+			globalHostLimiter.release(host)
+		}
+
+		logger.Debug("crawl limit reached, holding seed", "seed", seed.GetShortID())
+		<-a.ctx.Done()
+		return false
+	}
+
+	if seed.GetStatus() != models.ItemPreProcessed && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
+		logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hop", seed.GetURL().GetHops(), "item_status", seed.GetStatus().String())
+	} else {
+		stats.ActiveWorkersIncr()
+		archive(workerID, seed)
+		stats.ActiveWorkersDecr()
+	}
+
+	// The captures of the seed were aborted, it is given back to the source like the seeds not archived yet
+	if a.requestsCtx.Err() != nil {
+		if host != "" {
+			globalHostLimiter.release(host)
 		}
+
+		logger.Debug("aborting seed due to canceled requests", "seed", seed.GetShortID())
+		closeBodies(seed)
+		return false
+	}
+
+	if host != "" {
+		globalHostLimiter.release(host)
+	}
+
+	// The items that failed with a transient network error are attempted again once their backoff is over
+	if delay, requeued := requeueDelay(seed); requeued {
+		logger.Debug("requeuing seed", "seed", seed.GetShortID(), "delay", delay.String())
+		globalRequeue.add(seed, delay)
+		return true
+	}
+
+	select {
+	case <-a.ctx.Done():
+		logger.Debug("aborting seed due to stop", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hop", seed.GetURL().GetHops())
+		closeBodies(seed)
+		return false
+	case a.outputCh <- seed:
+		return true
 	}
 }
 
 func archive(workerID string, seed *models.Item) {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "archiver.archive",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	var (
-		guard = make(chan struct{}, config.Get().MaxConcurrentAssets)
-		wg    sync.WaitGroup
+		guard    = make(chan struct{}, config.Get().MaxConcurrentAssets)
+		wg       sync.WaitGroup
+		hlsSizes = newHLSSizeTracker(int64(config.Get().HLSMaxSize) * 1024 * 1024)
+		activity = globalWorkers.get(workerID)
 	)
 
 	items, err := seed.GetNodesAtLevel(seed.GetMaxDepth())
@@ -203,22 +495,52 @@ func archive(workerID string, seed *models.Item) {
 
 	for i := range items {
 		if items[i].GetStatus() != models.ItemPreProcessed {
-			logger.Debug("skipping item", "seed_id", seed.GetShortID(), "item_id", items[i].GetShortID(), "status", items[i].GetStatus().String(), "depth", items[i].GetDepth())
+			logger.Debug("skipping item", "seed_id", seed.GetShortID(), "item_id", items[i].GetShortID(), "item_status", items[i].GetStatus().String(), "depth", items[i].GetDepth())
 			continue
 		}
 
 		guard <- struct{}{}
 
+		// Checked once the guard is acquired, so that most of the previous segments are accounted for
+		playlistSize := hlsSizes.counter(items[i])
+		if hlsSizes.exceeded(playlistSize) {
+			<-guard
+			logger.Debug("skipping HLS segment, playlist size cap reached", "seed_id", seed.GetShortID(), "item_id", items[i].GetShortID(), "url", items[i].GetURL().String())
+			items[i].SetStatus(models.ItemCompleted)
+			continue
+		}
+
+		// The seeds of the failing hosts are deferred before reaching here, their assets are skipped
+		if items[i].IsChild() && items[i].GetURL().GetParsed() != nil && !globalHostBreaker.allow(strings.ToLower(items[i].GetURL().GetParsed().Host)) {
+			<-guard
+			logger.Debug("skipping asset, host circuit open", "seed_id", seed.GetShortID(), "item_id", items[i].GetShortID(), "url", items[i].GetURL().String())
+			items[i].SetStatus(models.ItemCompleted)
+			continue
+		}
+
+		if !globalCrawlLimit.take(items[i]) {
+			<-guard
+			logger.Debug("skipping item, crawl limit reached", "seed_id", seed.GetShortID(), "item_id", items[i].GetShortID(), "url", items[i].GetURL().String())
+			items[i].SetStatus(models.ItemCompleted)
+			continue
+		}
+
 		wg.Add(1)
 		go func(item *models.Item) {
 			defer wg.Done()
 			defer func() { <-guard }()
 			defer stats.URLsCrawledIncr()
+			defer stats.ItemsCrawledIncr(itemType(item))
+			defer activity.done()
+
+			current := newWorkerItem(item)
+			activity.set(workerFetching, current)
 
 			var (
 				err          error
 				resp         *http.Response
 				feedbackChan chan struct{}
+				payloadHash  hash.Hash
 			)
 
 			// Execute the request
@@ -227,21 +549,113 @@ func archive(workerID string, seed *models.Item) {
 				panic("request is nil")
 			}
 
-			// Wait for the rate limiter if enabled
-			if globalBucketManager != nil {
+			// The request, and the read of its body, are aborted by CancelRequests
+			requestCtx, cancelRequest := context.WithCancel(req.Context())
+			defer cancelRequest()
+			defer context.AfterFunc(globalArchiver.requestsCtx, cancelRequest)()
+			req = req.WithContext(requestCtx)
+
+			// Hold the client until the capture is written, a rotation closes it only then
+			client, release := globalArchiver.acquireClient(req.URL.Host)
+			defer release()
+
+			// Assets are bounded across all workers on top of the per page bound
+			if item.IsChild() {
+				releaseAsset := globalAssetLimiter.acquire(req.URL.Host)
+				defer releaseAsset()
+
+				stats.AssetsInFlightIncr()
+				defer stats.AssetsInFlightDecr()
+			}
+
+			// Hosts with their own politeness delay are spaced by it, the others wait for the rate limiter if enabled
+			if hostDelay, delay, created := globalHostDelays.Limiter(req.URL.Host); hostDelay != nil {
+				if created {
+					logger.Info("per-host delay applied", "host", req.URL.Host, "delay", delay)
+				}
+				elapsed, _ := hostDelay.Wait(globalArchiver.ctx)
+				logger.Debug("waited for host delay", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "elapsed", elapsed)
+			} else if globalBucketManager != nil {
 				elapsed := globalBucketManager.Wait(req.URL.Host)
-				logger.Debug("got token from bucket", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops(), "elapsed", elapsed)
+				logger.Debug("got token from bucket", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "elapsed", elapsed)
+			}
+
+			captureStartTime := time.Now()
+
+			// Seeds are rendered in the browser when headless mode is enabled for them,
+			// if the browser fails we fall back to the plain HTTP path
+			if renderFirst(item) {
+				if page := render(client, item); page != nil {
+					resp = page.Response
+				}
+			}
+
+			// Ask the server if the URL changed since its capture by a previous run
+			var previous *validators
+			if !item.GetURL().IsHeadless() {
+				previous = globalValidators.conditional(req)
+			}
+
+			// Start of the last attempt, the politeness delays are behind it
+			var requestStartTime time.Time
+
+			// Every capture attempt is written to the crawl logs, failures included. The status is the
+			// negative code of the error if the fetch failed, it stays 0 if the request is canceled.
+			var (
+				crawlStatus      int
+				crawlSize        int64
+				crawlAnnotations []string
+				crawlTruncated   bool
+				crawlChange      string
+				attempts         int
+			)
+			defer func() {
+				if crawlStatus == 0 {
+					return
+				}
+
+				fetchStart := requestStartTime
+				if fetchStart.IsZero() {
+					fetchStart = captureStartTime
+				}
+
+				var crawlResp *http.Response
+				if crawlStatus > 0 {
+					crawlResp = resp
+				}
+
+				entry := newCrawlLogEntry(workerID, item, crawlStatus, crawlResp, crawlSize, fetchStart, crawlAnnotations)
+				if err := globalCrawlLog.write(entry); err != nil {
+					logger.Error("unable to write crawl log", "err", err.Error(), "item_id", item.GetShortID())
+				}
+
+				event := newCrawlEvent(entry, item, crawlResp, time.Since(captureStartTime), crawlTruncated)
+				event.Change = crawlChange
+				if err := globalCrawlEventLog.write(event); err != nil {
+					logger.Error("unable to write crawl event", "err", err.Error(), "item_id", item.GetShortID())
+				}
+			}()
+
+			// Keep the details of the connection of the last attempt for the WARC metadata record
+			var connInfo *connectionInfo
+			if config.Get().WARCConnectionMetadata {
+				req, connInfo = traceConnection(req, globalArchiver.isProxied(client))
 			}
 
 			// Don't use the global bucket manager in the retry loop.
 			// Most failed requests won't reach the server anyway, so we don't need to wait for the rate limit.
 			// This prevents workers from being blocked for too long by dead sites, such as host unreachable or DNS errors.
-			for retry := 0; retry <= config.Get().MaxRetry; retry++ {
+			for retry := 0; retry <= config.Get().MaxRetry && !item.GetURL().IsHeadless(); retry++ {
 				// This is unused unless there is an error
 				retrySleepTime := time.Second * time.Duration(retry*2)
 
+				// Every request, retries included, counts against the global rate limit
+				waitGlobalLimiter()
+				attempts = retry + 1
+
 				// Get and measure request time
 				getStartTime := time.Now()
+				requestStartTime = getStartTime
 
 				// If WARC writing is asynchronous, we don't need a feedback channel
 				if !config.Get().WARCWriteAsync {
@@ -250,22 +664,82 @@ func archive(workerID string, seed *models.Item) {
 					req = req.WithContext(context.WithValue(req.Context(), "feedback", feedbackChan))
 				}
 
-				if config.Get().Proxy != "" {
-					resp, err = globalArchiver.ClientWithProxy.Do(req)
-				} else {
-					resp, err = globalArchiver.Client.Do(req)
+				// The record ID of the response is chosen here, so that the metadata records can refer to it
+				recordID := uuid.NewString()
+				req = req.WithContext(context.WithValue(req.Context(), "responseRecordID", recordID))
+				item.GetURL().SetRecordID("<urn:uuid:" + recordID + ">")
+
+				// The body is hashed as received, the digest is the WARC-Payload-Digest of the compressed responses too
+				payloadHash = sha1.New()
+				req = req.WithContext(context.WithValue(req.Context(), "payloadHash", payloadHash))
+
+				// The hosts that failed to resolve recently, after their requeues, fail right away
+				cachedDNSFailure := globalDNSFailures.failed(req.URL.Hostname())
+				if err = cachedDNSFailure; err == nil {
+					resp, err = client.Do(req)
+					globalArchiver.reportProxy(client, err != nil || (resp != nil && resp.StatusCode == http.StatusProxyAuthRequired))
+					err = dnsFailure(req.Context(), req.URL.Hostname(), err)
 				}
 
 				if err != nil {
+					if globalArchiver.requestsCtx.Err() != nil {
+						logger.Debug("request canceled", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "url", req.URL.String())
+						item.SetStatus(models.ItemFailed)
+						return
+					}
+
+					// A host that doesn't resolve is requeued like on a transient error, in case its records are
+					// being changed, and dropped once its requeues are exhausted. The other URLs of a host dropped
+					// recently are dropped right away.
+					if isDNSNotFound(err) && (cachedDNSFailure != nil || item.GetAttempts() >= config.Get().MaxRetry) {
+						if cachedDNSFailure == nil {
+							globalDNSFailures.record(req.URL.Hostname())
+						}
+
+						crawlStatus = fetchErrorStatus(err)
+						crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("%dt", item.GetAttempts()+1))
+						reportFailure(item, failureDNS, 0, err, item.GetAttempts()+1)
+						logger.Warn("host doesn't resolve after retries, dropping item", "err", err.Error(), "host", req.URL.Hostname(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "attempts", item.GetAttempts()+1)
+						item.SetStatus(models.ItemFailed)
+						stats.URLsFailedIncr()
+						return
+					}
+
+					// The transient network errors don't hold the worker, the item is requeued with its seed
+					// and attempted again after a backoff, up to --max-retry times
+					if isTransientNetworkError(err) || isDNSNotFound(err) {
+						if attempt := item.IncrAttempts(); attempt <= config.Get().MaxRetry {
+							crawlStatus = fetchErrorStatus(err)
+							crawlAnnotations = append(crawlAnnotations, "requeued")
+							logger.Warn("transient network error, requeuing item", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "attempt", attempt, "backoff", requeueBackoff(attempt).String())
+							stats.URLsRequeuedIncr()
+							return
+						}
+
+						crawlStatus = fetchErrorStatus(err)
+						crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("%dt", item.GetAttempts()))
+						reportFailure(item, fetchFailureReason(err), 0, err, item.GetAttempts())
+						globalHostBreaker.record(strings.ToLower(req.URL.Host), false)
+						logger.Error("transient network error, requeues exhausted", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "attempts", item.GetAttempts())
+						item.SetStatus(models.ItemFailed)
+						stats.URLsFailedIncr()
+						return
+					}
+
 					if retry < config.Get().MaxRetry {
-						logger.Warn("retrying request", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops(), "retry", retry, "sleep_time", retrySleepTime.String())
+						logger.Warn("retrying request", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "retry", retry, "sleep_time", retrySleepTime.String())
 						time.Sleep(retrySleepTime)
 						continue
 					}
 
 					// retries exhausted
-					logger.Error("unable to execute request", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops())
+					crawlStatus = fetchErrorStatus(err)
+					crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("%dt", retry+1))
+					reportFailure(item, fetchFailureReason(err), 0, err, attempts)
+					globalHostBreaker.record(strings.ToLower(req.URL.Host), false)
+					logger.Error("unable to execute request", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops())
 					item.SetStatus(models.ItemFailed)
+					stats.URLsFailedIncr()
 					return
 				}
 
@@ -277,21 +751,24 @@ func archive(workerID string, seed *models.Item) {
 						globalBucketManager.AdjustOnFailure(req.URL.Host, resp.StatusCode)
 					}
 					if retry < config.Get().MaxRetry {
-						logger.Warn("bad response code, retrying", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops(), "retry", retry, "sleep_time", retrySleepTime.String(), "status_code", resp.StatusCode, "url", req.URL.String())
+						logger.Warn("bad response code, retrying", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "retry", retry, "sleep_time", retrySleepTime.String(), "status", resp.StatusCode, "url", req.URL.String())
 
 						// Consume body, needed to avoid leaking RAM & storage
-						io.Copy(io.Discard, resp.Body)
-						resp.Body.Close()
+						discardResponse(resp)
 
 						time.Sleep(retrySleepTime)
 						continue
 					} else {
-						logger.Error("bad response code, retries exceeded", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops(), "status_code", resp.StatusCode, "url", req.URL.String())
+						logger.Error("bad response code, retries exceeded", "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "status", resp.StatusCode, "url", req.URL.String())
+						globalHostBreaker.record(strings.ToLower(req.URL.Host), resp.StatusCode < 500)
+						crawlStatus = resp.StatusCode
+						crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("%dt", retry+1))
+						reportFailure(item, failureStatus, resp.StatusCode, nil, attempts)
 						item.SetStatus(models.ItemFailed)
+						stats.URLsFailedIncr()
 
 						// Consume body, needed to avoid leaking RAM & storage
-						io.Copy(io.Discard, resp.Body)
-						resp.Body.Close()
+						discardResponse(resp)
 
 						return
 					}
@@ -303,33 +780,185 @@ func archive(workerID string, seed *models.Item) {
 
 				// OK
 				stats.MeanHTTPRespTimeAdd(time.Since(getStartTime))
+				globalHostBreaker.record(strings.ToLower(req.URL.Host), true)
+				if retry > 0 {
+					crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("%dt", retry+1))
+				}
 				break
 			}
 
+			if resp != nil {
+				crawlStatus = resp.StatusCode
+			}
+
+			// The connection of an upgraded WebSocket handshake is closed right away, the WARC library
+			// writes the exchange once it's closed. The response has no body to process.
+			if item.GetURL().IsWebSocket() && resp.StatusCode == http.StatusSwitchingProtocols {
+				logger.Info("WebSocket handshake captured", "url", item.GetURL().String(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				resp.Body.Close()
+				stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+				stats.HTTPHostResponsesIncr(req.URL.Hostname(), strconv.Itoa(resp.StatusCode))
+				item.SetStatus(models.ItemCompleted)
+				return
+			}
+
+			// Don't download the bodies of the unwanted content types, the WARC writer skips their capture
+			if !item.GetURL().IsHeadless() && contentTypeSkipped(resp.StatusCode, resp.Header.Get("Content-Type")) {
+				logger.Info("content type not fetched", "url", item.GetURL().String(), "content_type", resp.Header.Get("Content-Type"), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				crawlAnnotations = append(crawlAnnotations, "not-fetched:content-type")
+				resp.Body.Close()
+				item.SetStatus(models.ItemCompleted)
+				return
+			}
+
+			// The responses with a discarded status aren't written to the WARC, nor processed
+			if !item.GetURL().IsHeadless() && statusDiscarded(resp.StatusCode) {
+				logger.Info("response status not recorded", "url", item.GetURL().String(), "status", resp.StatusCode, "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				crawlAnnotations = append(crawlAnnotations, "not-recorded:status")
+				discardResponse(resp)
+				if resp.StatusCode >= 400 {
+					reportFailure(item, failureStatus, resp.StatusCode, nil, attempts)
+				}
+				stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+				stats.HTTPHostResponsesIncr(req.URL.Hostname(), strconv.Itoa(resp.StatusCode))
+				item.SetStatus(models.ItemCompleted)
+				return
+			}
+
+			// Stop reading the bodies bigger than the limit, the truncated capture is recorded once processed
+			var truncatedBody *truncatingBody
+			if config.Get().MaxResponseBodySize > 0 && !item.GetURL().IsHeadless() {
+				truncatedBody = newTruncatingBody(resp.Body, config.Get().MaxResponseBodySize)
+				defer truncatedBody.discard()
+				resp.Body = truncatedBody
+			}
+
+			// Count the bytes of the body for the crawl log and the size cap of the HLS playlists,
+			// the Content-Length is missing from the chunked responses
+			body := &countingBody{ReadCloser: resp.Body}
+			resp.Body = body
+			defer func() {
+				crawlSize = body.read
+				if playlistSize != nil {
+					playlistSize.Add(body.read)
+				}
+			}()
+
 			// Set the response in the URL
 			item.GetURL().SetResponse(resp)
 
 			// Process the body and measure the time
+			activity.set(workerExtracting, current)
 			processStartTime := time.Now()
-			err = ProcessBody(item.GetURL(), config.Get().DisableAssetsCapture, domainscrawl.Enabled(), config.Get().MaxHops, config.Get().WARCTempDir)
+			err = ProcessBody(item.GetURL(), config.Get().DisableAssetsCapture, domainscrawl.Enabled(), item.EffectiveMaxHops(config.Get().MaxHops), config.Get().WARCTempDir, config.Get().MaxInMemoryResponseSize, config.Get().ExtractContentTypes)
 			if err != nil {
-				logger.Error("unable to process body", "err", err.Error(), "item_id", item.GetShortID(), "seed_id", seed.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops())
+				logger.Error("unable to process body", "err", err.Error(), "item_id", item.GetShortID(), "seed_id", seed.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops())
+				crawlStatus = fetchErrorStatus(err)
+				reportFailure(item, failureBody, resp.StatusCode, err, attempts)
 				item.SetStatus(models.ItemFailed)
+				stats.URLsFailedIncr()
 				return
 			}
 
 			stats.MeanProcessBodyTimeAdd(time.Since(processStartTime))
+
+			// The body is read by ProcessBody, the extraction after it isn't part of the request
+			if !item.GetURL().IsHeadless() {
+				requestEndTime := body.end
+				if requestEndTime.IsZero() {
+					requestEndTime = time.Now()
+				}
+				stats.HTTPRequestDurationObserve(requestEndTime.Sub(requestStartTime), itemType(item))
+			}
+
+			if !item.GetURL().IsHeadless() {
+				item.GetURL().SetDigest(payloadDigest(payloadHash))
+			}
+
+			if truncatedBody != nil && truncatedBody.truncated {
+				logger.Warn("response body truncated", "url", item.GetURL().String(), "max_size", config.Get().MaxResponseBodySize, "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				crawlAnnotations = append(crawlAnnotations, "truncated")
+				crawlTruncated = true
+				reportFailure(item, fetchFailureReason(ErrResponseTooLarge), resp.StatusCode, ErrResponseTooLarge, attempts)
+
+				if err := writeTruncatedResponse(globalArchiver.recordWriter(client), item.GetURL().String(), resp, truncatedBody); err != nil {
+					logger.Error("unable to write truncated response", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				} else {
+					stats.TruncatedResponsesIncr()
+				}
+			}
+
+			if previous != nil && resp.StatusCode == http.StatusNotModified {
+				if err := writeNotModifiedRevisit(globalArchiver.recordWriter(client), item.GetURL().String(), resp, previous); err != nil {
+					logger.Error("unable to write not modified revisit record", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				}
+			} else if !item.GetURL().IsHeadless() && (truncatedBody == nil || !truncatedBody.truncated) {
+				globalValidators.remember(req, resp)
+			}
+
+			// The payload of the complete captures is compared to the one of the previous run
+			if !item.GetURL().IsHeadless() && resp.StatusCode == http.StatusOK && (truncatedBody == nil || !truncatedBody.truncated) {
+				crawlChange = globalChanges.compare(item.GetURL().String(), item.GetURL().GetDigest())
+				if crawlChange != "" {
+					crawlAnnotations = append(crawlAnnotations, "change:"+crawlChange)
+				}
+			}
+
+			checkSoft404(client, item)
+
+			// The page is captured in the other languages it's served in, with --accept-language-variants
+			if !item.GetURL().IsHeadless() {
+				if variants := captureLanguageVariants(client, item, req, resp); variants > 0 {
+					crawlAnnotations = append(crawlAnnotations, fmt.Sprintf("variants:%d", variants))
+				}
+			}
+
 			stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+			stats.HTTPHostResponsesIncr(req.URL.Hostname(), strconv.Itoa(resp.StatusCode))
 
 			// If WARC writing is asynchronous, we don't need to wait for the feedback channel
-			if !config.Get().WARCWriteAsync {
+			if !config.Get().WARCWriteAsync && feedbackChan != nil {
+				activity.set(workerWriting, current)
 				feedbackTime := time.Now()
 				// Waiting for WARC writing to finish
 				<-feedbackChan
 				stats.MeanWaitOnFeedbackTimeAdd(time.Since(feedbackTime))
 			}
 
-			logger.Info("url archived", "url", item.GetURL().String(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hops", item.GetURL().GetHops(), "status", resp.StatusCode)
+			if connInfo != nil && !item.GetURL().IsHeadless() {
+				if err := writeConnectionMetadata(globalArchiver.recordWriter(client), item.GetURL().String(), connInfo); err != nil {
+					logger.Error("unable to write connection metadata", "err", err.Error(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+				}
+			}
+
+			// Pages that look rendered by JavaScript are rendered again in the browser once their plain
+			// capture is written, the outlinks and assets are then extracted from the rendered page
+			if renderAfter(item) {
+				logger.Info("page looks rendered by JavaScript, rendering it in the browser", "url", item.GetURL().String(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID())
+
+				if page := render(client, item); page != nil {
+					item.GetURL().GetBody().Close()
+					item.GetURL().SetBody(nil)
+					item.GetURL().SetDocument(nil)
+					item.GetURL().SetResponse(page.Response)
+
+					err = ProcessBody(item.GetURL(), config.Get().DisableAssetsCapture, domainscrawl.Enabled(), item.EffectiveMaxHops(config.Get().MaxHops), config.Get().WARCTempDir, config.Get().MaxInMemoryResponseSize, config.Get().ExtractContentTypes)
+					if err != nil {
+						logger.Error("unable to process rendered body", "err", err.Error(), "item_id", item.GetShortID(), "seed_id", seed.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops())
+						reportFailure(item, failureBody, page.Response.StatusCode, err, attempts)
+						item.SetStatus(models.ItemFailed)
+						stats.URLsFailedIncr()
+						return
+					}
+				}
+			}
+
+			logger.Info("url archived", "url", item.GetURL().String(), "seed_id", seed.GetShortID(), "item_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops(), "status", resp.StatusCode)
+
+			// The error responses are captured, but the URL failed nonetheless
+			if resp.StatusCode >= 400 {
+				reportFailure(item, failureStatus, resp.StatusCode, nil, attempts)
+			}
 
 			item.SetStatus(models.ItemArchived)
 		}(items[i])
@@ -340,3 +969,16 @@ func archive(workerID string, seed *models.Item) {
 
 	return
 }
+
+// waitGlobalLimiter waits for the slot of the request if the global rate limit is enabled.
+// When the archiver is stopping, the in-flight requests don't wait anymore.
+func waitGlobalLimiter() {
+	if globalLimiter == nil {
+		return
+	}
+
+	stats.GlobalRateLimitWaitingIncr()
+	defer stats.GlobalRateLimitWaitingDecr()
+
+	globalLimiter.Wait(globalArchiver.ctx)
+}
@@ -0,0 +1,241 @@
+package archiver
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/CorentinB/warc/pkg/spooledtempfile"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// newRedirectedSeed returns a seed redirected to target, the seed's body spooled to a temp file of dir like
+// the bodies processed by the archiver. The redirection is still to archive.
+func newRedirectedSeed(t *testing.T, dir, target string) *models.Item {
+	t.Helper()
+
+	seedURL := &models.URL{Raw: target + "/moved"}
+	if err := seedURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	body := spooledtempfile.NewSpooledTempFile("zeno", dir, 0, true, -1)
+	if _, err := body.Write([]byte("<html>moved</html>")); err != nil {
+		t.Fatal(err)
+	}
+	seedURL.SetBody(body)
+
+	seed := models.NewItem(seedURL.Raw, seedURL, "")
+
+	redirectionURL := &models.URL{Raw: target + "/page", Redirects: 1}
+	if err := redirectionURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	redirection := models.NewItem(redirectionURL.Raw, redirectionURL, "")
+	if err := seed.AddChild(redirection, models.ItemGotRedirected); err != nil {
+		t.Fatal(err)
+	}
+
+	return seed
+}
+
+// The seeds aborted by CancelRequests or a stop are given back to the source, the temp files of their
+// bodies are removed all the same
+func TestArchiveSeedAbortedBodies(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	defer func(jobPath string, poolSize int, compression string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver, workers workersActivity) {
+		globalArchiver = previous
+		globalWorkers = workers
+	}(globalArchiver, globalWorkers)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		cancel bool // Cancel the requests, the stop is only noticed when the seed is passed on otherwise
+	}{
+		{"canceled requests", true},
+		{"stopped", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Get().JobPath = t.TempDir()
+			config.Get().WARCPoolSize = 1
+			config.Get().WARCCompression = "none"
+
+			if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			requestsCtx, cancelRequests := context.WithCancel(context.Background())
+			defer cancelRequests()
+
+			globalArchiver = &archiver{ctx: ctx, cancel: cancel, requestsCtx: requestsCtx, cancelRequests: cancelRequests, outputCh: make(chan *models.Item)}
+			globalWorkers = newWorkersActivity(1)
+
+			startWARCWriter()
+			defer globalArchiver.Client.Close()
+
+			dir := t.TempDir()
+			seed := newRedirectedSeed(t, dir, server.URL)
+
+			if tt.cancel {
+				cancelRequests()
+			} else {
+				// Nobody reads the output, the seed can't be passed on once archived
+				cancel()
+			}
+
+			if globalArchiver.archiveSeed("0", seed, "", logger) {
+				t.Fatal("expected the seed to be aborted")
+			}
+
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("expected no temp file left, got %d", len(entries))
+			}
+
+			seed.Traverse(func(item *models.Item) {
+				if item.GetURL().GetBody() != nil {
+					t.Errorf("expected the body of %s to be released", item.GetURL().Raw)
+				}
+			})
+		})
+	}
+}
+
+// The response records are written with the record ID set on the URL, the metadata records refer to it,
+// and the digest of the URL is their payload digest, the one of the body as received when it's compressed
+func TestArchiveResponseRecord(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	defer func(jobPath string, poolSize int, compression string, maxConcurrentAssets int) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().MaxConcurrentAssets = maxConcurrentAssets
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().MaxConcurrentAssets)
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().MaxConcurrentAssets = 1
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver, workers workersActivity) {
+		globalArchiver = previous
+		globalWorkers = workers
+	}(globalArchiver, globalWorkers)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		gzipWriter := gzip.NewWriter(w)
+		gzipWriter.Write([]byte("<html>page</html>"))
+		gzipWriter.Close()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel, requestsCtx: requestsCtx, cancelRequests: cancelRequests}
+	globalWorkers = newWorkersActivity(1)
+
+	startWARCWriter()
+
+	seedURL := &models.URL{Raw: server.URL + "/page"}
+	if err := seedURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, seedURL.Raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedURL.SetRequest(req)
+
+	seed := models.NewItem(seedURL.Raw, seedURL, "")
+	seed.SetStatus(models.ItemPreProcessed)
+
+	archive("0", seed)
+
+	if seedURL.GetRecordID() == "" {
+		t.Fatal("expected a record ID to be set on the URL")
+	}
+
+	globalArchiver.Client.WaitGroup.Wait()
+	globalArchiver.Client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 WARC file, got %q (err: %v)", files, err)
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		record, eol, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord() error = %v", err)
+		}
+		if eol {
+			t.Fatal("no response record found")
+		}
+
+		if record.Header.Get("WARC-Type") != "response" {
+			continue
+		}
+
+		if id := record.Header.Get("WARC-Record-ID"); id != seedURL.GetRecordID() {
+			t.Errorf("expected the response record ID to be %q, got %q", seedURL.GetRecordID(), id)
+		}
+
+		if digest := record.Header.Get("WARC-Payload-Digest"); digest != seedURL.GetDigest() {
+			t.Errorf("expected the digest of the URL to be the payload digest %q, got %q", digest, seedURL.GetDigest())
+		}
+
+		return
+	}
+}
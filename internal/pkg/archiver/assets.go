@@ -0,0 +1,87 @@
+package archiver
+
+import (
+	"strings"
+	"sync"
+)
+
+// assetLimiter bounds the number of assets captured at the same time across all workers, in total
+// and per host, on top of the --max-concurrent-assets bound of each page. Pages don't go through it,
+// so that a few asset-heavy pages can't starve page crawling.
+type assetLimiter struct {
+	total   chan struct{}
+	perHost int
+
+	mu    sync.Mutex
+	hosts map[string]*assetHostSlots
+}
+
+type assetHostSlots struct {
+	slots chan struct{}
+	users int
+}
+
+// newAssetLimiter returns nil if neither limit is set
+func newAssetLimiter(total, perHost int) *assetLimiter {
+	if total <= 0 && perHost <= 0 {
+		return nil
+	}
+
+	limiter := &assetLimiter{
+		perHost: perHost,
+		hosts:   make(map[string]*assetHostSlots),
+	}
+
+	if total > 0 {
+		limiter.total = make(chan struct{}, total)
+	}
+
+	return limiter
+}
+
+// acquire blocks until a slot is available for an asset of the given host, the returned
+// function must be called to release it. The host slot is taken first so that an asset
+// waiting on a busy host doesn't hold one of the slots shared by all hosts.
+func (l *assetLimiter) acquire(host string) (release func()) {
+	if l == nil {
+		return func() {}
+	}
+
+	var hostSlots *assetHostSlots
+	if l.perHost > 0 {
+		host = strings.ToLower(host)
+
+		l.mu.Lock()
+		hostSlots = l.hosts[host]
+		if hostSlots == nil {
+			hostSlots = &assetHostSlots{slots: make(chan struct{}, l.perHost)}
+			l.hosts[host] = hostSlots
+		}
+		hostSlots.users++
+		l.mu.Unlock()
+
+		hostSlots.slots <- struct{}{}
+	}
+
+	if l.total != nil {
+		l.total <- struct{}{}
+	}
+
+	return func() {
+		if l.total != nil {
+			<-l.total
+		}
+
+		if hostSlots != nil {
+			<-hostSlots.slots
+
+			// Forget the hosts nobody uses anymore, most of them are only seen once
+			l.mu.Lock()
+			hostSlots.users--
+			if hostSlots.users == 0 {
+				delete(l.hosts, host)
+			}
+			l.mu.Unlock()
+		}
+	}
+}
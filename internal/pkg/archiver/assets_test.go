@@ -0,0 +1,102 @@
+package archiver
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAssetLimiterDisabled(t *testing.T) {
+	limiter := newAssetLimiter(0, 0)
+	if limiter != nil {
+		t.Fatalf("expected no limiter without limits, got %+v", limiter)
+	}
+
+	// A nil limiter never blocks
+	limiter.acquire("example.com")()
+}
+
+// maxConcurrent runs a capture of each of the given hosts through the limiter and returns the highest number
+// of captures running at the same time for each host and in total
+func maxConcurrent(limiter *assetLimiter, hosts []string) (perHost map[string]int64, total int64) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running = make(map[string]*atomic.Int64)
+		all     atomic.Int64
+	)
+
+	perHost = make(map[string]int64)
+	for _, host := range hosts {
+		running[host] = new(atomic.Int64)
+	}
+
+	for _, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			release := limiter.acquire(host)
+			defer release()
+
+			hostRunning := running[host].Add(1)
+			allRunning := all.Add(1)
+
+			mu.Lock()
+			perHost[host] = max(perHost[host], hostRunning)
+			total = max(total, allRunning)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			running[host].Add(-1)
+			all.Add(-1)
+		}()
+	}
+
+	wg.Wait()
+
+	return perHost, total
+}
+
+func TestAssetLimiter(t *testing.T) {
+	var hosts []string
+	for range 6 {
+		hosts = append(hosts, "a.example.com", "b.example.com", "c.example.com")
+	}
+
+	perHost, total := maxConcurrent(newAssetLimiter(0, 2), hosts)
+	for host, got := range perHost {
+		if got > 2 {
+			t.Errorf("%d assets of %s captured at once, want at most 2", got, host)
+		}
+	}
+	if total < 4 {
+		t.Errorf("expected the hosts to be captured in parallel, got at most %d assets at once", total)
+	}
+
+	perHost, total = maxConcurrent(newAssetLimiter(3, 2), hosts)
+	if total > 3 {
+		t.Errorf("%d assets captured at once, want at most 3", total)
+	}
+	for host, got := range perHost {
+		if got > 2 {
+			t.Errorf("%d assets of %s captured at once, want at most 2", got, host)
+		}
+	}
+}
+
+func TestAssetLimiterForgetsHosts(t *testing.T) {
+	limiter := newAssetLimiter(0, 1)
+
+	release := limiter.acquire("Example.com")
+	if len(limiter.hosts) != 1 {
+		t.Fatalf("expected the host to be tracked, got %d hosts", len(limiter.hosts))
+	}
+
+	release()
+	if len(limiter.hosts) != 0 {
+		t.Errorf("expected the host to be forgotten once released, got %d hosts", len(limiter.hosts))
+	}
+}
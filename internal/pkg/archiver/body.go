@@ -3,7 +3,6 @@ package archiver
 import (
 	"bytes"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/CorentinB/warc/pkg/spooledtempfile"
@@ -13,8 +12,26 @@ import (
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
-// ProcessBody processes the body of a URL response, loading it into memory or a temporary file
-func ProcessBody(u *models.URL, disableAssetsCapture, domainsCrawl bool, maxHops int, WARCTempDir string) error {
+// defaultMaxInMemorySize is the size under which bodies are kept in memory if no threshold is given
+const defaultMaxInMemorySize = 2097152
+
+// defaultExtractContentTypes are the content types of the bodies kept for extraction if none are configured
+var defaultExtractContentTypes = []string{
+	"text/*",
+	"application/xhtml+xml",
+	"application/xml",
+	"image/svg+xml",
+	"application/pdf",
+	"application/vnd.apple.mpegurl",
+	"application/x-mpegurl",
+	"application/dash+xml",
+}
+
+// ProcessBody processes the body of a URL response, loading it into memory or a temporary file.
+// Bodies smaller than maxInMemorySize bytes are kept in memory, bigger ones are spooled to a temporary
+// file in WARCTempDir. If maxInMemorySize is <= 0, a 2MB threshold is used.
+// Only the bodies matching extractContentTypes are kept, if empty, a conservative default list is used.
+func ProcessBody(u *models.URL, disableAssetsCapture, domainsCrawl bool, maxHops int, WARCTempDir string, maxInMemorySize int, extractContentTypes []string) error {
 	defer u.GetResponse().Body.Close() // Ensure the response body is closed
 
 	// Retrieve the underlying TCP connection and apply a 10s read deadline
@@ -44,12 +61,17 @@ func ProcessBody(u *models.URL, disableAssetsCapture, domainsCrawl bool, maxHops
 	u.SetMIMEType(mimetype.Detect(buffer.Bytes()))
 
 	// Check if the MIME type requires post-processing
-	if (u.GetMIMEType().Parent() != nil && utils.IsMIMETypeInHierarchy(u.GetMIMEType().Parent(), "text/plain")) ||
-		u.GetMIMEType().Is("application/pdf") ||
-		strings.Contains(u.GetMIMEType().String(), "text/") {
+	if isExtractable(u, extractContentTypes) {
+
+		if maxInMemorySize <= 0 {
+			maxInMemorySize = defaultMaxInMemorySize
+		}
 
-		// Create a temp file with a 2MB memory buffer
-		spooledBuff := spooledtempfile.NewSpooledTempFile("zeno", WARCTempDir, 2097152, false, -1)
+		// If the response announces a body bigger than the threshold, we skip the memory buffer
+		// and stream it straight to the temp file
+		fullOnDisk := u.GetResponse().ContentLength > int64(maxInMemorySize)
+
+		spooledBuff := spooledtempfile.NewSpooledTempFile("zeno", WARCTempDir, maxInMemorySize, fullOnDisk, -1)
 		_, err := io.Copy(spooledBuff, buffer)
 		if err != nil {
 			closeErr := spooledBuff.Close()
@@ -82,6 +104,18 @@ func ProcessBody(u *models.URL, disableAssetsCapture, domainsCrawl bool, maxHops
 	return nil
 }
 
+// isExtractable returns true if the body of the response is eligible for extraction, meaning that
+// either its detected MIME type (or one of its parents, e.g. JSON is text) or its Content-Type
+// matches one of the content types
+func isExtractable(u *models.URL, contentTypes []string) bool {
+	if len(contentTypes) == 0 {
+		contentTypes = defaultExtractContentTypes
+	}
+
+	return utils.MatchMIMEType(u.GetMIMEType(), contentTypes) ||
+		utils.MatchContentType(u.GetResponse().Header.Get("Content-Type"), contentTypes)
+}
+
 // copyWithTimeout copies data and resets the read deadline after each successful read
 func copyWithTimeout(dst io.Writer, src io.Reader, conn interface{ SetReadDeadline(time.Time) error }) error {
 	buf := make([]byte, 4096)
@@ -125,3 +159,17 @@ func copyWithTimeoutN(dst io.Writer, src io.Reader, n int64, conn interface{ Set
 	}
 	return nil
 }
+
+// closeBodies closes the bodies of the items of the seed, every hop of its redirections included, so that
+// their temp files are removed when its archiving is aborted. The postprocessor closes them otherwise.
+func closeBodies(seed *models.Item) {
+	seed.Traverse(func(item *models.Item) {
+		if body := item.GetURL().GetBody(); body != nil {
+			if err := body.Close(); err != nil {
+				logger.Error("unable to close body", "err", err.Error(), "item_id", item.GetShortID())
+			}
+
+			item.GetURL().SetBody(nil)
+		}
+	})
+}
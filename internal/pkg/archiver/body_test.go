@@ -0,0 +1,113 @@
+package archiver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newHTMLURL(t *testing.T, body string, contentLength int64) *models.URL {
+	t.Helper()
+
+	u := &models.URL{Raw: "http://example.com/"}
+	if err := u.Parse(); err != nil {
+		t.Fatalf("unable to parse URL: %v", err)
+	}
+
+	u.SetResponse(&http.Response{
+		StatusCode:    200,
+		Header:        http.Header{"Content-Type": []string{"text/html"}},
+		ContentLength: contentLength,
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+	})
+
+	return u
+}
+
+func TestProcessBodyInMemoryAndOnDisk(t *testing.T) {
+	link := `<a href="http://example.com/page">link</a>`
+	small := "<html><body>" + link + "</body></html>"
+	large := "<html><body>" + link + strings.Repeat("<p>filler</p>", 20000) + "</body></html>"
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		onDisk        bool
+	}{
+		{"small body stays in memory", small, -1, false},
+		{"large body spills to disk", large, -1, true},
+		{"announced large body goes straight to disk", large, int64(len(large)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newHTMLURL(t, tt.body, tt.contentLength)
+
+			if err := ProcessBody(u, false, false, 0, t.TempDir(), 65536, nil); err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+			defer u.GetBody().Close()
+
+			if onDisk := u.GetBody().FileName() != ""; onDisk != tt.onDisk {
+				t.Errorf("expected body on disk to be %v, got %v", tt.onDisk, onDisk)
+			}
+
+			if u.GetBody().Len() != len(tt.body) {
+				t.Errorf("expected body length %d, got %d", len(tt.body), u.GetBody().Len())
+			}
+
+			doc, err := u.GetDocument()
+			if err != nil {
+				t.Fatalf("GetDocument() error = %v", err)
+			}
+
+			if href, _ := doc.Find("a").Attr("href"); href != "http://example.com/page" {
+				t.Errorf("expected link to be extracted, got %q", href)
+			}
+		})
+	}
+}
+
+func TestProcessBodyExtractContentTypes(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg"><image href="/img.png"/></svg>`
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"
+
+	tests := []struct {
+		name         string
+		body         string
+		contentType  string
+		contentTypes []string
+		kept         bool
+	}{
+		{"HTML is kept by default", "<html><body>hello</body></html>", "text/html", nil, true},
+		{"SVG is kept by default", svg, "image/svg+xml", nil, true},
+		{"binary is discarded by default", png, "image/png", nil, false},
+		{"SVG is discarded when not configured", svg, "image/svg+xml", []string{"text/html"}, false},
+		{"configured content type is kept", png, "image/png", []string{"image/*"}, true},
+		{"JSON is kept as text", `{"a": "b"}`, "application/json", []string{"text/*"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newHTMLURL(t, tt.body, -1)
+			u.GetResponse().Header.Set("Content-Type", tt.contentType)
+
+			if err := ProcessBody(u, false, false, 0, t.TempDir(), 0, tt.contentTypes); err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+
+			if kept := u.GetBody() != nil; kept != tt.kept {
+				t.Errorf("expected body kept to be %v, got %v", tt.kept, kept)
+			}
+
+			if u.GetBody() != nil {
+				u.GetBody().Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package archiver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// hostBreaker stops crawling the hosts failing most of their captures, set by --host-breaker-threshold.
+// Once a host failed threshold times within the window, more often than it succeeded, its circuit opens:
+// its seeds are deferred for the cooldown, then one of them is let through to test the host (half-open).
+// The circuit closes if it succeeds and opens again for another cooldown otherwise. Once maxDeferred seeds
+// are deferred, the workers stop taking new seeds until one is let through.
+type hostBreaker struct {
+	threshold   int
+	window      time.Duration
+	cooldown    time.Duration
+	maxDeferred int
+	now         func() time.Time
+
+	mu            sync.Mutex
+	hosts         map[string]*hostCircuit
+	deferred      map[string][]*models.Item
+	deferredCount int
+	lastSweep     time.Time
+
+	// Signaled when the circuit of a host with deferred seeds may let them through
+	wake chan struct{}
+}
+
+type hostCircuit struct {
+	state       breakerState
+	windowStart time.Time
+	failures    int
+	successes   int
+	openedAt    time.Time
+	probeAt     time.Time
+}
+
+// newHostBreaker returns nil if threshold isn't positive, the methods of a nil breaker let everything through
+func newHostBreaker(threshold int, window, cooldown time.Duration, maxDeferred int) *hostBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &hostBreaker{
+		threshold:   threshold,
+		window:      window,
+		cooldown:    cooldown,
+		maxDeferred: maxDeferred,
+		now:         time.Now,
+		hosts:       make(map[string]*hostCircuit),
+		deferred:    make(map[string][]*models.Item),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// allow tells if a capture on the host can be made. When the cooldown of an open circuit is over,
+// the first caller is let through to test the host, the others are refused until it is recorded.
+func (b *hostBreaker) allow(host string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.allowLocked(host, b.now())
+}
+
+func (b *hostBreaker) allowLocked(host string, now time.Time) bool {
+	circuit, found := b.hosts[host]
+	if !found {
+		return true
+	}
+
+	switch circuit.state {
+	case breakerOpen:
+		if now.Sub(circuit.openedAt) < b.cooldown {
+			return false
+		}
+
+		circuit.state = breakerHalfOpen
+		circuit.probeAt = now
+		return true
+	case breakerHalfOpen:
+		// The probe may never be recorded, e.g. if the crawl limit skips it
+		if now.Sub(circuit.probeAt) < b.cooldown {
+			return false
+		}
+
+		circuit.probeAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// record accounts for the outcome of a capture on the host
+func (b *hostBreaker) record(host string, success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.sweep(now)
+
+	// The successes are counted too, the hosts failing a few of many captures aren't tripped
+	circuit, found := b.hosts[host]
+	if !found {
+		circuit = &hostCircuit{windowStart: now}
+		b.hosts[host] = circuit
+	}
+
+	switch circuit.state {
+	case breakerHalfOpen:
+		if success {
+			logger.Info("host recovered, closing its circuit", "host", host, "deferred", len(b.deferred[host]))
+			delete(b.hosts, host)
+			if len(b.deferred[host]) > 0 {
+				b.signal()
+			}
+		} else {
+			logger.Warn("host still failing, keeping its circuit open", "host", host, "cooldown", b.cooldown)
+			b.open(host, circuit, now)
+		}
+	case breakerClosed:
+		if now.Sub(circuit.windowStart) >= b.window {
+			circuit.windowStart = now
+			circuit.failures = 0
+			circuit.successes = 0
+		}
+
+		if success {
+			circuit.successes++
+			return
+		}
+
+		circuit.failures++
+		if circuit.failures >= b.threshold && circuit.failures > circuit.successes {
+			logger.Warn("host failing, opening its circuit", "host", host, "failures", circuit.failures, "successes", circuit.successes, "cooldown", b.cooldown)
+			b.open(host, circuit, now)
+		}
+	}
+}
+
+func (b *hostBreaker) open(host string, circuit *hostCircuit, now time.Time) {
+	circuit.state = breakerOpen
+	circuit.openedAt = now
+
+	// Wake a worker once the cooldown is over to test the host with one of its deferred seeds
+	time.AfterFunc(b.cooldown, b.signal)
+}
+
+// sweep forgets the closed circuits whose window is over, at most once per window
+func (b *hostBreaker) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < b.window {
+		return
+	}
+	b.lastSweep = now
+
+	for host, circuit := range b.hosts {
+		if circuit.state == breakerClosed && now.Sub(circuit.windowStart) >= b.window {
+			delete(b.hosts, host)
+		}
+	}
+}
+
+func (b *hostBreaker) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deferItem keeps the seed of a host whose circuit is open until it lets it through
+func (b *hostBreaker) deferItem(host string, item *models.Item) {
+	b.mu.Lock()
+	b.deferred[host] = append(b.deferred[host], item)
+	b.deferredCount++
+	b.mu.Unlock()
+}
+
+// ready returns the channel signaled when a deferred seed may be let through, nil without breaker
+func (b *hostBreaker) ready() <-chan struct{} {
+	if b == nil {
+		return nil
+	}
+
+	return b.wake
+}
+
+// popReady returns a deferred seed whose host's circuit lets it through
+func (b *hostBreaker) popReady() (item *models.Item, host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	for host, items := range b.deferred {
+		if !b.allowLocked(host, now) {
+			continue
+		}
+
+		item = items[0]
+		if len(items) == 1 {
+			delete(b.deferred, host)
+		} else {
+			b.deferred[host] = items[1:]
+		}
+		b.deferredCount--
+
+		// The other seeds of a closed circuit are ready too, let another worker look
+		if len(b.deferred) > 0 {
+			b.signal()
+		}
+
+		return item, host
+	}
+
+	return nil, ""
+}
+
+// full tells if maxDeferred seeds are deferred, the workers don't take new seeds meanwhile
+func (b *hostBreaker) full() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.deferredCount >= b.maxDeferred
+}
+
+// drain forgets the deferred seeds and returns how many there were, once the workers are stopped
+func (b *hostBreaker) drain() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	drained := b.deferredCount
+	clear(b.deferred)
+	b.deferredCount = 0
+
+	return drained
+}
+
+// HostBreakerState is the state of the circuit of a host
+type HostBreakerState struct {
+	State     string    `json:"state"`
+	Failures  int       `json:"failures"`
+	Successes int       `json:"successes"`
+	OpenedAt  time.Time `json:"opened_at,omitzero"`
+	Deferred  int       `json:"deferred"`
+}
+
+func (b *hostBreaker) states() map[string]HostBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]HostBreakerState, len(b.hosts))
+	for host, circuit := range b.hosts {
+		states[host] = HostBreakerState{
+			State:     circuit.state.String(),
+			Failures:  circuit.failures,
+			Successes: circuit.successes,
+			OpenedAt:  circuit.openedAt,
+			Deferred:  len(b.deferred[host]),
+		}
+	}
+
+	for host, items := range b.deferred {
+		if _, found := states[host]; !found {
+			states[host] = HostBreakerState{State: breakerClosed.String(), Deferred: len(items)}
+		}
+	}
+
+	return states
+}
+
+// GetHostBreakers returns the state of the circuits of the hosts captured recently,
+// nil if the circuit breaker is disabled
+func GetHostBreakers() map[string]HostBreakerState {
+	if globalHostBreaker == nil {
+		return nil
+	}
+
+	return globalHostBreaker.states()
+}
@@ -0,0 +1,198 @@
+package archiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func newTestBreaker(threshold int) (*hostBreaker, *time.Time) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	now := time.Unix(1000, 0)
+	b := newHostBreaker(threshold, time.Minute, 5*time.Minute, 10)
+	b.now = func() time.Time { return now }
+
+	return b, &now
+}
+
+func TestHostBreakerOpens(t *testing.T) {
+	b, _ := newTestBreaker(3)
+
+	b.record("example.com", false)
+	b.record("example.com", false)
+	if !b.allow("example.com") {
+		t.Fatal("expected the circuit to be closed under the threshold")
+	}
+
+	b.record("example.com", false)
+	if b.allow("example.com") {
+		t.Fatal("expected the circuit to open at the threshold")
+	}
+
+	if !b.allow("other.com") {
+		t.Error("expected the other hosts not to be affected")
+	}
+
+	if state := b.states()["example.com"]; state.State != "open" || state.Failures != 3 {
+		t.Errorf("unexpected state %+v", state)
+	}
+}
+
+func TestHostBreakerMostlySucceeding(t *testing.T) {
+	b, _ := newTestBreaker(3)
+
+	for i := 0; i < 10; i++ {
+		b.record("example.com", true)
+	}
+
+	for i := 0; i < 5; i++ {
+		b.record("example.com", false)
+	}
+
+	if !b.allow("example.com") {
+		t.Error("expected the circuit of a host succeeding most of its captures to stay closed")
+	}
+}
+
+func TestHostBreakerWindow(t *testing.T) {
+	b, now := newTestBreaker(3)
+
+	b.record("example.com", false)
+	b.record("example.com", false)
+
+	*now = now.Add(2 * time.Minute)
+	b.record("example.com", false)
+
+	if !b.allow("example.com") {
+		t.Error("expected the failures of the previous window to be forgotten")
+	}
+}
+
+func TestHostBreakerHalfOpen(t *testing.T) {
+	b, now := newTestBreaker(1)
+
+	b.record("example.com", false)
+	if b.allow("example.com") {
+		t.Fatal("expected the circuit to be open")
+	}
+
+	*now = now.Add(5 * time.Minute)
+
+	if !b.allow("example.com") {
+		t.Fatal("expected a probe to be let through once the cooldown is over")
+	}
+
+	if b.allow("example.com") {
+		t.Fatal("expected a single probe to be let through")
+	}
+
+	// The probe fails, the circuit opens for another cooldown
+	b.record("example.com", false)
+	*now = now.Add(time.Minute)
+	if b.allow("example.com") {
+		t.Fatal("expected the circuit to open again after a failed probe")
+	}
+
+	*now = now.Add(5 * time.Minute)
+	if !b.allow("example.com") {
+		t.Fatal("expected a new probe after the cooldown")
+	}
+
+	b.record("example.com", true)
+	if !b.allow("example.com") || !b.allow("example.com") {
+		t.Error("expected the circuit to close after a successful probe")
+	}
+
+	if _, found := b.states()["example.com"]; found {
+		t.Error("expected the recovered host to be forgotten")
+	}
+}
+
+func TestHostBreakerDeferredSeeds(t *testing.T) {
+	b, now := newTestBreaker(1)
+
+	b.record("example.com", false)
+
+	first := newLimitTestItem(t, "http://example.com/a")
+	second := newLimitTestItem(t, "http://example.com/b")
+	b.deferItem("example.com", first)
+	b.deferItem("example.com", second)
+
+	if item, _ := b.popReady(); item != nil {
+		t.Fatal("expected no seed to be ready while the circuit is open")
+	}
+
+	*now = now.Add(5 * time.Minute)
+
+	item, host := b.popReady()
+	if item != first || host != "example.com" {
+		t.Fatalf("expected the first seed to probe the host, got %v", item)
+	}
+
+	if item, _ := b.popReady(); item != nil {
+		t.Fatal("expected the other seeds to wait for the probe")
+	}
+
+	b.record("example.com", true)
+
+	select {
+	case <-b.ready():
+	default:
+		t.Fatal("expected the breaker to signal the seeds left once closed")
+	}
+
+	if item, _ := b.popReady(); item != second {
+		t.Errorf("expected the second seed once the circuit is closed, got %v", item)
+	}
+
+	if b.states()["example.com"].Deferred != 0 {
+		t.Error("expected no seed left")
+	}
+}
+
+func TestHostBreakerDisabled(t *testing.T) {
+	var b *hostBreaker = newHostBreaker(0, time.Minute, time.Minute, 10)
+
+	b.record("example.com", false)
+	if !b.allow("example.com") {
+		t.Error("expected a disabled breaker to let everything through")
+	}
+
+	if b.ready() != nil {
+		t.Error("expected no ready channel without breaker")
+	}
+}
+
+func TestHostBreakerDeferredCap(t *testing.T) {
+	var disabled *hostBreaker
+	if disabled.full() || disabled.drain() != 0 {
+		t.Fatal("expected a disabled breaker to never be full")
+	}
+
+	b, now := newTestBreaker(1)
+	b.maxDeferred = 2
+
+	b.record("example.com", false)
+
+	b.deferItem("example.com", newLimitedSeed(t, "http://example.com/a"))
+	b.deferItem("example.com", newLimitedSeed(t, "http://example.com/b"))
+	if !b.full() {
+		t.Fatal("expected the deferred seeds to be at their cap")
+	}
+
+	// The probe let through after the cooldown makes room for another
+	*now = now.Add(5 * time.Minute)
+	if item, _ := b.popReady(); item == nil || b.full() {
+		t.Fatalf("expected the probe to make room, got %v", item)
+	}
+
+	if drained := b.drain(); drained != 1 {
+		t.Errorf("drain() = %d, want 1", drained)
+	}
+
+	if b.full() || b.states()["example.com"].Deferred != 0 {
+		t.Error("expected no deferred seed left")
+	}
+}
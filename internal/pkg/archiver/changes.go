@@ -0,0 +1,79 @@
+package archiver
+
+import (
+	"path"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/philippgille/gokv/leveldb"
+)
+
+// The change of the payload of a capture since the previous run, with --track-changes
+const (
+	changeNew       = "new"
+	changeChanged   = "changed"
+	changeUnchanged = "unchanged"
+)
+
+// lastCapture is the payload digest of the last capture of a URL
+type lastCapture struct {
+	Digest string `json:"digest"`
+	Date   string `json:"date"`
+}
+
+// changeStore keeps the payload digest of the last capture of the URLs in the job's directory,
+// to tell which URLs changed between runs
+type changeStore struct {
+	db leveldb.Store
+}
+
+var globalChanges *changeStore
+
+func openChangeStore(jobPath string) (*changeStore, error) {
+	db, err := leveldb.NewStore(leveldb.Options{Path: path.Join(jobPath, "digests")})
+	if err != nil {
+		return nil, err
+	}
+
+	return &changeStore{db: db}, nil
+}
+
+func (s *changeStore) close() error {
+	if s == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+// compare returns the change of the payload of the URL since its last capture and remembers its digest.
+// It returns an empty string if the changes aren't tracked or the digest can't be read.
+func (s *changeStore) compare(URL, digest string) string {
+	if s == nil || digest == "" {
+		return ""
+	}
+
+	var last lastCapture
+	found, err := s.db.Get(URL, &last)
+	if err != nil {
+		logger.Error("unable to read last digest", "err", err.Error(), "url", URL)
+		return ""
+	}
+
+	change := changeNew
+	if found {
+		if last.Digest == digest {
+			return changeUnchanged
+		}
+
+		change = changeChanged
+		logger.Info("capture changed since the previous run", "url", URL, "previous_digest", last.Digest, "previous_date", last.Date, "digest", digest)
+		stats.ChangedCapturesIncr()
+	}
+
+	if err := s.db.Set(URL, lastCapture{Digest: digest, Date: time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		logger.Error("unable to save last digest", "err", err.Error(), "url", URL)
+	}
+
+	return change
+}
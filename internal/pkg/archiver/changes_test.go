@@ -0,0 +1,67 @@
+package archiver
+
+import (
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+func TestChangeStore(t *testing.T) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+	stats.Init()
+
+	jobPath := t.TempDir()
+
+	s, err := openChangeStore(jobPath)
+	if err != nil {
+		t.Fatalf("openChangeStore() error = %v", err)
+	}
+
+	if change := s.compare("http://example.com/page", "sha1:AAAA"); change != changeNew {
+		t.Errorf("expected a URL never captured to be %q, got %q", changeNew, change)
+	}
+
+	if change := s.compare("http://example.com/stable", "sha1:BBBB"); change != changeNew {
+		t.Errorf("expected a URL never captured to be %q, got %q", changeNew, change)
+	}
+
+	if change := s.compare("http://example.com/page", ""); change != "" {
+		t.Errorf("expected no change without digest, got %q", change)
+	}
+
+	if err := s.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	// The digests are found by the next run
+	s, err = openChangeStore(jobPath)
+	if err != nil {
+		t.Fatalf("openChangeStore() error = %v", err)
+	}
+	defer s.close()
+
+	changed := stats.ChangedCapturesTotal()
+
+	if change := s.compare("http://example.com/page", "sha1:CCCC"); change != changeChanged {
+		t.Errorf("expected %q, got %q", changeChanged, change)
+	}
+
+	if change := s.compare("http://example.com/stable", "sha1:BBBB"); change != changeUnchanged {
+		t.Errorf("expected %q, got %q", changeUnchanged, change)
+	}
+
+	// The new digest replaces the previous one
+	if change := s.compare("http://example.com/page", "sha1:CCCC"); change != changeUnchanged {
+		t.Errorf("expected %q after the change, got %q", changeUnchanged, change)
+	}
+
+	if total := stats.ChangedCapturesTotal() - changed; total != 1 {
+		t.Errorf("expected 1 changed capture counted, got %d", total)
+	}
+
+	var disabled *changeStore
+	if change := disabled.compare("http://example.com/page", "sha1:AAAA"); change != "" {
+		t.Errorf("expected no change when the changes aren't tracked, got %q", change)
+	}
+}
@@ -0,0 +1,153 @@
+package archiver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// connectionInfo is the metadata of the connection a request was sent on, written by --warc-connection-metadata.
+// The WARC library only writes WARC-IP-Address on the records of the connections that aren't proxied.
+type connectionInfo struct {
+	mu sync.Mutex
+
+	remoteAddr  net.Addr
+	proxied     bool
+	reused      bool
+	tlsVersion  uint16
+	cipherSuite uint16
+	serverName  string
+}
+
+// traceConnection returns the request with a trace filling the returned connectionInfo once a connection is obtained
+func traceConnection(req *http.Request, proxied bool) (*http.Request, *connectionInfo) {
+	info := &connectionInfo{proxied: proxied}
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(got httptrace.GotConnInfo) {
+			info.mu.Lock()
+			defer info.mu.Unlock()
+
+			info.remoteAddr = got.Conn.RemoteAddr()
+			info.reused = got.Reused
+			info.tlsVersion, info.cipherSuite, info.serverName = tlsState(got.Conn)
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), info
+}
+
+// tlsState returns the TLS details of a connection, the zero values for plain connections. The connections
+// of the WARC library embed the TLS one without exposing it, so it is reached by reflection.
+func tlsState(conn net.Conn) (version, cipherSuite uint16, serverName string) {
+	if v := reflect.Indirect(reflect.ValueOf(conn)); v.Kind() == reflect.Struct {
+		if inner := v.FieldByName("Conn"); inner.IsValid() && inner.CanInterface() {
+			if innerConn, ok := inner.Interface().(net.Conn); ok && innerConn != nil {
+				conn = innerConn
+			}
+		}
+	}
+
+	method := reflect.ValueOf(conn).MethodByName("ConnectionState")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return 0, 0, ""
+	}
+
+	state := method.Call(nil)[0]
+	if state.Kind() != reflect.Struct {
+		return 0, 0, ""
+	}
+
+	if field := state.FieldByName("Version"); field.IsValid() && field.CanUint() {
+		version = uint16(field.Uint())
+	}
+
+	if field := state.FieldByName("CipherSuite"); field.IsValid() && field.CanUint() {
+		cipherSuite = uint16(field.Uint())
+	}
+
+	if field := state.FieldByName("ServerName"); field.IsValid() && field.Kind() == reflect.String {
+		serverName = field.String()
+	}
+
+	return version, cipherSuite, serverName
+}
+
+// ipAddress returns the IP address the request was sent to, empty if it went through a proxy:
+// the proxy resolves the target's address and doesn't tell it
+func (c *connectionInfo) ipAddress() string {
+	if c.proxied || c.remoteAddr == nil {
+		return ""
+	}
+
+	if addr, ok := c.remoteAddr.(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+
+	host, _, err := net.SplitHostPort(c.remoteAddr.String())
+	if err != nil {
+		return ""
+	}
+
+	return host
+}
+
+// fields returns the connection metadata in the application/warc-fields format
+func (c *connectionInfo) fields() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+
+	if IP := c.ipAddress(); IP != "" {
+		fmt.Fprintf(&b, "ip-address: %s\r\n", IP)
+	}
+
+	if c.proxied && c.remoteAddr != nil {
+		fmt.Fprintf(&b, "proxy-address: %s\r\n", c.remoteAddr.String())
+	}
+
+	fmt.Fprintf(&b, "connection-reused: %t\r\n", c.reused)
+
+	if c.tlsVersion != 0 {
+		fmt.Fprintf(&b, "tls-version: %s\r\n", tls.VersionName(c.tlsVersion))
+		fmt.Fprintf(&b, "tls-cipher-suite: %s\r\n", tls.CipherSuiteName(c.cipherSuite))
+		if c.serverName != "" {
+			fmt.Fprintf(&b, "tls-server-name: %s\r\n", c.serverName)
+		}
+	}
+
+	return b.String()
+}
+
+// writeConnectionMetadata writes the metadata of the connection the capture of targetURI was made on as a WARC
+// metadata record, with the WARC-IP-Address header when the connection isn't proxied
+func writeConnectionMetadata(w RecordWriter, targetURI string, info *connectionInfo) error {
+	info.mu.Lock()
+	if info.remoteAddr == nil {
+		info.mu.Unlock()
+		return nil
+	}
+	IP := info.ipAddress()
+	info.mu.Unlock()
+
+	record := warc.NewRecord(config.Get().WARCTempDir, config.Get().WARCOnDisk)
+	record.Header.Set("WARC-Type", "metadata")
+	record.Header.Set("WARC-Target-URI", targetURI)
+	record.Header.Set("Content-Type", "application/warc-fields")
+	if IP != "" {
+		record.Header.Set("WARC-IP-Address", IP)
+	}
+
+	record.Content.Write([]byte(info.fields()))
+
+	return w.WriteRecord(record)
+}
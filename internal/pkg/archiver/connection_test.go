@@ -0,0 +1,83 @@
+package archiver
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wrappedConn stands in for the connections of the WARC library, they embed the TLS connection
+type wrappedConn struct {
+	net.Conn
+}
+
+func TestTraceConnectionTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := server.Client()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	req, info := traceConnection(req, false)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if info.ipAddress() != "127.0.0.1" {
+		t.Errorf("expected the IP address of the server, got %q", info.ipAddress())
+	}
+
+	fields := info.fields()
+	for _, want := range []string{"ip-address: 127.0.0.1\r\n", "tls-version: TLS 1.3\r\n", "tls-cipher-suite: TLS_"} {
+		if !strings.Contains(fields, want) {
+			t.Errorf("expected %q in the fields, got %q", want, fields)
+		}
+	}
+}
+
+func TestTLSStateWrappedConn(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), server.Client().Transport.(*http.Transport).TLSClientConfig)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	version, cipherSuite, _ := tlsState(&wrappedConn{Conn: conn})
+	if version != tls.VersionTLS13 || cipherSuite == 0 {
+		t.Errorf("expected the TLS state of the wrapped connection, got version %d and cipher suite %d", version, cipherSuite)
+	}
+
+	if version, _, _ := tlsState(&wrappedConn{Conn: &net.TCPConn{}}); version != 0 {
+		t.Errorf("expected no TLS state for a plain connection, got version %d", version)
+	}
+}
+
+func TestConnectionInfoProxied(t *testing.T) {
+	info := &connectionInfo{
+		proxied:    true,
+		remoteAddr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 3128},
+	}
+
+	if info.ipAddress() != "" {
+		t.Errorf("expected no IP address through a proxy, got %q", info.ipAddress())
+	}
+
+	if fields := info.fields(); !strings.Contains(fields, "proxy-address: 10.0.0.1:3128\r\n") || strings.Contains(fields, "ip-address") {
+		t.Errorf("expected the proxy address only, got %q", fields)
+	}
+}
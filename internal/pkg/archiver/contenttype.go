@@ -0,0 +1,30 @@
+package archiver
+
+import (
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// fetchAllowed returns true if the body of a response with this Content-Type should be downloaded.
+// The deny patterns win over the allow ones, and everything is allowed if there is no allow pattern.
+// Responses without Content-Type are always downloaded, there is nothing to decide on.
+func fetchAllowed(contentType string, allow, deny []string) bool {
+	if strings.TrimSpace(contentType) == "" {
+		return true
+	}
+
+	if utils.MatchContentType(contentType, deny) {
+		return false
+	}
+
+	return len(allow) == 0 || utils.MatchContentType(contentType, allow)
+}
+
+// contentTypeSkipped is the SkipContentType of the WARC clients: the 2xx responses whose Content-Type isn't
+// fetched aren't written to the WARC, the browser's ones too. Redirections and errors are kept.
+func contentTypeSkipped(statusCode int, contentType string) bool {
+	return statusCode >= 200 && statusCode < 300 &&
+		!fetchAllowed(contentType, config.Get().FetchContentTypeAllow, config.Get().FetchContentTypeDeny)
+}
@@ -0,0 +1,142 @@
+package archiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestFetchAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		allow       []string
+		deny        []string
+		want        bool
+	}{
+		{"no lists", "video/mp4", nil, nil, true},
+		{"denied wildcard", "video/mp4", nil, []string{"video/*"}, false},
+		{"not denied", "text/html; charset=utf-8", nil, []string{"video/*"}, true},
+		{"allowed", "image/png", []string{"text/html", "image/*"}, nil, true},
+		{"not allowed", "application/zip", []string{"text/html", "image/*"}, nil, false},
+		{"deny wins over allow", "image/gif", []string{"image/*"}, []string{"image/gif"}, false},
+		{"case insensitive", "Text/HTML", []string{"text/html"}, nil, true},
+		{"no content type", "", []string{"text/html"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchAllowed(tt.contentType, tt.allow, tt.deny); got != tt.want {
+				t.Errorf("fetchAllowed(%q) = %v, want %v", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentTypeSkippedWARC(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, deny []string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().FetchContentTypeDeny = deny
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().FetchContentTypeDeny)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/video":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("frame"))
+		case "/movie":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte(strings.Repeat("frame", 100_000)))
+		case "/moved":
+			w.Header().Set("Content-Type", "video/mp4")
+			http.Redirect(w, r, "/video", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("page"))
+		}
+	}))
+	defer server.Close()
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().FetchContentTypeDeny = []string{"video/*"}
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+	client := globalArchiver.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	// The bodies of the denied content types are closed without being read, like the archiver does. The small
+	// ones are already buffered with the headers, they're skipped all the same.
+	for _, page := range []string{"/page", "/video", "/movie", "/moved"} {
+		resp, err := client.Get(server.URL + page)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", page, err)
+		}
+
+		if skipped := contentTypeSkipped(resp.StatusCode, resp.Header.Get("Content-Type")); skipped != (page == "/video" || page == "/movie") {
+			t.Errorf("contentTypeSkipped(%d, %s) = %t", resp.StatusCode, resp.Header.Get("Content-Type"), skipped)
+		}
+
+		if page == "/video" || page == "/movie" {
+			resp.Body.Close()
+		} else {
+			discardResponse(resp)
+		}
+	}
+
+	client.CloseIdleConnections()
+	client.WaitGroup.Wait()
+	client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+
+	var records strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records.Write(data)
+	}
+
+	for _, want := range []string{"WARC-Target-URI: " + server.URL + "/page", "WARC-Target-URI: " + server.URL + "/moved"} {
+		if !strings.Contains(records.String(), want) {
+			t.Errorf("expected %q in the WARC files", want)
+		}
+	}
+
+	for _, page := range []string{"/video", "/movie"} {
+		if strings.Contains(records.String(), "WARC-Target-URI: "+server.URL+page) {
+			t.Errorf("expected the denied content type of %s to be skipped from the WARC files", page)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package archiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// The negative status codes of the fetch errors, the ones of Heritrix
+const (
+	crawlLogConnectFailed    = -2
+	crawlLogConnectionBroken = -3
+	crawlLogTimeout          = -4
+	crawlLogDomainLookup     = -6
+)
+
+// crawlLogEntry is a capture attempt of --crawl-log, written with the columns of the Heritrix crawl.log:
+// timestamp, status, size, URL, discovery path, via, MIME type, worker, fetch start+duration, digest,
+// source (always "-") and annotations. The JSON lines crawl log of --crawl-log-jsonl is built from it.
+type crawlLogEntry struct {
+	Timestamp   time.Time
+	Status      int
+	Size        int64
+	URL         string
+	Path        string
+	Via         string
+	MIMEType    string
+	WorkerID    string
+	FetchStart  time.Time
+	DurationMs  int64
+	Digest      string
+	Annotations []string
+}
+
+func (e *crawlLogEntry) heritrix() string {
+	return fmt.Sprintf("%s %5d %10d %s %s %s %s #%s %s+%d %s - %s\n",
+		e.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"),
+		e.Status,
+		e.Size,
+		e.URL,
+		orDash(e.Path),
+		orDash(e.Via),
+		orDash(e.MIMEType),
+		heritrixWorkerID(e.WorkerID),
+		heritrixTimestamp(e.FetchStart),
+		e.DurationMs,
+		orDash(e.Digest),
+		orDash(strings.Join(e.Annotations, ",")))
+}
+
+// heritrixTimestamp formats the time as yyyyMMddHHmmssSSS
+func heritrixTimestamp(t time.Time) string {
+	t = t.UTC()
+	return t.Format("20060102150405") + fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// heritrixWorkerID pads the numeric worker ids like the Heritrix thread numbers
+func heritrixWorkerID(workerID string) string {
+	if id, err := strconv.Atoi(workerID); err == nil {
+		return fmt.Sprintf("%03d", id)
+	}
+
+	return workerID
+}
+
+// newCrawlLogEntry builds the crawl log entry of a capture attempt of the item, resp is nil if the fetch failed
+func newCrawlLogEntry(workerID string, item *models.Item, status int, resp *http.Response, size int64, fetchStart time.Time, annotations []string) *crawlLogEntry {
+	entry := &crawlLogEntry{
+		Timestamp:   time.Now().UTC(),
+		Status:      status,
+		Size:        size,
+		URL:         item.GetURL().String(),
+		Path:        discoveryPath(item),
+		Via:         viaURL(item),
+		WorkerID:    workerID,
+		FetchStart:  fetchStart,
+		DurationMs:  time.Since(fetchStart).Milliseconds(),
+		Digest:      item.GetURL().GetDigest(),
+		Annotations: annotations,
+	}
+
+	if resp != nil {
+		entry.MIMEType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+		entry.MIMEType = strings.TrimSpace(entry.MIMEType)
+	}
+
+	return entry
+}
+
+// discoveryPath returns the hops of the item in the notation of Heritrix: L for a link, R for a redirection
+// and E for an embedded asset, a seed has an empty path
+func discoveryPath(item *models.Item) string {
+	var tree []byte
+	for ; !item.IsSeed(); item = item.GetParent() {
+		if item.IsRedirection() {
+			tree = append(tree, 'R')
+		} else {
+			tree = append(tree, 'E')
+		}
+	}
+
+	// The items are walked from the leaf to the seed
+	for i, j := 0, len(tree)-1; i < j; i, j = i+1, j-1 {
+		tree[i], tree[j] = tree[j], tree[i]
+	}
+
+	return strings.Repeat("L", item.GetURL().GetHops()) + string(tree)
+}
+
+// viaURL returns the URL the item was discovered from: its parent, or the source of a seed
+func viaURL(item *models.Item) string {
+	if parent := item.GetParent(); parent != nil {
+		return parent.GetURL().String()
+	}
+
+	return item.GetSeedVia()
+}
+
+// fetchErrorStatus returns the negative status code of a fetch error
+func fetchErrorStatus(err error) int {
+	var DNSErr *net.DNSError
+	if errors.As(err, &DNSErr) {
+		return crawlLogDomainLookup
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return crawlLogTimeout
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return crawlLogConnectFailed
+	}
+
+	return crawlLogConnectionBroken
+}
+
+// crawlLog writes the capture attempts to crawl.log in the job's directory. The lines are buffered and
+// flushed every second, on rotation and when it's closed. The file is rotated once it exceeds maxSize.
+type crawlLog struct {
+	path    string
+	maxSize int64
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+var globalCrawlLog *crawlLog
+
+// openCrawlLog opens the crawl log at path, a maxSize of 0 disables the rotation
+func openCrawlLog(path string, maxSize int64) (*crawlLog, error) {
+	l := &crawlLog{
+		path:    path,
+		maxSize: maxSize,
+		stop:    make(chan struct{}),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	l.wg.Add(1)
+	go l.flusher(time.Second)
+
+	return l, nil
+}
+
+func (l *crawlLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.writer = bufio.NewWriterSize(file, 64*1024)
+	l.size = info.Size()
+
+	return nil
+}
+
+func (l *crawlLog) write(entry *crawlLogEntry) error {
+	if l == nil {
+		return nil
+	}
+
+	line := []byte(entry.heritrix())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return os.ErrClosed
+	}
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.writer.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+// renameFile renames the rotated files, replaced in the tests
+var renameFile = os.Rename
+
+// rotate renames the current file with the rotation time as suffix and starts a new one, l.mu must be held.
+// If the file can't be renamed, the lines keep being appended to it and the rotation is attempted again
+// once maxSize more bytes are written.
+func (l *crawlLog) rotate() error {
+	if err := l.writer.Flush(); err != nil {
+		return err
+	}
+
+	l.file.Close()
+	l.file = nil
+
+	renameErr := renameFile(l.path, l.path+"."+time.Now().UTC().Format("20060102150405.000"))
+	if renameErr != nil && os.IsNotExist(renameErr) {
+		renameErr = nil
+	}
+
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	if renameErr != nil {
+		logger.Error("unable to rotate crawl log, still writing to the current file", "err", renameErr.Error(), "path", l.path)
+		l.size = 0
+	}
+
+	return nil
+}
+
+func (l *crawlLog) flusher(interval time.Duration) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.file != nil {
+				if err := l.writer.Flush(); err != nil {
+					logger.Error("unable to flush crawl log", "err", err.Error())
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// close flushes the lines left and closes the file
+func (l *crawlLog) close() error {
+	if l == nil {
+		return nil
+	}
+
+	close(l.stop)
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := errors.Join(l.writer.Flush(), l.file.Close())
+	l.file = nil
+
+	return err
+}
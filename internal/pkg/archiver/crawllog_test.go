@@ -0,0 +1,214 @@
+package archiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestCrawlLogEntryHeritrix(t *testing.T) {
+	entry := &crawlLogEntry{
+		Timestamp:   time.Date(2024, 3, 1, 12, 30, 45, 123e6, time.UTC),
+		Status:      200,
+		Size:        5120,
+		URL:         "http://example.com/style.css",
+		Path:        "LE",
+		Via:         "http://example.com/",
+		MIMEType:    "text/css",
+		WorkerID:    "7",
+		FetchStart:  time.Date(2024, 3, 1, 12, 30, 45, 23e6, time.UTC),
+		DurationMs:  100,
+		Digest:      "sha1:AAAA",
+		Annotations: []string{"2t", "truncated"},
+	}
+
+	want := "2024-03-01T12:30:45.123Z   200       5120 http://example.com/style.css LE http://example.com/ text/css #007 20240301123045023+100 sha1:AAAA - 2t,truncated\n"
+	if got := entry.heritrix(); got != want {
+		t.Errorf("heritrix() =\n%q\nwant\n%q", got, want)
+	}
+
+	failed := &crawlLogEntry{Status: crawlLogDomainLookup, URL: "http://nope.invalid/", WorkerID: "12"}
+	if fields := strings.Fields(failed.heritrix()); len(fields) != 12 || fields[1] != "-6" || fields[4] != "-" || fields[5] != "-" || fields[7] != "#012" {
+		t.Errorf("unexpected columns for a failed fetch: %q", fields)
+	}
+}
+
+func TestDiscoveryPath(t *testing.T) {
+	seedURL := &models.URL{Raw: "http://example.com/", Hops: 2}
+	if err := seedURL.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	seed := models.NewItem("seed", seedURL, "http://via.example.com/")
+
+	redirection := newLimitTestItem(t, "https://example.com/")
+	if err := seed.AddChild(redirection, models.ItemGotRedirected); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := newLimitTestItem(t, "https://example.com/app.js")
+	if err := redirection.AddChild(asset, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		item     *models.Item
+		wantPath string
+		wantVia  string
+	}{
+		{seed, "LL", "http://via.example.com/"},
+		{redirection, "LLR", "http://example.com/"},
+		{asset, "LLRE", "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		if got := discoveryPath(tt.item); got != tt.wantPath {
+			t.Errorf("discoveryPath(%s) = %q, want %q", tt.item.GetURL().Raw, got, tt.wantPath)
+		}
+		if got := viaURL(tt.item); got != tt.wantVia {
+			t.Errorf("viaURL(%s) = %q, want %q", tt.item.GetURL().Raw, got, tt.wantVia)
+		}
+	}
+}
+
+func TestFetchErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"DNS", fmt.Errorf("get: %w", &net.DNSError{Err: "no such host", Name: "nope.invalid"}), crawlLogDomainLookup},
+		{"timeout", fmt.Errorf("get: %w", context.DeadlineExceeded), crawlLogTimeout},
+		{"refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, crawlLogConnectFailed},
+		{"reset", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, crawlLogConnectionBroken},
+	}
+
+	for _, tt := range tests {
+		if got := fetchErrorStatus(tt.err); got != tt.want {
+			t.Errorf("%s: fetchErrorStatus() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+
+	return lines
+}
+
+func TestCrawlLogRotationAndFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.log")
+
+	entry := newCrawlLogEntry("0", newLimitTestItem(t, "http://example.com/"), http.StatusOK, &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+	}, 10, time.Now(), nil)
+	if entry.MIMEType != "text/html" {
+		t.Errorf("MIMEType = %q, want text/html", entry.MIMEType)
+	}
+
+	lineSize := int64(len(entry.heritrix()))
+
+	// Room for 3 lines per file
+	l, err := openCrawlLog(path, 3*lineSize)
+	if err != nil {
+		t.Fatalf("openCrawlLog() error = %v", err)
+	}
+
+	for range 5 {
+		if err := l.write(entry); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+
+	// The lines left in the buffer are flushed on close
+	if err := l.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	if err := l.write(entry); err == nil {
+		t.Error("expected an error writing to a closed log")
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 1 {
+		t.Fatalf("expected 1 rotated file, got %q", rotated)
+	}
+
+	if lines := countLines(t, rotated[0]); lines != 3 {
+		t.Errorf("rotated file has %d lines, want 3", lines)
+	}
+
+	if lines := countLines(t, path); lines != 2 {
+		t.Errorf("current file has %d lines, want 2", lines)
+	}
+
+	// Without crawl log, nothing happens
+	var disabled *crawlLog
+	if err := disabled.write(entry); err != nil || disabled.close() != nil {
+		t.Error("expected a nil crawl log to be a no-op")
+	}
+}
+
+// The lines keep being written to the current file when it can't be renamed
+func TestCrawlLogFailedRotation(t *testing.T) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous func(oldpath, newpath string) error) {
+		renameFile = previous
+	}(renameFile)
+
+	renameFile = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EACCES}
+	}
+
+	path := filepath.Join(t.TempDir(), "crawl.log")
+
+	entry := newCrawlLogEntry("0", newLimitTestItem(t, "http://example.com/"), http.StatusOK, nil, 10, time.Now(), nil)
+	lineSize := int64(len(entry.heritrix()))
+
+	l, err := openCrawlLog(path, 3*lineSize)
+	if err != nil {
+		t.Fatalf("openCrawlLog() error = %v", err)
+	}
+
+	for range 5 {
+		if err := l.write(entry); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+
+	if err := l.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	if rotated, _ := filepath.Glob(path + ".*"); len(rotated) != 0 {
+		t.Errorf("expected no rotated file, got %q", rotated)
+	}
+
+	if lines := countLines(t, path); lines != 5 {
+		t.Errorf("current file has %d lines, want 5", lines)
+	}
+}
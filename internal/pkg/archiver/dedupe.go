@@ -0,0 +1,266 @@
+package archiver
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/CorentinB/warc"
+)
+
+// The WARC library doesn't let us seed its dedupe table, but it can query a CDX server for each
+// response it writes. A preloaded dedupe index is thus served to it by a local CDX server that
+// answers from the index, and forwards the misses to the remote CDX server if one is configured.
+
+// Default fields of the CDX formats we know about when the file has no header line
+var (
+	legacyCDXFields = []string{"N", "b", "a", "m", "s", "k", "r", "M", "S", "V", "g"}
+	apiCDXFields    = []string{"N", "b", "a", "m", "s", "k", "S"}
+)
+
+type cdxCapture struct {
+	timestamp string
+	URL       string
+	digest    string
+	size      int
+}
+
+type cdxIndex struct {
+	captures map[string]cdxCapture
+
+	// Remote CDX server queried when a URL isn't in the index
+	fallback string
+	cookie   string
+}
+
+var cdxIndexServer *http.Server
+
+// loadCDXIndex reads a CDX or CDXJ index, optionally gzipped, from a local path or an HTTP(S) URL.
+// A CDX API URL (e.g. a /cdx/search/cdx query) works as well as a static file.
+func loadCDXIndex(source string) (*cdxIndex, error) {
+	var reader io.ReadCloser
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unable to fetch dedupe index, status code: %d", resp.StatusCode)
+		}
+
+		reader = resp.Body
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+
+		reader = file
+	}
+	defer reader.Close()
+
+	return parseCDXIndex(reader)
+}
+
+func parseCDXIndex(r io.Reader) (*cdxIndex, error) {
+	buffered := bufio.NewReader(r)
+
+	// Transparently handle gzipped indexes
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+
+		buffered = bufio.NewReader(gzipReader)
+	}
+
+	index := &cdxIndex{
+		captures: make(map[string]cdxCapture),
+	}
+
+	var fields []string
+
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// Header line describing the fields, e.g. " CDX N b a m s k r M S V g"
+		if strings.HasPrefix(line, "CDX ") {
+			fields = strings.Fields(line)[1:]
+			continue
+		}
+
+		capture, ok := parseCDXLine(line, fields)
+		if !ok {
+			continue
+		}
+
+		// Keep the most recent capture of each URL
+		key := cdxIndexKey(capture.URL)
+		if existing, found := index.captures[key]; !found || capture.timestamp > existing.timestamp {
+			index.captures[key] = capture
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// parseCDXLine parses a CDXJ line or a space separated CDX line. Revisits aren't captures
+// that a revisit record can refer to, so they are skipped.
+func parseCDXLine(line string, fields []string) (capture cdxCapture, ok bool) {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) == 3 && strings.HasPrefix(parts[2], "{") {
+		var block struct {
+			URL    string `json:"url"`
+			Digest string `json:"digest"`
+			MIME   string `json:"mime"`
+			Length string `json:"length"`
+		}
+
+		if err := json.Unmarshal([]byte(parts[2]), &block); err != nil || block.MIME == "warc/revisit" {
+			return capture, false
+		}
+
+		capture.timestamp = parts[1]
+		capture.URL = block.URL
+		capture.digest = block.Digest
+		capture.size, _ = strconv.Atoi(block.Length)
+	} else {
+		values := strings.Fields(line)
+
+		if fields == nil {
+			switch len(values) {
+			case len(legacyCDXFields):
+				fields = legacyCDXFields
+			case len(apiCDXFields):
+				fields = apiCDXFields
+			default:
+				return capture, false
+			}
+		}
+
+		if len(values) != len(fields) {
+			return capture, false
+		}
+
+		for i, field := range fields {
+			switch field {
+			case "b":
+				capture.timestamp = values[i]
+			case "a":
+				capture.URL = values[i]
+			case "k":
+				capture.digest = values[i]
+			case "S":
+				capture.size, _ = strconv.Atoi(values[i])
+			case "m":
+				if values[i] == "warc/revisit" {
+					return capture, false
+				}
+			}
+		}
+	}
+
+	// The WARC library compares the base32 digest without its algorithm prefix
+	capture.digest = strings.ToUpper(strings.TrimPrefix(strings.ToLower(capture.digest), "sha1:"))
+
+	if capture.timestamp == "" || capture.URL == "" || capture.digest == "" || capture.digest == "-" {
+		return capture, false
+	}
+
+	return capture, true
+}
+
+// cdxIndexKey normalizes the parts of a URL that aren't case sensitive so that lookups don't depend on them
+func cdxIndexKey(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+
+	return parsed.String()
+}
+
+// ServeHTTP answers the timemap queries of the WARC library with a single CDX line, in the
+// "urlkey timestamp original mimetype statuscode digest length" format it expects.
+func (index *cdxIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if capture, found := index.captures[cdxIndexKey(r.URL.Query().Get("url"))]; found {
+		fmt.Fprintf(w, "- %s %s - - %s %d\n", capture.timestamp, capture.URL, capture.digest, capture.size)
+		return
+	}
+
+	if index.fallback == "" {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, index.fallback+r.URL.RequestURI(), nil)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	if index.cookie != "" {
+		req.Header.Add("Cookie", index.cookie)
+	}
+
+	resp, err := warc.CDXHTTPClient.Do(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// startCDXIndexServer serves the index on a random local port and returns its base URL
+func startCDXIndexServer(index *cdxIndex) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	cdxIndexServer = &http.Server{Handler: index}
+
+	go func() {
+		if err := cdxIndexServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("dedupe index server stopped", "err", err.Error(), "func", "archiver.startCDXIndexServer")
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), nil
+}
+
+func stopCDXIndexServer() {
+	if cdxIndexServer != nil {
+		cdxIndexServer.Close()
+		cdxIndexServer = nil
+	}
+}
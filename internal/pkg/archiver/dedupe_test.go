@@ -0,0 +1,131 @@
+package archiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestParseCDXIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		index string
+		want  map[string]cdxCapture
+	}{
+		{
+			name: "legacy CDX with header",
+			index: ` CDX N b a m s k r M S V g
+com,example)/ 20240101000000 http://example.com/ text/html 200 AAAA - - 1234 0 a.warc.gz
+com,example)/ 20240301000000 http://example.com/ text/html 200 BBBB - - 2345 0 b.warc.gz
+com,example)/ 20240401000000 http://example.com/ warc/revisit - BBBB - - 300 0 c.warc.gz
+`,
+			want: map[string]cdxCapture{
+				"http://example.com/": {timestamp: "20240301000000", URL: "http://example.com/", digest: "BBBB", size: 2345},
+			},
+		},
+		{
+			name: "CDX API output without header",
+			index: `com,example)/a.png 20240101000000 https://EXAMPLE.com/a.png image/png 200 CCCC 42
+com,example)/b.png 20240101000000 https://example.com/b.png image/png 200 - 42
+`,
+			want: map[string]cdxCapture{
+				"https://example.com/a.png": {timestamp: "20240101000000", URL: "https://EXAMPLE.com/a.png", digest: "CCCC", size: 42},
+			},
+		},
+		{
+			name: "CDXJ",
+			index: `com,example)/app.js 20240101000000 {"url": "https://example.com/app.js", "mime": "application/javascript", "status": "200", "digest": "sha1:dddd", "length": "512"}
+com,example)/app.js 20240201000000 {"url": "https://example.com/app.js", "mime": "warc/revisit", "digest": "sha1:DDDD", "length": "100"}
+`,
+			want: map[string]cdxCapture{
+				"https://example.com/app.js": {timestamp: "20240101000000", URL: "https://example.com/app.js", digest: "DDDD", size: 512},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := parseCDXIndex(strings.NewReader(tt.index))
+			if err != nil {
+				t.Fatalf("parseCDXIndex() error = %v", err)
+			}
+
+			if len(index.captures) != len(tt.want) {
+				t.Fatalf("parseCDXIndex() = %+v, want %+v", index.captures, tt.want)
+			}
+
+			for key, want := range tt.want {
+				if got := index.captures[key]; got != want {
+					t.Errorf("capture of %s = %+v, want %+v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCDXIndexGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	gzipWriter.Write([]byte("com,example)/ 20240101000000 http://example.com/ text/html 200 AAAA 1234\n"))
+	gzipWriter.Close()
+
+	indexPath := path.Join(t.TempDir(), "index.cdx.gz")
+	if err := os.WriteFile(indexPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := loadCDXIndex(indexPath)
+	if err != nil {
+		t.Fatalf("loadCDXIndex() error = %v", err)
+	}
+
+	if _, found := index.captures["http://example.com/"]; !found {
+		t.Errorf("expected the capture to be loaded, got %+v", index.captures)
+	}
+}
+
+func TestCDXIndexServeHTTP(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "token=1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		io.WriteString(w, "com,example)/remote 20230101000000 http://example.com/remote text/html 200 REMOTE 10\n")
+	}))
+	defer remote.Close()
+
+	index, err := parseCDXIndex(strings.NewReader("com,example)/ 20240101000000 http://example.com/ text/html 200 AAAA 1234\n"))
+	if err != nil {
+		t.Fatalf("parseCDXIndex() error = %v", err)
+	}
+
+	query := func(rawURL string) string {
+		w := httptest.NewRecorder()
+		index.ServeHTTP(w, httptest.NewRequest("GET", "/web/timemap/cdx?url="+url.QueryEscape(rawURL)+"&limit=-1", nil))
+		return w.Body.String()
+	}
+
+	// The WARC library reads the timestamp, original URL and digest from the 2nd, 3rd and 6th fields
+	fields := strings.Fields(query("http://example.com/#top"))
+	if len(fields) != 7 || fields[1] != "20240101000000" || fields[2] != "http://example.com/" || fields[5] != "AAAA" || fields[6] != "1234" {
+		t.Errorf("unexpected CDX line for an indexed URL: %q", fields)
+	}
+
+	if got := query("http://example.com/remote"); got != "" {
+		t.Errorf("expected an empty reply without a fallback, got %q", got)
+	}
+
+	index.fallback = remote.URL
+	index.cookie = "token=1"
+	if got := query("http://example.com/remote"); !strings.Contains(got, "REMOTE") {
+		t.Errorf("expected the reply of the fallback CDX server, got %q", got)
+	}
+}
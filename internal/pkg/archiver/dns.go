@@ -0,0 +1,231 @@
+package archiver
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/miekg/dns"
+)
+
+// The errors of the resolver of the WARC client, it doesn't return *net.DNSError
+var resolverErrors = []string{
+	"failed to resolve DNS",
+	"no suitable IP address found",
+	"no DNS servers configured",
+}
+
+// dnsFailure returns the error of a request as a *net.DNSError if it's a resolution failure,
+// so that it's classified as such by fetchErrorStatus. The other errors are returned as is.
+// A failure is only taken for a host that doesn't resolve when both its A and AAAA lookups got a reply
+// without address and confirmNotFound confirms it, the other ones are temporary: a timeout, a server
+// failure or an unreachable server don't tell anything about the host.
+func dnsFailure(ctx context.Context, host string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var DNSErr *net.DNSError
+	if errors.As(err, &DNSErr) {
+		return err
+	}
+
+	message := err.Error()
+	for _, resolverError := range resolverErrors {
+		if strings.Contains(message, resolverError) {
+			notFound := strings.Contains(message, "A error: no TYPE=A record found") &&
+				strings.Contains(message, "AAAA error: no TYPE=AAAA record found") &&
+				confirmNotFound(ctx, host)
+
+			return &net.DNSError{
+				Err:         message,
+				Name:        host,
+				IsTimeout:   strings.Contains(message, "timeout"),
+				IsNotFound:  notFound,
+				IsTemporary: !notFound,
+			}
+		}
+	}
+
+	return err
+}
+
+// The DNS configuration of the system, loaded once by loadResolvConf when the WARC writer starts. The WARC
+// clients query its servers, or the ones of --dns-servers, on its port.
+var resolvConf *dns.ClientConfig
+
+func loadResolvConf() (err error) {
+	resolvConf, err = dns.ClientConfigFromFile("/etc/resolv.conf")
+	return err
+}
+
+// confirmNotFound queries the DNS servers of the WARC clients for the addresses of the host, and tells
+// if they answer that it doesn't exist (NXDOMAIN) or has no address (NOERROR without answer). The WARC
+// client reports any reply without address the same way, whatever its response code.
+var confirmNotFound = func(ctx context.Context, host string) bool {
+	if resolvConf == nil {
+		return false
+	}
+
+	servers := resolvConf.Servers
+	if len(config.Get().DNSServers) > 0 {
+		servers = config.Get().DNSServers
+	}
+
+	client := &dns.Client{Net: "udp", Timeout: config.Get().DNSTimeout}
+
+	for _, server := range servers {
+		replied := true
+
+		for _, recordType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			query := new(dns.Msg)
+			query.SetQuestion(dns.Fqdn(host), recordType)
+
+			reply, _, err := client.ExchangeContext(ctx, query, net.JoinHostPort(server, resolvConf.Port))
+			if err != nil {
+				replied = false
+				break
+			}
+
+			if reply.Rcode == dns.RcodeNameError {
+				return true
+			}
+
+			if reply.Rcode != dns.RcodeSuccess || hasAddress(reply, recordType) {
+				return false
+			}
+		}
+
+		// Both lookups got NOERROR without address, a server that didn't reply is skipped
+		if replied {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAddress(reply *dns.Msg, recordType uint16) bool {
+	for _, answer := range reply.Answer {
+		if answer.Header().Rrtype == recordType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uncachedHosts returns the UncachedHost of the WARC clients, nil if the addresses of every host are cached:
+// no host is cached with a --dns-cache-ttl of 0, the hosts of --dns-no-cache-hosts and their subdomains aren't
+// otherwise. The WARC client replaces a TTL of 0 by its default one.
+func uncachedHosts(TTL time.Duration, hosts []string) func(host string) bool {
+	switch {
+	case TTL == 0:
+		return func(string) bool { return true }
+	case len(hosts) > 0:
+		return func(host string) bool { return hostMatches(host, hosts) }
+	}
+
+	return nil
+}
+
+// isDNSNotFound tells if the error is a resolution failure that isn't worth retrying:
+// the host doesn't exist or has no address
+func isDNSNotFound(err error) bool {
+	var DNSErr *net.DNSError
+	return errors.As(err, &DNSErr) && DNSErr.IsNotFound
+}
+
+// dnsNegativeCache remembers the hosts that failed to resolve for a short time, set by
+// --dns-negative-cache-ttl, so that their next URLs fail without querying the DNS servers again.
+// The addresses resolved successfully are cached by the WARC client itself. When it's full, the
+// least recently used host is forgotten.
+type dnsNegativeCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*list.Element
+	lru   *list.List
+}
+
+type dnsNegativeEntry struct {
+	host    string
+	expires time.Time
+}
+
+// newDNSNegativeCache returns nil if ttl isn't positive, the methods of a nil cache never fail a host
+func newDNSNegativeCache(ttl time.Duration, size int) *dnsNegativeCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &dnsNegativeCache{
+		ttl:   ttl,
+		size:  max(size, 1),
+		now:   time.Now,
+		hosts: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// failed returns the cached resolution failure of the host, or nil if it isn't cached
+func (c *dnsNegativeCache) failed(host string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.hosts[host]
+	if !found {
+		return nil
+	}
+
+	if !c.now().Before(element.Value.(*dnsNegativeEntry).expires) {
+		c.lru.Remove(element)
+		delete(c.hosts, host)
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+
+	return &net.DNSError{
+		Err:        "no such host (cached)",
+		Name:       host,
+		IsNotFound: true,
+	}
+}
+
+// record caches the resolution failure of the host
+func (c *dnsNegativeCache) record(host string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	if element, found := c.hosts[host]; found {
+		element.Value.(*dnsNegativeEntry).expires = expires
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.hosts[host] = c.lru.PushFront(&dnsNegativeEntry{host: host, expires: expires})
+
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.hosts, oldest.Value.(*dnsNegativeEntry).host)
+	}
+}
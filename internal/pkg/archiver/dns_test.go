@@ -0,0 +1,346 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/miekg/dns"
+)
+
+// stubConfirmNotFound replaces the DNS queries confirming that a host doesn't resolve for the test
+func stubConfirmNotFound(t *testing.T, confirmed bool) {
+	t.Helper()
+
+	previous := confirmNotFound
+	confirmNotFound = func(context.Context, string) bool { return confirmed }
+	t.Cleanup(func() { confirmNotFound = previous })
+}
+
+func TestDNSFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		confirmed bool
+		status    int
+		notFound  bool
+	}{
+		{
+			name:      "no record",
+			err:       &url.Error{Op: "Get", URL: "http://nx.example/", Err: errors.New("failed to resolve DNS: A error: no TYPE=A record found, AAAA error: no TYPE=AAAA record found")},
+			confirmed: true,
+			status:    crawlLogDomainLookup,
+			notFound:  true,
+		},
+		{
+			name:   "no record, not confirmed",
+			err:    &url.Error{Op: "Get", URL: "http://servfail.example/", Err: errors.New("failed to resolve DNS: A error: no TYPE=A record found, AAAA error: no TYPE=AAAA record found")},
+			status: crawlLogDomainLookup,
+		},
+		{
+			name:      "server unreachable",
+			err:       errors.New("failed to resolve DNS: A error: read udp 127.0.0.1:53: connection refused, AAAA error: no TYPE=AAAA record found"),
+			confirmed: true,
+			status:    crawlLogDomainLookup,
+		},
+		{
+			name:   "timeout",
+			err:    &url.Error{Op: "Get", URL: "http://slow.example/", Err: errors.New("failed to resolve DNS: A error: read udp: i/o timeout, AAAA error: read udp: i/o timeout")},
+			status: crawlLogDomainLookup,
+		},
+		{
+			// With one IP version disabled, the error of the other lookup isn't reported
+			name:      "no address",
+			err:       fmt.Errorf("no suitable IP address found for v6only.example"),
+			confirmed: true,
+			status:    crawlLogDomainLookup,
+		},
+		{
+			name:   "connection refused",
+			err:    errors.New("connection refused"),
+			status: crawlLogConnectionBroken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubConfirmNotFound(t, tt.confirmed)
+
+			err := dnsFailure(context.Background(), "example.com", tt.err)
+
+			if status := fetchErrorStatus(err); status != tt.status {
+				t.Errorf("fetchErrorStatus() = %d, want %d", status, tt.status)
+			}
+
+			if notFound := isDNSNotFound(err); notFound != tt.notFound {
+				t.Errorf("isDNSNotFound() = %v, want %v", notFound, tt.notFound)
+			}
+
+			var DNSErr *net.DNSError
+			if errors.As(err, &DNSErr) && DNSErr.IsTemporary == tt.notFound {
+				t.Errorf("expected IsTemporary to be %v", !tt.notFound)
+			}
+		})
+	}
+
+	if dnsFailure(context.Background(), "example.com", nil) != nil {
+		t.Error("expected no error without error")
+	}
+}
+
+// The DNS servers are queried on port 53, like the WARC client does
+func TestConfirmNotFound(t *testing.T) {
+	config.InitConfig()
+
+	defer func(DNSServers []string, timeout time.Duration) {
+		config.Get().DNSServers = DNSServers
+		config.Get().DNSTimeout = timeout
+	}(config.Get().DNSServers, config.Get().DNSTimeout)
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:53")
+	if err != nil {
+		t.Skipf("unable to serve the DNS records on port 53: %v", err)
+	}
+
+	server := &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+		reply := emptyReply(query)
+
+		switch query.Question[0].Name {
+		case "nx.test.":
+			reply.Rcode = dns.RcodeNameError
+		case "servfail.test.":
+			reply.Rcode = dns.RcodeServerFailure
+		case "v6only.test.":
+			if query.Question[0].Qtype == dns.TypeAAAA {
+				reply = pinnedReply(query, net.ParseIP("2001:db8::1"))
+			}
+		}
+
+		w.WriteMsg(reply)
+	})}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	defer func(previous *dns.ClientConfig) {
+		resolvConf = previous
+	}(resolvConf)
+
+	resolvConf = &dns.ClientConfig{Port: "53"}
+
+	config.Get().DNSServers = []string{"127.0.0.1"}
+	config.Get().DNSTimeout = time.Second
+
+	tests := []struct {
+		host      string
+		confirmed bool
+	}{
+		{host: "nx.test", confirmed: true},
+		{host: "nodata.test", confirmed: true},
+		{host: "servfail.test", confirmed: false},
+		{host: "v6only.test", confirmed: false},
+	}
+
+	for _, tt := range tests {
+		if confirmed := confirmNotFound(context.Background(), tt.host); confirmed != tt.confirmed {
+			t.Errorf("confirmNotFound(%s) = %v, want %v", tt.host, confirmed, tt.confirmed)
+		}
+	}
+
+	// A server that doesn't reply confirms nothing
+	config.Get().DNSServers = []string{"127.0.0.2"}
+	config.Get().DNSTimeout = 100 * time.Millisecond
+
+	if confirmNotFound(context.Background(), "nx.test") {
+		t.Error("expected no confirmation without reply")
+	}
+}
+
+func TestDNSNegativeCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := newDNSNegativeCache(30*time.Second, 2)
+	c.now = func() time.Time { return now }
+
+	if c.failed("nx.example") != nil {
+		t.Fatal("expected an unknown host not to fail")
+	}
+
+	c.record("nx.example")
+
+	err := c.failed("nx.example")
+	if err == nil {
+		t.Fatal("expected the cached host to fail")
+	}
+
+	if fetchErrorStatus(err) != crawlLogDomainLookup || !isDNSNotFound(err) {
+		t.Errorf("expected the cached failure to be a DNS not found error, got %v", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if c.failed("nx.example") != nil {
+		t.Error("expected the failure to expire after the TTL")
+	}
+
+	// The least recently used entry is evicted when the cache is full
+	c.record("a.example")
+	now = now.Add(time.Second)
+	c.record("b.example")
+	c.failed("a.example")
+	c.record("c.example")
+
+	if c.failed("b.example") != nil {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+
+	if c.failed("a.example") == nil || c.failed("c.example") == nil {
+		t.Error("expected the recently used entries to be kept")
+	}
+
+	disabled := newDNSNegativeCache(0, 10)
+	disabled.record("nx.example")
+	if disabled.failed("nx.example") != nil {
+		t.Error("expected a disabled cache never to fail a host")
+	}
+}
+
+func TestUncachedHosts(t *testing.T) {
+	if uncached := uncachedHosts(time.Minute, nil); uncached != nil {
+		t.Error("expected every host to be cached")
+	}
+
+	if uncached := uncachedHosts(0, nil); uncached == nil || !uncached("example.com") {
+		t.Error("expected no host to be cached with a TTL of 0")
+	}
+
+	uncached := uncachedHosts(time.Minute, []string{"rr.example.com"})
+	for host, want := range map[string]bool{"rr.example.com": true, "a.RR.example.com": true, "example.com": false} {
+		if got := uncached(host); got != want {
+			t.Errorf("uncached(%s) = %t, want %t", host, got, want)
+		}
+	}
+}
+
+// The DNS servers are queried on port 53, like the WARC client does
+func TestDNSCache(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, DNSServers []string, TTL, stale time.Duration, noCache []string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().DNSServers = DNSServers
+		config.Get().DNSCacheTTL = TTL
+		config.Get().DNSCacheStale = stale
+		config.Get().DNSNoCacheHosts = noCache
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().DNSServers, config.Get().DNSCacheTTL, config.Get().DNSCacheStale, config.Get().DNSNoCacheHosts)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:53")
+	if err != nil {
+		t.Skipf("unable to serve the DNS records on port 53: %v", err)
+	}
+
+	var mu sync.Mutex
+	queries := make(map[string]int)
+
+	DNSServer := &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if query.Question[0].Qtype == dns.TypeA {
+			queries[query.Question[0].Name]++
+		}
+
+		// stale.test only resolves once, its stale address is used after
+		if query.Question[0].Name == "stale.test." && queries["stale.test."] > 1 {
+			w.WriteMsg(emptyReply(query))
+			return
+		}
+
+		w.WriteMsg(pinnedReply(query, net.ParseIP("127.0.0.1")))
+	})}
+	go DNSServer.ActivateAndServe()
+	defer DNSServer.Shutdown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	tests := []struct {
+		name    string
+		host    string
+		TTL     time.Duration
+		stale   time.Duration
+		noCache []string
+		queries int
+	}{
+		{name: "cached", host: "cached.test", TTL: time.Minute, queries: 1},
+		{name: "cache disabled", host: "disabled.test", queries: 2},
+		{name: "uncached host", host: "rr.test", TTL: time.Minute, noCache: []string{"rr.test"}, queries: 2},
+		// The second connection uses the stale address while the host is resolved again in the background
+		{name: "stale", host: "stale.test", TTL: time.Millisecond, stale: time.Minute, queries: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Get().JobPath = t.TempDir()
+			config.Get().WARCPoolSize = 1
+			config.Get().WARCCompression = "none"
+			config.Get().DNSServers = []string{"127.0.0.1"}
+			config.Get().DNSCacheTTL = tt.TTL
+			config.Get().DNSCacheStale = tt.stale
+			config.Get().DNSNoCacheHosts = tt.noCache
+
+			if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+			startWARCWriter()
+			client := globalArchiver.Client
+
+			for range 2 {
+				resp, err := client.Get("http://" + net.JoinHostPort(tt.host, port) + "/")
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			// The revalidations are done before the client closes
+			client.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got := queries[tt.host+"."]; got != tt.queries {
+				t.Errorf("expected %d A queries for %s, got %d", tt.queries, tt.host, got)
+			}
+		})
+	}
+}
@@ -5,4 +5,10 @@ import "errors"
 var (
 	// ErrArchiverAlreadyInitialized is the error returned when the preprocess is already initialized
 	ErrArchiverAlreadyInitialized = errors.New("archiver already initialized")
+	// ErrNoWARCClient is the error returned when no WARC writing client is available
+	ErrNoWARCClient = errors.New("no WARC writing client available")
+	// ErrResponseTooLarge is the error reported when a response body is truncated at --max-response-body-size
+	ErrResponseTooLarge = errors.New("response body bigger than the maximum size")
+	// ErrExcluded is the error reported when a seed is excluded by the filters once taken from the queue
+	ErrExcluded = errors.New("excluded by the crawl filters")
 )
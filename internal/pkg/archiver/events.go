@@ -0,0 +1,196 @@
+package archiver
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// crawlEvent is the line of the JSON lines crawl log written for each capture attempt, failures included
+// like in crawl.log: the status is the negative code of the error if the fetch failed.
+// The WARC library doesn't expose where it writes the records, so the WARC file name
+// and offset of the capture aren't part of it.
+type crawlEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Bytes       int64     `json:"bytes"`
+	DurationMs  int64     `json:"duration_ms"`
+	Hops        int       `json:"hops"`
+	Path        string    `json:"path,omitempty"` // Discovery path, like in crawl.log
+	Parent      string    `json:"parent,omitempty"`
+	Seed        string    `json:"seed,omitempty"`
+	WorkerID    string    `json:"worker_id,omitempty"`
+	Digest      string    `json:"digest,omitempty"`
+	Truncated   bool      `json:"truncated,omitempty"`
+	Change      string    `json:"change,omitempty"` // new, changed or unchanged with --track-changes
+	Annotations []string  `json:"annotations,omitempty"`
+}
+
+// crawlEventLog writes the crawl events to a JSON lines file, rotated with the WARC files
+type crawlEventLog struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+var globalCrawlEventLog *crawlEventLog
+
+func openCrawlEventLog(path string) (*crawlEventLog, error) {
+	l := &crawlEventLog{path: path}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *crawlEventLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	l.file = file
+	l.encoder = json.NewEncoder(file)
+
+	return nil
+}
+
+func (l *crawlEventLog) write(event *crawlEvent) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return os.ErrClosed
+	}
+
+	return l.encoder.Encode(event)
+}
+
+// rotate renames the current file with the rotation time as suffix and starts a new one
+func (l *crawlEventLog) rotate() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+
+	if err := os.Rename(l.path, l.path+"."+time.Now().UTC().Format("20060102150405")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return l.open()
+}
+
+func (l *crawlEventLog) close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
+
+// newCrawlEvent builds the crawl event of a capture attempt of the item from its crawl.log entry,
+// resp is nil if the fetch failed
+func newCrawlEvent(entry *crawlLogEntry, item *models.Item, resp *http.Response, duration time.Duration, truncated bool) *crawlEvent {
+	event := &crawlEvent{
+		Timestamp:   entry.Timestamp,
+		URL:         entry.URL,
+		Status:      entry.Status,
+		Bytes:       entry.Size,
+		DurationMs:  duration.Milliseconds(),
+		Hops:        item.GetURL().GetHops(),
+		Path:        entry.Path,
+		WorkerID:    entry.WorkerID,
+		Digest:      entry.Digest,
+		Truncated:   truncated,
+		Annotations: entry.Annotations,
+	}
+
+	if resp != nil {
+		event.ContentType = resp.Header.Get("Content-Type")
+	}
+
+	if parent := item.GetParent(); parent != nil {
+		event.Parent = parent.GetURL().String()
+	}
+
+	if !item.IsSeed() {
+		event.Seed = item.GetSeed().GetURL().String()
+	}
+
+	return event
+}
+
+// countingBody counts the bytes read from a response body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+	end  time.Time // When the body was read to the end
+}
+
+func (b *countingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	if err == io.EOF && b.end.IsZero() {
+		b.end = time.Now()
+	}
+
+	return n, err
+}
+
+// payloadDigest returns the SHA-1 hashed in the format of the WARC-Payload-Digest header
+func payloadDigest(payloadHash hash.Hash) string {
+	return "sha1:" + base32.StdEncoding.EncodeToString(payloadHash.Sum(nil))
+}
+
+// SetReadDeadline forwards the read deadline to the underlying body, ProcessBody relies on it
+func (b *countingBody) SetReadDeadline(t time.Time) error {
+	return setReadDeadline(b.ReadCloser, t)
+}
+
+func setReadDeadline(body io.Reader, t time.Time) error {
+	if conn, ok := body.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return conn.SetReadDeadline(t)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package archiver
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func readCrawlEvents(t *testing.T, path string) (events []crawlEvent) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event crawlEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestCrawlEventLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "crawl.jsonl")
+
+	l, err := openCrawlEventLog(path)
+	if err != nil {
+		t.Fatalf("openCrawlEventLog() error = %v", err)
+	}
+
+	if err := l.write(&crawlEvent{URL: "http://example.com/1"}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if err := l.rotate(); err != nil {
+		t.Fatalf("rotate() error = %v", err)
+	}
+
+	if err := l.write(&crawlEvent{URL: "http://example.com/2"}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if err := l.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	if err := l.write(&crawlEvent{URL: "http://example.com/3"}); err == nil {
+		t.Error("expected an error writing to a closed log")
+	}
+
+	if events := readCrawlEvents(t, path); len(events) != 1 || events[0].URL != "http://example.com/2" {
+		t.Errorf("expected the current file to hold the event written after the rotation, got %+v", events)
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 1 {
+		t.Fatalf("expected 1 rotated file, got %q", rotated)
+	}
+
+	if events := readCrawlEvents(t, rotated[0]); len(events) != 1 || events[0].URL != "http://example.com/1" {
+		t.Errorf("expected the rotated file to hold the event written before the rotation, got %+v", events)
+	}
+
+	// Without crawl log, nothing happens
+	var disabled *crawlEventLog
+	if err := disabled.write(&crawlEvent{}); err != nil || disabled.rotate() != nil || disabled.close() != nil {
+		t.Error("expected a nil crawl log to be a no-op")
+	}
+}
+
+func TestNewCrawlEvent(t *testing.T) {
+	parentURL := &models.URL{Raw: "http://example.com/"}
+	if err := parentURL.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	URL := &models.URL{Raw: "http://example.com/style.css", Hops: 1}
+	if err := URL.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := &countingBody{ReadCloser: io.NopCloser(strings.NewReader("body{}"))}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/css"}},
+		Body:       body,
+	}
+	URL.SetResponse(resp)
+
+	if _, err := io.ReadAll(body); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := models.NewItem("parent", parentURL, "")
+	item := models.NewItem("item", URL, "")
+	if err := parent.AddChild(item, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := newCrawlLogEntry("3", item, http.StatusOK, resp, body.read, time.Now(), []string{"2t"})
+	event := newCrawlEvent(entry, item, resp, 1500*time.Millisecond, false)
+
+	want := crawlEvent{
+		Timestamp:   entry.Timestamp,
+		URL:         "http://example.com/style.css",
+		Status:      http.StatusOK,
+		ContentType: "text/css",
+		Bytes:       6,
+		DurationMs:  1500,
+		Hops:        1,
+		Path:        "E",
+		Parent:      "http://example.com/",
+		Seed:        "http://example.com/",
+		WorkerID:    "3",
+		Annotations: []string{"2t"},
+	}
+
+	if !reflect.DeepEqual(*event, want) {
+		t.Errorf("newCrawlEvent() = %+v, want %+v", *event, want)
+	}
+
+	// A failed fetch has the negative status code of its error and no content type
+	failed := newCrawlEvent(newCrawlLogEntry("3", item, crawlLogDomainLookup, nil, 0, time.Now(), nil), item, nil, time.Second, false)
+	if failed.Status != crawlLogDomainLookup || failed.ContentType != "" || failed.Bytes != 0 {
+		t.Errorf("unexpected event for a failed fetch: %+v", *failed)
+	}
+}
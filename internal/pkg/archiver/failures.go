@@ -0,0 +1,203 @@
+package archiver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// The reasons of the permanent failures
+const (
+	failureDNS        = "dns"
+	failureTimeout    = "timeout"
+	failureConnect    = "connect"
+	failureConnection = "connection"
+	failureStatus     = "http_status"
+	failureBody       = "body"
+	failureTooLarge   = "too_large"
+	failureExcluded   = "excluded"
+)
+
+// maxRecentFailures is the number of failures kept in memory for the API
+const maxRecentFailures = 1000
+
+// Failure is the final disposition of a URL that permanently failed, once its retries are exhausted
+type Failure struct {
+	Timestamp time.Time `json:"timestamp"`
+	URL       string    `json:"url"`
+	Reason    string    `json:"reason"`
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+	Hops      int       `json:"hops"`
+	Seed      string    `json:"seed,omitempty"`
+}
+
+// failureReport keeps the most recent failures in memory and, with --failure-report, appends them all
+// to failures.jsonl in the job's directory
+type failureReport struct {
+	mu      sync.Mutex
+	recent  []Failure // Ring of the most recent failures, next is the oldest once it's full
+	next    int
+	file    *os.File
+	encoder *json.Encoder
+}
+
+var globalFailures *failureReport
+
+// newFailureReport keeps up to keep failures in memory, they are appended to path too if not empty
+func newFailureReport(path string, keep int) (*failureReport, error) {
+	r := &failureReport{recent: make([]Failure, 0, keep)}
+
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		r.file = file
+		r.encoder = json.NewEncoder(file)
+	}
+
+	return r, nil
+}
+
+// fetchFailureReason classifies the error of a request whose retries are exhausted,
+// or the one of a URL that wasn't entirely captured
+func fetchFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrResponseTooLarge):
+		return failureTooLarge
+	case errors.Is(err, ErrExcluded):
+		return failureExcluded
+	}
+
+	switch fetchErrorStatus(err) {
+	case crawlLogDomainLookup:
+		return failureDNS
+	case crawlLogTimeout:
+		return failureTimeout
+	case crawlLogConnectFailed:
+		return failureConnect
+	default:
+		return failureConnection
+	}
+}
+
+func newFailure(item *models.Item, reason string, status int, err error, attempts int) Failure {
+	failure := Failure{
+		Timestamp: time.Now().UTC(),
+		URL:       item.GetURL().String(),
+		Reason:    reason,
+		Status:    status,
+		Attempts:  attempts,
+		Hops:      item.GetURL().GetHops(),
+	}
+
+	if err != nil {
+		failure.Error = err.Error()
+	}
+
+	if !item.IsSeed() {
+		failure.Seed = item.GetSeed().GetURL().String()
+	}
+
+	return failure
+}
+
+// add records the failure of the item
+func (r *failureReport) add(item *models.Item, reason string, status int, err error, attempts int) error {
+	if r == nil {
+		return nil
+	}
+
+	failure := newFailure(item, reason, status, err, attempts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.recent) < cap(r.recent) {
+		r.recent = append(r.recent, failure)
+	} else if cap(r.recent) > 0 {
+		r.recent[r.next] = failure
+		r.next = (r.next + 1) % cap(r.recent)
+	}
+
+	if r.encoder == nil {
+		return nil
+	}
+
+	return r.encoder.Encode(failure)
+}
+
+// reportFailure records the permanent failure of the item, it is logged if it can't be written
+func reportFailure(item *models.Item, reason string, status int, err error, attempts int) {
+	if writeErr := globalFailures.add(item, reason, status, err, attempts); writeErr != nil {
+		logger.Error("unable to write failure report", "err", writeErr.Error(), "item_id", item.GetShortID())
+	}
+}
+
+// ReportExcluded records the seed excluded by the filters once taken from the queue,
+// the reason says which filters excluded it
+func ReportExcluded(item *models.Item, reason string) {
+	err := fmt.Errorf("%w: %s", ErrExcluded, reason)
+	reportFailure(item, fetchFailureReason(err), 0, err, 0)
+}
+
+// last returns up to limit of the most recent failures, the newest first
+func (r *failureReport) last(limit int) []Failure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 || limit > len(r.recent) {
+		limit = len(r.recent)
+	}
+
+	failures := make([]Failure, 0, limit)
+	for i := 0; i < limit; i++ {
+		// The newest is right before next, the index of the oldest once the ring is full
+		index := (r.next - 1 - i + 2*len(r.recent)) % len(r.recent)
+		failures = append(failures, r.recent[index])
+	}
+
+	return failures
+}
+
+func (r *failureReport) close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	r.encoder = nil
+
+	return err
+}
+
+// GetRecentFailures returns up to limit of the most recent permanent failures, the newest first,
+// nil if the archiver isn't started
+func GetRecentFailures(limit int) []Failure {
+	if globalFailures == nil {
+		return nil
+	}
+
+	return globalFailures.last(limit)
+}
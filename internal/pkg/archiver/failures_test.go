@@ -0,0 +1,141 @@
+package archiver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailureReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job", "failures.jsonl")
+
+	r, err := newFailureReport(path, 3)
+	if err != nil {
+		t.Fatalf("newFailureReport() error = %v", err)
+	}
+
+	for i := range 5 {
+		item := newLimitTestItem(t, fmt.Sprintf("http://example.com/%d", i))
+		if err := r.add(item, failureStatus, 404, nil, 1); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+
+	// The ring keeps the 3 most recent, the newest first
+	recent := r.last(10)
+	if len(recent) != 3 {
+		t.Fatalf("last() returned %d failures, want 3", len(recent))
+	}
+	for i, want := range []string{"http://example.com/4", "http://example.com/3", "http://example.com/2"} {
+		if recent[i].URL != want {
+			t.Errorf("last()[%d] = %s, want %s", i, recent[i].URL, want)
+		}
+	}
+
+	if recent := r.last(1); len(recent) != 1 || recent[0].URL != "http://example.com/4" {
+		t.Errorf("last(1) = %+v", recent)
+	}
+
+	if err := r.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	// The file holds them all
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var failure Failure
+		if err := json.Unmarshal(scanner.Bytes(), &failure); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		if failure.Reason != failureStatus || failure.Status != 404 {
+			t.Errorf("unexpected failure %+v", failure)
+		}
+		lines++
+	}
+
+	if lines != 5 {
+		t.Errorf("the report has %d lines, want 5", lines)
+	}
+
+	// Without report, nothing happens
+	var disabled *failureReport
+	if err := disabled.add(newLimitTestItem(t, "http://example.com/"), failureDNS, 0, nil, 1); err != nil || disabled.close() != nil {
+		t.Error("expected a nil report to be a no-op")
+	}
+}
+
+func TestFailureReportInMemoryOnly(t *testing.T) {
+	r, err := newFailureReport("", 2)
+	if err != nil {
+		t.Fatalf("newFailureReport() error = %v", err)
+	}
+
+	if recent := r.last(10); len(recent) != 0 {
+		t.Errorf("expected no failure, got %+v", recent)
+	}
+
+	if err := r.add(newLimitTestItem(t, "http://example.com/"), failureTimeout, 0, errors.New("deadline"), 6); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	if recent := r.last(10); len(recent) != 1 || recent[0].Error != "deadline" || recent[0].Attempts != 6 {
+		t.Errorf("last() = %+v", recent)
+	}
+}
+
+func TestFetchFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&net.DNSError{Err: "no such host"}, failureDNS},
+		{context.DeadlineExceeded, failureTimeout},
+		{&timeoutError{err: errReadIdleTimeout}, failureTimeout},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, failureConnect},
+		{errors.New("EOF"), failureConnection},
+		{ErrResponseTooLarge, failureTooLarge},
+		{fmt.Errorf("%w: matches exclusion filters", ErrExcluded), failureExcluded},
+	}
+
+	for _, tt := range tests {
+		if got := fetchFailureReason(tt.err); got != tt.want {
+			t.Errorf("fetchFailureReason(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReportExcluded(t *testing.T) {
+	defer func(previous *failureReport) {
+		globalFailures = previous
+	}(globalFailures)
+
+	var err error
+	globalFailures, err = newFailureReport("", 2)
+	if err != nil {
+		t.Fatalf("newFailureReport() error = %v", err)
+	}
+
+	ReportExcluded(newLimitTestItem(t, "http://example.com/"), "matches exclusion filters")
+
+	recent := globalFailures.last(10)
+	if len(recent) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", recent)
+	}
+
+	if recent[0].Reason != failureExcluded || recent[0].Error != "excluded by the crawl filters: matches exclusion filters" {
+		t.Errorf("unexpected failure %+v", recent[0])
+	}
+}
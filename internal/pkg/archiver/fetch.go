@@ -0,0 +1,38 @@
+package archiver
+
+import (
+	"net/http"
+)
+
+// Fetch sends a request out of the crawl, like the ones expanding the seeds, with the WARC writing client
+// of its host once the politeness delays of the host are waited for. The response is written to the WARC
+// files like the captures once its body is read. The client is held until release is called, after the
+// body is closed.
+func Fetch(req *http.Request) (resp *http.Response, release func(), err error) {
+	if globalArchiver == nil {
+		return nil, nil, ErrNoWARCClient
+	}
+
+	client, release := globalArchiver.acquireClient(req.URL.Host)
+	if client == nil {
+		release()
+		return nil, nil, ErrNoWARCClient
+	}
+
+	if hostDelay, _, _ := globalHostDelays.Limiter(req.URL.Host); hostDelay != nil {
+		hostDelay.Wait(globalArchiver.ctx)
+	} else if globalBucketManager != nil {
+		globalBucketManager.Wait(req.URL.Host)
+	}
+
+	waitGlobalLimiter()
+
+	resp, err = client.Do(req)
+	globalArchiver.reportProxy(client, err != nil || (resp != nil && resp.StatusCode == http.StatusProxyAuthRequired))
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	return resp, release, nil
+}
@@ -0,0 +1,108 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// networkIdleTime is how long the page must go without any request for the network to be considered idle
+	networkIdleTime = 500 * time.Millisecond
+	// networkIdlePollInterval is how often the in-flight requests are checked while waiting for the network to be idle
+	networkIdlePollInterval = 100 * time.Millisecond
+
+	scrollScript   = `window.scrollBy(0, window.innerHeight)`
+	atBottomScript = `window.scrollY + window.innerHeight >= document.documentElement.scrollHeight - 1`
+	// clickScript clicks on every visible element matching the selector and returns the number of clicks
+	clickScript = `(() => {
+	let clicks = 0;
+	document.querySelectorAll(%s).forEach((element) => {
+		if (element.offsetParent !== null) {
+			element.click();
+			clicks++;
+		}
+	});
+	return clicks;
+})()`
+)
+
+// runBehaviors interacts with the page to trigger the loading of content that is only fetched on user
+// interactions: it clicks on the elements matching the configured selectors and scrolls down the page,
+// until the bottom of the page is reached and nothing got clicked, or ctx is done.
+// The requests triggered by the behaviors are intercepted and archived like any other request of the page.
+func (t *tab) runBehaviors(ctx context.Context) (scrolls, clicks int) {
+	t.waitNetworkIdle(ctx)
+
+	for ctx.Err() == nil {
+		clicked := t.clickSelectors(ctx)
+		clicks += clicked
+
+		if err := chromedp.Run(ctx, chromedp.Evaluate(scrollScript, nil)); err != nil {
+			logger.Debug("unable to scroll", "err", err.Error())
+			return scrolls, clicks
+		}
+		scrolls++
+
+		select {
+		case <-ctx.Done():
+			return scrolls, clicks
+		case <-time.After(globalBrowser.scrollDelay):
+		}
+
+		t.waitNetworkIdle(ctx)
+
+		var atBottom bool
+		if err := chromedp.Run(ctx, chromedp.Evaluate(atBottomScript, &atBottom)); err != nil {
+			logger.Debug("unable to get scroll position", "err", err.Error())
+			return scrolls, clicks
+		}
+
+		if atBottom && clicked == 0 {
+			break
+		}
+	}
+
+	return scrolls, clicks
+}
+
+// clickSelectors clicks on the visible elements matching the configured selectors
+func (t *tab) clickSelectors(ctx context.Context) (clicks int) {
+	for _, selector := range globalBrowser.clickSelectors {
+		encodedSelector, err := json.Marshal(selector)
+		if err != nil {
+			continue
+		}
+
+		var clicked int
+		if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(clickScript, encodedSelector), &clicked)); err != nil {
+			logger.Debug("unable to click on selector", "err", err.Error(), "selector", selector)
+			continue
+		}
+
+		clicks += clicked
+	}
+
+	return clicks
+}
+
+// waitNetworkIdle waits until the page has no request in flight for networkIdleTime, or until ctx is done
+func (t *tab) waitNetworkIdle(ctx context.Context) {
+	ticker := time.NewTicker(networkIdlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if t.inflight.Load() == 0 && time.Since(time.Unix(0, t.lastActivity.Load())) >= networkIdleTime {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
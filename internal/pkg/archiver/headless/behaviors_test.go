@@ -0,0 +1,36 @@
+package headless
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitNetworkIdle(t *testing.T) {
+	tb := &tab{}
+
+	// Nothing happened on the network, it's idle right away
+	start := time.Now()
+	tb.waitNetworkIdle(context.Background())
+	if elapsed := time.Since(start); elapsed > networkIdlePollInterval {
+		t.Errorf("expected an immediate return on an idle network, waited %s", elapsed)
+	}
+
+	// A request just finished, we wait for the idle time
+	tb.lastActivity.Store(time.Now().UnixNano())
+	start = time.Now()
+	tb.waitNetworkIdle(context.Background())
+	if elapsed := time.Since(start); elapsed < networkIdleTime {
+		t.Errorf("expected to wait at least %s, waited %s", networkIdleTime, elapsed)
+	}
+
+	// A request is in flight, we wait until the context is done
+	tb.inflight.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*networkIdleTime)
+	defer cancel()
+
+	tb.waitNetworkIdle(ctx)
+	if ctx.Err() == nil {
+		t.Error("expected to wait until the context is done while a request is in flight")
+	}
+}
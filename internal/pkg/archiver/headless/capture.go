@@ -0,0 +1,366 @@
+package headless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+type tab struct {
+	ctx       context.Context
+	client    HTTPClient
+	mainFrame cdp.FrameID
+	wg        sync.WaitGroup
+
+	// Used to detect when the network is idle and to report the size of the page
+	inflight     atomic.Int64
+	lastActivity atomic.Int64 // unix nano
+	bytes        atomic.Int64
+
+	mu        sync.Mutex
+	closing   bool
+	navigated bool
+	document  *document
+}
+
+// document is the last response received for the main frame of the tab,
+// when the page got redirected it is the response at the end of the chain
+type document struct {
+	response *http.Response
+	body     []byte
+}
+
+// Page is the result of the capture of a page in the browser
+type Page struct {
+	// Response is the response of the page's document, with its body replaced by the DOM rendered
+	// after the page loaded when the document is HTML. Its Request is the one of the last hop,
+	// so it reflects the final URL of the page.
+	Response *http.Response
+
+	// Screenshot of the page, nil if screenshots are disabled or if it failed
+	Screenshot            []byte
+	ScreenshotContentType string
+}
+
+// Capture loads the URL in a browser tab. All the requests made by the browser are executed
+// with the given client, so they are written to WARC like any other request.
+func Capture(client HTTPClient, u *models.URL) (*Page, error) {
+	if globalBrowser == nil {
+		return nil, ErrHeadlessNotStarted
+	}
+
+	globalBrowser.tabs <- struct{}{}
+	defer func() { <-globalBrowser.tabs }()
+
+	browserCtx, err := globalBrowser.browserContext()
+	if err != nil {
+		return nil, err
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	defer cancelTab()
+
+	// The requests of the page can run for as long as the page loads and the behaviors run
+	captureCtx, cancelCapture := context.WithTimeout(tabCtx, globalBrowser.pageTimeout+globalBrowser.behaviorsTimeout)
+	defer cancelCapture()
+
+	pageCtx, cancelPage := context.WithTimeout(captureCtx, globalBrowser.pageTimeout)
+	defer cancelPage()
+
+	t := &tab{
+		ctx:    captureCtx,
+		client: client,
+	}
+
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// The listener must not block, everything is done in separate goroutines
+		if t.closing {
+			go t.failRequest(paused)
+			return
+		}
+
+		t.wg.Add(1)
+		go t.handleRequest(paused)
+	})
+
+	// The first run creates the tab, the interception must be enabled before navigating
+	if err := chromedp.Run(tabCtx, fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}})); err != nil {
+		return nil, err
+	}
+
+	// The main frame of a page target shares its ID
+	t.mainFrame = cdp.FrameID(chromedp.FromContext(tabCtx).Target.TargetID)
+
+	if globalBrowser.screenshot {
+		if err := chromedp.Run(tabCtx, chromedp.EmulateViewport(globalBrowser.screenshotWidth, viewportHeight)); err != nil {
+			return nil, err
+		}
+	}
+
+	navigateErr := chromedp.Run(pageCtx, chromedp.Navigate(u.String()))
+
+	// From now on, a document loaded in the main frame (e.g. by a behavior clicking
+	// on a link) isn't the page we are capturing
+	t.mu.Lock()
+	t.navigated = true
+	t.mu.Unlock()
+
+	doc := t.getDocument()
+	if doc == nil {
+		t.finish()
+
+		if navigateErr != nil {
+			return nil, navigateErr
+		}
+
+		return nil, ErrNoDocument
+	}
+
+	page := new(Page)
+
+	body := doc.body
+	if isHTML(doc.response) {
+		if navigateErr != nil {
+			t.finish()
+			return nil, navigateErr
+		}
+
+		if globalBrowser.behaviors {
+			behaviorsCtx, cancelBehaviors := context.WithTimeout(captureCtx, globalBrowser.behaviorsTimeout)
+			startTime := time.Now()
+
+			scrolls, clicks := t.runBehaviors(behaviorsCtx)
+
+			logger.Info("page behaviors done", "url", u.String(), "scrolls", scrolls, "clicks", clicks, "bytes", t.bytes.Load(), "elapsed", time.Since(startTime).String())
+			cancelBehaviors()
+		}
+
+		var html string
+		if err := chromedp.Run(captureCtx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+			t.finish()
+			return nil, err
+		}
+
+		body = []byte(html)
+
+		// A failed screenshot doesn't fail the capture
+		if globalBrowser.screenshot {
+			page.Screenshot, page.ScreenshotContentType, err = t.screenshot(captureCtx)
+			if err != nil {
+				logger.Warn("unable to take screenshot", "err", err.Error(), "url", u.String())
+			}
+		}
+	}
+
+	t.finish()
+
+	page.Response = doc.response
+	page.Response.Header.Del("Content-Length")
+	page.Response.ContentLength = int64(len(body))
+	page.Response.Body = io.NopCloser(bytes.NewReader(body))
+
+	return page, nil
+}
+
+// finish refuses any new request from the page and waits for the in-flight ones,
+// at most until the end of the capture's time budget
+func (t *tab) finish() {
+	t.mu.Lock()
+	t.closing = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-t.ctx.Done():
+	}
+}
+
+func (t *tab) getDocument() *document {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.document
+}
+
+func (t *tab) setDocument(resp *http.Response, body []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.navigated {
+		return
+	}
+
+	t.document = &document{
+		response: resp,
+		body:     body,
+	}
+}
+
+func (t *tab) executor() context.Context {
+	return cdp.WithExecutor(t.ctx, chromedp.FromContext(t.ctx).Target)
+}
+
+func (t *tab) failRequest(ev *fetch.EventRequestPaused) {
+	if err := fetch.FailRequest(ev.RequestID, network.ErrorReasonFailed).Do(t.executor()); err != nil {
+		logger.Debug("unable to fail browser request", "err", err.Error(), "url", ev.Request.URL)
+	}
+}
+
+// handleRequest executes a request intercepted from the browser with the WARC writing client
+// and hands the response back to the browser
+func (t *tab) handleRequest(ev *fetch.EventRequestPaused) {
+	defer t.wg.Done()
+
+	t.inflight.Add(1)
+	defer func() {
+		t.lastActivity.Store(time.Now().UnixNano())
+		t.inflight.Add(-1)
+	}()
+
+	// Only HTTP traffic can be archived, let the browser handle the rest (data:, blob:, etc.)
+	if !strings.HasPrefix(ev.Request.URL, "http://") && !strings.HasPrefix(ev.Request.URL, "https://") {
+		if err := fetch.ContinueRequest(ev.RequestID).Do(t.executor()); err != nil {
+			logger.Debug("unable to continue browser request", "err", err.Error(), "url", ev.Request.URL)
+		}
+		return
+	}
+
+	isDocument := ev.ResourceType == network.ResourceTypeDocument && ev.FrameID == t.mainFrame
+
+	req, err := newRequest(t.ctx, ev.Request)
+	if err != nil {
+		logger.Debug("unable to build browser request", "err", err.Error(), "url", ev.Request.URL)
+		t.failRequest(ev)
+		return
+	}
+
+	// If WARC writing is asynchronous, we don't need a feedback channel
+	var feedbackChan chan struct{}
+	if !config.Get().WARCWriteAsync {
+		feedbackChan = make(chan struct{}, 1)
+		req = req.WithContext(context.WithValue(req.Context(), "feedback", feedbackChan))
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		logger.Debug("unable to execute browser request", "err", err.Error(), "url", ev.Request.URL)
+		t.failRequest(ev)
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		logger.Debug("unable to read browser response", "err", err.Error(), "url", ev.Request.URL)
+		t.failRequest(ev)
+		return
+	}
+
+	t.bytes.Add(int64(len(body)))
+
+	// The page's document is accounted for by the archiver itself
+	if isDocument {
+		t.setDocument(resp, body)
+	} else {
+		stats.URLsCrawledIncr()
+		stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+		stats.HTTPHostResponsesIncr(req.URL.Hostname(), strconv.Itoa(resp.StatusCode))
+	}
+
+	err = fetch.FulfillRequest(ev.RequestID, int64(resp.StatusCode)).
+		WithResponseHeaders(responseHeaders(resp.Header)).
+		WithBody(base64.StdEncoding.EncodeToString(body)).
+		Do(t.executor())
+	if err != nil {
+		logger.Debug("unable to fulfill browser request", "err", err.Error(), "url", ev.Request.URL)
+	}
+
+	if feedbackChan != nil {
+		// Waiting for WARC writing to finish
+		select {
+		case <-feedbackChan:
+		case <-t.ctx.Done():
+		}
+	}
+}
+
+// newRequest converts a request intercepted from the browser to an HTTP request
+func newRequest(ctx context.Context, r *network.Request) (*http.Request, error) {
+	var body io.Reader
+	if len(r.PostDataEntries) > 0 {
+		buf := new(bytes.Buffer)
+		for _, entry := range r.PostDataEntries {
+			data, err := base64.StdEncoding.DecodeString(entry.Bytes)
+			if err != nil {
+				return nil, err
+			}
+
+			buf.Write(data)
+		}
+
+		body = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range r.Headers {
+		req.Header.Set(name, fmt.Sprint(value))
+	}
+
+	return req, nil
+}
+
+// responseHeaders converts the response headers to the browser's format. The body handed
+// to the browser is always decompressed, so the encoding and length headers are dropped.
+func responseHeaders(header http.Header) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(header))
+	for name, values := range header {
+		if strings.EqualFold(name, "Content-Encoding") || strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+
+		for _, value := range values {
+			entries = append(entries, &fetch.HeaderEntry{Name: name, Value: value})
+		}
+	}
+
+	return entries
+}
+
+func isHTML(resp *http.Response) bool {
+	contentType := resp.Header.Get("Content-Type")
+	return contentType == "" || strings.Contains(contentType, "html")
+}
@@ -0,0 +1,113 @@
+package headless
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestNewRequest(t *testing.T) {
+	r := &network.Request{
+		URL:     "https://example.com/api",
+		Method:  http.MethodPost,
+		Headers: network.Headers{"Content-Type": "application/json", "X-Count": 3},
+		PostDataEntries: []*network.PostDataEntry{
+			{Bytes: base64.StdEncoding.EncodeToString([]byte(`{"a":`))},
+			{Bytes: base64.StdEncoding.EncodeToString([]byte(`1}`))},
+		},
+	}
+
+	req, err := newRequest(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if req.Method != http.MethodPost || req.URL.String() != r.URL {
+		t.Errorf("unexpected request line: %s %s", req.Method, req.URL)
+	}
+
+	if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-Count") != "3" {
+		t.Errorf("unexpected headers: %v", req.Header)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("unable to read body: %v", err)
+	}
+
+	if string(body) != `{"a":1}` {
+		t.Errorf("expected body %q, got %q", `{"a":1}`, body)
+	}
+}
+
+func TestNewRequestInvalidPostData(t *testing.T) {
+	r := &network.Request{
+		URL:             "https://example.com/",
+		Method:          http.MethodPost,
+		PostDataEntries: []*network.PostDataEntry{{Bytes: "not base64!"}},
+	}
+
+	if _, err := newRequest(context.Background(), r); err == nil {
+		t.Error("expected an error for invalid post data")
+	}
+}
+
+func TestResponseHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/html")
+	header.Set("Content-Encoding", "gzip")
+	header.Set("Content-Length", "42")
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+
+	entries := responseHeaders(header)
+
+	got := make(map[string][]string)
+	for _, entry := range entries {
+		got[entry.Name] = append(got[entry.Name], entry.Value)
+	}
+
+	if _, ok := got["Content-Encoding"]; ok {
+		t.Error("Content-Encoding should be dropped")
+	}
+
+	if _, ok := got["Content-Length"]; ok {
+		t.Error("Content-Length should be dropped")
+	}
+
+	if len(got["Set-Cookie"]) != 2 {
+		t.Errorf("expected 2 Set-Cookie headers, got %v", got["Set-Cookie"])
+	}
+
+	if len(got["Content-Type"]) != 1 || got["Content-Type"][0] != "text/html" {
+		t.Errorf("unexpected Content-Type: %v", got["Content-Type"])
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"text/html; charset=utf-8", true},
+		{"application/xhtml+xml", true},
+		{"", true},
+		{"application/pdf", false},
+		{"image/png", false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.contentType != "" {
+			resp.Header.Set("Content-Type", tt.contentType)
+		}
+
+		if got := isHTML(resp); got != tt.want {
+			t.Errorf("isHTML(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package headless
+
+import "errors"
+
+var (
+	// ErrHeadlessAlreadyInitialized is the error returned when the headless browser is already initialized
+	ErrHeadlessAlreadyInitialized = errors.New("headless browser already initialized")
+	// ErrHeadlessNotStarted is the error returned when a capture is requested but the browser isn't started
+	ErrHeadlessNotStarted = errors.New("headless browser not started")
+	// ErrNoDocument is the error returned when the browser didn't load any document for the page
+	ErrNoDocument = errors.New("browser did not load any document")
+)
@@ -0,0 +1,192 @@
+// Package headless renders pages in a Chromium instance driven through the
+// Chrome DevTools Protocol. Every request the browser makes is intercepted and
+// executed with the WARC writing HTTP client, so that the traffic ends up in the
+// WARC files exactly like the traffic of the plain HTTP path.
+package headless
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+// HTTPClient is the client used to execute the requests intercepted from the browser,
+// it is satisfied by the WARC writing client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type browser struct {
+	mu          sync.Mutex
+	allocCtx    context.Context
+	allocCancel context.CancelFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+
+	tabs        chan struct{}
+	pageTimeout time.Duration
+
+	// Page behaviors
+	behaviors        bool
+	behaviorsTimeout time.Duration
+	scrollDelay      time.Duration
+	clickSelectors   []string
+
+	// Screenshots
+	screenshot          bool
+	screenshotFormat    string
+	screenshotQuality   int64
+	screenshotWidth     int64
+	screenshotMaxHeight int64
+}
+
+var (
+	globalBrowser *browser
+	once          sync.Once
+	logger        *log.FieldedLogger
+)
+
+// Start launches the browser used to capture pages, should only be called once and returns an error if called more than once
+func Start() error {
+	var (
+		done bool
+		err  error
+	)
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "archiver.headless",
+	})
+
+	once.Do(func() {
+		b := &browser{
+			tabs:        make(chan struct{}, config.Get().HeadlessTabs),
+			pageTimeout: config.Get().HeadlessPageTimeout,
+		}
+
+		if config.Get().HeadlessBehaviors {
+			b.behaviors = true
+			b.behaviorsTimeout = config.Get().HeadlessBehaviorsTimeout
+			b.scrollDelay = config.Get().HeadlessScrollDelay
+			b.clickSelectors = config.Get().HeadlessClickSelectors
+		}
+
+		if config.Get().Screenshot {
+			b.screenshot = true
+			b.screenshotFormat = config.Get().ScreenshotFormat
+			b.screenshotQuality = int64(config.Get().ScreenshotQuality)
+			b.screenshotWidth = int64(config.Get().ScreenshotWidth)
+			b.screenshotMaxHeight = int64(config.Get().ScreenshotMaxHeight)
+		}
+
+		if err = b.launch(); err != nil {
+			return
+		}
+
+		globalBrowser = b
+		logger.Info("started", "tabs", config.Get().HeadlessTabs, "page_timeout", b.pageTimeout.String())
+		done = true
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !done {
+		return ErrHeadlessAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Stop closes the browser
+func Stop() {
+	if globalBrowser != nil {
+		globalBrowser.mu.Lock()
+		globalBrowser.close()
+		globalBrowser.mu.Unlock()
+
+		logger.Info("stopped")
+	}
+}
+
+// Enabled returns true if the browser has been started
+func Enabled() bool {
+	return globalBrowser != nil
+}
+
+// launch starts a new browser process, must be called with b.mu held (or before b is shared)
+func (b *browser) launch() error {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserAgent(config.Get().UserAgent),
+		// TLS is handled by the WARC writing client, the browser never talks to the servers directly
+		chromedp.IgnoreCertErrors,
+	)
+
+	if config.Get().HeadlessBrowserPath != "" {
+		opts = append(opts, chromedp.ExecPath(config.Get().HeadlessBrowserPath))
+	}
+
+	b.allocCtx, b.allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
+	b.ctx, b.cancel = chromedp.NewContext(b.allocCtx)
+
+	// Running without any action starts the browser
+	if err := chromedp.Run(b.ctx); err != nil {
+		b.close()
+		return err
+	}
+
+	return nil
+}
+
+// close stops the browser process, must be called with b.mu held
+func (b *browser) close() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+
+	if b.allocCancel != nil {
+		b.allocCancel()
+	}
+}
+
+// alive returns true if the connection to the browser is still up, must be called with b.mu held
+func (b *browser) alive() bool {
+	if b.ctx == nil || b.ctx.Err() != nil {
+		return false
+	}
+
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Browser == nil {
+		return false
+	}
+
+	select {
+	case <-c.Browser.LostConnection:
+		return false
+	default:
+		return true
+	}
+}
+
+// browserContext returns the context of a running browser, relaunching it if it crashed
+func (b *browser) browserContext() (context.Context, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.alive() {
+		logger.Warn("browser is not running anymore, relaunching it")
+
+		b.close()
+		if err := b.launch(); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.ctx, nil
+}
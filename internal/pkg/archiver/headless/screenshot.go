@@ -0,0 +1,59 @@
+package headless
+
+import (
+	"context"
+	"math"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// viewportHeight is the height of the viewport when screenshots are enabled,
+// the screenshot itself covers the full height of the page
+const viewportHeight = 1080
+
+// screenshot takes a screenshot of the full page, cropped at the configured max height
+func (t *tab) screenshot(ctx context.Context) (screenshot []byte, contentType string, err error) {
+	format, contentType := screenshotFormat(globalBrowser.screenshotFormat)
+
+	err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, _, _, _, contentSize, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		height := math.Ceil(contentSize.Height)
+		if globalBrowser.screenshotMaxHeight > 0 && height > float64(globalBrowser.screenshotMaxHeight) {
+			height = float64(globalBrowser.screenshotMaxHeight)
+		}
+
+		params := page.CaptureScreenshot().
+			WithFormat(format).
+			WithCaptureBeyondViewport(true).
+			WithClip(&page.Viewport{
+				Width:  math.Ceil(contentSize.Width),
+				Height: height,
+				Scale:  1,
+			})
+
+		if format == page.CaptureScreenshotFormatJpeg {
+			params = params.WithQuality(globalBrowser.screenshotQuality)
+		}
+
+		screenshot, err = params.Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return screenshot, contentType, nil
+}
+
+func screenshotFormat(format string) (page.CaptureScreenshotFormat, string) {
+	if format == "jpeg" {
+		return page.CaptureScreenshotFormatJpeg, "image/jpeg"
+	}
+
+	return page.CaptureScreenshotFormatPng, "image/png"
+}
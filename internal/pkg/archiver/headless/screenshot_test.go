@@ -0,0 +1,26 @@
+package headless
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestScreenshotFormat(t *testing.T) {
+	tests := []struct {
+		format      string
+		want        page.CaptureScreenshotFormat
+		contentType string
+	}{
+		{"png", page.CaptureScreenshotFormatPng, "image/png"},
+		{"jpeg", page.CaptureScreenshotFormatJpeg, "image/jpeg"},
+		{"", page.CaptureScreenshotFormatPng, "image/png"},
+	}
+
+	for _, tt := range tests {
+		got, contentType := screenshotFormat(tt.format)
+		if got != tt.want || contentType != tt.contentType {
+			t.Errorf("screenshotFormat(%q) = %s, %s; want %s, %s", tt.format, got, contentType, tt.want, tt.contentType)
+		}
+	}
+}
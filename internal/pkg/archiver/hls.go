@@ -0,0 +1,52 @@
+package archiver
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// hlsSizeTracker keeps count of the bytes captured for the segments of each HLS playlist of a seed,
+// so that the capture of a playlist's segments stops once it reaches the configured size.
+// Live streams would otherwise be captured forever.
+type hlsSizeTracker struct {
+	maxBytes int64
+	sizes    map[*models.Item]*atomic.Int64
+}
+
+func newHLSSizeTracker(maxBytes int64) *hlsSizeTracker {
+	return &hlsSizeTracker{
+		maxBytes: maxBytes,
+		sizes:    make(map[*models.Item]*atomic.Int64),
+	}
+}
+
+// counter returns the size counter of the playlist the item belongs to, or nil if the item
+// isn't part of a playlist or if there is no size cap. It must not be called concurrently.
+func (t *hlsSizeTracker) counter(item *models.Item) *atomic.Int64 {
+	if t.maxBytes <= 0 || item.GetParent() == nil || !isHLSPlaylist(item.GetParent().GetURL()) {
+		return nil
+	}
+
+	size, ok := t.sizes[item.GetParent()]
+	if !ok {
+		size = new(atomic.Int64)
+		t.sizes[item.GetParent()] = size
+	}
+
+	return size
+}
+
+// exceeded returns true if the size cap of the playlist the item belongs to is reached
+func (t *hlsSizeTracker) exceeded(size *atomic.Int64) bool {
+	return size != nil && size.Load() >= t.maxBytes
+}
+
+func isHLSPlaylist(u *models.URL) bool {
+	if u == nil || u.GetResponse() == nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(u.GetResponse().Header.Get("Content-Type")), "mpegurl")
+}
@@ -0,0 +1,166 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestHLSSizeTracker(t *testing.T) {
+	newItem := func(rawURL, contentType string) *models.Item {
+		u := &models.URL{Raw: rawURL}
+		if err := u.Parse(); err != nil {
+			t.Fatalf("unable to parse URL: %v", err)
+		}
+
+		if contentType != "" {
+			u.SetResponse(&http.Response{Header: http.Header{"Content-Type": []string{contentType}}})
+		}
+
+		return models.NewItem(rawURL, u, "")
+	}
+
+	playlist := newItem("http://example.com/index.m3u8", "application/x-mpegURL")
+	segment := newItem("http://example.com/seg0.ts", "")
+	otherSegment := newItem("http://example.com/seg1.ts", "")
+	if err := playlist.AddChild(segment, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+	if err := playlist.AddChild(otherSegment, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	page := newItem("http://example.com/", "text/html")
+	image := newItem("http://example.com/img.png", "")
+	if err := page.AddChild(image, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := newHLSSizeTracker(1000)
+
+	if tracker.counter(image) != nil {
+		t.Errorf("expected no counter for an item that isn't a segment")
+	}
+
+	size := tracker.counter(segment)
+	if size == nil {
+		t.Fatalf("expected a counter for a segment")
+	}
+
+	if tracker.counter(otherSegment) != size {
+		t.Errorf("expected the segments of a playlist to share their counter")
+	}
+
+	size.Add(600)
+	if tracker.exceeded(size) {
+		t.Errorf("expected the cap to not be reached at 600 bytes")
+	}
+
+	size.Add(600)
+	if !tracker.exceeded(size) {
+		t.Errorf("expected the cap to be reached at 1200 bytes")
+	}
+
+	if newHLSSizeTracker(0).counter(segment) != nil {
+		t.Errorf("expected no counter without a size cap")
+	}
+}
+
+// The segments are served chunked, without a Content-Length, their size is counted from the bytes read
+func TestHLSSizeChunkedSegments(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	defer func(jobPath string, poolSize int, compression string, hlsMaxSize, maxConcurrentAssets int) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().HLSMaxSize = hlsMaxSize
+		config.Get().MaxConcurrentAssets = maxConcurrentAssets
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().HLSMaxSize, config.Get().MaxConcurrentAssets)
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().HLSMaxSize = 1
+	config.Get().MaxConcurrentAssets = 1
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver, workers workersActivity) {
+		globalArchiver = previous
+		globalWorkers = workers
+	}(globalArchiver, globalWorkers)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		// Flushing before the end of the body sends it chunked
+		chunk := bytes.Repeat([]byte("s"), 512*1024)
+		for range 3 {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel, requestsCtx: requestsCtx, cancelRequests: cancelRequests}
+	globalWorkers = newWorkersActivity(1)
+
+	startWARCWriter()
+	defer globalArchiver.Client.Close()
+
+	newItem := func(rawURL string) *models.Item {
+		u := &models.URL{Raw: rawURL}
+		if err := u.Parse(); err != nil {
+			t.Fatalf("unable to parse URL: %v", err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.SetRequest(req)
+
+		return models.NewItem(rawURL, u, "")
+	}
+
+	playlist := newItem(server.URL + "/index.m3u8")
+	playlist.GetURL().SetResponse(&http.Response{Header: http.Header{"Content-Type": []string{"application/x-mpegURL"}}})
+	playlist.SetStatus(models.ItemGotChildren)
+
+	for i := range 2 {
+		segment := newItem(server.URL + "/seg" + strconv.Itoa(i) + ".ts")
+		if err := playlist.AddChild(segment, models.ItemGotChildren); err != nil {
+			t.Fatal(err)
+		}
+		segment.SetStatus(models.ItemPreProcessed)
+	}
+
+	archive("0", playlist)
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected the second segment to be skipped once the first one reached the cap, got %d requests", got)
+	}
+}
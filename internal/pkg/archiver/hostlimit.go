@@ -0,0 +1,276 @@
+package archiver
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+const hostLimiterShards = 32
+
+// hostLimiter bounds the number of pages requested at the same time on each host. The workers
+// don't wait for a saturated host: the items are deferred and picked up by the next worker
+// that is free once a slot of their host is released, meanwhile the workers archive other items.
+// Once maxDeferred items are deferred, the workers stop taking new seeds until one is picked up.
+type hostLimiter struct {
+	max    int
+	shards [hostLimiterShards]hostLimiterShard
+
+	deferredMu    sync.Mutex
+	deferred      map[string][]*models.Item
+	deferredCount int
+	maxDeferred   int
+
+	// Signaled when a deferred item may have become ready
+	wake chan struct{}
+}
+
+type hostLimiterShard struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// newHostLimiter returns nil if max isn't positive, the methods of a nil limiter don't limit anything
+func newHostLimiter(max, maxDeferred int) *hostLimiter {
+	if max <= 0 {
+		return nil
+	}
+
+	l := &hostLimiter{
+		max:         max,
+		deferred:    make(map[string][]*models.Item),
+		maxDeferred: maxDeferred,
+		wake:        make(chan struct{}, 1),
+	}
+
+	for i := range l.shards {
+		l.shards[i].inFlight = make(map[string]int)
+	}
+
+	return l
+}
+
+func (l *hostLimiter) shard(host string) *hostLimiterShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(host))
+
+	return &l.shards[hash.Sum32()%hostLimiterShards]
+}
+
+// tryAcquire takes a slot of the host if one is free
+func (l *hostLimiter) tryAcquire(host string) bool {
+	if l == nil {
+		return true
+	}
+
+	shard := l.shard(host)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if shard.inFlight[host] >= l.max {
+		return false
+	}
+
+	shard.inFlight[host]++
+
+	return true
+}
+
+// available tells if the host has a free slot, without taking it
+func (l *hostLimiter) available(host string) bool {
+	if l == nil {
+		return true
+	}
+
+	shard := l.shard(host)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.inFlight[host] < l.max
+}
+
+// release frees a slot of the host, the idle hosts are forgotten
+func (l *hostLimiter) release(host string) {
+	if l == nil {
+		return
+	}
+
+	shard := l.shard(host)
+	shard.mu.Lock()
+	if shard.inFlight[host] <= 1 {
+		delete(shard.inFlight, host)
+	} else {
+		shard.inFlight[host]--
+	}
+	shard.mu.Unlock()
+
+	l.deferredMu.Lock()
+	waiting := len(l.deferred[host]) > 0
+	l.deferredMu.Unlock()
+
+	if waiting {
+		l.signal()
+	}
+}
+
+// deferItem keeps the item of a saturated host until one of its slots is released
+func (l *hostLimiter) deferItem(host string, item *models.Item) {
+	l.deferredMu.Lock()
+	l.deferred[host] = append(l.deferred[host], item)
+	l.deferredCount++
+	l.deferredMu.Unlock()
+
+	// The slot may have been released since the item was refused
+	l.signal()
+}
+
+// ready returns the channel signaled when a deferred item may be ready, nil without limiter
+func (l *hostLimiter) ready() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+
+	return l.wake
+}
+
+// popReady returns a deferred item whose host has a free slot, with the slot taken
+func (l *hostLimiter) popReady() (item *models.Item, host string) {
+	l.deferredMu.Lock()
+	defer l.deferredMu.Unlock()
+
+	for host, items := range l.deferred {
+		if !l.tryAcquire(host) {
+			continue
+		}
+
+		item = items[0]
+		if len(items) == 1 {
+			delete(l.deferred, host)
+		} else {
+			l.deferred[host] = items[1:]
+		}
+		l.deferredCount--
+
+		// Other deferred items may be ready too, let another worker look
+		if len(l.deferred) > 0 {
+			l.signal()
+		}
+
+		return item, host
+	}
+
+	return nil, ""
+}
+
+// full tells if maxDeferred items are deferred, the workers don't take new seeds meanwhile
+func (l *hostLimiter) full() bool {
+	if l == nil {
+		return false
+	}
+
+	l.deferredMu.Lock()
+	defer l.deferredMu.Unlock()
+
+	return l.deferredCount >= l.maxDeferred
+}
+
+// drain forgets the deferred items and returns how many there were, once the workers are stopped
+func (l *hostLimiter) drain() int {
+	if l == nil {
+		return 0
+	}
+
+	l.deferredMu.Lock()
+	defer l.deferredMu.Unlock()
+
+	drained := l.deferredCount
+	clear(l.deferred)
+	l.deferredCount = 0
+
+	return drained
+}
+
+func (l *hostLimiter) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// HostActivity is the number of pages being requested and waiting for a slot on a host
+type HostActivity struct {
+	InFlight int `json:"in_flight"`
+	Deferred int `json:"deferred"`
+}
+
+func (l *hostLimiter) activity() map[string]HostActivity {
+	activity := make(map[string]HostActivity)
+
+	for i := range l.shards {
+		l.shards[i].mu.Lock()
+		for host, inFlight := range l.shards[i].inFlight {
+			activity[host] = HostActivity{InFlight: inFlight}
+		}
+		l.shards[i].mu.Unlock()
+	}
+
+	l.deferredMu.Lock()
+	for host, items := range l.deferred {
+		hostActivity := activity[host]
+		hostActivity.Deferred = len(items)
+		activity[host] = hostActivity
+	}
+	l.deferredMu.Unlock()
+
+	return activity
+}
+
+// GetHostsActivity returns the hosts with pages being requested or waiting for a slot,
+// nil if the number of concurrent requests per host isn't limited
+func GetHostsActivity() map[string]HostActivity {
+	if globalHostLimiter == nil {
+		return nil
+	}
+
+	return globalHostLimiter.activity()
+}
+
+// HostAvailable tells if a page of the host can be requested right away,
+// the queue uses it to serve the other hosts first
+func HostAvailable(host string) bool {
+	return globalHostLimiter.available(host)
+}
+
+// limitedHost returns the host the seed is about to request a page of, or an empty string if
+// the seed isn't limited: the assets are bounded by the assets limiter instead
+func limitedHost(seed *models.Item) string {
+	if seed.GetStatus() != models.ItemPreProcessed && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
+		return ""
+	}
+
+	items, err := seed.GetNodesAtLevel(seed.GetMaxDepth())
+	if err != nil {
+		return ""
+	}
+
+	var page *models.Item
+	for _, item := range items {
+		if item.GetStatus() != models.ItemPreProcessed {
+			continue
+		}
+
+		if page != nil || item.IsChild() {
+			return ""
+		}
+
+		page = item
+	}
+
+	if page == nil || page.GetURL().GetParsed() == nil {
+		return ""
+	}
+
+	return strings.ToLower(page.GetURL().GetParsed().Host)
+}
@@ -0,0 +1,164 @@
+package archiver
+
+import (
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newLimitedSeed(t *testing.T, rawURL string) *models.Item {
+	t.Helper()
+
+	u := &models.URL{Raw: rawURL}
+	if err := u.Parse(); err != nil {
+		t.Fatalf("unable to parse URL: %v", err)
+	}
+
+	seed := models.NewItem(rawURL, u, "")
+	seed.SetStatus(models.ItemPreProcessed)
+
+	return seed
+}
+
+func TestHostLimiterSlots(t *testing.T) {
+	if l := newHostLimiter(0, 0); l != nil || !l.tryAcquire("example.com") {
+		t.Fatal("expected a disabled limiter to allow everything")
+	}
+
+	l := newHostLimiter(2, 10)
+
+	if !l.tryAcquire("example.com") || !l.tryAcquire("example.com") {
+		t.Fatal("expected 2 slots for the host")
+	}
+	if l.tryAcquire("example.com") {
+		t.Fatal("expected the host to be saturated")
+	}
+	if !l.tryAcquire("other.com") {
+		t.Fatal("expected the other hosts not to be affected")
+	}
+
+	if got := l.activity()["example.com"].InFlight; got != 2 {
+		t.Errorf("in flight = %d, want 2", got)
+	}
+
+	l.release("example.com")
+	l.release("example.com")
+	l.release("other.com")
+
+	// Idle hosts are forgotten
+	if activity := l.activity(); len(activity) != 0 {
+		t.Errorf("expected no host left, got %v", activity)
+	}
+}
+
+func TestHostLimiterDeferred(t *testing.T) {
+	l := newHostLimiter(1, 10)
+
+	if !l.tryAcquire("example.com") {
+		t.Fatal("expected a slot for the host")
+	}
+
+	deferred := newLimitedSeed(t, "http://example.com/b")
+	l.deferItem("example.com", deferred)
+
+	if got := l.activity()["example.com"].Deferred; got != 1 {
+		t.Errorf("deferred = %d, want 1", got)
+	}
+
+	// Still saturated, nothing to resume
+	<-l.ready()
+	if item, _ := l.popReady(); item != nil {
+		t.Fatal("expected no deferred item to be ready")
+	}
+
+	l.release("example.com")
+
+	select {
+	case <-l.ready():
+	default:
+		t.Fatal("expected the release to wake a worker")
+	}
+
+	item, host := l.popReady()
+	if item != deferred || host != "example.com" {
+		t.Fatalf("popReady() = %v, %q, want the deferred seed", item, host)
+	}
+
+	if got := l.activity()["example.com"]; got.InFlight != 1 || got.Deferred != 0 {
+		t.Errorf("expected the resumed seed to hold the slot, got %+v", got)
+	}
+}
+
+func TestLimitedHost(t *testing.T) {
+	seed := newLimitedSeed(t, "http://Example.com/a")
+	if got := limitedHost(seed); got != "example.com" {
+		t.Errorf("limitedHost(seed) = %q, want example.com", got)
+	}
+
+	// The assets of a page aren't limited by host
+	asset := newLimitedSeed(t, "http://cdn.example.com/a.css")
+	if err := seed.AddChild(asset, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := limitedHost(seed); got != "" {
+		t.Errorf("limitedHost(seed with assets) = %q, want none", got)
+	}
+}
+
+func TestHostLimiterAvailable(t *testing.T) {
+	var disabled *hostLimiter
+	if !disabled.available("example.com") {
+		t.Fatal("expected a disabled limiter to allow everything")
+	}
+
+	l := newHostLimiter(1, 10)
+	if !l.available("example.com") || !l.tryAcquire("example.com") {
+		t.Fatal("expected a slot for the host")
+	}
+
+	if l.available("example.com") {
+		t.Error("expected the host to be saturated")
+	}
+
+	if got := l.activity()["example.com"].InFlight; got != 1 {
+		t.Errorf("available() took a slot, in flight = %d", got)
+	}
+}
+
+func TestHostLimiterDeferredCap(t *testing.T) {
+	var disabled *hostLimiter
+	if disabled.full() || disabled.drain() != 0 {
+		t.Fatal("expected a disabled limiter to never be full")
+	}
+
+	l := newHostLimiter(1, 2)
+	if !l.tryAcquire("example.com") {
+		t.Fatal("expected a slot for the host")
+	}
+
+	l.deferItem("example.com", newLimitedSeed(t, "http://example.com/a"))
+	if l.full() {
+		t.Fatal("expected room for another deferred seed")
+	}
+
+	l.deferItem("example.com", newLimitedSeed(t, "http://example.com/b"))
+	if !l.full() {
+		t.Fatal("expected the deferred seeds to be at their cap")
+	}
+
+	// Resuming a seed makes room for another
+	l.release("example.com")
+	if item, _ := l.popReady(); item == nil || l.full() {
+		t.Fatalf("expected a resumed seed to make room, got %v", item)
+	}
+
+	// The seeds still deferred when stopping are forgotten, the source resets them
+	if drained := l.drain(); drained != 1 {
+		t.Errorf("drain() = %d, want 1", drained)
+	}
+
+	if l.full() || l.activity()["example.com"].Deferred != 0 {
+		t.Error("expected no deferred seed left")
+	}
+}
@@ -0,0 +1,113 @@
+package archiver
+
+import (
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/philippgille/gokv/leveldb"
+)
+
+// validators are the cache validators of a capture, sent back in the conditional requests of the next runs
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// Date of the capture, referred to by the revisit records
+	Date string `json:"date"`
+}
+
+// validatorStore keeps the validators of the captured URLs in the job's directory, for incremental crawls
+type validatorStore struct {
+	db leveldb.Store
+}
+
+var globalValidators *validatorStore
+
+func openValidatorStore(jobPath string) (*validatorStore, error) {
+	db, err := leveldb.NewStore(leveldb.Options{Path: path.Join(jobPath, "validators")})
+	if err != nil {
+		return nil, err
+	}
+
+	return &validatorStore{db: db}, nil
+}
+
+func (s *validatorStore) close() error {
+	if s == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+// conditional makes req conditional if the URL was captured by a previous run,
+// the validators of the previous capture are returned
+func (s *validatorStore) conditional(req *http.Request) *validators {
+	if s == nil {
+		return nil
+	}
+
+	var previous validators
+	found, err := s.db.Get(req.URL.String(), &previous)
+	if err != nil {
+		logger.Error("unable to read validators", "err", err.Error(), "url", req.URL.String())
+		return nil
+	}
+
+	if !found {
+		return nil
+	}
+
+	if previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+
+	if previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+
+	return &previous
+}
+
+// remember keeps the validators of a successful capture, if the server sent any
+func (s *validatorStore) remember(req *http.Request, resp *http.Response) {
+	if s == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	captured := validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Date:         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if captured.ETag == "" && captured.LastModified == "" {
+		return
+	}
+
+	if err := s.db.Set(req.URL.String(), captured); err != nil {
+		logger.Error("unable to save validators", "err", err.Error(), "url", req.URL.String())
+	}
+}
+
+// writeNotModifiedRevisit writes a revisit record with the server-not-modified profile for a 304 answer
+// to a conditional request. The WARC library also writes the 304 exchange as a response record,
+// the revisit record is what ties it to the previous capture for replay tools.
+func writeNotModifiedRevisit(w RecordWriter, targetURI string, resp *http.Response, previous *validators) error {
+	record := warc.NewRecord(config.Get().WARCTempDir, config.Get().WARCOnDisk)
+	record.Header.Set("WARC-Type", "revisit")
+	record.Header.Set("WARC-Target-URI", targetURI)
+	record.Header.Set("Content-Type", "application/http; msgtype=response")
+	record.Header.Set("WARC-Profile", "http://netpreserve.org/warc/1.1/revisit/server-not-modified")
+	record.Header.Set("WARC-Refers-To-Target-URI", targetURI)
+	record.Header.Set("WARC-Refers-To-Date", previous.Date)
+
+	if err := writeResponseHead(record.Content, resp); err != nil {
+		record.Content.Close()
+		return err
+	}
+
+	return w.WriteRecord(record)
+}
@@ -0,0 +1,168 @@
+package archiver
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestValidatorStore(t *testing.T) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	jobPath := t.TempDir()
+
+	s, err := openValidatorStore(jobPath)
+	if err != nil {
+		t.Fatalf("openValidatorStore() error = %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/page", nil)
+	if previous := s.conditional(req); previous != nil || req.Header.Get("If-None-Match") != "" {
+		t.Fatal("expected a URL never captured not to be conditional")
+	}
+
+	s.remember(req, &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": []string{`"abc"`}, "Last-Modified": []string{"Wed, 21 Oct 2015 07:28:00 GMT"}},
+	})
+
+	// Without validators, or on errors, nothing is remembered
+	noValidators, _ := http.NewRequest(http.MethodGet, "http://example.com/dynamic", nil)
+	s.remember(noValidators, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}})
+
+	notFound, _ := http.NewRequest(http.MethodGet, "http://example.com/missing", nil)
+	s.remember(notFound, &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{"Etag": []string{`"404"`}}})
+
+	if err := s.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	// The validators are found by the next run
+	s, err = openValidatorStore(jobPath)
+	if err != nil {
+		t.Fatalf("openValidatorStore() error = %v", err)
+	}
+	defer s.close()
+
+	req, _ = http.NewRequest(http.MethodGet, "http://example.com/page", nil)
+	previous := s.conditional(req)
+	if previous == nil || previous.Date == "" {
+		t.Fatalf("expected the previous capture to be found, got %+v", previous)
+	}
+
+	if got := req.Header.Get("If-None-Match"); got != `"abc"` {
+		t.Errorf("If-None-Match = %q", got)
+	}
+
+	if got := req.Header.Get("If-Modified-Since"); got != "Wed, 21 Oct 2015 07:28:00 GMT" {
+		t.Errorf("If-Modified-Since = %q", got)
+	}
+
+	for _, URL := range []string{"http://example.com/dynamic", "http://example.com/missing"} {
+		req, _ := http.NewRequest(http.MethodGet, URL, nil)
+		if previous := s.conditional(req); previous != nil {
+			t.Errorf("expected no validators for %s, got %+v", URL, previous)
+		}
+	}
+
+	// Without incremental crawl, nothing happens
+	var disabled *validatorStore
+	if disabled.conditional(req) != nil || disabled.close() != nil {
+		t.Error("expected a nil validator store to be a no-op")
+	}
+}
+
+func TestWriteNotModifiedRevisit(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+	}(config.Get().JobPath, config.Get().WARCPoolSize)
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+
+	resp := &http.Response{
+		Status:     "304 Not Modified",
+		StatusCode: http.StatusNotModified,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Etag": []string{`"abc"`}},
+	}
+
+	client, release := globalArchiver.acquireClient("")
+	err := writeNotModifiedRevisit(globalArchiver.recordWriter(client), "http://example.com/page", resp, &validators{ETag: `"abc"`, Date: "2025-01-02T03:04:05Z"})
+	if err != nil {
+		t.Fatalf("writeNotModifiedRevisit() error = %v", err)
+	}
+	release()
+
+	globalArchiver.Client.WaitGroup.Wait()
+	globalArchiver.Client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 WARC file, got %q (err: %v)", files, err)
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		record, eol, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord() error = %v", err)
+		}
+		if eol {
+			t.Fatal("no revisit record found")
+		}
+
+		if record.Header.Get("WARC-Type") != "revisit" {
+			continue
+		}
+
+		if profile := record.Header.Get("WARC-Profile"); profile != "http://netpreserve.org/warc/1.1/revisit/server-not-modified" {
+			t.Errorf("unexpected WARC-Profile %q", profile)
+		}
+
+		if date := record.Header.Get("WARC-Refers-To-Date"); date != "2025-01-02T03:04:05Z" {
+			t.Errorf("unexpected WARC-Refers-To-Date %q", date)
+		}
+
+		if uri := record.Header.Get("WARC-Refers-To-Target-URI"); uri != "http://example.com/page" {
+			t.Errorf("unexpected WARC-Refers-To-Target-URI %q", uri)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,95 @@
+package archiver
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// crawlLimit counts the URLs crawled against --max-crawled-items. A slot is taken before each
+// capture, so the workers can't go past the limit however many of them are capturing at the same time.
+type crawlLimit struct {
+	max     int64
+	scope   string
+	count   atomic.Int64
+	reached chan struct{}
+	once    sync.Once
+}
+
+func newCrawlLimit(max int64, scope string) *crawlLimit {
+	return &crawlLimit{
+		max:     max,
+		scope:   scope,
+		reached: make(chan struct{}),
+	}
+}
+
+// take reserves the capture of the item, it returns false if the limit is reached. A requeued item
+// reserved its capture on its first attempt.
+func (l *crawlLimit) take(item *models.Item) bool {
+	if l == nil || l.max <= 0 || item.GetAttempts() > 0 || !l.counts(item) {
+		return true
+	}
+
+	for {
+		count := l.count.Load()
+		if count >= l.max {
+			return false
+		}
+
+		if l.count.CompareAndSwap(count, count+1) {
+			stats.CrawlLimitItemsSet(count + 1)
+			if count+1 == l.max {
+				l.once.Do(func() { close(l.reached) })
+			}
+			return true
+		}
+	}
+}
+
+// isReached returns true once the limit is reached, the seeds aren't archived anymore then
+func (l *crawlLimit) isReached() bool {
+	return l != nil && l.max > 0 && l.count.Load() >= l.max
+}
+
+// counts tells if the item is counted against the limit depending on its scope
+func (l *crawlLimit) counts(item *models.Item) bool {
+	switch l.scope {
+	case "seeds":
+		return !isAsset(item)
+	case "assets":
+		return isAsset(item)
+	default:
+		return true
+	}
+}
+
+// isAsset returns true if the item is an asset or a redirection of an asset
+func isAsset(item *models.Item) bool {
+	for item.IsRedirection() {
+		item = item.GetParent()
+	}
+
+	return item.IsChild()
+}
+
+// itemType returns the type of the item in the metrics, seed or asset
+func itemType(item *models.Item) string {
+	if isAsset(item) {
+		return "asset"
+	}
+
+	return "seed"
+}
+
+// CrawlLimitReached returns a channel closed once --max-crawled-items URLs are crawled,
+// it is never closed if there is no limit
+func CrawlLimitReached() <-chan struct{} {
+	if globalCrawlLimit == nil || globalCrawlLimit.max <= 0 {
+		return nil
+	}
+
+	return globalCrawlLimit.reached
+}
@@ -0,0 +1,117 @@
+package archiver
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newLimitTestItem(t *testing.T, rawURL string) *models.Item {
+	t.Helper()
+
+	u := &models.URL{Raw: rawURL}
+	if err := u.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return models.NewItem(rawURL, u, "")
+}
+
+func TestCrawlLimitConcurrentTakes(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	limit := newCrawlLimit(50, "all")
+	item := newLimitTestItem(t, "http://example.com/")
+
+	var (
+		wg    sync.WaitGroup
+		taken atomic.Int64
+	)
+	for range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limit.take(item) {
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if taken.Load() != 50 {
+		t.Errorf("expected 50 captures, got %d", taken.Load())
+	}
+
+	if !limit.isReached() {
+		t.Error("expected the limit to be reached")
+	}
+
+	select {
+	case <-limit.reached:
+	default:
+		t.Error("expected the reached channel to be closed")
+	}
+
+	if got := stats.CrawlLimitItemsGet(); got != 50 {
+		t.Errorf("expected the stats to report 50 items, got %d", got)
+	}
+}
+
+func TestCrawlLimitScope(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	page := newLimitTestItem(t, "http://example.com/")
+	image := newLimitTestItem(t, "http://example.com/image.png")
+	if err := page.AddChild(image, models.ItemGotChildren); err != nil {
+		t.Fatalf("AddChild() error = %v", err)
+	}
+
+	// The redirection of an asset is an asset
+	script := newLimitTestItem(t, "http://example.com/script.js")
+	if err := page.AddChild(script, models.ItemGotChildren); err != nil {
+		t.Fatalf("AddChild() error = %v", err)
+	}
+	redirectedScript := newLimitTestItem(t, "http://cdn.example.com/script.js")
+	if err := script.AddChild(redirectedScript, models.ItemGotRedirected); err != nil {
+		t.Fatalf("AddChild() error = %v", err)
+	}
+
+	seeds := newCrawlLimit(1, "seeds")
+	if !seeds.take(image) || !seeds.take(redirectedScript) {
+		t.Error("expected the assets not to be counted with the seeds scope")
+	}
+	if !seeds.take(page) {
+		t.Error("expected the first seed to be captured")
+	}
+	if seeds.take(newLimitTestItem(t, "http://example.org/")) {
+		t.Error("expected the second seed to be over the limit")
+	}
+
+	// A requeued seed reserved its capture on its first attempt
+	page.IncrAttempts()
+	if !seeds.take(page) || seeds.count.Load() != 1 {
+		t.Errorf("expected the requeued seed to be captured without counting it again, count %d", seeds.count.Load())
+	}
+
+	assets := newCrawlLimit(1, "assets")
+	if !assets.take(page) {
+		t.Error("expected the seeds not to be counted with the assets scope")
+	}
+	if !assets.take(redirectedScript) {
+		t.Error("expected the first asset to be captured")
+	}
+	if assets.take(image) {
+		t.Error("expected the second asset to be over the limit")
+	}
+
+	var unlimited *crawlLimit
+	if !unlimited.take(page) || unlimited.isReached() {
+		t.Error("expected no limit when it isn't configured")
+	}
+}
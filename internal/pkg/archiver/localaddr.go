@@ -0,0 +1,124 @@
+package archiver
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// localAddresses binds the connections of the WARC clients, the ones made to the proxies too, to
+// --local-address or, per connection and round-robin, to the addresses of --local-cidr assigned to
+// the interfaces. The address of a connection is picked among the ones of the version of the address
+// connected to when it's known.
+type localAddresses struct {
+	IPs  []net.IP
+	next atomic.Uint64
+}
+
+// newLocalAddresses returns nil without --local-address and --local-cidr, it returns an error if none
+// of the addresses is assigned to an interface
+func newLocalAddresses(address, CIDR string) (*localAddresses, error) {
+	if address == "" && CIDR == "" {
+		return nil, nil
+	}
+
+	assigned, err := interfaceAddresses()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the addresses of the interfaces: %w", err)
+	}
+
+	l := new(localAddresses)
+
+	if address != "" {
+		IP := net.ParseIP(address)
+		if IP == nil {
+			return nil, fmt.Errorf("invalid local address %q", address)
+		}
+
+		for _, candidate := range assigned {
+			if candidate.Equal(IP) {
+				l.IPs = append(l.IPs, candidate)
+				break
+			}
+		}
+
+		if len(l.IPs) == 0 {
+			return nil, fmt.Errorf("local address %s isn't assigned to any interface", IP)
+		}
+
+		return l, nil
+	}
+
+	_, prefix, err := net.ParseCIDR(CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local CIDR %q: %w", CIDR, err)
+	}
+
+	for _, candidate := range assigned {
+		if prefix.Contains(candidate) {
+			l.IPs = append(l.IPs, candidate)
+		}
+	}
+
+	if len(l.IPs) == 0 {
+		return nil, fmt.Errorf("no address of %s is assigned to an interface", prefix)
+	}
+
+	return l, nil
+}
+
+// interfaceAddresses returns the addresses assigned to the interfaces that are up
+func interfaceAddresses() (IPs []net.IP, err error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				IPs = append(IPs, ipNet.IP)
+			}
+		}
+	}
+
+	return IPs, nil
+}
+
+// pick returns the local address of a connection to IP, nil if it isn't known, it's the LocalAddr of the
+// WARC clients' settings
+func (l *localAddresses) pick(network string, IP net.IP) net.Addr {
+	candidates := l.IPs
+	if IP != nil {
+		var sameVersion []net.IP
+		for _, candidate := range l.IPs {
+			if (candidate.To4() != nil) == (IP.To4() != nil) {
+				sameVersion = append(sameVersion, candidate)
+			}
+		}
+
+		// A connection to the other version fails, the crawl traffic never leaves from another address
+		if len(sameVersion) > 0 {
+			candidates = sameVersion
+		}
+	}
+
+	local := candidates[(l.next.Add(1)-1)%uint64(len(candidates))]
+
+	logger.Debug("binding connection", "local_address", local.String(), "remote_address", IP, "network", network)
+
+	if network == "udp" || network == "udp4" || network == "udp6" {
+		return &net.UDPAddr{IP: local}
+	}
+
+	return &net.TCPAddr{IP: local}
+}
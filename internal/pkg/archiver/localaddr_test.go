@@ -0,0 +1,138 @@
+package archiver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestNewLocalAddresses(t *testing.T) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	if locals, err := newLocalAddresses("", ""); locals != nil || err != nil {
+		t.Errorf("newLocalAddresses() = %v, %v without address, want nil", locals, err)
+	}
+
+	locals, err := newLocalAddresses("127.0.0.1", "")
+	if err != nil || len(locals.IPs) != 1 || !locals.IPs[0].Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("newLocalAddresses(127.0.0.1) = %v, %v, want the address", locals, err)
+	}
+
+	locals, err = newLocalAddresses("", "127.0.0.0/8")
+	if err != nil || len(locals.IPs) == 0 {
+		t.Errorf("newLocalAddresses(127.0.0.0/8) = %v, %v, want the loopback addresses", locals, err)
+	}
+
+	// The addresses must be assigned to an interface
+	if _, err := newLocalAddresses("198.51.100.7", ""); err == nil {
+		t.Error("newLocalAddresses(198.51.100.7) expected an error for an address that isn't assigned")
+	}
+	if _, err := newLocalAddresses("", "198.51.100.0/29"); err == nil {
+		t.Error("newLocalAddresses(198.51.100.0/29) expected an error for a prefix without assigned address")
+	}
+}
+
+func TestLocalAddressesPick(t *testing.T) {
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	locals := &localAddresses{IPs: []net.IP{net.ParseIP("192.0.2.8"), net.ParseIP("2001:db8::8"), net.ParseIP("192.0.2.9")}}
+
+	// The addresses of the version connected to are picked round-robin
+	var picked []string
+	for range 4 {
+		picked = append(picked, locals.pick("tcp", net.ParseIP("203.0.113.1")).String())
+	}
+	if want := []string{"192.0.2.8:0", "192.0.2.9:0", "192.0.2.8:0", "192.0.2.9:0"}; !slices.Equal(picked, want) {
+		t.Errorf("pick() to an IPv4 address = %v, want %v", picked, want)
+	}
+
+	if addr := locals.pick("tcp", net.ParseIP("2001:db8::1")); addr.String() != "[2001:db8::8]:0" {
+		t.Errorf("pick() to an IPv6 address = %v, want the IPv6 one", addr)
+	}
+
+	// The connections to the proxies get any of them
+	if addr, ok := locals.pick("tcp", nil).(*net.TCPAddr); !ok || addr.IP == nil {
+		t.Errorf("pick() without remote address = %v, want one of the addresses", addr)
+	}
+}
+
+func TestLocalAddressCapture(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression, proxy, localAddress string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().Proxy = proxy
+		config.Get().LocalAddress = localAddress
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().Proxy, config.Get().LocalAddress)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	// An address of another interface than the loopback one, that the connections to 127.0.0.1 don't use by default
+	assigned, err := interfaceAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var local net.IP
+	for _, IP := range assigned {
+		if IP.To4() != nil && !IP.IsLoopback() {
+			local = IP
+			break
+		}
+	}
+	if local == nil {
+		t.Skip("no IPv4 address assigned to an interface other than the loopback one")
+	}
+
+	remoteHosts := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remoteHosts <- host
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().Proxy = ""
+	config.Get().LocalAddress = local.String()
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+	client := globalArchiver.Client
+	defer client.Close()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if host := <-remoteHosts; host != local.String() {
+		t.Errorf("expected the connection to come from %s, got %s", local, host)
+	}
+}
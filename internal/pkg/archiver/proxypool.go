@@ -0,0 +1,287 @@
+package archiver
+
+import (
+	"errors"
+	"hash/fnv"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// proxyPool picks the proxy of each request among the configured ones, per request (round-robin)
+// or per host (a host always goes through the same proxy while it's healthy). A proxy failing
+// maxFailures times in a row is taken out of the rotation for the cooldown, then re-admitted.
+// The proxies of --proxy-file are replaced when it's reloaded, the index of a proxy is the one
+// of its client in ClientsWithProxyPool: both are replaced together, under clientsMu.
+type proxyPool struct {
+	perHost     bool
+	maxFailures int
+	cooldown    time.Duration
+
+	mu      sync.Mutex
+	next    int
+	proxies []*pooledProxy
+}
+
+type pooledProxy struct {
+	address       string
+	failures      int
+	disabledUntil time.Time
+}
+
+func newProxyPool(addresses []string, rotation string, maxFailures int, cooldown time.Duration) *proxyPool {
+	pool := &proxyPool{
+		perHost:     rotation == "per-host",
+		maxFailures: max(maxFailures, 1),
+		cooldown:    cooldown,
+	}
+
+	for _, address := range addresses {
+		pool.proxies = append(pool.proxies, &pooledProxy{address: address})
+	}
+
+	return pool
+}
+
+// poolProxies returns the proxies of --proxy-pool followed by fileProxies, the ones of --proxy-file,
+// without duplicates
+func poolProxies(fileProxies []string) (proxies []string) {
+	for _, proxy := range append(slices.Clone(config.Get().ProxyPool), fileProxies...) {
+		if !slices.Contains(proxies, proxy) {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+// addresses returns the addresses of the proxies, in the order of their index
+func (p *proxyPool) addresses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	addresses := make([]string, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		addresses = append(addresses, proxy.address)
+	}
+
+	return addresses
+}
+
+// replace replaces the proxies of the pool, the ones kept keep their failures and cooldown
+func (p *proxyPool) replace(addresses []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	kept := make(map[string]*pooledProxy, len(p.proxies))
+	for _, proxy := range p.proxies {
+		kept[proxy.address] = proxy
+	}
+
+	p.proxies = make([]*pooledProxy, 0, len(addresses))
+	for _, address := range addresses {
+		proxy, found := kept[address]
+		if !found {
+			proxy = &pooledProxy{address: address}
+		}
+
+		p.proxies = append(p.proxies, proxy)
+	}
+
+	p.next = 0
+}
+
+// pick returns the index of the proxy to use for a request to host. If all the proxies are out
+// of the rotation, the one coming back the soonest is used rather than blocking the request.
+func (p *proxyPool) pick(host string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	start := p.next
+	if p.perHost {
+		hash := fnv.New32a()
+		hash.Write([]byte(strings.ToLower(host)))
+		start = int(hash.Sum32() % uint32(len(p.proxies)))
+	}
+
+	soonest := start
+	for offset := range p.proxies {
+		index := (start + offset) % len(p.proxies)
+
+		if !p.proxies[index].disabledUntil.After(now) {
+			if !p.proxies[index].disabledUntil.IsZero() {
+				p.proxies[index].disabledUntil = time.Time{}
+				logger.Info("proxy re-admitted in the rotation", "proxy", redactProxy(p.proxies[index].address))
+			}
+
+			if !p.perHost {
+				p.next = (index + 1) % len(p.proxies)
+			}
+
+			return index
+		}
+
+		if p.proxies[index].disabledUntil.Before(p.proxies[soonest].disabledUntil) {
+			soonest = index
+		}
+	}
+
+	return soonest
+}
+
+// report records the outcome of a request made through the proxy at address. The proxies removed from
+// the pool by a reload are only counted in the stats.
+func (p *proxyPool) report(address string, failed bool) {
+	stats.ProxyRequestIncr(redactProxy(address), failed)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := slices.IndexFunc(p.proxies, func(proxy *pooledProxy) bool { return proxy.address == address })
+	if index == -1 {
+		return
+	}
+
+	proxy := p.proxies[index]
+
+	if !failed {
+		proxy.failures = 0
+		return
+	}
+
+	proxy.failures++
+	if proxy.failures < p.maxFailures {
+		return
+	}
+
+	proxy.failures = 0
+	proxy.disabledUntil = time.Now().Add(p.cooldown)
+
+	logger.Warn("proxy removed from the rotation", "proxy", redactProxy(proxy.address), "cooldown", p.cooldown.String())
+}
+
+// proxyReloader reloads --proxy-file at every SIGHUP and, if interval is positive, every interval,
+// until the archiver stops. SIGHUP is caught as long as it runs.
+func (a *archiver) proxyReloader(path string, interval time.Duration) {
+	defer a.wg.Done()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGHUP)
+	defer signal.Stop(signalChan)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-signalChan:
+		case <-tick:
+		}
+
+		if err := a.reloadProxies(path); err != nil {
+			logger.Error("unable to reload proxy file, keeping the current proxies", "err", err.Error(), "path", path, "func", "archiver.proxyReloader")
+		}
+	}
+}
+
+// reloadProxies replaces the proxies of the pool by the ones of --proxy-pool and of the proxy file
+// at path. The clients of the proxies kept are kept, the ones of the new proxies are created and
+// the ones of the removed proxies are closed once the captures using them are done.
+func (a *archiver) reloadProxies(path string) error {
+	fileProxies, err := config.ReadProxyFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(fileProxies) == 0 {
+		return errors.New("no proxy found")
+	}
+
+	a.replaceMu.Lock()
+	defer a.replaceMu.Unlock()
+
+	proxies := poolProxies(fileProxies)
+	current := globalProxyPool.addresses()
+	if slices.Equal(proxies, current) {
+		return nil
+	}
+
+	a.clientsMu.RLock()
+	kept := make(map[string]*warc.CustomHTTPClient, len(current))
+	for index, address := range current {
+		kept[address] = a.ClientsWithProxyPool[index]
+	}
+	a.clientsMu.RUnlock()
+
+	var (
+		clients = make([]*warc.CustomHTTPClient, 0, len(proxies))
+		created []*warc.CustomHTTPClient
+	)
+	for _, proxy := range proxies {
+		if client, found := kept[proxy]; found {
+			clients = append(clients, client)
+			delete(kept, proxy)
+			continue
+		}
+
+		client, err := newWARCClient(warcSettings, proxy)
+		if err != nil {
+			for _, createdClient := range created {
+				createdClient.Close()
+			}
+
+			return err
+		}
+
+		clients = append(clients, client)
+		created = append(created, client)
+	}
+
+	a.clientsMu.Lock()
+	globalProxyPool.replace(proxies)
+	a.ClientsWithProxyPool = clients
+	a.clientsMu.Unlock()
+
+	// Wait for the captures still holding the clients of the removed proxies
+	for _, removed := range kept {
+		a.closeClient(removed)
+	}
+
+	logger.Info("proxy file reloaded", "path", path, "proxies", len(proxies), "added", len(created), "removed", len(kept))
+
+	return nil
+}
+
+// redactProxy hides the credentials of a proxy address in the logs
+func redactProxy(address string) string {
+	scheme, rest, found := strings.Cut(address, "://")
+	if !found {
+		rest, scheme = address, ""
+	}
+
+	if at := strings.LastIndex(rest, "@"); at != -1 {
+		rest = "xxxxx@" + rest[at+1:]
+	}
+
+	if scheme == "" {
+		return rest
+	}
+
+	return scheme + "://" + rest
+}
@@ -0,0 +1,71 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GlobalLimiter caps the rate of all the requests of the crawl, whatever their host.
+// Each request reserves the next free slot in arrival order, so that the requests are served
+// first come, first served and no worker or asset capture can be starved by the others.
+type GlobalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // time between two slots
+	next     time.Time     // next free slot
+
+	// nowFunc is used to fetch the current time; it defaults to time.Now,
+	// but can be overridden for testing.
+	nowFunc func() time.Time
+}
+
+// NewGlobalLimiter returns a limiter allowing qps requests per second, or nil if qps isn't positive
+func NewGlobalLimiter(qps float64) *GlobalLimiter {
+	if qps <= 0 {
+		return nil
+	}
+
+	return &GlobalLimiter{
+		interval: time.Duration(float64(time.Second) / qps),
+		nowFunc:  time.Now,
+	}
+}
+
+// reserve books the next free slot and returns how long to wait for it
+func (gl *GlobalLimiter) reserve() time.Duration {
+	gl.mu.Lock()
+	defer gl.mu.Unlock()
+
+	now := gl.nowFunc()
+
+	slot := gl.next
+	if slot.Before(now) {
+		slot = now
+	}
+	gl.next = slot.Add(gl.interval)
+
+	return slot.Sub(now)
+}
+
+// Wait blocks until the request's slot comes or the context is done, and returns how long it waited.
+// A nil limiter doesn't wait.
+func (gl *GlobalLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	if gl == nil {
+		return 0, nil
+	}
+
+	delay := gl.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return delay, nil
+	}
+}
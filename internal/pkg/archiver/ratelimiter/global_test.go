@@ -0,0 +1,77 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewGlobalLimiterDisabled(t *testing.T) {
+	for _, qps := range []float64{0, -1} {
+		if NewGlobalLimiter(qps) != nil {
+			t.Errorf("NewGlobalLimiter(%v) should be disabled", qps)
+		}
+	}
+
+	// A disabled limiter never waits
+	var gl *GlobalLimiter
+	if waited, err := gl.Wait(context.Background()); waited != 0 || err != nil {
+		t.Errorf("nil limiter Wait() = %v, %v", waited, err)
+	}
+}
+
+func TestGlobalLimiterReserve(t *testing.T) {
+	now := time.Now()
+	gl := NewGlobalLimiter(10)
+	gl.nowFunc = func() time.Time { return now }
+
+	// Slots are handed out in arrival order, 100ms apart
+	for i := 0; i < 5; i++ {
+		if delay := gl.reserve(); delay != time.Duration(i)*100*time.Millisecond {
+			t.Errorf("reservation %d: delay = %v, want %v", i, delay, time.Duration(i)*100*time.Millisecond)
+		}
+	}
+
+	// Once idle, the next request doesn't wait and unused slots aren't accumulated
+	now = now.Add(10 * time.Second)
+	if delay := gl.reserve(); delay != 0 {
+		t.Errorf("delay after idling = %v, want 0", delay)
+	}
+	if delay := gl.reserve(); delay != 100*time.Millisecond {
+		t.Errorf("delay of the second request after idling = %v, want 100ms", delay)
+	}
+}
+
+func TestGlobalLimiterWait(t *testing.T) {
+	gl := NewGlobalLimiter(100)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := gl.Wait(context.Background()); err != nil {
+				t.Errorf("Wait() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 20 requests at 100 QPS take at least 190ms
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("20 requests took %v, expected at least 190ms", elapsed)
+	}
+
+	// Waiting is aborted when the context is done
+	gl = NewGlobalLimiter(0.1)
+	gl.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := gl.Wait(ctx); err == nil {
+		t.Errorf("expected Wait() to return the context error")
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimiter
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostDelays spaces the requests of the hosts configured with their own politeness delay,
+// instead of the per-host token buckets. A delay applies to its host and to the host's subdomains,
+// the most specific host wins. Each host gets its own spacing.
+type HostDelays struct {
+	mu       sync.Mutex
+	delays   map[string]time.Duration
+	limiters map[string]*GlobalLimiter
+}
+
+// NewHostDelays returns the limiter of the hosts with their own delay, or nil if there is none
+func NewHostDelays(delays map[string]time.Duration) *HostDelays {
+	if len(delays) == 0 {
+		return nil
+	}
+
+	hd := &HostDelays{
+		delays:   make(map[string]time.Duration, len(delays)),
+		limiters: make(map[string]*GlobalLimiter),
+	}
+
+	for host, delay := range delays {
+		hd.delays[strings.ToLower(host)] = delay
+	}
+
+	return hd
+}
+
+// Delay returns the delay configured for the host, if any
+func (hd *HostDelays) Delay(host string) (delay time.Duration, found bool) {
+	if hd == nil {
+		return 0, false
+	}
+
+	host = strings.ToLower(host)
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+
+	// Look for the host, then for its parent domains
+	for {
+		if delay, found := hd.delays[host]; found {
+			return delay, true
+		}
+
+		i := strings.IndexByte(host, '.')
+		if i == -1 {
+			return 0, false
+		}
+		host = host[i+1:]
+	}
+}
+
+// Limiter returns the limiter spacing the requests of the host with its delay, nil if the host
+// has none. created is true the first time the limiter of the host is returned.
+func (hd *HostDelays) Limiter(host string) (limiter *GlobalLimiter, delay time.Duration, created bool) {
+	delay, found := hd.Delay(host)
+	if !found {
+		return nil, 0, false
+	}
+
+	hd.mu.Lock()
+	defer hd.mu.Unlock()
+
+	limiter, found = hd.limiters[host]
+	if !found {
+		limiter = &GlobalLimiter{
+			interval: delay,
+			nowFunc:  time.Now,
+		}
+		hd.limiters[host] = limiter
+	}
+
+	return limiter, delay, !found
+}
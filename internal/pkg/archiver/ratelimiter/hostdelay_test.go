@@ -0,0 +1,74 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostDelaysDelay(t *testing.T) {
+	hd := NewHostDelays(map[string]time.Duration{
+		"example.com":      2 * time.Second,
+		"slow.example.com": 10 * time.Second,
+	})
+
+	tests := []struct {
+		host  string
+		delay time.Duration
+		found bool
+	}{
+		{"example.com", 2 * time.Second, true},
+		{"www.Example.com", 2 * time.Second, true},
+		{"example.com:8080", 2 * time.Second, true},
+		{"slow.example.com", 10 * time.Second, true},
+		{"a.slow.example.com", 10 * time.Second, true},
+		{"notexample.com", 0, false},
+		{"example.org", 0, false},
+	}
+
+	for _, tt := range tests {
+		delay, found := hd.Delay(tt.host)
+		if delay != tt.delay || found != tt.found {
+			t.Errorf("Delay(%q) = %v, %v, want %v, %v", tt.host, delay, found, tt.delay, tt.found)
+		}
+	}
+
+	if NewHostDelays(nil) != nil {
+		t.Error("expected no limiter without delays")
+	}
+
+	var disabled *HostDelays
+	if limiter, _, _ := disabled.Limiter("example.com"); limiter != nil {
+		t.Error("expected a nil HostDelays to return no limiter")
+	}
+}
+
+func TestHostDelaysLimiter(t *testing.T) {
+	hd := NewHostDelays(map[string]time.Duration{"example.com": time.Second})
+
+	limiter, delay, created := hd.Limiter("www.example.com")
+	if limiter == nil || delay != time.Second || !created {
+		t.Fatalf("Limiter() = %v, %v, %v, want a new limiter with a 1s delay", limiter, delay, created)
+	}
+
+	again, _, created := hd.Limiter("www.example.com")
+	if again != limiter || created {
+		t.Error("expected the limiter of the host to be reused")
+	}
+
+	// Each host gets its own spacing
+	if other, _, created := hd.Limiter("example.com"); other == limiter || !created {
+		t.Error("expected another host to get its own limiter")
+	}
+
+	// The second request of the host waits for the delay
+	now := time.Now()
+	limiter.nowFunc = func() time.Time { return now }
+	limiter.reserve()
+	if wait := limiter.reserve(); wait != time.Second {
+		t.Errorf("expected the second request to wait 1s, got %v", wait)
+	}
+
+	if limiter, _, _ := hd.Limiter("example.org"); limiter != nil {
+		t.Error("expected no limiter for a host without delay")
+	}
+}
@@ -0,0 +1,31 @@
+package archiver
+
+import (
+	"github.com/CorentinB/warc"
+)
+
+// RecordWriter receives the WARC records built by Zeno itself: the metadata, resource, revisit and truncated
+// response records along with their requests. The other captures are written by the WARC writing clients.
+type RecordWriter interface {
+	WriteRecord(record *warc.Record) error
+}
+
+// clientRecordWriter writes the records with the WARC writer of a client, in the same files as its captures
+type clientRecordWriter struct {
+	client *warc.CustomHTTPClient
+}
+
+func (w *clientRecordWriter) WriteRecord(record *warc.Record) error {
+	writeRecordBatch(w.client, record)
+	return nil
+}
+
+// recordWriter returns where the records of the captures made with client are written,
+// the record writer of the archiver if it's set, e.g. an in-memory one in the tests
+func (a *archiver) recordWriter(client *warc.CustomHTTPClient) RecordWriter {
+	if a != nil && a.records != nil {
+		return a.records
+	}
+
+	return &clientRecordWriter{client: client}
+}
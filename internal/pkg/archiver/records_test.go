@@ -0,0 +1,136 @@
+package archiver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// memoryRecord is a record kept by memoryRecordWriter, with its content read
+type memoryRecord struct {
+	header  warc.Header
+	content string
+}
+
+// memoryRecordWriter keeps the records in memory, so that the tests check them without WARC files
+type memoryRecordWriter struct {
+	mu      sync.Mutex
+	records []memoryRecord
+}
+
+func (w *memoryRecordWriter) WriteRecord(record *warc.Record) error {
+	defer record.Content.Close()
+
+	if _, err := record.Content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	content, err := io.ReadAll(record.Content)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.records = append(w.records, memoryRecord{header: record.Header, content: string(content)})
+
+	return nil
+}
+
+// withMemoryRecordWriter replaces the archiver by one writing its records in memory for the duration of the test
+func withMemoryRecordWriter(t *testing.T) *memoryRecordWriter {
+	t.Helper()
+
+	config.InitConfig()
+
+	previous := globalArchiver
+	t.Cleanup(func() { globalArchiver = previous })
+
+	records := &memoryRecordWriter{}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel, records: records}
+
+	return records
+}
+
+func TestWriteMetadataRecordToRecordWriter(t *testing.T) {
+	records := withMemoryRecordWriter(t)
+
+	if err := WriteMetadataRecord("http://example.com/", "<urn:uuid:response>", "application/json", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("WriteMetadataRecord() error = %v", err)
+	}
+
+	if err := WriteResourceRecord("http://example.com/screenshot", "image/png", []byte("png")); err != nil {
+		t.Fatalf("WriteResourceRecord() error = %v", err)
+	}
+
+	if len(records.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records.records))
+	}
+
+	metadata, resource := records.records[0], records.records[1]
+	if metadata.header.Get("WARC-Type") != "metadata" || metadata.header.Get("WARC-Target-URI") != "http://example.com/" || metadata.header.Get("WARC-Concurrent-To") != "<urn:uuid:response>" || metadata.header.Get("Content-Type") != "application/json" || metadata.content != `{"a":1}` {
+		t.Errorf("unexpected metadata record %v %q", metadata.header, metadata.content)
+	}
+
+	if resource.header.Get("WARC-Type") != "resource" || resource.header.Get("WARC-Concurrent-To") != "" || resource.header.Get("Content-Type") != "image/png" || resource.content != "png" {
+		t.Errorf("unexpected resource record %v %q", resource.header, resource.content)
+	}
+}
+
+func TestWriteConnectionMetadataToRecordWriter(t *testing.T) {
+	records := withMemoryRecordWriter(t)
+
+	info := &connectionInfo{remoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 443}}
+	if err := writeConnectionMetadata(globalArchiver.recordWriter(nil), "https://example.com/", info); err != nil {
+		t.Fatalf("writeConnectionMetadata() error = %v", err)
+	}
+
+	// Without a connection, e.g. when the request failed, there is nothing to write
+	if err := writeConnectionMetadata(globalArchiver.recordWriter(nil), "https://example.com/", &connectionInfo{}); err != nil {
+		t.Fatalf("writeConnectionMetadata() error = %v", err)
+	}
+
+	if len(records.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records.records))
+	}
+
+	record := records.records[0]
+	if record.header.Get("WARC-Type") != "metadata" || record.header.Get("WARC-IP-Address") != "192.0.2.1" || !strings.Contains(record.content, "ip-address: 192.0.2.1") {
+		t.Errorf("unexpected connection metadata record %v %q", record.header, record.content)
+	}
+}
+
+func TestWriteNotModifiedRevisitToRecordWriter(t *testing.T) {
+	records := withMemoryRecordWriter(t)
+
+	resp := &http.Response{
+		Status:     "304 Not Modified",
+		StatusCode: http.StatusNotModified,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Etag": []string{`"abc"`}},
+	}
+
+	if err := writeNotModifiedRevisit(globalArchiver.recordWriter(nil), "http://example.com/page", resp, &validators{ETag: `"abc"`, Date: "2025-01-02T03:04:05Z"}); err != nil {
+		t.Fatalf("writeNotModifiedRevisit() error = %v", err)
+	}
+
+	if len(records.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records.records))
+	}
+
+	record := records.records[0]
+	if record.header.Get("WARC-Type") != "revisit" || record.header.Get("WARC-Refers-To-Date") != "2025-01-02T03:04:05Z" || !strings.HasPrefix(record.content, "HTTP/1.1 304 Not Modified\r\n") {
+		t.Errorf("unexpected revisit record %v %q", record.header, record.content)
+	}
+}
@@ -0,0 +1,107 @@
+package archiver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver/headless"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// headlessWanted tells if any seed may be rendered in the browser, it is only started then
+func headlessWanted() bool {
+	return config.Get().Headless || len(config.Get().HeadlessHosts) > 0 || config.Get().HeadlessFallback
+}
+
+// renderFirst tells if the seed is rendered in the browser instead of being captured over plain HTTP
+func renderFirst(item *models.Item) bool {
+	if !headless.Enabled() || !item.IsSeed() {
+		return false
+	}
+
+	return config.Get().Headless || hostMatches(item.GetURL().GetParsed().Hostname(), config.Get().HeadlessHosts)
+}
+
+// renderAfter tells if the seed captured over plain HTTP must be rendered again in the browser
+// because its HTML looks rendered by JavaScript
+func renderAfter(item *models.Item) bool {
+	if !headless.Enabled() || !item.IsSeed() || !config.Get().HeadlessFallback || item.GetURL().IsHeadless() {
+		return false
+	}
+
+	resp := item.GetURL().GetResponse()
+	if resp == nil || resp.StatusCode != 200 || item.GetURL().GetBody() == nil ||
+		item.GetURL().GetMIMEType() == nil || !strings.Contains(item.GetURL().GetMIMEType().String(), "html") {
+		return false
+	}
+
+	doc, err := item.GetURL().GetDocument()
+	item.GetURL().RewindBody()
+	if err != nil {
+		return false
+	}
+
+	return looksJSRendered(doc, config.Get().HeadlessFallbackMaxText, config.Get().HeadlessFallbackMinScripts)
+}
+
+// looksJSRendered tells if a page is likely rendered by JavaScript: it has little visible text,
+// at most maxText characters, but at least minScripts scripts
+func looksJSRendered(doc *goquery.Document, maxText, minScripts int) bool {
+	if doc.Find("script").Length() < minScripts {
+		return false
+	}
+
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+
+	return len(strings.Join(strings.Fields(body.Text()), " ")) <= maxText
+}
+
+// hostMatches tells if the host is one of the hosts or one of their subdomains
+func hostMatches(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render captures the seed in the browser and marks it as captured by the browser, the screenshot
+// of the page is written if any. It returns nil if the capture failed.
+func render(client headless.HTTPClient, item *models.Item) *headless.Page {
+	waitGlobalLimiter()
+
+	getStartTime := time.Now()
+
+	page, err := headless.Capture(client, item.GetURL())
+	if err != nil {
+		logger.Warn("headless capture failed, falling back to plain HTTP", "err", err.Error(), "seed_id", item.GetShortID(), "depth", item.GetDepth(), "hop", item.GetURL().GetHops())
+		return nil
+	}
+
+	stats.MeanHTTPRespTimeAdd(time.Since(getStartTime))
+	item.GetURL().SetHeadless(true)
+
+	if page.Screenshot != nil {
+		err = WriteResourceRecord("urn:screenshot:"+item.GetURL().String(), page.ScreenshotContentType, page.Screenshot)
+		if err != nil {
+			logger.Error("unable to write screenshot", "err", err.Error(), "seed_id", item.GetShortID())
+		} else {
+			stats.ScreenshotsTakenIncr()
+		}
+	}
+
+	// Relative links of the rendered page resolve against the URL the browser ended up on
+	if page.Response.Request != nil && page.Response.Request.URL.String() != item.GetURL().String() {
+		item.SetBase(page.Response.Request.URL.String())
+	}
+
+	return page
+}
@@ -0,0 +1,65 @@
+package archiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestLooksJSRendered(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want bool
+	}{
+		{
+			name: "application shell",
+			html: `<html><head><script src="/a.js"></script><script src="/b.js"></script></head>
+				<body><div id="root"></div><noscript>You need to enable JavaScript to run this app.</noscript>
+				<script>window.__STATE__ = {"a": "lots of inline state that isn't visible text"}</script></body></html>`,
+			want: true,
+		},
+		{
+			name: "article with scripts",
+			html: `<html><head><script src="/a.js"></script><script src="/b.js"></script><script src="/c.js"></script></head>
+				<body><article>` + strings.Repeat("Some visible text of the article. ", 20) + `</article></body></html>`,
+			want: false,
+		},
+		{
+			name: "short page without scripts",
+			html: `<html><body><p>Hello</p></body></html>`,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := looksJSRendered(doc, 100, 3); got != tt.want {
+				t.Errorf("looksJSRendered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	hosts := []string{"example.com", "App.example.org"}
+
+	for host, want := range map[string]bool{
+		"example.com":         true,
+		"www.example.com":     true,
+		"notexample.com":      false,
+		"app.example.org":     true,
+		"cdn.app.example.org": true,
+		"example.org":         false,
+	} {
+		if got := hostMatches(host, hosts); got != want {
+			t.Errorf("hostMatches(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
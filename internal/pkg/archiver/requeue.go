@@ -0,0 +1,150 @@
+package archiver
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// The delays before the next attempt of an item requeued after a transient network failure,
+// doubled on every attempt
+const (
+	requeueBaseDelay = 2 * time.Second
+	requeueMaxDelay  = 5 * time.Minute
+)
+
+// isTransientNetworkError tells if the error of a request may not happen on a later attempt:
+// a resolution timeout, a timeout, or a connection reset or refused
+func isTransientNetworkError(err error) bool {
+	var DNSErr *net.DNSError
+	if errors.As(err, &DNSErr) {
+		return !DNSErr.IsNotFound && (DNSErr.IsTimeout || DNSErr.IsTemporary)
+	}
+
+	if fetchErrorStatus(err) == crawlLogTimeout {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// The WARC client doesn't always wrap the errors of the connection
+	message := err.Error()
+	return strings.Contains(message, "connection reset by peer") || strings.Contains(message, "connection refused")
+}
+
+// requeueBackoff is the delay before the next attempt of an item requeued attempts times
+func requeueBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	if attempts > 10 {
+		return requeueMaxDelay
+	}
+
+	return min(requeueBaseDelay<<(attempts-1), requeueMaxDelay)
+}
+
+// requeueDelay returns the delay before the seed is archived again if some of its items were requeued
+// after a transient network failure: they are left pre-processed, with their attempts counted.
+// The seed waits for the longest backoff of its items.
+func requeueDelay(seed *models.Item) (delay time.Duration, requeued bool) {
+	items, err := seed.GetNodesAtLevel(seed.GetMaxDepth())
+	if err != nil {
+		return 0, false
+	}
+
+	for _, item := range items {
+		if item.GetStatus() == models.ItemPreProcessed && item.GetAttempts() > 0 {
+			delay = max(delay, requeueBackoff(item.GetAttempts()))
+			requeued = true
+		}
+	}
+
+	return delay, requeued
+}
+
+// requeue keeps the seeds whose items failed with a transient network error until their backoff
+// is over, then gives them back to the workers. The seeds still waiting when the crawl stops
+// are left claimed in the source, like the seeds being archived.
+type requeue struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	seeds []requeuedSeed
+
+	// Signaled when the backoff of a seed may be over
+	wake chan struct{}
+}
+
+type requeuedSeed struct {
+	seed *models.Item
+	due  time.Time
+}
+
+var globalRequeue *requeue
+
+func newRequeue() *requeue {
+	return &requeue{
+		now:  time.Now,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// add keeps the seed for delay
+func (q *requeue) add(seed *models.Item, delay time.Duration) {
+	q.mu.Lock()
+	q.seeds = append(q.seeds, requeuedSeed{seed: seed, due: q.now().Add(delay)})
+	q.mu.Unlock()
+
+	time.AfterFunc(delay, q.signal)
+}
+
+// ready returns the channel signaled when the backoff of a seed may be over, nil without requeue
+func (q *requeue) ready() <-chan struct{} {
+	if q == nil {
+		return nil
+	}
+
+	return q.wake
+}
+
+// popReady returns a seed whose backoff is over
+func (q *requeue) popReady() *models.Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+
+	for i, requeued := range q.seeds {
+		if now.Before(requeued.due) {
+			continue
+		}
+
+		q.seeds = append(q.seeds[:i], q.seeds[i+1:]...)
+
+		// Other seeds may be due too, let another worker look
+		if len(q.seeds) > 0 {
+			q.signal()
+		}
+
+		return requeued.seed
+	}
+
+	return nil
+}
+
+func (q *requeue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
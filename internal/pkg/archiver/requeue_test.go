@@ -0,0 +1,159 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestIsTransientNetworkError(t *testing.T) {
+	stubConfirmNotFound(t, true)
+
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{
+			name:      "DNS timeout",
+			err:       dnsFailure(context.Background(), "slow.example", errors.New("failed to resolve DNS: A error: read udp: i/o timeout")),
+			transient: true,
+		},
+		{
+			name: "DNS not found",
+			err:  dnsFailure(context.Background(), "nx.example", errors.New("failed to resolve DNS: A error: no TYPE=A record found, AAAA error: no TYPE=AAAA record found")),
+		},
+		{
+			name:      "connection reset",
+			err:       &url.Error{Op: "Get", URL: "http://example.com/", Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}},
+			transient: true,
+		},
+		{
+			name:      "connection refused",
+			err:       &url.Error{Op: "Get", URL: "http://example.com/", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+			transient: true,
+		},
+		{
+			name:      "unwrapped connection reset",
+			err:       fmt.Errorf("read tcp 10.0.0.1:1234->10.0.0.2:80: read: connection reset by peer"),
+			transient: true,
+		},
+		{
+			name:      "timeout",
+			err:       &url.Error{Op: "Get", URL: "http://example.com/", Err: os.ErrDeadlineExceeded},
+			transient: true,
+		},
+		{
+			name: "TLS error",
+			err:  errors.New("tls: failed to verify certificate: x509: certificate signed by unknown authority"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if transient := isTransientNetworkError(tt.err); transient != tt.transient {
+				t.Errorf("isTransientNetworkError() = %v, want %v", transient, tt.transient)
+			}
+		})
+	}
+}
+
+func TestRequeueBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{0, 0},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{8, 256 * time.Second},
+		{9, requeueMaxDelay},
+		{100, requeueMaxDelay},
+	}
+
+	for _, tt := range tests {
+		if backoff := requeueBackoff(tt.attempts); backoff != tt.backoff {
+			t.Errorf("requeueBackoff(%d) = %s, want %s", tt.attempts, backoff, tt.backoff)
+		}
+	}
+}
+
+func TestRequeueDelay(t *testing.T) {
+	seed := newLimitTestItem(t, "http://example.com/")
+	seed.SetStatus(models.ItemGotChildren)
+
+	var assets []*models.Item
+	for _, rawURL := range []string{"http://example.com/a.css", "http://example.com/b.js"} {
+		asset := newLimitTestItem(t, rawURL)
+		if err := seed.AddChild(asset, models.ItemGotChildren); err != nil {
+			t.Fatalf("AddChild() error = %v", err)
+		}
+		assets = append(assets, asset)
+	}
+
+	assets[0].SetStatus(models.ItemCompleted)
+	assets[1].SetStatus(models.ItemPreProcessed)
+
+	if _, requeued := requeueDelay(seed); requeued {
+		t.Fatal("expected a seed without failed attempts not to be requeued")
+	}
+
+	assets[1].IncrAttempts()
+	assets[1].IncrAttempts()
+
+	delay, requeued := requeueDelay(seed)
+	if !requeued || delay != 4*time.Second {
+		t.Errorf("requeueDelay() = %s, %v, want 4s, true", delay, requeued)
+	}
+
+	// Once failed for good, the item isn't attempted again
+	assets[1].SetStatus(models.ItemFailed)
+	if _, requeued := requeueDelay(seed); requeued {
+		t.Error("expected a seed whose items are done not to be requeued")
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	now := time.Unix(1000, 0)
+	q := newRequeue()
+	q.now = func() time.Time { return now }
+
+	first := newLimitTestItem(t, "http://example.com/a")
+	second := newLimitTestItem(t, "http://example.com/b")
+	q.add(first, 10*time.Second)
+	q.add(second, time.Hour)
+
+	if seed := q.popReady(); seed != nil {
+		t.Fatal("expected no seed to be ready before its backoff is over")
+	}
+
+	now = now.Add(10 * time.Second)
+
+	if seed := q.popReady(); seed != first {
+		t.Fatalf("expected the first seed once its backoff is over, got %v", seed)
+	}
+
+	if seed := q.popReady(); seed != nil {
+		t.Fatal("expected the second seed to wait for its backoff")
+	}
+
+	now = now.Add(time.Hour)
+
+	if seed := q.popReady(); seed != second {
+		t.Errorf("expected the second seed once its backoff is over, got %v", seed)
+	}
+
+	var disabled *requeue
+	if disabled.ready() != nil {
+		t.Error("expected no ready channel without requeue")
+	}
+}
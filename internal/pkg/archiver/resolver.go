@@ -0,0 +1,35 @@
+package archiver
+
+import (
+	"net"
+	"strings"
+)
+
+// addressResolver applies --resolve to the connections of the WARC clients. The pinned hosts are connected
+// to their address without being resolved, and no DNS record is written for them. The other hosts are
+// connected to the addresses their DNS records were written for, resolved with --dns-servers and cached
+// by the dialer of the WARC clients, in the order set by --prefer-ipv4 and --prefer-ipv6.
+type addressResolver struct {
+	pins map[string]net.IP // Addresses of --resolve, by lowercased host:port
+}
+
+var globalResolver *addressResolver
+
+// newAddressResolver returns nil if there is nothing to apply, the methods of a nil resolver don't do anything
+func newAddressResolver(pins map[string]net.IP) *addressResolver {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	return &addressResolver{pins: pins}
+}
+
+// pinned returns the address of --resolve for a host:port, it's the StaticAddress of the WARC clients: the
+// pinned hosts aren't resolved for the DNS records, and so don't need to resolve at all
+func (r *addressResolver) pinned(address string) net.IP {
+	if r == nil {
+		return nil
+	}
+
+	return r.pins[strings.ToLower(address)]
+}
@@ -0,0 +1,233 @@
+package archiver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/miekg/dns"
+)
+
+// pinnedReply answers the query with IP if it asks for an address of its version, with no answer otherwise
+func pinnedReply(query *dns.Msg, IP net.IP) *dns.Msg {
+	reply := emptyReply(query)
+	header := dns.RR_Header{Name: query.Question[0].Name, Class: dns.ClassINET}
+
+	switch {
+	case query.Question[0].Qtype == dns.TypeA && IP.To4() != nil:
+		header.Rrtype = dns.TypeA
+		reply.Answer = append(reply.Answer, &dns.A{Hdr: header, A: IP.To4()})
+	case query.Question[0].Qtype == dns.TypeAAAA && IP.To4() == nil:
+		header.Rrtype = dns.TypeAAAA
+		reply.Answer = append(reply.Answer, &dns.AAAA{Hdr: header, AAAA: IP})
+	}
+
+	return reply
+}
+
+// emptyReply is a successful reply to the query without answer
+func emptyReply(query *dns.Msg) *dns.Msg {
+	reply := new(dns.Msg)
+	reply.SetReply(query)
+	reply.RecursionAvailable = true
+
+	return reply
+}
+
+func TestAddressResolverPinned(t *testing.T) {
+	r := newAddressResolver(map[string]net.IP{
+		"pinned.test:443": net.ParseIP("192.0.2.7"),
+	})
+
+	if IP := r.pinned("Pinned.test:443"); !IP.Equal(net.ParseIP("192.0.2.7")) {
+		t.Errorf("pinned(Pinned.test:443) = %v, want the pinned address", IP)
+	}
+
+	// The pin is only for its port
+	if IP := r.pinned("pinned.test:80"); IP != nil {
+		t.Errorf("pinned(pinned.test:80) = %v, want nil", IP)
+	}
+
+	if newAddressResolver(nil) != nil {
+		t.Error("expected no resolver without pin")
+	}
+}
+
+// The connections are made to the addresses resolved with --dns-servers, the ones the DNS records are written
+// for. The DNS servers are queried on port 53, like the WARC client does.
+func TestResolvedCapture(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, DNSServers []string, preferIPv4, preferIPv6 bool) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().DNSServers = DNSServers
+		config.Get().PreferIPv4 = preferIPv4
+		config.Get().PreferIPv6 = preferIPv6
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().DNSServers, config.Get().PreferIPv4, config.Get().PreferIPv6)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:53")
+	if err != nil {
+		t.Skipf("unable to serve the DNS records on port 53: %v", err)
+	}
+
+	// dual.test has an IPv6 address nothing listens on, the connection falls back on its IPv4 one
+	DNSServer := &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+		if query.Question[0].Qtype == dns.TypeAAAA {
+			w.WriteMsg(pinnedReply(query, net.ParseIP("::1")))
+			return
+		}
+
+		w.WriteMsg(pinnedReply(query, net.ParseIP("127.0.0.1")))
+	})}
+	go DNSServer.ActivateAndServe()
+	defer DNSServer.Shutdown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("resolved page"))
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	tests := []struct {
+		name       string
+		preferIPv4 bool
+		preferIPv6 bool
+		connected  bool
+	}{
+		{name: "fallback on IPv4", connected: true},
+		{name: "prefer IPv4", preferIPv4: true, connected: true},
+		{name: "prefer IPv6", preferIPv6: true, connected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Get().JobPath = t.TempDir()
+			config.Get().WARCPoolSize = 1
+			config.Get().WARCCompression = "none"
+			config.Get().DNSServers = []string{"127.0.0.1"}
+			config.Get().PreferIPv4 = tt.preferIPv4
+			config.Get().PreferIPv6 = tt.preferIPv6
+
+			if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+			startWARCWriter()
+			client := globalArchiver.Client
+			defer client.Close()
+
+			resp, err := client.Get("http://" + net.JoinHostPort("dual.test", port) + "/page")
+			if connected := err == nil; connected != tt.connected {
+				t.Fatalf("Get() error = %v, want connected %t", err, tt.connected)
+			}
+
+			if err == nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestPinnedCapture(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, DNSServers []string, resolved map[string]net.IP) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().DNSServers = DNSServers
+		config.Get().ResolvedAddresses = resolved
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().DNSServers, config.Get().ResolvedAddresses)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver, resolver *addressResolver) {
+		globalArchiver = previous
+		globalResolver = resolver
+	}(globalArchiver, globalResolver)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("pinned page"))
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// The pinned host doesn't resolve with the DNS servers
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().DNSServers = []string{"127.0.0.1"}
+	config.Get().ResolvedAddresses = map[string]net.IP{net.JoinHostPort("pinned.test", port): net.ParseIP("127.0.0.1")}
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+	client := globalArchiver.Client
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+net.JoinHostPort("Pinned.test", port)+"/page", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	client.CloseIdleConnections()
+	client.WaitGroup.Wait()
+	client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+
+	var records strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records.Write(data)
+	}
+
+	if !strings.Contains(records.String(), "pinned page") {
+		t.Error("expected the response of the pinned address in the WARC files")
+	}
+
+	if strings.Contains(records.String(), "dns:") {
+		t.Error("expected no DNS record for the pinned host")
+	}
+}
@@ -0,0 +1,59 @@
+package archiver
+
+import (
+	"time"
+)
+
+// The WARC library only rotates its files by size. To rotate them by age, the WARC writing clients
+// are replaced by new ones, which start new files, and the previous ones are closed once the captures
+// using them are done, which finalizes their files.
+
+// warcRotator rotates the WARC files every maxAge until the archiver stops
+func (a *archiver) warcRotator(maxAge time.Duration) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(maxAge)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.rotateWARCs(); err != nil {
+				logger.Error("unable to rotate WARC files", "err", err.Error(), "func", "archiver.warcRotator")
+				continue
+			}
+
+			logger.Info("WARC files rotated", "max_age", maxAge.String())
+		}
+	}
+}
+
+// rotateWARCs swaps the WARC writing clients for new ones and closes the previous ones
+func (a *archiver) rotateWARCs() error {
+	a.replaceMu.Lock()
+	defer a.replaceMu.Unlock()
+
+	client, clientWithProxy, clientsWithProxyPool, err := newWARCClients(warcSettings)
+	if err != nil {
+		return err
+	}
+
+	a.clientsMu.Lock()
+	previousClients := a.clients()
+	a.Client, a.ClientWithProxy, a.ClientsWithProxyPool = client, clientWithProxy, clientsWithProxyPool
+	a.clientsMu.Unlock()
+
+	// Wait for the captures still holding the previous clients
+	for _, previousClient := range previousClients {
+		a.closeClient(previousClient)
+	}
+
+	// The crawl log follows the WARC files
+	if err := globalCrawlEventLog.rotate(); err != nil {
+		logger.Error("unable to rotate JSON lines crawl log", "err", err.Error(), "func", "archiver.rotateWARCs")
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package archiver
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestRotateWARCs(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+	}(config.Get().JobPath, config.Get().WARCPoolSize)
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+
+	if err := WriteResourceRecord("urn:test:1", "text/plain", []byte("first")); err != nil {
+		t.Fatalf("WriteResourceRecord() error = %v", err)
+	}
+
+	// A capture holding the client delays its closing until it's released
+	held, release := globalArchiver.acquireClient("")
+
+	// The hold isn't a write, it must not show in the writing queue
+	if size := GetWARCWritingQueueSize(); size != 0 {
+		t.Errorf("expected an empty writing queue while held, got %d", size)
+	}
+
+	rotated := make(chan error)
+	go func() {
+		rotated <- globalArchiver.rotateWARCs()
+	}()
+
+	// Wait for the new clients to be swapped in
+	for {
+		client, releaseNew := globalArchiver.acquireClient("")
+		releaseNew()
+
+		if client != held {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if files, _ := GetWARCFiles(); len(files) != 0 {
+		t.Errorf("expected the previous WARC file to stay open while held, got %q", files)
+	}
+
+	release()
+	if err := <-rotated; err != nil {
+		t.Fatalf("rotateWARCs() error = %v", err)
+	}
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 finished WARC file after the rotation, got %q", files)
+	}
+
+	if err := WriteResourceRecord("urn:test:2", "text/plain", []byte("second")); err != nil {
+		t.Fatalf("WriteResourceRecord() error = %v", err)
+	}
+
+	globalArchiver.Client.WaitGroup.Wait()
+	globalArchiver.Client.Close()
+
+	files, err = GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 finished WARC files, got %q", files)
+	}
+}
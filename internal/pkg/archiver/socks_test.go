@@ -0,0 +1,342 @@
+package archiver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/miekg/dns"
+)
+
+// socksServer is a SOCKS5 server accepting the CONNECT command with username/password authentication.
+// The destinations are recorded and connected to on 127.0.0.1, with their port.
+type socksServer struct {
+	listener net.Listener
+	user     string
+	password string
+
+	mu           sync.Mutex
+	destinations []string
+}
+
+func startSOCKSServer(t *testing.T, user, password string) *socksServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &socksServer{listener: listener, user: user, password: password}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *socksServer) URL(scheme string) string {
+	return (&url.URL{Scheme: scheme, User: url.UserPassword(s.user, s.password), Host: s.listener.Addr().String()}).String()
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, number of methods, methods. Only the username/password method is accepted.
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil || greeting[0] != 5 {
+		return
+	}
+
+	methods := make([]byte, greeting[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	if !strings.Contains(string(methods), "\x02") {
+		conn.Write([]byte{5, 0xff})
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	// Authentication (RFC 1929): version, username, password
+	user, password, ok := readSOCKSCredentials(conn)
+	if !ok || user != s.user || password != s.password {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	// Request: version, command, reserved, address type, address, port
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[1] != 1 {
+		return
+	}
+
+	var host string
+	switch request[3] {
+	case 1, 4:
+		address := make([]byte, net.IPv4len)
+		if request[3] == 4 {
+			address = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, address); err != nil {
+			return
+		}
+		host = net.IP(address).String()
+	case 3:
+		name, ok := readSOCKSString(conn)
+		if !ok {
+			return
+		}
+		host = name
+	default:
+		return
+	}
+
+	var port uint16
+	if err := binary.Read(conn, binary.BigEndian, &port); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.destinations = append(s.destinations, net.JoinHostPort(host, strconv.Itoa(int(port))))
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+
+	go func() {
+		io.Copy(upstream, conn)
+		upstream.Close()
+	}()
+	io.Copy(conn, upstream)
+}
+
+func (s *socksServer) connected() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.destinations...)
+}
+
+func readSOCKSCredentials(conn net.Conn) (user, password string, ok bool) {
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(conn, version); err != nil || version[0] != 1 {
+		return "", "", false
+	}
+
+	if user, ok = readSOCKSString(conn); !ok {
+		return "", "", false
+	}
+
+	password, ok = readSOCKSString(conn)
+
+	return user, password, ok
+}
+
+// readSOCKSString reads a string prefixed by its length on a byte
+func readSOCKSString(conn net.Conn) (string, bool) {
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return "", false
+	}
+
+	value := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, value); err != nil {
+		return "", false
+	}
+
+	return string(value), true
+}
+
+// captureThroughProxy requests target with the WARC client of --proxy and returns the body of the
+// response and the content of the WARC files once the client is closed
+func captureThroughProxy(t *testing.T, proxy, target string) (body, records string) {
+	t.Helper()
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().Proxy = proxy
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	client, release := globalArchiver.acquireClient(req.URL.Host)
+	if client != globalArchiver.ClientWithProxy {
+		t.Fatal("expected the request to go through the client of --proxy")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	release()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	// The records are written once the connection is closed
+	client.CloseIdleConnections()
+	client.WaitGroup.Wait()
+	client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+
+	var all strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all.Write(data)
+	}
+
+	return string(content), all.String()
+}
+
+func TestSOCKSProxyCapture(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression, proxy string, certValidation bool, DNSServers []string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().Proxy = proxy
+		config.Get().CertValidation = certValidation
+		config.Get().DNSServers = DNSServers
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().Proxy, config.Get().CertValidation, config.Get().DNSServers)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	config.Get().CertValidation = false
+
+	serverNames := make(chan string, 10)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("captured through the proxy"))
+	}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames <- hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	proxy := startSOCKSServer(t, "zeno", "s3cr3t")
+
+	t.Run("address", func(t *testing.T) {
+		target := "https://" + net.JoinHostPort("127.0.0.1", port) + "/page"
+
+		body, records := captureThroughProxy(t, proxy.URL("socks5"), target)
+		if body != "captured through the proxy" {
+			t.Errorf("expected the body of the server, got %q", body)
+		}
+
+		for _, want := range []string{"WARC-Type: response", "WARC-Target-URI: " + target, "captured through the proxy"} {
+			if !strings.Contains(records, want) {
+				t.Errorf("expected %q in the WARC files", want)
+			}
+		}
+
+		if destinations := proxy.connected(); len(destinations) == 0 || destinations[len(destinations)-1] != net.JoinHostPort("127.0.0.1", port) {
+			t.Errorf("expected the proxy to connect to the server, got %v", destinations)
+		}
+	})
+
+	// The host names are resolved by the WARC client for its DNS records, on port 53 of the DNS servers
+	t.Run("host name", func(t *testing.T) {
+		packetConn, err := net.ListenPacket("udp", "127.0.0.1:53")
+		if err != nil {
+			t.Skipf("unable to serve the DNS records on port 53: %v", err)
+		}
+
+		DNSServer := &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+			w.WriteMsg(pinnedReply(query, net.ParseIP("127.0.0.1")))
+		})}
+		go DNSServer.ActivateAndServe()
+		defer DNSServer.Shutdown()
+
+		config.Get().DNSServers = []string{"127.0.0.1"}
+
+		for len(serverNames) > 0 {
+			<-serverNames
+		}
+
+		target := "https://" + net.JoinHostPort("example.com", port) + "/page"
+
+		body, records := captureThroughProxy(t, proxy.URL("socks5h"), target)
+		if body != "captured through the proxy" {
+			t.Errorf("expected the body of the server, got %q", body)
+		}
+
+		if !strings.Contains(records, "WARC-Target-URI: "+target) {
+			t.Errorf("expected the response to %s in the WARC files", target)
+		}
+
+		// The host name is resolved by the proxy and presented to the server
+		if destinations := proxy.connected(); destinations[len(destinations)-1] != net.JoinHostPort("example.com", port) {
+			t.Errorf("expected the proxy to be given the host name, got %v", destinations)
+		}
+
+		if serverName := <-serverNames; serverName != "example.com" {
+			t.Errorf("expected the SNI of the host, got %q", serverName)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package archiver
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver/soft404"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+var globalSoft404 *soft404.Detector
+
+// checkSoft404 flags the pages that are the error page their host returns for missing URLs,
+// they stay in the WARC files but aren't used for extraction. The host is probed the first
+// time one of its pages is captured.
+func checkSoft404(client *warc.CustomHTTPClient, item *models.Item) {
+	URL := item.GetURL()
+
+	if globalSoft404 == nil || item.IsChild() || URL.GetBody() == nil || URL.GetResponse().StatusCode != http.StatusOK || !isHTMLResponse(URL) {
+		return
+	}
+
+	signature := globalSoft404.Signature(strings.ToLower(URL.GetParsed().Host), func() (*soft404.Signature, error) {
+		return probeSoft404(client, URL)
+	})
+	if signature == nil {
+		return
+	}
+
+	defer URL.RewindBody()
+
+	pageSignature, err := soft404.NewSignature(URL.GetBody())
+	if err != nil {
+		logger.Debug("unable to compute soft 404 signature", "err", err.Error(), "item_id", item.GetShortID())
+		return
+	}
+
+	if signature.Matches(pageSignature) {
+		URL.SetSoft404(true)
+		stats.Soft404sIncr()
+		logger.Info("soft 404 detected", "url", URL.String(), "item_id", item.GetShortID())
+	}
+}
+
+// probeSoft404 requests a bogus path of the host of URL with the WARC writing client, so that
+// the probe is archived too, and returns the signature of the answer if it's a 200
+func probeSoft404(client *warc.CustomHTTPClient, URL *models.URL) (*soft404.Signature, error) {
+	req, err := http.NewRequestWithContext(globalArchiver.ctx, http.MethodGet, soft404.ProbeURL(URL.GetParsed()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if URL.GetRequest() != nil {
+		req.Header.Set("User-Agent", URL.GetRequest().Header.Get("User-Agent"))
+	}
+
+	if globalBucketManager != nil {
+		globalBucketManager.Wait(req.URL.Host)
+	}
+	waitGlobalLimiter()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The record is written once the body is consumed
+	defer io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	return soft404.NewSignature(resp.Body)
+}
+
+func isHTMLResponse(URL *models.URL) bool {
+	return strings.Contains(strings.ToLower(URL.GetResponse().Header.Get("Content-Type")), "html") ||
+		(URL.GetMIMEType() != nil && strings.Contains(URL.GetMIMEType().String(), "html"))
+}
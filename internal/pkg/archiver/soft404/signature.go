@@ -0,0 +1,98 @@
+package soft404
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io"
+	"math/bits"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// Error pages are small, we don't need more than this to recognize them
+	maxSignatureBodySize = 1024 * 1024
+
+	// Maximum number of differing bits between the simhashes of two matching pages,
+	// error pages often quote the requested URL
+	maxSimhashDistance = 3
+)
+
+// Signature summarizes a page well enough to recognize the error page of a host,
+// whatever the URL it was served for
+type Signature struct {
+	SizeBucket int
+	Title      string
+	Simhash    uint64
+}
+
+// NewSignature computes the signature of an HTML page
+func NewSignature(body io.Reader) (*Signature, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxSignatureBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.Join(strings.Fields(document.Find("title").First().Text()), " ")
+
+	document.Find("script, style, noscript").Remove()
+
+	return &Signature{
+		SizeBucket: bits.Len(uint(len(data))),
+		Title:      title,
+		Simhash:    simhash(strings.Fields(strings.ToLower(document.Text()))),
+	}, nil
+}
+
+// Matches returns true if both signatures are close enough to be the ones of the same page:
+// same title, sizes within a factor of 2, and nearly identical text
+func (s *Signature) Matches(other *Signature) bool {
+	if s == nil || other == nil {
+		return false
+	}
+
+	if s.Title != other.Title {
+		return false
+	}
+
+	if sizeDistance := s.SizeBucket - other.SizeBucket; sizeDistance > 1 || sizeDistance < -1 {
+		return false
+	}
+
+	return bits.OnesCount64(s.Simhash^other.Simhash) <= maxSimhashDistance
+}
+
+// simhash computes the 64 bits simhash of the tokens, pages with similar text have simhashes
+// that only differ by a few bits
+func simhash(tokens []string) uint64 {
+	var weights [64]int
+
+	for _, token := range tokens {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(token))
+		hash := hasher.Sum64()
+
+		for bit := range 64 {
+			if hash&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
@@ -0,0 +1,87 @@
+// Package soft404 detects the hosts that answer the requests for missing URLs with a 200
+// and an error page, and the captures of these hosts that are that error page. Each host
+// is probed once with a bogus path, and the signature of its answer is compared to the
+// signature of the pages captured on it.
+package soft404
+
+import (
+	"container/list"
+	"net/url"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Detector keeps the soft-404 signatures of the most recently seen hosts
+type Detector struct {
+	maxHosts int
+
+	mu    sync.Mutex
+	hosts map[string]*list.Element
+	lru   *list.List
+}
+
+type hostEntry struct {
+	host      string
+	ready     chan struct{}
+	signature *Signature
+}
+
+// NewDetector returns a detector that remembers the signatures of up to maxHosts hosts,
+// the least recently seen hosts are forgotten first and probed again if they come back
+func NewDetector(maxHosts int) *Detector {
+	return &Detector{
+		maxHosts: max(maxHosts, 1),
+		hosts:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Signature returns the signature of the error page of the host, calling probe to get it the
+// first time the host is seen. Concurrent callers for the same host wait for the same probe.
+// A nil signature means the host answers missing URLs properly, or couldn't be probed.
+func (d *Detector) Signature(host string, probe func() (*Signature, error)) *Signature {
+	d.mu.Lock()
+
+	if element, found := d.hosts[host]; found {
+		d.lru.MoveToFront(element)
+		entry := element.Value.(*hostEntry)
+		d.mu.Unlock()
+
+		<-entry.ready
+		return entry.signature
+	}
+
+	entry := &hostEntry{
+		host:  host,
+		ready: make(chan struct{}),
+	}
+	d.hosts[host] = d.lru.PushFront(entry)
+
+	if d.lru.Len() > d.maxHosts {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.hosts, oldest.Value.(*hostEntry).host)
+	}
+
+	d.mu.Unlock()
+
+	signature, err := probe()
+	if err == nil {
+		entry.signature = signature
+	}
+	close(entry.ready)
+
+	return entry.signature
+}
+
+// ProbeURL returns a URL of the host of u that is very unlikely to exist
+func ProbeURL(u *url.URL) string {
+	probe := url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   "/" + uuid.New().String(),
+	}
+
+	return probe.String()
+}
@@ -0,0 +1,143 @@
+package soft404
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const errorPage = `<html><head><title>Page not found</title><style>body { color: red; }</style></head>
+<body><h1>Oops!</h1><p>We couldn't find the page %s. It may have been moved or deleted.
+Check the address or go back to the home page, where you will find our latest articles and news.</p></body></html>`
+
+func mustSignature(t *testing.T, page string) *Signature {
+	t.Helper()
+
+	signature, err := NewSignature(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("NewSignature() error = %v", err)
+	}
+
+	return signature
+}
+
+func TestSignatureMatches(t *testing.T) {
+	probe := mustSignature(t, strings.Replace(errorPage, "%s", "/3f2a9c1e-4b7d-4e8a-9c6f-1a2b3c4d5e6f", 1))
+
+	if probe.Title != "Page not found" {
+		t.Errorf("Title = %q, want %q", probe.Title, "Page not found")
+	}
+
+	tests := []struct {
+		name string
+		page string
+		want bool
+	}{
+		{"same error page for another path", strings.Replace(errorPage, "%s", "/blog/some-old-post", 1), true},
+		{"different title", strings.Replace(strings.Replace(errorPage, "%s", "/a", 1), "Page not found", "Home", 1), false},
+		{"different content", `<html><head><title>Page not found</title></head><body><article>` + strings.Repeat("A long article about a completely unrelated topic, with plenty of words. ", 20) + `</article></body></html>`, false},
+	}
+
+	for _, tt := range tests {
+		if got := probe.Matches(mustSignature(t, tt.page)); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var nilSignature *Signature
+	if nilSignature.Matches(probe) || probe.Matches(nil) {
+		t.Errorf("expected a nil signature to never match")
+	}
+}
+
+func TestSimhash(t *testing.T) {
+	if simhash(strings.Fields("the quick brown fox")) != simhash(strings.Fields("the quick brown fox")) {
+		t.Errorf("expected the simhash to be deterministic")
+	}
+
+	if simhash(nil) != 0 {
+		t.Errorf("expected the simhash of no tokens to be 0")
+	}
+}
+
+func TestDetectorProbesOnce(t *testing.T) {
+	detector := NewDetector(10)
+
+	var (
+		probes    atomic.Int32
+		wg        sync.WaitGroup
+		signature = &Signature{Title: "Not found"}
+	)
+
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			got := detector.Signature("example.com", func() (*Signature, error) {
+				probes.Add(1)
+				return signature, nil
+			})
+			if got != signature {
+				t.Errorf("Signature() = %+v, want %+v", got, signature)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if probes.Load() != 1 {
+		t.Errorf("host probed %d times, want 1", probes.Load())
+	}
+
+	// A failed probe is remembered as no signature
+	got := detector.Signature("broken.example.com", func() (*Signature, error) {
+		return signature, errors.New("connection refused")
+	})
+	if got != nil {
+		t.Errorf("Signature() after a failed probe = %+v, want nil", got)
+	}
+}
+
+func TestDetectorBounded(t *testing.T) {
+	detector := NewDetector(2)
+
+	var probes int
+	probe := func() (*Signature, error) {
+		probes++
+		return nil, nil
+	}
+
+	for _, host := range []string{"a.com", "b.com", "a.com", "c.com", "a.com", "b.com"} {
+		detector.Signature(host, probe)
+	}
+
+	// b.com is the least recently seen host when c.com comes in, so it's probed again
+	if probes != 4 {
+		t.Errorf("hosts probed %d times, want 4", probes)
+	}
+
+	if len(detector.hosts) != 2 || detector.lru.Len() != 2 {
+		t.Errorf("expected 2 hosts to be kept, got %d", len(detector.hosts))
+	}
+}
+
+func TestProbeURL(t *testing.T) {
+	u, _ := url.Parse("https://Example.com:8443/blog/post?id=1#top")
+
+	probe, err := url.Parse(ProbeURL(u))
+	if err != nil {
+		t.Fatalf("ProbeURL() returned an invalid URL: %v", err)
+	}
+
+	if probe.Scheme != "https" || probe.Host != "Example.com:8443" || probe.RawQuery != "" || len(probe.Path) < 30 {
+		t.Errorf("unexpected probe URL %s", probe)
+	}
+
+	if ProbeURL(u) == ProbeURL(u) {
+		t.Errorf("expected the probe paths to be random")
+	}
+}
@@ -0,0 +1,36 @@
+package archiver
+
+import (
+	"io"
+	"net/http"
+	"slices"
+)
+
+// discardedStatuses returns the status codes of the responses that aren't written to the WARC, the ones of
+// --warc-discard-status and, if recordErrors is false, the 4xx and 5xx ones. They're the SkipHTTPStatusCodes
+// of the WARC clients, skipped by the WARC writer once it reads their headers.
+func discardedStatuses(discard []int, recordErrors bool) []int {
+	statuses := slices.Clone(discard)
+
+	if !recordErrors {
+		for statusCode := 400; statusCode < 600; statusCode++ {
+			if !slices.Contains(statuses, statusCode) {
+				statuses = append(statuses, statusCode)
+			}
+		}
+	}
+
+	return statuses
+}
+
+// statusDiscarded returns true if the responses with this status aren't written to the WARC
+func statusDiscarded(statusCode int) bool {
+	return slices.Contains(warcSettings.SkipHTTPStatusCodes, statusCode)
+}
+
+// discardResponse releases a response that isn't used. Its body is read so that it is written to the WARC,
+// the read stops early if its status is discarded.
+func discardResponse(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,114 @@
+package archiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestDiscardedStatuses(t *testing.T) {
+	if got := discardedStatuses([]int{429}, true); !slices.Equal(got, []int{429}) {
+		t.Errorf("discardedStatuses([429], true) = %v, want [429]", got)
+	}
+
+	got := discardedStatuses([]int{429}, false)
+	if len(got) != 200 || !slices.Contains(got, 404) || !slices.Contains(got, 599) || slices.Contains(got, 301) {
+		t.Errorf("discardedStatuses([429], false) = %v, want the 4xx and 5xx codes", got)
+	}
+}
+
+func TestDiscardedStatusesWARC(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, discard []int, recordErrors bool) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().WARCDiscardStatus = discard
+		config.Get().WARCRecordErrorResponses = recordErrors
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().WARCDiscardStatus, config.Get().WARCRecordErrorResponses)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().WARCDiscardStatus = []int{404}
+	config.Get().WARCRecordErrorResponses = true
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+	client := globalArchiver.Client
+
+	// The small bodies are already buffered with the headers, they're discarded all the same
+	for _, page := range []string{"/page", "/missing", "/error"} {
+		resp, err := client.Get(server.URL + page)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", page, err)
+		}
+
+		if discarded := statusDiscarded(resp.StatusCode); discarded != (page == "/missing") {
+			t.Errorf("statusDiscarded(%d) = %t", resp.StatusCode, discarded)
+		}
+
+		discardResponse(resp)
+	}
+
+	client.CloseIdleConnections()
+	client.WaitGroup.Wait()
+	client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+
+	var records strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records.Write(data)
+	}
+
+	for _, want := range []string{"WARC-Target-URI: " + server.URL + "/page", "WARC-Target-URI: " + server.URL + "/error"} {
+		if !strings.Contains(records.String(), want) {
+			t.Errorf("expected %q in the WARC files", want)
+		}
+	}
+
+	if strings.Contains(records.String(), "WARC-Target-URI: "+server.URL+"/missing") {
+		t.Error("expected the 404 response to be discarded from the WARC files")
+	}
+}
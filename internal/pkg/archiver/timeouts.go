@@ -0,0 +1,149 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+var (
+	errResponseHeaderTimeout = errors.New("timeout awaiting response headers")
+	errReadIdleTimeout       = errors.New("timeout reading response body, no data received")
+)
+
+// timeoutError is a timeout of timeoutTransport, it is a net.Error so that it is retried like the other timeouts
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string   { return e.err.Error() }
+func (e *timeoutError) Unwrap() error   { return e.err }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// timeoutTransport bounds, on top of the connect timeouts of the WARC library's transport, the wait for the
+// response headers once the request is written (--response-header-timeout) and the time without any byte
+// of the body read (--read-idle-timeout). Unlike --http-timeout, a download that keeps progressing is never aborted.
+type timeoutTransport struct {
+	next                  http.RoundTripper
+	responseHeaderTimeout time.Duration
+	readIdleTimeout       time.Duration
+}
+
+// CloseIdleConnections closes the idle connections of the WARC library's transport, http.Client only
+// forwards it to a transport implementing it
+func (t *timeoutTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.responseHeaderTimeout <= 0 && t.readIdleTimeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancelCause(req.Context())
+
+	// The wait for the headers starts once the request is written, the connection and the upload aren't part of it
+	var (
+		mu          sync.Mutex
+		headerTimer *time.Timer
+		responded   bool
+	)
+	if t.responseHeaderTimeout > 0 {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteRequest: func(httptrace.WroteRequestInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if !responded && headerTimer == nil {
+					headerTimer = time.AfterFunc(t.responseHeaderTimeout, func() { cancel(errResponseHeaderTimeout) })
+				}
+			},
+		})
+	}
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+
+	mu.Lock()
+	responded = true
+	timedOut := headerTimer != nil && !headerTimer.Stop()
+	mu.Unlock()
+
+	if err == nil && timedOut {
+		resp.Body.Close()
+		err = errResponseHeaderTimeout
+	}
+
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errResponseHeaderTimeout) {
+			err = &timeoutError{err: cause}
+		}
+		cancel(nil)
+		return nil, err
+	}
+
+	resp.Body = newIdleTimeoutBody(ctx, resp.Body, t.readIdleTimeout, cancel)
+
+	return resp, nil
+}
+
+// idleTimeoutBody aborts the read of a body when no byte is received for the timeout, if positive.
+// The request's context is canceled once it's closed.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleTimeoutBody(ctx context.Context, body io.ReadCloser, timeout time.Duration, cancel context.CancelCauseFunc) *idleTimeoutBody {
+	b := &idleTimeoutBody{
+		ReadCloser: body,
+		ctx:        ctx,
+		cancel:     cancel,
+		timeout:    timeout,
+	}
+
+	if timeout > 0 {
+		b.timer = time.AfterFunc(timeout, func() { cancel(errReadIdleTimeout) })
+	}
+
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+
+	if n > 0 && b.timer != nil {
+		b.timer.Reset(b.timeout)
+	}
+
+	if err != nil && err != io.EOF && errors.Is(context.Cause(b.ctx), errReadIdleTimeout) {
+		err = &timeoutError{err: errReadIdleTimeout}
+	}
+
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+
+	err := b.ReadCloser.Close()
+	b.cancel(nil)
+
+	return err
+}
+
+// SetReadDeadline forwards the read deadline to the underlying body, ProcessBody relies on it
+func (b *idleTimeoutBody) SetReadDeadline(t time.Time) error {
+	return setReadDeadline(b.ReadCloser, t)
+}
@@ -0,0 +1,106 @@
+package archiver
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTimeoutTestClient(responseHeaderTimeout, readIdleTimeout time.Duration) *http.Client {
+	return &http.Client{Transport: &timeoutTransport{
+		next:                  http.DefaultTransport,
+		responseHeaderTimeout: responseHeaderTimeout,
+		readIdleTimeout:       readIdleTimeout,
+	}}
+}
+
+func TestTimeoutTransportResponseHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := newTimeoutTestClient(100*time.Millisecond, 0)
+
+	_, err := client.Get(server.URL + "/slow")
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() || !errors.Is(err, errResponseHeaderTimeout) {
+		t.Fatalf("expected a response header timeout, got %v", err)
+	}
+
+	resp, err := client.Get(server.URL + "/fast")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "ok" {
+		t.Errorf("ReadAll() = %q, %v", body, err)
+	}
+}
+
+func TestTimeoutTransportReadIdle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 6 chunks, the slow download takes longer than the idle timeout but keeps progressing
+		for range 6 {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+
+			if r.URL.Path == "/stalled" {
+				<-r.Context().Done()
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := newTimeoutTestClient(0, 150*time.Millisecond)
+
+	resp, err := client.Get(server.URL + "/progressing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(body) != 30 {
+		t.Errorf("expected the progressing download to complete, got %d bytes, %v", len(body), err)
+	}
+
+	resp, err = client.Get(server.URL + "/stalled")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() || !errors.Is(err, errReadIdleTimeout) {
+		t.Fatalf("expected a read idle timeout, got %v", err)
+	}
+}
+
+type idleClosingTransport struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *idleClosingTransport) CloseIdleConnections() { t.closed++ }
+
+func TestTimeoutTransportCloseIdleConnections(t *testing.T) {
+	next := &idleClosingTransport{RoundTripper: http.DefaultTransport}
+	client := &http.Client{Transport: &timeoutTransport{next: next}}
+
+	client.CloseIdleConnections()
+
+	if next.closed != 1 {
+		t.Errorf("expected CloseIdleConnections to reach the wrapped transport, got %d calls", next.closed)
+	}
+}
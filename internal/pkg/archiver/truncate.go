@@ -0,0 +1,183 @@
+package archiver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/CorentinB/warc/pkg/spooledtempfile"
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// truncatingBody wraps a response body to stop reading it after maxSize bytes.
+// The bytes read are kept so that the truncated capture can be written as a WARC record,
+// the WARC library drops the responses that aren't read until the end.
+type truncatingBody struct {
+	body      io.ReadCloser
+	remaining int64
+	truncated bool
+	kept      spooledtempfile.ReadWriteSeekCloser
+}
+
+func newTruncatingBody(body io.ReadCloser, maxSize int64) *truncatingBody {
+	return &truncatingBody{
+		body:      body,
+		remaining: maxSize,
+		kept:      spooledtempfile.NewSpooledTempFile("zeno", config.Get().WARCTempDir, config.Get().MaxInMemoryResponseSize, config.Get().WARCOnDisk, -1),
+	}
+}
+
+func (b *truncatingBody) Read(p []byte) (n int, err error) {
+	if b.truncated {
+		return 0, io.EOF
+	}
+
+	if b.remaining <= 0 {
+		// The limit is reached, the body is only truncated if there is something left to read
+		var probe [1]byte
+		n, err = io.ReadFull(b.body, probe[:])
+		if n == 0 {
+			return 0, err
+		}
+
+		b.truncated = true
+
+		// Closing the body before the end closes the connection instead of reusing it
+		b.body.Close()
+
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err = b.body.Read(p)
+	b.remaining -= int64(n)
+
+	if n > 0 {
+		if _, writeErr := b.kept.Write(p[:n]); writeErr != nil {
+			return n, writeErr
+		}
+	}
+
+	return n, err
+}
+
+func (b *truncatingBody) Close() error {
+	if b.truncated {
+		return nil
+	}
+
+	return b.body.Close()
+}
+
+// SetReadDeadline forwards the read deadline to the underlying body, ProcessBody relies on it
+func (b *truncatingBody) SetReadDeadline(t time.Time) error {
+	return setReadDeadline(b.body, t)
+}
+
+// discard frees the bytes kept for the truncated record
+func (b *truncatingBody) discard() {
+	b.kept.Close()
+}
+
+// writeTruncatedResponse writes the truncated capture of resp as a WARC response record
+// flagged with WARC-Truncated: length. The HTTP headers are written as received,
+// followed by the part of the body that was read. The WARC library drops the request
+// along with the response, so the request is written too, concurrent to the response.
+func writeTruncatedResponse(w RecordWriter, targetURI string, resp *http.Response, body *truncatingBody) error {
+	if _, err := body.kept.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	responseID := "<urn:uuid:" + uuid.NewString() + ">"
+
+	record := warc.NewRecord(config.Get().WARCTempDir, config.Get().WARCOnDisk)
+	record.Header.Set("WARC-Type", "response")
+	record.Header.Set("WARC-Record-ID", responseID)
+	record.Header.Set("WARC-Target-URI", targetURI)
+	record.Header.Set("Content-Type", "application/http; msgtype=response")
+	record.Header.Set("WARC-Truncated", "length")
+
+	if err := writeResponseHead(record.Content, resp); err != nil {
+		record.Content.Close()
+		return err
+	}
+
+	if _, err := io.Copy(record.Content, body.kept); err != nil {
+		record.Content.Close()
+		return err
+	}
+
+	if err := w.WriteRecord(record); err != nil {
+		return err
+	}
+
+	if resp.Request == nil {
+		return nil
+	}
+
+	request := warc.NewRecord(config.Get().WARCTempDir, config.Get().WARCOnDisk)
+	request.Header.Set("WARC-Type", "request")
+	request.Header.Set("WARC-Target-URI", targetURI)
+	request.Header.Set("WARC-Concurrent-To", responseID)
+	request.Header.Set("Content-Type", "application/http; msgtype=request")
+
+	if err := writeRequestHead(request.Content, resp.Request); err != nil {
+		request.Content.Close()
+		return err
+	}
+
+	return w.WriteRecord(request)
+}
+
+// writeResponseHead writes the status line and the headers of resp as received
+func writeResponseHead(w io.Writer, resp *http.Response) error {
+	if _, err := fmt.Fprintf(w, "HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status); err != nil {
+		return err
+	}
+
+	if err := resp.Header.Write(w); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\r\n")
+
+	return err
+}
+
+// writeRequestHead writes the request line and the headers of req as sent, the truncated
+// captures are GET requests without a body
+func writeRequestHead(w io.Writer, req *http.Request) error {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	if _, err := fmt.Fprintf(w, "%s %s HTTP/%d.%d\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.ProtoMajor, req.ProtoMinor, host); err != nil {
+		return err
+	}
+
+	if err := req.Header.Write(w); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\r\n")
+
+	return err
+}
+
+// writeRecordBatch sends the record to the WARC writer of the client and waits for it to be written
+func writeRecordBatch(client *warc.CustomHTTPClient, record *warc.Record) {
+	batch := warc.NewRecordBatch(make(chan struct{}, 1))
+	batch.Records = append(batch.Records, record)
+
+	client.WARCWriter <- batch
+
+	// Wait for the record to be written
+	<-batch.FeedbackChan
+}
@@ -0,0 +1,203 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTruncatingBody(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		name      string
+		body      string
+		maxSize   int64
+		want      string
+		truncated bool
+	}{
+		{"smaller than the limit", "hello", 10, "hello", false},
+		{"exactly the limit", "hello", 5, "hello", false},
+		{"bigger than the limit", "hello world", 5, "hello", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := &closeRecorder{Reader: strings.NewReader(tt.body)}
+
+			body := newTruncatingBody(underlying, tt.maxSize)
+			defer body.discard()
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("expected to read %q, got %q", tt.want, data)
+			}
+
+			if body.truncated != tt.truncated {
+				t.Errorf("expected truncated to be %v, got %v", tt.truncated, body.truncated)
+			}
+
+			// A truncated body is closed as soon as the limit is crossed, the others by their reader
+			if underlying.closed != tt.truncated {
+				t.Errorf("expected the underlying body closed to be %v, got %v", tt.truncated, underlying.closed)
+			}
+
+			body.Close()
+			if !underlying.closed {
+				t.Error("expected the underlying body to be closed")
+			}
+		})
+	}
+}
+
+func TestWriteTruncatedResponse(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+	}(config.Get().JobPath, config.Get().WARCPoolSize)
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "Zeno")
+	resp.Request = req
+
+	body := newTruncatingBody(io.NopCloser(strings.NewReader("0123456789")), 4)
+	defer body.discard()
+
+	if _, err := io.ReadAll(body); err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	client, release := globalArchiver.acquireClient("")
+	if err := writeTruncatedResponse(globalArchiver.recordWriter(client), "http://example.com/stream", resp, body); err != nil {
+		t.Fatalf("writeTruncatedResponse() error = %v", err)
+	}
+	release()
+
+	globalArchiver.Client.WaitGroup.Wait()
+	globalArchiver.Client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 WARC file, got %q (err: %v)", files, err)
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var responseID, concurrentTo string
+	for {
+		record, eol, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord() error = %v", err)
+		}
+		if eol {
+			break
+		}
+
+		content, err := io.ReadAll(record.Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch record.Header.Get("WARC-Type") {
+		case "response":
+			responseID = record.Header.Get("WARC-Record-ID")
+
+			if truncated := record.Header.Get("WARC-Truncated"); truncated != "length" {
+				t.Errorf("expected WARC-Truncated to be length, got %q", truncated)
+			}
+
+			if uri := record.Header.Get("WARC-Target-URI"); uri != "http://example.com/stream" {
+				t.Errorf("unexpected WARC-Target-URI %q", uri)
+			}
+
+			if !bytes.HasPrefix(content, []byte("HTTP/1.1 200 OK\r\n")) {
+				t.Errorf("expected the record to start with the status line, got %q", content)
+			}
+
+			if !bytes.HasSuffix(content, []byte("\r\n\r\n0123")) {
+				t.Errorf("expected the record to end with the truncated body, got %q", content)
+			}
+		case "request":
+			concurrentTo = record.Header.Get("WARC-Concurrent-To")
+
+			if !bytes.HasPrefix(content, []byte("GET /stream HTTP/1.1\r\nHost: example.com\r\n")) {
+				t.Errorf("expected the record to start with the request line, got %q", content)
+			}
+
+			if !bytes.Contains(content, []byte("User-Agent: Zeno\r\n")) {
+				t.Errorf("expected the record to hold the request headers, got %q", content)
+			}
+		}
+	}
+
+	if responseID == "" {
+		t.Fatal("no response record found")
+	}
+
+	if concurrentTo != responseID {
+		t.Errorf("expected the request record to be concurrent to %q, got %q", responseID, concurrentTo)
+	}
+}
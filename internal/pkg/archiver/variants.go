@@ -0,0 +1,113 @@
+package archiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// captureLanguageVariants requests the page again with each of --accept-language-variants when its
+// response varies on Accept-Language, one after the other under the host slot the worker holds for the
+// page, so that every variant is written to the WARC files. Only their records are kept, the page's own
+// response is the one used for extraction. It returns the number of variants captured.
+func captureLanguageVariants(client *warc.CustomHTTPClient, item *models.Item, req *http.Request, resp *http.Response) (captured int) {
+	if len(config.Get().AcceptLanguageVariants) == 0 || item.IsChild() || req.Method != http.MethodGet ||
+		resp.StatusCode != http.StatusOK || !variesOn(resp.Header, "Accept-Language") {
+		return 0
+	}
+
+	for _, language := range languageVariants(req.Header.Get("Accept-Language"), config.Get().AcceptLanguageVariants) {
+		if err := captureLanguageVariant(client, req, language); err != nil {
+			// The requests were canceled, the next variants would fail the same way
+			if req.Context().Err() != nil {
+				break
+			}
+
+			logger.Warn("unable to capture language variant", "err", err.Error(), "url", req.URL.String(), "accept_language", language, "item_id", item.GetShortID())
+			continue
+		}
+
+		captured++
+	}
+
+	return captured
+}
+
+// captureLanguageVariant keeps the context of the page's request, the variant is aborted by
+// CancelRequests and resolved the same way as the page. It gets its own WARC feedback channel,
+// gowarc closes the channel of each request once its records are written.
+func captureLanguageVariant(client *warc.CustomHTTPClient, req *http.Request, language string) error {
+	var feedbackChan chan struct{}
+	if !config.Get().WARCWriteAsync {
+		feedbackChan = make(chan struct{}, 1)
+	}
+
+	variant := req.Clone(variantContext(req.Context(), feedbackChan))
+	variant.Header.Set("Accept-Language", language)
+
+	if globalBucketManager != nil {
+		globalBucketManager.Wait(variant.URL.Host)
+	}
+	waitGlobalLimiter()
+
+	resp, err := client.Do(variant)
+	if err != nil {
+		return err
+	}
+
+	// The record is written once the body is consumed
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if feedbackChan != nil {
+		<-feedbackChan
+	}
+
+	return err
+}
+
+// variantContext replaces the WARC feedback channel of the page's request context, none is set when
+// feedbackChan is nil
+func variantContext(ctx context.Context, feedbackChan chan struct{}) context.Context {
+	if feedbackChan == nil {
+		return context.WithValue(ctx, "feedback", nil)
+	}
+
+	return context.WithValue(ctx, "feedback", feedbackChan)
+}
+
+// languageVariants returns the variants other than the Accept-Language of the page, without duplicates
+func languageVariants(current string, variants []string) (languages []string) {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(current)): true}
+
+	for _, variant := range variants {
+		key := strings.ToLower(strings.TrimSpace(variant))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		languages = append(languages, strings.TrimSpace(variant))
+	}
+
+	return languages
+}
+
+// variesOn tells if the Vary header of a response names the header. "Vary: *" isn't taken for
+// a negotiation on the language, the response varies on things the crawler can't reproduce.
+func variesOn(header http.Header, name string) bool {
+	for _, value := range header.Values("Vary") {
+		for field := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package archiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestVariesOn(t *testing.T) {
+	tests := []struct {
+		vary   []string
+		varies bool
+	}{
+		{nil, false},
+		{[]string{"Accept-Encoding"}, false},
+		{[]string{"Accept-Encoding, accept-language"}, true},
+		{[]string{"Cookie", "Accept-Language"}, true},
+		{[]string{"*"}, false},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{"Vary": tt.vary}
+		if varies := variesOn(header, "Accept-Language"); varies != tt.varies {
+			t.Errorf("variesOn(%q) = %v, want %v", tt.vary, varies, tt.varies)
+		}
+	}
+}
+
+func TestLanguageVariants(t *testing.T) {
+	got := languageVariants("en", []string{"fr", " EN ", "de", "fr"})
+	if want := []string{"fr", "de"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("languageVariants() = %q, want %q", got, want)
+	}
+}
+
+func TestCaptureLanguageVariants(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string, writeAsync bool, variants []string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+		config.Get().WARCWriteAsync = writeAsync
+		config.Get().AcceptLanguageVariants = variants
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression, config.Get().WARCWriteAsync, config.Get().AcceptLanguageVariants)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte("page in " + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WARCPoolSize = 1
+	config.Get().WARCCompression = "none"
+	config.Get().WARCWriteAsync = false
+	config.Get().AcceptLanguageVariants = []string{"fr", "en", "de"}
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+	startWARCWriter()
+	client := globalArchiver.Client
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Accept-Language", "en")
+
+	// The page goes through the synchronous WARC writing like in the archiver, its feedback channel
+	// is in the context the variants are cloned from
+	feedbackChan := make(chan struct{}, 1)
+	req = req.WithContext(context.WithValue(req.Context(), "feedback", feedbackChan))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	<-feedbackChan
+
+	u := &models.URL{Raw: req.URL.String()}
+	if err := u.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	item := models.NewItem("page", u, "")
+
+	if captured := captureLanguageVariants(client, item, req, resp); captured != 2 {
+		t.Errorf("expected the 2 other variants to be captured, got %d", captured)
+	}
+
+	// The variants of a canceled request aren't captured
+	canceledCtx, cancelRequest := context.WithCancel(context.Background())
+	cancelRequest()
+	if captured := captureLanguageVariants(client, item, req.WithContext(canceledCtx), resp); captured != 0 {
+		t.Errorf("expected no variant for a canceled request, got %d", captured)
+	}
+
+	// A response that doesn't vary on the language has no variants
+	resp.Header.Del("Vary")
+	if captured := captureLanguageVariants(client, item, req, resp); captured != 0 {
+		t.Errorf("expected no variant without Vary, got %d", captured)
+	}
+
+	client.CloseIdleConnections()
+	client.WaitGroup.Wait()
+	client.Close()
+
+	files, err := GetWARCFiles()
+	if err != nil {
+		t.Fatalf("GetWARCFiles() error = %v", err)
+	}
+
+	var records strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records.Write(data)
+	}
+
+	for _, language := range []string{"en", "fr", "de"} {
+		if !strings.Contains(records.String(), "page in "+language) {
+			t.Errorf("expected the %s variant in the WARC files", language)
+		}
+	}
+}
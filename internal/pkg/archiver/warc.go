@@ -3,12 +3,17 @@ package archiver
 import (
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/CorentinB/warc"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 )
 
+// Settings of the WARC writing HTTP clients, kept to create new ones when the WARC files are rotated
+var warcSettings warc.HTTPClientSettings
+
 func startWARCWriter() {
 	// Configure WARC rotator settings
 	rotatorSettings := warc.NewRotatorSettings()
@@ -16,6 +21,7 @@ func startWARCWriter() {
 	rotatorSettings.WARCWriterPoolSize = config.Get().WARCPoolSize
 	rotatorSettings.WarcSize = float64(config.Get().WARCSize)
 	rotatorSettings.OutputDirectory = path.Join(config.Get().JobPath, "warcs")
+	rotatorSettings.Compression = warcCompression(config.Get().WARCCompression)
 
 	if config.Get().WARCOperator != "" {
 		rotatorSettings.WarcinfoContent.Set("operator", config.Get().WARCOperator)
@@ -32,68 +38,219 @@ func startWARCWriter() {
 		}
 	}
 
-	// Configure WARC settings
-	WARCSettings := warc.HTTPClientSettings{
-		RotatorSettings:     rotatorSettings,
-		DedupeOptions:       dedupeOptions,
-		DecompressBody:      true,
-		SkipHTTPStatusCodes: config.Get().WARCDiscardStatus,
-		VerifyCerts:         config.Get().CertValidation,
-		TempDir:             config.Get().WARCTempDir,
-		FullOnDisk:          config.Get().WARCOnDisk,
-		RandomLocalIP:       config.Get().RandomLocalIP,
-		DisableIPv4:         config.Get().DisableIPv4,
-		DisableIPv6:         config.Get().DisableIPv6,
-		IPv6AnyIP:           config.Get().IPv6AnyIP,
-	}
-
-	// Instantiate WARC client
-	var err error
-	if config.Get().Proxy != "" {
-		proxiedWARCSettings := WARCSettings
-		proxiedWARCSettings.Proxy = config.Get().Proxy
-		globalArchiver.ClientWithProxy, err = warc.NewWARCWritingHTTPClient(proxiedWARCSettings)
+	// Preload the dedupe index of prior captures, if any, and serve it to the WARC writer as a CDX server
+	if config.Get().WARCDedupeIndex != "" {
+		index, err := loadCDXIndex(config.Get().WARCDedupeIndex)
 		if err != nil {
-			logger.Error("unable to init proxied WARC HTTP client", "err", err.Error(), "func", "archiver.startWARCWriter")
+			logger.Error("unable to load dedupe index", "err", err.Error(), "source", config.Get().WARCDedupeIndex, "func", "archiver.startWARCWriter")
 			os.Exit(1)
 		}
 
-		go func() {
-			for err := range globalArchiver.ClientWithProxy.ErrChan {
-				logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
-			}
-		}()
-	}
+		index.fallback = config.Get().CDXDedupeServer
+		index.cookie = config.Get().CDXCookie
 
-	// Even if a proxied client has been set, we want to create an non-proxied one
-	if config.Get().Proxy == "" {
-		globalArchiver.Client, err = warc.NewWARCWritingHTTPClient(WARCSettings)
+		dedupeOptions.CDXURL, err = startCDXIndexServer(index)
 		if err != nil {
-			logger.Error("unable to init WARC HTTP client", "err", err.Error(), "func", "archiver.startWARCWriter")
+			logger.Error("unable to start dedupe index server", "err", err.Error(), "func", "archiver.startWARCWriter")
 			os.Exit(1)
 		}
+		dedupeOptions.CDXDedupe = true
 
-		go func() {
-			for err := range globalArchiver.Client.ErrChan {
-				logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
-			}
-		}()
+		logger.Info("dedupe index loaded", "source", config.Get().WARCDedupeIndex, "captures", len(index.captures))
 	}
 
-	// Set the timeouts
-	if config.Get().HTTPTimeout > 0 {
-		if globalArchiver.Client != nil {
-			globalArchiver.Client.Timeout = time.Duration(config.Get().HTTPTimeout) * time.Second
+	// Configure WARC settings
+	WARCSettings := warc.HTTPClientSettings{
+		RotatorSettings:      rotatorSettings,
+		DedupeOptions:        dedupeOptions,
+		DecompressBody:       true,
+		SkipHTTPStatusCodes:  discardedStatuses(config.Get().WARCDiscardStatus, config.Get().WARCRecordErrorResponses),
+		VerifyCerts:          config.Get().CertValidation,
+		TempDir:              config.Get().WARCTempDir,
+		FullOnDisk:           config.Get().WARCOnDisk,
+		RandomLocalIP:        config.Get().RandomLocalIP,
+		DisableIPv4:          config.Get().DisableIPv4,
+		DisableIPv6:          config.Get().DisableIPv6,
+		PreferIPv4:           config.Get().PreferIPv4,
+		PreferIPv6:           config.Get().PreferIPv6,
+		IPv6AnyIP:            config.Get().IPv6AnyIP,
+		DNSRecordsTTL:        config.Get().DNSCacheTTL,
+		DNSRecordsStale:      config.Get().DNSCacheStale,
+		UncachedHost:         uncachedHosts(config.Get().DNSCacheTTL, config.Get().DNSNoCacheHosts),
+		DNSCacheSize:         config.Get().DNSCacheSize,
+		DNSServers:           config.Get().DNSServers,
+		DNSResolutionTimeout: config.Get().DNSTimeout,
+		DialTimeout:          config.Get().ConnectTimeout,
+		TLSHandshakeTimeout:  config.Get().ConnectTimeout,
+	}
+
+	locals, err := newLocalAddresses(config.Get().LocalAddress, config.Get().LocalCIDR)
+	if err != nil {
+		logger.Error("unable to bind the connections", "err", err.Error(), "func", "archiver.startWARCWriter")
+		os.Exit(1)
+	}
+
+	if locals != nil {
+		WARCSettings.LocalAddr = locals.pick
+		logger.Info("binding the connections to local addresses", "addresses", locals.IPs)
+	}
+
+	// The WARC clients can't resolve anything without it, the error is theirs
+	if err := loadResolvConf(); err != nil {
+		logger.Warn("unable to load the DNS configuration", "err", err.Error(), "func", "archiver.startWARCWriter")
+	}
+
+	if len(config.Get().FetchContentTypeAllow) > 0 || len(config.Get().FetchContentTypeDeny) > 0 {
+		WARCSettings.SkipContentType = contentTypeSkipped
+	}
+
+	globalResolver = newAddressResolver(config.Get().ResolvedAddresses)
+
+	if len(config.Get().ResolvedAddresses) > 0 {
+		WARCSettings.StaticAddress = globalResolver.pinned
+	}
+
+	warcSettings = WARCSettings
+
+	if proxies := poolProxies(config.Get().ProxyFileProxies); len(proxies) > 0 {
+		globalProxyPool = newProxyPool(proxies, config.Get().ProxyRotation, config.Get().ProxyMaxFailures, config.Get().ProxyCooldown)
+	}
+
+	globalArchiver.Client, globalArchiver.ClientWithProxy, globalArchiver.ClientsWithProxyPool, err = newWARCClients(WARCSettings)
+	if err != nil {
+		logger.Error("unable to init WARC HTTP client", "err", err.Error(), "func", "archiver.startWARCWriter")
+		os.Exit(1)
+	}
+}
+
+// newWARCClients creates the WARC writing HTTP clients, each of them starts its own set of WARC files.
+// Without proxy a single non-proxied client is created, otherwise a client per proxy.
+func newWARCClients(WARCSettings warc.HTTPClientSettings) (client, clientWithProxy *warc.CustomHTTPClient, clientsWithProxyPool []*warc.CustomHTTPClient, err error) {
+	switch {
+	case globalProxyPool != nil:
+		for _, proxy := range globalProxyPool.addresses() {
+			pooledClient, err := newWARCClient(WARCSettings, proxy)
+			if err != nil {
+				for _, created := range clientsWithProxyPool {
+					created.Close()
+				}
+
+				return nil, nil, nil, err
+			}
+
+			clientsWithProxyPool = append(clientsWithProxyPool, pooledClient)
 		}
+	case config.Get().Proxy != "":
+		clientWithProxy, err = newWARCClient(WARCSettings, config.Get().Proxy)
+	default:
+		client, err = newWARCClient(WARCSettings, "")
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return client, clientWithProxy, clientsWithProxyPool, nil
+}
+
+func newWARCClient(WARCSettings warc.HTTPClientSettings, proxy string) (*warc.CustomHTTPClient, error) {
+	WARCSettings.Proxy = proxy
+
+	client, err := warc.NewWARCWritingHTTPClient(WARCSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxy != "" && globalArchiver != nil {
+		globalArchiver.proxies.Store(client, proxy)
+	}
 
-		if globalArchiver.ClientWithProxy != nil {
-			globalArchiver.ClientWithProxy.Timeout = time.Duration(config.Get().HTTPTimeout) * time.Second
+	go func() {
+		for err := range client.ErrChan {
+			// The responses with a discarded status or content type are skipped with an error
+			if strings.Contains(err.Err.Error(), "was blocked by config") {
+				logger.Debug("response discarded from the WARC", "err", err.Err.Error())
+				continue
+			}
+
+			logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
 		}
+	}()
+
+	// Set the timeout
+	if config.Get().HTTPTimeout > 0 {
+		client.Timeout = time.Duration(config.Get().HTTPTimeout) * time.Second
 	}
+
+	client.Transport = &timeoutTransport{
+		next:                  client.Transport,
+		responseHeaderTimeout: config.Get().ResponseHeaderTimeout,
+		readIdleTimeout:       config.Get().ReadIdleTimeout,
+	}
+
+	return client, nil
 }
 
-func GetClients() (clients []*warc.CustomHTTPClient) {
-	for _, c := range []*warc.CustomHTTPClient{globalArchiver.Client, globalArchiver.ClientWithProxy} {
+// acquireClient returns the WARC writing client to use for a request to host and holds it until
+// release is called, so that a rotation doesn't close it while a capture is still using it
+func (a *archiver) acquireClient(host string) (client *warc.CustomHTTPClient, release func()) {
+	a.clientsMu.RLock()
+	defer a.clientsMu.RUnlock()
+
+	switch {
+	case globalProxyPool != nil && len(a.ClientsWithProxyPool) > 0:
+		client = a.ClientsWithProxyPool[globalProxyPool.pick(host)]
+	case config.Get().Proxy != "":
+		client = a.ClientWithProxy
+	default:
+		client = a.Client
+	}
+
+	if client == nil {
+		return nil, func() {}
+	}
+
+	// The previous clients aren't acquired anymore once closeClient waits for them
+	holds, _ := a.holds.LoadOrStore(client, new(sync.WaitGroup))
+	holds.(*sync.WaitGroup).Add(1)
+
+	return client, holds.(*sync.WaitGroup).Done
+}
+
+// closeClient closes a client once the captures holding it are done, it's called once the client is
+// replaced, or when stopping
+func (a *archiver) closeClient(client *warc.CustomHTTPClient) {
+	if holds, found := a.holds.LoadAndDelete(client); found {
+		holds.(*sync.WaitGroup).Wait()
+	}
+
+	a.proxies.Delete(client)
+	client.Close()
+}
+
+// reportProxy records the outcome of a request made with client, so that the proxies of the pool
+// that keep failing are taken out of the rotation
+func (a *archiver) reportProxy(client *warc.CustomHTTPClient, failed bool) {
+	if globalProxyPool == nil {
+		return
+	}
+
+	if proxy, found := a.proxies.Load(client); found {
+		globalProxyPool.report(proxy.(string), failed)
+	}
+}
+
+// isProxied returns true if the requests of the client go through a proxy
+func (a *archiver) isProxied(client *warc.CustomHTTPClient) bool {
+	a.clientsMu.RLock()
+	defer a.clientsMu.RUnlock()
+
+	return client != a.Client
+}
+
+// clients returns all the WARC writing clients, the caller must hold clientsMu
+func (a *archiver) clients() (clients []*warc.CustomHTTPClient) {
+	for _, c := range append([]*warc.CustomHTTPClient{a.Client, a.ClientWithProxy}, a.ClientsWithProxyPool...) {
 		if c != nil {
 			clients = append(clients, c)
 		}
@@ -102,12 +259,98 @@ func GetClients() (clients []*warc.CustomHTTPClient) {
 	return clients
 }
 
+func GetClients() (clients []*warc.CustomHTTPClient) {
+	globalArchiver.clientsMu.RLock()
+	defer globalArchiver.clientsMu.RUnlock()
+
+	return globalArchiver.clients()
+}
+
 func GetWARCWritingQueueSize() (total int) {
-	for _, c := range []*warc.CustomHTTPClient{globalArchiver.Client, globalArchiver.ClientWithProxy} {
-		if c != nil {
-			total += c.WaitGroup.Size()
-		}
+	globalArchiver.clientsMu.RLock()
+	defer globalArchiver.clientsMu.RUnlock()
+
+	for _, c := range globalArchiver.clients() {
+		total += c.WaitGroup.Size()
 	}
 
 	return total
 }
+
+// WriteMetadataRecord writes a WARC metadata record for the given target URI with the given payload,
+// concurrent to the record of the given ID, e.g. the response of the capture it describes.
+func WriteMetadataRecord(targetURI, concurrentTo, contentType string, payload []byte) error {
+	return writeRecord(targetURI, "metadata", concurrentTo, contentType, payload)
+}
+
+// WriteResourceRecord writes a WARC resource record for the given target URI with the given payload.
+func WriteResourceRecord(targetURI, contentType string, payload []byte) error {
+	return writeRecord(targetURI, "resource", "", contentType, payload)
+}
+
+func writeRecord(targetURI, warcType, concurrentTo, contentType string, payload []byte) error {
+	if globalArchiver == nil {
+		return ErrNoWARCClient
+	}
+
+	record := warc.NewRecord(config.Get().WARCTempDir, config.Get().WARCOnDisk)
+	record.Header.Set("WARC-Type", warcType)
+	record.Header.Set("WARC-Target-URI", targetURI)
+	if concurrentTo != "" {
+		record.Header.Set("WARC-Concurrent-To", concurrentTo)
+	}
+	if contentType != "" {
+		record.Header.Set("Content-Type", contentType)
+	}
+
+	if _, err := record.Content.Write(payload); err != nil {
+		record.Content.Close()
+		return err
+	}
+
+	if globalArchiver.records != nil {
+		return globalArchiver.records.WriteRecord(record)
+	}
+
+	// Hold the client so that Stop() or a rotation doesn't close the WARC writer under our feet
+	client, release := globalArchiver.acquireClient("")
+	defer release()
+
+	if client == nil {
+		record.Content.Close()
+		return ErrNoWARCClient
+	}
+
+	return globalArchiver.recordWriter(client).WriteRecord(record)
+}
+
+// GetWARCFiles returns the paths of the finished WARC files in the job's output directory,
+// files that are still being written (.open) are ignored
+func GetWARCFiles() (files []string, err error) {
+	outputDir := path.Join(config.Get().JobPath, "warcs")
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.Contains(entry.Name(), ".warc") || strings.HasSuffix(entry.Name(), ".open") {
+			continue
+		}
+
+		files = append(files, path.Join(outputDir, entry.Name()))
+	}
+
+	return files, nil
+}
+
+// warcCompression returns the compression of the WARC library for --warc-compression. The records are
+// compressed one by one, so that every rotated file can be indexed and read from any record offset.
+func warcCompression(compression string) string {
+	if compression == "none" {
+		return ""
+	}
+
+	return "GZIP"
+}
@@ -0,0 +1,111 @@
+package archiver
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestWARCCompression(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, poolSize int, compression string) {
+		config.Get().JobPath = jobPath
+		config.Get().WARCPoolSize = poolSize
+		config.Get().WARCCompression = compression
+	}(config.Get().JobPath, config.Get().WARCPoolSize, config.Get().WARCCompression)
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "archiver"})
+
+	defer func(previous *archiver) {
+		globalArchiver = previous
+	}(globalArchiver)
+
+	for _, tt := range []struct {
+		compression string
+		suffix      string
+	}{
+		{"gzip", ".warc.gz"},
+		{"none", ".warc"},
+	} {
+		t.Run(tt.compression, func(t *testing.T) {
+			config.Get().JobPath = t.TempDir()
+			config.Get().WARCPoolSize = 1
+			config.Get().WARCCompression = tt.compression
+
+			if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			globalArchiver = &archiver{ctx: ctx, cancel: cancel}
+
+			startWARCWriter()
+
+			// Every rotated file is a WARC file of its own
+			if err := WriteResourceRecord("urn:test:1", "text/plain", []byte("first")); err != nil {
+				t.Fatalf("WriteResourceRecord() error = %v", err)
+			}
+
+			if err := globalArchiver.rotateWARCs(); err != nil {
+				t.Fatalf("rotateWARCs() error = %v", err)
+			}
+
+			globalArchiver.Client.WaitGroup.Wait()
+			globalArchiver.Client.Close()
+
+			files, err := GetWARCFiles()
+			if err != nil {
+				t.Fatalf("GetWARCFiles() error = %v", err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("expected 2 WARC files, got %q", files)
+			}
+
+			for _, file := range files {
+				if !strings.HasSuffix(file, tt.suffix) {
+					t.Errorf("WARC file %s doesn't end with %s", file, tt.suffix)
+				}
+
+				if got := firstLine(t, file, tt.compression == "gzip"); got != "WARC/1.1" {
+					t.Errorf("%s starts with %q, want a WARC record", file, got)
+				}
+			}
+		})
+	}
+}
+
+// firstLine returns the first line of the file, of its first gzip member if compressed
+func firstLine(t *testing.T, name string, compressed bool) string {
+	t.Helper()
+
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if compressed {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			t.Fatalf("%s isn't gzipped: %v", name, err)
+		}
+		// Only the first member, each record is compressed on its own
+		gzipReader.Multistream(false)
+
+		reader = bufio.NewReader(gzipReader)
+	}
+
+	line, _ := reader.ReadString('\n')
+
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,145 @@
+package archiver
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// warcSizeLimit stops the crawl once the WARC files of the job reach --max-total-warc-size. The size on disk
+// is counted, compressed if the files are, including the files of the previous runs of the job. The WARC
+// library writes whole records, so the limit is checked between records: the records of the captures
+// in flight when it's reached are still written before the files are finalized.
+type warcSizeLimit struct {
+	max     int64
+	dir     string
+	size    atomic.Int64
+	reached chan struct{}
+	once    sync.Once
+}
+
+var (
+	globalWARCSizeLimit *warcSizeLimit
+
+	// limitReached merges the channels of the two limits when both are set
+	limitReached     chan struct{}
+	limitReachedOnce sync.Once
+)
+
+func newWARCSizeLimit(max int64, dir string) *warcSizeLimit {
+	return &warcSizeLimit{
+		max:     max,
+		dir:     dir,
+		reached: make(chan struct{}),
+	}
+}
+
+// update records the size of the WARC files and closes reached once it's over the limit,
+// it returns true when it's the update reaching it
+func (l *warcSizeLimit) update(size int64) (justReached bool) {
+	l.size.Store(size)
+	stats.WARCBytesOnDiskSet(size)
+
+	if size >= l.max {
+		l.once.Do(func() {
+			close(l.reached)
+			justReached = true
+		})
+	}
+
+	return justReached
+}
+
+// isReached returns true once the limit is reached, the seeds aren't archived anymore then
+func (l *warcSizeLimit) isReached() bool {
+	return l != nil && l.max > 0 && l.size.Load() >= l.max
+}
+
+// warcDiskSize returns the size on disk of the WARC files of dir, finalized or still open
+func warcDiskSize(dir string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.Contains(entry.Name(), ".warc") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file was finalized, i.e. renamed, in the meantime, it's counted at the next check
+			return nil
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+// warcSizeWatcher checks the size of the WARC files at every interval until the archiver is stopped
+func (a *archiver) warcSizeWatcher(interval time.Duration) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			size, err := warcDiskSize(globalWARCSizeLimit.dir)
+			if err != nil {
+				logger.Error("unable to compute the size of the WARC files", "err", err.Error(), "func", "archiver.warcSizeWatcher")
+				continue
+			}
+
+			if globalWARCSizeLimit.update(size) {
+				logger.Info("maximum total WARC size reached, stopping the crawl", "size", size, "max", globalWARCSizeLimit.max)
+			}
+		}
+	}
+}
+
+// WARCSizeLimitReached returns a channel closed once the WARC files reach --max-total-warc-size,
+// it is never closed if there is no limit
+func WARCSizeLimitReached() <-chan struct{} {
+	if globalWARCSizeLimit == nil {
+		return nil
+	}
+
+	return globalWARCSizeLimit.reached
+}
+
+// LimitReached returns a channel closed once the crawl is over by itself, when --max-crawled-items URLs
+// are crawled or the WARC files reach --max-total-warc-size. It is never closed if there is no limit.
+func LimitReached() <-chan struct{} {
+	crawlLimitReached, warcSizeLimitReached := CrawlLimitReached(), WARCSizeLimitReached()
+	if crawlLimitReached == nil {
+		return warcSizeLimitReached
+	} else if warcSizeLimitReached == nil {
+		return crawlLimitReached
+	}
+
+	limitReachedOnce.Do(func() {
+		limitReached = make(chan struct{})
+		go func() {
+			select {
+			case <-crawlLimitReached:
+			case <-warcSizeLimitReached:
+			}
+			close(limitReached)
+		}()
+	})
+
+	return limitReached
+}
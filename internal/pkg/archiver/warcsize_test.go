@@ -0,0 +1,85 @@
+package archiver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+func TestWARCDiskSize(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]int{
+		"ZENO-20240301-00001.warc.gz":              100,
+		"ZENO-20240301-00002.warc.gz.open":         50,
+		"temp/ZENO-20240301-00003.warc.zst":        25,
+		"ZENO-20240301-00001.warc.gz.cdx.tmp.junk": 0,
+		"notes.txt": 1000,
+	}
+
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, err := warcDiskSize(dir)
+	if err != nil {
+		t.Fatalf("warcDiskSize() error = %v", err)
+	}
+
+	if size != 175 {
+		t.Errorf("warcDiskSize() = %d, want 175", size)
+	}
+
+	if _, err := warcDiskSize(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestWARCSizeLimit(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	limit := newWARCSizeLimit(1000, t.TempDir())
+
+	if limit.update(999) || limit.isReached() {
+		t.Fatal("expected the limit not to be reached")
+	}
+
+	select {
+	case <-limit.reached:
+		t.Fatal("expected reached to be open")
+	default:
+	}
+
+	if !limit.update(1200) || !limit.isReached() {
+		t.Fatal("expected the limit to be reached")
+	}
+
+	if limit.update(1500) {
+		t.Error("expected the limit to be reported reached once")
+	}
+
+	select {
+	case <-limit.reached:
+	default:
+		t.Fatal("expected reached to be closed")
+	}
+
+	if got := stats.WARCBytesOnDiskGet(); got != 1500 {
+		t.Errorf("WARCBytesOnDiskGet() = %d, want 1500", got)
+	}
+
+	var disabled *warcSizeLimit
+	if disabled.isReached() {
+		t.Error("expected no limit to never be reached")
+	}
+}
@@ -0,0 +1,180 @@
+package archiver
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+type workerState int32
+
+const (
+	workerIdle workerState = iota
+	workerFetching
+	workerExtracting
+	workerWriting
+)
+
+func (s workerState) String() string {
+	switch s {
+	case workerFetching:
+		return "fetching"
+	case workerExtracting:
+		return "extracting"
+	case workerWriting:
+		return "writing"
+	default:
+		return "idle"
+	}
+}
+
+// workerItem is the URL a worker is busy with
+type workerItem struct {
+	url      string
+	itemType string
+}
+
+func newWorkerItem(item *models.Item) *workerItem {
+	return &workerItem{
+		url:      item.GetURL().String(),
+		itemType: itemType(item),
+	}
+}
+
+// workerActivity is what a worker is doing right now. It is only made of atomics so that the workers
+// don't wait on the readers. The captures of the assets of a seed run concurrently, the activity is
+// the last state one of them went through.
+type workerActivity struct {
+	state     atomic.Int32
+	since     atomic.Int64 // UnixNano
+	item      atomic.Pointer[workerItem]
+	processed atomic.Uint64
+}
+
+func (a *workerActivity) set(state workerState, item *workerItem) {
+	if a == nil {
+		return
+	}
+
+	a.item.Store(item)
+	a.since.Store(time.Now().UnixNano())
+	a.state.Store(int32(state))
+}
+
+func (a *workerActivity) done() {
+	if a == nil {
+		return
+	}
+
+	a.processed.Add(1)
+}
+
+// WorkerActivity is the snapshot of the activity of a worker
+type WorkerActivity struct {
+	ID                 string    `json:"id"`
+	State              string    `json:"state"`
+	URL                string    `json:"url,omitempty"`
+	ItemType           string    `json:"item_type,omitempty"`
+	StateSince         time.Time `json:"state_since"`
+	TimeInStateSeconds float64   `json:"time_in_state_seconds"`
+	Processed          uint64    `json:"processed"`
+	Stuck              bool      `json:"stuck"`
+}
+
+func (a *workerActivity) snapshot(ID string, now time.Time, stuckThreshold time.Duration) WorkerActivity {
+	state := workerState(a.state.Load())
+	since := time.Unix(0, a.since.Load())
+
+	snapshot := WorkerActivity{
+		ID:                 ID,
+		State:              state.String(),
+		StateSince:         since,
+		TimeInStateSeconds: now.Sub(since).Seconds(),
+		Processed:          a.processed.Load(),
+		Stuck:              state != workerIdle && now.Sub(since) > stuckThreshold,
+	}
+
+	if item := a.item.Load(); item != nil {
+		snapshot.URL = item.url
+		snapshot.ItemType = item.itemType
+	}
+
+	return snapshot
+}
+
+// workersActivity holds the activity of the archiver workers, indexed by worker ID
+type workersActivity []*workerActivity
+
+func newWorkersActivity(count int) workersActivity {
+	activity := make(workersActivity, count)
+	now := time.Now().UnixNano()
+
+	for i := range activity {
+		activity[i] = &workerActivity{}
+		activity[i].since.Store(now)
+	}
+
+	return activity
+}
+
+// get returns the activity of the worker, nil if there is no such worker
+func (w workersActivity) get(workerID string) *workerActivity {
+	i, err := strconv.Atoi(workerID)
+	if err != nil || i < 0 || i >= len(w) || strconv.Itoa(i) != workerID {
+		return nil
+	}
+
+	return w[i]
+}
+
+func (w workersActivity) snapshot(now time.Time, stuckThreshold time.Duration) []WorkerActivity {
+	snapshots := make([]WorkerActivity, len(w))
+	for i := range w {
+		snapshots[i] = w[i].snapshot(strconv.Itoa(i), now, stuckThreshold)
+	}
+
+	return snapshots
+}
+
+// stuckWorkersMonitor updates the number of stuck workers in the stats every second
+func (a *archiver) stuckWorkersMonitor() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			var stuck int64
+			for _, activity := range globalWorkers.snapshot(now, config.Get().WorkerStuckThreshold) {
+				if activity.Stuck {
+					stuck++
+				}
+			}
+
+			stats.StuckWorkersSet(stuck)
+		}
+	}
+}
+
+// GetWorkersActivity returns what each archiver worker is doing right now
+func GetWorkersActivity() []WorkerActivity {
+	return globalWorkers.snapshot(time.Now(), config.Get().WorkerStuckThreshold)
+}
+
+// GetWorkerActivity returns what the archiver worker is doing right now, false if there is no such worker
+func GetWorkerActivity(workerID string) (WorkerActivity, bool) {
+	activity := globalWorkers.get(workerID)
+	if activity == nil {
+		return WorkerActivity{}, false
+	}
+
+	return activity.snapshot(workerID, time.Now(), config.Get().WorkerStuckThreshold), true
+}
@@ -0,0 +1,64 @@
+package archiver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkerActivitySnapshot(t *testing.T) {
+	workers := newWorkersActivity(2)
+	item := newLimitTestItem(t, "http://example.com/page")
+
+	activity := workers.get("1")
+	activity.set(workerExtracting, newWorkerItem(item))
+	activity.done()
+
+	snapshots := workers.snapshot(time.Now(), time.Minute)
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 workers, got %d", len(snapshots))
+	}
+
+	if snapshots[0].State != "idle" || snapshots[0].URL != "" {
+		t.Errorf("expected worker 0 to be idle, got %+v", snapshots[0])
+	}
+
+	got := snapshots[1]
+	if got.ID != "1" || got.State != "extracting" || got.URL != "http://example.com/page" || got.ItemType != "seed" || got.Processed != 1 {
+		t.Errorf("unexpected snapshot of worker 1: %+v", got)
+	}
+
+	if got.Stuck {
+		t.Error("expected worker 1 not to be stuck yet")
+	}
+}
+
+func TestWorkerActivityStuck(t *testing.T) {
+	workers := newWorkersActivity(2)
+	item := newLimitTestItem(t, "http://example.com/slow")
+
+	workers.get("0").set(workerFetching, newWorkerItem(item))
+
+	// Idle workers are never stuck, however long they wait
+	snapshots := workers.snapshot(time.Now().Add(time.Hour), time.Minute)
+	if !snapshots[0].Stuck {
+		t.Error("expected the fetching worker to be stuck")
+	}
+
+	if snapshots[1].Stuck {
+		t.Error("expected the idle worker not to be stuck")
+	}
+}
+
+func TestWorkersActivityGet(t *testing.T) {
+	workers := newWorkersActivity(2)
+
+	for _, ID := range []string{"2", "-1", "01", "a", ""} {
+		if workers.get(ID) != nil {
+			t.Errorf("expected no worker %q", ID)
+		}
+	}
+
+	// The activity of unknown workers is ignored
+	workers.get("2").set(workerFetching, nil)
+	workers.get("2").done()
+}
@@ -2,14 +2,23 @@ package config
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +28,7 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpguts"
 )
 
 // Config holds all configuration for our program, parsed from various sources
@@ -32,85 +42,341 @@ type Config struct {
 	DisableSeencheck bool `mapstructure:"disable-seencheck"`
 	UseSeencheck     bool
 
-	UserAgent              string   `mapstructure:"user-agent"`
-	Cookies                string   `mapstructure:"cookies"`
-	WARCPrefix             string   `mapstructure:"warc-prefix"`
-	WARCOperator           string   `mapstructure:"warc-operator"`
-	WARCTempDir            string   `mapstructure:"warc-temp-dir"`
-	WARCSize               int      `mapstructure:"warc-size"`
-	WARCOnDisk             bool     `mapstructure:"warc-on-disk"`
-	WARCPoolSize           int      `mapstructure:"warc-pool-size"`
-	WARCQueueSize          int      `mapstructure:"warc-queue-size"`
-	WARCDedupeSize         int      `mapstructure:"warc-dedupe-size"`
-	WARCWriteAsync         bool     `mapstructure:"async-warc-write"`
-	WARCDiscardStatus      []int    `mapstructure:"warc-discard-status"`
-	CDXDedupeServer        string   `mapstructure:"warc-cdx-dedupe-server"`
-	CDXCookie              string   `mapstructure:"warc-cdx-cookie"`
-	HQAddress              string   `mapstructure:"hq-address"`
-	HQKey                  string   `mapstructure:"hq-key"`
-	HQSecret               string   `mapstructure:"hq-secret"`
-	HQProject              string   `mapstructure:"hq-project"`
-	HQBatchSize            int      `mapstructure:"hq-batch-size"`
-	HQBatchConcurrency     int      `mapstructure:"hq-batch-concurrency"`
-	DisableHTMLTag         []string `mapstructure:"disable-html-tag"`
-	ExcludeHosts           []string `mapstructure:"exclude-host"`
-	IncludeHosts           []string `mapstructure:"include-host"`
-	IncludeString          []string `mapstructure:"include-string"`
-	ExcludeString          []string `mapstructure:"exclude-string"`
-	ExclusionFile          []string `mapstructure:"exclusion-file"`
-	WorkersCount           int      `mapstructure:"workers"`
-	MaxConcurrentAssets    int      `mapstructure:"max-concurrent-assets"`
-	MaxHops                int      `mapstructure:"max-hops"`
-	MaxRedirect            int      `mapstructure:"max-redirect"`
-	MaxRetry               int      `mapstructure:"max-retry"`
-	HTTPTimeout            int      `mapstructure:"http-timeout"`
-	HTTPReadDeadline       int      `mapstructure:"http-read-deadline"`
-	CrawlTimeLimit         int      `mapstructure:"crawl-time-limit"`
-	CrawlMaxTimeLimit      int      `mapstructure:"crawl-max-time-limit"`
-	MinSpaceRequired       float64  `mapstructure:"min-space-required"`
-	DomainsCrawl           []string `mapstructure:"domains-crawl"`
-	CaptureAlternatePages  bool     `mapstructure:"capture-alternate-pages"`
-	DisableLocalDedupe     bool     `mapstructure:"disable-local-dedupe"`
-	CertValidation         bool     `mapstructure:"cert-validation"`
-	DisableAssetsCapture   bool     `mapstructure:"disable-assets-capture"`
-	UseHQ                  bool     // Special field to check if HQ is enabled depending on the command called
-	HQRateLimitingSendBack bool     `mapstructure:"hq-rate-limiting-send-back"`
+	// Bloom filter seencheck, trades a small false positive rate for a much lower memory usage
+	SeencheckBloom       bool    `mapstructure:"seencheck-bloom"`
+	SeencheckBloomItems  uint    `mapstructure:"seencheck-bloom-items"`
+	SeencheckBloomFPRate float64 `mapstructure:"seencheck-bloom-fp-rate"`
+
+	// Number of recently queued outlinks remembered to drop their duplicates before the seencheck
+	QueueDedupeWindow int `mapstructure:"queue-dedupe-window"`
+
+	// Memory in MB over which the outlinks are spilled on disk, -1 for 90% of GOMEMLIMIT
+	OutlinksSpillMemory int `mapstructure:"outlinks-spill-memory"`
+
+	UserAgent              string        `mapstructure:"user-agent"`
+	Cookies                string        `mapstructure:"cookies"`
+	WARCPrefix             string        `mapstructure:"warc-prefix"`
+	WARCOperator           string        `mapstructure:"warc-operator"`
+	WARCTempDir            string        `mapstructure:"warc-temp-dir"`
+	WARCSize               int           `mapstructure:"warc-size"`
+	WARCCompression        string        `mapstructure:"warc-compression"`
+	WARCOnDisk             bool          `mapstructure:"warc-on-disk"`
+	WARCPoolSize           int           `mapstructure:"warc-pool-size"`
+	WARCQueueSize          int           `mapstructure:"warc-queue-size"`
+	WARCDedupeSize         int           `mapstructure:"warc-dedupe-size"`
+	WARCWriteAsync         bool          `mapstructure:"async-warc-write"`
+	WARCDiscardStatus      []int         `mapstructure:"warc-discard-status"`
+	WARCOutlinksMetadata   bool          `mapstructure:"warc-outlinks-metadata"`
+	WARCOutlinksFormat     string        `mapstructure:"warc-outlinks-metadata-format"`
+	WARCConnectionMetadata bool          `mapstructure:"warc-connection-metadata"`
+	CDXDedupeServer        string        `mapstructure:"warc-cdx-dedupe-server"`
+	CDXCookie              string        `mapstructure:"warc-cdx-cookie"`
+	WARCDedupeIndex        string        `mapstructure:"warc-dedupe-index"`
+	HQAddress              string        `mapstructure:"hq-address"`
+	HQKey                  string        `mapstructure:"hq-key"`
+	HQSecret               string        `mapstructure:"hq-secret"`
+	HQProject              string        `mapstructure:"hq-project"`
+	HQBatchSize            int           `mapstructure:"hq-batch-size"`
+	HQBatchConcurrency     int           `mapstructure:"hq-batch-concurrency"`
+	HQSpoolMaxSize         int           `mapstructure:"hq-spool-max-size"`
+	DisableHTMLTag         []string      `mapstructure:"disable-html-tag"`
+	ExcludeHosts           []string      `mapstructure:"exclude-host"`
+	IncludeHosts           []string      `mapstructure:"include-host"`
+	IncludeString          []string      `mapstructure:"include-string"`
+	ExcludeString          []string      `mapstructure:"exclude-string"`
+	ExclusionFile          []string      `mapstructure:"exclusion-file"`
+	WorkersCount           int           `mapstructure:"workers"`
+	MaxConcurrentAssets    int           `mapstructure:"max-concurrent-assets"`
+	MaxHops                int           `mapstructure:"max-hops"`
+	MaxRedirect            int           `mapstructure:"max-redirect"`
+	MaxRetry               int           `mapstructure:"max-retry"`
+	HTTPTimeout            int           `mapstructure:"http-timeout"`
+	HTTPReadDeadline       int           `mapstructure:"http-read-deadline"`
+	ConnectTimeout         time.Duration `mapstructure:"connect-timeout"`
+	ResponseHeaderTimeout  time.Duration `mapstructure:"response-header-timeout"`
+	ReadIdleTimeout        time.Duration `mapstructure:"read-idle-timeout"`
+	CrawlTimeLimit         int           `mapstructure:"crawl-time-limit"`
+	CrawlMaxTimeLimit      int           `mapstructure:"crawl-max-time-limit"`
+	MaxCrawledItems        int           `mapstructure:"max-crawled-items"`
+	MaxCrawledItemsScope   string        `mapstructure:"max-crawled-items-scope"`
+	MaxTotalWARCSize       int           `mapstructure:"max-total-warc-size"`
+	MinSpaceRequired       float64       `mapstructure:"min-space-required"`
+	MinSpaceResumeMargin   float64       `mapstructure:"min-space-resume-margin"`
+	DiskCheckInterval      time.Duration `mapstructure:"disk-check-interval"`
+	DomainsCrawl           []string      `mapstructure:"domains-crawl"`
+	CaptureAlternatePages  bool          `mapstructure:"capture-alternate-pages"`
+	DisableLocalDedupe     bool          `mapstructure:"disable-local-dedupe"`
+	CertValidation         bool          `mapstructure:"cert-validation"`
+	DisableAssetsCapture   bool          `mapstructure:"disable-assets-capture"`
+	ExcludeAssetExtensions []string      `mapstructure:"exclude-asset-extensions"`
+	UseHQ                  bool          // Special field to check if HQ is enabled depending on the command called
+	UseKafka               bool          // Special field to check if Kafka is enabled depending on the command called
+	UseRedis               bool          // Special field to check if Redis is enabled depending on the command called
+	HQRateLimitingSendBack bool          `mapstructure:"hq-rate-limiting-send-back"`
+
+	// WARC rotation, on top of the --warc-size one
+	WARCMaxAge time.Duration `mapstructure:"warc-max-age"`
+
+	// Assets concurrency, on top of the --max-concurrent-assets one of each page
+	MaxConcurrentAssetsTotal   int `mapstructure:"max-concurrent-assets-total"`
+	MaxConcurrentAssetsPerHost int `mapstructure:"max-concurrent-assets-per-host"`
+
+	// Temporary files
+	CleanTempOnStart bool          `mapstructure:"clean-temp-on-start"`
+	CleanTempMinAge  time.Duration `mapstructure:"clean-temp-min-age"`
+
+	// Soft-404 detection
+	Soft404Detection bool `mapstructure:"soft-404-detection"`
+	Soft404MaxHosts  int  `mapstructure:"soft-404-max-hosts"`
+
+	// Favicons
+	CaptureFavicon bool `mapstructure:"capture-favicon"`
+
+	// Frames
+	InlineIframeSrcdoc bool `mapstructure:"inline-iframe-srcdoc"`
+
+	// Extract the URLs of the HTML pages from a goquery document instead of streaming them, deprecated
+	HTMLGoqueryExtraction bool `mapstructure:"html-goquery-extraction"`
+
+	// Crawl log
+	CrawlLogJSONL   string `mapstructure:"crawl-log-jsonl"`
+	CrawlLog        bool   `mapstructure:"crawl-log"`
+	CrawlLogMaxSize int    `mapstructure:"crawl-log-max-size"`
+	FailureReport   bool   `mapstructure:"failure-report"`
+
+	// Per-host concurrency
+	MaxConcurrentRequestsPerDomain int `mapstructure:"max-concurrent-requests-per-domain"`
+
+	// Per-host circuit breaker
+	HostBreakerThreshold int           `mapstructure:"host-breaker-threshold"`
+	HostBreakerWindow    time.Duration `mapstructure:"host-breaker-window"`
+	HostBreakerCooldown  time.Duration `mapstructure:"host-breaker-cooldown"`
+
+	// Frontier memory
+	FrontierHostMemory int `mapstructure:"frontier-host-memory"`
+	FrontierMaxMemory  int `mapstructure:"frontier-max-memory"`
+
+	// Frontier dequeue order
+	FrontierStrategy string `mapstructure:"frontier-strategy"`
+
+	// Kafka
+	KafkaBrokers          []string `mapstructure:"kafka-brokers"`
+	KafkaSeedsTopic       string   `mapstructure:"kafka-seeds-topic"`
+	KafkaConsumerGroup    string   `mapstructure:"kafka-consumer-group"`
+	KafkaOutlinksTopic    string   `mapstructure:"kafka-outlinks-topic"`
+	KafkaResultsTopic     string   `mapstructure:"kafka-results-topic"`
+	KafkaBatchSize        int      `mapstructure:"kafka-batch-size"`
+	KafkaProduceBatchSize int      `mapstructure:"kafka-produce-batch-size"`
+	KafkaMaxInFlight      int      `mapstructure:"kafka-max-in-flight"`
+	KafkaTLS              bool     `mapstructure:"kafka-tls"`
+	KafkaTLSCA            string   `mapstructure:"kafka-tls-ca"`
+	KafkaSASLMechanism    string   `mapstructure:"kafka-sasl-mechanism"`
+	KafkaSASLUsername     string   `mapstructure:"kafka-sasl-username"`
+	KafkaSASLPassword     string   `mapstructure:"kafka-sasl-password"`
+
+	// Redis
+	RedisAddress      string        `mapstructure:"redis-address"`
+	RedisMode         string        `mapstructure:"redis-mode"`
+	RedisKeyPrefix    string        `mapstructure:"redis-key-prefix"`
+	RedisBatchSize    int           `mapstructure:"redis-batch-size"`
+	RedisSeencheck    bool          `mapstructure:"redis-seencheck"`
+	RedisClaimTimeout time.Duration `mapstructure:"redis-claim-timeout"`
+	RedisInstanceID   string        `mapstructure:"redis-instance-id"`
+
+	// Time after which a worker in the same state is reported as stuck
+	WorkerStuckThreshold time.Duration `mapstructure:"worker-stuck-threshold"`
+
+	// Time spent reporting the finished and discovered items left to the source when stopping
+	SourceFlushTimeout time.Duration `mapstructure:"source-flush-timeout"`
+
+	// Time a graceful stop waits for the in-flight requests before canceling them, 0 waits for them
+	StopTimeout time.Duration `mapstructure:"stop-timeout"`
+
+	// Frontier dump to enqueue before the seeds
+	LoadFrontier string `mapstructure:"load-frontier"`
+
+	// Incremental crawls
+	Incremental  bool `mapstructure:"incremental"`
+	TrackChanges bool `mapstructure:"track-changes"`
+
+	// Proxy pool
+	ProxyPool        []string      `mapstructure:"proxy-pool"`
+	ProxyRotation    string        `mapstructure:"proxy-rotation"`
+	ProxyMaxFailures int           `mapstructure:"proxy-max-failures"`
+	ProxyCooldown    time.Duration `mapstructure:"proxy-cooldown"`
+	ProxyFile        string        `mapstructure:"proxy-file"`
+	ProxyFileReload  time.Duration `mapstructure:"proxy-file-reload"`
+	ProxyFileProxies []string      // Proxies read from --proxy-file when the crawl starts
+
+	// HLS
+	HLSMaxSegments int `mapstructure:"hls-max-segments"`
+	HLSMaxSize     int `mapstructure:"hls-max-size"`
+
+	// DASH
+	DASHMaxSegments int  `mapstructure:"dash-max-segments"`
+	DASHCaptureLive bool `mapstructure:"dash-capture-live"`
+
+	// JavaScript extraction
+	JSExtraction         bool `mapstructure:"js-extraction"`
+	JSExtractionSameHost bool `mapstructure:"js-extraction-same-host"`
+	JSExtractionMaxURLs  int  `mapstructure:"js-extraction-max-urls"`
+
+	// WebSocket endpoints
+	WebSocketDiscovery bool `mapstructure:"websocket-discovery"`
+	WebSocketHandshake bool `mapstructure:"websocket-handshake"`
+
+	// User-Agent rotation
+	UserAgentPool     []string `mapstructure:"user-agent-pool"`
+	UserAgentRotation string   `mapstructure:"user-agent-rotation"`
+
+	// Referer
+	RefererPolicy string `mapstructure:"referer-policy"`
+
+	// Content negotiation
+	Accept                 string   `mapstructure:"accept"`
+	AcceptLanguage         string   `mapstructure:"accept-language"`
+	AcceptLanguageVariants []string `mapstructure:"accept-language-variants"`
+
+	// Authentication
+	HostAuth  map[string]string `mapstructure:"host-auth"`
+	HostAuths map[string]string // Authorization header by host
+
+	// Custom headers
+	Header      []string               `mapstructure:"header"`
+	HostHeader  []string               `mapstructure:"host-header"`
+	Headers     http.Header            // Headers of --header
+	HostHeaders map[string]http.Header // Headers of --host-header by host
+
+	// Feeds
+	FeedMaxEntries int    `mapstructure:"feed-max-entries"`
+	SeedFromFeed   bool   `mapstructure:"seed-from-feed"`
+	SeedListFormat string `mapstructure:"seed-list-format"`
+
+	// Meta refresh
+	MetaRefreshMaxDelay int `mapstructure:"meta-refresh-max-delay"`
+
+	// Canonical links
+	Canonical string `mapstructure:"canonical"`
+
+	// Crawl windows
+	CrawlWindows        []string `mapstructure:"crawl-window"`
+	CrawlWindowTimezone string   `mapstructure:"crawl-window-timezone"`
 
 	// Network
-	Proxy         string `mapstructure:"proxy"`
-	RandomLocalIP bool   `mapstructure:"random-local-ip"`
-	DisableIPv4   bool   `mapstructure:"disable-ipv4"`
-	DisableIPv6   bool   `mapstructure:"disable-ipv6"`
-	IPv6AnyIP     bool   `mapstructure:"ipv6-anyip"`
+	Proxy               string        `mapstructure:"proxy"`
+	RandomLocalIP       bool          `mapstructure:"random-local-ip"`
+	LocalAddress        string        `mapstructure:"local-address"`
+	LocalCIDR           string        `mapstructure:"local-cidr"`
+	DisableIPv4         bool          `mapstructure:"disable-ipv4"`
+	DisableIPv6         bool          `mapstructure:"disable-ipv6"`
+	IPv6AnyIP           bool          `mapstructure:"ipv6-anyip"`
+	DNSCacheTTL         time.Duration `mapstructure:"dns-cache-ttl"`
+	DNSCacheStale       time.Duration `mapstructure:"dns-cache-stale"`
+	DNSNoCacheHosts     []string      `mapstructure:"dns-no-cache-hosts"`
+	DNSCacheSize        int           `mapstructure:"dns-cache-size"`
+	DNSServers          []string      `mapstructure:"dns-servers"`
+	DNSTimeout          time.Duration `mapstructure:"dns-timeout"`
+	DNSNegativeCacheTTL time.Duration `mapstructure:"dns-negative-cache-ttl"`
+	PreferIPv4          bool          `mapstructure:"prefer-ipv4"`
+	PreferIPv6          bool          `mapstructure:"prefer-ipv6"`
+	Resolve             []string      `mapstructure:"resolve"`
+	ResolvedAddresses   map[string]net.IP
+
+	// Bodies
+	MaxInMemoryResponseSize int      `mapstructure:"max-in-memory-response-size"`
+	MaxResponseBodySize     int64    `mapstructure:"max-response-body-size"`
+	ExtractContentTypes     []string `mapstructure:"extract-content-types"`
+	FetchContentTypeAllow   []string `mapstructure:"fetch-content-type-allow"`
+	FetchContentTypeDeny    []string `mapstructure:"fetch-content-type-deny"`
+	LazyLoadAttributes      []string `mapstructure:"lazy-load-attributes"`
+
+	// Responses recorded and extracted depending on their status
+	WARCRecordErrorResponses bool `mapstructure:"warc-record-error-responses"`
+	ExtractOnlyOn2xx         bool `mapstructure:"extract-only-on-2xx"`
+
+	// Links queued per page
+	MaxOutlinksPerPage int    `mapstructure:"max-outlinks-per-page"`
+	MaxAssetsPerPage   int    `mapstructure:"max-assets-per-page"`
+	MaxLinksSelection  string `mapstructure:"max-links-selection"`
+
+	// Headless
+	Headless            bool          `mapstructure:"headless"`
+	HeadlessTabs        int           `mapstructure:"headless-tabs"`
+	HeadlessPageTimeout time.Duration `mapstructure:"headless-page-timeout"`
+	HeadlessBrowserPath string        `mapstructure:"headless-browser-path"`
+
+	HeadlessHosts              []string `mapstructure:"headless-hosts"`
+	HeadlessFallback           bool     `mapstructure:"headless-fallback"`
+	HeadlessFallbackMaxText    int      `mapstructure:"headless-fallback-max-text"`
+	HeadlessFallbackMinScripts int      `mapstructure:"headless-fallback-min-scripts"`
+
+	HeadlessBehaviors        bool          `mapstructure:"headless-behaviors"`
+	HeadlessBehaviorsTimeout time.Duration `mapstructure:"headless-behaviors-timeout"`
+	HeadlessScrollDelay      time.Duration `mapstructure:"headless-scroll-delay"`
+	HeadlessClickSelectors   []string      `mapstructure:"headless-click-selector"`
+
+	Screenshot          bool   `mapstructure:"screenshot"`
+	ScreenshotFormat    string `mapstructure:"screenshot-format"`
+	ScreenshotQuality   int    `mapstructure:"screenshot-quality"`
+	ScreenshotWidth     int    `mapstructure:"screenshot-width"`
+	ScreenshotMaxHeight int    `mapstructure:"screenshot-max-height"`
 
 	// Rate limiting
-	DisableRateLimit          bool          `mapstructure:"disable-rate-limit"`
-	RateLimitCapacity         float64       `mapstructure:"rate-limit-capacity"`
-	RateLimitRefillRate       float64       `mapstructure:"rate-limit-refill-rate"`
-	RateLimitCleanupFrequency time.Duration `mapstructure:"rate-limit-cleanup-frequency"`
+	DisableRateLimit          bool              `mapstructure:"disable-rate-limit"`
+	RateLimitCapacity         float64           `mapstructure:"rate-limit-capacity"`
+	RateLimitRefillRate       float64           `mapstructure:"rate-limit-refill-rate"`
+	RateLimitCleanupFrequency time.Duration     `mapstructure:"rate-limit-cleanup-frequency"`
+	GlobalMaxQPS              float64           `mapstructure:"global-max-qps"`
+	HostDelay                 map[string]string `mapstructure:"host-delay"`
+	HostDelays                map[string]time.Duration
 
 	// Logging
 	NoStdoutLogging  bool   `mapstructure:"no-stdout-log"`
 	NoStderrLogging  bool   `mapstructure:"no-stderr-log"`
 	NoFileLogging    bool   `mapstructure:"no-log-file"`
 	StdoutLogLevel   string `mapstructure:"log-level"`
+	LogFormat        string `mapstructure:"log-format"`
 	TUI              bool   `mapstructure:"tui"`
 	TUILogLevel      string `mapstructure:"tui-log-level"`
 	LogFileLevel     string `mapstructure:"log-file-level"`
+	LogFileFormat    string `mapstructure:"log-file-format"`
 	LogFileOutputDir string `mapstructure:"log-file-output-dir"`
 	LogFilePrefix    string `mapstructure:"log-file-prefix"`
 	LogFileRotation  string `mapstructure:"log-file-rotation"`
+	LogMaxSize       int    `mapstructure:"log-max-size"`
+	LogMaxFiles      int    `mapstructure:"log-max-files"`
+	LogSampleInfo    int    `mapstructure:"log-sample-info"`
+	LogSampleWarn    int    `mapstructure:"log-sample-warn"`
+	LogSampleError   int    `mapstructure:"log-sample-error"`
+
+	// Elasticsearch logging
+	LogElasticsearchURLs          []string      `mapstructure:"log-es-urls"`
+	LogElasticsearchUsername      string        `mapstructure:"log-es-user"`
+	LogElasticsearchPassword      string        `mapstructure:"log-es-password"`
+	LogElasticsearchIndexPrefix   string        `mapstructure:"log-es-index-prefix"`
+	LogElasticsearchLevel         string        `mapstructure:"log-es-level"`
+	LogElasticsearchBulkSize      int           `mapstructure:"log-es-bulk-size"`
+	LogElasticsearchFlushInterval time.Duration `mapstructure:"log-es-flush-interval"`
+	LogElasticsearchBufferSize    int           `mapstructure:"log-es-buffer-size"`
 
 	// Profiling
 	PyroscopeAddress string `mapstructure:"pyroscope-address"`
 
 	// API
-	APIPort int  `mapstructure:"api-port"`
-	API     bool `mapstructure:"api"`
+	APIPort         int    `mapstructure:"api-port"`
+	APIHost         string `mapstructure:"api-host"`
+	API             bool   `mapstructure:"api"`
+	APIToken        string `mapstructure:"api-token"`
+	APIMetricsToken string `mapstructure:"api-metrics-token"`
+
+	// Health and readiness probes
+	HealthPort             int           `mapstructure:"health-port"`
+	HealthWARCStallTimeout time.Duration `mapstructure:"health-warc-stall-timeout"`
 
 	// Prometheus and metrics
 	Prometheus       bool   `mapstructure:"prometheus"`
 	PrometheusPrefix string `mapstructure:"prometheus-prefix"`
+	PrometheusHosts  int    `mapstructure:"prometheus-hosts"`
 
 	// Consul
 	ConsulAddress      string   `mapstructure:"consul-address"`
@@ -119,7 +385,12 @@ type Config struct {
 	ConsulRegister     bool     `mapstructure:"consul-register"`
 	ConsulRegisterTags []string `mapstructure:"consul-register-tags"`
 
+	// Webhook
+	WebhookURL  string `mapstructure:"webhook-url"`
+	WebhookAuth string `mapstructure:"webhook-auth"`
+
 	InputSeeds       []string         // Special field to store the input URLs
+	InputSeedLists   []string         // Special field to store the input seed lists, files or URLs
 	ExclusionRegexes []*regexp.Regexp // Special field to store the compiled exclusion regex (from --exclusion-file)
 }
 
@@ -203,6 +474,154 @@ func Get() *Config {
 	return config
 }
 
+// Hash returns the SHA-256 of the settings deciding what the crawler captures and how, it tells apart
+// the crawlers of a fleet that don't run with the same configuration. The settings of each instance
+// (job, paths, identifiers, sources and outputs) are left out, like the secrets: only the names of the
+// custom headers and the hosts of the credentials are hashed. The regular expressions are hashed by their source.
+func Hash() (string, error) {
+	payload, err := json.Marshal(crawlSettings())
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// crawlSettings returns the settings hashed by Hash, by flag name
+func crawlSettings() map[string]any {
+	exclusions := make([]string, 0, len(config.ExclusionRegexes))
+	for _, exclusion := range config.ExclusionRegexes {
+		exclusions = append(exclusions, exclusion.String())
+	}
+
+	hostHeaders := make(map[string][]string, len(config.HostHeaders))
+	for host, headers := range config.HostHeaders {
+		hostHeaders[host] = headerNames(headers)
+	}
+
+	return map[string]any{
+		// Scope
+		"disable-seencheck":       config.DisableSeencheck,
+		"exclude-host":            config.ExcludeHosts,
+		"include-host":            config.IncludeHosts,
+		"include-string":          config.IncludeString,
+		"exclude-string":          config.ExcludeString,
+		"exclusion-file":          exclusions,
+		"domains-crawl":           config.DomainsCrawl,
+		"max-hops":                config.MaxHops,
+		"max-redirect":            config.MaxRedirect,
+		"max-retry":               config.MaxRetry,
+		"max-crawled-items":       config.MaxCrawledItems,
+		"max-crawled-items-scope": config.MaxCrawledItemsScope,
+		"incremental":             config.Incremental,
+		"track-changes":           config.TrackChanges,
+		"frontier-strategy":       config.FrontierStrategy,
+		"crawl-window":            config.CrawlWindows,
+		"crawl-window-timezone":   config.CrawlWindowTimezone,
+
+		// Extraction
+		"disable-assets-capture":   config.DisableAssetsCapture,
+		"exclude-asset-extensions": config.ExcludeAssetExtensions,
+		"disable-html-tag":         config.DisableHTMLTag,
+		"capture-alternate-pages":  config.CaptureAlternatePages,
+		"capture-favicon":          config.CaptureFavicon,
+		"inline-iframe-srcdoc":     config.InlineIframeSrcdoc,
+		"html-goquery-extraction":  config.HTMLGoqueryExtraction,
+		"soft-404-detection":       config.Soft404Detection,
+		"js-extraction":            config.JSExtraction,
+		"js-extraction-same-host":  config.JSExtractionSameHost,
+		"js-extraction-max-urls":   config.JSExtractionMaxURLs,
+		"websocket-discovery":      config.WebSocketDiscovery,
+		"websocket-handshake":      config.WebSocketHandshake,
+		"hls-max-segments":         config.HLSMaxSegments,
+		"hls-max-size":             config.HLSMaxSize,
+		"dash-max-segments":        config.DASHMaxSegments,
+		"dash-capture-live":        config.DASHCaptureLive,
+		"feed-max-entries":         config.FeedMaxEntries,
+		"seed-from-feed":           config.SeedFromFeed,
+		"meta-refresh-max-delay":   config.MetaRefreshMaxDelay,
+		"canonical":                config.Canonical,
+		"extract-content-types":    config.ExtractContentTypes,
+		"fetch-content-type-allow": config.FetchContentTypeAllow,
+		"fetch-content-type-deny":  config.FetchContentTypeDeny,
+		"lazy-load-attributes":     config.LazyLoadAttributes,
+		"extract-only-on-2xx":      config.ExtractOnlyOn2xx,
+		"max-outlinks-per-page":    config.MaxOutlinksPerPage,
+		"max-assets-per-page":      config.MaxAssetsPerPage,
+		"max-links-selection":      config.MaxLinksSelection,
+
+		// Headless
+		"headless":                      config.Headless,
+		"headless-hosts":                config.HeadlessHosts,
+		"headless-fallback":             config.HeadlessFallback,
+		"headless-fallback-max-text":    config.HeadlessFallbackMaxText,
+		"headless-fallback-min-scripts": config.HeadlessFallbackMinScripts,
+		"headless-page-timeout":         config.HeadlessPageTimeout,
+		"headless-behaviors":            config.HeadlessBehaviors,
+		"headless-behaviors-timeout":    config.HeadlessBehaviorsTimeout,
+		"headless-scroll-delay":         config.HeadlessScrollDelay,
+		"headless-click-selector":       config.HeadlessClickSelectors,
+		"screenshot":                    config.Screenshot,
+		"screenshot-format":             config.ScreenshotFormat,
+		"screenshot-quality":            config.ScreenshotQuality,
+		"screenshot-width":              config.ScreenshotWidth,
+		"screenshot-max-height":         config.ScreenshotMaxHeight,
+
+		// Requests
+		"user-agent":               config.UserAgent,
+		"user-agent-pool":          config.UserAgentPool,
+		"user-agent-rotation":      config.UserAgentRotation,
+		"referer-policy":           config.RefererPolicy,
+		"accept":                   config.Accept,
+		"accept-language":          config.AcceptLanguage,
+		"accept-language-variants": config.AcceptLanguageVariants,
+		"header":                   headerNames(config.Headers),
+		"host-header":              hostHeaders,
+		"host-auth":                slices.Sorted(maps.Keys(config.HostAuths)),
+		"cert-validation":          config.CertValidation,
+		"http-timeout":             config.HTTPTimeout,
+		"connect-timeout":          config.ConnectTimeout,
+		"response-header-timeout":  config.ResponseHeaderTimeout,
+		"read-idle-timeout":        config.ReadIdleTimeout,
+		"max-response-body-size":   config.MaxResponseBodySize,
+		"disable-ipv4":             config.DisableIPv4,
+		"disable-ipv6":             config.DisableIPv6,
+		"prefer-ipv4":              config.PreferIPv4,
+		"prefer-ipv6":              config.PreferIPv6,
+		"resolve":                  config.Resolve,
+
+		// Politeness
+		"disable-rate-limit":                 config.DisableRateLimit,
+		"rate-limit-capacity":                config.RateLimitCapacity,
+		"rate-limit-refill-rate":             config.RateLimitRefillRate,
+		"global-max-qps":                     config.GlobalMaxQPS,
+		"host-delay":                         config.HostDelay,
+		"max-concurrent-assets":              config.MaxConcurrentAssets,
+		"max-concurrent-requests-per-domain": config.MaxConcurrentRequestsPerDomain,
+		"host-breaker-threshold":             config.HostBreakerThreshold,
+		"host-breaker-window":                config.HostBreakerWindow,
+		"host-breaker-cooldown":              config.HostBreakerCooldown,
+
+		// WARC records
+		"warc-size":                     config.WARCSize,
+		"warc-max-age":                  config.WARCMaxAge,
+		"warc-compression":              config.WARCCompression,
+		"disable-local-dedupe":          config.DisableLocalDedupe,
+		"warc-discard-status":           config.WARCDiscardStatus,
+		"warc-record-error-responses":   config.WARCRecordErrorResponses,
+		"warc-outlinks-metadata":        config.WARCOutlinksMetadata,
+		"warc-outlinks-metadata-format": config.WARCOutlinksFormat,
+		"warc-connection-metadata":      config.WARCConnectionMetadata,
+	}
+}
+
+// headerNames returns the sorted names of the headers, without their values
+func headerNames(headers http.Header) []string {
+	return slices.Sorted(maps.Keys(headers))
+}
+
 func GenerateCrawlConfig() error {
 	// If the job name isn't specified, we generate a random name
 	if config.Job == "" {
@@ -230,6 +649,11 @@ func GenerateCrawlConfig() error {
 	// We exclude some hosts by default
 	config.ExcludeHosts = utils.DedupeStrings(append(config.ExcludeHosts, "archive.org", "archive-it.org"))
 
+	// The extensions are matched lowercased and without their dot
+	for i, extension := range config.ExcludeAssetExtensions {
+		config.ExcludeAssetExtensions[i] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(extension)), ".")
+	}
+
 	if config.WARCTempDir == "" {
 		config.WARCTempDir = path.Join(config.JobPath, "temp")
 	}
@@ -246,10 +670,379 @@ func GenerateCrawlConfig() error {
 		slog.Info("User-Agent set to", "user-agent", config.UserAgent)
 	}
 
+	if len(config.UserAgentPool) > 0 && config.UserAgentRotation != "per-host" && config.UserAgentRotation != "per-request" {
+		return fmt.Errorf("invalid --user-agent-rotation %q, must be per-host or per-request", config.UserAgentRotation)
+	}
+
+	if config.MaxOutlinksPerPage < 0 {
+		return fmt.Errorf("invalid --max-outlinks-per-page %d, must be positive or 0 for no limit", config.MaxOutlinksPerPage)
+	}
+
+	if config.MaxAssetsPerPage < 0 {
+		return fmt.Errorf("invalid --max-assets-per-page %d, must be positive or 0 for no limit", config.MaxAssetsPerPage)
+	}
+
+	if config.MaxLinksSelection != "first" && config.MaxLinksSelection != "sample" {
+		return fmt.Errorf("invalid --max-links-selection %q, must be first or sample", config.MaxLinksSelection)
+	}
+
+	for _, variant := range config.AcceptLanguageVariants {
+		if strings.TrimSpace(variant) == "" {
+			return fmt.Errorf("invalid --accept-language-variants, the values can't be empty")
+		}
+	}
+
+	switch config.RefererPolicy {
+	case "no-referrer", "unsafe-url", "origin", "same-origin", "strict-origin", "no-referrer-when-downgrade":
+	default:
+		return fmt.Errorf("invalid --referer-policy %q, must be no-referrer, unsafe-url, origin, same-origin, strict-origin or no-referrer-when-downgrade", config.RefererPolicy)
+	}
+
 	if config.RandomLocalIP {
 		slog.Warn("Random local IP is enabled")
 	}
 
+	if config.WARCOutlinksMetadata && config.WARCOutlinksFormat != "heritrix" && config.WARCOutlinksFormat != "json" {
+		return fmt.Errorf("invalid --warc-outlinks-metadata-format %q, must be heritrix or json", config.WARCOutlinksFormat)
+	}
+
+	if config.PrometheusHosts < 0 {
+		return fmt.Errorf("invalid --prometheus-hosts %d, must be positive or 0 to disable the per-host metrics", config.PrometheusHosts)
+	}
+
+	if config.QueueDedupeWindow < 0 {
+		return fmt.Errorf("invalid --queue-dedupe-window %d, must be positive or 0 to disable it", config.QueueDedupeWindow)
+	}
+
+	if config.OutlinksSpillMemory < -1 {
+		return fmt.Errorf("invalid --outlinks-spill-memory %d, must be positive, 0 to disable it or -1 to follow GOMEMLIMIT", config.OutlinksSpillMemory)
+	}
+
+	if config.MaxCrawledItems < 0 {
+		return fmt.Errorf("invalid --max-crawled-items %d, must be positive or 0 to disable it", config.MaxCrawledItems)
+	}
+
+	if config.WARCCompression != "gzip" && config.WARCCompression != "none" {
+		return fmt.Errorf("invalid --warc-compression %q, must be gzip or none", config.WARCCompression)
+	}
+
+	if config.MaxTotalWARCSize < 0 {
+		return fmt.Errorf("invalid --max-total-warc-size %d, must be positive or 0 to disable it", config.MaxTotalWARCSize)
+	}
+
+	if len(config.InputSeedLists) > 0 {
+		switch config.SeedListFormat {
+		case "auto", "txt", "jsonl", "csv":
+		default:
+			return fmt.Errorf("invalid --seed-list-format %q, must be auto, txt, jsonl or csv", config.SeedListFormat)
+		}
+	}
+
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		return fmt.Errorf("invalid --log-format %q, must be text or json", config.LogFormat)
+	}
+
+	if config.LogFileFormat != "text" && config.LogFileFormat != "json" {
+		return fmt.Errorf("invalid --log-file-format %q, must be text or json", config.LogFileFormat)
+	}
+
+	if config.LogMaxSize < 0 {
+		return fmt.Errorf("invalid --log-max-size %d, must be positive or 0 to disable it", config.LogMaxSize)
+	}
+
+	if config.LogMaxFiles < 0 {
+		return fmt.Errorf("invalid --log-max-files %d, must be positive or 0 to keep them all", config.LogMaxFiles)
+	}
+
+	for flag, limit := range map[string]int{"log-sample-info": config.LogSampleInfo, "log-sample-warn": config.LogSampleWarn, "log-sample-error": config.LogSampleError} {
+		if limit < 0 {
+			return fmt.Errorf("invalid --%s %d, must be positive or 0 to disable it", flag, limit)
+		}
+	}
+
+	if len(config.LogElasticsearchURLs) > 0 {
+		if config.LogElasticsearchBulkSize <= 0 {
+			return fmt.Errorf("invalid --log-es-bulk-size %d, must be positive", config.LogElasticsearchBulkSize)
+		}
+
+		if config.LogElasticsearchBufferSize < config.LogElasticsearchBulkSize {
+			return fmt.Errorf("invalid --log-es-buffer-size %d, must be at least --log-es-bulk-size", config.LogElasticsearchBufferSize)
+		}
+
+		if config.LogElasticsearchFlushInterval <= 0 {
+			return fmt.Errorf("invalid --log-es-flush-interval %s, must be positive", config.LogElasticsearchFlushInterval)
+		}
+	}
+
+	if config.Canonical != "off" && config.Canonical != "log" && config.Canonical != "follow" {
+		return fmt.Errorf("invalid --canonical %q, must be off, log or follow", config.Canonical)
+	}
+
+	if config.CrawlLogMaxSize < 0 {
+		return fmt.Errorf("invalid --crawl-log-max-size %d, must be positive or 0", config.CrawlLogMaxSize)
+	}
+
+	if config.MaxCrawledItemsScope != "all" && config.MaxCrawledItemsScope != "seeds" && config.MaxCrawledItemsScope != "assets" {
+		return fmt.Errorf("invalid --max-crawled-items-scope %q, must be all, seeds or assets", config.MaxCrawledItemsScope)
+	}
+
+	if config.HostBreakerThreshold < 0 {
+		return fmt.Errorf("invalid --host-breaker-threshold %d, must be positive or 0 to disable it", config.HostBreakerThreshold)
+	}
+
+	if config.HostBreakerThreshold > 0 && config.HostBreakerWindow <= 0 {
+		return fmt.Errorf("invalid --host-breaker-window %s, must be positive", config.HostBreakerWindow)
+	}
+
+	if config.HostBreakerThreshold > 0 && config.HostBreakerCooldown <= 0 {
+		return fmt.Errorf("invalid --host-breaker-cooldown %s, must be positive", config.HostBreakerCooldown)
+	}
+
+	if config.ConnectTimeout <= 0 {
+		return fmt.Errorf("invalid --connect-timeout %s, must be positive", config.ConnectTimeout)
+	}
+
+	if config.ResponseHeaderTimeout < 0 {
+		return fmt.Errorf("invalid --response-header-timeout %s, must be positive or 0 to disable it", config.ResponseHeaderTimeout)
+	}
+
+	if config.ReadIdleTimeout < 0 {
+		return fmt.Errorf("invalid --read-idle-timeout %s, must be positive or 0 to disable it", config.ReadIdleTimeout)
+	}
+
+	if config.HealthPort < 0 || config.HealthPort > 65535 {
+		return fmt.Errorf("invalid --health-port %d, must be between 0 and 65535", config.HealthPort)
+	}
+
+	if config.HealthWARCStallTimeout <= 0 {
+		return fmt.Errorf("invalid --health-warc-stall-timeout %s, must be positive", config.HealthWARCStallTimeout)
+	}
+
+	if config.WorkerStuckThreshold <= 0 {
+		return fmt.Errorf("invalid --worker-stuck-threshold %s, must be positive", config.WorkerStuckThreshold)
+	}
+
+	if config.SourceFlushTimeout <= 0 {
+		return fmt.Errorf("invalid --source-flush-timeout %s, must be positive", config.SourceFlushTimeout)
+	}
+
+	if config.MinSpaceResumeMargin < 0 {
+		return fmt.Errorf("invalid --min-space-resume-margin %v, must be positive or 0", config.MinSpaceResumeMargin)
+	}
+
+	if config.DiskCheckInterval <= 0 {
+		return fmt.Errorf("invalid --disk-check-interval %s, must be positive", config.DiskCheckInterval)
+	}
+
+	if config.StopTimeout < 0 {
+		return fmt.Errorf("invalid --stop-timeout %s, must be positive or 0 to wait for the in-flight requests", config.StopTimeout)
+	}
+
+	if config.WARCMaxAge < 0 {
+		return fmt.Errorf("invalid --warc-max-age %s, must be positive or 0 to disable it", config.WARCMaxAge)
+	}
+
+	if config.Proxy != "" {
+		if err := checkProxy(config.Proxy); err != nil {
+			return fmt.Errorf("invalid --proxy, %s", err)
+		}
+	}
+
+	if config.ProxyFile != "" {
+		if config.Proxy != "" {
+			return fmt.Errorf("invalid --proxy-file, can't be used with --proxy")
+		}
+
+		if config.ProxyFileReload < 0 {
+			return fmt.Errorf("invalid --proxy-file-reload %s, must be positive or 0 to reload on SIGHUP only", config.ProxyFileReload)
+		}
+
+		proxies, err := ReadProxyFile(config.ProxyFile)
+		if err != nil {
+			return fmt.Errorf("invalid --proxy-file, %w", err)
+		}
+
+		if len(proxies) == 0 {
+			return fmt.Errorf("invalid --proxy-file, no proxy found in %s", config.ProxyFile)
+		}
+
+		config.ProxyFileProxies = proxies
+	}
+
+	for _, proxy := range config.ProxyPool {
+		if err := checkProxy(proxy); err != nil {
+			return fmt.Errorf("invalid --proxy-pool entry, %s", err)
+		}
+	}
+
+	if len(config.ProxyPool) > 0 || len(config.ProxyFileProxies) > 0 {
+		if config.Proxy != "" {
+			return fmt.Errorf("invalid --proxy-pool, can't be used with --proxy")
+		}
+
+		if config.ProxyRotation != "per-request" && config.ProxyRotation != "per-host" {
+			return fmt.Errorf("invalid --proxy-rotation %q, must be per-request or per-host", config.ProxyRotation)
+		}
+
+		if config.ProxyMaxFailures < 1 {
+			return fmt.Errorf("invalid --proxy-max-failures %d, must be at least 1", config.ProxyMaxFailures)
+		}
+	}
+
+	if config.DNSCacheTTL < 0 {
+		return fmt.Errorf("invalid --dns-cache-ttl %s, must be positive or 0 to disable it", config.DNSCacheTTL)
+	}
+
+	if config.DNSCacheStale < 0 {
+		return fmt.Errorf("invalid --dns-cache-stale %s, must be positive or 0 to disable it", config.DNSCacheStale)
+	}
+
+	if config.DNSCacheSize < 1 {
+		return fmt.Errorf("invalid --dns-cache-size %d, must be at least 1", config.DNSCacheSize)
+	}
+
+	for _, server := range config.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("invalid --dns-servers %q, must be an IP address", server)
+		}
+	}
+
+	if config.DNSTimeout <= 0 {
+		return fmt.Errorf("invalid --dns-timeout %s, must be positive", config.DNSTimeout)
+	}
+
+	if config.DNSNegativeCacheTTL < 0 {
+		return fmt.Errorf("invalid --dns-negative-cache-ttl %s, must be positive or 0 to disable it", config.DNSNegativeCacheTTL)
+	}
+
+	if config.WebSocketHandshake && !config.WebSocketDiscovery {
+		return fmt.Errorf("invalid --websocket-handshake, requires --websocket-discovery")
+	}
+
+	if config.PreferIPv4 && config.PreferIPv6 {
+		return fmt.Errorf("invalid --prefer-ipv4, can't be used with --prefer-ipv6")
+	}
+
+	if (config.PreferIPv4 && config.DisableIPv4) || (config.PreferIPv6 && config.DisableIPv6) {
+		return fmt.Errorf("invalid --prefer-ipv4 or --prefer-ipv6, the preferred IP version is disabled")
+	}
+
+	config.ResolvedAddresses = make(map[string]net.IP, len(config.Resolve))
+	for _, value := range config.Resolve {
+		hostPort, IP, err := parseResolve(value)
+		if err != nil {
+			return fmt.Errorf("invalid --resolve %q: %w", value, err)
+		}
+
+		config.ResolvedAddresses[hostPort] = IP
+	}
+
+	if config.LocalAddress != "" && net.ParseIP(config.LocalAddress) == nil {
+		return fmt.Errorf("invalid --local-address %q, must be an IP address", config.LocalAddress)
+	}
+
+	if config.LocalCIDR != "" {
+		if _, _, err := net.ParseCIDR(config.LocalCIDR); err != nil {
+			return fmt.Errorf("invalid --local-cidr %q: %w", config.LocalCIDR, err)
+		}
+	}
+
+	if config.LocalAddress != "" && (config.LocalCIDR != "" || config.RandomLocalIP) {
+		return fmt.Errorf("invalid --local-address, can't be used with --local-cidr or --random-local-ip")
+	}
+
+	if config.LocalCIDR != "" && config.RandomLocalIP {
+		return fmt.Errorf("invalid --local-cidr, can't be used with --random-local-ip")
+	}
+
+	if config.FrontierHostMemory < 0 {
+		return fmt.Errorf("invalid --frontier-host-memory %d, must be positive or 0 to disable it", config.FrontierHostMemory)
+	}
+
+	if config.FrontierMaxMemory < 0 {
+		return fmt.Errorf("invalid --frontier-max-memory %d, must be positive or 0 to disable it", config.FrontierMaxMemory)
+	}
+
+	if config.FrontierStrategy != "host-fair" && config.FrontierStrategy != "bfs" && config.FrontierStrategy != "dfs" {
+		return fmt.Errorf("invalid --frontier-strategy %q, must be host-fair, bfs or dfs", config.FrontierStrategy)
+	}
+
+	config.HostDelays = make(map[string]time.Duration, len(config.HostDelay))
+	for host, value := range config.HostDelay {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay <= 0 {
+			return fmt.Errorf("invalid --host-delay %q for %s, must be a positive duration like 2s", value, host)
+		}
+		config.HostDelays[host] = delay
+	}
+
+	config.HostAuths = make(map[string]string, len(config.HostAuth))
+	for host, value := range config.HostAuth {
+		authorization, err := parseHostAuth(value)
+		if err != nil {
+			return fmt.Errorf("invalid --host-auth for %s: %w", host, err)
+		}
+		config.HostAuths[strings.ToLower(host)] = authorization
+	}
+
+	config.Headers = make(http.Header, len(config.Header))
+	for _, value := range config.Header {
+		name, headerValue, err := parseHeader(value)
+		if err != nil {
+			return fmt.Errorf("invalid --header: %w", err)
+		}
+		config.Headers.Add(name, headerValue)
+	}
+
+	config.HostHeaders = make(map[string]http.Header)
+	for _, value := range config.HostHeader {
+		host, header, found := strings.Cut(value, "|")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !found || host == "" {
+			return errors.New("invalid --host-header, must be host|Name: value")
+		}
+
+		name, headerValue, err := parseHeader(header)
+		if err != nil {
+			return fmt.Errorf("invalid --host-header for %s: %w", host, err)
+		}
+
+		if config.HostHeaders[host] == nil {
+			config.HostHeaders[host] = make(http.Header)
+		}
+		config.HostHeaders[host].Add(name, headerValue)
+	}
+
+	if config.MaxResponseBodySize < 0 {
+		return fmt.Errorf("invalid --max-response-body-size %d, must be positive or 0 to disable it", config.MaxResponseBodySize)
+	}
+
+	if (config.Headless || len(config.HeadlessHosts) > 0 || config.HeadlessFallback) && config.HeadlessTabs < 1 {
+		return fmt.Errorf("invalid --headless-tabs %d, must be at least 1", config.HeadlessTabs)
+	}
+
+	if config.HeadlessFallbackMaxText < 0 {
+		return fmt.Errorf("invalid --headless-fallback-max-text %d, must be positive", config.HeadlessFallbackMaxText)
+	}
+
+	if config.HeadlessFallbackMinScripts < 0 {
+		return fmt.Errorf("invalid --headless-fallback-min-scripts %d, must be positive", config.HeadlessFallbackMinScripts)
+	}
+
+	if config.Screenshot {
+		if !config.Headless && len(config.HeadlessHosts) == 0 && !config.HeadlessFallback {
+			return fmt.Errorf("--screenshot requires --headless, --headless-hosts or --headless-fallback")
+		}
+
+		if config.ScreenshotFormat != "png" && config.ScreenshotFormat != "jpeg" {
+			return fmt.Errorf("invalid --screenshot-format %q, must be png or jpeg", config.ScreenshotFormat)
+		}
+	}
+
+	if config.SeencheckBloom && (config.SeencheckBloomFPRate <= 0 || config.SeencheckBloomFPRate >= 1) {
+		return fmt.Errorf("invalid --seencheck-bloom-fp-rate %v, must be between 0 and 1 (exclusive)", config.SeencheckBloomFPRate)
+	}
+
 	if config.DisableIPv4 && config.DisableIPv6 {
 		slog.Error("Both IPv4 and IPv6 are disabled, at least one of them must be enabled.")
 		os.Exit(1)
@@ -330,6 +1123,39 @@ func readLocalExclusionFile(file string) (regexes []string, err error) {
 	return regexes, nil
 }
 
+// ReadProxyFile returns the proxies of a --proxy-file, one per line, without the blank lines, the comments
+// starting with # and the duplicates. The proxies are checked like --proxy, an error gives the line at fault.
+func ReadProxyFile(path string) (proxies []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		proxy := strings.TrimSpace(scanner.Text())
+		if proxy == "" || strings.HasPrefix(proxy, "#") || seen[proxy] {
+			continue
+		}
+
+		if err := checkProxy(proxy); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		seen[proxy] = true
+		proxies = append(proxies, proxy)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return proxies, nil
+}
+
 func readRemoteExclusionFile(URL string) (regexes []string, err error) {
 	httpClient := &http.Client{
 		Timeout: time.Second * 5,
@@ -394,3 +1220,96 @@ func handleFlagsAliases() {
 		viper.Set("min-space-required", viper.GetInt("msr"))
 	}
 }
+
+// parseResolve parses a --resolve value in the format of curl, host:port:address, the address of an
+// IPv6 may be enclosed in brackets. It returns the lowercased host:port and the address.
+func parseResolve(value string) (hostPort string, IP net.IP, err error) {
+	host, rest, found := strings.Cut(value, ":")
+	if !found || host == "" {
+		return "", nil, fmt.Errorf("must be host:port:address")
+	}
+
+	port, address, found := strings.Cut(rest, ":")
+	if !found {
+		return "", nil, fmt.Errorf("must be host:port:address")
+	}
+
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return "", nil, fmt.Errorf("invalid port %q", port)
+	}
+
+	IP = net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"))
+	if IP == nil {
+		return "", nil, fmt.Errorf("invalid address %q", address)
+	}
+
+	return net.JoinHostPort(strings.ToLower(host), port), IP, nil
+}
+
+// checkProxy returns an error if value isn't the URL of a proxy the WARC clients can dial, they only
+// speak SOCKS5: socks5://[user:pass@]host:port or socks5h://. The value may contain credentials,
+// it's never part of the error.
+func checkProxy(value string) error {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return errors.New("must be a socks5:// or socks5h:// URL")
+	}
+
+	if proxyURL.Scheme != "socks5" && proxyURL.Scheme != "socks5h" {
+		return fmt.Errorf("unsupported scheme %q, must be socks5 or socks5h", proxyURL.Scheme)
+	}
+
+	if proxyURL.Hostname() == "" {
+		return errors.New("missing host")
+	}
+
+	return nil
+}
+
+// parseHeader returns the canonical name and the value of a --header, Name: value. Both are checked
+// like net/http does before sending a request, the value is never part of the error.
+func parseHeader(header string) (name, value string, err error) {
+	name, value, found := strings.Cut(header, ":")
+	if !found {
+		return "", "", errors.New("must be Name: value")
+	}
+
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+
+	if !httpguts.ValidHeaderFieldName(name) {
+		return "", "", fmt.Errorf("invalid header name %q", name)
+	}
+
+	// net/http takes the Host header from the URL of the request, it ignores the one of the headers
+	if strings.EqualFold(name, "Host") {
+		return "", "", errors.New("the Host header can't be set")
+	}
+
+	if !httpguts.ValidHeaderFieldValue(value) {
+		return "", "", fmt.Errorf("invalid characters in the value of %s", name)
+	}
+
+	return http.CanonicalHeaderKey(name), value, nil
+}
+
+// parseHostAuth returns the Authorization header of a --host-auth credential, basic:<username>:<password>
+// or bearer:<token>. The credential itself is never part of the error.
+func parseHostAuth(value string) (string, error) {
+	scheme, credential, found := strings.Cut(value, ":")
+	if !found || credential == "" {
+		return "", errors.New("must be basic:<username>:<password> or bearer:<token>")
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !strings.Contains(credential, ":") {
+			return "", errors.New("basic credentials must be basic:<username>:<password>")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credential)), nil
+	case "bearer":
+		return "Bearer " + credential, nil
+	default:
+		return "", fmt.Errorf("unknown scheme %q, must be basic or bearer", scheme)
+	}
+}
@@ -1,6 +1,11 @@
 // Package controler provides a way to start and stop the pipeline.
 package controler
 
+import (
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
 // Start initializes the pipeline.
 func Start() {
 	startPipeline()
@@ -8,6 +13,13 @@ func Start() {
 
 // Stop stops the pipeline.
 func Stop() {
+	stats.StoppingSet()
 	stopPipeline()
 	closeStageChannels()
 }
+
+// Finished returns a channel closed when the crawl is over by itself, i.e. when --max-crawled-items URLs are crawled
+// or the WARC files reach --max-total-warc-size. The pipeline must be stopped then.
+func Finished() <-chan struct{} {
+	return archiver.LimitReached()
+}
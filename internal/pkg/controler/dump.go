@@ -0,0 +1,151 @@
+package controler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"syscall"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// dumpFrontierHosts is the number of hosts with the most pending URLs in the state dumps
+const dumpFrontierHosts = 20
+
+// stateDump is the snapshot of the crawl written to state.json on SIGUSR1, next to the goroutine stacks
+type stateDump struct {
+	Time          time.Time                 `json:"time"`
+	Job           string                    `json:"job"`
+	Paused        bool                      `json:"paused"`
+	Stopping      bool                      `json:"stopping"`
+	InFlight      int                       `json:"in_flight"`
+	Workers       []archiver.WorkerActivity `json:"workers"`
+	FrontierHosts []lq.FrontierHost         `json:"frontier_hosts,omitempty"`
+	Crawled       uint64                    `json:"crawled"`
+	Failed        uint64                    `json:"failed"`
+	Goroutines    int                       `json:"goroutines"`
+	Memory        memoryDump                `json:"memory"`
+}
+
+type memoryDump struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// watchDumpSignal writes a state dump in dir at every SIGUSR1, without interrupting the crawl, until ctx is done.
+// The signal is caught once it returns.
+func watchDumpSignal(ctx context.Context, dir string) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.dumpWatcher",
+	})
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGUSR1)
+
+	go func() {
+		defer signal.Stop(signalChan)
+		dumpOnSignal(ctx, dir, signalChan, logger)
+	}()
+}
+
+func dumpOnSignal(ctx context.Context, dir string, signalChan <-chan os.Signal, logger *log.FieldedLogger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signalChan:
+			path, err := dumpState(dir, time.Now())
+			if err != nil {
+				logger.Error("unable to dump the state of the crawl", "err", err.Error())
+				continue
+			}
+
+			logger.Info("state of the crawl dumped", "path", path)
+		}
+	}
+}
+
+// dumpState writes the goroutine stacks to goroutines.txt and the state of the crawl to state.json,
+// in a directory of dir named after now that it returns
+func dumpState(dir string, now time.Time) (string, error) {
+	path := filepath.Join(dir, now.UTC().Format("20060102T150405.000"))
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", err
+	}
+
+	goroutines, err := os.Create(filepath.Join(path, "goroutines.txt"))
+	if err != nil {
+		return "", err
+	}
+
+	err = pprof.Lookup("goroutine").WriteTo(goroutines, 2)
+	if err = errors.Join(err, goroutines.Close()); err != nil {
+		return "", err
+	}
+
+	state, err := json.MarshalIndent(snapshotState(now), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return path, os.WriteFile(filepath.Join(path, "state.json"), state, 0644)
+}
+
+func snapshotState(now time.Time) stateDump {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	state := stateDump{
+		Time:       now.UTC(),
+		Job:        config.Get().Job,
+		Paused:     stats.PausedGet(),
+		Stopping:   stats.StoppingGet(),
+		InFlight:   len(reactor.GetStateTable()),
+		Workers:    archiver.GetWorkersActivity(),
+		Crawled:    stats.URLsCrawledTotal(),
+		Failed:     stats.URLsFailedTotal(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: memoryDump{
+			HeapAlloc:    memStats.HeapAlloc,
+			HeapInuse:    memStats.HeapInuse,
+			HeapObjects:  memStats.HeapObjects,
+			Sys:          memStats.Sys,
+			NumGC:        memStats.NumGC,
+			PauseTotalNs: memStats.PauseTotalNs,
+		},
+	}
+
+	// Only the local queue knows its hosts, HQ, Kafka and Redis manage the frontier elsewhere
+	if hosts, err := lq.FrontierHosts(dumpFrontierHosts); err == nil {
+		state.FrontierHosts = hosts
+	}
+
+	return state
+}
+
+// logCrawlSummary logs the totals of the crawl once it's stopped
+func logCrawlSummary(logger *log.FieldedLogger) {
+	logger.Info("crawl summary",
+		"crawled", stats.URLsCrawledTotal(),
+		"seeds", stats.SeedsCrawledTotal(),
+		"assets", stats.AssetsCrawledTotal(),
+		"failed", stats.URLsFailedTotal(),
+		"seeds_finished", stats.SeedsFinishedTotal(),
+		"warc_bytes_written", stats.WARCBytesWrittenGet(),
+	)
+}
@@ -0,0 +1,72 @@
+package controler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+func TestDumpOnSIGUSR1(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watchDumpSignal(ctx, dir)
+
+	// The crawl isn't interrupted: the process is still there to send the signal twice
+	for i := 0; i < 2; i++ {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			dumps, _ := filepath.Glob(filepath.Join(dir, "*", "state.json"))
+			if len(dumps) == i+1 {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d dumps, got %v", i+1, dumps)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// Dumps in the same millisecond would share their directory
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	dumps, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, dump := range dumps {
+		goroutines, err := os.ReadFile(filepath.Join(dump, "goroutines.txt"))
+		if err != nil || !strings.Contains(string(goroutines), "goroutine ") {
+			t.Errorf("expected the goroutine stacks in %s, got %d bytes (err: %v)", dump, len(goroutines), err)
+		}
+
+		raw, err := os.ReadFile(filepath.Join(dump, "state.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var state stateDump
+		if err := json.Unmarshal(raw, &state); err != nil {
+			t.Fatalf("unable to decode %s: %v", raw, err)
+		}
+
+		if state.Goroutines == 0 || state.Memory.Sys == 0 || state.Time.IsZero() {
+			t.Errorf("unexpected state %+v", state)
+		}
+	}
+}
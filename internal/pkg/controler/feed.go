@@ -0,0 +1,115 @@
+package controler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/extractor"
+	"github.com/internetarchive/Zeno/internal/pkg/preprocessor"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// Maximum size of a feed fetched to expand the seeds
+const maxSeedFeedSize = 50 * 1024 * 1024
+
+// expandFeedSeeds fetches the seeds and, for the ones that are RSS or Atom feeds, adds the links of
+// their entries to the seeds. The feeds themselves are kept as seeds so that they go through the crawl.
+// The requests are made and archived like the ones of the crawl, the seeds that fail to be fetched
+// are kept as they are.
+func expandFeedSeeds(seeds []string) []string {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.expandFeedSeeds",
+	})
+
+	expanded := append([]string{}, seeds...)
+
+	for _, seed := range seeds {
+		links, skipped, err := fetchFeedLinks(seed)
+		if err != nil {
+			logger.Warn("unable to fetch seed to expand it", "seed", seed, "err", err.Error())
+			continue
+		}
+
+		if links == nil {
+			continue
+		}
+
+		if skipped > 0 {
+			logger.Info("skipped feed entries over the limit", "seed", seed, "max_entries", config.Get().FeedMaxEntries, "skipped", skipped)
+		}
+
+		logger.Info("expanded feed seed", "seed", seed, "entries_links", len(links))
+		expanded = append(expanded, links...)
+	}
+
+	return utils.DedupeStrings(expanded)
+}
+
+// fetchFeedLinks returns the links of the entries of the feed at rawURL, and the number of entries skipped
+// over the limit. The links are nil if it isn't a feed. The redirections are followed up to --max-redirect.
+func fetchFeedLinks(rawURL string) (links []string, skipped int, err error) {
+	URL := &models.URL{Raw: rawURL}
+	if err := URL.Parse(); err != nil {
+		return nil, 0, err
+	}
+
+	item := models.NewItem(uuid.New().String(), URL, "")
+
+	for redirects := 0; ; redirects++ {
+		req, err := preprocessor.NewRequest(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		item.GetURL().SetRequest(req)
+
+		resp, release, err := archiver.Fetch(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		item.GetURL().SetResponse(resp)
+
+		// The body is read to the end in any case, so that the response is archived
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxSeedFeedSize))
+		resp.Body.Close()
+		release()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		location, locationErr := resp.Location()
+		if !isRedirection(resp.StatusCode) || locationErr != nil || redirects >= config.Get().MaxRedirect {
+			if !extractor.SniffFeed(bytes.NewReader(body)) {
+				return nil, 0, nil
+			}
+
+			// Relative links are relative to the final URL
+			return extractor.ParseFeed(bytes.NewReader(body), item.GetURL().GetParsed())
+		}
+
+		redirection := &models.URL{Raw: location.String()}
+		if err := redirection.Parse(); err != nil {
+			return nil, 0, err
+		}
+
+		next := models.NewItem(uuid.New().String(), redirection, "")
+		if err := item.AddChild(next, models.ItemGotRedirected); err != nil {
+			return nil, 0, err
+		}
+		item = next
+	}
+}
+
+func isRedirection(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package controler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// The seeds are fetched through the archiver, the feeds end up in the WARC files
+func TestExpandFeedSeeds(t *testing.T) {
+	config.InitConfig()
+
+	defer func(jobPath string, workers, poolSize, maxRedirect int, compression string, disableRateLimit bool) {
+		config.Get().JobPath = jobPath
+		config.Get().WorkersCount = workers
+		config.Get().WARCPoolSize = poolSize
+		config.Get().MaxRedirect = maxRedirect
+		config.Get().WARCCompression = compression
+		config.Get().DisableRateLimit = disableRateLimit
+	}(config.Get().JobPath, config.Get().WorkersCount, config.Get().WARCPoolSize, config.Get().MaxRedirect, config.Get().WARCCompression, config.Get().DisableRateLimit)
+
+	config.Get().JobPath = t.TempDir()
+	config.Get().WorkersCount = 1
+	config.Get().WARCPoolSize = 1
+	config.Get().MaxRedirect = 1
+	config.Get().WARCCompression = "none"
+	config.Get().DisableRateLimit = true
+
+	if err := os.MkdirAll(path.Join(config.Get().JobPath, "warcs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := archiver.Start(make(chan *models.Item), make(chan *models.Item)); err != nil {
+		t.Fatalf("archiver.Start() error = %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			io.WriteString(w, `<rss><channel>
+				<item><link>/post-1</link></item>
+				<item><link>/post-2</link><enclosure url="/episode-2.mp3"/></item>
+			</channel></rss>`)
+		case "/moved":
+			http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<html><body><a href="/other">link</a></body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	seeds := []string{server.URL + "/moved", server.URL + "/page", "http://127.0.0.1:1/unreachable"}
+
+	got := expandFeedSeeds(seeds)
+
+	want := append(slices.Clone(seeds),
+		server.URL+"/post-1",
+		server.URL+"/post-2",
+		server.URL+"/episode-2.mp3",
+	)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("expandFeedSeeds() = %q, want %q", got, want)
+	}
+
+	archiver.Stop()
+	archiver.Close()
+
+	files, err := archiver.GetWARCFiles()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 WARC file, got %q (err: %v)", files, err)
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var captured []string
+	for {
+		record, eol, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord() error = %v", err)
+		}
+		if eol {
+			break
+		}
+
+		if record.Header.Get("WARC-Type") == "response" {
+			captured = append(captured, record.Header.Get("WARC-Target-URI"))
+		}
+	}
+
+	slices.Sort(captured)
+	if wantCaptured := []string{server.URL + "/feed", server.URL + "/moved", server.URL + "/page"}; !slices.Equal(captured, wantCaptured) {
+		t.Errorf("expected the responses %q to be archived, got %q", wantCaptured, captured)
+	}
+}
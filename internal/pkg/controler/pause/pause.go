@@ -1,6 +1,8 @@
 package pause
 
 import (
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -15,9 +17,22 @@ type ControlChans struct {
 type pauseManager struct {
 	subscribers sync.Map // Map of *ControlChans to struct{}
 	isPaused    atomic.Bool
-	message     string
+
+	// The pipeline stays paused as long as one of the reasons holds it. Their messages are kept apart,
+	// a resume holds mu until every subscriber resumed.
+	mu       sync.Mutex
+	reasons  []pauseReason
+	messages atomic.Value // string
+}
+
+type pauseReason struct {
+	reason  string
+	message string
 }
 
+// The reason of Pause and Resume, the pause of the user
+const manualReason = "manual"
+
 var manager = &pauseManager{}
 
 // Subscribe returns a ControlChans struct for the subscriber to use.
@@ -41,18 +56,44 @@ func Unsubscribe(chans *ControlChans) {
 	close(chans.ResumeCh)
 }
 
-// Pause sends a pause signal to all subscribers.
+// Pause sends a pause signal to all subscribers, it's the pause of the user released by Resume.
 func Pause(message ...string) {
-	swap := manager.isPaused.CompareAndSwap(false, true)
-	if !swap {
-		return
-	}
-
 	if len(message) == 0 {
 		message = append(message, "Paused")
 	}
 
-	manager.message = message[0]
+	PauseFor(manualReason, message[0])
+}
+
+// Resume releases the pause of the user, the pipeline resumes if nothing else holds it.
+func Resume() {
+	ResumeFor(manualReason)
+}
+
+// PauseFor pauses the pipeline for the reason, the subscribers are signaled if it isn't paused already.
+// The pipeline stays paused until every reason it's paused for is released by ResumeFor.
+func PauseFor(reason, message string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	held := false
+	for i := range manager.reasons {
+		if manager.reasons[i].reason == reason {
+			manager.reasons[i].message = message
+			held = true
+		}
+	}
+
+	if !held {
+		manager.reasons = append(manager.reasons, pauseReason{reason: reason, message: message})
+	}
+
+	manager.storeMessages()
+
+	swap := manager.isPaused.CompareAndSwap(false, true)
+	if !swap {
+		return
+	}
 
 	manager.subscribers.Range(func(key, _ interface{}) bool {
 		chans := key.(*ControlChans)
@@ -68,8 +109,24 @@ func Pause(message ...string) {
 	stats.PausedSet()
 }
 
-// Resume reads from each subscriber's ResumeCh to unblock them.
-func Resume() {
+// ResumeFor releases the pause for the reason, and reads from each subscriber's ResumeCh to unblock them
+// if nothing else holds the pipeline. It does nothing if the pipeline isn't paused for the reason.
+func ResumeFor(reason string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	index := slices.IndexFunc(manager.reasons, func(r pauseReason) bool { return r.reason == reason })
+	if index == -1 {
+		return
+	}
+
+	manager.reasons = slices.Delete(manager.reasons, index, index+1)
+	manager.storeMessages()
+
+	if len(manager.reasons) > 0 {
+		return
+	}
+
 	var wg sync.WaitGroup
 	manager.subscribers.Range(func(key, _ interface{}) bool {
 		chans := key.(*ControlChans)
@@ -92,7 +149,6 @@ func Resume() {
 	if !swap {
 		return
 	}
-	manager.message = ""
 
 	stats.PausedReset()
 }
@@ -101,6 +157,26 @@ func IsPaused() bool {
 	return manager.isPaused.Load()
 }
 
+// PausedByUser returns true if the pipeline is paused by Pause, it may be paused for other reasons too
+func PausedByUser() bool {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return slices.ContainsFunc(manager.reasons, func(r pauseReason) bool { return r.reason == manualReason })
+}
+
+// storeMessages joins the messages of the reasons, in the order they paused the pipeline. mu must be held.
+func (m *pauseManager) storeMessages() {
+	messages := make([]string, 0, len(m.reasons))
+	for _, r := range m.reasons {
+		messages = append(messages, r.message)
+	}
+
+	m.messages.Store(strings.Join(messages, "; "))
+}
+
+// GetMessage returns the messages of the reasons the pipeline is paused for
 func GetMessage() string {
-	return manager.message
+	messages, _ := manager.messages.Load().(string)
+	return messages
 }
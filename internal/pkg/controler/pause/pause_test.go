@@ -427,3 +427,61 @@ func TestPauseResumeE2E(t *testing.T) {
 	cancel()
 	wg.Wait()
 }
+
+func TestPauseReasons(t *testing.T) {
+	stats.Init()
+	manager = &pauseManager{}
+
+	subscribed := make(chan struct{})
+	resumed := make(chan struct{}, 1)
+
+	go func() {
+		controlChans := Subscribe()
+		defer Unsubscribe(controlChans)
+
+		subscribed <- struct{}{}
+
+		<-controlChans.PauseCh
+		controlChans.ResumeCh <- struct{}{}
+		resumed <- struct{}{}
+	}()
+
+	// Wait for the goroutine to subscribe
+	<-subscribed
+
+	PauseFor("crawl-window", "Outside of the crawl windows")
+	PauseFor("disk-space", "Not enough disk space")
+
+	if message := GetMessage(); message != "Outside of the crawl windows; Not enough disk space" {
+		t.Errorf("GetMessage() = %q, want both messages", message)
+	}
+
+	// A reason that doesn't hold the pipeline doesn't resume it
+	Resume()
+	ResumeFor("warc-queue")
+
+	// The pipeline stays paused for the other reason
+	ResumeFor("crawl-window")
+
+	if !IsPaused() {
+		t.Fatal("expected the pipeline to stay paused for the disk space")
+	}
+
+	select {
+	case <-resumed:
+		t.Fatal("subscriber resumed while the pipeline is still paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ResumeFor("disk-space")
+
+	select {
+	case <-resumed:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("subscriber did not resume")
+	}
+
+	if IsPaused() || GetMessage() != "" {
+		t.Errorf("expected the pipeline to be resumed, got paused %t with %q", IsPaused(), GetMessage())
+	}
+}
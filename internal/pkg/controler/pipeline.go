@@ -17,13 +17,18 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/preprocessor"
 	"github.com/internetarchive/Zeno/internal/pkg/preprocessor/seencheck"
 	"github.com/internetarchive/Zeno/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
 	"github.com/internetarchive/Zeno/internal/pkg/source/hq"
+	"github.com/internetarchive/Zeno/internal/pkg/source/kafka"
 	"github.com/internetarchive/Zeno/internal/pkg/source/lq"
+	"github.com/internetarchive/Zeno/internal/pkg/source/redis"
 	"github.com/internetarchive/Zeno/internal/pkg/stats"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
 func startPipeline() {
+	startTime = time.Now()
+
 	if err := os.MkdirAll(config.Get().JobPath, 0755); err != nil {
 		fmt.Printf("can't create job directory: %s\n", err)
 		os.Exit(1)
@@ -34,6 +39,26 @@ func startPipeline() {
 		os.Exit(1)
 	}
 
+	// Validate the crawl windows before starting anything
+	var (
+		crawlWindows        []watchers.CrawlWindow
+		crawlWindowLocation *time.Location
+	)
+	if len(config.Get().CrawlWindows) > 0 {
+		var err error
+		crawlWindows, err = watchers.ParseCrawlWindows(config.Get().CrawlWindows)
+		if err != nil {
+			fmt.Printf("can't start Zeno: %s\n", err)
+			os.Exit(1)
+		}
+
+		crawlWindowLocation, err = time.LoadLocation(config.Get().CrawlWindowTimezone)
+		if err != nil {
+			fmt.Printf("can't start Zeno: invalid crawl window timezone: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	err := log.Start()
 	if err != nil {
 		fmt.Println("error starting logger", "err", err.Error())
@@ -50,14 +75,36 @@ func startPipeline() {
 		panic(err)
 	}
 
+	// Lock the temp directory, and remove the temporary files left behind by crashed runs if needed.
+	// The files are kept if another instance is using the directory.
+	locked, err := lockTempDir(config.Get().WARCTempDir)
+	if err != nil {
+		logger.Error("unable to lock temp dir", "err", err.Error(), "dir", config.Get().WARCTempDir)
+	} else if !locked {
+		logger.Warn("temp dir is used by another instance", "dir", config.Get().WARCTempDir)
+	}
+
+	if config.Get().CleanTempOnStart && locked {
+		removed, err := cleanTempDir(config.Get().WARCTempDir, config.Get().CleanTempMinAge)
+		if err != nil {
+			logger.Error("unable to clean temp dir", "err", err.Error(), "dir", config.Get().WARCTempDir)
+		}
+		logger.Info("removed stale temp files", "dir", config.Get().WARCTempDir, "removed", removed)
+	}
+
 	// Start the disk watcher
-	go watchers.WatchDiskSpace(config.Get().JobPath, 5*time.Second)
+	go watchers.WatchDiskSpace(config.Get().DiskCheckInterval)
 
 	// Start the API server if needed
 	if config.Get().API {
 		api.Start()
 	}
 
+	// Start the probes listener, even without API
+	if config.Get().HealthPort > 0 {
+		api.StartHealth()
+	}
+
 	// Register Zeno as Consul service if needed
 	if config.Get().ConsulRegister {
 		err := consul.Register()
@@ -75,9 +122,15 @@ func startPipeline() {
 		panic(err)
 	}
 
-	// If needed, create the seencheck DB (only if not using HQ)
-	if config.Get().UseSeencheck && !config.Get().UseHQ {
-		err := seencheck.Start(config.Get().JobPath)
+	// If needed, create the seencheck DB (only if not using HQ or the Redis seencheck)
+	if useLocalSeencheck() {
+		var err error
+		if config.Get().SeencheckBloom {
+			logger.Info("starting seencheck in bloom filter mode", "expected_items", config.Get().SeencheckBloomItems, "fp_rate", config.Get().SeencheckBloomFPRate)
+			err = seencheck.StartBloom(config.Get().JobPath, config.Get().SeencheckBloomItems, config.Get().SeencheckBloomFPRate)
+		} else {
+			err = seencheck.Start(config.Get().JobPath)
+		}
 		if err != nil {
 			logger.Error("unable to start seencheck", "err", err.Error())
 			panic(err)
@@ -111,20 +164,55 @@ func startPipeline() {
 	finisherFinishChan := makeStageChannel(config.Get().WorkersCount)
 	finisherProduceChan := makeStageChannel(config.Get().WorkersCount)
 
+	var urlSource source.URLSource
 	if config.Get().UseHQ {
 		logger.Info("starting hq")
-		err = hq.Start(finisherFinishChan, finisherProduceChan)
+		if config.Get().LoadFrontier != "" {
+			logger.Warn("--load-frontier is ignored when crawling with HQ, the frontier is managed by HQ")
+		}
+		err = hq.Start()
 		if err != nil {
 			logger.Error("error starting hq source, retrying", "err", err.Error())
 			panic(err)
 		}
+		urlSource = hq.Source()
+	} else if config.Get().UseKafka {
+		logger.Info("starting kafka")
+		if config.Get().LoadFrontier != "" {
+			logger.Warn("--load-frontier is ignored when crawling with Kafka, the seeds are consumed from Kafka")
+		}
+		err = kafka.Start()
+		if err != nil {
+			logger.Error("error starting kafka source", "err", err.Error())
+			panic(err)
+		}
+		urlSource = kafka.Source()
+	} else if config.Get().UseRedis {
+		logger.Info("starting redis")
+		if config.Get().LoadFrontier != "" {
+			logger.Warn("--load-frontier is ignored when crawling with Redis, the seeds are pulled from Redis")
+		}
+		err = redis.Start()
+		if err != nil {
+			logger.Error("error starting redis source", "err", err.Error())
+			panic(err)
+		}
+		urlSource = redis.Source()
 	} else {
 		logger.Info("starting local queue")
-		err = lq.Start(finisherFinishChan, finisherProduceChan)
+		lq.SetHostReadyFunc(archiver.HostAvailable)
+		err = lq.Start()
 		if err != nil {
 			logger.Error("error starting local queue source", "err", err.Error())
 			panic(err)
 		}
+		urlSource = lq.Source()
+	}
+
+	err = source.Start(urlSource, finisherFinishChan, finisherProduceChan)
+	if err != nil {
+		logger.Error("error starting source", "err", err.Error())
+		panic(err)
 	}
 
 	err = finisher.Start(postprocessorOutputChan, finisherFinishChan, finisherProduceChan)
@@ -133,10 +221,28 @@ func startPipeline() {
 		panic(err)
 	}
 
-	// Pipe in the reactor the input seeds if any
-	if len(config.Get().InputSeeds) > 0 {
-		for _, seed := range config.Get().InputSeeds {
-			parsedURL := &models.URL{Raw: seed}
+	// Pipe in the reactor the input seeds if any, the ones of the command line then the ones of the seed lists
+	var seeds []inputSeed
+	for _, URL := range config.Get().InputSeeds {
+		seeds = append(seeds, inputSeed{URL: URL})
+	}
+
+	if len(config.Get().InputSeedLists) > 0 {
+		listSeeds, err := loadSeedLists(config.Get().InputSeedLists, config.Get().SeedListFormat)
+		if err != nil {
+			logger.Error("unable to load seed lists", "err", err.Error())
+			panic(err)
+		}
+		seeds = append(seeds, listSeeds...)
+	}
+
+	if len(seeds) > 0 {
+		if config.Get().SeedFromFeed {
+			seeds = feedSeeds(seeds)
+		}
+
+		for _, seed := range seeds {
+			parsedURL := &models.URL{Raw: seed.URL, Hops: seed.Hops}
 			err := parsedURL.Parse()
 			if err != nil {
 				panic(err)
@@ -144,6 +250,10 @@ func startPipeline() {
 
 			item := models.NewItem(uuid.New().String(), parsedURL, "")
 			item.SetSource(models.ItemSourceQueue)
+			item.SetHeaders(seed.Headers)
+			if seed.MaxHops != nil {
+				item.SetMaxHops(*seed.MaxHops)
+			}
 
 			err = reactor.ReceiveInsert(item)
 			if err != nil {
@@ -152,6 +262,11 @@ func startPipeline() {
 			}
 		}
 	}
+
+	// Start the crawl windows watcher once every worker is subscribed to the pause controler
+	if len(crawlWindows) > 0 {
+		go watchers.WatchCrawlWindows(crawlWindows, crawlWindowLocation, 30*time.Second)
+	}
 }
 
 func stopPipeline() {
@@ -161,6 +276,7 @@ func stopPipeline() {
 
 	watchers.StopDiskWatcher()
 	watchers.StopWARCWritingQueueWatcher()
+	watchers.StopCrawlWindowsWatcher()
 
 	reactor.Freeze()
 
@@ -169,18 +285,36 @@ func stopPipeline() {
 	postprocessor.Stop()
 	finisher.Stop()
 
-	if config.Get().UseSeencheck && !config.Get().UseHQ {
-		seencheck.Close()
+	if useLocalSeencheck() {
+		if err := seencheck.Close(); err != nil {
+			logger.Error("unable to close seencheck", "err", err.Error())
+		}
 	}
 
+	source.Stop()
+
 	if config.Get().UseHQ {
 		hq.Stop()
+	} else if config.Get().UseKafka {
+		kafka.Stop()
+	} else if config.Get().UseRedis {
+		redis.Stop()
 	} else {
 		lq.Stop()
 	}
 
+	// The items left are reported to the source, the WARC files can be closed
+	archiver.Close()
+
 	reactor.Stop()
 
+	// The crawl is over and the WARC files are closed, notify the webhook if needed
+	if config.Get().WebhookURL != "" {
+		sendCompletionWebhook()
+	}
+
+	unlockTempDir()
+
 	if config.Get().WARCTempDir != "" {
 		err := os.Remove(config.Get().WARCTempDir)
 		if err != nil {
@@ -192,11 +326,22 @@ func stopPipeline() {
 		api.Stop(5 * time.Second)
 	}
 
+	if config.Get().HealthPort > 0 {
+		api.StopHealth(5 * time.Second)
+	}
+
 	if config.Get().ConsulRegister {
 		consul.Stop()
 	}
 
+	logCrawlSummary(logger)
+
 	logger.Info("done, logs are flushing and will be closed")
 
 	log.Stop()
 }
+
+// useLocalSeencheck tells if the seencheck is done locally, HQ and Redis can do it for all the crawlers
+func useLocalSeencheck() bool {
+	return config.Get().UseSeencheck && !config.Get().UseHQ && !(config.Get().UseRedis && config.Get().RedisSeencheck)
+}
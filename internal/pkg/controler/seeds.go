@@ -0,0 +1,343 @@
+package controler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// inputSeed is a seed given on the command line or read from a seed list, with its metadata
+type inputSeed struct {
+	URL     string
+	Hops    int
+	MaxHops *int // nil if the global --max-hops applies
+	Headers http.Header
+}
+
+// jsonlSeed is a line of a JSONL seed list: {"url": "...", "hops": 1, "max_hops": 3, "headers": {"Name": "value"}}
+type jsonlSeed struct {
+	URL     string            `json:"url"`
+	Hops    int               `json:"hops"`
+	MaxHops *int              `json:"max_hops"`
+	Headers map[string]string `json:"headers"`
+}
+
+// loadSeedLists reads the seeds of the lists, local files or HTTP(S) URLs. The format is either txt, jsonl, csv
+// or auto to pick it from the extension or the content type of each list. The invalid seeds are skipped.
+func loadSeedLists(lists []string, format string) (seeds []inputSeed, err error) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.loadSeedLists",
+	})
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	for _, list := range lists {
+		body, contentType, err := openSeedList(client, list)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open seed list %s: %w", list, err)
+		}
+
+		listFormat := seedListFormat(list, contentType, format)
+		listSeeds, skipped, err := parseSeedList(body, listFormat, func(line int, reason string) {
+			logger.Warn("skipping invalid seed", "list", list, "line", line, "reason", reason)
+		})
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read seed list %s: %w", list, err)
+		}
+
+		logger.Info("seed list loaded", "list", list, "format", listFormat, "seeds", len(listSeeds), "skipped", skipped)
+		seeds = append(seeds, listSeeds...)
+	}
+
+	return seeds, nil
+}
+
+// openSeedList opens a local seed list, or fetches the remote one and returns its content type
+func openSeedList(client *http.Client, list string) (body io.ReadCloser, contentType string, err error) {
+	if !strings.HasPrefix(list, "http://") && !strings.HasPrefix(list, "https://") {
+		file, err := os.Open(list)
+		return file, "", err
+	}
+
+	resp, err := client.Get(list)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// seedListFormat returns the format of the list, picked from its extension or its content type in auto mode
+func seedListFormat(list, contentType, format string) string {
+	if format != "auto" {
+		return format
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+			return "jsonl"
+		case "text/csv":
+			return "csv"
+		}
+	}
+
+	// The query of a remote list isn't part of its extension
+	list, _, _ = strings.Cut(list, "?")
+
+	switch strings.ToLower(path.Ext(list)) {
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".csv":
+		return "csv"
+	default:
+		return "txt"
+	}
+}
+
+// parseSeedList reads the seeds of the list in the format, the invalid ones are reported to skip and counted
+func parseSeedList(r io.Reader, format string, skip func(line int, reason string)) (seeds []inputSeed, skipped int, err error) {
+	add := func(line int, seed inputSeed) {
+		URL := &models.URL{Raw: seed.URL}
+		if err := URL.Parse(); err != nil {
+			skip(line, err.Error())
+			skipped++
+			return
+		}
+
+		if seed.Hops < 0 {
+			skip(line, "negative hops")
+			skipped++
+			return
+		}
+
+		if seed.MaxHops != nil && *seed.MaxHops < 0 {
+			skip(line, "negative max hops")
+			skipped++
+			return
+		}
+
+		seeds = append(seeds, seed)
+	}
+
+	invalid := func(line int, reason string) {
+		skip(line, reason)
+		skipped++
+	}
+
+	switch format {
+	case "jsonl":
+		err = parseJSONLSeeds(r, add, invalid)
+	case "csv":
+		err = parseCSVSeeds(r, add, invalid)
+	default:
+		err = parseTextSeeds(r, add, invalid)
+	}
+
+	return seeds, skipped, err
+}
+
+// maxSeedLineSize is the size of the longest line of a txt or JSONL seed list, the longer ones are skipped
+const maxSeedLineSize = 1024 * 1024
+
+// readSeedLines calls read with each line of r, without its line ending. The lines longer than
+// maxSeedLineSize are reported to invalid instead.
+func readSeedLines(r io.Reader, read func(line int, text []byte), invalid func(int, string)) error {
+	reader := bufio.NewReaderSize(r, maxSeedLineSize)
+
+	for line := 1; ; line++ {
+		text, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// Skip the rest of the line
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = reader.ReadSlice('\n')
+			}
+
+			invalid(line, fmt.Sprintf("line longer than %d bytes", maxSeedLineSize))
+		} else if len(text) > 0 {
+			read(line, bytes.TrimRight(text, "\r\n"))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// parseTextSeeds reads one URL per line, the empty lines and the ones starting with # are ignored
+func parseTextSeeds(r io.Reader, add func(int, inputSeed), invalid func(int, string)) error {
+	return readSeedLines(r, func(line int, text []byte) {
+		URL := strings.TrimSpace(string(text))
+		if URL == "" || strings.HasPrefix(URL, "#") {
+			return
+		}
+
+		add(line, inputSeed{URL: URL})
+	}, invalid)
+}
+
+func parseJSONLSeeds(r io.Reader, add func(int, inputSeed), invalid func(int, string)) error {
+	return readSeedLines(r, func(line int, text []byte) {
+		if len(bytes.TrimSpace(text)) == 0 {
+			return
+		}
+
+		var seed jsonlSeed
+		if err := json.Unmarshal(text, &seed); err != nil {
+			invalid(line, err.Error())
+			return
+		}
+
+		if seed.URL == "" {
+			invalid(line, "missing url")
+			return
+		}
+
+		add(line, inputSeed{URL: seed.URL, Hops: seed.Hops, MaxHops: seed.MaxHops, Headers: seedHeaders(seed.Headers)})
+	}, invalid)
+}
+
+// parseCSVSeeds reads a CSV seed list. If its first row has a url column, it names the columns: url, hops,
+// max_hops and header:<Name> for the request headers. Otherwise the list has no header row and the URL is the first column.
+func parseCSVSeeds(r io.Reader, add func(int, inputSeed), invalid func(int, string)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"url": 0}
+	headerColumns := map[string]int{}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			invalid(row, parseErr.Err.Error())
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if row == 1 && isCSVHeader(record) {
+			columns = map[string]int{}
+			for i, name := range record {
+				name = strings.TrimSpace(name)
+				if header, found := strings.CutPrefix(name, "header:"); found {
+					headerColumns[header] = i
+				} else {
+					columns[strings.ToLower(name)] = i
+				}
+			}
+			continue
+		}
+
+		seed := inputSeed{URL: csvField(record, columns["url"])}
+		if seed.URL == "" {
+			invalid(row, "missing url")
+			continue
+		}
+
+		if i, found := columns["hops"]; found && csvField(record, i) != "" {
+			hops, err := strconv.Atoi(csvField(record, i))
+			if err != nil {
+				invalid(row, "invalid hops "+strconv.Quote(csvField(record, i)))
+				continue
+			}
+			seed.Hops = hops
+		}
+
+		if i, found := columns["max_hops"]; found && csvField(record, i) != "" {
+			maxHops, err := strconv.Atoi(csvField(record, i))
+			if err != nil {
+				invalid(row, "invalid max hops "+strconv.Quote(csvField(record, i)))
+				continue
+			}
+			seed.MaxHops = &maxHops
+		}
+
+		headers := map[string]string{}
+		for name, i := range headerColumns {
+			if value := csvField(record, i); value != "" {
+				headers[name] = value
+			}
+		}
+		seed.Headers = seedHeaders(headers)
+
+		add(row, seed)
+	}
+}
+
+func isCSVHeader(record []string) bool {
+	for _, name := range record {
+		if strings.EqualFold(strings.TrimSpace(name), "url") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func csvField(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+func seedHeaders(headers map[string]string) http.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	h := make(http.Header, len(headers))
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+
+	return h
+}
+
+// feedSeeds expands the seeds that are feeds with the links of their entries, added as plain seeds
+func feedSeeds(seeds []inputSeed) []inputSeed {
+	URLs := make([]string, 0, len(seeds))
+	known := make(map[string]bool, len(seeds))
+	for _, seed := range seeds {
+		URLs = append(URLs, seed.URL)
+		known[seed.URL] = true
+	}
+
+	for _, URL := range expandFeedSeeds(URLs) {
+		if !known[URL] {
+			known[URL] = true
+			seeds = append(seeds, inputSeed{URL: URL})
+		}
+	}
+
+	return seeds
+}
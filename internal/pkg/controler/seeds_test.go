@@ -0,0 +1,174 @@
+package controler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSeedList(t *testing.T) {
+	maxHops := func(hops int) *int { return &hops }
+
+	tests := []struct {
+		name        string
+		format      string
+		list        string
+		want        []inputSeed
+		wantSkipped int
+	}{
+		{
+			name:   "txt",
+			format: "txt",
+			list:   "http://example.com/\n\n# comment\n  https://example.org/a  \nnot a url\n",
+			want: []inputSeed{
+				{URL: "http://example.com/"},
+				{URL: "https://example.org/a"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:   "jsonl",
+			format: "jsonl",
+			list: `{"url": "http://example.com/", "hops": 2, "headers": {"x-token": "abc"}}
+{"url": "http://example.com/broken"
+{"hops": 1}
+{"url": "http://example.com/negative", "hops": -1}
+{"url": "http://example.com/negative-max", "max_hops": -1}
+
+{"url": "https://example.org/"}
+{"url": "https://example.org/deep", "hops": 1, "max_hops": 4}
+{"url": "https://example.org/shallow", "max_hops": 0}
+`,
+			want: []inputSeed{
+				{URL: "http://example.com/", Hops: 2, Headers: http.Header{"X-Token": []string{"abc"}}},
+				{URL: "https://example.org/"},
+				{URL: "https://example.org/deep", Hops: 1, MaxHops: maxHops(4)},
+				{URL: "https://example.org/shallow", MaxHops: maxHops(0)},
+			},
+			wantSkipped: 4,
+		},
+		{
+			name:   "csv with header row",
+			format: "csv",
+			list: `hops,url,header:Cookie,max_hops
+1,http://example.com/,session=1,
+,https://example.org/,,2
+x,http://example.com/bad-hops,,
+,http://example.com/bad-max-hops,,y
+2,,,
+`,
+			want: []inputSeed{
+				{URL: "http://example.com/", Hops: 1, Headers: http.Header{"Cookie": []string{"session=1"}}},
+				{URL: "https://example.org/", MaxHops: maxHops(2)},
+			},
+			wantSkipped: 3,
+		},
+		{
+			name:   "txt with a line too long",
+			format: "txt",
+			list:   "http://example.com/\nhttp://example.com/" + strings.Repeat("a", 2*maxSeedLineSize) + "\nhttps://example.org/",
+			want: []inputSeed{
+				{URL: "http://example.com/"},
+				{URL: "https://example.org/"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:   "jsonl with a line too long",
+			format: "jsonl",
+			list:   `{"url": "http://example.com/` + strings.Repeat("a", maxSeedLineSize) + `"}` + "\n" + `{"url": "https://example.org/"}` + "\n",
+			want: []inputSeed{
+				{URL: "https://example.org/"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:   "csv without header row",
+			format: "csv",
+			list:   "http://example.com/,ignored\nhttps://example.org/\n",
+			want: []inputSeed{
+				{URL: "http://example.com/"},
+				{URL: "https://example.org/"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported int
+			got, skipped, err := parseSeedList(strings.NewReader(tt.list), tt.format, func(int, string) { reported++ })
+			if err != nil {
+				t.Fatalf("parseSeedList() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSeedList() = %+v, want %+v", got, tt.want)
+			}
+
+			if skipped != tt.wantSkipped || reported != tt.wantSkipped {
+				t.Errorf("skipped %d, reported %d, want %d", skipped, reported, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSeedListFormat(t *testing.T) {
+	tests := []struct {
+		list        string
+		contentType string
+		format      string
+		want        string
+	}{
+		{"seeds.txt", "", "auto", "txt"},
+		{"seeds", "", "auto", "txt"},
+		{"seeds.JSONL", "", "auto", "jsonl"},
+		{"seeds.ndjson", "", "auto", "jsonl"},
+		{"seeds.csv", "", "auto", "csv"},
+		{"https://example.com/seeds.csv?token=1", "text/plain", "auto", "csv"},
+		{"https://example.com/seeds", "application/x-ndjson; charset=utf-8", "auto", "jsonl"},
+		{"https://example.com/seeds", "text/csv", "auto", "csv"},
+		{"seeds.csv", "", "txt", "txt"},
+	}
+
+	for _, tt := range tests {
+		if got := seedListFormat(tt.list, tt.contentType, tt.format); got != tt.want {
+			t.Errorf("seedListFormat(%q, %q, %q) = %q, want %q", tt.list, tt.contentType, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSeedLists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/seeds" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/jsonl")
+		w.Write([]byte(`{"url": "https://example.org/", "hops": 1}` + "\n"))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "seeds.txt")
+	if err := os.WriteFile(file, []byte("http://example.com/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	seeds, err := loadSeedLists([]string{file, server.URL + "/seeds"}, "auto")
+	if err != nil {
+		t.Fatalf("loadSeedLists() error = %v", err)
+	}
+
+	want := []inputSeed{{URL: "http://example.com/"}, {URL: "https://example.org/", Hops: 1}}
+	if !reflect.DeepEqual(seeds, want) {
+		t.Errorf("loadSeedLists() = %+v, want %+v", seeds, want)
+	}
+
+	if _, err := loadSeedLists([]string{server.URL + "/missing"}, "auto"); err == nil {
+		t.Error("expected an error for a missing remote list")
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
+	"github.com/internetarchive/Zeno/internal/pkg/api"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 )
 
 var signalWatcherCtx, signalWatcherCancel = context.WithCancel(context.Background())
 
-// WatchSignals listens for OS signals and handles them gracefully
+// WatchSignals listens for OS signals and handles them gracefully: SIGINT and SIGTERM stop the crawl
+// gracefully, a second one forces the exit, and SIGUSR1 dumps its state to the dumps directory of the job
 func WatchSignals() {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "controler.signalWatcher",
@@ -20,19 +24,40 @@ func WatchSignals() {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
-	select {
-	case <-signalWatcherCtx.Done():
+	// SIGUSR1 dumps the state of the crawl without interrupting it
+	watchDumpSignal(signalWatcherCtx, filepath.Join(config.Get().JobPath, "dumps"))
+
+	mode, stop := waitForStop(signalWatcherCtx, signalChan, logger)
+	if !stop {
 		return
+	}
+
+	// Catch a second signal to force exit
+	go func() {
+		<-signalChan
+		logger.Info("received second shutdown signal, forcing exit...")
+		os.Exit(1)
+	}()
+
+	StopWithMode(mode)
+	os.Exit(0)
+}
+
+// waitForStop blocks until the crawl has to stop: on SIGINT or SIGTERM, once a crawl limit is reached or
+// when a stop is requested through the API. It returns the mode of the stop, stop is false if ctx is done.
+func waitForStop(ctx context.Context, signalChan <-chan os.Signal, logger *log.FieldedLogger) (mode string, stop bool) {
+	mode = api.StopGraceful
+
+	select {
+	case <-ctx.Done():
+		return "", false
 	case <-signalChan:
 		logger.Info("received shutdown signal, stopping services...")
-		// Catch a second signal to force exit
-		go func() {
-			<-signalChan
-			logger.Info("received second shutdown signal, forcing exit...")
-			os.Exit(1)
-		}()
-
-		Stop()
-		os.Exit(0)
+	case <-Finished():
+		logger.Info("crawl limit reached, stopping services...")
+	case mode = <-StopRequested():
+		logger.Info("received stop request, stopping services...", "mode", mode)
 	}
+
+	return mode, true
 }
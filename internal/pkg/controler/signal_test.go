@@ -0,0 +1,44 @@
+package controler
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/api"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+func TestStopOnSIGTERM(t *testing.T) {
+	config.InitConfig()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.signalWatcher",
+	})
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signalChan)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	mode, stop := waitForStop(ctx, signalChan, logger)
+	if !stop || mode != api.StopGraceful {
+		t.Errorf("waitForStop() = %q, %v, want a graceful stop", mode, stop)
+	}
+
+	// The watcher canceled doesn't stop the crawl
+	cancel()
+	if _, stop := waitForStop(ctx, signalChan, logger); stop {
+		t.Error("expected no stop once the watcher is canceled")
+	}
+}
@@ -0,0 +1,47 @@
+package controler
+
+import (
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/api"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// StopRequested returns a channel receiving the mode of the stops requested through the API, graceful or now.
+func StopRequested() <-chan string {
+	return api.StopRequests()
+}
+
+// StopWithMode stops the pipeline. In the now mode the in-flight requests are canceled instead of waited for,
+// what was captured is still written. A stop now requested while stopping gracefully is honored, and so is
+// --stop-timeout: the in-flight requests are canceled once it's over.
+func StopWithMode(mode string) {
+	if mode == api.StopNow {
+		archiver.CancelRequests()
+	} else {
+		var deadline <-chan time.Time
+		if config.Get().StopTimeout > 0 {
+			timer := time.NewTimer(config.Get().StopTimeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		go func() {
+			for {
+				select {
+				case mode := <-StopRequested():
+					if mode != api.StopNow {
+						continue
+					}
+				case <-deadline:
+				}
+
+				archiver.CancelRequests()
+				return
+			}
+		}()
+	}
+
+	Stop()
+}
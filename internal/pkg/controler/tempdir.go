@@ -0,0 +1,110 @@
+package controler
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Name of the lock file held in the temp directory by the running instance
+const tempDirLockName = "zeno.lock"
+
+// Prefixes of the temporary files written by Zeno (response bodies kept for extraction)
+// and by the WARC library (payloads being recorded)
+var tempFilePrefixes = []string{"zeno-", "warc-"}
+
+var tempDirLock *os.File
+
+// lockTempDir takes an exclusive lock on the temp directory for the lifetime of the process,
+// so that the files of an instance sharing the directory aren't cleaned up under its feet.
+// The lock is released by the kernel if the process crashes. It returns false if another
+// instance holds the lock.
+func lockTempDir(dir string) (locked bool, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+
+	file, err := os.OpenFile(path.Join(dir, tempDirLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	// The PID is only informative, for whoever looks at the directory
+	file.Truncate(0)
+	file.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+
+	tempDirLock = file
+
+	return true, nil
+}
+
+// unlockTempDir releases the lock on the temp directory and removes the lock file
+func unlockTempDir() {
+	if tempDirLock == nil {
+		return
+	}
+
+	os.Remove(tempDirLock.Name())
+	tempDirLock.Close()
+	tempDirLock = nil
+}
+
+// cleanTempDir removes the temporary files of the directory last modified more than minAge ago,
+// left behind by the instances that crashed. It must only be called with the directory locked.
+func cleanTempDir(dir string, minAge time.Duration) (removed int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isTempFile(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) < minAge {
+			continue
+		}
+
+		if err := os.Remove(path.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+func isTempFile(name string) bool {
+	for _, prefix := range tempFilePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
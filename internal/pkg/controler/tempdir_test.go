@@ -0,0 +1,75 @@
+package controler
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestCleanTempDir(t *testing.T) {
+	dir := t.TempDir()
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"zeno-123", "warc-456", "zeno-recent", "notes.txt"} {
+		if err := os.WriteFile(path.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if name != "zeno-recent" {
+			if err := os.Chtimes(path.Join(dir, name), old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed, err := cleanTempDir(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("cleanTempDir() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("cleanTempDir() removed %d files, want 2", removed)
+	}
+
+	for name, wantExists := range map[string]bool{"zeno-123": false, "warc-456": false, "zeno-recent": true, "notes.txt": true} {
+		_, err := os.Stat(path.Join(dir, name))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s exists = %v, want %v", name, exists, wantExists)
+		}
+	}
+
+	if removed, err := cleanTempDir(path.Join(dir, "missing"), time.Hour); err != nil || removed != 0 {
+		t.Errorf("cleanTempDir() on a missing directory = %d, %v, want 0, nil", removed, err)
+	}
+}
+
+func TestLockTempDir(t *testing.T) {
+	dir := path.Join(t.TempDir(), "temp")
+
+	locked, err := lockTempDir(dir)
+	if err != nil || !locked {
+		t.Fatalf("lockTempDir() = %v, %v, want true, nil", locked, err)
+	}
+
+	// Another instance can't take the lock, flock locks are held by the open file
+	first := tempDirLock
+	tempDirLock = nil
+
+	locked, err = lockTempDir(dir)
+	if err != nil || locked {
+		t.Errorf("lockTempDir() on a locked directory = %v, %v, want false, nil", locked, err)
+	}
+
+	tempDirLock = first
+	unlockTempDir()
+
+	if _, err := os.Stat(path.Join(dir, tempDirLockName)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+
+	locked, err = lockTempDir(dir)
+	if err != nil || !locked {
+		t.Errorf("lockTempDir() once unlocked = %v, %v, want true, nil", locked, err)
+	}
+	unlockTempDir()
+}
@@ -3,37 +3,56 @@ package watchers
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
 )
 
+// The reason the watcher pauses the pipeline for, the other pauses don't resume it
+const diskPauseReason = "disk-space"
+
 var (
 	diskWatcherCtx, diskWatcherCancel = context.WithCancel(context.Background())
 	diskWatcherWg                     sync.WaitGroup
+
+	// diskLow is set while the free disk space is below the threshold
+	diskLow atomic.Bool
 )
 
-// Implements f(x)={ if total <= 256GB then threshold = 50GB * (total / 256GB) else threshold = 50GB }
-func checkThreshold(total, free uint64, minSpaceRequired float64) error {
+// DiskLow returns true while the free disk space is below the threshold and the pipeline is paused for it
+func DiskLow() bool {
+	return diskLow.Load()
+}
+
+// diskThreshold returns the free space in bytes required on a disk of total bytes, --min-space-required if set, otherwise
+// f(x)={ if total <= 256GB then threshold = 50GB * (total / 256GB) else threshold = 50GB }
+func diskThreshold(total uint64, minSpaceRequired float64) float64 {
 	const (
 		GB = 1024 * 1024 * 1024
 	)
-	var threshold float64
 
 	if minSpaceRequired > 0 {
-		threshold = float64(minSpaceRequired) * float64(GB)
-	} else {
-		if total <= 256*GB {
-			threshold = float64(50*GB) * (float64(total) / float64(256*GB))
-		} else {
-			threshold = 50 * GB
-		}
+		return float64(minSpaceRequired) * float64(GB)
 	}
 
+	if total <= 256*GB {
+		return float64(50*GB) * (float64(total) / float64(256*GB))
+	}
+
+	return 50 * GB
+}
+
+func checkThreshold(total, free uint64, minSpaceRequired float64) error {
+	threshold := diskThreshold(total, minSpaceRequired)
+
 	// Compare free space with threshold
 	if free < uint64(threshold) {
 		return fmt.Errorf("low disk space: free=%.2f GB, threshold=%.2f GB", float64(free)/1e9, float64(threshold)/1e9)
@@ -43,19 +62,83 @@ func checkThreshold(total, free uint64, minSpaceRequired float64) error {
 }
 
 func CheckDiskUsage(path string) error {
+	total, free, err := diskSpace(path)
+	if err != nil {
+		return err
+	}
+
+	return checkThreshold(total, free, config.Get().MinSpaceRequired)
+}
+
+// diskSpace returns the total and free space in bytes of the disk of path
+func diskSpace(path string) (total, free uint64, err error) {
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(path, &stat); err != nil {
-		panic(fmt.Sprintf("Error retrieving disk stats: %v\n", err))
+		return 0, 0, fmt.Errorf("error retrieving disk stats of %s: %w", path, err)
 	}
 
-	total := stat.Blocks * uint64(stat.Bsize)
-	free := stat.Bavail * uint64(stat.Bsize)
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize), nil
+}
 
-	return checkThreshold(total, free, config.Get().MinSpaceRequired)
+// diskWatch tells if the disks of its paths are low on space. Once one is, they must all be back over the
+// threshold plus the resume margin for the space to be sufficient again, so that the crawl doesn't pause
+// and resume over and over around the threshold.
+type diskWatch struct {
+	paths            []string
+	minSpaceRequired float64
+	resumeMargin     float64 // Percentage of the threshold
+	space            func(path string) (total, free uint64, err error)
+	onSpace          func(path string, total, free uint64)
+
+	low bool
 }
 
-// WatchDiskSpace watches the disk space and pauses the pipeline if it's low
-func WatchDiskSpace(path string, interval time.Duration) {
+// check returns true while the space is low, with the reason if it is. The paths whose space can't be
+// retrieved, e.g. a temp dir not created yet, are skipped and their errors returned.
+func (w *diskWatch) check() (low bool, reason string, errs []error) {
+	var reasons []string
+
+	for _, path := range w.paths {
+		total, free, err := w.space(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if w.onSpace != nil {
+			w.onSpace(path, total, free)
+		}
+
+		threshold := diskThreshold(total, w.minSpaceRequired)
+		if w.low {
+			threshold *= 1 + w.resumeMargin/100
+		}
+
+		if free < uint64(threshold) {
+			reasons = append(reasons, fmt.Sprintf("%s: free=%.2f GB, threshold=%.2f GB", path, float64(free)/1e9, threshold/1e9))
+		}
+	}
+
+	w.low = len(reasons) > 0
+
+	return w.low, strings.Join(reasons, ", "), errs
+}
+
+// diskWatchPaths returns the paths whose disks are watched: the job's, where the WARC files are written,
+// and the WARC temp dir's if it's set
+func diskWatchPaths() []string {
+	paths := []string{config.Get().JobPath}
+	if tempDir := config.Get().WARCTempDir; tempDir != "" && filepath.Clean(tempDir) != filepath.Clean(config.Get().JobPath) {
+		paths = append(paths, tempDir)
+	}
+
+	return paths
+}
+
+// WatchDiskSpace watches the disk space of the job and of the WARC temp dir and pauses the pipeline while it's low.
+// No new item is archived, hence no new record sent to the WARC writer, until the space is back over the
+// threshold plus --min-space-resume-margin.
+func WatchDiskSpace(interval time.Duration) {
 	diskWatcherWg.Add(1)
 	defer diskWatcherWg.Done()
 
@@ -63,6 +146,19 @@ func WatchDiskSpace(path string, interval time.Duration) {
 		"component": "controler.diskWatcher",
 	})
 
+	watch := &diskWatch{
+		paths:            diskWatchPaths(),
+		minSpaceRequired: config.Get().MinSpaceRequired,
+		resumeMargin:     config.Get().MinSpaceResumeMargin,
+		space:            diskSpace,
+		onSpace: func(path string, total, free uint64) {
+			if path == config.Get().JobPath {
+				stats.DiskSpaceSet(total, free)
+			}
+			stats.DiskFreeSet(path, free)
+		},
+	}
+
 	paused := false
 	returnASAP := false
 	ticker := time.NewTicker(interval)
@@ -78,15 +174,20 @@ func WatchDiskSpace(path string, interval time.Duration) {
 			}
 			return
 		case <-ticker.C:
-			err := CheckDiskUsage(path)
+			low, reason, errs := watch.check()
+			for _, err := range errs {
+				logger.Debug("unable to check disk space", "err", err.Error())
+			}
+
+			diskLow.Store(low)
 
-			if err != nil && !paused {
-				logger.Warn("Low disk space, pausing the pipeline", "err", err.Error())
-				pause.Pause("Not enough disk space!!!")
+			if low && !paused {
+				logger.Warn("Low disk space, pausing the pipeline", "reason", reason)
+				pause.PauseFor(diskPauseReason, "Not enough disk space: "+reason)
 				paused = true
-			} else if err == nil && paused {
+			} else if !low && paused {
 				logger.Info("Disk space is sufficient, resuming the pipeline")
-				pause.Resume()
+				pause.ResumeFor(diskPauseReason)
 				paused = false
 				if returnASAP {
 					return
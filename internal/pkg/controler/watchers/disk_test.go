@@ -1,6 +1,8 @@
 package watchers
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -58,3 +60,53 @@ func TestCheckThreshold(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskWatchHysteresis(t *testing.T) {
+	const GB = 1024 * 1024 * 1024
+
+	free := map[string]uint64{"/job": 20 * GB, "/tmp": 20 * GB}
+	watch := &diskWatch{
+		paths:            []string{"/job", "/tmp", "/missing"},
+		minSpaceRequired: 10,
+		resumeMargin:     20,
+		space: func(path string) (uint64, uint64, error) {
+			if _, found := free[path]; !found {
+				return 0, 0, errors.New("no such directory")
+			}
+			return 100 * GB, free[path], nil
+		},
+	}
+
+	steps := []struct {
+		path    string
+		free    uint64
+		wantLow bool
+	}{
+		{"/job", 20 * GB, false},
+		// The temp dir's disk is low on its own
+		{"/tmp", 9 * GB, true},
+		// Over the threshold but not over the resume margin
+		{"/tmp", 11 * GB, true},
+		{"/tmp", 12 * GB, false},
+		// Under the resume margin again but over the threshold, it's not low anymore
+		{"/job", 11 * GB, false},
+		{"/job", 5 * GB, true},
+	}
+
+	for i, step := range steps {
+		free[step.path] = step.free
+
+		low, reason, errs := watch.check()
+		if low != step.wantLow {
+			t.Fatalf("step %d: expected low to be %v, got %v (%s)", i, step.wantLow, low, reason)
+		}
+
+		if low && !strings.Contains(reason, step.path) {
+			t.Errorf("step %d: expected the reason to name %s, got %q", i, step.path, reason)
+		}
+
+		if len(errs) != 1 {
+			t.Errorf("step %d: expected the error of the missing path, got %v", i, errs)
+		}
+	}
+}
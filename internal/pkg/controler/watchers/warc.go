@@ -3,8 +3,10 @@ package watchers
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/CorentinB/warc"
 	"github.com/internetarchive/Zeno/internal/pkg/archiver"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
@@ -12,11 +14,38 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/stats"
 )
 
+// The reason the watcher pauses the pipeline for, the other pauses don't resume it
+const warcPauseReason = "warc-queue"
+
 var (
 	wwqCtx, wwqCancel = context.WithCancel(context.Background())
 	wwqWg             sync.WaitGroup
+
+	// warcStalledSince is the time in Unix nanoseconds since when records wait for the WARC writer
+	// without any byte being written, 0 when it's progressing
+	warcStalledSince atomic.Int64
 )
 
+// WARCWriterStalledFor returns for how long records wait for the WARC writer without any byte being written
+func WARCWriterStalledFor() time.Duration {
+	since := warcStalledSince.Load()
+	if since == 0 {
+		return 0
+	}
+
+	return time.Since(time.Unix(0, since))
+}
+
+// updateWARCStall tracks the progress of the WARC writer from the records waiting for it and the bytes written
+func updateWARCStall(queueSize int, written, lastWritten int64, now time.Time) {
+	if queueSize == 0 || written != lastWritten {
+		warcStalledSince.Store(0)
+		return
+	}
+
+	warcStalledSince.CompareAndSwap(0, now.UnixNano())
+}
+
 // StartWatchWARCWritingQueue watches the WARC writing queue size and pauses the pipeline if it exceeds the worker count
 func StartWatchWARCWritingQueue(pauseCheckInterval time.Duration, pauseTimeout time.Duration, statsUpdateInterval time.Duration) {
 	// If Zeno writes WARCs synchronously, no need to check for the queue size and pause the pipeline
@@ -59,12 +88,12 @@ func StartWatchWARCWritingQueue(pauseCheckInterval time.Duration, pauseTimeout t
 
 					if !paused && queueSize > maxQueueSize {
 						logger.Warn("WARC writing queue exceeded the worker count, pausing the pipeline")
-						pause.Pause("WARC writing queue exceeded the worker count")
+						pause.PauseFor(warcPauseReason, "WARC writing queue exceeded the worker count")
 						paused = true
 						lastPauseTime = time.Now()
 					} else if paused && time.Since(lastPauseTime) >= pauseTimeout && queueSize < config.Get().WorkersCount {
 						logger.Info("WARC writing queue size returned to acceptable, resuming the pipeline")
-						pause.Resume()
+						pause.ResumeFor(warcPauseReason)
 						paused = false
 						if returnAfterResume {
 							return
@@ -88,14 +117,21 @@ func StartWatchWARCWritingQueue(pauseCheckInterval time.Duration, pauseTimeout t
 		statsTicker := time.NewTicker(statsUpdateInterval)
 		defer statsTicker.Stop()
 
+		var lastWritten int64
+
 		for {
 			select {
 			case <-wwqCtx.Done():
 				return
 			case <-statsTicker.C:
 				queueSize := archiver.GetWARCWritingQueueSize()
+				written := warc.DataTotal.Value()
 
 				stats.WarcWritingQueueSizeSet(int64(queueSize))
+				stats.WARCBytesWrittenSet(written)
+
+				updateWARCStall(queueSize, written, lastWritten, time.Now())
+				lastWritten = written
 			}
 		}
 	}()
@@ -0,0 +1,40 @@
+package watchers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateWARCStall(t *testing.T) {
+	defer warcStalledSince.Store(0)
+
+	now := time.Now()
+
+	// Nothing waiting, the writer is idle but not stalled
+	updateWARCStall(0, 10, 10, now)
+	if warcStalledSince.Load() != 0 {
+		t.Fatal("idle writer flagged as stalled")
+	}
+
+	// Records waiting and nothing written, the stall starts
+	updateWARCStall(3, 10, 10, now)
+	if warcStalledSince.Load() != now.UnixNano() {
+		t.Fatal("stall not recorded")
+	}
+
+	// Still stalled later, the start of the stall is kept
+	updateWARCStall(3, 10, 10, now.Add(time.Second))
+	if warcStalledSince.Load() != now.UnixNano() {
+		t.Fatal("start of the stall moved")
+	}
+
+	if WARCWriterStalledFor() <= 0 {
+		t.Fatal("stall duration not reported")
+	}
+
+	// Bytes written, the writer progresses again
+	updateWARCStall(3, 20, 10, now.Add(2*time.Second))
+	if WARCWriterStalledFor() != 0 {
+		t.Fatal("progressing writer flagged as stalled")
+	}
+}
@@ -0,0 +1,140 @@
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+// The reason the watcher pauses the pipeline for, the other pauses don't resume it
+const windowPauseReason = "crawl-window"
+
+var (
+	windowWatcherCtx, windowWatcherCancel = context.WithCancel(context.Background())
+	windowWatcherWg                       sync.WaitGroup
+)
+
+// CrawlWindow is a daily time range during which crawling is allowed,
+// expressed in minutes since midnight. A window with an end before its
+// start spans over midnight (e.g. 22:00-04:00).
+type CrawlWindow struct {
+	start int
+	end   int
+}
+
+func (w CrawlWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.start/60, w.start%60, w.end/60, w.end%60)
+}
+
+// contains returns true if the given minute of the day is inside the window
+func (w CrawlWindow) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+
+	return minute >= w.start || minute < w.end
+}
+
+// ParseCrawlWindows parses time ranges formatted as HH:MM-HH:MM
+func ParseCrawlWindows(ranges []string) (windows []CrawlWindow, err error) {
+	for _, r := range ranges {
+		startStr, endStr, found := strings.Cut(strings.TrimSpace(r), "-")
+		if !found {
+			return nil, fmt.Errorf("invalid crawl window %q: expected HH:MM-HH:MM", r)
+		}
+
+		start, err := time.Parse("15:04", strings.TrimSpace(startStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window %q: %w", r, err)
+		}
+
+		end, err := time.Parse("15:04", strings.TrimSpace(endStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window %q: %w", r, err)
+		}
+
+		window := CrawlWindow{
+			start: start.Hour()*60 + start.Minute(),
+			end:   end.Hour()*60 + end.Minute(),
+		}
+
+		if window.start == window.end {
+			return nil, fmt.Errorf("invalid crawl window %q: start and end are the same", r)
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// inCrawlWindows returns true if t is inside any of the windows
+func inCrawlWindows(windows []CrawlWindow, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range windows {
+		if window.contains(minute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WatchCrawlWindows pauses the pipeline when the time in the given location is outside
+// of every crawl window, and resumes it when a window opens. Workers only handle the pause
+// between items, so the in-flight captures finish before the pipeline is paused.
+func WatchCrawlWindows(windows []CrawlWindow, location *time.Location, interval time.Duration) {
+	windowWatcherWg.Add(1)
+	defer windowWatcherWg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.windowWatcher",
+	})
+
+	paused := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	check := func() {
+		now := time.Now().In(location)
+		inWindow := inCrawlWindows(windows, now)
+
+		if !inWindow && !paused {
+			logger.Info("outside of the crawl windows, pausing the pipeline", "time", now.Format("15:04 MST"), "windows", fmt.Sprint(windows))
+			pause.PauseFor(windowPauseReason, "Outside of the crawl windows")
+			paused = true
+		} else if inWindow && paused {
+			logger.Info("crawl window opened, resuming the pipeline", "time", now.Format("15:04 MST"), "windows", fmt.Sprint(windows))
+			pause.ResumeFor(windowPauseReason)
+			paused = false
+		}
+	}
+
+	check()
+
+	for {
+		select {
+		case <-windowWatcherCtx.Done():
+			defer logger.Debug("closed")
+			// Paused workers can't stop, resume them so that the pipeline can shut down
+			if paused {
+				logger.Info("resuming the pipeline before stopping")
+				pause.ResumeFor(windowPauseReason)
+			}
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// StopCrawlWindowsWatcher stops the crawl windows watcher by canceling the context and waiting for the goroutine to finish.
+func StopCrawlWindowsWatcher() {
+	windowWatcherCancel()
+	windowWatcherWg.Wait()
+}
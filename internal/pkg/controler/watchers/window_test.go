@@ -0,0 +1,105 @@
+package watchers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCrawlWindows(t *testing.T) {
+	tests := []struct {
+		name      string
+		ranges    []string
+		want      []string
+		wantError bool
+	}{
+		{
+			name:   "Single window",
+			ranges: []string{"01:00-06:00"},
+			want:   []string{"01:00-06:00"},
+		},
+		{
+			name:   "Multiple windows with spaces",
+			ranges: []string{" 01:00 - 06:00", "22:30-23:45"},
+			want:   []string{"01:00-06:00", "22:30-23:45"},
+		},
+		{
+			name:   "Window spanning over midnight",
+			ranges: []string{"22:00-04:00"},
+			want:   []string{"22:00-04:00"},
+		},
+		{
+			name:      "Missing end",
+			ranges:    []string{"01:00"},
+			wantError: true,
+		},
+		{
+			name:      "Invalid hour",
+			ranges:    []string{"25:00-06:00"},
+			wantError: true,
+		},
+		{
+			name:      "Empty window",
+			ranges:    []string{"06:00-06:00"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := ParseCrawlWindows(tt.ranges)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ParseCrawlWindows() error = %v, wantError %v", err, tt.wantError)
+			}
+
+			if len(windows) != len(tt.want) {
+				t.Fatalf("ParseCrawlWindows() returned %d windows, want %d", len(windows), len(tt.want))
+			}
+
+			for i, window := range windows {
+				if window.String() != tt.want[i] {
+					t.Errorf("window %d = %s, want %s", i, window.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInCrawlWindows(t *testing.T) {
+	windows, err := ParseCrawlWindows([]string{"01:00-06:00", "22:00-23:30"})
+	if err != nil {
+		t.Fatalf("ParseCrawlWindows() error = %v", err)
+	}
+
+	overnight, err := ParseCrawlWindows([]string{"22:00-04:00"})
+	if err != nil {
+		t.Fatalf("ParseCrawlWindows() error = %v", err)
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		windows []CrawlWindow
+		time    time.Time
+		want    bool
+	}{
+		{"Start of a window is inside", windows, at(1, 0), true},
+		{"Middle of a window", windows, at(3, 30), true},
+		{"End of a window is outside", windows, at(6, 0), false},
+		{"Between windows", windows, at(12, 0), false},
+		{"Second window", windows, at(23, 29), true},
+		{"Overnight window before midnight", overnight, at(23, 0), true},
+		{"Overnight window after midnight", overnight, at(2, 0), true},
+		{"Outside of the overnight window", overnight, at(4, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inCrawlWindows(tt.windows, tt.time); got != tt.want {
+				t.Errorf("inCrawlWindows(%s) = %v, want %v", tt.time.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
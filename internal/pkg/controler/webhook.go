@@ -0,0 +1,51 @@
+package controler
+
+import (
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/internal/pkg/webhook"
+)
+
+var startTime time.Time
+
+// sendCompletionWebhook sends the crawl summary to the configured webhook,
+// it must be called once the archiver is stopped so that all WARC files are closed
+func sendCompletionWebhook() {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "controler.sendCompletionWebhook",
+	})
+
+	warcFiles, err := archiver.GetWARCFiles()
+	if err != nil {
+		logger.Error("unable to list WARC files", "err", err.Error())
+	}
+
+	// Everything that isn't a seed is an asset (or a redirection)
+	URLsCrawled := stats.URLsCrawledTotal()
+	seedsCrawled := stats.SeedsFinishedTotal()
+	var assetsCrawled uint64
+	if URLsCrawled > seedsCrawled {
+		assetsCrawled = URLsCrawled - seedsCrawled
+	}
+
+	summary := &webhook.Summary{
+		Job:           config.Get().Job,
+		StartTime:     startTime,
+		EndTime:       time.Now(),
+		SeedsCrawled:  seedsCrawled,
+		AssetsCrawled: assetsCrawled,
+		URLsCrawled:   URLsCrawled,
+		BytesWritten:  warc.DataTotal.Value(),
+		Errors:        stats.URLsFailedTotal(),
+		WARCFiles:     warcFiles,
+	}
+
+	if err := webhook.Send(summary); err != nil {
+		logger.Error("unable to send crawl summary to webhook", "err", err.Error())
+	}
+}
@@ -81,7 +81,7 @@ func (f *finisher) worker(workerID string) {
 	defer f.wg.Done()
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "finisher.worker",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	controlChans := pause.Subscribe()
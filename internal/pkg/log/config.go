@@ -4,6 +4,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -20,20 +21,27 @@ var (
 
 type logConfig struct {
 	FileConfig    *logfileConfig
+	Format        string // Format of stdout and stderr, text or json
+	Job           string // Added to every line if not empty
 	StdoutEnabled bool
 	StdoutLevel   slog.Level
 	StderrEnabled bool
 	StderrLevel   slog.Level
 	LogTUI        bool
 	TUILogLevel   slog.Level
+	SampleLimits  map[slog.Level]int // Identical messages allowed per minute, by level
+	Elasticsearch *elasticsearchConfig
 }
 
 type logfileConfig struct {
 	Dir          string
 	Prefix       string
+	Format       string
 	Level        slog.Level
 	Rotate       bool
 	RotatePeriod time.Duration
+	MaxSize      int64 // In bytes, the file is rotated once it's reached, 0 disables it
+	MaxFiles     int   // Number of compressed rotated files kept, 0 keeps them all
 }
 
 // makeConfig returns the default configuration
@@ -41,6 +49,7 @@ func makeConfig() *logConfig {
 	if config.Get() == nil {
 		return &logConfig{
 			FileConfig:    nil,
+			Format:        "text",
 			StdoutEnabled: true,
 			StdoutLevel:   slog.LevelInfo,
 			StderrEnabled: true,
@@ -64,22 +73,47 @@ func makeConfig() *logConfig {
 		logFileConfig = &logfileConfig{
 			Dir:          logFileOutputDir,
 			Prefix:       config.Get().LogFilePrefix,
+			Format:       config.Get().LogFileFormat,
 			Level:        parseLevel(config.Get().LogFileLevel),
 			Rotate:       config.Get().LogFileRotation != "",
 			RotatePeriod: fileRotatePeriod,
+			MaxSize:      int64(config.Get().LogMaxSize) * 1024 * 1024,
+			MaxFiles:     config.Get().LogMaxFiles,
 		}
 	} else {
 		logFileConfig = nil
 	}
 
+	var esConfig *elasticsearchConfig
+	if len(config.Get().LogElasticsearchURLs) > 0 {
+		esConfig = &elasticsearchConfig{
+			URLs:          config.Get().LogElasticsearchURLs,
+			Username:      config.Get().LogElasticsearchUsername,
+			Password:      config.Get().LogElasticsearchPassword,
+			IndexPrefix:   config.Get().LogElasticsearchIndexPrefix,
+			Level:         parseLevel(config.Get().LogElasticsearchLevel),
+			BulkSize:      config.Get().LogElasticsearchBulkSize,
+			FlushInterval: config.Get().LogElasticsearchFlushInterval,
+			BufferSize:    config.Get().LogElasticsearchBufferSize,
+		}
+	}
+
 	return &logConfig{
 		FileConfig:    logFileConfig,
+		Elasticsearch: esConfig,
+		Format:        config.Get().LogFormat,
+		Job:           config.Get().Job,
 		StdoutEnabled: !config.Get().NoStdoutLogging,
 		StdoutLevel:   parseLevel(config.Get().StdoutLogLevel),
 		StderrEnabled: !config.Get().NoStderrLogging,
 		StderrLevel:   slog.LevelError,
 		LogTUI:        config.Get().TUI,
 		TUILogLevel:   parseLevel(config.Get().TUILogLevel),
+		SampleLimits: map[slog.Level]int{
+			slog.LevelInfo:  config.Get().LogSampleInfo,
+			slog.LevelWarn:  config.Get().LogSampleWarn,
+			slog.LevelError: config.Get().LogSampleError,
+		},
 	}
 }
 
@@ -99,23 +133,39 @@ func parseLevel(level string) slog.Level {
 	}
 }
 
+// newHandler returns a handler writing the records to w in the format, json or text by default
+func (c *logConfig) newHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	} else {
+		handler = slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+	}
+
+	if c.Job != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("job", c.Job)})
+	}
+
+	return handler
+}
+
 func (c *logConfig) makeMultiLogger() *slog.Logger {
 	baseRouter := slogmulti.Router()
 
 	// Handle stdout/stderr logging configuration
 	// If Stdout and Stderr are both enabled we log every level below stderr level to stdout and the rest (above) to stderr
 	if c.StdoutEnabled && c.StderrEnabled {
-		stderrHandler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: c.StderrLevel})
+		stderrHandler := c.newHandler(os.Stderr, c.Format, c.StderrLevel)
 		baseRouter = baseRouter.Add(stderrHandler, func(_ context.Context, r slog.Record) bool {
 			return r.Level >= c.StderrLevel
 		})
 
-		stdoutHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: c.StdoutLevel})
+		stdoutHandler := c.newHandler(os.Stdout, c.Format, c.StdoutLevel)
 		baseRouter = baseRouter.Add(stdoutHandler, func(_ context.Context, r slog.Record) bool {
 			return r.Level >= c.StdoutLevel && r.Level < c.StderrLevel
 		})
 	} else if c.StdoutEnabled {
-		stdoutHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: c.StdoutLevel})
+		stdoutHandler := c.newHandler(os.Stdout, c.Format, c.StdoutLevel)
 		baseRouter = baseRouter.Add(stdoutHandler, func(_ context.Context, r slog.Record) bool {
 			return r.Level >= c.StdoutLevel
 		})
@@ -124,7 +174,7 @@ func (c *logConfig) makeMultiLogger() *slog.Logger {
 	// Handle file logging configuration
 	if c.FileConfig != nil {
 		rotatedLogFile = newRotatedFile(c.FileConfig)
-		fileHandler := slog.NewTextHandler(rotatedLogFile, &slog.HandlerOptions{Level: c.FileConfig.Level})
+		fileHandler := c.newHandler(rotatedLogFile, c.FileConfig.Format, c.FileConfig.Level)
 		baseRouter = baseRouter.Add(fileHandler, func(_ context.Context, r slog.Record) bool {
 			return r.Level >= c.FileConfig.Level
 		})
@@ -141,7 +191,30 @@ func (c *logConfig) makeMultiLogger() *slog.Logger {
 	}
 
 	// Handle Elasticsearch logging configuration
-	// TODO
+	if c.Elasticsearch != nil {
+		elasticsearchLogShipper = newElasticsearchShipper(c.Elasticsearch)
+		elasticsearchLogShipper.start()
+
+		esHandler := newElasticsearchHandler(elasticsearchLogShipper, c.Elasticsearch.Level)
+		if c.Job != "" {
+			esHandler = esHandler.WithAttrs([]slog.Attr{slog.String("job", c.Job)}).(*elasticsearchHandler)
+		}
+		baseRouter = baseRouter.Add(esHandler, func(_ context.Context, r slog.Record) bool {
+			return r.Level >= c.Elasticsearch.Level
+		})
+	}
+
+	handler := baseRouter.Handler()
+
+	// Sample the identical messages if it's enabled for any level
+	for _, limit := range c.SampleLimits {
+		if limit > 0 {
+			logSampler = newSampler(handler, c.SampleLimits, time.Minute)
+			logSampler.start()
+			handler = &samplingHandler{next: handler, sampler: logSampler}
+			break
+		}
+	}
 
-	return slog.New(baseRouter.Handler())
+	return slog.New(handler)
 }
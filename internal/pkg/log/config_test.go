@@ -0,0 +1,36 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewHandlerFormat(t *testing.T) {
+	c := &logConfig{Job: "my-job"}
+
+	var buf bytes.Buffer
+	slog.New(c.newHandler(&buf, "json", slog.LevelInfo)).Info("url archived", "url", "http://example.com/", "status", 200, "hop", 1)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", buf.String(), err)
+	}
+
+	if line["msg"] != "url archived" || line["job"] != "my-job" || line["url"] != "http://example.com/" || line["status"] != float64(200) || line["hop"] != float64(1) {
+		t.Errorf("unexpected JSON line %v", line)
+	}
+
+	buf.Reset()
+	slog.New(c.newHandler(&buf, "text", slog.LevelInfo)).Debug("filtered")
+	if buf.Len() != 0 {
+		t.Errorf("expected the debug line to be filtered, got %q", buf.String())
+	}
+
+	slog.New(c.newHandler(&buf, "text", slog.LevelInfo)).Info("url archived", "status", 200)
+	if got := buf.String(); !strings.Contains(got, `msg="url archived"`) || !strings.Contains(got, "job=my-job") || !strings.Contains(got, "status=200") {
+		t.Errorf("unexpected text line %q", got)
+	}
+}
@@ -0,0 +1,418 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+const (
+	// elasticsearchMaxRetries is the number of times a bulk is retried before its documents are dropped
+	elasticsearchMaxRetries = 5
+	// elasticsearchMaxBackoff caps the exponential backoff between the retries of a bulk
+	elasticsearchMaxBackoff = 10 * time.Second
+	// elasticsearchFlushTimeout bounds the flush of the buffer when the logging system is stopped
+	elasticsearchFlushTimeout = 10 * time.Second
+)
+
+var elasticsearchLogShipper *elasticsearchShipper
+
+type elasticsearchConfig struct {
+	URLs          []string
+	Username      string
+	Password      string
+	IndexPrefix   string // The documents are indexed in <prefix>-YYYY.MM.DD, by the day of their record
+	Level         slog.Level
+	BulkSize      int
+	FlushInterval time.Duration
+	BufferSize    int
+}
+
+type elasticsearchDocument struct {
+	level slog.Level
+	index string
+	body  []byte
+}
+
+// elasticsearchShipper indexes the log records with the bulk API. The records are buffered and sent every
+// BulkSize records or FlushInterval, the failed bulks are retried with backoff. Adding a record never waits
+// on Elasticsearch: when the buffer is full, the debug and info records are dropped first to keep the
+// warnings and errors.
+type elasticsearchShipper struct {
+	config *elasticsearchConfig
+	client *http.Client
+	node   int // Index of the URL the bulks are sent to, the next one is tried after a failure
+
+	mu      sync.Mutex
+	buffer  []elasticsearchDocument
+	dropped atomic.Uint64
+
+	flush  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newElasticsearchShipper(config *elasticsearchConfig) *elasticsearchShipper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &elasticsearchShipper{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+		buffer: make([]elasticsearchDocument, 0, config.BufferSize),
+		flush:  make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *elasticsearchShipper) start() {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.config.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.flush:
+			}
+
+			s.flushBuffer(s.ctx)
+		}
+	}()
+}
+
+// close stops the shipper and flushes the records left, for up to timeout
+func (s *elasticsearchShipper) close(timeout time.Duration) {
+	s.cancel()
+	<-s.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.flushBuffer(ctx)
+
+	s.mu.Lock()
+	left := len(s.buffer)
+	s.mu.Unlock()
+
+	if left > 0 {
+		log.Printf("elasticsearch logging: %d records not indexed at shutdown", left)
+	}
+}
+
+// add buffers the document, it never blocks
+func (s *elasticsearchShipper) add(doc elasticsearchDocument) {
+	s.mu.Lock()
+	if len(s.buffer) >= s.config.BufferSize && !s.evict(doc.level) {
+		s.mu.Unlock()
+		s.drop(doc.level)
+		return
+	}
+
+	s.buffer = append(s.buffer, doc)
+	full := len(s.buffer) >= s.config.BulkSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// evict makes room in the full buffer for a record of the level: a warning or an error takes the place
+// of the oldest debug or info record. It returns false if there is no room, s.mu must be held.
+func (s *elasticsearchShipper) evict(level slog.Level) bool {
+	if level < slog.LevelWarn {
+		return false
+	}
+
+	for i, doc := range s.buffer {
+		if doc.level < slog.LevelWarn {
+			s.buffer = append(s.buffer[:i], s.buffer[i+1:]...)
+			s.drop(doc.level)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *elasticsearchShipper) drop(level slog.Level) {
+	s.dropped.Add(1)
+	stats.LogRecordsDroppedIncr(level.String())
+}
+
+// flushBuffer sends the buffered records by bulks until the buffer is empty. If ctx is done while a bulk is
+// retried, its records are put back at the front of the buffer for the next flush.
+func (s *elasticsearchShipper) flushBuffer(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.mu.Lock()
+		size := min(len(s.buffer), s.config.BulkSize)
+		batch := make([]elasticsearchDocument, size)
+		copy(batch, s.buffer)
+		s.buffer = append(s.buffer[:0], s.buffer[size:]...)
+		s.mu.Unlock()
+
+		if size == 0 {
+			return
+		}
+
+		failed, err := s.sendWithRetry(ctx, batch)
+		if len(failed) == 0 {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			s.mu.Lock()
+			s.buffer = append(failed, s.buffer...)
+			s.mu.Unlock()
+			return
+		}
+
+		log.Printf("elasticsearch logging: dropping %d records after %d retries: %v", len(failed), elasticsearchMaxRetries, err)
+		for _, doc := range failed {
+			s.drop(doc.level)
+		}
+	}
+}
+
+// sendWithRetry sends the bulk, retrying its failed records with an exponential backoff.
+// It returns the records that couldn't be indexed.
+func (s *elasticsearchShipper) sendWithRetry(ctx context.Context, batch []elasticsearchDocument) ([]elasticsearchDocument, error) {
+	backoff := 100 * time.Millisecond
+
+	for retry := 0; ; retry++ {
+		var err error
+		batch, err = s.send(ctx, batch)
+		if len(batch) == 0 || retry == elasticsearchMaxRetries {
+			return batch, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, elasticsearchMaxBackoff)
+	}
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool                                  `json:"errors"`
+	Items  []map[string]elasticsearchBulkOutcome `json:"items"`
+}
+
+type elasticsearchBulkOutcome struct {
+	Status int `json:"status"`
+}
+
+// send sends a bulk and returns its records to retry: all of them if the request failed, the ones rejected
+// with a 429 or 5xx status otherwise. The records rejected for another reason are dropped.
+func (s *elasticsearchShipper) send(ctx context.Context, batch []elasticsearchDocument) ([]elasticsearchDocument, error) {
+	var body bytes.Buffer
+	for _, doc := range batch {
+		index, _ := json.Marshal(doc.index)
+		fmt.Fprintf(&body, `{"index":{"_index":%s}}`+"\n", index)
+		body.Write(doc.body)
+		body.WriteByte('\n')
+	}
+
+	URL := s.config.URLs[s.node%len(s.config.URLs)]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.node++
+		return batch, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		io.Copy(io.Discard, resp.Body)
+		s.node++
+		return batch, fmt.Errorf("bulk request failed with status %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		log.Printf("elasticsearch logging: dropping %d records, bulk request failed with status %d", len(batch), resp.StatusCode)
+		for _, doc := range batch {
+			s.drop(doc.level)
+		}
+		return nil, nil
+	}
+
+	var result elasticsearchBulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return batch, fmt.Errorf("unable to decode bulk response: %w", err)
+	}
+
+	if !result.Errors {
+		return nil, nil
+	}
+
+	if len(result.Items) != len(batch) {
+		return batch, errors.New("bulk response doesn't match the request")
+	}
+
+	var retry []elasticsearchDocument
+	for i, item := range result.Items {
+		for _, outcome := range item {
+			switch {
+			case outcome.Status == http.StatusTooManyRequests || outcome.Status >= 500:
+				retry = append(retry, batch[i])
+			case outcome.Status >= 300:
+				s.drop(batch[i].level)
+			}
+		}
+	}
+
+	if len(retry) > 0 {
+		return retry, fmt.Errorf("%d records rejected", len(retry))
+	}
+
+	return nil, nil
+}
+
+// elasticsearchHandler turns the records into documents for its shipper
+type elasticsearchHandler struct {
+	shipper *elasticsearchShipper
+	level   slog.Level
+	attrs   map[string]any // Fields added by WithAttrs, with their group prefix
+	prefix  string         // Prefix of the fields of the records, from WithGroup
+}
+
+func newElasticsearchHandler(shipper *elasticsearchShipper, level slog.Level) *elasticsearchHandler {
+	return &elasticsearchHandler{
+		shipper: shipper,
+		level:   level,
+		attrs:   map[string]any{},
+	}
+}
+
+func (h *elasticsearchHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *elasticsearchHandler) Handle(_ context.Context, r slog.Record) error {
+	doc := make(map[string]any, len(h.attrs)+r.NumAttrs()+3)
+	for key, value := range h.attrs {
+		doc[key] = value
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		addElasticsearchField(doc, h.prefix, a)
+		return true
+	})
+
+	doc["@timestamp"] = r.Time.UTC().Format(time.RFC3339Nano)
+	doc["level"] = r.Level.String()
+	doc["message"] = r.Message
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	h.shipper.add(elasticsearchDocument{
+		level: r.Level,
+		index: h.shipper.config.IndexPrefix + "-" + r.Time.UTC().Format("2006.01.02"),
+		body:  body,
+	})
+
+	return nil
+}
+
+func (h *elasticsearchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make(map[string]any, len(h.attrs)+len(attrs))
+	for key, value := range h.attrs {
+		clone.attrs[key] = value
+	}
+
+	for _, a := range attrs {
+		addElasticsearchField(clone.attrs, h.prefix, a)
+	}
+
+	return &clone
+}
+
+func (h *elasticsearchHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+
+	return &clone
+}
+
+// addElasticsearchField adds the attribute to the document, the attributes of the groups are
+// flattened with dotted keys, that Elasticsearch maps as objects
+func addElasticsearchField(doc map[string]any, prefix string, a slog.Attr) {
+	value := a.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			addElasticsearchField(doc, prefix, groupAttr)
+		}
+		return
+	}
+
+	if a.Key == "" {
+		return
+	}
+
+	switch v := value.Any().(type) {
+	case error:
+		doc[prefix+a.Key] = v.Error()
+	case time.Time:
+		doc[prefix+a.Key] = v.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		doc[prefix+a.Key] = v.String()
+	case fmt.Stringer:
+		doc[prefix+a.Key] = v.String()
+	default:
+		if _, err := json.Marshal(v); err != nil {
+			doc[prefix+a.Key] = fmt.Sprint(v)
+		} else {
+			doc[prefix+a.Key] = v
+		}
+	}
+}
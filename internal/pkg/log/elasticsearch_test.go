@@ -0,0 +1,211 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// bulkServer is a fake Elasticsearch recording the indexed documents, the first reject documents
+// it receives are rejected with a 429
+type bulkServer struct {
+	mu       sync.Mutex
+	indices  []string
+	docs     []map[string]any
+	requests atomic.Int64
+	reject   atomic.Int64
+}
+
+func (b *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.requests.Add(1)
+
+	if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var (
+		items    []map[string]elasticsearchBulkOutcome
+		rejected bool
+	)
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		scanner.Scan()
+		var doc map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if b.reject.Add(-1) >= 0 {
+			rejected = true
+			items = append(items, map[string]elasticsearchBulkOutcome{"index": {Status: http.StatusTooManyRequests}})
+			continue
+		}
+
+		b.mu.Lock()
+		b.indices = append(b.indices, action["index"]["_index"])
+		b.docs = append(b.docs, doc)
+		b.mu.Unlock()
+
+		items = append(items, map[string]elasticsearchBulkOutcome{"index": {Status: http.StatusCreated}})
+	}
+
+	json.NewEncoder(w).Encode(elasticsearchBulkResponse{Errors: rejected, Items: items})
+}
+
+func (b *bulkServer) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.docs)
+}
+
+func TestElasticsearchHandler(t *testing.T) {
+	server := &bulkServer{}
+	server.reject.Store(1)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	shipper := newElasticsearchShipper(&elasticsearchConfig{
+		URLs:          []string{"http://127.0.0.1:1", ts.URL},
+		IndexPrefix:   "zeno",
+		BulkSize:      2,
+		FlushInterval: time.Hour,
+		BufferSize:    10,
+	})
+	shipper.start()
+
+	logger := slog.New(newElasticsearchHandler(shipper, slog.LevelInfo).WithAttrs([]slog.Attr{slog.String("job", "test")}))
+
+	// The first document sent to the fake Elasticsearch is rejected, then retried
+	logger.Debug("filtered")
+	logger.Info("url archived", "url", "http://example.com/", "status", 200)
+	logger.WithGroup("http").Warn("retrying", "err", errors.New("connection reset"), "sleep", time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	shipper.close(time.Second)
+
+	if server.count() != 2 {
+		t.Fatalf("indexed %d documents, want 2", server.count())
+	}
+
+	day := time.Now().UTC().Format("2006.01.02")
+	for _, index := range server.indices {
+		if index != "zeno-"+day {
+			t.Errorf("index = %q, want zeno-%s", index, day)
+		}
+	}
+
+	byMessage := map[string]map[string]any{}
+	for _, doc := range server.docs {
+		byMessage[doc["message"].(string)] = doc
+	}
+
+	archived := byMessage["url archived"]
+	if archived == nil || archived["job"] != "test" || archived["url"] != "http://example.com/" || archived["status"] != float64(200) || archived["level"] != "INFO" || archived["@timestamp"] == nil {
+		t.Errorf("unexpected document %v", archived)
+	}
+
+	retrying := byMessage["retrying"]
+	if retrying == nil || retrying["http.err"] != "connection reset" || retrying["http.sleep"] != "1s" || retrying["job"] != "test" {
+		t.Errorf("unexpected document %v", retrying)
+	}
+
+	if shipper.dropped.Load() != 0 {
+		t.Errorf("dropped %d records, want 0", shipper.dropped.Load())
+	}
+}
+
+func TestElasticsearchShipperFullBuffer(t *testing.T) {
+	shipper := newElasticsearchShipper(&elasticsearchConfig{BulkSize: 10, BufferSize: 3})
+
+	add := func(level slog.Level, msg string) {
+		shipper.add(elasticsearchDocument{level: level, body: []byte(msg)})
+	}
+
+	add(slog.LevelInfo, "info 1")
+	add(slog.LevelWarn, "warn 1")
+	add(slog.LevelDebug, "debug 1")
+
+	// The buffer is full: an info record is dropped, a warning takes the place of the oldest debug or info one
+	add(slog.LevelInfo, "info 2")
+	add(slog.LevelError, "error 1")
+	add(slog.LevelError, "error 2")
+
+	// Only warnings and errors are left, the new ones are dropped too
+	add(slog.LevelError, "error 3")
+
+	var got []string
+	for _, doc := range shipper.buffer {
+		got = append(got, string(doc.body))
+	}
+
+	if strings.Join(got, ",") != "warn 1,error 1,error 2" {
+		t.Errorf("buffer = %q, want the warnings and errors", got)
+	}
+
+	if shipper.dropped.Load() != 4 {
+		t.Errorf("dropped %d records, want 4", shipper.dropped.Load())
+	}
+}
+
+func TestElasticsearchShipperNeverBlocks(t *testing.T) {
+	// Elasticsearch hangs, adding records must still return immediately
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	shipper := newElasticsearchShipper(&elasticsearchConfig{
+		URLs:          []string{ts.URL},
+		IndexPrefix:   "zeno",
+		BulkSize:      1,
+		FlushInterval: time.Hour,
+		BufferSize:    5,
+	})
+	shipper.start()
+
+	handler := newElasticsearchHandler(shipper, slog.LevelInfo)
+
+	start := time.Now()
+	for range 100 {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "url archived", 0)
+		if err := handler.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("adding the records took %s", elapsed)
+	}
+
+	if shipper.dropped.Load() == 0 {
+		t.Error("expected records to be dropped while Elasticsearch hangs")
+	}
+
+	shipper.close(100 * time.Millisecond)
+}
@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrLoggerAlreadyInitialized is the error returned when the logger is already initialized
 	ErrLoggerAlreadyInitialized = errors.New("logger already initialized")
+	// ErrLoggerNotStarted is the error returned by Healthy when the logger isn't started
+	ErrLoggerNotStarted = errors.New("logger not started")
 )
@@ -2,6 +2,7 @@
 package log
 
 import (
+	"fmt"
 	"log/slog"
 	"sync"
 
@@ -37,14 +38,41 @@ func Start() error {
 
 // Stop gracefully shuts down the logging system
 func Stop() {
+	if logSampler != nil {
+		logSampler.close()
+		logSampler = nil
+	}
+	if elasticsearchLogShipper != nil {
+		elasticsearchLogShipper.close(elasticsearchFlushTimeout)
+		elasticsearchLogShipper = nil
+	}
+	// The last summaries of the sampler and the compression of the rotated files are done before the file is closed
 	if rotatedLogFile != nil {
-		rotatedLogFile.Close()
+		rotatedLogFile.stopRotation()
 	}
 	wg.Wait()
+	if rotatedLogFile != nil {
+		rotatedLogFile.Close()
+	}
 	multiLogger = nil
 	once = sync.Once{}
 }
 
+// Healthy returns an error if the logging system isn't started or its log file can't be written anymore
+func Healthy() error {
+	if multiLogger == nil {
+		return ErrLoggerNotStarted
+	}
+
+	if rotatedLogFile != nil {
+		if err := rotatedLogFile.healthy(); err != nil {
+			return fmt.Errorf("log file: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // Debug logs a message at the debug level
 func Debug(msg string, args ...any) {
 	if multiLogger != nil {
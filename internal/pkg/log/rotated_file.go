@@ -1,11 +1,18 @@
 package log
 
 import (
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +23,12 @@ type rotatedFile struct {
 	mu        sync.Mutex
 	ticker    *time.Ticker
 	closeChan chan struct{}
+	stopOnce  sync.Once
+	rotating  atomic.Bool
+	size      int64 // Size of the current file, for the rotation by size
+
+	// archiveMu serializes the compression and the pruning of the rotated files
+	archiveMu sync.Mutex
 }
 
 func newRotatedFile(config *logfileConfig) *rotatedFile {
@@ -28,26 +41,175 @@ func newRotatedFile(config *logfileConfig) *rotatedFile {
 	if rfile.config.Rotate && rfile.config.RotatePeriod > 0 {
 		rfile.ticker = time.NewTicker(rfile.config.RotatePeriod)
 		wg.Add(1)
+		rfile.rotating.Store(true)
 		go rfile.rotationWorker()
 	}
 
 	return rfile
 }
 
+// Write writes a line to the current file. It's rotated before if the line would make it exceed the maximum
+// size, the rotation holds the lock so the lines are never split or lost across the files.
 func (d *rotatedFile) Write(p []byte) (int, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	if d.file == nil {
 		return 0, os.ErrClosed
 	}
-	return d.file.Write(p)
+
+	// The line is still written to the current file if it can't be rotated
+	if d.config.MaxSize > 0 && d.size > 0 && d.size+int64(len(p)) > d.config.MaxSize {
+		if err := d.rotateBySize(); err != nil {
+			log.Printf("Failed to rotate log file %s: %v", d.file.Name(), err)
+		}
+	}
+
+	n, err := d.file.Write(p)
+	d.size += int64(n)
+
+	return n, err
 }
 
-func (d *rotatedFile) Close() {
-	if d.ticker != nil {
-		d.ticker.Stop()
+// managed returns true if the rotated files are compressed and pruned
+func (d *rotatedFile) managed() bool {
+	return d.config.MaxSize > 0 || d.config.MaxFiles > 0
+}
+
+// rotateBySize renames the current file with the rotation time and starts a new one with the same name,
+// the rotated file is then compressed in the background. The current file is kept if the new one can't
+// be started. d.mu must be held.
+func (d *rotatedFile) rotateBySize() error {
+	name := d.file.Name()
+
+	// Two rotations in the same millisecond mustn't overwrite the first rotated file, not compressed yet
+	base := strings.TrimSuffix(name, ".log") + "." + time.Now().Format("20060102T150405.000")
+	rotated := base + ".log"
+	for i := 1; fileExists(rotated) || fileExists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%d.log", base, i)
+	}
+
+	if err := os.Rename(name, rotated); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		// The current file, still open, gets its name back
+		return errors.Join(err, os.Rename(rotated, name))
+	}
+
+	if err := d.file.Close(); err != nil {
+		log.Printf("Failed to close rotated log file %s: %v", rotated, err)
+	}
+
+	d.file = file
+	d.size = 0
+
+	d.archive(rotated)
+
+	return nil
+}
+
+// archive gzips the rotated file and removes the oldest ones beyond the maximum number of files, in the background
+func (d *rotatedFile) archive(name string) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		d.archiveMu.Lock()
+		defer d.archiveMu.Unlock()
+
+		if err := gzipFile(name); err != nil {
+			log.Printf("Failed to compress rotated log file %s: %v", name, err)
+		}
+
+		if err := d.prune(); err != nil {
+			log.Printf("Failed to prune rotated log files: %v", err)
+		}
+	}()
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// gzipFile compresses the file to name.gz and removes it
+func gzipFile(name string) error {
+	source, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(destination)
+	if _, err := io.Copy(writer, source); err != nil {
+		destination.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	if err := errors.Join(writer.Close(), destination.Close()); err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// prune removes the oldest compressed log files beyond the maximum number of files, if any
+func (d *rotatedFile) prune() error {
+	if d.config.MaxFiles <= 0 {
+		return nil
 	}
-	close(d.closeChan)
+
+	rotated, err := filepath.Glob(filepath.Join(d.config.Dir, d.config.Prefix+"-*.log.gz"))
+	if err != nil || len(rotated) <= d.config.MaxFiles {
+		return err
+	}
+
+	modTimes := make(map[string]time.Time, len(rotated))
+	for _, name := range rotated {
+		if info, err := os.Stat(name); err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+
+	// The newest first
+	sort.Slice(rotated, func(i, j int) bool {
+		if modTimes[rotated[i]].Equal(modTimes[rotated[j]]) {
+			return rotated[i] > rotated[j]
+		}
+		return modTimes[rotated[i]].After(modTimes[rotated[j]])
+	})
+
+	var errs []error
+	for _, name := range rotated[d.config.MaxFiles:] {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopRotation stops the rotation by period, the file is still written until it's closed
+func (d *rotatedFile) stopRotation() {
+	d.stopOnce.Do(func() {
+		if d.ticker != nil {
+			d.ticker.Stop()
+		}
+		close(d.closeChan)
+	})
+}
+
+func (d *rotatedFile) Close() {
+	d.stopRotation()
 	d.mu.Lock()
 	if d.file != nil {
 		fmt.Fprintln(d.file, "Log file closed")
@@ -59,7 +221,10 @@ func (d *rotatedFile) Close() {
 func (d *rotatedFile) rotateFile() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+
+	var previous string
 	if d.file != nil {
+		previous = d.file.Name()
 		d.file.Close()
 	}
 
@@ -77,10 +242,21 @@ func (d *rotatedFile) rotateFile() {
 		log.Fatalf("Failed to open log file: %v", err)
 	}
 	d.file = file
+
+	d.size = 0
+	if info, err := file.Stat(); err == nil {
+		d.size = info.Size()
+	}
+
+	// The previous file is done with unless the new one has the same name, started in the same minute
+	if previous != "" && previous != filename && d.managed() {
+		d.archive(previous)
+	}
 }
 
 func (d *rotatedFile) rotationWorker() {
 	defer wg.Done()
+	defer d.rotating.Store(false)
 	for {
 		select {
 		case <-d.ticker.C:
@@ -90,3 +266,20 @@ func (d *rotatedFile) rotationWorker() {
 		}
 	}
 }
+
+// healthy returns an error if the file is closed or its rotation stopped while it is still written
+func (d *rotatedFile) healthy() error {
+	d.mu.Lock()
+	closed := d.file == nil
+	d.mu.Unlock()
+
+	if closed {
+		return os.ErrClosed
+	}
+
+	if d.ticker != nil && !d.rotating.Load() {
+		return errors.New("log file rotation stopped")
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// readLogLines returns the lines of all the log files of dir, compressed or not
+func readLogLines(t *testing.T, dir string) (lines []string, compressed int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		file, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var reader io.Reader = file
+		if strings.HasSuffix(entry.Name(), ".gz") {
+			compressed++
+			gzipReader, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatalf("%s: %v", entry.Name(), err)
+			}
+			reader = gzipReader
+		} else if !strings.HasSuffix(entry.Name(), ".log") {
+			t.Errorf("unexpected file %s", entry.Name())
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+
+		file.Close()
+	}
+
+	return lines, compressed
+}
+
+func writeLogLines(t *testing.T, file *rotatedFile, writers, perWriter int) {
+	t.Helper()
+
+	var writersWG sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersWG.Add(1)
+		go func() {
+			defer writersWG.Done()
+			for i := 0; i < perWriter; i++ {
+				if _, err := fmt.Fprintf(file, "writer=%02d line=%04d %s end\n", w, i, strings.Repeat("x", 50)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	writersWG.Wait()
+
+	file.Close()
+	wg.Wait()
+}
+
+func TestRotatedFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	file := newRotatedFile(&logfileConfig{Dir: dir, Prefix: "zeno", MaxSize: 4096})
+
+	writeLogLines(t, file, 8, 200)
+
+	lines, compressed := readLogLines(t, dir)
+	if compressed < 10 {
+		t.Errorf("expected several rotations, got %d compressed files", compressed)
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if line == "Log file closed" {
+			continue
+		}
+
+		var w, i int
+		if _, err := fmt.Sscanf(line, "writer=%02d line=%04d", &w, &i); err != nil || !strings.HasSuffix(line, strings.Repeat("x", 50)+" end") {
+			t.Fatalf("interleaved or truncated line %q", line)
+		}
+
+		seen[line] = true
+	}
+
+	if len(seen) != 8*200 {
+		t.Errorf("expected %d distinct lines, got %d", 8*200, len(seen))
+	}
+}
+
+func TestRotatedFileMaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := newRotatedFile(&logfileConfig{Dir: dir, Prefix: "zeno", MaxSize: 2048, MaxFiles: 3})
+
+	writeLogLines(t, file, 4, 200)
+
+	_, compressed := readLogLines(t, dir)
+	if compressed != 3 {
+		t.Errorf("expected the 3 newest compressed files to be kept, got %d", compressed)
+	}
+
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(logs) != 1 {
+		t.Errorf("expected only the current file not compressed, got %v", logs)
+	}
+}
+
+func TestRotatedFileRotationFailure(t *testing.T) {
+	dir := t.TempDir()
+	file := newRotatedFile(&logfileConfig{Dir: dir, Prefix: "zeno", MaxSize: 64})
+	defer file.Close()
+
+	current := file.file
+
+	// The file can't be renamed anymore, it's kept and still written
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := fmt.Fprintf(file, "line=%d %s\n", i, strings.Repeat("x", 50)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if file.file != current {
+		t.Errorf("expected the current file to be kept")
+	}
+}
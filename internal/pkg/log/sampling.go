@@ -0,0 +1,220 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// maxSampledMessages bounds the number of distinct message and host pairs tracked, the messages
+// beyond it are logged without sampling until the next sweep frees some room
+const maxSampledMessages = 10000
+
+var logSampler *sampler
+
+type samplingKey struct {
+	level slog.Level
+	msg   string
+	host  string
+}
+
+type samplingEntry struct {
+	start      time.Time
+	count      int
+	suppressed uint64
+}
+
+// sampler suppresses the identical messages, same level, message and host, logged more than the limit
+// of their level in a window. A summary line with the number of suppressed occurrences replaces them
+// once the window is over.
+type sampler struct {
+	next    slog.Handler
+	limits  map[slog.Level]int // 0 or missing disables the sampling of the level
+	window  time.Duration
+	maxKeys int
+
+	mu      sync.Mutex
+	entries map[samplingKey]*samplingEntry
+
+	stop chan struct{}
+}
+
+func newSampler(next slog.Handler, limits map[slog.Level]int, window time.Duration) *sampler {
+	return &sampler{
+		next:    next,
+		limits:  limits,
+		window:  window,
+		maxKeys: maxSampledMessages,
+		entries: make(map[samplingKey]*samplingEntry),
+		stop:    make(chan struct{}),
+	}
+}
+
+// start sweeps the windows that are over at every interval, until stopped
+func (s *sampler) start() {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.window)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				s.sweep(time.Time{})
+				return
+			case now := <-ticker.C:
+				s.sweep(now)
+			}
+		}
+	}()
+}
+
+// sweep forgets the entries whose window is over at now, all of them if now is zero,
+// and logs the summary of the ones with suppressed occurrences
+func (s *sampler) sweep(now time.Time) {
+	type summary struct {
+		key        samplingKey
+		suppressed uint64
+	}
+
+	var summaries []summary
+
+	s.mu.Lock()
+	for key, entry := range s.entries {
+		if !now.IsZero() && now.Sub(entry.start) < s.window {
+			continue
+		}
+
+		if entry.suppressed > 0 {
+			summaries = append(summaries, summary{key, entry.suppressed})
+		}
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+
+	for _, summary := range summaries {
+		s.summarize(summary.key, summary.suppressed)
+	}
+}
+
+func (s *sampler) summarize(key samplingKey, suppressed uint64) {
+	msg := fmt.Sprintf("suppressed %s occurrences of %q", humanize.Comma(int64(suppressed)), key.msg)
+	if key.host != "" {
+		msg += " for host " + key.host
+	}
+	msg += fmt.Sprintf(" in the last %s", s.window)
+
+	r := slog.NewRecord(time.Now(), key.level, msg, 0)
+	r.AddAttrs(slog.String("component", "log.sampler"), slog.String("message", key.msg), slog.String("host", key.host), slog.Uint64("suppressed", suppressed))
+
+	if s.next.Enabled(context.Background(), key.level) {
+		s.next.Handle(context.Background(), r)
+	}
+}
+
+// allow returns false if the record is an occurrence beyond the limit of its level in the current window
+func (s *sampler) allow(r slog.Record, now time.Time) bool {
+	limit := s.limits[r.Level]
+	if limit <= 0 {
+		return true
+	}
+
+	key := samplingKey{level: r.Level, msg: r.Message, host: recordHost(r)}
+
+	var ended uint64
+
+	s.mu.Lock()
+	entry, found := s.entries[key]
+	if !found {
+		if len(s.entries) >= s.maxKeys {
+			s.mu.Unlock()
+			return true
+		}
+
+		entry = &samplingEntry{start: now}
+		s.entries[key] = entry
+	} else if now.Sub(entry.start) >= s.window {
+		// The window is over, its summary is logged before this occurrence that starts a new one
+		ended = entry.suppressed
+		*entry = samplingEntry{start: now}
+	}
+
+	entry.count++
+	allowed := entry.count <= limit
+	if !allowed {
+		entry.suppressed++
+	}
+	s.mu.Unlock()
+
+	if ended > 0 {
+		s.summarize(key, ended)
+	}
+
+	if !allowed {
+		stats.LogLinesSuppressedIncr(r.Level.String())
+	}
+
+	return allowed
+}
+
+func (s *sampler) close() {
+	close(s.stop)
+}
+
+// recordHost returns the host attribute of the record, or the host of its url attribute
+func recordHost(r slog.Record) (host string) {
+	var rawURL string
+
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "host":
+			host = a.Value.String()
+			return false
+		case "url":
+			rawURL = a.Value.String()
+		}
+		return true
+	})
+
+	if host == "" && rawURL != "" {
+		if parsed, err := url.Parse(rawURL); err == nil {
+			host = parsed.Hostname()
+		}
+	}
+
+	return host
+}
+
+// samplingHandler drops the records suppressed by its sampler before they reach the next handler
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.sampler.allow(r, time.Now()) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
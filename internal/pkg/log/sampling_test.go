@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	next := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	s := newSampler(next, map[slog.Level]int{slog.LevelWarn: 2, slog.LevelError: 5}, time.Minute)
+
+	record := func(level slog.Level, msg, rawURL string) slog.Record {
+		r := slog.NewRecord(time.Now(), level, msg, 0)
+		r.AddAttrs(slog.String("url", rawURL))
+		return r
+	}
+
+	now := time.Now()
+
+	allowed := 0
+	for range 10 {
+		if s.allow(record(slog.LevelWarn, "bad response code", "http://a.example.com/page"), now) {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d warnings, want 2", allowed)
+	}
+
+	// Another host, a more lenient level and an unsampled level have their own budget
+	if !s.allow(record(slog.LevelWarn, "bad response code", "http://b.example.com/page"), now) {
+		t.Error("expected the warning of another host to be allowed")
+	}
+
+	allowed = 0
+	for range 10 {
+		if s.allow(record(slog.LevelError, "bad response code", "http://a.example.com/page"), now) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("allowed %d errors, want 5", allowed)
+	}
+
+	for range 10 {
+		if !s.allow(record(slog.LevelInfo, "url archived", "http://a.example.com/page"), now) {
+			t.Fatal("expected the info messages not to be sampled")
+		}
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no summary before the end of the window, got %q", buf.String())
+	}
+
+	// A new window logs the summary of the previous one
+	if !s.allow(record(slog.LevelWarn, "bad response code", "http://a.example.com/page"), now.Add(time.Minute)) {
+		t.Error("expected the warning to be allowed in a new window")
+	}
+
+	if got := buf.String(); !strings.Contains(got, `suppressed 8 occurrences of \"bad response code\" for host a.example.com in the last 1m0s`) || !strings.Contains(got, "level=WARN") {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	// The sweep summarizes and forgets the other entries
+	buf.Reset()
+	s.sweep(now.Add(2 * time.Minute))
+
+	if got := buf.String(); !strings.Contains(got, "suppressed 5 occurrences") || !strings.Contains(got, "level=ERROR") || strings.Count(got, "\n") != 1 {
+		t.Errorf("unexpected sweep summaries %q", got)
+	}
+
+	if len(s.entries) != 0 {
+		t.Errorf("expected the entries to be forgotten, %d left", len(s.entries))
+	}
+}
+
+func TestSamplerBounded(t *testing.T) {
+	var buf bytes.Buffer
+	s := newSampler(slog.NewTextHandler(&buf, nil), map[slog.Level]int{slog.LevelWarn: 1}, time.Minute)
+	s.maxKeys = 2
+
+	now := time.Now()
+	for _, host := range []string{"a", "b", "c"} {
+		r := slog.NewRecord(now, slog.LevelWarn, "unreachable", 0)
+		r.AddAttrs(slog.String("host", host))
+		s.allow(r, now)
+		s.allow(r, now)
+	}
+
+	if len(s.entries) != 2 {
+		t.Errorf("tracked %d messages, want at most 2", len(s.entries))
+	}
+}
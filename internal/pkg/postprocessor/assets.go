@@ -1,7 +1,10 @@
 package postprocessor
 
 import (
+	"net/url"
+	"path"
 	"slices"
+	"strings"
 
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
@@ -51,6 +54,36 @@ func extractAssets(item *models.Item) (assets, outlinks []*models.URL, err error
 			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
 			return assets, outlinks, err
 		}
+	case extractor.IsMPD(item.GetURL()):
+		assets, err = extractor.MPD(item.GetURL())
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
+			return assets, outlinks, err
+		}
+	case extractor.IsSVG(item.GetURL()):
+		assets, err = extractor.SVG(item)
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
+			return assets, outlinks, err
+		}
+	case extractor.IsCSS(item.GetURL()):
+		assets, err = extractor.CSS(item)
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
+			return assets, outlinks, err
+		}
+	case (config.Get().JSExtraction || config.Get().WebSocketDiscovery) && extractor.IsJS(item.GetURL()):
+		assets, err = extractor.JS(item)
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
+			return assets, outlinks, err
+		}
+	case extractor.IsWebManifest(item.GetURL()):
+		assets, err = extractor.WebManifest(item.GetURL())
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error(), "item", item.GetShortID())
+			return assets, outlinks, err
+		}
 	case extractor.IsJSON(item.GetURL()):
 		assets, outlinks, err = extractor.JSON(item.GetURL())
 		if err != nil {
@@ -71,9 +104,13 @@ func extractAssets(item *models.Item) (assets, outlinks []*models.URL, err error
 		}
 	default:
 		logger.Debug("no extractor used for page", "content-type", contentType, "item", item.GetShortID())
-		return assets, outlinks, nil
 	}
 
+	// Extract the assets of the Link headers (preload, prefetch), whatever the content type
+	assets = append(assets, linkHeaderAssets(item)...)
+
+	var excludedByExtension int
+
 	for i := 0; i < len(assets); {
 		asset := assets[i]
 
@@ -93,10 +130,22 @@ func extractAssets(item *models.Item) (assets, outlinks []*models.URL, err error
 			continue // same: skip increment to check the next item now at index i
 		}
 
+		// Case 3: asset has an excluded extension
+		if excludedAssetExtension(asset.Raw, config.Get().ExcludeAssetExtensions) {
+			logger.Debug("removing asset with an excluded extension", "item", item.GetShortID(), "asset", asset.Raw)
+			assets = slices.Delete(assets, i, i+1)
+			excludedByExtension++
+			continue
+		}
+
 		// Nothing to delete → move to next item
 		i++
 	}
 
+	if excludedByExtension > 0 {
+		logger.Info("skipped assets with an excluded extension", "item", item.GetShortID(), "count", excludedByExtension)
+	}
+
 	// For assets, set the hops level to the item's level
 	for _, asset := range assets {
 		asset.SetHops(item.GetURL().GetHops())
@@ -111,5 +160,26 @@ func extractAssets(item *models.Item) (assets, outlinks []*models.URL, err error
 }
 
 func shouldExtractAssets(item *models.Item) bool {
-	return !config.Get().DisableAssetsCapture && item.GetURL().GetBody() != nil
+	// The browser already fetched (and archived) the assets of the pages it rendered
+	return !config.Get().DisableAssetsCapture && item.GetURL().GetBody() != nil && !item.GetURL().IsHeadless()
+}
+
+// excludedAssetExtension returns true if the extension of the URL's path is one of the excluded ones,
+// the query string and the fragment are ignored
+func excludedAssetExtension(rawURL string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return false
+	}
+
+	var urlPath string
+	if u, err := url.Parse(rawURL); err == nil {
+		urlPath = u.Path
+	} else {
+		urlPath, _, _ = strings.Cut(rawURL, "?")
+		urlPath, _, _ = strings.Cut(urlPath, "#")
+	}
+
+	extension := strings.TrimPrefix(strings.ToLower(path.Ext(urlPath)), ".")
+
+	return extension != "" && slices.Contains(extensions, extension)
 }
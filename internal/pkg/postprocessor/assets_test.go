@@ -0,0 +1,79 @@
+package postprocessor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestExcludedAssetExtension(t *testing.T) {
+	extensions := []string{"mp4", "zip"}
+
+	tests := map[string]bool{
+		"http://example.com/video.mp4":            true,
+		"http://example.com/video.MP4":            true,
+		"http://example.com/video.mp4?token=abc":  true,
+		"http://example.com/archive.zip#part":     true,
+		"http://example.com/style.css":            false,
+		"http://example.com/watch?file=video.mp4": false,
+		"http://example.com/mp4":                  false,
+		"http://example.com/":                     false,
+	}
+
+	for rawURL, want := range tests {
+		if got := excludedAssetExtension(rawURL, extensions); got != want {
+			t.Errorf("excludedAssetExtension(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+
+	if excludedAssetExtension("http://example.com/video.mp4", nil) {
+		t.Error("expected nothing excluded without extensions")
+	}
+}
+
+func TestExtractAssetsExcludedExtensions(t *testing.T) {
+	config.InitConfig()
+
+	defer func(extensions []string) {
+		config.Get().ExcludeAssetExtensions = extensions
+	}(config.Get().ExcludeAssetExtensions)
+	config.Get().ExcludeAssetExtensions = []string{"mp4"}
+
+	html := `<html><body><img src="/image.png"><video src="/video.mp4?quality=hd"></video></body></html>`
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/html"}},
+		Body:   io.NopCloser(bytes.NewBufferString(html)),
+	}
+
+	URL := &models.URL{Raw: "http://example.com/page"}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	assets, _, err := extractAssets(models.NewItem("page", URL, ""))
+	if err != nil {
+		t.Fatalf("extractAssets() error = %v", err)
+	}
+
+	var raws []string
+	for _, asset := range assets {
+		raws = append(raws, asset.Raw)
+	}
+
+	// The assets are resolved against the page later on
+	if want := []string{"/image.png"}; !slices.Equal(raws, want) {
+		t.Errorf("expected %v, the video being skipped, got %v", want, raws)
+	}
+}
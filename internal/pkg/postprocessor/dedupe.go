@@ -0,0 +1,60 @@
+package postprocessor
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+)
+
+// dedupeWindow remembers the hashes of the most recently queued outlinks, set by --queue-dedupe-window.
+// Densely interlinked sites give the same outlinks on every page, the window drops them before they
+// reach the seencheck and the queue. It isn't persisted, the seencheck still catches what it forgets.
+type dedupeWindow struct {
+	size int
+
+	mu     sync.Mutex
+	hashes map[uint64]*list.Element
+	lru    *list.List
+}
+
+// newDedupeWindow returns a window remembering up to size outlinks, nil if size is 0
+func newDedupeWindow(size int) *dedupeWindow {
+	if size <= 0 {
+		return nil
+	}
+
+	return &dedupeWindow{
+		size:   size,
+		hashes: make(map[uint64]*list.Element),
+		lru:    list.New(),
+	}
+}
+
+// seen returns true if the URL was queued recently, it remembers it otherwise
+func (w *dedupeWindow) seen(URL string) bool {
+	if w == nil {
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(URL))
+	hash := h.Sum64()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if element, found := w.hashes[hash]; found {
+		w.lru.MoveToFront(element)
+		return true
+	}
+
+	w.hashes[hash] = w.lru.PushFront(hash)
+
+	if w.lru.Len() > w.size {
+		oldest := w.lru.Back()
+		w.lru.Remove(oldest)
+		delete(w.hashes, oldest.Value.(uint64))
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package postprocessor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestDedupeWindowSeen(t *testing.T) {
+	w := newDedupeWindow(2)
+
+	if w.seen("https://example.com/a") {
+		t.Error("expected the first occurrence not to be seen")
+	}
+
+	if !w.seen("https://example.com/a") {
+		t.Error("expected the second occurrence to be seen")
+	}
+
+	w.seen("https://example.com/b")
+	w.seen("https://example.com/c")
+
+	// a was the least recently queued, it got forgotten
+	if w.seen("https://example.com/a") {
+		t.Error("expected the least recently queued URL to be forgotten")
+	}
+
+	if !w.seen("https://example.com/c") {
+		t.Error("expected the most recently queued URL to be remembered")
+	}
+}
+
+func TestDedupeWindowDisabled(t *testing.T) {
+	w := newDedupeWindow(0)
+	if w != nil {
+		t.Fatal("expected no window for a size of 0")
+	}
+
+	if w.seen("https://example.com/a") || w.seen("https://example.com/a") {
+		t.Error("expected nothing to be seen without a window")
+	}
+}
+
+func TestDedupeWindowConcurrent(t *testing.T) {
+	w := newDedupeWindow(1000)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		unseen int
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if !w.seen(fmt.Sprintf("https://example.com/%d", j)) {
+					mu.Lock()
+					unseen++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if unseen != 100 {
+		t.Errorf("expected each URL to be unseen once, got %d unseen", unseen)
+	}
+}
@@ -1,13 +1,62 @@
 package extractor
 
 import (
-	"github.com/PuerkitoBio/goquery"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
-func extractBaseTag(item *models.Item, doc *goquery.Document) {
-	base, exists := doc.Find("base").First().Attr("href")
-	if exists {
-		item.SetBase(base)
+// extractBaseTag sets the base of the item from the first <base> element with an href, resolved against
+// the URL the document was fetched from. Per the HTML spec, the <base> elements after it are ignored.
+// Empty, invalid or non-HTTP(S) hrefs are ignored: the URLs are then resolved against the document's URL.
+func extractBaseTag(item *models.Item, page *htmlPage) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.extractor.extractBaseTag",
+	})
+
+	var (
+		href   string
+		exists bool
+	)
+
+	for _, base := range page.find("base") {
+		if href, exists = base.Attr("href"); exists {
+			break
+		}
+	}
+
+	href = strings.TrimSpace(href)
+	if !exists || href == "" {
+		return
+	}
+
+	link, err := url.Parse(href)
+	if err != nil {
+		logger.Debug("ignoring malformed base href", "href", href, "err", err, "url", item.GetURL().String(), "item", item.GetShortID())
+		return
 	}
+
+	// Resolve against the document's URL rather than the current base of the item,
+	// so that extracting the base tag more than once gives the same result
+	if !link.IsAbs() {
+		documentURL := item.GetURL().GetParsed()
+		if resp := item.GetURL().GetResponse(); resp != nil && resp.Request != nil && resp.Request.URL != nil {
+			documentURL = resp.Request.URL
+		}
+
+		if documentURL == nil {
+			return
+		}
+
+		link = documentURL.ResolveReference(link)
+	}
+
+	if link.Scheme != "http" && link.Scheme != "https" {
+		logger.Debug("ignoring non-HTTP base href", "href", href, "url", item.GetURL().String(), "item", item.GetShortID())
+		return
+	}
+
+	item.SetBase(link.String())
 }
@@ -29,9 +29,86 @@ func TestExtractBaseTag(t *testing.T) {
     Raw: "https://example.com/something/page.html",
   }, "")
 
-	extractBaseTag(item, doc)
+	extractBaseTag(item, documentPage(doc))
 
 	if item.GetBase() != "http://example.com/something/" {
 		t.Errorf("Cannot find html doc base.href")
 	}
 }
+
+func TestExtractBaseTagResolution(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "relative base",
+			html: `<html><head><base href="../static/"></head></html>`,
+			want: "https://example.com/static/",
+		},
+		{
+			name: "root-relative base",
+			html: `<html><head><base href=" /assets/v2/ "></head></html>`,
+			want: "https://example.com/assets/v2/",
+		},
+		{
+			name: "protocol-relative base",
+			html: `<html><head><base href="//cdn.example.com/"></head></html>`,
+			want: "https://cdn.example.com/",
+		},
+		{
+			name: "base without href is skipped",
+			html: `<html><head><base target="_blank"><base href="http://other.com/"></head></html>`,
+			want: "http://other.com/",
+		},
+		{
+			name: "absolute base",
+			html: `<html><head><base href="https://cdn.example.org/app/"></head></html>`,
+			want: "https://cdn.example.org/app/",
+		},
+		{
+			name: "only the first base counts",
+			html: `<html><head><base href="https://cdn.example.org/app/"><base href="https://other.example.org/"></head></html>`,
+			want: "https://cdn.example.org/app/",
+		},
+		{
+			name: "malformed href",
+			html: `<html><head><base href="http://[::1/app/"><base href="https://other.example.org/"></head></html>`,
+			want: "",
+		},
+		{
+			name: "empty href",
+			html: `<html><head><base href=""></head></html>`,
+			want: "",
+		},
+		{
+			name: "non-HTTP href",
+			html: `<html><head><base href="javascript:void(0)"></head></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("html doc loading failed %s", err)
+			}
+
+			URL := &models.URL{Raw: "https://example.com/pages/page.html"}
+			if err := URL.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			item := models.NewItem("test", URL, "")
+
+			// Extracting the base twice must not resolve a relative base against itself
+			extractBaseTag(item, documentPage(doc))
+			extractBaseTag(item, documentPage(doc))
+
+			if item.GetBase() != tt.want {
+				t.Errorf("extractBaseTag() base = %q, want %q", item.GetBase(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// Canonical returns the URL of the first <link rel="canonical"> of the page that has one,
+// resolved against the base of the page
+func Canonical(item *models.Item) (canonical string, found bool) {
+	defer item.GetURL().RewindBody()
+
+	page, err := loadHTMLPage(item, false)
+	if err != nil {
+		return "", false
+	}
+
+	// The canonical URL is relative to the <base> of the page, if any
+	extractBaseTag(item, page)
+
+	for _, link := range page.find("link") {
+		rel, hasRel := link.Attr("rel")
+		href, hasHref := link.Attr("href")
+		if !hasRel || !hasHref || !hasRelToken(rel, "canonical") {
+			continue
+		}
+
+		if strings.TrimSpace(href) == "" {
+			continue
+		}
+
+		resolved, err := resolveURL(strings.TrimSpace(href), item)
+		if err != nil || resolved == "" {
+			continue
+		}
+
+		return resolved, true
+	}
+
+	return "", false
+}
+
+// hasRelToken returns true if the space separated rel attribute contains the token, case-insensitively
+func hasRelToken(rel, token string) bool {
+	for _, t := range strings.Fields(rel) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
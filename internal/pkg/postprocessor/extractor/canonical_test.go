@@ -0,0 +1,72 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestCanonical(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		name          string
+		html          string
+		wantCanonical string
+		wantFound     bool
+	}{
+		{
+			name:          "absolute URL",
+			html:          `<html><head><link rel="canonical" href="http://example.com/article"></head></html>`,
+			wantCanonical: "http://example.com/article",
+			wantFound:     true,
+		},
+		{
+			name:          "relative URL and several rel tokens",
+			html:          `<html><head><link rel="alternate CANONICAL" href=" ../article "></head></html>`,
+			wantCanonical: "http://example.com/article",
+			wantFound:     true,
+		},
+		{
+			name:          "resolved against the base tag",
+			html:          `<html><head><base href="http://example.com/v2/"><link rel="canonical" href="article"></head></html>`,
+			wantCanonical: "http://example.com/v2/article",
+			wantFound:     true,
+		},
+		{
+			name:      "other rel",
+			html:      `<html><head><link rel="stylesheet" href="/style.css"><link rel="canonical" href=""></head></html>`,
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{"text/html"}},
+				Body:   io.NopCloser(bytes.NewBufferString(tt.html)),
+			}
+			newURL := &models.URL{Raw: "http://example.com/old/article?utm_source=feed"}
+			if err := newURL.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			newURL.SetResponse(resp)
+			err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+			if err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+			item := models.NewItem("test", newURL, "")
+
+			canonical, found := Canonical(item)
+			if canonical != tt.wantCanonical || found != tt.wantFound {
+				t.Errorf("Canonical() = %q, %v, want %q, %v", canonical, found, tt.wantCanonical, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package extractor
+
+import (
+	"io"
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func IsCSS(URL *models.URL) bool {
+	return isContentType(URL.GetResponse().Header.Get("Content-Type"), "text/css")
+}
+
+// CSS extracts the url() and @import references of a stylesheet, resolved against the stylesheet's URL.
+// Nested stylesheets are extracted as assets of the stylesheet importing them, the depth limit
+// applied to the assets in the postprocessor prevents import loops from going on forever.
+func CSS(item *models.Item) (assets []*models.URL, err error) {
+	defer item.GetURL().RewindBody()
+
+	body, err := io.ReadAll(item.GetURL().GetBody())
+	if err != nil {
+		return assets, err
+	}
+
+	for _, rawAsset := range cssAssets(item, string(body)) {
+		assets = append(assets, &models.URL{
+			Raw: rawAsset,
+		})
+	}
+
+	return assets, nil
+}
+
+// cssAssets returns the URLs referenced by a piece of CSS, resolved against the base of the item
+func cssAssets(item *models.Item, css string) (assets []string) {
+	for _, URL := range parseCSSURLs(css) {
+		resolved, err := resolveURL(URL, item)
+		if err != nil || resolved == "" {
+			assets = append(assets, URL)
+			continue
+		}
+
+		assets = append(assets, resolved)
+	}
+
+	return assets
+}
+
+// parseCSSURLs returns the URLs of the url() functions and @import rules of a piece of CSS.
+// Quoted and unquoted URLs are supported. data: URIs and references to fragments
+// of the document itself (e.g. url(#gradient) in SVG filters) are skipped.
+func parseCSSURLs(css string) (URLs []string) {
+	css = stripCSSComments(css)
+
+	for i := 0; i < len(css); {
+		switch {
+		case hasPrefixFold(css[i:], "url("):
+			URL, end := readCSSURL(css, i+len("url("))
+			URLs = appendCSSURL(URLs, URL)
+			i = end
+		case hasPrefixFold(css[i:], "@import"):
+			// The url() form of @import is handled by the url( case on the next iteration
+			j := skipCSSSpaces(css, i+len("@import"))
+			if j < len(css) && (css[j] == '"' || css[j] == '\'') {
+				URL, end := readCSSString(css, j)
+				URLs = appendCSSURL(URLs, URL)
+				i = end
+				continue
+			}
+
+			i = j
+		default:
+			i++
+		}
+	}
+
+	return URLs
+}
+
+func appendCSSURL(URLs []string, URL string) []string {
+	URL = strings.TrimSpace(URL)
+	if URL == "" || strings.HasPrefix(URL, "#") || hasPrefixFold(URL, "data:") {
+		return URLs
+	}
+
+	return append(URLs, URL)
+}
+
+// readCSSURL reads the content of a url() function starting at i, right after the opening parenthesis.
+// It returns the URL and the position after the closing parenthesis.
+func readCSSURL(css string, i int) (URL string, end int) {
+	i = skipCSSSpaces(css, i)
+	if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+		URL, i = readCSSString(css, i)
+		// Skip up to the closing parenthesis
+		for i < len(css) && css[i] != ')' {
+			i++
+		}
+
+		return URL, min(i+1, len(css))
+	}
+
+	start := i
+	for i < len(css) && css[i] != ')' {
+		i++
+	}
+
+	return css[start:i], min(i+1, len(css))
+}
+
+// readCSSString reads a string delimited by the quote at i and returns its content
+// and the position after the closing quote
+func readCSSString(css string, i int) (value string, end int) {
+	quote := css[i]
+	i++
+
+	var b strings.Builder
+	for i < len(css) {
+		c := css[i]
+		switch {
+		case c == '\\' && i+1 < len(css):
+			// Escaped character, e.g. an escaped quote
+			b.WriteByte(css[i+1])
+			i += 2
+		case c == quote:
+			return b.String(), i + 1
+		case c == '\n':
+			// Unterminated string
+			return b.String(), i
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), i
+}
+
+func stripCSSComments(css string) string {
+	if !strings.Contains(css, "/*") {
+		return css
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start == -1 {
+			b.WriteString(css)
+			break
+		}
+
+		b.WriteString(css[:start])
+
+		end := strings.Index(css[start+2:], "*/")
+		if end == -1 {
+			break
+		}
+
+		css = css[start+2+end+2:]
+	}
+
+	return b.String()
+}
+
+func skipCSSSpaces(css string, i int) int {
+	for i < len(css) && (css[i] == ' ' || css[i] == '\t' || css[i] == '\n' || css[i] == '\r' || css[i] == '\f') {
+		i++
+	}
+
+	return i
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
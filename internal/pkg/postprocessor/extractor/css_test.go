@@ -0,0 +1,177 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseCSSURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want []string
+	}{
+		{
+			name: "unquoted url",
+			css:  `body { background: url(/img/bg.png) no-repeat; }`,
+			want: []string{"/img/bg.png"},
+		},
+		{
+			name: "quoted urls",
+			css:  `.a { background-image: url("a.png"); } .b { background-image: url( 'b.png' ); }`,
+			want: []string{"a.png", "b.png"},
+		},
+		{
+			name: "quoted url containing parentheses",
+			css:  `.a { background: url("img/photo (1).jpg"); }`,
+			want: []string{"img/photo (1).jpg"},
+		},
+		{
+			name: "font-face sources",
+			css:  `@font-face { font-family: "F"; src: url(f.woff2) format("woff2"), URL(f.woff) format("woff"); }`,
+			want: []string{"f.woff2", "f.woff"},
+		},
+		{
+			name: "imports",
+			css:  `@import "reset.css"; @import url("theme.css") screen; @IMPORT 'print.css' print; @import url(fonts.css);`,
+			want: []string{"reset.css", "theme.css", "print.css", "fonts.css"},
+		},
+		{
+			name: "data URIs and fragments are skipped",
+			css:  `.a { background: url(data:image/png;base64,iVBORw0KGgo=); } .b { filter: url(#blur); } .c { background: url("DATA:image/svg+xml;utf8,<svg></svg>"); }`,
+			want: nil,
+		},
+		{
+			name: "comments are ignored",
+			css:  `/* .old { background: url(old.png); } */ .new { background: url(new.png); }`,
+			want: []string{"new.png"},
+		},
+		{
+			name: "escaped quote",
+			css:  `.a { background: url("it\"s.png"); }`,
+			want: []string{`it"s.png`},
+		},
+		{
+			name: "empty url",
+			css:  `.a { background: url(); } .b { background: url(""); }`,
+			want: nil,
+		},
+		{
+			name: "unterminated url",
+			css:  `.a { background: url(a.png`,
+			want: []string{"a.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCSSURLs(tt.css)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseCSSURLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSS(t *testing.T) {
+	config.InitConfig()
+	css := `
+	@import "../base/reset.css";
+	@font-face { src: url("../fonts/font.woff2") format("woff2"); }
+	.logo { background: url(//cdn.example.com/logo.svg); }
+	.hero { background-image: url('img/hero.jpg'); }
+	.icon { background: url(data:image/gif;base64,R0lGODlhAQABAAAAACw=); }
+	`
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/css; charset=utf-8"}},
+		Body:   io.NopCloser(bytes.NewBufferString(css)),
+	}
+	newURL := &models.URL{Raw: "https://example.com/static/css/main.css"}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+	item := models.NewItem("test", newURL, "")
+
+	if !IsCSS(newURL) {
+		t.Fatalf("IsCSS() = false, want true")
+	}
+
+	assets, err := CSS(item)
+	if err != nil {
+		t.Fatalf("CSS() error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	want := []string{
+		"https://example.com/static/base/reset.css",
+		"https://example.com/static/fonts/font.woff2",
+		"https://cdn.example.com/logo.svg",
+		"https://example.com/static/css/img/hero.jpg",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("CSS() = %q, want %q", got, want)
+	}
+}
+
+func TestHTMLAssetsStyleBlock(t *testing.T) {
+	config.InitConfig()
+	html := `
+	<html>
+		<head>
+			<style>
+				@import url("/css/extra.css");
+				body { background: url('/img/bg.png'); }
+				.grad { fill: url(#wp-gradient); }
+			</style>
+		</head>
+	</html>
+	`
+
+	resp := &http.Response{
+		Body: io.NopCloser(bytes.NewBufferString(html)),
+	}
+	newURL := &models.URL{Raw: "http://ex.com/page"}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+	item := models.NewItem("test", newURL, "")
+
+	assets, err := HTMLAssets(item)
+	if err != nil {
+		t.Errorf("HTMLAssets error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	want := []string{"http://ex.com/css/extra.css", "http://ex.com/img/bg.png"}
+	if !slices.Equal(got, want) {
+		t.Errorf("HTMLAssets() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// Number of tokens read to find the root element of a document when sniffing a feed
+const feedSniffTokens = 64
+
+// HTML void elements found unclosed in the descriptions of feeds. <link> is one in HTML,
+// but not in feeds, where it holds the link of the entry.
+var feedAutoClose = slices.DeleteFunc(slices.Clone(xml.HTMLAutoClose), func(name string) bool {
+	return name == "link"
+})
+
+func IsFeed(URL *models.URL) bool {
+	contentType := URL.GetResponse().Header.Get("Content-Type")
+	if isContentType(contentType, "application/rss+xml") || isContentType(contentType, "application/atom+xml") {
+		return true
+	}
+
+	if !isContentType(contentType, "xml") && (URL.GetMIMEType() == nil || !strings.Contains(URL.GetMIMEType().String(), "xml")) {
+		return false
+	}
+
+	defer URL.RewindBody()
+
+	return SniffFeed(URL.GetBody())
+}
+
+// SniffFeed returns true if the root element of the document is the one of an RSS, RDF (RSS 1.0) or Atom feed
+func SniffFeed(r io.Reader) bool {
+	decoder := newFeedDecoder(r)
+
+	for range feedSniffTokens {
+		tok, err := decoder.RawToken()
+		if err != nil {
+			return false
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			switch strings.ToLower(start.Name.Local) {
+			case "rss", "feed", "rdf":
+				return true
+			default:
+				return false
+			}
+		}
+	}
+
+	return false
+}
+
+// Feed extracts the links and enclosures of the entries of an RSS, RDF or Atom feed
+func Feed(URL *models.URL) (outlinks []*models.URL, err error) {
+	defer URL.RewindBody()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.extractor.Feed",
+	})
+
+	links, skipped, err := ParseFeed(URL.GetBody(), URL.GetParsed())
+	if err != nil {
+		return outlinks, err
+	}
+
+	if skipped > 0 {
+		logger.Info("skipped feed entries over the limit", "url", URL.String(), "max_entries", config.Get().FeedMaxEntries, "skipped", skipped)
+	}
+
+	for _, link := range links {
+		outlinks = append(outlinks, &models.URL{
+			Raw: link,
+		})
+	}
+
+	return outlinks, nil
+}
+
+// ParseFeed returns the links and enclosures of the entries of a feed, resolved against base if it's not nil.
+// Feeds are parsed leniently: unknown entities, unclosed tags and syntax errors don't discard the entries
+// found so far. The entries over the configured maximum are skipped and counted.
+func ParseFeed(r io.Reader, base *url.URL) (links []string, skipped int, err error) {
+	var (
+		decoder    = newFeedDecoder(r)
+		maxEntries = config.Get().FeedMaxEntries
+		entries    int
+		inEntry    bool
+		inLink     bool
+		linkText   strings.Builder
+		rawLinks   []string
+	)
+
+	add := func(link string) {
+		link = strings.TrimSpace(link)
+		if link != "" && (maxEntries <= 0 || entries <= maxEntries) {
+			rawLinks = append(rawLinks, link)
+		}
+	}
+
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			// Malformed feeds are the norm, keep what we got so far
+			break
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(tok.Name.Local)
+
+			switch {
+			case name == "item" || name == "entry":
+				inEntry = true
+				entries++
+				if maxEntries > 0 && entries > maxEntries {
+					skipped++
+				}
+			case !inEntry:
+				continue
+			case name == "link":
+				// Atom links have an href, RSS links have text content
+				href := feedAttr(tok, "href")
+				if href == "" {
+					inLink = true
+					linkText.Reset()
+					continue
+				}
+
+				rel := strings.ToLower(feedAttr(tok, "rel"))
+				if rel == "" || rel == "alternate" || rel == "enclosure" {
+					add(href)
+				}
+			case name == "enclosure":
+				add(feedAttr(tok, "url"))
+			case name == "content" && tok.Name.Space != "" && feedAttr(tok, "url") != "":
+				// Media RSS (media:content), common in podcasts and video feeds
+				add(feedAttr(tok, "url"))
+			}
+		case xml.CharData:
+			if inLink {
+				linkText.Write(tok)
+			}
+		case xml.EndElement:
+			name := strings.ToLower(tok.Name.Local)
+
+			switch name {
+			case "item", "entry":
+				inEntry = false
+			case "link":
+				if inLink {
+					add(linkText.String())
+					inLink = false
+				}
+			}
+		}
+	}
+
+	for _, rawLink := range utils.DedupeStrings(rawLinks) {
+		if base != nil {
+			resolved, err := base.Parse(rawLink)
+			if err != nil {
+				continue
+			}
+			rawLink = resolved.String()
+		}
+
+		links = append(links, rawLink)
+	}
+
+	return links, skipped, nil
+}
+
+func newFeedDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = feedAutoClose
+	decoder.Entity = xml.HTMLEntity
+	// Feeds declaring another charset are mostly ASCII compatible where it matters for links
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	return decoder
+}
+
+func feedAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
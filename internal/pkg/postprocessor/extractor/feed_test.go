@@ -0,0 +1,177 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseFeed(t *testing.T) {
+	config.InitConfig()
+
+	base, _ := url.Parse("https://blog.example.com/feed/")
+
+	tests := []struct {
+		name string
+		feed string
+		want []string
+	}{
+		{
+			name: "RSS with enclosures",
+			feed: `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+	<channel>
+		<title>Blog</title>
+		<link>https://blog.example.com/</link>
+		<item>
+			<title>First &amp; foremost &nbsp;</title>
+			<link>https://blog.example.com/2024/first</link>
+			<enclosure url="https://cdn.example.com/ep1.mp3" length="1234" type="audio/mpeg"/>
+		</item>
+		<item>
+			<title>Second</title>
+			<link>
+				/2024/second
+			</link>
+			<media:content url="https://cdn.example.com/ep2.mp4" type="video/mp4"/>
+		</item>
+	</channel>
+</rss>`,
+			want: []string{
+				"https://blog.example.com/2024/first",
+				"https://cdn.example.com/ep1.mp3",
+				"https://blog.example.com/2024/second",
+				"https://cdn.example.com/ep2.mp4",
+			},
+		},
+		{
+			name: "Atom",
+			feed: `<feed xmlns="http://www.w3.org/2005/Atom">
+	<link rel="self" href="https://blog.example.com/feed/"/>
+	<entry>
+		<link rel="alternate" href="https://blog.example.com/post-1"/>
+		<link rel="enclosure" href="/files/post-1.pdf"/>
+		<link rel="replies" href="https://blog.example.com/post-1/comments"/>
+	</entry>
+	<entry>
+		<link href="post-2"/>
+	</entry>
+</feed>`,
+			want: []string{
+				"https://blog.example.com/post-1",
+				"https://blog.example.com/files/post-1.pdf",
+				"https://blog.example.com/feed/post-2",
+			},
+		},
+		{
+			name: "RDF",
+			feed: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+	<item rdf:about="https://blog.example.com/rdf-1"><link>https://blog.example.com/rdf-1</link></item>
+</rdf:RDF>`,
+			want: []string{"https://blog.example.com/rdf-1"},
+		},
+		{
+			name: "malformed feed",
+			feed: `<rss><channel>
+		<item><link>https://blog.example.com/ok</link><description><p>unclosed<br></description></item>
+		<item><link>https://blog.example.com/broken-after</link>
+		<item><title>Garbage < & ></title>`,
+			want: []string{"https://blog.example.com/ok", "https://blog.example.com/broken-after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := ParseFeed(strings.NewReader(tt.feed), base)
+			if err != nil {
+				t.Fatalf("ParseFeed() error = %v", err)
+			}
+
+			if skipped != 0 {
+				t.Errorf("ParseFeed() skipped = %d, want 0", skipped)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseFeed() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeedMaxEntries(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxEntries int) {
+		config.Get().FeedMaxEntries = maxEntries
+	}(config.Get().FeedMaxEntries)
+	config.Get().FeedMaxEntries = 2
+
+	feed := `<rss><channel>
+		<item><link>https://example.com/1</link></item>
+		<item><link>https://example.com/2</link></item>
+		<item><link>https://example.com/3</link></item>
+		<item><link>https://example.com/4</link></item>
+	</channel></rss>`
+
+	got, skipped, err := ParseFeed(strings.NewReader(feed), nil)
+	if err != nil {
+		t.Fatalf("ParseFeed() error = %v", err)
+	}
+
+	if want := []string{"https://example.com/1", "https://example.com/2"}; !slices.Equal(got, want) {
+		t.Errorf("ParseFeed() = %q, want %q", got, want)
+	}
+
+	if skipped != 2 {
+		t.Errorf("ParseFeed() skipped = %d, want 2", skipped)
+	}
+}
+
+func TestIsFeed(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"RSS content type", "application/rss+xml; charset=utf-8", `<rss></rss>`, true},
+		{"Atom content type", "application/atom+xml", `<feed></feed>`, true},
+		{"sniffed RSS", "text/xml", `<?xml version="1.0"?><!-- comment --><rss version="2.0"><channel></channel></rss>`, true},
+		{"sniffed Atom", "application/xml", `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"></feed>`, true},
+		{"other XML", "application/xml", `<?xml version="1.0"?><urlset></urlset>`, false},
+		{"HTML", "text/html", `<html><body><rss></rss></body></html>`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{tt.contentType}},
+				Body:   io.NopCloser(bytes.NewBufferString(tt.body)),
+			}
+			URL := &models.URL{Raw: "https://example.com/feed"}
+			if err := URL.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			URL.SetResponse(resp)
+
+			if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+
+			if got := IsFeed(URL); got != tt.want {
+				t.Errorf("IsFeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
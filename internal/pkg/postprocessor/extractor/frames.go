@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+// frameOutlinks returns the src of the <iframe> and <frame> tags. The documents they embed are
+// crawled as outlinks, one hop away from the page, and go through the same host rules.
+func frameOutlinks(page *htmlPage) (rawOutlinks []string) {
+	for _, tag := range []string{"iframe", "frame"} {
+		if slices.Contains(config.Get().DisableHTMLTag, tag) {
+			continue
+		}
+
+		for _, frame := range page.find(tag) {
+			src, exists := frame.Attr("src")
+			src = strings.TrimSpace(src)
+			if !exists || src == "" || src == "about:blank" || strings.HasPrefix(src, "javascript:") {
+				continue
+			}
+
+			rawOutlinks = append(rawOutlinks, src)
+		}
+	}
+
+	return rawOutlinks
+}
+
+// inlineSrcdocFrames moves the documents of the srcdoc attribute of the <iframe> tags inside them, so that
+// their assets and outlinks are extracted with the page's and their relative URLs resolved against it,
+// like browsers do. The attribute is removed once inlined, the document is only modified once.
+func inlineSrcdocFrames(document *goquery.Document) {
+	if !srcdocInliningEnabled() {
+		return
+	}
+
+	document.Find("iframe[srcdoc]").Each(func(index int, sel *goquery.Selection) {
+		srcdoc := sel.AttrOr("srcdoc", "")
+		sel.RemoveAttr("srcdoc")
+
+		inline, err := goquery.NewDocumentFromReader(strings.NewReader(srcdoc))
+		if err != nil {
+			return
+		}
+
+		// The base of the page applies to the inlined document
+		inline.Find("base").Remove()
+
+		sel.AppendSelection(inline.Find("head, body").Children())
+	})
+}
+
+func srcdocInliningEnabled() bool {
+	return config.Get().InlineIframeSrcdoc && !slices.Contains(config.Get().DisableHTMLTag, "iframe")
+}
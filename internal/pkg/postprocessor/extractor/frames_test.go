@@ -0,0 +1,162 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newFramesTestItem(t testing.TB, rawURL, body string) *models.Item {
+	t.Helper()
+
+	resp := &http.Response{
+		Body: io.NopCloser(bytes.NewBufferString(body)),
+	}
+	newURL := &models.URL{Raw: rawURL}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	if err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	return models.NewItem("test", newURL, "")
+}
+
+func rawURLs(URLs []*models.URL) (raws []string) {
+	for _, URL := range URLs {
+		raws = append(raws, URL.Raw)
+	}
+
+	return raws
+}
+
+func TestHTMLOutlinksFrames(t *testing.T) {
+	config.InitConfig()
+
+	item := newFramesTestItem(t, "http://ex.com/page/", `
+	<html>
+		<body>
+			<iframe src="https://player.example.org/embed/1"></iframe>
+			<iframe src="widget.html"></iframe>
+			<iframe src="about:blank"></iframe>
+			<iframe></iframe>
+		</body>
+	</html>
+	`)
+
+	outlinks, err := HTMLOutlinks(item)
+	if err != nil {
+		t.Fatalf("HTMLOutlinks() error = %v", err)
+	}
+
+	got := rawURLs(outlinks)
+	for _, want := range []string{"https://player.example.org/embed/1", "http://ex.com/page/widget.html"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("expected outlink %s, got %v", want, got)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Errorf("expected 2 outlinks, got %v", got)
+	}
+}
+
+func TestHTMLOutlinksFrameset(t *testing.T) {
+	config.InitConfig()
+
+	item := newFramesTestItem(t, "http://ex.com/", `
+	<html>
+		<frameset cols="50%,50%">
+			<frame src="/left.html">
+			<frame src="/right.html">
+		</frameset>
+	</html>
+	`)
+
+	outlinks, err := HTMLOutlinks(item)
+	if err != nil {
+		t.Fatalf("HTMLOutlinks() error = %v", err)
+	}
+
+	got := rawURLs(outlinks)
+	if !slices.Equal(got, []string{"http://ex.com/left.html", "http://ex.com/right.html"}) {
+		t.Errorf("expected the frames as outlinks, got %v", got)
+	}
+}
+
+func TestHTMLOutlinksFramesDisabled(t *testing.T) {
+	config.InitConfig()
+
+	defer func(tags []string) { config.Get().DisableHTMLTag = tags }(config.Get().DisableHTMLTag)
+	config.Get().DisableHTMLTag = []string{"iframe"}
+
+	item := newFramesTestItem(t, "http://ex.com/", `<html><body><iframe src="/embed"></iframe></body></html>`)
+
+	outlinks, err := HTMLOutlinks(item)
+	if err != nil {
+		t.Fatalf("HTMLOutlinks() error = %v", err)
+	}
+
+	if len(outlinks) != 0 {
+		t.Errorf("expected no outlinks, got %v", rawURLs(outlinks))
+	}
+}
+
+func TestHTMLAssetsIframeSrcdoc(t *testing.T) {
+	config.InitConfig()
+
+	body := `
+	<html>
+		<body>
+			<iframe srcdoc="<html><head><base href='http://other.com/'></head><body><img src='img/inline.png'><a href='/inline-link'>link</a></body></html>"></iframe>
+		</body>
+	</html>
+	`
+
+	defer func(inline bool) { config.Get().InlineIframeSrcdoc = inline }(config.Get().InlineIframeSrcdoc)
+
+	config.Get().InlineIframeSrcdoc = false
+	assets, err := HTMLAssets(newFramesTestItem(t, "http://ex.com/page/", body))
+	if err != nil {
+		t.Fatalf("HTMLAssets() error = %v", err)
+	}
+
+	if slices.Contains(rawURLs(assets), "img/inline.png") {
+		t.Errorf("expected the srcdoc not to be extracted when disabled, got %v", rawURLs(assets))
+	}
+
+	config.Get().InlineIframeSrcdoc = true
+	item := newFramesTestItem(t, "http://ex.com/page/", body)
+
+	assets, err = HTMLAssets(item)
+	if err != nil {
+		t.Fatalf("HTMLAssets() error = %v", err)
+	}
+
+	if !slices.Contains(rawURLs(assets), "img/inline.png") {
+		t.Errorf("expected the asset of the srcdoc, got %v", rawURLs(assets))
+	}
+
+	// The base of the inlined document is ignored, its URLs are resolved against the page
+	if item.GetBase() != "" {
+		t.Errorf("expected the base of the srcdoc to be ignored, got %s", item.GetBase())
+	}
+
+	outlinks, err := HTMLOutlinks(item)
+	if err != nil {
+		t.Fatalf("HTMLOutlinks() error = %v", err)
+	}
+
+	if !slices.Contains(rawURLs(outlinks), "http://ex.com/inline-link") {
+		t.Errorf("expected the outlink of the srcdoc resolved against the page, got %v", rawURLs(outlinks))
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
@@ -16,7 +15,6 @@ import (
 
 var (
 	backgroundImageRegex = regexp.MustCompile(`(?:\(['"]?)(.*?)(?:['"]?\))`)
-	urlRegex             = regexp.MustCompile(`(?m)url\((.*?)\)`)
 )
 
 func IsHTML(URL *models.URL) bool {
@@ -32,14 +30,14 @@ func HTMLOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 
 	var rawOutlinks []string
 
-	// Retrieve (potentially creates it) the document from the body
-	document, err := item.GetURL().GetDocument()
+	// Read the elements of the page, the documents inlined in iframes are extracted with it
+	page, err := loadHTMLPage(item, true)
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract the base tag if it exists
-	extractBaseTag(item, document)
+	extractBaseTag(item, page)
 
 	// Match <a> tags with href, data-href, data-src, data-srcset, data-lazy-src, data-srcset, src, srcset
 	// Extract potential URLs from <a> tags using common attributes
@@ -56,9 +54,9 @@ func HTMLOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 			"to",
 		}
 
-		document.Find("a").Each(func(index int, sel *goquery.Selection) {
+		for _, a := range page.find("a") {
 			for _, key := range attrs {
-				val, exists := sel.Attr(key)
+				val, exists := a.Attr(key)
 				if !exists || val == "" {
 					continue
 				}
@@ -74,9 +72,21 @@ func HTMLOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 
 				rawOutlinks = append(rawOutlinks, val)
 			}
-		})
+		}
+	}
+
+	// Documents the page expects to be navigated to next
+	if !slices.Contains(config.Get().DisableHTMLTag, "link") {
+		for _, link := range page.find("link") {
+			if href, exists := link.Attr("href"); exists && isDocumentLink(link) {
+				rawOutlinks = append(rawOutlinks, href)
+			}
+		}
 	}
 
+	// Documents embedded in the page
+	rawOutlinks = append(rawOutlinks, frameOutlinks(page)...)
+
 	for _, rawOutlink := range rawOutlinks {
 		resolvedURL, err := resolveURL(rawOutlink, item)
 		if err != nil {
@@ -107,20 +117,23 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 		"component": "postprocessor.extractor.HTMLAssets",
 	})
 
-	var rawAssets []string
+	var (
+		rawAssets  []string
+		webSockets []*models.URL
+	)
 
-	// Retrieve (potentially creates it) the document from the body
-	document, err := item.GetURL().GetDocument()
+	// Read the elements of the page, the documents inlined in iframes are extracted with it
+	page, err := loadHTMLPage(item, true)
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract the base tag if it exists
-	extractBaseTag(item, document)
+	extractBaseTag(item, page)
 
 	// Get assets from JSON payloads in data-item values
 	// Check all elements style attributes for background-image & also data-preview
-	document.Find("[data-item], [style], [data-preview]").Each(func(index int, i *goquery.Selection) {
+	for _, i := range page.findWithAttr("data-item", "style", "data-preview") {
 		dataItem, exists := i.Attr("data-item")
 		if exists {
 			URLsFromJSON, _, err := GetURLsFromJSON(json.NewDecoder(strings.NewReader(dataItem)))
@@ -161,7 +174,7 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 				rawAssets = append(rawAssets, dataPreview)
 			}
 		}
-	})
+	}
 
 	// Try to find assets in <a> tags.. this is a bit funky
 	if !slices.Contains(config.Get().DisableHTMLTag, "a") {
@@ -185,7 +198,7 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 			"srcset",
 		}
 
-		document.Find("a").Each(func(index int, i *goquery.Selection) {
+		for _, i := range page.find("a") {
 			for _, attr := range validAssetAttributes {
 				link, exists := i.Attr(attr)
 				if exists {
@@ -194,93 +207,48 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 					}
 				}
 			}
-		})
+		}
 	}
 
 	// Extract assets on the page (images, scripts, videos..)
 	if !slices.Contains(config.Get().DisableHTMLTag, "img") {
-		document.Find("img").Each(func(index int, i *goquery.Selection) {
+		for _, i := range page.find("img") {
 			link, exists := i.Attr("src")
 			if exists {
 				rawAssets = append(rawAssets, link)
 			}
 
-			link, exists = i.Attr("data-src")
-			if exists {
-				rawAssets = append(rawAssets, link)
-			}
-
-			link, exists = i.Attr("data-lazy-src")
-			if exists {
-				rawAssets = append(rawAssets, link)
-			}
-
-			link, exists = i.Attr("data-srcset")
-			if exists {
-				links := strings.Split(link, ",")
-				for _, link := range links {
-					rawAssets = append(rawAssets, strings.Split(strings.TrimSpace(link), " ")[0])
-				}
-			}
-
 			link, exists = i.Attr("srcset")
 			if exists {
-				links := strings.Split(link, ",")
-				for _, link := range links {
-					rawAssets = append(rawAssets, strings.Split(strings.TrimSpace(link), " ")[0])
-				}
+				rawAssets = append(rawAssets, srcsetAssets(item, link)...)
 			}
-		})
+		}
 	}
 
-	var targetElements = []string{}
-	if !slices.Contains(config.Get().DisableHTMLTag, "video") {
-		targetElements = append(targetElements, "video[src]")
-	}
-	if !slices.Contains(config.Get().DisableHTMLTag, "audio") {
-		targetElements = append(targetElements, "audio[src]")
-	}
-	if len(targetElements) > 0 {
-		document.Find(strings.Join(targetElements, ", ")).Each(func(index int, i *goquery.Selection) {
-			if link, exists := i.Attr("src"); exists {
-				rawAssets = append(rawAssets, link)
-			}
-		})
-	}
+	// Videos and audios, with their posters, sources and text tracks
+	rawAssets = append(rawAssets, mediaAssets(item, page)...)
 
 	if !slices.Contains(config.Get().DisableHTMLTag, "style") {
-		document.Find("style").Each(func(index int, i *goquery.Selection) {
-			matches := urlRegex.FindAllStringSubmatch(i.Text(), -1)
-			for match := range matches {
-				matchReplacement := matches[match][1]
-				matchReplacement = strings.Replace(matchReplacement, "'", "", -1)
-				matchReplacement = strings.Replace(matchReplacement, "\"", "", -1)
-
-				// If the URL already has http (or https), we don't need add anything to it.
-				if !strings.Contains(matchReplacement, "http") {
-					matchReplacement = strings.Replace(matchReplacement, "//", "http://", -1)
-				}
-
-				if strings.HasPrefix(matchReplacement, "#wp-") {
-					continue
-				}
-
-				rawAssets = append(rawAssets, matchReplacement)
-			}
-		})
+		for _, i := range page.find("style") {
+			rawAssets = append(rawAssets, cssAssets(item, i.text)...)
+		}
 	}
 
 	if !slices.Contains(config.Get().DisableHTMLTag, "script") {
-		document.Find("script").Each(func(index int, i *goquery.Selection) {
+		var inlineScripts []string
+
+		for _, i := range page.find("script") {
 			link, exists := i.Attr("src")
 			if exists {
 				rawAssets = append(rawAssets, link)
+			} else if config.Get().JSExtraction || config.Get().WebSocketDiscovery {
+				inlineScripts = append(inlineScripts, i.text)
 			}
 
 			scriptType, exists := i.Attr("type")
 			if exists {
 				if strings.Contains(scriptType, "json") {
-					URLsFromJSON, _, err := GetURLsFromJSON(json.NewDecoder(strings.NewReader(i.Text())))
+					URLsFromJSON, _, err := GetURLsFromJSON(json.NewDecoder(strings.NewReader(i.text)))
 					if err != nil {
 						// TODO: maybe add back when https://github.com/internetarchive/Zeno/issues/147 is fixed
 						// c.Log.Debug("unable to extract URLs from JSON in script tag", "error", err, "url", URL)
@@ -291,54 +259,83 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 			}
 
 			// Apply regex on the script's HTML to extract potential assets
-			outerHTML, err := goquery.OuterHtml(i)
-			if err != nil {
-				logger.Debug("unable to extract outer HTML from script tag", "err", err, "url", item.GetURL().String(), "item", item.GetShortID())
-			} else {
-				scriptLinks := utils.DedupeStrings(LinkRegexStrict.FindAllString(outerHTML, -1))
-				for _, scriptLink := range scriptLinks {
-					if strings.HasPrefix(scriptLink, "http") {
-						// Escape URLs when unicode runes are present in the extracted URLs
-						scriptLink, err := strconv.Unquote(`"` + scriptLink + `"`)
-						if err != nil {
-							logger.Debug("unable to escape URL from JSON in script tag", "error", err, "url", item.GetURL().String(), "item", item.GetShortID())
-							continue
-						}
-						rawAssets = append(rawAssets, scriptLink)
+			scriptLinks := utils.DedupeStrings(LinkRegexStrict.FindAllString(i.outer, -1))
+			for _, scriptLink := range scriptLinks {
+				if strings.HasPrefix(scriptLink, "http") {
+					// Escape URLs when unicode runes are present in the extracted URLs
+					scriptLink, err := strconv.Unquote(`"` + scriptLink + `"`)
+					if err != nil {
+						logger.Debug("unable to escape URL from JSON in script tag", "error", err, "url", item.GetURL().String(), "item", item.GetShortID())
+						continue
 					}
+					rawAssets = append(rawAssets, scriptLink)
 				}
 			}
 
 			// Some <script> embed variable initialisation, we can strip the variable part and just scrape JSON
-			if !strings.HasPrefix(i.Text(), "{") {
-				assetsFromScriptContent, err := extractFromScriptContent(i.Text())
+			if !strings.HasPrefix(i.text, "{") {
+				assetsFromScriptContent, err := extractFromScriptContent(i.text)
 				if err != nil {
 					logger.Debug("unable to extract URLs from JSON in script tag", "error", err, "url", item.GetURL().String(), "item", item.GetShortID())
 				} else {
 					rawAssets = append(rawAssets, assetsFromScriptContent...)
 				}
 			}
-		})
+		}
+
+		// The inline scripts of the page share the same cap on the number of extracted URLs
+		if len(inlineScripts) > 0 && config.Get().JSExtraction {
+			rawAssets = append(rawAssets, jsAssets(item, item.GetURL(), strings.Join(inlineScripts, "\n"))...)
+		}
+
+		if len(inlineScripts) > 0 {
+			webSockets = webSocketHandshakes(item, strings.Join(inlineScripts, "\n"))
+		}
 	}
 
 	if !slices.Contains(config.Get().DisableHTMLTag, "link") {
-		document.Find("link").Each(func(index int, i *goquery.Selection) {
+		var hasIcon bool
+
+		for _, i := range page.find("link") {
+			if isIconLink(i) {
+				hasIcon = true
+			}
+
 			if !config.Get().CaptureAlternatePages {
 				relation, exists := i.Attr("rel")
 				if exists && relation == "alternate" {
-					return
+					continue
 				}
 			}
 
+			// Prefetched and prerendered documents are outlinks
+			if isDocumentLink(i) {
+				continue
+			}
+
+			// Resources the page declares it will need (fonts, scripts, responsive images..)
+			// are captured whatever their "as" attribute says
+			if isPreloadLink(i) {
+				rawAssets = append(rawAssets, preloadLinkAssets(item, i)...)
+				continue
+			}
+
 			link, exists := i.Attr("href")
 			if exists {
 				rawAssets = append(rawAssets, link)
 			}
-		})
+		}
+
+		// Browsers fall back to the legacy favicon location when pages don't declare an icon
+		if !hasIcon && config.Get().CaptureFavicon && item.IsSeed() && item.GetURL().GetParsed() != nil {
+			if favicon, err := item.GetURL().GetParsed().Parse("/favicon.ico"); err == nil {
+				rawAssets = append(rawAssets, favicon.String())
+			}
+		}
 	}
 
 	if !slices.Contains(config.Get().DisableHTMLTag, "meta") {
-		document.Find("meta").Each(func(index int, i *goquery.Selection) {
+		for _, i := range page.find("meta") {
 			link, exists := i.Attr("href")
 			if exists {
 				rawAssets = append(rawAssets, link)
@@ -349,11 +346,16 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 					rawAssets = append(rawAssets, link)
 				}
 			}
-		})
+		}
 	}
 
 	if !slices.Contains(config.Get().DisableHTMLTag, "source") {
-		document.Find("source").Each(func(index int, i *goquery.Selection) {
+		for _, i := range page.find("source") {
+			// The sources of videos and audios are handled with their element
+			if i.parent == "video" || i.parent == "audio" {
+				continue
+			}
+
 			link, exists := i.Attr("src")
 			if exists {
 				rawAssets = append(rawAssets, link)
@@ -361,28 +363,110 @@ func HTMLAssets(item *models.Item) (assets []*models.URL, err error) {
 
 			link, exists = i.Attr("srcset")
 			if exists {
-				links := strings.Split(link, ",")
-				for _, link := range links {
-					rawAssets = append(rawAssets, strings.Split(strings.TrimSpace(link), " ")[0])
-				}
+				rawAssets = append(rawAssets, srcsetAssets(item, link)...)
 			}
+		}
+	}
 
-			link, exists = i.Attr("data-srcset")
-			if exists {
-				links := strings.Split(link, ",")
-				for _, link := range links {
-					rawAssets = append(rawAssets, strings.Split(strings.TrimSpace(link), " ")[0])
-				}
-			}
-		})
+	// The real URLs of lazy-loaded elements, captured along with their placeholder
+	rawAssets = append(rawAssets, lazyLoadAssets(item, page)...)
+
+	// Resolve the relative assets against the <base> of the page, without one the preprocessor
+	// resolves them against the URL of the page
+	for i, rawAsset := range rawAssets {
+		if item.GetBase() == "" {
+			break
+		}
+
+		resolvedURL, err := resolveURL(rawAsset, item)
+		if err != nil {
+			logger.Debug("unable to resolve URL", "error", err, "url", item.GetURL().String(), "item", item.GetShortID())
+			continue
+		}
+
+		rawAssets[i] = resolvedURL
 	}
 
-	for _, rawAsset := range rawAssets {
+	for _, rawAsset := range utils.DedupeStrings(rawAssets) {
 		assets = append(assets, &models.URL{
 			Raw: rawAsset,
 		})
+	}
+
+	return append(assets, webSockets...), nil
+}
+
+// isIconLink returns true if the <link> declares an icon of the page, e.g. rel="icon", rel="shortcut icon"
+// or rel="apple-touch-icon"
+func isIconLink(link *htmlElement) bool {
+	relation, exists := link.Attr("rel")
+	if !exists {
+		return false
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(relation)) {
+		if rel == "icon" || rel == "apple-touch-icon" || rel == "apple-touch-icon-precomposed" || rel == "mask-icon" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPreloadLink returns true if the <link> has a preload, prefetch or modulepreload relation
+func isPreloadLink(link *htmlElement) bool {
+	relation, exists := link.Attr("rel")
+	if !exists {
+		return false
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(relation)) {
+		if rel == "preload" || rel == "prefetch" || rel == "modulepreload" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDocumentLink returns true if the <link> prerenders a document, or prefetches one: without an "as"
+// attribute, or with as="document". Prefetches of other destinations are resources of the page.
+func isDocumentLink(link *htmlElement) bool {
+	relation, exists := link.Attr("rel")
+	if !exists {
+		return false
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(relation)) {
+		switch rel {
+		case "prerender":
+			return true
+		case "prefetch":
+			destination := strings.ToLower(strings.TrimSpace(link.attrOr("as", "")))
+			if destination == "" || destination == "document" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// preloadLinkAssets returns the URLs of a preload/prefetch/modulepreload <link>, from its href
+// and imagesrcset attributes, resolved against the base of the page
+func preloadLinkAssets(item *models.Item, link *htmlElement) (assets []string) {
+	if href, exists := link.Attr("href"); exists && href != "" {
+		resolved, err := resolveURL(href, item)
+		if err != nil || resolved == "" {
+			assets = append(assets, href)
+		} else {
+			assets = append(assets, resolved)
+		}
+	}
 
+	if srcset, exists := link.Attr("imagesrcset"); exists {
+		assets = append(assets, srcsetAssets(item, srcset)...)
 	}
 
-	return assets, nil
+	return assets
 }
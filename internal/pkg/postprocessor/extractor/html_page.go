@@ -0,0 +1,241 @@
+package extractor
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/CorentinB/warc/pkg/spooledtempfile"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+	"golang.org/x/net/html"
+)
+
+// htmlElement is an element of a page that matters to the extraction of its URLs
+type htmlElement struct {
+	tag    string
+	attrs  []html.Attribute
+	text   string       // Text of the <script> and <style> elements
+	outer  string       // HTML of the <script> elements
+	parent string       // Tag of the parent element, empty at the root of the document
+	media  *htmlElement // Closest <video> or <audio> ancestor
+	framed bool         // true for the elements of the document of a srcdoc attribute
+}
+
+// Attr returns the value of the attribute, whatever its namespace like goquery does
+func (e *htmlElement) Attr(name string) (string, bool) {
+	for _, attr := range e.attrs {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+
+	return "", false
+}
+
+func (e *htmlElement) attrOr(name, value string) string {
+	if v, exists := e.Attr(name); exists {
+		return v
+	}
+
+	return value
+}
+
+// recordedElements are the elements whose attributes or content hold URLs, the other elements
+// are only recorded if they have one of the recordedAttributes
+var (
+	recordedElements = []string{
+		"a", "audio", "base", "frame", "iframe", "img", "link", "meta", "script", "source", "style", "track", "video",
+	}
+	recordedAttributes = []string{"data-item", "data-preview", "style"}
+)
+
+// htmlPage holds the elements of a page that matter to the extraction of its URLs, in document order.
+// Only these elements are kept, unlike a goquery document that holds the whole tree of the page.
+type htmlPage struct {
+	elements []*htmlElement
+}
+
+// find returns the elements with one of the tags, in document order
+func (p *htmlPage) find(tags ...string) (elements []*htmlElement) {
+	for _, element := range p.elements {
+		if slices.Contains(tags, element.tag) {
+			elements = append(elements, element)
+		}
+	}
+
+	return elements
+}
+
+// findWithAttr returns the elements with one of the attributes, in document order
+func (p *htmlPage) findWithAttr(names ...string) (elements []*htmlElement) {
+	for _, element := range p.elements {
+		for _, name := range names {
+			if _, exists := element.Attr(name); exists {
+				elements = append(elements, element)
+				break
+			}
+		}
+	}
+
+	return elements
+}
+
+// htmlPages caches the pages of the URLs being extracted, by URL, until ReleaseHTMLPage is called
+var htmlPages sync.Map
+
+// cachedHTMLPage is a page of the cache with the body it was read from, the page is read again if the
+// body of the URL was replaced
+type cachedHTMLPage struct {
+	body spooledtempfile.ReadSeekCloser
+	page *htmlPage
+}
+
+// ReleaseHTMLPage drops the page of the URL cached by the extractor, once the extraction of the URL is done
+func ReleaseHTMLPage(URL *models.URL) {
+	htmlPages.Delete(URL)
+}
+
+// loadHTMLPage returns the elements of the HTML body of the item, streamed from the body by a tokenizer.
+// With --html-goquery-extraction, they are taken from the goquery document of the body instead.
+// The body is read once, the page is cached for the URL of the item with the documents of the srcdoc
+// attribute of the <iframe> inlined, see inlineSrcdocFrames. If inlineSrcdoc is false, their elements
+// are left out of the page returned.
+func loadHTMLPage(item *models.Item, inlineSrcdoc bool) (*htmlPage, error) {
+	var page *htmlPage
+	if cached, ok := htmlPages.Load(item.GetURL()); ok && cached.(*cachedHTMLPage).body == item.GetURL().GetBody() {
+		page = cached.(*cachedHTMLPage).page
+	} else {
+		var err error
+		if page, err = readHTMLPage(item); err != nil {
+			return nil, err
+		}
+
+		htmlPages.Store(item.GetURL(), &cachedHTMLPage{body: item.GetURL().GetBody(), page: page})
+	}
+
+	if inlineSrcdoc {
+		return page, nil
+	}
+
+	return page.unframed(), nil
+}
+
+func readHTMLPage(item *models.Item) (*htmlPage, error) {
+	if config.Get().HTMLGoqueryExtraction {
+		document, err := item.GetURL().GetDocument()
+		if err != nil {
+			return nil, err
+		}
+
+		inlineSrcdocFrames(document)
+
+		return documentPage(document), nil
+	}
+
+	defer item.GetURL().RewindBody()
+
+	return scanHTML(item.GetURL().GetBody(), srcdocInliningEnabled())
+}
+
+// unframed returns the page without the elements of the documents of the srcdoc attributes
+func (p *htmlPage) unframed() *htmlPage {
+	page := &htmlPage{elements: make([]*htmlElement, 0, len(p.elements))}
+	for _, element := range p.elements {
+		if !element.framed {
+			page.elements = append(page.elements, element)
+		}
+	}
+
+	return page
+}
+
+// documentPage returns the elements of a goquery document
+func documentPage(document *goquery.Document) *htmlPage {
+	page := &htmlPage{}
+	for _, node := range document.Nodes {
+		page.walk(node, nil)
+	}
+
+	return page
+}
+
+func (p *htmlPage) walk(node *html.Node, media *htmlElement) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+
+		childMedia := media
+		if isRecordedNode(child) {
+			element := &htmlElement{tag: child.Data, attrs: child.Attr, media: media, framed: inFrame(child)}
+			if node.Type == html.ElementNode {
+				element.parent = node.Data
+			}
+
+			switch element.tag {
+			case "script":
+				element.text = nodeText(child)
+
+				var outer strings.Builder
+				if err := html.Render(&outer, child); err == nil {
+					element.outer = outer.String()
+				}
+			case "style":
+				element.text = nodeText(child)
+			case "video", "audio":
+				childMedia = element
+			}
+
+			p.elements = append(p.elements, element)
+		}
+
+		p.walk(child, childMedia)
+	}
+}
+
+// inFrame tells if the node is in an <iframe>, the parser keeps their content as text so the only
+// elements in one are the ones of its srcdoc document moved there by inlineSrcdocFrames
+func inFrame(node *html.Node) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.Type == html.ElementNode && parent.Data == "iframe" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isRecordedNode(node *html.Node) bool {
+	if slices.Contains(recordedElements, node.Data) {
+		return true
+	}
+
+	for _, attr := range node.Attr {
+		if slices.Contains(recordedAttributes, attr.Key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodeText returns the text of the node and its descendants, like goquery's Text()
+func nodeText(node *html.Node) string {
+	var text strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	return text.String()
+}
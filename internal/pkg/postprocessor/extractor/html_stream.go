@@ -0,0 +1,204 @@
+package extractor
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// voidElements have no content nor end tag
+var voidElements = []string{
+	"area", "base", "basefont", "bgsound", "br", "col", "embed", "frame", "hr", "image", "img", "input", "keygen",
+	"link", "meta", "param", "source", "track", "wbr",
+}
+
+// openElement is an element of the stack of the elements open while scanning a page
+type openElement struct {
+	tag     string
+	element *htmlElement // nil if the element isn't recorded
+	media   *htmlElement // Closest <video> or <audio> element, itself included
+	foreign bool         // true in SVG and MathML content, where there are no raw text elements
+}
+
+// scanHTML returns the elements of the page read from r, streamed by a tokenizer: the page is read once
+// and only the elements that matter to the extraction are kept. The elements are the ones the HTML parser
+// would give, except when it fixes malformed pages: the elements it drops, like a <frameset> in a <body>, and
+// the ones it reopens, like an unclosed <a>, aren't dropped nor repeated. If inlineSrcdoc is true, the documents of the srcdoc attribute of the
+// <iframe> are scanned as their content, see inlineSrcdocFrames.
+func scanHTML(r io.Reader, inlineSrcdoc bool) (*htmlPage, error) {
+	page := &htmlPage{}
+
+	if err := page.scan(r, inlineSrcdoc, nil); err != nil {
+		return nil, err
+	}
+
+	for _, element := range page.elements {
+		if element.tag == "script" {
+			element.outer += element.text + "</script>"
+		}
+	}
+
+	return page, nil
+}
+
+// scan appends the elements read from r to the page. The document of a srcdoc attribute is scanned with
+// its <iframe> as root, without its <html>, <head>, <body> and <base> elements, nor its own srcdoc documents.
+func (p *htmlPage) scan(r io.Reader, inlineSrcdoc bool, root *openElement) error {
+	tokenizer := html.NewTokenizer(r)
+
+	var (
+		stack    []openElement
+		rawAttrs [][2][]byte
+	)
+
+	if root != nil {
+		stack = append(stack, *root)
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return nil
+			}
+
+			return tokenizer.Err()
+		case html.TextToken:
+			if len(stack) == 0 {
+				continue
+			}
+
+			if top := stack[len(stack)-1]; top.element != nil && (top.tag == "script" || top.tag == "style") {
+				top.element.text += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+
+			// Close the element and the ones still open in it, the root is never closed
+			for i := len(stack) - 1; i >= 0 && (root == nil || i > 0); i-- {
+				if stack[i].tag == string(name) {
+					stack = stack[:i]
+					break
+				}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+
+			var parent openElement
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			open := openElement{tag: tagName(name), media: parent.media}
+			open.foreign = parent.foreign || open.tag == "svg" || open.tag == "math"
+
+			// The parser renames <image> to <img> in HTML content
+			if open.tag == "image" && !open.foreign {
+				open.tag = "img"
+			}
+
+			if root != nil && slices.Contains([]string{"html", "head", "body", "base"}, open.tag) {
+				continue
+			}
+
+			// The raw attributes are only valid until the next token, they are copied if the element is recorded
+			rawAttrs = rawAttrs[:0]
+			for more := hasAttr; more; {
+				var key, value []byte
+				key, value, more = tokenizer.TagAttr()
+				rawAttrs = append(rawAttrs, [2][]byte{key, value})
+			}
+
+			if isRecordedElement(open.tag, rawAttrs) {
+				attrs := scannedAttributes(rawAttrs, open.foreign)
+				element := &htmlElement{tag: open.tag, attrs: attrs, parent: parent.tag, media: parent.media, framed: root != nil}
+
+				if open.tag == "script" {
+					element.outer = html.Token{Type: html.StartTagToken, Data: open.tag, Attr: attrs}.String()
+				}
+
+				if open.tag == "video" || open.tag == "audio" {
+					open.media = element
+				}
+
+				open.element = element
+				p.elements = append(p.elements, element)
+
+				if srcdoc, exists := element.Attr("srcdoc"); exists && inlineSrcdoc && open.tag == "iframe" {
+					element.attrs = slices.DeleteFunc(slices.Clone(element.attrs), func(attr html.Attribute) bool {
+						return attr.Key == "srcdoc"
+					})
+
+					if err := p.scan(strings.NewReader(srcdoc), false, &openElement{tag: "iframe", media: open.media}); err != nil {
+						return err
+					}
+				}
+			}
+
+			if open.foreign {
+				// Like the parser, there is no raw text in foreign content (e.g. in an SVG <style>)
+				tokenizer.NextIsNotRawText()
+
+				if tokenType == html.SelfClosingTagToken {
+					continue
+				}
+			}
+
+			if slices.Contains(voidElements, open.tag) {
+				continue
+			}
+
+			stack = append(stack, open)
+		}
+	}
+}
+
+// tagName returns the tag name, without allocating for the known tags
+func tagName(name []byte) string {
+	if a := atom.Lookup(name); a != 0 {
+		return a.String()
+	}
+
+	return string(name)
+}
+
+// isRecordedElement tells if an element is recorded from its tag and its raw attributes, see recordedElements
+func isRecordedElement(tag string, rawAttrs [][2][]byte) bool {
+	if slices.Contains(recordedElements, tag) {
+		return true
+	}
+
+	for _, attr := range rawAttrs {
+		if slices.Contains(recordedAttributes, string(attr[0])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scannedAttributes returns the attributes of a tag as the parser gives them: the repeated ones are dropped
+// and the namespaced attributes of the foreign content are split
+func scannedAttributes(rawAttrs [][2][]byte, foreign bool) []html.Attribute {
+	var scanned []html.Attribute
+
+	for _, rawAttr := range rawAttrs {
+		attr := html.Attribute{Key: string(rawAttr[0]), Val: string(rawAttr[1])}
+		if foreign {
+			if namespace, key, found := strings.Cut(attr.Key, ":"); found && (namespace == "xlink" || namespace == "xml" || namespace == "xmlns") {
+				attr.Namespace, attr.Key = namespace, key
+			}
+		}
+
+		if !slices.ContainsFunc(scanned, func(a html.Attribute) bool { return a.Key == attr.Key && a.Namespace == attr.Namespace }) {
+			scanned = append(scanned, attr)
+		}
+	}
+
+	return scanned
+}
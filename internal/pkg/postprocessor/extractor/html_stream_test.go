@@ -0,0 +1,256 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+type htmlExtraction struct {
+	outlinks, assets []string
+	base, canonical  string
+	refresh          string
+}
+
+func extractHTML(t testing.TB, body string, goquery bool) (extraction htmlExtraction) {
+	t.Helper()
+
+	config.Get().HTMLGoqueryExtraction = goquery
+
+	item := newFramesTestItem(t, "https://example.com/blog/article", body)
+
+	outlinks, err := HTMLOutlinks(item)
+	if err != nil {
+		t.Fatalf("HTMLOutlinks() error = %v", err)
+	}
+
+	assets, err := HTMLAssets(item)
+	if err != nil {
+		t.Fatalf("HTMLAssets() error = %v", err)
+	}
+
+	extraction.outlinks, extraction.assets, extraction.base = rawURLs(outlinks), rawURLs(assets), item.GetBase()
+	extraction.canonical, _ = Canonical(item)
+	extraction.refresh, _, _ = MetaRefresh(item)
+
+	return extraction
+}
+
+func TestHTMLStreamingMatchesGoquery(t *testing.T) {
+	pages, err := filepath.Glob("testdata/html/*.html")
+	if err != nil || len(pages) == 0 {
+		t.Fatalf("no test pages: %v", err)
+	}
+
+	variants := []struct {
+		name               string
+		srcdoc, js         bool
+		alternate, favicon bool
+		disabledTags       []string
+	}{
+		{name: "default"},
+		{name: "srcdoc and js", srcdoc: true, js: true},
+		{name: "alternate and favicon", alternate: true, favicon: true},
+		{name: "disabled tags", disabledTags: []string{"source", "iframe", "script"}},
+	}
+
+	config.InitConfig()
+	defer func(c config.Config) {
+		config.Get().InlineIframeSrcdoc, config.Get().JSExtraction = c.InlineIframeSrcdoc, c.JSExtraction
+		config.Get().CaptureAlternatePages, config.Get().CaptureFavicon = c.CaptureAlternatePages, c.CaptureFavicon
+		config.Get().DisableHTMLTag, config.Get().HTMLGoqueryExtraction = c.DisableHTMLTag, c.HTMLGoqueryExtraction
+	}(*config.Get())
+
+	for _, page := range pages {
+		body, err := os.ReadFile(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, variant := range variants {
+			t.Run(filepath.Base(page)+"/"+variant.name, func(t *testing.T) {
+				config.Get().InlineIframeSrcdoc, config.Get().JSExtraction = variant.srcdoc, variant.js
+				config.Get().CaptureAlternatePages, config.Get().CaptureFavicon = variant.alternate, variant.favicon
+				config.Get().DisableHTMLTag = variant.disabledTags
+
+				want := extractHTML(t, string(body), true)
+				got := extractHTML(t, string(body), false)
+
+				if !slices.Equal(got.outlinks, want.outlinks) {
+					t.Errorf("outlinks differ:\nstreamed %v\ngoquery  %v", got.outlinks, want.outlinks)
+				}
+
+				if !slices.Equal(got.assets, want.assets) {
+					t.Errorf("assets differ:\nstreamed %v\ngoquery  %v", got.assets, want.assets)
+				}
+
+				if got.base != want.base || got.canonical != want.canonical || got.refresh != want.refresh {
+					t.Errorf("streamed base %q, canonical %q, refresh %q, goquery %q, %q, %q",
+						got.base, got.canonical, got.refresh, want.base, want.canonical, want.refresh)
+				}
+
+				if len(got.outlinks) == 0 && len(got.assets) == 0 {
+					t.Errorf("nothing extracted")
+				}
+			})
+		}
+	}
+}
+
+func TestScanHTMLElements(t *testing.T) {
+	page, err := scanHTML(strings.NewReader(`
+		<video><div><source src="a.webm"></div><track src="a.vtt"></video>
+		<source src="b.mp3">
+		<script src="app.js" SRC="other.js">var x = "<a href='/not-an-element'>";</script>
+		<svg><style>.c { background: url(svg.png) }</style><a xlink:href="/svg"></a></svg>
+		<p style="color: red">text</p>`), false)
+	if err != nil {
+		t.Fatalf("scanHTML() error = %v", err)
+	}
+
+	var tags []string
+	for _, element := range page.elements {
+		tags = append(tags, element.tag)
+	}
+
+	if want := []string{"video", "source", "track", "source", "script", "style", "a", "p"}; !slices.Equal(tags, want) {
+		t.Fatalf("scanHTML() elements = %v, want %v", tags, want)
+	}
+
+	video := page.elements[0]
+	if page.elements[1].media != video || page.elements[2].media != video || page.elements[3].media != nil {
+		t.Errorf("the sources and tracks of the video aren't attached to it")
+	}
+
+	if page.elements[1].parent != "div" || page.elements[3].parent != "" {
+		t.Errorf("source parents = %q, %q", page.elements[1].parent, page.elements[3].parent)
+	}
+
+	script := page.elements[4]
+	if src, _ := script.Attr("src"); src != "app.js" || len(script.attrs) != 1 {
+		t.Errorf("script src = %q with %d attributes, want the first one only", src, len(script.attrs))
+	}
+
+	if script.text != `var x = "<a href='/not-an-element'>";` || script.outer != `<script src="app.js">`+script.text+`</script>` {
+		t.Errorf("script text = %q, outer = %q", script.text, script.outer)
+	}
+
+	if page.elements[5].text != ".c { background: url(svg.png) }" {
+		t.Errorf("svg style text = %q", page.elements[5].text)
+	}
+
+	if href, _ := page.elements[6].Attr("href"); href != "/svg" {
+		t.Errorf("svg link href = %q", href)
+	}
+}
+
+// BenchmarkHTMLExtraction extracts the outlinks and assets of a large page, streamed and from a goquery document
+func BenchmarkHTMLExtraction(b *testing.B) {
+	config.InitConfig()
+	defer func() { config.Get().HTMLGoqueryExtraction = false }()
+
+	article, err := os.ReadFile("testdata/html/article.html")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// A page of about 2 MB
+	body := "<html><body>" + strings.Repeat(string(article), 800) + "</body></html>"
+
+	for _, goquery := range []bool{false, true} {
+		name := "streamed"
+		if goquery {
+			name = "goquery"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			config.Get().HTMLGoqueryExtraction = goquery
+			item := newFramesTestItem(b, "https://example.com/blog/article", body)
+
+			// The memory held while the page is extracted: its elements, and its document with goquery
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+
+			page, err := loadHTMLPage(item, true)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(page)
+
+			held := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				item.GetURL().SetDocument(nil)
+
+				if _, err := HTMLOutlinks(item); err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err := HTMLAssets(item); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			// Reported after the loop, ResetTimer drops the metrics
+			b.ReportMetric(float64(held), "page-B")
+		})
+	}
+}
+
+func TestLoadHTMLPageCached(t *testing.T) {
+	config.InitConfig()
+	defer func(inline, goquery bool) {
+		config.Get().InlineIframeSrcdoc, config.Get().HTMLGoqueryExtraction = inline, goquery
+	}(config.Get().InlineIframeSrcdoc, config.Get().HTMLGoqueryExtraction)
+
+	config.Get().InlineIframeSrcdoc = true
+
+	body := `<link rel="canonical" href="/page"><iframe srcdoc="<link rel='canonical' href='/framed'>"></iframe>`
+
+	for _, goquery := range []bool{false, true} {
+		config.Get().HTMLGoqueryExtraction = goquery
+
+		item := newFramesTestItem(t, "https://example.com/", body)
+
+		page, err := loadHTMLPage(item, true)
+		if err != nil {
+			t.Fatalf("loadHTMLPage() error = %v", err)
+		}
+
+		if again, _ := loadHTMLPage(item, true); again != page {
+			t.Errorf("goquery %v: expected the page to be read once", goquery)
+		}
+
+		if links := len(page.find("link")); links != 2 {
+			t.Errorf("goquery %v: expected the link of the srcdoc in the page, got %d links", goquery, links)
+		}
+
+		unframed, _ := loadHTMLPage(item, false)
+		if links := unframed.find("link"); len(links) != 1 || links[0].attrOr("href", "") != "/page" {
+			t.Errorf("goquery %v: expected only the link of the page without the srcdoc", goquery)
+		}
+
+		if canonical, _ := Canonical(item); canonical != "https://example.com/page" {
+			t.Errorf("goquery %v: expected the canonical URL of the page, got %q", goquery, canonical)
+		}
+
+		// The page is read again once released
+		ReleaseHTMLPage(item.GetURL())
+		if _, cached := htmlPages.Load(item.GetURL()); cached {
+			t.Errorf("goquery %v: expected the page to be released", goquery)
+		}
+	}
+}
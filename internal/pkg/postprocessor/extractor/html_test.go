@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/internetarchive/Zeno/internal/pkg/archiver"
@@ -31,7 +32,7 @@ func TestHTMLOutlinks(t *testing.T) {
 	}
 	newURL := &models.URL{Raw: "http://ex.com"}
 	newURL.SetResponse(resp)
-	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir())
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
 	if err != nil {
 		t.Errorf("ProcessBody() error = %v", err)
 	}
@@ -65,7 +66,7 @@ func TestHTMLAssetsAudioVideo(t *testing.T) {
 	}
 	newURL := &models.URL{Raw: "http://ex.com"}
 	newURL.SetResponse(resp)
-	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir())
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
 	if err != nil {
 		t.Errorf("ProcessBody() error = %v", err)
 	}
@@ -102,7 +103,7 @@ func TestHTMLAssetsAttributes(t *testing.T) {
 	}
 	newURL := &models.URL{Raw: "http://ex.com"}
 	newURL.SetResponse(resp)
-	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir())
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
 	if err != nil {
 		t.Errorf("ProcessBody() error = %v", err)
 	}
@@ -116,3 +117,197 @@ func TestHTMLAssetsAttributes(t *testing.T) {
 		t.Errorf("We couldn't extract all [data-item], [style], [data-preview] attribute assets. %d", len(assets))
 	}
 }
+
+func TestHTMLAssetsPreloadLinks(t *testing.T) {
+	config.InitConfig()
+	html := `
+	<html>
+		<head>
+			<base href="http://cdn.ex.com/static/">
+			<link rel="preload" href="fonts/main.woff2" as="font" crossorigin>
+			<link rel="modulepreload" href="/js/app.mjs">
+			<link rel="prefetch" href="http://ex.com/next.json" as="fetch">
+			<link rel="preload" as="image" imagesrcset="hero-480.jpg 480w, hero-960.jpg 960w" imagesizes="100vw">
+		</head>
+		<body>
+			<img src="http://cdn.ex.com/static/fonts/main.woff2">
+		</body>
+	</html>
+	`
+
+	resp := &http.Response{
+		Body: io.NopCloser(bytes.NewBufferString(html)),
+	}
+	newURL := &models.URL{Raw: "http://ex.com/page"}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+	item := models.NewItem("test", newURL, "")
+
+	assets, err := HTMLAssets(item)
+	if err != nil {
+		t.Errorf("HTMLAssets error = %v", err)
+	}
+
+	expected := []string{
+		"http://cdn.ex.com/static/fonts/main.woff2",
+		"http://cdn.ex.com/js/app.mjs",
+		"http://ex.com/next.json",
+		"http://cdn.ex.com/static/hero-480.jpg",
+		"http://cdn.ex.com/static/hero-960.jpg",
+	}
+
+	found := make(map[string]int)
+	for _, asset := range assets {
+		found[asset.Raw]++
+	}
+
+	for _, URL := range expected {
+		if found[URL] != 1 {
+			t.Errorf("expected %s to be extracted once, found %d times", URL, found[URL])
+		}
+	}
+}
+
+func TestHTMLPreloadDocumentLinks(t *testing.T) {
+	config.InitConfig()
+	html := `
+	<html>
+		<head>
+			<link rel="preload" href="/fonts/only-here.woff2" as="font" type="font/woff2" crossorigin="anonymous">
+			<link rel="prefetch" href="/articles/next">
+			<link rel="prefetch" href="/articles/other" as="document">
+			<link rel="prerender" href="/articles/popular">
+			<link rel="prefetch" href="/js/chunk.js" as="script">
+		</head>
+		<body><p>No other reference to the font or the articles</p></body>
+	</html>
+	`
+
+	newItem := func() *models.Item {
+		resp := &http.Response{
+			Body: io.NopCloser(bytes.NewBufferString(html)),
+		}
+		newURL := &models.URL{Raw: "http://ex.com/page"}
+		if err := newURL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		newURL.SetResponse(resp)
+		if err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		return models.NewItem("test", newURL, "")
+	}
+
+	assets, err := HTMLAssets(newItem())
+	if err != nil {
+		t.Fatalf("HTMLAssets error = %v", err)
+	}
+
+	outlinks, err := HTMLOutlinks(newItem())
+	if err != nil {
+		t.Fatalf("HTMLOutlinks error = %v", err)
+	}
+
+	var gotAssets, gotOutlinks []string
+	for _, asset := range assets {
+		gotAssets = append(gotAssets, asset.Raw)
+	}
+	for _, outlink := range outlinks {
+		gotOutlinks = append(gotOutlinks, outlink.Raw)
+	}
+
+	wantAssets := []string{"http://ex.com/fonts/only-here.woff2", "http://ex.com/js/chunk.js"}
+	wantOutlinks := []string{"http://ex.com/articles/next", "http://ex.com/articles/other", "http://ex.com/articles/popular"}
+
+	if !slices.Equal(gotAssets, wantAssets) {
+		t.Errorf("HTMLAssets() = %q, want %q", gotAssets, wantAssets)
+	}
+
+	if !slices.Equal(gotOutlinks, wantOutlinks) {
+		t.Errorf("HTMLOutlinks() = %q, want %q", gotOutlinks, wantOutlinks)
+	}
+}
+
+func TestHTMLBaseResolution(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		name        string
+		base        string
+		wantOutlink string
+		wantAsset   string
+	}{
+		{
+			name:        "absolute base",
+			base:        `<base href="https://cdn.example.org/app/">`,
+			wantOutlink: "https://cdn.example.org/app/docs/intro.html",
+			wantAsset:   "https://cdn.example.org/app/img/logo.png",
+		},
+		{
+			name:        "relative base",
+			base:        `<base href="../app/">`,
+			wantOutlink: "http://ex.com/app/docs/intro.html",
+			wantAsset:   "http://ex.com/app/img/logo.png",
+		},
+		{
+			name:        "protocol-relative base",
+			base:        `<base href="//cdn.example.org/app/">`,
+			wantOutlink: "http://cdn.example.org/app/docs/intro.html",
+			wantAsset:   "http://cdn.example.org/app/img/logo.png",
+		},
+		{
+			name:        "malformed base",
+			base:        `<base href="http://[::1/app/">`,
+			wantOutlink: "http://ex.com/blog/docs/intro.html",
+			// Resolved against the URL of the page by the preprocessor
+			wantAsset: "img/logo.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `<html><head>` + tt.base + `<base href="https://ignored.example.org/"></head>` +
+				`<body><a href="docs/intro.html">Intro</a><img src="img/logo.png"></body></html>`
+
+			newURL := &models.URL{Raw: "http://ex.com/blog/post"}
+			if err := newURL.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			newURL.SetResponse(&http.Response{Body: io.NopCloser(bytes.NewBufferString(body))})
+			if err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+			item := models.NewItem("test", newURL, "")
+
+			outlinks, err := HTMLOutlinks(item)
+			if err != nil {
+				t.Fatalf("HTMLOutlinks error = %v", err)
+			}
+
+			if len(outlinks) != 1 || outlinks[0].Raw != tt.wantOutlink {
+				t.Errorf("HTMLOutlinks() = %d outlinks, want %s", len(outlinks), tt.wantOutlink)
+			}
+
+			assets, err := HTMLAssets(item)
+			if err != nil {
+				t.Fatalf("HTMLAssets error = %v", err)
+			}
+
+			var rawAssets []string
+			for _, asset := range assets {
+				rawAssets = append(rawAssets, asset.Raw)
+			}
+
+			if !slices.Contains(rawAssets, tt.wantAsset) {
+				t.Errorf("HTMLAssets() = %v, want %s", rawAssets, tt.wantAsset)
+			}
+		})
+	}
+}
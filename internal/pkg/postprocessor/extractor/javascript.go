@@ -0,0 +1,185 @@
+package extractor
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func IsJS(URL *models.URL) bool {
+	contentType := URL.GetResponse().Header.Get("Content-Type")
+	return isContentType(contentType, "javascript") || isContentType(contentType, "ecmascript")
+}
+
+// JS extracts the URLs found in the string literals of a script, and the handshakes of its WebSocket endpoints
+func JS(item *models.Item) (assets []*models.URL, err error) {
+	defer item.GetURL().RewindBody()
+
+	body, err := io.ReadAll(item.GetURL().GetBody())
+	if err != nil {
+		return assets, err
+	}
+
+	// The URLs of a script are scoped to the page that included it
+	scope := item.GetURL()
+	if item.GetParent() != nil {
+		scope = item.GetParent().GetURL()
+	}
+
+	if config.Get().JSExtraction {
+		for _, rawAsset := range jsAssets(item, scope, string(body)) {
+			assets = append(assets, &models.URL{
+				Raw: rawAsset,
+			})
+		}
+	}
+
+	assets = append(assets, webSocketHandshakes(item, string(body))...)
+
+	return assets, nil
+}
+
+// jsAssets returns the URLs found in the string literals of a script, resolved against the base of the item.
+// If configured, only the URLs on the same host as scope are kept. The number of URLs is capped.
+func jsAssets(item *models.Item, scope *models.URL, js string) (assets []string) {
+	maxURLs := config.Get().JSExtractionMaxURLs
+
+	for _, candidate := range parseJSStringURLs(js) {
+		if maxURLs > 0 && len(assets) >= maxURLs {
+			break
+		}
+
+		resolved, err := resolveURL(candidate, item)
+		if err != nil || resolved == "" {
+			continue
+		}
+
+		if config.Get().JSExtractionSameHost && !sameHost(resolved, scope) {
+			continue
+		}
+
+		assets = append(assets, resolved)
+	}
+
+	return assets
+}
+
+func sameHost(rawURL string, scope *models.URL) bool {
+	if scope == nil || scope.GetParsed() == nil {
+		return false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(parsed.Hostname(), scope.GetParsed().Hostname())
+}
+
+// parseJSStringURLs returns the string literals of a script that look like an absolute URL or a root-relative path
+func parseJSStringURLs(js string) (URLs []string) {
+	return parseJSStrings(js, jsStringURL)
+}
+
+// parseJSStrings tokenizes a script just enough to find its string literals (single, double quoted
+// and template literals), and returns the values match returns for them. Comments are skipped.
+func parseJSStrings(js string, match func(literal string) (string, bool)) (values []string) {
+	for i := 0; i < len(js); {
+		c := js[i]
+
+		switch {
+		case c == '/' && i+1 < len(js) && js[i+1] == '/':
+			// Line comment
+			end := strings.IndexByte(js[i:], '\n')
+			if end == -1 {
+				return values
+			}
+			i += end + 1
+		case c == '/' && i+1 < len(js) && js[i+1] == '*':
+			// Block comment
+			end := strings.Index(js[i+2:], "*/")
+			if end == -1 {
+				return values
+			}
+			i += 2 + end + 2
+		case c == '"' || c == '\'' || c == '`':
+			literal, end := readJSString(js, i)
+			if value, ok := match(literal); ok {
+				values = append(values, value)
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+
+	return values
+}
+
+// readJSString returns the raw content of the string literal starting at i and the position after it
+func readJSString(js string, i int) (literal string, end int) {
+	quote := js[i]
+	start := i + 1
+
+	for i = start; i < len(js); i++ {
+		switch js[i] {
+		case '\\':
+			i++
+		case '\n':
+			// Only template literals can span over multiple lines
+			if quote != '`' {
+				return js[start:i], i
+			}
+		case quote:
+			return js[start:i], i + 1
+		}
+	}
+
+	return js[start:], len(js)
+}
+
+// jsStringURL returns the unescaped URL contained in a string literal, if it looks like one.
+// Templated strings (containing ${, %s or {{) are ignored as they aren't usable URLs.
+func jsStringURL(literal string) (string, bool) {
+	literal, ok := unescapeJSString(literal)
+	if !ok {
+		return "", false
+	}
+
+	if isAbsoluteURL(literal) || isRootRelativePath(literal) {
+		return literal, true
+	}
+
+	return "", false
+}
+
+// unescapeJSString returns the value of a string literal that may contain a URL, it's false for
+// the literals too short or too long, templated or with escapes Go doesn't know about
+func unescapeJSString(literal string) (string, bool) {
+	if len(literal) < 2 || len(literal) > 2048 {
+		return "", false
+	}
+
+	if isTemplatedString(literal) {
+		return "", false
+	}
+
+	if strings.Contains(literal, `\`) {
+		// Unescape sequences like \/ or \u002F
+		replacer := strings.NewReplacer(`\/`, `/`, `\'`, `'`, "\\`", "`")
+
+		unquoted, err := strconv.Unquote(`"` + replacer.Replace(literal) + `"`)
+		if err != nil {
+			return "", false
+		}
+
+		literal = unquoted
+	}
+
+	return literal, true
+}
@@ -0,0 +1,151 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseJSStringURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		js   string
+		want []string
+	}{
+		{
+			name: "fetch calls",
+			js:   `fetch("/api/v1/items?page=2").then(r => r.json()); fetch('https://api.example.com/feed')`,
+			want: []string{"/api/v1/items?page=2", "https://api.example.com/feed"},
+		},
+		{
+			name: "template literal without substitution",
+			js:   "const url = `/static/app.js`;",
+			want: []string{"/static/app.js"},
+		},
+		{
+			name: "templated strings are skipped",
+			js:   "fetch(`/api/items/${id}`); var a = '/user/%s'; var b = \"/tpl/{{name}}\";",
+			want: nil,
+		},
+		{
+			name: "escaped slashes",
+			js:   `var cfg = {"ajaxurl":"http:\/\/example.com\/wp-admin\/admin-ajax.php","cdn":"//cdn.example.com/lib.js"};`,
+			want: []string{"http://example.com/wp-admin/admin-ajax.php", "//cdn.example.com/lib.js"},
+		},
+		{
+			name: "comments are skipped",
+			js:   "// fetch('/commented/line')\n/* fetch('/commented/block') */ fetch('/live');",
+			want: []string{"/live"},
+		},
+		{
+			name: "URLs in comments are not mistaken for comments inside strings",
+			js:   `var home = "https://example.com/"; // home page`,
+			want: []string{"https://example.com/"},
+		},
+		{
+			name: "strings that aren't URLs",
+			js:   `var a = "hello world", b = "/", c = "//", d = "a/b/c", e = "/ spaced path", f = 'text/html', g = "mailto:a@b.c";`,
+			want: nil,
+		},
+		{
+			name: "escaped quote inside a string",
+			js:   `var a = 'it\'s', b = "/path/to/file.json";`,
+			want: []string{"/path/to/file.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseJSStringURLs(tt.js)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseJSStringURLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLAssetsInlineScripts(t *testing.T) {
+	config.InitConfig()
+
+	html := `
+	<html>
+		<head>
+			<script src="/js/external.js"></script>
+			<script>
+				fetch("/api/feed.json");
+				var img = '//cdn.other.com/img.png';
+				var tpl = ` + "`/items/${id}`" + `;
+			</script>
+		</head>
+	</html>
+	`
+
+	extract := func() []string {
+		resp := &http.Response{
+			Body: io.NopCloser(bytes.NewBufferString(html)),
+		}
+		newURL := &models.URL{Raw: "http://ex.com/page"}
+		if err := newURL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		newURL.SetResponse(resp)
+		err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+		if err != nil {
+			t.Errorf("ProcessBody() error = %v", err)
+		}
+		item := models.NewItem("test", newURL, "")
+
+		assets, err := HTMLAssets(item)
+		if err != nil {
+			t.Errorf("HTMLAssets error = %v", err)
+		}
+
+		var got []string
+		for _, asset := range assets {
+			got = append(got, asset.Raw)
+		}
+
+		return got
+	}
+
+	defer func(extraction, sameHost bool, maxURLs int) {
+		config.Get().JSExtraction = extraction
+		config.Get().JSExtractionSameHost = sameHost
+		config.Get().JSExtractionMaxURLs = maxURLs
+	}(config.Get().JSExtraction, config.Get().JSExtractionSameHost, config.Get().JSExtractionMaxURLs)
+
+	var (
+		feed = "http://ex.com/api/feed.json"
+		img  = "http://cdn.other.com/img.png"
+	)
+
+	config.Get().JSExtraction = false
+	if got := extract(); slices.Contains(got, feed) || slices.Contains(got, img) {
+		t.Errorf("with extraction disabled, got %q", got)
+	}
+
+	config.Get().JSExtraction = true
+	config.Get().JSExtractionSameHost = false
+	config.Get().JSExtractionMaxURLs = 0
+	if got := extract(); !slices.Contains(got, feed) || !slices.Contains(got, img) {
+		t.Errorf("with extraction enabled, got %q", got)
+	}
+
+	config.Get().JSExtractionSameHost = true
+	if got := extract(); !slices.Contains(got, feed) || slices.Contains(got, img) {
+		t.Errorf("with same host extraction, got %q", got)
+	}
+
+	config.Get().JSExtractionSameHost = false
+	config.Get().JSExtractionMaxURLs = 1
+	if got := extract(); !slices.Contains(got, feed) || slices.Contains(got, img) {
+		t.Errorf("with a cap of 1 URL, got %q", got)
+	}
+}
@@ -12,14 +12,30 @@ func IsJSON(URL *models.URL) bool {
 	return isContentType(URL.GetResponse().Header.Get("Content-Type"), "json") || strings.Contains(URL.GetMIMEType().String(), "json")
 }
 
+// JSON extracts the URLs of a JSON document: absolute URLs, and root-relative paths resolved against the document's URL
 func JSON(URL *models.URL) (assets, outlinks []*models.URL, err error) {
 	defer URL.RewindBody()
 
-	rawAssets, rawOutlinks, err := GetURLsFromJSON(json.NewDecoder(URL.GetBody()))
-	if err != nil {
+	var data interface{}
+	if err := json.NewDecoder(URL.GetBody()).Decode(&data); err != nil {
 		return nil, nil, err
 	}
 
+	walker := &jsonWalker{paths: URL.GetParsed() != nil}
+	walker.walk(data, 0)
+
+	links := walker.links
+	for _, path := range walker.rootRelative {
+		resolved, err := URL.GetParsed().Parse(path)
+		if err != nil {
+			continue
+		}
+
+		links = append(links, resolved.String())
+	}
+
+	rawAssets, rawOutlinks := splitAssetsOutlinks(links)
+
 	for _, rawAsset := range rawAssets {
 		assets = append(assets, &models.URL{Raw: rawAsset})
 	}
@@ -39,10 +55,16 @@ func GetURLsFromJSON(decoder *json.Decoder) (assets, outlinks []string, err erro
 		return nil, nil, err
 	}
 
-	links := make([]string, 0)
-	findURLs(data, &links)
+	walker := new(jsonWalker)
+	walker.walk(data, 0)
+
+	assets, outlinks = splitAssetsOutlinks(walker.links)
+
+	return assets, outlinks, nil
+}
 
-	// We only consider as assets the URLs in which we can find a file extension
+// splitAssetsOutlinks considers as assets the URLs in which we can find a file extension, and the others as outlinks
+func splitAssetsOutlinks(links []string) (assets, outlinks []string) {
 	for _, link := range links {
 		if hasFileExtension(link) {
 			assets = append(assets, link)
@@ -51,7 +73,7 @@ func GetURLsFromJSON(decoder *json.Decoder) (assets, outlinks []string, err erro
 		}
 	}
 
-	return assets, outlinks, nil
+	return assets, outlinks
 }
 
 func isLikelyJSON(str string) bool {
@@ -64,26 +86,55 @@ func isLikelyJSON(str string) bool {
 	return ((str[0] == '{' && str[len(str)-1] == '}') || (str[0] == '[' && str[len(str)-1] == ']')) && strings.Contains(str, `"`)
 }
 
-func findURLs(data interface{}, links *[]string) {
+const (
+	// maxJSONDepth is the nesting level after which the values of a JSON document aren't looked at
+	maxJSONDepth = 64
+	// maxJSONNodes is the number of values of a JSON document after which the walk stops
+	maxJSONNodes = 100000
+)
+
+// jsonWalker collects the URLs found in the string values of a decoded JSON document,
+// within depth and node count limits to not get stuck on pathological documents
+type jsonWalker struct {
+	// paths enables the collection of root-relative paths, that need a base URL to be resolved
+	paths bool
+
+	nodes        int
+	links        []string
+	rootRelative []string
+}
+
+func (w *jsonWalker) walk(data interface{}, depth int) {
+	if depth > maxJSONDepth || w.nodes >= maxJSONNodes {
+		return
+	}
+	w.nodes++
+
 	switch v := data.(type) {
 	case string:
+		if isTemplatedString(v) {
+			return
+		}
+
 		if isValidURL(v) {
-			*links = append(*links, v)
+			w.links = append(w.links, v)
+		} else if w.paths && isRootRelativePath(v) {
+			w.rootRelative = append(w.rootRelative, v)
 		} else if isLikelyJSON(v) {
 			// handle JSON in JSON
 			var jsonstringdata interface{}
 			err := json.Unmarshal([]byte(v), &jsonstringdata)
 			if err == nil {
-				findURLs(jsonstringdata, links)
+				w.walk(jsonstringdata, depth+1)
 			}
 		}
 	case []interface{}:
 		for _, element := range v {
-			findURLs(element, links)
+			w.walk(element, depth+1)
 		}
 	case map[string]interface{}:
 		for _, value := range v {
-			findURLs(value, links)
+			w.walk(value, depth+1)
 		}
 	}
 }
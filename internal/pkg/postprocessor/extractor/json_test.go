@@ -86,7 +86,7 @@ func TestJSON(t *testing.T) {
 			var URL = new(models.URL)
 			URL.SetResponse(resp)
 
-			err := archiver.ProcessBody(URL, false, false, 0, os.TempDir())
+			err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
 			if err != nil {
 				t.Errorf("ProcessBody() error = %v", err)
 			}
@@ -114,3 +114,87 @@ func TestJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONRootRelativePaths(t *testing.T) {
+	body := `{
+		"items": [
+			{"thumbnail": "/media/thumb/1.jpg", "permalink": "/posts/1"},
+			{"thumbnail": "/media/thumb/2.jpg", "permalink": "https://other.example.com/posts/2"}
+		],
+		"template": "/posts/${id}",
+		"root": "/"
+	}`
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/vnd.api+json"}},
+		Body:   io.NopCloser(bytes.NewBufferString(body)),
+	}
+
+	URL := &models.URL{Raw: "https://example.com/api/v1/feed"}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+
+	if !IsJSON(URL) {
+		t.Fatalf("IsJSON() = false, want true")
+	}
+
+	assets, outlinks, err := JSON(URL)
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	sortURLs(assets)
+	sortURLs(outlinks)
+
+	wantAssets := []string{"https://example.com/media/thumb/1.jpg", "https://example.com/media/thumb/2.jpg"}
+	wantOutlinks := []string{"https://example.com/posts/1", "https://other.example.com/posts/2"}
+
+	if len(assets) != len(wantAssets) || len(outlinks) != len(wantOutlinks) {
+		t.Fatalf("JSON() returned %d assets and %d outlinks, want %d and %d", len(assets), len(outlinks), len(wantAssets), len(wantOutlinks))
+	}
+
+	for i := range assets {
+		if assets[i].Raw != wantAssets[i] {
+			t.Errorf("Expected asset %s, got %s", wantAssets[i], assets[i].Raw)
+		}
+	}
+
+	for i := range outlinks {
+		if outlinks[i].Raw != wantOutlinks[i] {
+			t.Errorf("Expected outlink %s, got %s", wantOutlinks[i], outlinks[i].Raw)
+		}
+	}
+}
+
+func TestJSONWalkerLimits(t *testing.T) {
+	// A document nested deeper than the limit
+	var deep interface{} = "https://example.com/deep"
+	for range maxJSONDepth + 1 {
+		deep = []interface{}{deep}
+	}
+
+	walker := new(jsonWalker)
+	walker.walk(deep, 0)
+	if len(walker.links) != 0 {
+		t.Errorf("expected no URL past the depth limit, got %v", walker.links)
+	}
+
+	// A document with more values than the limit
+	wide := make([]interface{}, maxJSONNodes+10)
+	for i := range wide {
+		wide[i] = "https://example.com/wide"
+	}
+
+	walker = new(jsonWalker)
+	walker.walk(wide, 0)
+	if len(walker.links) != maxJSONNodes-1 {
+		t.Errorf("expected %d URLs with the node limit, got %d", maxJSONNodes-1, len(walker.links))
+	}
+}
@@ -0,0 +1,68 @@
+package extractor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// defaultLazyLoadAttributes are the attributes read if none are configured
+var defaultLazyLoadAttributes = []string{
+	"data-src",
+	"data-srcset",
+	"data-original",
+	"data-lazy-src",
+	"data-lazy-srcset",
+	"data-lazy",
+	"data-hi-res-src",
+}
+
+// Elements whose lazy-loading attributes are read
+var lazyLoadElements = []string{"img", "source", "iframe", "video"}
+
+// lazyLoadAssets returns the URLs held by the lazy-loading attributes of the img, source, iframe and video
+// elements, where lazy-loading scripts keep the real URL while src points at a placeholder.
+// Attributes named like *srcset are parsed as srcset. The URLs are resolved against the base of the page.
+func lazyLoadAssets(item *models.Item, page *htmlPage) (assets []string) {
+	attributes := config.Get().LazyLoadAttributes
+	if len(attributes) == 0 {
+		attributes = defaultLazyLoadAttributes
+	}
+
+	var elements []string
+	for _, element := range lazyLoadElements {
+		if !slices.Contains(config.Get().DisableHTMLTag, element) {
+			elements = append(elements, element)
+		}
+	}
+
+	if len(elements) == 0 {
+		return assets
+	}
+
+	for _, element := range page.find(elements...) {
+		for _, attribute := range attributes {
+			value, exists := element.Attr(attribute)
+			value = strings.TrimSpace(value)
+			if !exists || value == "" || hasPrefixFold(value, "data:") {
+				continue
+			}
+
+			if strings.HasSuffix(strings.ToLower(attribute), "srcset") {
+				assets = append(assets, srcsetAssets(item, value)...)
+				continue
+			}
+
+			resolved, err := resolveURL(value, item)
+			if err != nil || resolved == "" {
+				continue
+			}
+
+			assets = append(assets, resolved)
+		}
+	}
+
+	return assets
+}
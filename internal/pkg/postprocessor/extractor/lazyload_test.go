@@ -0,0 +1,92 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestHTMLAssetsLazyLoad(t *testing.T) {
+	config.InitConfig()
+
+	html := `
+	<html>
+		<body>
+			<img src="/img/placeholder.gif" data-src="/img/photo.jpg" loading="lazy">
+			<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-original="photo-2.jpg">
+			<img data-lazy-srcset="/img/small.jpg 1x, /img/large.jpg 2x">
+			<picture><source data-srcset="/img/pic.webp" type="image/webp"></picture>
+			<iframe data-src="https://player.example.com/embed/1"></iframe>
+			<video data-src="/media/clip.mp4" data-custom="/media/custom.mp4"></video>
+		</body>
+	</html>
+	`
+
+	extract := func() []string {
+		resp := &http.Response{
+			Body: io.NopCloser(bytes.NewBufferString(html)),
+		}
+		newURL := &models.URL{Raw: "http://ex.com/gallery/"}
+		if err := newURL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		newURL.SetResponse(resp)
+		err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+		if err != nil {
+			t.Errorf("ProcessBody() error = %v", err)
+		}
+		item := models.NewItem("test", newURL, "")
+
+		assets, err := HTMLAssets(item)
+		if err != nil {
+			t.Errorf("HTMLAssets error = %v", err)
+		}
+
+		var got []string
+		for _, asset := range assets {
+			got = append(got, asset.Raw)
+		}
+
+		return got
+	}
+
+	defer func(attributes []string) {
+		config.Get().LazyLoadAttributes = attributes
+	}(config.Get().LazyLoadAttributes)
+
+	// Default attributes, the placeholders are captured as well
+	config.Get().LazyLoadAttributes = nil
+	got := extract()
+	for _, want := range []string{
+		"/img/placeholder.gif",
+		"http://ex.com/img/photo.jpg",
+		"http://ex.com/gallery/photo-2.jpg",
+		"http://ex.com/img/small.jpg",
+		"http://ex.com/img/large.jpg",
+		"http://ex.com/img/pic.webp",
+		"https://player.example.com/embed/1",
+		"http://ex.com/media/clip.mp4",
+	} {
+		if !slices.Contains(got, want) {
+			t.Errorf("HTMLAssets() = %q, missing %q", got, want)
+		}
+	}
+
+	if slices.Contains(got, "http://ex.com/media/custom.mp4") {
+		t.Errorf("HTMLAssets() = %q, unexpected attribute read", got)
+	}
+
+	// Configured attributes replace the defaults
+	config.Get().LazyLoadAttributes = []string{"data-custom"}
+	got = extract()
+	if !slices.Contains(got, "http://ex.com/media/custom.mp4") || slices.Contains(got, "http://ex.com/img/photo.jpg") {
+		t.Errorf("with configured attributes, HTMLAssets() = %q", got)
+	}
+}
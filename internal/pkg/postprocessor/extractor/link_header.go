@@ -1,56 +1,180 @@
 package extractor
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
-// ExtractURLsFromHeader parses a raw Link header in the form:
+// HeaderLink is a link of the Link headers of a response
+type HeaderLink struct {
+	URL  string
+	Rels []string // Relation types of the link, lowercased
+}
+
+// HasRel returns true if the link has the relation type
+func (l HeaderLink) HasRel(rel string) bool {
+	for _, r := range l.Rels {
+		if r == rel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HeaderLinks parses the Link headers of the response, in the form:
 //
 //	<url1>; rel="what", <url2>; rel="any"; another="yes", <url3>; rel="thing"
 //
-// returning a slice of models.URL structs
-// Each of these are separated by a `, ` and the in turn by a `; `, with the first always being the url, and the remaining the key-val pairs
-// See: https://simon-frey.com/blog/link-header/, https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Link
-func ExtractURLsFromHeader(URL *models.URL) (URLs []*models.URL) {
-	var link = URL.GetResponse().Header.Get("link")
+// The links are separated by commas and their parameters by semicolons, commas and semicolons are allowed
+// in the URLs and in the quoted parameters. The relative URLs are resolved against the URL of the response.
+// See: https://www.rfc-editor.org/rfc/rfc8288, https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Link
+func HeaderLinks(URL *models.URL) (links []HeaderLink) {
+	if URL.GetResponse() == nil {
+		return nil
+	}
+
+	for _, value := range URL.GetResponse().Header.Values("Link") {
+		for _, link := range parseLinkHeader(value) {
+			if base := URL.GetParsed(); base != nil {
+				if target, err := url.Parse(link.URL); err == nil {
+					link.URL = base.ResolveReference(target).String()
+				}
+			}
 
-	if link == "" {
-		return URLs
+			links = append(links, link)
+		}
 	}
 
-	for _, link := range strings.Split(link, ", ") {
-		parts := strings.Split(link, ";")
-		if len(parts) < 1 {
-			// Malformed input, somehow we didn't get at least one part
-			continue
+	return links
+}
+
+// ExtractURLsFromHeader returns the URLs of all the links of the Link headers of the response,
+// whatever their relation type
+func ExtractURLsFromHeader(URL *models.URL) (URLs []*models.URL) {
+	for _, link := range HeaderLinks(URL) {
+		URLs = append(URLs, &models.URL{
+			Raw: link.URL,
+		})
+	}
+
+	return URLs
+}
+
+// parseLinkHeader parses the links of a Link header value. Malformed links are parsed as best as possible:
+// a URL without its angle brackets ends at the first semicolon or comma.
+func parseLinkHeader(value string) (links []HeaderLink) {
+	i := 0
+
+	for i < len(value) {
+		// Skip the separators between the links
+		for i < len(value) && (value[i] == ',' || value[i] == ' ' || value[i] == '\t') {
+			i++
 		}
 
-		extractedURL := strings.TrimSpace(strings.Trim(parts[0], "<>"))
-		if extractedURL == "" {
-			// Malformed input, URL is empty
-			continue
+		if i >= len(value) {
+			break
+		}
+
+		var target string
+		if value[i] == '<' {
+			end := strings.IndexByte(value[i:], '>')
+			if end < 0 {
+				end = len(value) - i
+			}
+
+			target = value[i+1 : i+end]
+			i = min(i+end+1, len(value))
+		} else {
+			// Malformed input, the URL isn't between angle brackets
+			end := strings.IndexAny(value[i:], ";,")
+			if end < 0 {
+				end = len(value) - i
+			}
+
+			target = value[i : i+end]
+			i += end
 		}
 
-		for _, attrs := range parts[1:] {
-			key, _ := parseAttr(attrs)
-			if key == "" {
-				// Malformed input, somehow the key is nothing
+		var (
+			rels   []string
+			relSet bool
+		)
+
+		// Parameters, up to the next link
+		for i < len(value) && value[i] != ',' {
+			if value[i] == ';' || value[i] == ' ' || value[i] == '\t' {
+				i++
 				continue
 			}
 
-			if key == "rel" {
-				break
+			var key, param string
+			key, param, i = parseLinkParam(value, i)
+
+			// Only the first rel parameter is considered, per RFC 8288
+			if strings.EqualFold(key, "rel") && !relSet {
+				rels, relSet = strings.Fields(strings.ToLower(param)), true
 			}
 		}
 
-		URLs = append(URLs, &models.URL{
-			Raw: extractedURL,
-		})
+		target = strings.TrimSpace(strings.Trim(strings.TrimSpace(target), "<>"))
+		if target == "" {
+			// Malformed input, URL is empty
+			continue
+		}
+
+		links = append(links, HeaderLink{URL: target, Rels: rels})
 	}
 
-	return URLs
+	return links
+}
+
+// parseLinkParam parses the parameter starting at i, a key with an optional value, quoted or not.
+// It returns the index right after it.
+func parseLinkParam(value string, i int) (key, param string, next int) {
+	start := i
+	for i < len(value) && value[i] != '=' && value[i] != ';' && value[i] != ',' {
+		i++
+	}
+
+	key = strings.TrimSpace(value[start:i])
+
+	if i >= len(value) || value[i] != '=' {
+		return key, "", i
+	}
+
+	// Skip the '=' and the spaces after it
+	i++
+	for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+		i++
+	}
+
+	if i < len(value) && value[i] == '"' {
+		var b strings.Builder
+
+		for i++; i < len(value) && value[i] != '"'; i++ {
+			if value[i] == '\\' && i+1 < len(value) {
+				i++
+			}
+			b.WriteByte(value[i])
+		}
+
+		// Skip the closing quote
+		if i < len(value) {
+			i++
+		}
+
+		return key, b.String(), i
+	}
+
+	start = i
+	for i < len(value) && value[i] != ';' && value[i] != ',' {
+		i++
+	}
+
+	return key, strings.Trim(strings.TrimSpace(value[start:i]), "\""), i
 }
 
 // Parse a single attribute key value pair and return it
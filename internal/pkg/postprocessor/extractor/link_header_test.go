@@ -112,7 +112,7 @@ func TestExtractURLsFromHeader(t *testing.T) {
 				t.Errorf("unable to read response body: %v", err)
 			}
 
-			err = archiver.ProcessBody(URL, false, false, 0, os.TempDir())
+			err = archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
 			if err != nil {
 				t.Errorf("ProcessBody() error = %v", err)
 			}
@@ -171,3 +171,42 @@ func TestParseAttr(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaderLinks(t *testing.T) {
+	URL := &models.URL{Raw: "https://example.com/api/items?page=2"}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	URL.SetResponse(&http.Response{
+		Header: http.Header{
+			"Link": []string{
+				`</api/items?page=3&sort=a,b>; rel="next", </api/items?page=1>; title="first, really; no"; rel=prev`,
+				`<https://cdn.example.com/app.js>; rel="Preload Prefetch"; as=script; rel="ignored"`,
+				`<style.css>; rel=preload, <>; rel="empty"`,
+			},
+		},
+	})
+
+	expected := []HeaderLink{
+		{URL: "https://example.com/api/items?page=3&sort=a,b", Rels: []string{"next"}},
+		{URL: "https://example.com/api/items?page=1", Rels: []string{"prev"}},
+		{URL: "https://cdn.example.com/app.js", Rels: []string{"preload", "prefetch"}},
+		{URL: "https://example.com/api/style.css", Rels: []string{"preload"}},
+	}
+
+	got := HeaderLinks(URL)
+	if len(got) != len(expected) {
+		t.Fatalf("HeaderLinks() = %v, want %v", got, expected)
+	}
+
+	for i := range got {
+		if got[i].URL != expected[i].URL || fmt.Sprint(got[i].Rels) != fmt.Sprint(expected[i].Rels) {
+			t.Errorf("HeaderLinks()[%d] = %v, want %v", i, got[i], expected[i])
+		}
+	}
+
+	if !got[2].HasRel("prefetch") || got[2].HasRel("ignored") {
+		t.Errorf("expected only the first rel parameter to be considered, got %v", got[2].Rels)
+	}
+}
@@ -2,6 +2,8 @@ package extractor
 
 import (
 	"github.com/grafov/m3u8"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
@@ -10,6 +12,9 @@ func IsM3U8(URL *models.URL) bool {
 		isContentType(URL.GetResponse().Header.Get("Content-Type"), "application/x-mpegURL")
 }
 
+// M3U8 extracts the variant playlists of a master playlist, or the segments, encryption keys
+// and initialization sections of a media playlist. Relative URIs are resolved against the playlist's URL.
+// The number of segments is capped, so that live playlists don't get captured forever.
 func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 	defer URL.RewindBody()
 
@@ -24,9 +29,35 @@ func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 	case m3u8.MEDIA:
 		mediapl := playlist.(*m3u8.MediaPlaylist)
 
+		if mediapl.Key != nil && mediapl.Key.URI != "" {
+			rawAssets = append(rawAssets, mediapl.Key.URI)
+		}
+
+		if mediapl.Map != nil && mediapl.Map.URI != "" {
+			rawAssets = append(rawAssets, mediapl.Map.URI)
+		}
+
+		segments := 0
 		for _, segment := range mediapl.Segments {
-			if segment != nil && segment.URI != "" {
+			if segment == nil {
+				continue
+			}
+
+			if config.Get().HLSMaxSegments > 0 && segments >= config.Get().HLSMaxSegments {
+				break
+			}
+
+			if segment.Key != nil && segment.Key.URI != "" {
+				rawAssets = append(rawAssets, segment.Key.URI)
+			}
+
+			if segment.Map != nil && segment.Map.URI != "" {
+				rawAssets = append(rawAssets, segment.Map.URI)
+			}
+
+			if segment.URI != "" {
 				rawAssets = append(rawAssets, segment.URI)
+				segments++
 			}
 		}
 	case m3u8.MASTER:
@@ -47,9 +78,16 @@ func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 		}
 	}
 
-	for _, rawAsset := range rawAssets {
+	// Segments with byte ranges share the same URI, and keys are usually repeated
+	for _, rawAsset := range utils.DedupeStrings(rawAssets) {
+		if base := URL.GetParsed(); base != nil {
+			if resolved, err := base.Parse(rawAsset); err == nil {
+				rawAsset = resolved.String()
+			}
+		}
+
 		assets = append(assets, &models.URL{
-			Raw:  rawAsset,
+			Raw: rawAsset,
 		})
 	}
 
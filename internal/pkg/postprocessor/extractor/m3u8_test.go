@@ -0,0 +1,130 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func m3u8Assets(t *testing.T, rawURL, playlist string) []string {
+	t.Helper()
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/vnd.apple.mpegurl"}},
+		Body:   io.NopCloser(bytes.NewBufferString(playlist)),
+	}
+	URL := &models.URL{Raw: rawURL}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	if !IsM3U8(URL) {
+		t.Fatalf("IsM3U8() = false, want true")
+	}
+
+	assets, err := M3U8(URL)
+	if err != nil {
+		t.Fatalf("M3U8() error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	return got
+}
+
+func TestM3U8Master(t *testing.T) {
+	config.InitConfig()
+
+	playlist := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,URI="audio/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,RESOLUTION=640x360,AUDIO="aac"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,RESOLUTION=1280x720,AUDIO="aac"
+https://cdn.example.com/high/index.m3u8
+`
+
+	got := m3u8Assets(t, "https://example.com/video/master.m3u8", playlist)
+	want := []string{
+		"https://example.com/video/low/index.m3u8",
+		"https://example.com/video/audio/en.m3u8",
+		"https://cdn.example.com/high/index.m3u8",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("M3U8() = %q, want %q", got, want)
+	}
+}
+
+func TestM3U8Media(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxSegments int) {
+		config.Get().HLSMaxSegments = maxSegments
+	}(config.Get().HLSMaxSegments)
+
+	playlist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="/keys/1.key"
+#EXTINF:6.0,
+seg0.m4s
+#EXTINF:6.0,
+seg1.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="/keys/2.key"
+#EXTINF:6.0,
+../other/seg2.m4s
+#EXT-X-BYTERANGE:1000@0
+#EXTINF:6.0,
+all.ts
+#EXT-X-BYTERANGE:1000@1000
+#EXTINF:6.0,
+all.ts
+#EXT-X-ENDLIST
+`
+
+	config.Get().HLSMaxSegments = 0
+	got := m3u8Assets(t, "https://example.com/video/low/index.m3u8", playlist)
+	want := []string{
+		"https://example.com/keys/1.key",
+		"https://example.com/video/low/init.mp4",
+		"https://example.com/video/low/seg0.m4s",
+		"https://example.com/video/low/seg1.m4s",
+		"https://example.com/keys/2.key",
+		"https://example.com/video/other/seg2.m4s",
+		"https://example.com/video/low/all.ts",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("M3U8() = %q, want %q", got, want)
+	}
+
+	config.Get().HLSMaxSegments = 2
+	got = m3u8Assets(t, "https://example.com/video/low/index.m3u8", playlist)
+	want = []string{
+		"https://example.com/keys/1.key",
+		"https://example.com/video/low/init.mp4",
+		"https://example.com/video/low/seg0.m4s",
+		"https://example.com/video/low/seg1.m4s",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("with a cap of 2 segments, M3U8() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+type webManifestIcon struct {
+	Src string `json:"src"`
+}
+
+// webManifest holds the parts of a web app manifest that reference images
+type webManifest struct {
+	Icons       []webManifestIcon `json:"icons"`
+	Screenshots []webManifestIcon `json:"screenshots"`
+	Shortcuts   []struct {
+		Icons []webManifestIcon `json:"icons"`
+	} `json:"shortcuts"`
+}
+
+// IsWebManifest returns true for web app manifests, identified by their Content-Type or, as they are
+// often served as plain JSON, by their conventional file names
+func IsWebManifest(URL *models.URL) bool {
+	if isContentType(URL.GetResponse().Header.Get("Content-Type"), "application/manifest+json") {
+		return true
+	}
+
+	if URL.GetParsed() == nil {
+		return false
+	}
+
+	name := strings.ToLower(path.Base(URL.GetParsed().Path))
+
+	return strings.HasSuffix(name, ".webmanifest") || (name == "manifest.json" && IsJSON(URL))
+}
+
+// WebManifest extracts the icons and screenshots of a web app manifest, resolved against the manifest's URL
+func WebManifest(URL *models.URL) (assets []*models.URL, err error) {
+	defer URL.RewindBody()
+
+	var manifest webManifest
+	if err := json.NewDecoder(URL.GetBody()).Decode(&manifest); err != nil {
+		return nil, err
+	}
+
+	icons := append(manifest.Icons, manifest.Screenshots...)
+	for _, shortcut := range manifest.Shortcuts {
+		icons = append(icons, shortcut.Icons...)
+	}
+
+	var rawAssets []string
+	for _, icon := range icons {
+		src := strings.TrimSpace(icon.Src)
+		if src == "" || strings.HasPrefix(src, "data:") {
+			continue
+		}
+
+		resolved, err := URL.GetParsed().Parse(src)
+		if err != nil {
+			continue
+		}
+
+		rawAssets = append(rawAssets, resolved.String())
+	}
+
+	for _, rawAsset := range utils.DedupeStrings(rawAssets) {
+		assets = append(assets, &models.URL{
+			Raw: rawAsset,
+		})
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,152 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newManifestURL(t *testing.T, rawURL, contentType, body string) *models.URL {
+	t.Helper()
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{contentType}},
+		Body:   io.NopCloser(bytes.NewBufferString(body)),
+	}
+	URL := &models.URL{Raw: rawURL}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	return URL
+}
+
+func TestIsWebManifest(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		rawURL      string
+		contentType string
+		want        bool
+	}{
+		{"https://example.com/site.webmanifest", "application/octet-stream", true},
+		{"https://example.com/app/manifest", "application/manifest+json", true},
+		{"https://example.com/static/manifest.json", "application/json", true},
+		{"https://example.com/static/data.json", "application/json", false},
+	}
+
+	for _, tt := range tests {
+		URL := newManifestURL(t, tt.rawURL, tt.contentType, `{"name": "App"}`)
+		if got := IsWebManifest(URL); got != tt.want {
+			t.Errorf("IsWebManifest(%s) = %v, want %v", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestWebManifest(t *testing.T) {
+	config.InitConfig()
+
+	manifest := `{
+		"name": "App",
+		"start_url": "/",
+		"icons": [
+			{"src": "icons/192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "/icons/512.png", "sizes": "512x512"},
+			{"src": "https://cdn.example.com/icon.svg"},
+			{"src": "icons/192.png"},
+			{"src": "data:image/png;base64,iVBORw0KGgo="},
+			{"src": ""}
+		],
+		"screenshots": [{"src": "shots/home.jpg"}],
+		"shortcuts": [{"name": "New", "url": "/new", "icons": [{"src": "icons/new.png"}]}]
+	}`
+
+	URL := newManifestURL(t, "https://example.com/static/manifest.webmanifest", "application/manifest+json", manifest)
+
+	assets, err := WebManifest(URL)
+	if err != nil {
+		t.Fatalf("WebManifest() error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	want := []string{
+		"https://example.com/static/icons/192.png",
+		"https://example.com/icons/512.png",
+		"https://cdn.example.com/icon.svg",
+		"https://example.com/static/shots/home.jpg",
+		"https://example.com/static/icons/new.png",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("WebManifest() = %q, want %q", got, want)
+	}
+}
+
+func TestHTMLAssetsFavicon(t *testing.T) {
+	config.InitConfig()
+
+	defer func(captureFavicon bool) {
+		config.Get().CaptureFavicon = captureFavicon
+	}(config.Get().CaptureFavicon)
+
+	extract := func(html string) []string {
+		URL := newManifestURL(t, "https://example.com/blog/post", "text/html", html)
+
+		assets, err := HTMLAssets(models.NewItem("test", URL, ""))
+		if err != nil {
+			t.Fatalf("HTMLAssets() error = %v", err)
+		}
+
+		var got []string
+		for _, asset := range assets {
+			got = append(got, asset.Raw)
+		}
+
+		return got
+	}
+
+	withIcons := `<html><head>
+		<link rel="icon" href="/favicon-32.png">
+		<link rel="apple-touch-icon" href="/apple-touch-icon.png">
+		<link rel="manifest" href="/site.webmanifest">
+	</head><body></body></html>`
+	withoutIcon := `<html><head><link rel="stylesheet" href="/style.css"></head><body></body></html>`
+
+	config.Get().CaptureFavicon = true
+
+	got := extract(withIcons)
+	for _, want := range []string{"/favicon-32.png", "/apple-touch-icon.png", "/site.webmanifest"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("expected %s in the assets, got %q", want, got)
+		}
+	}
+	if slices.Contains(got, "https://example.com/favicon.ico") {
+		t.Errorf("expected no legacy favicon when the page declares an icon, got %q", got)
+	}
+
+	if got := extract(withoutIcon); !slices.Contains(got, "https://example.com/favicon.ico") {
+		t.Errorf("expected the legacy favicon when the page doesn't declare an icon, got %q", got)
+	}
+
+	config.Get().CaptureFavicon = false
+
+	if got := extract(withoutIcon); slices.Contains(got, "https://example.com/favicon.ico") {
+		t.Errorf("expected no legacy favicon when disabled, got %q", got)
+	}
+}
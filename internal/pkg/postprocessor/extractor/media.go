@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// mediaAssets returns the URLs of the <video> and <audio> elements of the page: their src, the poster
+// of the videos, and the src of their <source> and <track> (captions, subtitles..) children.
+// The URLs are resolved against the base of the page.
+func mediaAssets(item *models.Item, page *htmlPage) (assets []string) {
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		if value == "" || hasPrefixFold(value, "data:") {
+			return
+		}
+
+		resolved, err := resolveURL(value, item)
+		if err != nil || resolved == "" {
+			return
+		}
+
+		assets = append(assets, resolved)
+	}
+
+	for _, element := range []string{"video", "audio"} {
+		if slices.Contains(config.Get().DisableHTMLTag, element) {
+			continue
+		}
+
+		for _, media := range page.find(element) {
+			add(media.attrOr("src", ""))
+
+			if element == "video" {
+				add(media.attrOr("poster", ""))
+			}
+
+			for _, child := range []string{"source", "track"} {
+				if slices.Contains(config.Get().DisableHTMLTag, child) {
+					continue
+				}
+
+				for _, descendant := range page.find(child) {
+					if descendant.media == media {
+						add(descendant.attrOr("src", ""))
+					}
+				}
+			}
+		}
+	}
+
+	return assets
+}
@@ -0,0 +1,115 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestHTMLAssetsMedia(t *testing.T) {
+	config.InitConfig()
+
+	html := `
+	<html>
+		<head><base href="https://cdn.ex.com/media/"></head>
+		<body>
+			<video src="intro.mp4" poster="intro.jpg" controls>
+				<source src="intro.mp4" type="video/mp4">
+				<source src="intro.webm" type="video/webm">
+				<track kind="captions" src="intro.en.vtt" srclang="en">
+				<track kind="subtitles" src="/subs/intro.fr.vtt" srclang="fr">
+			</video>
+			<video poster="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+				<source src="https://other.ex.com/clip.mp4">
+			</video>
+			<audio controls>
+				<source src="podcast.ogg" type="audio/ogg">
+				<track kind="chapters" src="podcast.chapters.vtt">
+			</audio>
+			<picture><source srcset="/img/pic.webp"><img src="/img/pic.jpg"></picture>
+		</body>
+	</html>
+	`
+
+	extract := func() []string {
+		resp := &http.Response{
+			Body: io.NopCloser(bytes.NewBufferString(html)),
+		}
+		newURL := &models.URL{Raw: "https://ex.com/watch"}
+		if err := newURL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		newURL.SetResponse(resp)
+		if err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		assets, err := HTMLAssets(models.NewItem("test", newURL, ""))
+		if err != nil {
+			t.Fatalf("HTMLAssets error = %v", err)
+		}
+
+		var got []string
+		for _, asset := range assets {
+			got = append(got, asset.Raw)
+		}
+
+		return got
+	}
+
+	got := extract()
+
+	want := []string{
+		"https://cdn.ex.com/media/intro.mp4",
+		"https://cdn.ex.com/media/intro.jpg",
+		"https://cdn.ex.com/media/intro.webm",
+		"https://cdn.ex.com/media/intro.en.vtt",
+		"https://cdn.ex.com/subs/intro.fr.vtt",
+		"https://other.ex.com/clip.mp4",
+		"https://cdn.ex.com/media/podcast.ogg",
+		"https://cdn.ex.com/media/podcast.chapters.vtt",
+	}
+
+	for _, URL := range want {
+		if count := countOf(got, URL); count != 1 {
+			t.Errorf("expected %s to be extracted once, found %d times in %q", URL, count, got)
+		}
+	}
+
+	for _, asset := range got {
+		if hasPrefixFold(asset, "data:") {
+			t.Errorf("expected data: posters to be skipped, got %s", asset)
+		}
+	}
+
+	// The sources of pictures are still extracted
+	if !slices.Contains(got, "https://cdn.ex.com/img/pic.webp") {
+		t.Errorf("expected the picture source to be extracted, got %q", got)
+	}
+
+	defer func(disabled []string) {
+		config.Get().DisableHTMLTag = disabled
+	}(config.Get().DisableHTMLTag)
+	config.Get().DisableHTMLTag = []string{"track"}
+
+	if got := extract(); slices.Contains(got, "https://cdn.ex.com/media/intro.en.vtt") {
+		t.Errorf("expected the tracks to be skipped when disabled, got %q", got)
+	}
+}
+
+func countOf(values []string, value string) (count int) {
+	for _, v := range values {
+		if v == value {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,103 @@
+package extractor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// MetaRefresh returns the target of the first <meta http-equiv="refresh"> of the page that has one,
+// resolved against the base of the page, and its delay in seconds
+func MetaRefresh(item *models.Item) (target string, delay float64, found bool) {
+	defer item.GetURL().RewindBody()
+
+	page, err := loadHTMLPage(item, false)
+	if err != nil {
+		return "", 0, false
+	}
+
+	// The target is relative to the <base> of the page, if any
+	extractBaseTag(item, page)
+
+	for _, meta := range page.find("meta") {
+		httpEquiv, hasHTTPEquiv := meta.Attr("http-equiv")
+		content, hasContent := meta.Attr("content")
+		if !hasHTTPEquiv || !hasContent || !strings.EqualFold(strings.TrimSpace(httpEquiv), "refresh") {
+			continue
+		}
+
+		rawTarget, refreshDelay, ok := parseMetaRefresh(content)
+		if !ok {
+			continue
+		}
+
+		resolved, err := resolveURL(rawTarget, item)
+		if err != nil || resolved == "" {
+			continue
+		}
+
+		return resolved, refreshDelay, true
+	}
+
+	return "", 0, false
+}
+
+// parseMetaRefresh parses the content of a refresh directive, following the algorithm of the HTML standard:
+// a delay, optionally followed by a ; or , separator, an optional "url=" prefix and the URL, optionally quoted.
+// A directive without URL refreshes the page itself, it isn't returned.
+func parseMetaRefresh(content string) (target string, delay float64, ok bool) {
+	content = strings.TrimLeft(content, " \t\n\r\f")
+
+	// Delay, fractional parts are allowed but ignored
+	end := 0
+	for end < len(content) && content[end] >= '0' && content[end] <= '9' {
+		end++
+	}
+
+	if end == 0 && (len(content) == 0 || content[0] != '.') {
+		return "", 0, false
+	}
+
+	if end > 0 {
+		var err error
+		delay, err = strconv.ParseFloat(content[:end], 64)
+		if err != nil {
+			return "", 0, false
+		}
+	}
+
+	content = strings.TrimLeft(content[end:], "0123456789.")
+
+	// Separator
+	content = strings.TrimLeft(content, " \t\n\r\f")
+	if len(content) > 0 && (content[0] == ';' || content[0] == ',') {
+		content = content[1:]
+	}
+	content = strings.TrimLeft(content, " \t\n\r\f")
+
+	// Optional url= prefix
+	if len(content) >= 3 && strings.EqualFold(content[:3], "url") {
+		rest := strings.TrimLeft(content[3:], " \t\n\r\f")
+		if strings.HasPrefix(rest, "=") {
+			content = strings.TrimLeft(rest[1:], " \t\n\r\f")
+		}
+	}
+
+	// Optionally quoted URL, an unterminated quote runs to the end of the content
+	if len(content) > 0 && (content[0] == '\'' || content[0] == '"') {
+		quote := content[0]
+		content = content[1:]
+
+		if i := strings.IndexByte(content, quote); i != -1 {
+			content = content[:i]
+		}
+	}
+
+	target = strings.TrimSpace(content)
+	if target == "" {
+		return "", 0, false
+	}
+
+	return target, delay, true
+}
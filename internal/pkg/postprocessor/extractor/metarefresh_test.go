@@ -0,0 +1,100 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseMetaRefresh(t *testing.T) {
+	tests := []struct {
+		content    string
+		wantTarget string
+		wantDelay  float64
+		wantOK     bool
+	}{
+		{"0;url=http://example.com/", "http://example.com/", 0, true},
+		{"0; URL=http://example.com/", "http://example.com/", 0, true},
+		{"5 ; Url = '/next page.html' ", "/next page.html", 5, true},
+		{`3;URL="/quoted"`, "/quoted", 3, true},
+		{"0,url=/comma", "/comma", 0, true},
+		{"1.5; url=/fraction", "/fraction", 1, true},
+		{"0;/without-prefix", "/without-prefix", 0, true},
+		{"0; url='/unterminated", "/unterminated", 0, true},
+		{"30", "", 0, false},
+		{"0;", "", 0, false},
+		{"url=/no-delay", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		target, delay, ok := parseMetaRefresh(tt.content)
+		if target != tt.wantTarget || delay != tt.wantDelay || ok != tt.wantOK {
+			t.Errorf("parseMetaRefresh(%q) = %q, %v, %v, want %q, %v, %v", tt.content, target, delay, ok, tt.wantTarget, tt.wantDelay, tt.wantOK)
+		}
+	}
+}
+
+func TestMetaRefresh(t *testing.T) {
+	config.InitConfig()
+
+	tests := []struct {
+		name       string
+		html       string
+		wantTarget string
+		wantFound  bool
+	}{
+		{
+			name:       "uppercase attributes and relative URL",
+			html:       `<html><head><META HTTP-EQUIV="REFRESH" CONTENT="0; URL=../landing/"></head></html>`,
+			wantTarget: "http://example.com/landing/",
+			wantFound:  true,
+		},
+		{
+			name:       "resolved against the base tag",
+			html:       `<html><head><base href="http://cdn.example.com/v2/"><meta http-equiv="refresh" content="2;url=start.html"></head></html>`,
+			wantTarget: "http://cdn.example.com/v2/start.html",
+			wantFound:  true,
+		},
+		{
+			name:      "reload without URL",
+			html:      `<html><head><meta http-equiv="refresh" content="60"></head></html>`,
+			wantFound: false,
+		},
+		{
+			name:      "other http-equiv",
+			html:      `<html><head><meta http-equiv="content-type" content="text/html; charset=utf-8"></head></html>`,
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{"text/html"}},
+				Body:   io.NopCloser(bytes.NewBufferString(tt.html)),
+			}
+			newURL := &models.URL{Raw: "http://example.com/old/page.html"}
+			if err := newURL.Parse(); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			newURL.SetResponse(resp)
+			err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+			if err != nil {
+				t.Fatalf("ProcessBody() error = %v", err)
+			}
+			item := models.NewItem("test", newURL, "")
+
+			target, _, found := MetaRefresh(item)
+			if target != tt.wantTarget || found != tt.wantFound {
+				t.Errorf("MetaRefresh() = %q, %v, want %q, %v", target, found, tt.wantTarget, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+type mpdDocument struct {
+	Type                      string      `xml:"type,attr"`
+	MediaPresentationDuration string      `xml:"mediaPresentationDuration,attr"`
+	AvailabilityStartTime     string      `xml:"availabilityStartTime,attr"`
+	TimeShiftBufferDepth      string      `xml:"timeShiftBufferDepth,attr"`
+	BaseURLs                  []string    `xml:"BaseURL"`
+	Periods                   []mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	Start          string             `xml:"start,attr"`
+	Duration       string             `xml:"duration,attr"`
+	BaseURLs       []string           `xml:"BaseURL"`
+	AdaptationSets []mpdAdaptationSet `xml:"AdaptationSet"`
+	mpdSegmentInformation
+}
+
+type mpdAdaptationSet struct {
+	BaseURLs        []string            `xml:"BaseURL"`
+	Representations []mpdRepresentation `xml:"Representation"`
+	mpdSegmentInformation
+}
+
+type mpdRepresentation struct {
+	ID        string   `xml:"id,attr"`
+	Bandwidth string   `xml:"bandwidth,attr"`
+	BaseURLs  []string `xml:"BaseURL"`
+	mpdSegmentInformation
+}
+
+// mpdSegmentInformation holds the segment descriptions that can be declared at the Period,
+// AdaptationSet or Representation level, the lower levels inheriting from the upper ones
+type mpdSegmentInformation struct {
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	SegmentList     *mpdSegmentList     `xml:"SegmentList"`
+	SegmentBase     *mpdSegmentBase     `xml:"SegmentBase"`
+}
+
+type mpdSegmentTemplate struct {
+	Media          string              `xml:"media,attr"`
+	Initialization string              `xml:"initialization,attr"`
+	StartNumber    *uint64             `xml:"startNumber,attr"`
+	Timescale      *uint64             `xml:"timescale,attr"`
+	Duration       *uint64             `xml:"duration,attr"`
+	Timeline       *mpdSegmentTimeline `xml:"SegmentTimeline"`
+}
+
+type mpdSegmentTimeline struct {
+	Segments []struct {
+		T *uint64 `xml:"t,attr"`
+		D uint64  `xml:"d,attr"`
+		R int64   `xml:"r,attr"`
+	} `xml:"S"`
+}
+
+type mpdSegmentList struct {
+	Initialization *mpdURL  `xml:"Initialization"`
+	SegmentURLs    []mpdURL `xml:"SegmentURL"`
+}
+
+type mpdSegmentBase struct {
+	Initialization *mpdURL `xml:"Initialization"`
+}
+
+type mpdURL struct {
+	SourceURL string `xml:"sourceURL,attr"`
+	Media     string `xml:"media,attr"`
+}
+
+// mpdSegment identifies a media segment for the expansion of a SegmentTemplate
+type mpdSegment struct {
+	number uint64
+	time   uint64
+}
+
+func IsMPD(URL *models.URL) bool {
+	if isContentType(URL.GetResponse().Header.Get("Content-Type"), "application/dash+xml") {
+		return true
+	}
+
+	return URL.GetParsed() != nil && strings.HasSuffix(strings.ToLower(URL.GetParsed().Path), ".mpd") &&
+		URL.GetMIMEType() != nil && strings.Contains(URL.GetMIMEType().String(), "xml")
+}
+
+// MPD extracts the initialization and media segments of all the Representations of a DASH manifest.
+// SegmentTemplate (with or without SegmentTimeline), SegmentList and SegmentBase are supported and
+// BaseURL elements are resolved level by level. The number of media segments of each Representation
+// is capped. Dynamic (live) manifests are skipped unless configured otherwise, in which case only
+// the segments currently advertised by the manifest are captured.
+func MPD(URL *models.URL) (assets []*models.URL, err error) {
+	defer URL.RewindBody()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.extractor.MPD",
+	})
+
+	var document mpdDocument
+	if err := xml.NewDecoder(URL.GetBody()).Decode(&document); err != nil {
+		return assets, err
+	}
+
+	if document.Type == "dynamic" && !config.Get().DASHCaptureLive {
+		logger.Debug("skipping dynamic manifest", "url", URL.String())
+		return assets, nil
+	}
+
+	base := URL.GetParsed()
+	if base == nil {
+		if base, err = url.Parse(URL.String()); err != nil {
+			return assets, err
+		}
+	}
+
+	base = resolveMPDBaseURL(base, document.BaseURLs)
+
+	var (
+		rawAssets []string
+		segments  int
+	)
+
+	for i, period := range document.Periods {
+		periodBase := resolveMPDBaseURL(base, period.BaseURLs)
+		periodDuration, hasDuration := mpdPeriodDuration(&document, i)
+
+		for _, adaptationSet := range period.AdaptationSets {
+			adaptationSetBase := resolveMPDBaseURL(periodBase, adaptationSet.BaseURLs)
+
+			for _, representation := range adaptationSet.Representations {
+				representationBase := resolveMPDBaseURL(adaptationSetBase, representation.BaseURLs)
+				hasBaseURL := len(document.BaseURLs)+len(period.BaseURLs)+len(adaptationSet.BaseURLs)+len(representation.BaseURLs) > 0
+
+				template := mergeMPDSegmentTemplates(period.SegmentTemplate, adaptationSet.SegmentTemplate, representation.SegmentTemplate)
+				list := mergeMPDSegmentLists(period.SegmentList, adaptationSet.SegmentList, representation.SegmentList)
+				segmentBase := mergeMPDSegmentBases(period.SegmentBase, adaptationSet.SegmentBase, representation.SegmentBase)
+
+				var representationAssets []string
+
+				switch {
+				case template != nil:
+					if template.Initialization != "" {
+						representationAssets = append(representationAssets, expandMPDTemplate(template.Initialization, &representation, mpdSegment{}))
+					}
+
+					if template.Media != "" {
+						mediaSegments := mpdTemplateSegments(&document, template, periodDuration, hasDuration)
+						for _, segment := range mediaSegments {
+							representationAssets = append(representationAssets, expandMPDTemplate(template.Media, &representation, segment))
+						}
+						segments += len(mediaSegments)
+					}
+				case list != nil:
+					if list.Initialization != nil && list.Initialization.SourceURL != "" {
+						representationAssets = append(representationAssets, list.Initialization.SourceURL)
+					}
+
+					for j, segmentURL := range list.SegmentURLs {
+						if config.Get().DASHMaxSegments > 0 && j >= config.Get().DASHMaxSegments {
+							break
+						}
+
+						// A SegmentURL without media is a byte range of the BaseURL
+						if segmentURL.Media != "" {
+							representationAssets = append(representationAssets, segmentURL.Media)
+						} else if hasBaseURL {
+							representationAssets = append(representationAssets, representationBase.String())
+						}
+						segments++
+					}
+				default:
+					if segmentBase != nil && segmentBase.Initialization != nil && segmentBase.Initialization.SourceURL != "" {
+						representationAssets = append(representationAssets, segmentBase.Initialization.SourceURL)
+					}
+
+					// The whole media of the Representation is at its BaseURL
+					if hasBaseURL {
+						representationAssets = append(representationAssets, representationBase.String())
+						segments++
+					}
+				}
+
+				for _, rawAsset := range representationAssets {
+					resolved, err := representationBase.Parse(rawAsset)
+					if err != nil {
+						continue
+					}
+
+					rawAssets = append(rawAssets, resolved.String())
+				}
+			}
+		}
+	}
+
+	rawAssets = utils.DedupeStrings(rawAssets)
+
+	logger.Info("extracted DASH manifest", "url", URL.String(), "type", document.Type, "segments", segments, "assets", len(rawAssets))
+
+	for _, rawAsset := range rawAssets {
+		assets = append(assets, &models.URL{
+			Raw: rawAsset,
+		})
+	}
+
+	return assets, nil
+}
+
+// resolveMPDBaseURL resolves the first BaseURL of a level against the BaseURL of its parent
+func resolveMPDBaseURL(parent *url.URL, baseURLs []string) *url.URL {
+	for _, baseURL := range baseURLs {
+		baseURL = strings.TrimSpace(baseURL)
+		if baseURL == "" {
+			continue
+		}
+
+		if resolved, err := parent.Parse(baseURL); err == nil {
+			return resolved
+		}
+	}
+
+	return parent
+}
+
+func mergeMPDSegmentTemplates(levels ...*mpdSegmentTemplate) (merged *mpdSegmentTemplate) {
+	for _, level := range levels {
+		if level == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &mpdSegmentTemplate{}
+		}
+
+		if level.Media != "" {
+			merged.Media = level.Media
+		}
+		if level.Initialization != "" {
+			merged.Initialization = level.Initialization
+		}
+		if level.StartNumber != nil {
+			merged.StartNumber = level.StartNumber
+		}
+		if level.Timescale != nil {
+			merged.Timescale = level.Timescale
+		}
+		if level.Duration != nil {
+			merged.Duration = level.Duration
+		}
+		if level.Timeline != nil {
+			merged.Timeline = level.Timeline
+		}
+	}
+
+	return merged
+}
+
+func mergeMPDSegmentLists(levels ...*mpdSegmentList) (merged *mpdSegmentList) {
+	for _, level := range levels {
+		if level == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &mpdSegmentList{}
+		}
+
+		if level.Initialization != nil {
+			merged.Initialization = level.Initialization
+		}
+		if len(level.SegmentURLs) > 0 {
+			merged.SegmentURLs = level.SegmentURLs
+		}
+	}
+
+	return merged
+}
+
+func mergeMPDSegmentBases(levels ...*mpdSegmentBase) (merged *mpdSegmentBase) {
+	for _, level := range levels {
+		if level == nil {
+			continue
+		}
+
+		if merged == nil || level.Initialization != nil {
+			merged = level
+		}
+	}
+
+	return merged
+}
+
+// mpdPeriodDuration returns the duration of the i-th period, from its own duration, the start of the next
+// period or the duration of the presentation, in that order
+func mpdPeriodDuration(document *mpdDocument, i int) (time.Duration, bool) {
+	period := document.Periods[i]
+
+	if duration, ok := parseISO8601Duration(period.Duration); ok {
+		return duration, true
+	}
+
+	start, _ := parseISO8601Duration(period.Start)
+
+	var end time.Duration
+	var ok bool
+	if i+1 < len(document.Periods) {
+		end, ok = parseISO8601Duration(document.Periods[i+1].Start)
+	} else {
+		end, ok = parseISO8601Duration(document.MediaPresentationDuration)
+	}
+
+	if !ok || end <= start {
+		return 0, false
+	}
+
+	return end - start, true
+}
+
+// mpdTemplateSegments lists the media segments described by a SegmentTemplate, from its SegmentTimeline
+// or from its fixed segment duration, capped at the configured number of segments
+func mpdTemplateSegments(document *mpdDocument, template *mpdSegmentTemplate, periodDuration time.Duration, hasDuration bool) (segments []mpdSegment) {
+	var (
+		maxSegments = config.Get().DASHMaxSegments
+		startNumber = uint64(1)
+		timescale   = uint64(1)
+	)
+
+	if template.StartNumber != nil {
+		startNumber = *template.StartNumber
+	}
+
+	if template.Timescale != nil && *template.Timescale > 0 {
+		timescale = *template.Timescale
+	}
+
+	capped := func() bool {
+		return maxSegments > 0 && len(segments) >= maxSegments
+	}
+
+	// With a timeline, the segments are listed explicitly. For live manifests that's the advertised window.
+	if template.Timeline != nil {
+		var (
+			number      = startNumber
+			currentTime uint64
+			periodEnd   = uint64(math.MaxUint64)
+		)
+
+		if hasDuration {
+			periodEnd = uint64(periodDuration.Seconds() * float64(timescale))
+		}
+
+		for i, s := range template.Timeline.Segments {
+			if s.T != nil {
+				currentTime = *s.T
+			}
+
+			if s.D == 0 {
+				break
+			}
+
+			// A negative repeat count repeats the segment until the next S element or the end of the period
+			repeat := s.R
+			if repeat < 0 {
+				end := periodEnd
+				if i+1 < len(template.Timeline.Segments) && template.Timeline.Segments[i+1].T != nil {
+					end = *template.Timeline.Segments[i+1].T
+				}
+
+				if end == math.MaxUint64 {
+					repeat = int64(maxSegments)
+				} else if end > currentTime {
+					repeat = int64((end-currentTime+s.D-1)/s.D) - 1
+				} else {
+					repeat = 0
+				}
+			}
+
+			for r := int64(0); r <= repeat; r++ {
+				if capped() {
+					return segments
+				}
+
+				segments = append(segments, mpdSegment{number: number, time: currentTime})
+				number++
+				currentTime += s.D
+			}
+		}
+
+		return segments
+	}
+
+	if template.Duration == nil || *template.Duration == 0 {
+		return segments
+	}
+
+	duration := *template.Duration
+	first, last := startNumber, uint64(0)
+
+	if document.Type == "dynamic" {
+		// Only the segments available right now, within the time shift buffer
+		availabilityStart, err := time.Parse(time.RFC3339, document.AvailabilityStartTime)
+		if err != nil || time.Since(availabilityStart) <= 0 {
+			return segments
+		}
+
+		available := uint64(time.Since(availabilityStart).Seconds() * float64(timescale) / float64(duration))
+		if available == 0 {
+			return segments
+		}
+		last = startNumber + available - 1
+
+		if depth, ok := parseISO8601Duration(document.TimeShiftBufferDepth); ok {
+			window := uint64(math.Ceil(depth.Seconds() * float64(timescale) / float64(duration)))
+			if window > 0 && last-startNumber+1 > window {
+				first = last - window + 1
+			}
+		}
+	} else if hasDuration {
+		count := uint64(math.Ceil(periodDuration.Seconds() * float64(timescale) / float64(duration)))
+		if count == 0 {
+			return segments
+		}
+		last = startNumber + count - 1
+	} else {
+		// Without a known duration, only the cap bounds the segments
+		if maxSegments <= 0 {
+			return segments
+		}
+		last = startNumber + uint64(maxSegments) - 1
+	}
+
+	// Keep the most recent segments of the window when it's capped
+	if document.Type == "dynamic" && maxSegments > 0 && last-first+1 > uint64(maxSegments) {
+		first = last - uint64(maxSegments) + 1
+	}
+
+	for number := first; number <= last && !capped(); number++ {
+		segments = append(segments, mpdSegment{number: number, time: (number - startNumber) * duration})
+	}
+
+	return segments
+}
+
+// expandMPDTemplate substitutes the $RepresentationID$, $Number$, $Time$ and $Bandwidth$ identifiers
+// of a SegmentTemplate, with their optional %0[width]d format tag, and the $$ escape
+func expandMPDTemplate(template string, representation *mpdRepresentation, segment mpdSegment) string {
+	var builder strings.Builder
+
+	for {
+		start := strings.IndexByte(template, '$')
+		if start == -1 {
+			builder.WriteString(template)
+			break
+		}
+
+		end := strings.IndexByte(template[start+1:], '$')
+		if end == -1 {
+			builder.WriteString(template)
+			break
+		}
+		end += start + 1
+
+		builder.WriteString(template[:start])
+
+		identifier, format, _ := strings.Cut(template[start+1:end], "%")
+
+		var value string
+		switch identifier {
+		case "":
+			value = "$"
+		case "RepresentationID":
+			value = representation.ID
+		case "Bandwidth":
+			value = formatMPDNumber(representation.Bandwidth, format)
+		case "Number":
+			value = formatMPDNumber(strconv.FormatUint(segment.number, 10), format)
+		case "Time":
+			value = formatMPDNumber(strconv.FormatUint(segment.time, 10), format)
+		default:
+			// Unknown identifiers are left untouched
+			value = template[start : end+1]
+		}
+
+		builder.WriteString(value)
+		template = template[end+1:]
+	}
+
+	return builder.String()
+}
+
+// formatMPDNumber applies a "0[width]d" format tag to a number
+func formatMPDNumber(number, format string) string {
+	if format == "" {
+		return number
+	}
+
+	width, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(format, "0"), "d"))
+	if err != nil || width <= len(number) {
+		return number
+	}
+
+	return fmt.Sprintf("%0*s", width, number)
+}
+
+// parseISO8601Duration parses the xs:duration values used by DASH manifests, e.g. PT1H2M3.5S
+func parseISO8601Duration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, false
+	}
+
+	var (
+		total   float64
+		inTime  bool
+		number  strings.Builder
+		seconds = map[bool]map[byte]float64{
+			false: {'Y': 365 * 86400, 'M': 30 * 86400, 'W': 7 * 86400, 'D': 86400},
+			true:  {'H': 3600, 'M': 60, 'S': 1},
+		}
+	)
+
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case c == 'T':
+			inTime = true
+		case (c >= '0' && c <= '9') || c == '.':
+			number.WriteByte(c)
+		default:
+			unit, ok := seconds[inTime][c]
+			if !ok || number.Len() == 0 {
+				return 0, false
+			}
+
+			n, err := strconv.ParseFloat(number.String(), 64)
+			if err != nil {
+				return 0, false
+			}
+
+			total += n * unit
+			number.Reset()
+		}
+	}
+
+	if number.Len() > 0 {
+		return 0, false
+	}
+
+	return time.Duration(total * float64(time.Second)), true
+}
@@ -0,0 +1,252 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func mpdAssets(t *testing.T, rawURL, manifest string) []string {
+	t.Helper()
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/dash+xml"}},
+		Body:   io.NopCloser(bytes.NewBufferString(manifest)),
+	}
+	URL := &models.URL{Raw: rawURL}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	if !IsMPD(URL) {
+		t.Fatalf("IsMPD() = false, want true")
+	}
+
+	assets, err := MPD(URL)
+	if err != nil {
+		t.Fatalf("MPD() error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	return got
+}
+
+func TestMPDSegmentTemplate(t *testing.T) {
+	config.InitConfig()
+
+	manifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT10S">
+	<BaseURL>https://cdn.example.com/video/</BaseURL>
+	<Period>
+		<AdaptationSet mimeType="video/mp4">
+			<BaseURL>v/</BaseURL>
+			<SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg-$Number%05d$.m4s" startNumber="1" timescale="1000" duration="4000"/>
+			<Representation id="720p" bandwidth="3000000"/>
+			<Representation id="1080p" bandwidth="6000000">
+				<SegmentTemplate media="$Bandwidth$/$Number$.m4s" startNumber="10"/>
+			</Representation>
+		</AdaptationSet>
+	</Period>
+</MPD>`
+
+	got := mpdAssets(t, "https://example.com/stream/manifest.mpd", manifest)
+
+	want := []string{
+		"https://cdn.example.com/video/v/720p/init.mp4",
+		"https://cdn.example.com/video/v/720p/seg-00001.m4s",
+		"https://cdn.example.com/video/v/720p/seg-00002.m4s",
+		"https://cdn.example.com/video/v/720p/seg-00003.m4s",
+		"https://cdn.example.com/video/v/1080p/init.mp4",
+		"https://cdn.example.com/video/v/6000000/10.m4s",
+		"https://cdn.example.com/video/v/6000000/11.m4s",
+		"https://cdn.example.com/video/v/6000000/12.m4s",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("MPD() = %q, want %q", got, want)
+	}
+}
+
+func TestMPDSegmentTimeline(t *testing.T) {
+	config.InitConfig()
+
+	manifest := `<MPD type="static" mediaPresentationDuration="PT6S">
+	<Period>
+		<AdaptationSet>
+			<SegmentTemplate timescale="90000" initialization="init-$RepresentationID$.m4s" media="chunk-$RepresentationID$-$Time$.m4s">
+				<SegmentTimeline>
+					<S t="0" d="180000" r="1"/>
+					<S d="90000" r="-1"/>
+				</SegmentTimeline>
+			</SegmentTemplate>
+			<Representation id="a"/>
+		</AdaptationSet>
+	</Period>
+</MPD>`
+
+	got := mpdAssets(t, "https://example.com/dash/manifest.mpd", manifest)
+
+	want := []string{
+		"https://example.com/dash/init-a.m4s",
+		"https://example.com/dash/chunk-a-0.m4s",
+		"https://example.com/dash/chunk-a-180000.m4s",
+		"https://example.com/dash/chunk-a-360000.m4s",
+		"https://example.com/dash/chunk-a-450000.m4s",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("MPD() = %q, want %q", got, want)
+	}
+
+	// The number of media segments of each Representation is capped
+	defer func(maxSegments int) {
+		config.Get().DASHMaxSegments = maxSegments
+	}(config.Get().DASHMaxSegments)
+	config.Get().DASHMaxSegments = 2
+
+	got = mpdAssets(t, "https://example.com/dash/manifest.mpd", manifest)
+	if !slices.Equal(got, want[:3]) {
+		t.Errorf("with a cap of 2 segments, MPD() = %q, want %q", got, want[:3])
+	}
+}
+
+func TestMPDSegmentListAndBase(t *testing.T) {
+	config.InitConfig()
+
+	manifest := `<MPD type="static">
+	<Period>
+		<BaseURL>/media/</BaseURL>
+		<AdaptationSet>
+			<Representation id="list">
+				<SegmentList duration="10">
+					<Initialization sourceURL="list/init.mp4"/>
+					<SegmentURL media="list/1.m4s"/>
+					<SegmentURL media="list/2.m4s"/>
+				</SegmentList>
+			</Representation>
+		</AdaptationSet>
+		<AdaptationSet>
+			<Representation id="base">
+				<BaseURL>audio.mp4</BaseURL>
+				<SegmentBase indexRange="800-1000">
+					<Initialization range="0-799"/>
+				</SegmentBase>
+			</Representation>
+		</AdaptationSet>
+	</Period>
+</MPD>`
+
+	got := mpdAssets(t, "https://example.com/dash/manifest.mpd", manifest)
+
+	want := []string{
+		"https://example.com/media/list/init.mp4",
+		"https://example.com/media/list/1.m4s",
+		"https://example.com/media/list/2.m4s",
+		"https://example.com/media/audio.mp4",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("MPD() = %q, want %q", got, want)
+	}
+}
+
+func TestMPDDynamic(t *testing.T) {
+	config.InitConfig()
+
+	defer func(captureLive bool) {
+		config.Get().DASHCaptureLive = captureLive
+	}(config.Get().DASHCaptureLive)
+
+	availabilityStart := time.Now().Add(-100 * time.Second).UTC().Format(time.RFC3339)
+	manifest := `<MPD type="dynamic" availabilityStartTime="` + availabilityStart + `" timeShiftBufferDepth="PT30S">
+	<Period start="PT0S">
+		<AdaptationSet>
+			<SegmentTemplate media="live-$Number$.m4s" startNumber="1" duration="10"/>
+			<Representation id="live"/>
+		</AdaptationSet>
+	</Period>
+</MPD>`
+
+	config.Get().DASHCaptureLive = false
+	if got := mpdAssets(t, "https://example.com/live/manifest.mpd", manifest); len(got) != 0 {
+		t.Errorf("expected dynamic manifests to be skipped, got %q", got)
+	}
+
+	// 10 segments are available, the time shift buffer only advertises the last 3
+	config.Get().DASHCaptureLive = true
+	want := []string{
+		"https://example.com/live/live-8.m4s",
+		"https://example.com/live/live-9.m4s",
+		"https://example.com/live/live-10.m4s",
+	}
+
+	if got := mpdAssets(t, "https://example.com/live/manifest.mpd", manifest); !slices.Equal(got, want) {
+		t.Errorf("MPD() = %q, want %q", got, want)
+	}
+}
+
+func TestExpandMPDTemplate(t *testing.T) {
+	representation := &mpdRepresentation{ID: "v1", Bandwidth: "500000"}
+	segment := mpdSegment{number: 42, time: 1234}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"$RepresentationID$/$Number$.m4s", "v1/42.m4s"},
+		{"seg-$Number%06d$.ts", "seg-000042.ts"},
+		{"$Bandwidth$-$Time$.mp4", "500000-1234.mp4"},
+		{"cost$$-$Time%02d$", "cost$-1234"},
+		{"$Unknown$-$Number$", "$Unknown$-42"},
+		{"no-identifier.mp4", "no-identifier.mp4"},
+		{"unterminated-$Number", "unterminated-$Number"},
+	}
+
+	for _, tt := range tests {
+		if got := expandMPDTemplate(tt.template, representation, segment); got != tt.want {
+			t.Errorf("expandMPDTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"PT10S", 10 * time.Second, true},
+		{"PT1H2M3.5S", time.Hour + 2*time.Minute + 3500*time.Millisecond, true},
+		{"P1DT1S", 24*time.Hour + time.Second, true},
+		{"PT0S", 0, true},
+		{"", 0, false},
+		{"10S", 0, false},
+		{"PT10", 0, false},
+		{"PTXS", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseISO8601Duration(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseISO8601Duration(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
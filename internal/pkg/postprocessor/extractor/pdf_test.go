@@ -24,7 +24,7 @@ func TestPDF(t *testing.T) {
 	var URL = new(models.URL)
 	URL.SetResponse(resp)
 
-	err := archiver.ProcessBody(URL, false, false, 0, os.TempDir())
+	err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil)
 	if err != nil {
 		t.Errorf("ProcessBody() error = %v", err)
 	}
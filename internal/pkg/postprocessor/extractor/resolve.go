@@ -35,6 +35,10 @@ func resolveURL(URL string, item *models.Item) (absolute string, err error) {
 		return link.String(), nil
 	}
 
+	if baseURL == nil {
+		return "", fmt.Errorf("no base URL to resolve %q against", URL)
+	}
+
 	// Resolve the relative URL against the base.
 	// The net/url.ResolveReference method follows RFC 3986, handling
 	// relative paths (including those starting with "/" or "../").
@@ -0,0 +1,79 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// parseSrcset returns the candidate URLs of a srcset attribute, following the parsing algorithm of the
+// HTML standard: https://html.spec.whatwg.org/multipage/images.html#parsing-a-srcset-attribute
+// URLs can contain commas (e.g. in their query), a comma only separates candidates when it ends the
+// URL or when it follows the descriptors. The descriptors (1.5x, 640w..) are not needed for extraction.
+func parseSrcset(srcset string) (URLs []string) {
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+	}
+
+	position := 0
+	for position < len(srcset) {
+		// Skip whitespaces and commas before the URL
+		for position < len(srcset) && (isSpace(srcset[position]) || srcset[position] == ',') {
+			position++
+		}
+
+		if position >= len(srcset) {
+			break
+		}
+
+		// The URL is everything up to the next whitespace
+		start := position
+		for position < len(srcset) && !isSpace(srcset[position]) {
+			position++
+		}
+
+		URL := srcset[start:position]
+
+		// Trailing commas of the URL mean that it has no descriptors
+		if strings.HasSuffix(URL, ",") {
+			URL = strings.TrimRight(URL, ",")
+		} else {
+			// Skip the descriptors, up to the next comma that isn't between parentheses
+			inParens := false
+			for position < len(srcset) {
+				c := srcset[position]
+				if c == '(' {
+					inParens = true
+				} else if c == ')' {
+					inParens = false
+				} else if c == ',' && !inParens {
+					position++
+					break
+				}
+
+				position++
+			}
+		}
+
+		if URL != "" {
+			URLs = append(URLs, URL)
+		}
+	}
+
+	return URLs
+}
+
+// srcsetAssets returns the candidate URLs of a srcset attribute resolved against the base of the page
+func srcsetAssets(item *models.Item, srcset string) (assets []string) {
+	for _, candidate := range parseSrcset(srcset) {
+		resolved, err := resolveURL(candidate, item)
+		if err != nil || resolved == "" {
+			assets = append(assets, candidate)
+			continue
+		}
+
+		assets = append(assets, resolved)
+	}
+
+	return assets
+}
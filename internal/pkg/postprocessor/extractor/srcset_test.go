@@ -0,0 +1,137 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		name   string
+		srcset string
+		want   []string
+	}{
+		{
+			name:   "width descriptors",
+			srcset: "image-320w.jpg 320w, image-480w.jpg 480w, image-800w.jpg 800w",
+			want:   []string{"image-320w.jpg", "image-480w.jpg", "image-800w.jpg"},
+		},
+		{
+			name:   "density descriptors without spaces after commas",
+			srcset: "a.png 1x,b.png 2x,c.png 3.5x",
+			want:   []string{"a.png", "b.png", "c.png"},
+		},
+		{
+			name:   "single URL without descriptor",
+			srcset: "/img/single.png",
+			want:   []string{"/img/single.png"},
+		},
+		{
+			name:   "trailing comma",
+			srcset: "a.jpg 1x, b.jpg 2x,",
+			want:   []string{"a.jpg", "b.jpg"},
+		},
+		{
+			name:   "URL without descriptor followed by comma",
+			srcset: "a.jpg, b.jpg 2x",
+			want:   []string{"a.jpg", "b.jpg"},
+		},
+		{
+			name:   "commas in the query string",
+			srcset: "https://img.ex.com/resize?w=320,h=200&fit=crop 320w, https://img.ex.com/resize?w=640,h=400&fit=crop 640w",
+			want:   []string{"https://img.ex.com/resize?w=320,h=200&fit=crop", "https://img.ex.com/resize?w=640,h=400&fit=crop"},
+		},
+		{
+			name:   "cloudinary style transformations",
+			srcset: "https://res.cloudinary.com/demo/image/upload/c_scale,w_400/sample.jpg 400w,\n\t\thttps://res.cloudinary.com/demo/image/upload/c_scale,w_800/sample.jpg 800w",
+			want:   []string{"https://res.cloudinary.com/demo/image/upload/c_scale,w_400/sample.jpg", "https://res.cloudinary.com/demo/image/upload/c_scale,w_800/sample.jpg"},
+		},
+		{
+			name:   "data URL",
+			srcset: "data:image/gif;base64,R0lGODlhAQABAAAAACw= 1x, real.png 2x",
+			want:   []string{"data:image/gif;base64,R0lGODlhAQABAAAAACw=", "real.png"},
+		},
+		{
+			name:   "extra whitespaces and empty candidates",
+			srcset: "  ,, a.jpg   640w  ,  , b.jpg 1280w  ",
+			want:   []string{"a.jpg", "b.jpg"},
+		},
+		{
+			name:   "empty",
+			srcset: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSrcset(tt.srcset)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseSrcset(%q) = %q, want %q", tt.srcset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLAssetsPicture(t *testing.T) {
+	config.InitConfig()
+	html := `
+	<html>
+		<body>
+			<picture>
+				<source type="image/webp" srcset="/img/photo.webp 1x, /img/photo@2x.webp 2x" sizes="(max-width: 600px) 100vw, 50vw">
+				<source media="(min-width: 800px)" srcset="https://cdn.ex.com/i?w=800,q=80 800w, https://cdn.ex.com/i?w=1600,q=80 1600w,">
+				<img src="/img/photo.jpg" srcset="photo-1x.jpg, photo-2x.jpg 2x" alt="photo">
+			</picture>
+		</body>
+	</html>
+	`
+
+	resp := &http.Response{
+		Body: io.NopCloser(bytes.NewBufferString(html)),
+	}
+	newURL := &models.URL{Raw: "http://ex.com/gallery/"}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+	item := models.NewItem("test", newURL, "")
+
+	assets, err := HTMLAssets(item)
+	if err != nil {
+		t.Errorf("HTMLAssets error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	expected := []string{
+		"http://ex.com/img/photo.webp",
+		"http://ex.com/img/photo@2x.webp",
+		"https://cdn.ex.com/i?w=800,q=80",
+		"https://cdn.ex.com/i?w=1600,q=80",
+		"/img/photo.jpg",
+		"http://ex.com/gallery/photo-1x.jpg",
+		"http://ex.com/gallery/photo-2x.jpg",
+	}
+
+	for _, URL := range expected {
+		if !slices.Contains(got, URL) {
+			t.Errorf("expected %s to be extracted, got %v", URL, got)
+		}
+	}
+}
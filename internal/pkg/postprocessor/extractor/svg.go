@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func IsSVG(URL *models.URL) bool {
+	return isContentType(URL.GetResponse().Header.Get("Content-Type"), "image/svg+xml") ||
+		(URL.GetMIMEType() != nil && URL.GetMIMEType().Is("image/svg+xml"))
+}
+
+// SVG extracts the resources referenced by an SVG document: href and xlink:href attributes
+// (images, external <use> references..), and url() references of its styles. Links (<a>) are ignored.
+func SVG(item *models.Item) (assets []*models.URL, err error) {
+	defer item.GetURL().RewindBody()
+
+	decoder := xml.NewDecoder(item.GetURL().GetBody())
+	decoder.Strict = false
+
+	var (
+		rawAssets []string
+		inStyle   bool
+	)
+
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			// Return the assets we got so far
+			break
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			inStyle = tok.Name.Local == "style"
+
+			for _, attr := range tok.Attr {
+				switch attr.Name.Local {
+				case "href", "src":
+					// Links are pages, not resources of the document
+					if tok.Name.Local == "a" {
+						continue
+					}
+
+					value := strings.TrimSpace(attr.Value)
+					if value != "" && !strings.HasPrefix(value, "#") && !hasPrefixFold(value, "data:") {
+						rawAssets = append(rawAssets, value)
+					}
+				case "style":
+					rawAssets = append(rawAssets, parseCSSURLs(attr.Value)...)
+				}
+			}
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if inStyle {
+				rawAssets = append(rawAssets, parseCSSURLs(string(tok))...)
+			}
+		}
+	}
+
+	for _, rawAsset := range rawAssets {
+		resolved, err := resolveURL(rawAsset, item)
+		if err != nil || resolved == "" {
+			continue
+		}
+
+		assets = append(assets, &models.URL{
+			Raw: resolved,
+		})
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestSVG(t *testing.T) {
+	config.InitConfig()
+	svg := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="100" height="100">
+	<style><![CDATA[
+		@font-face { src: url("../fonts/icons.woff2"); }
+	]]></style>
+	<defs><linearGradient id="g"/></defs>
+	<image href="photo.jpg" width="50" height="50"/>
+	<image xlink:href="https://cdn.example.com/legacy.png"/>
+	<use xlink:href="sprite.svg#icon"/>
+	<use href="#local"/>
+	<rect fill="url(#g)" style="background: url('bg.png')"/>
+	<image href="data:image/png;base64,iVBORw0KGgo="/>
+	<a href="https://example.com/page"><text>link</text></a>
+</svg>`
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"image/svg+xml"}},
+		Body:   io.NopCloser(bytes.NewBufferString(svg)),
+	}
+	newURL := &models.URL{Raw: "https://example.com/static/img/drawing.svg"}
+	if err := newURL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	newURL.SetResponse(resp)
+	err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil)
+	if err != nil {
+		t.Errorf("ProcessBody() error = %v", err)
+	}
+	item := models.NewItem("test", newURL, "")
+
+	if !IsSVG(newURL) {
+		t.Fatalf("IsSVG() = false, want true")
+	}
+
+	assets, err := SVG(item)
+	if err != nil {
+		t.Fatalf("SVG() error = %v", err)
+	}
+
+	var got []string
+	for _, asset := range assets {
+		got = append(got, asset.Raw)
+	}
+
+	want := []string{
+		"https://example.com/static/fonts/icons.woff2",
+		"https://example.com/static/img/photo.jpg",
+		"https://cdn.example.com/legacy.png",
+		"https://example.com/static/img/sprite.svg#icon",
+		"https://example.com/static/img/bg.png",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("SVG() = %q, want %q", got, want)
+	}
+}
@@ -13,8 +13,32 @@ var (
 	LinkRegexStrict = xurls.Strict()
 	LinkRegex       = regexp.MustCompile(`['"]((http|https)://[^'"]+)['"]`)
 	AssetsRegex     = `(?i)\b(?:src|href)=["']([^"']+\.(?:css|js|png|jpg|jpeg|gif|svg|webp|woff|woff2|ttf|eot))["']`
+
+	// rootRelativePathRegex matches paths like /api/v1/items or /static/app.js
+	rootRelativePathRegex = regexp.MustCompile(`^/[A-Za-z0-9._~%!$&'()*+,;=:@-][^\s"'<>\\^{|}]*$`)
+	// absoluteURLRegex matches http(s) and protocol-relative URLs without whitespaces
+	absoluteURLRegex = regexp.MustCompile(`^(?:https?:)?//[A-Za-z0-9.-]+(?::\d+)?(?:[/?#][^\s"'<>\\^{|}]*)?$`)
 )
 
+// The following heuristics are shared by the extractors looking for URLs in arbitrary
+// strings (JavaScript literals, JSON values) so that they stay consistent.
+
+// isTemplatedString returns true if the string contains placeholders (${, %s or {{),
+// such strings aren't usable URLs
+func isTemplatedString(s string) bool {
+	return strings.Contains(s, "${") || strings.Contains(s, "%s") || strings.Contains(s, "{{")
+}
+
+// isAbsoluteURL returns true if the string looks like an http(s) or protocol-relative URL
+func isAbsoluteURL(s string) bool {
+	return absoluteURLRegex.MatchString(s)
+}
+
+// isRootRelativePath returns true if the string looks like a path relative to the root of the host
+func isRootRelativePath(s string) bool {
+	return len(s) <= 2048 && rootRelativePathRegex.MatchString(s)
+}
+
 // hasFileExtension checks if a URL has a file extension in it.
 // It might yield false positives, like https://example.com/super.idea,
 // but it's good enough for our purposes.
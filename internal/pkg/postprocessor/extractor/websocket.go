@@ -0,0 +1,60 @@
+package extractor
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// webSocketURLRegex matches the ws and wss URLs without whitespaces
+var webSocketURLRegex = regexp.MustCompile(`^(?i:wss?)://[A-Za-z0-9.-]+(?::\d+)?(?:[/?#][^\s"'<>\\^{|}]*)?$`)
+
+// parseJSWebSockets returns the string literals of a script that are WebSocket endpoints (ws:// or wss:// URLs)
+func parseJSWebSockets(js string) (endpoints []string) {
+	return parseJSStrings(js, func(literal string) (string, bool) {
+		literal, ok := unescapeJSString(literal)
+		return literal, ok && webSocketURLRegex.MatchString(literal)
+	})
+}
+
+// webSocketHandshakes logs the WebSocket endpoints found in a script, with --websocket-discovery. With
+// --websocket-handshake, it returns the HTTP URLs of their handshakes, to capture the upgrade responses.
+func webSocketHandshakes(item *models.Item, js string) (handshakes []*models.URL) {
+	if !config.Get().WebSocketDiscovery {
+		return nil
+	}
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.extractor.webSocketHandshakes",
+	})
+
+	for _, endpoint := range parseJSWebSockets(js) {
+		logger.Info("WebSocket endpoint found", "endpoint", endpoint, "url", item.GetURL().String(), "item", item.GetShortID())
+
+		if !config.Get().WebSocketHandshake {
+			continue
+		}
+
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			continue
+		}
+
+		// The handshake is an HTTP GET on the same URL, wss is on top of TLS
+		if strings.EqualFold(parsed.Scheme, "wss") {
+			parsed.Scheme = "https"
+		} else {
+			parsed.Scheme = "http"
+		}
+
+		handshake := &models.URL{Raw: parsed.String()}
+		handshake.SetWebSocket(true)
+		handshakes = append(handshakes, handshake)
+	}
+
+	return handshakes
+}
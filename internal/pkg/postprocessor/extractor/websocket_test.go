@@ -0,0 +1,106 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestParseJSWebSockets(t *testing.T) {
+	js := `
+		const socket = new WebSocket("wss://live.example.com/socket?v=2");
+		const escaped = 'ws:\/\/chat.example.com:8080\/room';
+		// const old = "ws://commented.example.com/";
+		const page = "https://example.com/page";
+		const tpl = ` + "`wss://${host}/socket`" + `;
+		const upper = "WSS://upper.example.com/";
+	`
+
+	want := []string{
+		"wss://live.example.com/socket?v=2",
+		"ws://chat.example.com:8080/room",
+		"WSS://upper.example.com/",
+	}
+
+	if got := parseJSWebSockets(js); !slices.Equal(got, want) {
+		t.Errorf("parseJSWebSockets() = %q, want %q", got, want)
+	}
+}
+
+func TestHTMLAssetsWebSockets(t *testing.T) {
+	config.InitConfig()
+
+	html := `
+	<html>
+		<head>
+			<script>
+				var socket = new WebSocket("wss://live.ex.com/");
+				var fallback = "ws://ex.com/poll";
+			</script>
+		</head>
+	</html>
+	`
+
+	extract := func() []*models.URL {
+		newURL := &models.URL{Raw: "http://ex.com/page"}
+		if err := newURL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		newURL.SetResponse(&http.Response{Body: io.NopCloser(bytes.NewBufferString(html))})
+		if err := archiver.ProcessBody(newURL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		assets, err := HTMLAssets(models.NewItem("test", newURL, ""))
+		if err != nil {
+			t.Fatalf("HTMLAssets error = %v", err)
+		}
+
+		var handshakes []*models.URL
+		for _, asset := range assets {
+			if asset.IsWebSocket() {
+				handshakes = append(handshakes, asset)
+			}
+		}
+
+		return handshakes
+	}
+
+	defer func(extraction, discovery, handshake bool) {
+		config.Get().JSExtraction = extraction
+		config.Get().WebSocketDiscovery = discovery
+		config.Get().WebSocketHandshake = handshake
+	}(config.Get().JSExtraction, config.Get().WebSocketDiscovery, config.Get().WebSocketHandshake)
+
+	config.Get().JSExtraction = false
+	config.Get().WebSocketDiscovery = true
+	config.Get().WebSocketHandshake = false
+	if handshakes := extract(); len(handshakes) != 0 {
+		t.Errorf("expected the endpoints to be logged only, got %d handshakes", len(handshakes))
+	}
+
+	// The handshakes are captured without JavaScript extraction
+	config.Get().WebSocketHandshake = true
+
+	var got []string
+	for _, handshake := range extract() {
+		got = append(got, handshake.Raw)
+	}
+
+	want := []string{"https://live.ex.com/", "http://ex.com/poll"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected the handshakes %q, got %q", want, got)
+	}
+
+	config.Get().WebSocketDiscovery = false
+	if handshakes := extract(); len(handshakes) != 0 {
+		t.Errorf("expected no handshake with the discovery disabled, got %d", len(handshakes))
+	}
+}
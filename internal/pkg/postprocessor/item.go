@@ -8,6 +8,7 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/domainscrawl"
+	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/extractor"
 	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/sitespecific/reddit"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
@@ -39,15 +40,28 @@ func postprocessItem(item *models.Item) []*models.Item {
 			return outlinks
 		}
 
+		target, err := redirectTarget(item)
+		if err != nil {
+			logger.Warn("unable to follow redirection", "err", err.Error(), "item_id", item.GetShortID(), "location", item.GetURL().GetResponse().Header.Get("Location"))
+			item.SetStatus(models.ItemCompleted)
+			return outlinks
+		}
+
+		if isRedirectLoop(item, target) {
+			logger.Warn("redirect loop detected", "item_id", item.GetShortID(), "url", item.GetURL().String(), "location", target.String())
+			item.SetStatus(models.ItemCompleted)
+			return outlinks
+		}
+
 		// Prepare the new item resulting from the redirection
 		newURL := &models.URL{
-			Raw:       item.GetURL().GetResponse().Header.Get("Location"),
+			Raw:       target.String(),
 			Redirects: item.GetURL().GetRedirects() + 1,
 			Hops:      item.GetURL().GetHops(),
 		}
 
 		newChild := models.NewItem(uuid.New().String(), newURL, "")
-		err := item.AddChild(newChild, models.ItemGotRedirected)
+		err = item.AddChild(newChild, models.ItemGotRedirected)
 		if err != nil {
 			panic(err)
 		}
@@ -86,12 +100,20 @@ func postprocessItem(item *models.Item) []*models.Item {
 		logger.Debug("assets capture and domains crawl are disabled", "item_id", item.GetShortID())
 		item.SetStatus(models.ItemCompleted)
 		return outlinks
+	} else if item.GetURL().IsSoft404() {
+		logger.Debug("item is a soft 404, skipping extraction", "item_id", item.GetShortID())
+		item.SetStatus(models.ItemCompleted)
+		return outlinks
 	}
 
-	if item.GetURL().GetResponse() != nil && item.GetURL().GetResponse().StatusCode == 200 {
-		logger.Debug("item is a success", "item_id", item.GetShortID())
+	if item.GetURL().GetResponse() != nil && shouldExtract(item.GetURL().GetResponse().StatusCode) {
+		logger.Debug("extracting item", "item_id", item.GetShortID(), "status", item.GetURL().GetResponse().StatusCode)
 
-		var outlinksFromAssets []*models.URL
+		var (
+			outlinksFromAssets []*models.URL
+			capturedAssets     []*models.URL
+			capturedOutlinks   []*models.URL
+		)
 
 		// Extract assets from the page
 		if shouldExtractAssets(item) {
@@ -102,6 +124,7 @@ func postprocessItem(item *models.Item) []*models.Item {
 			if err != nil {
 				logger.Error("unable to extract assets", "err", err.Error(), "item_id", item.GetShortID())
 			} else {
+				var validAssets []*models.URL
 				for i := range assets {
 					if assets[i] == nil {
 						logger.Warn("nil asset", "item", item.GetShortID())
@@ -123,19 +146,39 @@ func postprocessItem(item *models.Item) []*models.Item {
 						}
 					}
 
+					validAssets = append(validAssets, assets[i])
+				}
+				assets = validAssets
+
+				if kept := capLinks(assets, config.Get().MaxAssetsPerPage, config.Get().MaxLinksSelection); len(kept) < len(assets) {
+					logger.Warn("too many assets, page truncated", "item_id", item.GetShortID(), "url", item.GetURL().String(), "count", len(assets), "kept", len(kept), "selection", config.Get().MaxLinksSelection)
+					assets = kept
+				}
+
+				for i := range assets {
 					newChild := models.NewItem(uuid.New().String(), assets[i], "")
 					err = item.AddChild(newChild, models.ItemGotChildren)
 					if err != nil {
 						panic(err)
 					}
+
+					capturedAssets = append(capturedAssets, assets[i])
 				}
 
 				logger.Debug("extracted assets", "item_id", item.GetShortID(), "count", len(assets))
 			}
 		}
 
+		// A page whose canonical URL is another page of the same host is a duplicate, with --canonical follow
+		// the canonical is queued instead of the outlinks of the page
+		canonical := checkCanonical(item)
+
 		// Extract outlinks from the page
-		if shouldExtractOutlinks(item) {
+		if canonical != nil {
+			logger.Debug("skipping outlinks of a non-canonical page", "item_id", item.GetShortID(), "canonical", canonical.GetURL().String())
+			outlinks = append(outlinks, canonical)
+			capturedOutlinks = append(capturedOutlinks, canonical.GetURL())
+		} else if shouldExtractOutlinks(item) {
 			newOutlinks, err := extractOutlinks(item)
 			if err != nil {
 				logger.Error("unable to extract outlinks", "err", err.Error(), "item_id", item.GetShortID())
@@ -143,6 +186,7 @@ func postprocessItem(item *models.Item) []*models.Item {
 				// Append the outlinks found from the assets
 				newOutlinks = append(newOutlinks, outlinksFromAssets...)
 
+				var newOutlinkItems []*models.Item
 				for i := range newOutlinks {
 					if newOutlinks[i] == nil {
 						logger.Warn("nil link", "item_id", item.GetShortID())
@@ -155,22 +199,46 @@ func postprocessItem(item *models.Item) []*models.Item {
 					if domainscrawl.Enabled() && domainscrawl.Match(newOutlinks[i].Raw) {
 						logger.Debug("setting hop count to 0 (domains crawl)", "item_id", item.GetShortID(), "url", newOutlinks[i].Raw)
 						newOutlinks[i].SetHops(0)
-					} else if domainscrawl.Enabled() && !domainscrawl.Match(newOutlinks[i].Raw) && item.GetURL().GetHops() >= config.Get().MaxHops {
+					} else if domainscrawl.Enabled() && !domainscrawl.Match(newOutlinks[i].Raw) && item.GetURL().GetHops() >= item.EffectiveMaxHops(config.Get().MaxHops) {
 						logger.Debug("skipping outlink due to hop count", "item_id", item.GetShortID(), "url", newOutlinks[i].Raw)
 						continue
 					}
 
-					newOutlinkItem := models.NewItem(uuid.New().String(), newOutlinks[i], item.GetURL().String())
+					newOutlinkItems = append(newOutlinkItems, models.NewItem(uuid.New().String(), newOutlinks[i], item.GetURL().String()))
+				}
+
+				// Pages with a huge number of outlinks, like link farms, would flood the queue
+				if kept := capLinks(newOutlinkItems, config.Get().MaxOutlinksPerPage, config.Get().MaxLinksSelection); len(kept) < len(newOutlinkItems) {
+					logger.Warn("too many outlinks, page truncated", "item_id", item.GetShortID(), "url", item.GetURL().String(), "count", len(newOutlinkItems), "kept", len(kept), "selection", config.Get().MaxLinksSelection)
+					newOutlinkItems = kept
+				}
+
+				for _, newOutlinkItem := range newOutlinkItems {
 					outlinks = append(outlinks, newOutlinkItem)
+					capturedOutlinks = append(capturedOutlinks, newOutlinkItem.GetURL())
 				}
 
 				logger.Debug("extracted outlinks", "item_id", item.GetShortID(), "count", len(newOutlinks))
 			}
 		}
+
+		// Follow meta refresh redirections, like HTTP redirections they keep the hop of the page
+		if extractor.IsHTML(item.GetURL()) && item.GetURL().GetBody() != nil {
+			if metaRefresh := followMetaRefresh(item); metaRefresh != nil {
+				outlinks = append(outlinks, metaRefresh)
+				capturedOutlinks = append(capturedOutlinks, metaRefresh.GetURL())
+			}
+		}
+
+		// Record the discovered links in a WARC metadata record if needed
+		if config.Get().WARCOutlinksMetadata {
+			writeOutlinksMetadata(item, capturedAssets, capturedOutlinks)
+		}
 	}
 
-	// Make sure the goquery document's memory can be freed
+	// Make sure the goquery document's and the scanned page's memory can be freed
 	item.GetURL().SetDocument(nil)
+	extractor.ReleaseHTMLPage(item.GetURL())
 
 	if !item.HasChildren() && !item.HasRedirection() && item.GetStatus() != models.ItemFailed {
 		logger.Debug("item has no children, setting as completed", "item_id", item.GetShortID())
@@ -179,3 +247,9 @@ func postprocessItem(item *models.Item) []*models.Item {
 
 	return outlinks
 }
+
+// shouldExtract returns true if the assets and outlinks of a response with this status are extracted,
+// only the 2xx ones unless --extract-only-on-2xx is turned off. Redirections are followed before.
+func shouldExtract(statusCode int) bool {
+	return !config.Get().ExtractOnlyOn2xx || (statusCode >= 200 && statusCode < 300)
+}
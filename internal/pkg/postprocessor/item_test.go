@@ -0,0 +1,78 @@
+package postprocessor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestShouldExtract(t *testing.T) {
+	config.InitConfig()
+
+	defer func(only2xx bool) { config.Get().ExtractOnlyOn2xx = only2xx }(config.Get().ExtractOnlyOn2xx)
+
+	config.Get().ExtractOnlyOn2xx = true
+	for statusCode, want := range map[int]bool{200: true, 203: true, 206: true, 404: false, 500: false} {
+		if got := shouldExtract(statusCode); got != want {
+			t.Errorf("shouldExtract(%d) = %t, want %t with --extract-only-on-2xx", statusCode, got, want)
+		}
+	}
+
+	config.Get().ExtractOnlyOn2xx = false
+	for _, statusCode := range []int{200, 404, 500} {
+		if !shouldExtract(statusCode) {
+			t.Errorf("shouldExtract(%d) = false, want true without --extract-only-on-2xx", statusCode)
+		}
+	}
+}
+
+func TestPostprocessAssetsCappedAfterFiltering(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxAssets int, selection string, extensions []string) {
+		config.Get().MaxAssetsPerPage = maxAssets
+		config.Get().MaxLinksSelection = selection
+		config.Get().ExcludeAssetExtensions = extensions
+	}(config.Get().MaxAssetsPerPage, config.Get().MaxLinksSelection, config.Get().ExcludeAssetExtensions)
+	config.Get().MaxAssetsPerPage = 1
+	config.Get().MaxLinksSelection = "first"
+	config.Get().ExcludeAssetExtensions = []string{"mp4"}
+
+	// The video is excluded and the first image can't be unescaped, only the last image is kept
+	html := `<html><body><video src="/video.mp4"></video><img src="/bad%zz.png"><img src="/good.png"></body></html>`
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       io.NopCloser(bytes.NewBufferString(html)),
+	}
+
+	URL := &models.URL{Raw: "https://www.reddit.com/r/test"}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	URL.SetResponse(resp)
+
+	if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+		t.Fatalf("ProcessBody() error = %v", err)
+	}
+
+	item := models.NewItem("page", URL, "")
+	item.SetStatus(models.ItemArchived)
+
+	postprocessItem(item)
+
+	var assets []string
+	for _, child := range item.GetChildren() {
+		assets = append(assets, child.GetURL().Raw)
+	}
+
+	if len(assets) != 1 || assets[0] != "/good.png" {
+		t.Errorf("expected the asset cap to keep /good.png, got %v", assets)
+	}
+}
@@ -0,0 +1,53 @@
+package postprocessor
+
+import (
+	"slices"
+
+	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/extractor"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+var (
+	// linkHeaderAssetRels are the relation types of the Link headers whose targets are needed by the page,
+	// they are captured as its assets. rel="shortcut icon" is matched by icon.
+	linkHeaderAssetRels = []string{"preload", "modulepreload", "prefetch", "stylesheet", "icon"}
+	// linkHeaderIgnoredRels are the relation types of the Link headers whose targets aren't captured: origins
+	// to connect to and the canonical URL, checked by checkCanonical
+	linkHeaderIgnoredRels = []string{"preconnect", "dns-prefetch", "canonical"}
+)
+
+// linkHeaderAssets returns the targets of the Link headers of the response that are assets of the page
+func linkHeaderAssets(item *models.Item) (assets []*models.URL) {
+	for _, link := range extractor.HeaderLinks(item.GetURL()) {
+		if slices.ContainsFunc(linkHeaderAssetRels, link.HasRel) {
+			assets = append(assets, &models.URL{Raw: link.URL})
+		}
+	}
+
+	return assets
+}
+
+// linkHeaderOutlinks returns the targets of the Link headers of the response that are outlinks: the pagination
+// (next, prev) and the links of the other relation types, except the assets and the ignored ones
+func linkHeaderOutlinks(item *models.Item) (outlinks []*models.URL) {
+	for _, link := range extractor.HeaderLinks(item.GetURL()) {
+		if slices.ContainsFunc(linkHeaderAssetRels, link.HasRel) || slices.ContainsFunc(linkHeaderIgnoredRels, link.HasRel) {
+			continue
+		}
+
+		outlinks = append(outlinks, &models.URL{Raw: link.URL})
+	}
+
+	return outlinks
+}
+
+// linkHeaderCanonical returns the target of the canonical Link header of the response, if any
+func linkHeaderCanonical(item *models.Item) (canonical string, found bool) {
+	for _, link := range extractor.HeaderLinks(item.GetURL()) {
+		if link.HasRel("canonical") {
+			return link.URL, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,56 @@
+package postprocessor
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestLinkHeaderRels(t *testing.T) {
+	URL := &models.URL{Raw: "https://example.com/page"}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	URL.SetResponse(&http.Response{
+		Header: http.Header{
+			"Link": []string{
+				`</font.woff2>; rel=preload; as=font, </page?p=2>; rel="next", </page?p=0>; rel="prev"`,
+				`<https://cdn.example.com>; rel=preconnect, </canonical>; rel=canonical, </about>; rel=author`,
+				`</style.css>; rel=stylesheet, </favicon.ico>; rel="shortcut icon"`,
+			},
+		},
+	})
+
+	item := models.NewItem("stub", URL, "")
+
+	var assets []string
+	for _, asset := range linkHeaderAssets(item) {
+		assets = append(assets, asset.Raw)
+	}
+
+	if expected := []string{"https://example.com/font.woff2", "https://example.com/style.css", "https://example.com/favicon.ico"}; !slices.Equal(assets, expected) {
+		t.Errorf("assets = %v, want %v", assets, expected)
+	}
+
+	var outlinks []string
+	for _, outlink := range linkHeaderOutlinks(item) {
+		outlinks = append(outlinks, outlink.Raw)
+	}
+
+	expected := []string{"https://example.com/page?p=2", "https://example.com/page?p=0", "https://example.com/about"}
+	if len(outlinks) != len(expected) {
+		t.Fatalf("outlinks = %v, want %v", outlinks, expected)
+	}
+	for i := range expected {
+		if outlinks[i] != expected[i] {
+			t.Errorf("outlinks[%d] = %s, want %s", i, outlinks[i], expected[i])
+		}
+	}
+
+	if canonical, found := linkHeaderCanonical(item); !found || canonical != "https://example.com/canonical" {
+		t.Errorf("linkHeaderCanonical() = %q, %v", canonical, found)
+	}
+}
@@ -0,0 +1,29 @@
+package postprocessor
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// capLinks returns at most limit of the links of a page, set by --max-outlinks-per-page and
+// --max-assets-per-page: the first ones, or a random subset with "sample" as selection,
+// in the order of the page either way. A limit of 0 keeps them all.
+func capLinks[T any](links []T, limit int, selection string) []T {
+	if limit <= 0 || len(links) <= limit {
+		return links
+	}
+
+	if selection != "sample" {
+		return links[:limit]
+	}
+
+	indexes := rand.Perm(len(links))[:limit]
+	slices.Sort(indexes)
+
+	sampled := make([]T, 0, limit)
+	for _, index := range indexes {
+		sampled = append(sampled, links[index])
+	}
+
+	return sampled
+}
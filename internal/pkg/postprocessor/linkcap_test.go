@@ -0,0 +1,37 @@
+package postprocessor
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCapLinks(t *testing.T) {
+	links := []string{"a", "b", "c", "d", "e"}
+
+	if got := capLinks(links, 0, "first"); !reflect.DeepEqual(got, links) {
+		t.Errorf("expected all the links without limit, got %q", got)
+	}
+
+	if got := capLinks(links, 10, "sample"); !reflect.DeepEqual(got, links) {
+		t.Errorf("expected all the links under the limit, got %q", got)
+	}
+
+	if got, want := capLinks(links, 2, "first"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("capLinks(first) = %q, want %q", got, want)
+	}
+
+	for range 20 {
+		sampled := capLinks(links, 3, "sample")
+		if len(sampled) != 3 {
+			t.Fatalf("expected 3 sampled links, got %q", sampled)
+		}
+
+		// The sample keeps the order of the page, without duplicates
+		for i, link := range sampled {
+			if !slices.Contains(links, link) || (i > 0 && slices.Index(links, sampled[i-1]) >= slices.Index(links, link)) {
+				t.Fatalf("expected a subset in the order of the page, got %q", sampled)
+			}
+		}
+	}
+}
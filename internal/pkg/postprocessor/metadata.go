@@ -0,0 +1,118 @@
+package postprocessor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// maxOutlinksMetadataEntries is the maximum number of links written in a single
+// outlinks metadata record, extremely link-heavy pages get truncated past that
+const maxOutlinksMetadataEntries = 50000
+
+type outlinksMetadata struct {
+	URL       string   `json:"url"`
+	Outlinks  []string `json:"outlinks"`
+	Assets    []string `json:"assets"`
+	Total     int      `json:"total"`
+	Truncated bool     `json:"truncated"`
+}
+
+// writeOutlinksMetadata writes a WARC metadata record listing the assets and outlinks discovered on the item's page
+func writeOutlinksMetadata(item *models.Item, assets, outlinks []*models.URL) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.writeOutlinksMetadata",
+	})
+
+	if len(assets) == 0 && len(outlinks) == 0 {
+		return
+	}
+
+	base := item.GetURL().GetParsed()
+	if item.GetBase() != "" {
+		if parsedBase, err := url.Parse(item.GetBase()); err == nil {
+			base = base.ResolveReference(parsedBase)
+		}
+	}
+
+	metadata := outlinksMetadata{
+		URL:   item.GetURL().String(),
+		Total: len(assets) + len(outlinks),
+	}
+
+	for _, outlink := range outlinks {
+		if len(metadata.Outlinks) >= maxOutlinksMetadataEntries {
+			metadata.Truncated = true
+			break
+		}
+		metadata.Outlinks = append(metadata.Outlinks, resolveMetadataURL(base, outlink.Raw))
+	}
+
+	for _, asset := range assets {
+		if len(metadata.Outlinks)+len(metadata.Assets) >= maxOutlinksMetadataEntries {
+			metadata.Truncated = true
+			break
+		}
+		metadata.Assets = append(metadata.Assets, resolveMetadataURL(base, asset.Raw))
+	}
+
+	contentType, payload, err := formatOutlinksMetadata(&metadata, config.Get().WARCOutlinksFormat)
+	if err != nil {
+		logger.Error("unable to format outlinks metadata", "err", err.Error(), "item_id", item.GetShortID())
+		return
+	}
+
+	if metadata.Truncated {
+		logger.Warn("outlinks metadata truncated", "item_id", item.GetShortID(), "total", metadata.Total, "max", maxOutlinksMetadataEntries)
+	}
+
+	if err := archiver.WriteMetadataRecord(metadata.URL, item.GetURL().GetRecordID(), contentType, payload); err != nil {
+		logger.Error("unable to write outlinks metadata record", "err", err.Error(), "item_id", item.GetShortID())
+	}
+}
+
+// formatOutlinksMetadata returns the content type and the body of the metadata record in the given format.
+// The heritrix format mimics the outlink lines Heritrix writes in its metadata records, L being
+// the path of navigational links and E the path of embedded assets.
+func formatOutlinksMetadata(metadata *outlinksMetadata, format string) (contentType string, payload []byte, err error) {
+	switch format {
+	case "json":
+		payload, err = json.Marshal(metadata)
+		return "application/json", payload, err
+	case "heritrix", "":
+		var buf bytes.Buffer
+		for _, outlink := range metadata.Outlinks {
+			fmt.Fprintf(&buf, "outlink: %s L =NAVLINK_MISC\r\n", outlink)
+		}
+		for _, asset := range metadata.Assets {
+			fmt.Fprintf(&buf, "outlink: %s E =EMBED_MISC\r\n", asset)
+		}
+		if metadata.Truncated {
+			fmt.Fprintf(&buf, "outlinks-truncated: %d of %d links written\r\n", len(metadata.Outlinks)+len(metadata.Assets), metadata.Total)
+		}
+		return "application/warc-fields", buf.Bytes(), nil
+	default:
+		return "", nil, fmt.Errorf("unknown outlinks metadata format %q", format)
+	}
+}
+
+func resolveMetadataURL(base *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if base == nil {
+		return raw
+	}
+
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return base.ResolveReference(ref).String()
+}
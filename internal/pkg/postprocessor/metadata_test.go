@@ -0,0 +1,82 @@
+package postprocessor
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormatOutlinksMetadata(t *testing.T) {
+	metadata := &outlinksMetadata{
+		URL:      "https://example.com/",
+		Outlinks: []string{"https://example.com/page"},
+		Assets:   []string{"https://example.com/style.css"},
+		Total:    2,
+	}
+
+	contentType, payload, err := formatOutlinksMetadata(metadata, "heritrix")
+	if err != nil {
+		t.Fatalf("formatOutlinksMetadata() error = %v", err)
+	}
+	if contentType != "application/warc-fields" {
+		t.Errorf("expected application/warc-fields content type, got %s", contentType)
+	}
+	expected := "outlink: https://example.com/page L =NAVLINK_MISC\r\noutlink: https://example.com/style.css E =EMBED_MISC\r\n"
+	if string(payload) != expected {
+		t.Errorf("unexpected heritrix payload:\n%q\nwant:\n%q", payload, expected)
+	}
+
+	contentType, payload, err = formatOutlinksMetadata(metadata, "json")
+	if err != nil {
+		t.Fatalf("formatOutlinksMetadata() error = %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("expected application/json content type, got %s", contentType)
+	}
+	var decoded outlinksMetadata
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unable to decode JSON payload: %v", err)
+	}
+	if decoded.URL != metadata.URL || len(decoded.Outlinks) != 1 || len(decoded.Assets) != 1 {
+		t.Errorf("unexpected JSON payload: %s", payload)
+	}
+
+	if _, _, err := formatOutlinksMetadata(metadata, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestFormatOutlinksMetadataTruncated(t *testing.T) {
+	metadata := &outlinksMetadata{
+		URL:       "https://example.com/",
+		Outlinks:  []string{"https://example.com/page"},
+		Total:     60000,
+		Truncated: true,
+	}
+
+	_, payload, err := formatOutlinksMetadata(metadata, "heritrix")
+	if err != nil {
+		t.Fatalf("formatOutlinksMetadata() error = %v", err)
+	}
+	if !strings.Contains(string(payload), "outlinks-truncated: 1 of 60000 links written") {
+		t.Errorf("expected truncation note in payload, got %q", payload)
+	}
+}
+
+func TestResolveMetadataURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/dir/page.html")
+
+	tests := map[string]string{
+		"https://other.com/a": "https://other.com/a",
+		"/root.css":           "https://example.com/root.css",
+		"img.png":             "https://example.com/dir/img.png",
+		" ../up.js ":          "https://example.com/up.js",
+	}
+
+	for raw, want := range tests {
+		if got := resolveMetadataURL(base, raw); got != want {
+			t.Errorf("resolveMetadataURL(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
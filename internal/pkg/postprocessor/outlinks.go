@@ -2,8 +2,10 @@ package postprocessor
 
 import (
 	"io"
+	"net/url"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/postprocessor/domainscrawl"
@@ -17,6 +19,7 @@ import (
 func extractOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 	var (
 		contentType = item.GetURL().GetResponse().Header.Get("Content-Type")
+		extracted   = true // False if no extractor handles the content type
 		logger      = log.NewFieldedLogger(&log.Fields{
 			"component": "postprocessor.extractOutlinks",
 		})
@@ -47,6 +50,12 @@ func extractOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 			logger.Error("unable to extract outlinks from S3", "extractor", "S3", "err", err.Error(), "item", item.GetShortID(), "url", item.GetURL().String())
 			return outlinks, err
 		}
+	case extractor.IsFeed(item.GetURL()):
+		outlinks, err = extractor.Feed(item.GetURL())
+		if err != nil {
+			logger.Error("unable to extract outlinks", "extractor", "Feed", "err", err.Error(), "item", item.GetShortID(), "url", item.GetURL().String())
+			return outlinks, err
+		}
 	case extractor.IsSitemapXML(item.GetURL()):
 		var assets []*models.URL
 
@@ -79,17 +88,14 @@ func extractOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 		}
 	default:
 		logger.Debug("no extractor used for page", "content-type", contentType, "item", item.GetShortID(), "url", item.GetURL().String())
-		return outlinks, nil
+		extracted = false
 	}
 
-	// Try to extract links from link headers
-	linksFromLinkHeader := extractor.ExtractURLsFromHeader(item.GetURL())
-	if linksFromLinkHeader != nil {
-		outlinks = append(outlinks, linksFromLinkHeader...)
-	}
+	// Extract the outlinks of the Link headers, whatever the content type
+	outlinks = append(outlinks, linkHeaderOutlinks(item)...)
 
 	// If the page is a text/* content type, extract links from the body (aggressively)
-	if strings.Contains(contentType, "text/") {
+	if extracted && strings.Contains(contentType, "text/") {
 		outlinks = append(outlinks, extractLinksFromPage(item.GetURL())...)
 	}
 
@@ -101,6 +107,114 @@ func extractOutlinks(item *models.Item) (outlinks []*models.URL, err error) {
 	return outlinks, nil
 }
 
+// followMetaRefresh returns the target of the meta refresh of the page as a new item at the same hop,
+// if its delay is within the configured maximum and it doesn't point back at the page itself
+func followMetaRefresh(item *models.Item) *models.Item {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.followMetaRefresh",
+	})
+
+	if config.Get().MetaRefreshMaxDelay < 0 {
+		return nil
+	}
+
+	target, delay, found := extractor.MetaRefresh(item)
+	if !found {
+		return nil
+	}
+
+	if delay > float64(config.Get().MetaRefreshMaxDelay) {
+		logger.Debug("not following meta refresh, delay too long", "item_id", item.GetShortID(), "url", target, "delay", delay)
+		return nil
+	}
+
+	targetURL := &models.URL{
+		Raw:  target,
+		Hops: item.GetURL().GetHops(),
+	}
+
+	if err := targetURL.Parse(); err != nil {
+		logger.Debug("not following meta refresh, invalid URL", "item_id", item.GetShortID(), "url", target, "err", err.Error())
+		return nil
+	}
+
+	// A page refreshing itself would be a loop, fragments don't make it another page
+	if sameDocument(item.GetURL().String(), target) {
+		logger.Debug("not following meta refresh, target is the page itself", "item_id", item.GetShortID(), "url", target)
+		return nil
+	}
+
+	logger.Debug("following meta refresh", "item_id", item.GetShortID(), "url", target, "delay", delay)
+
+	return models.NewItem(uuid.New().String(), targetURL, item.GetURL().String())
+}
+
+// checkCanonical logs the canonical URL of the page when it's another page of the same host, with --canonical.
+// In follow mode, it's returned as a new item at the same hop, the outlinks of the page are then not extracted.
+func checkCanonical(item *models.Item) *models.Item {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.checkCanonical",
+	})
+
+	mode := config.Get().Canonical
+	if mode != "log" && mode != "follow" {
+		return nil
+	}
+
+	// The <link rel="canonical"> of the page prevails over the canonical Link header of the response
+	var (
+		canonical string
+		found     bool
+	)
+	if extractor.IsHTML(item.GetURL()) && item.GetURL().GetBody() != nil {
+		canonical, found = extractor.Canonical(item)
+	}
+	if !found {
+		canonical, found = linkHeaderCanonical(item)
+	}
+
+	if !found || sameDocument(item.GetURL().String(), canonical) {
+		return nil
+	}
+
+	canonicalURL := &models.URL{
+		Raw:  canonical,
+		Hops: item.GetURL().GetHops(),
+	}
+
+	if err := canonicalURL.Parse(); err != nil {
+		logger.Debug("ignoring canonical, invalid URL", "item_id", item.GetShortID(), "canonical", canonical, "err", err.Error())
+		return nil
+	}
+
+	// Another host isn't a duplicate of this page, a syndicated article for example
+	if !strings.EqualFold(canonicalURL.GetParsed().Hostname(), item.GetURL().GetParsed().Hostname()) {
+		logger.Debug("ignoring canonical, other host", "item_id", item.GetShortID(), "canonical", canonical)
+		return nil
+	}
+
+	logger.Info("page has another canonical URL", "item_id", item.GetShortID(), "url", item.GetURL().String(), "canonical", canonicalURL.String())
+
+	if mode != "follow" {
+		return nil
+	}
+
+	return models.NewItem(uuid.New().String(), canonicalURL, item.GetURL().String())
+}
+
+func sameDocument(a, b string) bool {
+	parsedA, errA := url.Parse(a)
+	parsedB, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	parsedA.Fragment, parsedA.RawFragment = "", ""
+	parsedB.Fragment, parsedB.RawFragment = "", ""
+
+	return parsedA.String() == parsedB.String()
+}
+
 func extractLinksFromPage(URL *models.URL) (links []*models.URL) {
 	defer URL.RewindBody()
 
@@ -130,7 +244,7 @@ func shouldExtractOutlinks(item *models.Item) bool {
 	}
 
 	// Match pure hops count
-	if item.GetURL().GetHops() < config.Get().MaxHops && item.GetURL().GetBody() != nil {
+	if item.GetURL().GetHops() < item.EffectiveMaxHops(config.Get().MaxHops) && item.GetURL().GetBody() != nil {
 		return true
 	}
 
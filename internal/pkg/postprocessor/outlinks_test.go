@@ -0,0 +1,180 @@
+package postprocessor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestFollowMetaRefresh(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxDelay int) {
+		config.Get().MetaRefreshMaxDelay = maxDelay
+	}(config.Get().MetaRefreshMaxDelay)
+
+	newItem := func(content string) *models.Item {
+		html := `<html><head><meta http-equiv="refresh" content="` + content + `"></head></html>`
+		resp := &http.Response{
+			Header: http.Header{"Content-Type": []string{"text/html"}},
+			Body:   io.NopCloser(bytes.NewBufferString(html)),
+		}
+
+		URL := &models.URL{Raw: "http://example.com/stub", Hops: 2}
+		if err := URL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		URL.SetResponse(resp)
+
+		if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		return models.NewItem("stub", URL, "")
+	}
+
+	config.Get().MetaRefreshMaxDelay = 10
+
+	target := followMetaRefresh(newItem("0;url=/destination"))
+	if target == nil {
+		t.Fatalf("expected the meta refresh to be followed")
+	}
+
+	if target.GetURL().String() != "http://example.com/destination" {
+		t.Errorf("target URL = %q, want %q", target.GetURL().String(), "http://example.com/destination")
+	}
+
+	if target.GetURL().GetHops() != 2 {
+		t.Errorf("target hops = %d, want 2", target.GetURL().GetHops())
+	}
+
+	if target.GetSeedVia() != "http://example.com/stub" {
+		t.Errorf("target via = %q, want the stub page", target.GetSeedVia())
+	}
+
+	if followMetaRefresh(newItem("30;url=/later")) != nil {
+		t.Errorf("expected a meta refresh with a long delay to be ignored")
+	}
+
+	if followMetaRefresh(newItem("0;url=/stub#top")) != nil {
+		t.Errorf("expected a meta refresh to the page itself to be ignored")
+	}
+
+	config.Get().MetaRefreshMaxDelay = -1
+	if followMetaRefresh(newItem("0;url=/destination")) != nil {
+		t.Errorf("expected meta refresh following to be disabled")
+	}
+}
+
+func TestCheckCanonical(t *testing.T) {
+	config.InitConfig()
+
+	defer func(mode string) {
+		config.Get().Canonical = mode
+	}(config.Get().Canonical)
+
+	newItem := func(canonical string) *models.Item {
+		html := `<html><head><link rel="canonical" href="` + canonical + `"></head></html>`
+		resp := &http.Response{
+			Header: http.Header{"Content-Type": []string{"text/html"}},
+			Body:   io.NopCloser(bytes.NewBufferString(html)),
+		}
+
+		URL := &models.URL{Raw: "http://example.com/article?utm_source=feed", Hops: 1}
+		if err := URL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		URL.SetResponse(resp)
+
+		if err := archiver.ProcessBody(URL, false, false, 0, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		return models.NewItem("stub", URL, "")
+	}
+
+	config.Get().Canonical = "follow"
+
+	canonical := checkCanonical(newItem("/article"))
+	if canonical == nil {
+		t.Fatalf("expected the canonical to be followed")
+	}
+
+	if canonical.GetURL().String() != "http://example.com/article" || canonical.GetURL().GetHops() != 1 {
+		t.Errorf("canonical = %q at hop %d, want http://example.com/article at hop 1", canonical.GetURL().String(), canonical.GetURL().GetHops())
+	}
+
+	if checkCanonical(newItem("http://example.com/article?utm_source=feed#comments")) != nil {
+		t.Errorf("expected a canonical pointing at the page itself to be ignored")
+	}
+
+	if checkCanonical(newItem("http://other.example.org/article")) != nil {
+		t.Errorf("expected a canonical on another host to be ignored")
+	}
+
+	config.Get().Canonical = "log"
+	if checkCanonical(newItem("/article")) != nil {
+		t.Errorf("expected the canonical to only be logged")
+	}
+
+	config.Get().Canonical = "off"
+	if checkCanonical(newItem("/article")) != nil {
+		t.Errorf("expected the canonical to be ignored")
+	}
+}
+
+func TestShouldExtractOutlinksMaxHops(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxHops int) { config.Get().MaxHops = maxHops }(config.Get().MaxHops)
+
+	newSeed := func(hops, maxHops int) *models.Item {
+		URL := &models.URL{Raw: "http://example.com/page", Hops: hops}
+		if err := URL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		URL.SetResponse(&http.Response{
+			Header: http.Header{"Content-Type": []string{"text/html"}},
+			Body:   io.NopCloser(bytes.NewBufferString(`<html><a href="/next">next</a></html>`)),
+		})
+
+		if err := archiver.ProcessBody(URL, false, false, 1, os.TempDir(), 0, nil); err != nil {
+			t.Fatalf("ProcessBody() error = %v", err)
+		}
+
+		seed := models.NewItem("seed", URL, "")
+		seed.SetMaxHops(maxHops)
+
+		return seed
+	}
+
+	tests := []struct {
+		name          string
+		globalMaxHops int
+		hops          int
+		maxHops       int
+		want          bool
+	}{
+		{"global max hops", 2, 1, -1, true},
+		{"global max hops reached", 2, 2, -1, false},
+		{"deeper seed", 0, 1, 3, true},
+		{"seed max hops reached", 5, 3, 3, false},
+		{"seed without outlinks", 5, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Get().MaxHops = tt.globalMaxHops
+
+			if got := shouldExtractOutlinks(newSeed(tt.hops, tt.maxHops)); got != tt.want {
+				t.Errorf("shouldExtractOutlinks() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ package postprocessor
 import (
 	"context"
 	"fmt"
+	"path"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
@@ -19,6 +21,9 @@ type postprocessor struct {
 	cancel   context.CancelFunc
 	inputCh  chan *models.Item
 	outputCh chan *models.Item
+	dedupe   *dedupeWindow
+	spill    *spill // Outlinks spilled on disk while the memory is under pressure, nil if disabled
+	pressure *memoryPressure
 }
 
 var (
@@ -30,7 +35,10 @@ var (
 // This functions starts the preprocessor responsible for preparing
 // the seeds sent by the reactor for captures
 func Start(inputChan, outputChan chan *models.Item) error {
-	var done bool
+	var (
+		done bool
+		err  error
+	)
 
 	log.Start()
 	logger = log.NewFieldedLogger(&log.Fields{
@@ -46,8 +54,28 @@ func Start(inputChan, outputChan chan *models.Item) error {
 			cancel:   cancel,
 			inputCh:  inputChan,
 			outputCh: outputChan,
+			dedupe:   newDedupeWindow(config.Get().QueueDedupeWindow),
 		}
 		logger.Debug("initialized")
+
+		if threshold := spillThreshold(config.Get().OutlinksSpillMemory); threshold > 0 {
+			globalPostprocessor.spill, err = newSpill(path.Join(config.Get().JobPath, "outlinks-spill"))
+			if err != nil {
+				logger.Error("unable to open the outlinks spill", "err", err.Error())
+				return
+			}
+			globalPostprocessor.pressure = &memoryPressure{threshold: threshold}
+
+			if spilled := globalPostprocessor.spill.count(); spilled > 0 {
+				logger.Info("outlinks spilled by a previous run will be queued", "count", spilled)
+			}
+
+			globalPostprocessor.wg.Add(1)
+			go globalPostprocessor.spillWatcher(time.Second)
+		} else if config.Get().OutlinksSpillMemory == -1 {
+			logger.Warn("no GOMEMLIMIT set, the outlinks won't be spilled on disk")
+		}
+
 		for i := 0; i < config.Get().WorkersCount; i++ {
 			globalPostprocessor.wg.Add(1)
 			go globalPostprocessor.worker(strconv.Itoa(i))
@@ -56,6 +84,10 @@ func Start(inputChan, outputChan chan *models.Item) error {
 		done = true
 	})
 
+	if err != nil {
+		return err
+	}
+
 	if !done {
 		return ErrPostprocessorAlreadyInitialized
 	}
@@ -75,7 +107,7 @@ func (p *postprocessor) worker(workerID string) {
 	defer p.wg.Done()
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "postprocessor.worker",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	stats.PostprocessorRoutinesIncr()
@@ -102,23 +134,10 @@ func (p *postprocessor) worker(workerID string) {
 					panic(fmt.Sprintf("seed consistency check failed with err: %s, seed id %s", err.Error(), seed.GetShortID()))
 				}
 
-				if seed.GetStatus() != models.ItemArchived && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
-					logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops(), "status", seed.GetStatus().String())
-				} else {
-					outlinks := postprocess(workerID, seed)
-					for i := range outlinks {
-						select {
-						case <-p.ctx.Done():
-							logger.Debug("aborting outlink feeding due to stop", "seed", outlinks[i].GetShortID())
-							return
-						case p.outputCh <- outlinks[i]:
-							logger.Debug("sending outlink", "seed", outlinks[i].GetShortID())
-						}
-					}
+				if !p.process(workerID, seed, logger) {
+					return
 				}
 
-				closeBodies(seed)
-
 				select {
 				case <-p.ctx.Done():
 					logger.Debug("aborting seed due to stop", "seed", seed.GetShortID())
@@ -130,10 +149,56 @@ func (p *postprocessor) worker(workerID string) {
 	}
 }
 
+// process postprocesses the seed and sends its outlinks. The bodies of all its items, every hop of its redirections
+// included, are closed once done, even if the postprocessor is stopped meanwhile, so that their temp files
+// are removed. It returns false if the postprocessor is stopped.
+func (p *postprocessor) process(workerID string, seed *models.Item, logger *log.FieldedLogger) bool {
+	defer closeBodies(seed)
+
+	if seed.GetStatus() != models.ItemArchived && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
+		logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hop", seed.GetURL().GetHops(), "item_status", seed.GetStatus().String())
+		return true
+	}
+
+	outlinks := postprocess(workerID, seed)
+	queued := outlinks[:0]
+	for i := range outlinks {
+		if p.dedupe.seen(outlinks[i].GetURL().Raw) {
+			logger.Debug("skipping outlink queued recently", "seed", outlinks[i].GetShortID(), "url", outlinks[i].GetURL().Raw)
+			continue
+		}
+
+		queued = append(queued, outlinks[i])
+	}
+
+	outlinks = queued
+	if len(outlinks) > 0 && p.pressure.isActive() {
+		err := p.spill.push(outlinks)
+		if err == nil {
+			logger.Debug("spilled outlinks", "seed", seed.GetShortID(), "count", len(outlinks))
+			return true
+		}
+
+		logger.Error("unable to spill outlinks, queueing them", "err", err.Error(), "seed", seed.GetShortID())
+	}
+
+	for i := range outlinks {
+		select {
+		case <-p.ctx.Done():
+			logger.Debug("aborting outlink feeding due to stop", "seed", outlinks[i].GetShortID())
+			return false
+		case p.outputCh <- outlinks[i]:
+			logger.Debug("sending outlink", "seed", outlinks[i].GetShortID())
+		}
+	}
+
+	return true
+}
+
 func postprocess(workerID string, seed *models.Item) []*models.Item {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "postprocessor.postprocess",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	outlinks := make([]*models.Item, 0)
@@ -149,6 +214,11 @@ func postprocess(workerID string, seed *models.Item) []*models.Item {
 		outlinks = append(outlinks, seedOutlinks...)
 	}
 
+	// The outlinks are seeds of their own, they keep the max hops of the seed they were found from
+	for i := range outlinks {
+		outlinks[i].SetMaxHops(seed.GetMaxHops())
+	}
+
 	return outlinks
 }
 
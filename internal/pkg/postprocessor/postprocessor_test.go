@@ -0,0 +1,114 @@
+package postprocessor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CorentinB/warc/pkg/spooledtempfile"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// newRedirectChain returns a seed redirected hops times, the last hop failed. Every item has its body
+// spooled to a temp file of dir, like the bodies processed by the archiver.
+func newRedirectChain(t *testing.T, dir string, hops int) *models.Item {
+	t.Helper()
+
+	var seed, previous *models.Item
+	for hop := 0; hop <= hops; hop++ {
+		URL := &models.URL{Raw: fmt.Sprintf("https://example.com/%d", hop), Redirects: hop}
+		if err := URL.Parse(); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+
+		URL.SetResponse(&http.Response{
+			StatusCode: http.StatusFound,
+			Header:     http.Header{"Location": []string{fmt.Sprintf("/%d", hop+1)}},
+		})
+
+		body := spooledtempfile.NewSpooledTempFile("zeno", dir, 0, true, -1)
+		if _, err := body.Write([]byte("<html>moved</html>")); err != nil {
+			t.Fatal(err)
+		}
+		URL.SetBody(body)
+
+		item := models.NewItem(URL.Raw, URL, "")
+		if previous == nil {
+			seed = item
+		} else if err := previous.AddChild(item, models.ItemGotRedirected); err != nil {
+			t.Fatal(err)
+		}
+
+		previous = item
+	}
+
+	previous.SetStatus(models.ItemFailed)
+
+	return seed
+}
+
+func TestRedirectChainTempFiles(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	tests := []struct {
+		name string
+		stop bool // Stop the postprocessor before the seed is sent to the finisher
+	}{
+		{"finished", false},
+		{"stopped", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			seed := newRedirectChain(t, dir, 3)
+			if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+				t.Fatalf("expected a temp file per hop, got %d", len(entries))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			p := &postprocessor{
+				ctx:      ctx,
+				cancel:   cancel,
+				inputCh:  make(chan *models.Item),
+				outputCh: make(chan *models.Item),
+				dedupe:   newDedupeWindow(0),
+			}
+
+			p.wg.Add(1)
+			go p.worker("0")
+
+			p.inputCh <- seed
+
+			if tt.stop {
+				// Nobody reads the output, the worker is stuck sending the seed until stopped
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			} else if processed := <-p.outputCh; processed != seed {
+				t.Fatalf("expected the seed back, got %v", processed)
+			}
+
+			cancel()
+			p.wg.Wait()
+
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("expected no temp file left, got %d", len(entries))
+			}
+
+			seed.Traverse(func(item *models.Item) {
+				if item.GetURL().GetBody() != nil {
+					t.Errorf("expected the body of %s to be released", item.GetURL().Raw)
+				}
+			})
+		})
+	}
+}
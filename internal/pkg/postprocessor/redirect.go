@@ -0,0 +1,46 @@
+package postprocessor
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+var errNoLocation = errors.New("redirection without Location header")
+
+// redirectTarget resolves the Location header of the response against the URL that was requested,
+// relative (/login) and protocol-relative (//example.com/login) locations are common
+func redirectTarget(item *models.Item) (*url.URL, error) {
+	location := strings.TrimSpace(item.GetURL().GetResponse().Header.Get("Location"))
+	if location == "" {
+		return nil, errNoLocation
+	}
+
+	parsedLocation, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	target := item.GetURL().GetParsed().ResolveReference(parsedLocation)
+	target.Fragment, target.RawFragment = "", ""
+
+	return target, nil
+}
+
+// isRedirectLoop returns true if target was already requested within the redirect chain
+// that led to the item, including the item itself (A → B → A)
+func isRedirectLoop(item *models.Item, target *url.URL) bool {
+	for current := item; current != nil; current = current.GetParent() {
+		if sameDocument(current.GetURL().String(), target.String()) {
+			return true
+		}
+
+		if !current.IsRedirection() {
+			break
+		}
+	}
+
+	return false
+}
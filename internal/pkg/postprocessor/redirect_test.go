@@ -0,0 +1,145 @@
+package postprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newRedirectItem(t *testing.T, rawURL, location string, redirects int) *models.Item {
+	t.Helper()
+
+	URL := &models.URL{Raw: rawURL, Redirects: redirects}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	URL.SetResponse(&http.Response{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Location": []string{location}},
+	})
+
+	item := models.NewItem(rawURL, URL, "")
+	item.SetStatus(models.ItemArchived)
+
+	return item
+}
+
+func redirectionOf(t *testing.T, item *models.Item) *models.Item {
+	t.Helper()
+
+	children := item.GetChildren()
+	if item.GetStatus() != models.ItemGotRedirected || len(children) != 1 {
+		return nil
+	}
+
+	return children[0]
+}
+
+func TestPostprocessRedirectLocation(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxRedirect int) {
+		config.Get().MaxRedirect = maxRedirect
+	}(config.Get().MaxRedirect)
+	config.Get().MaxRedirect = 20
+
+	tests := []struct {
+		name     string
+		location string
+		want     string
+	}{
+		{"absolute", "https://other.com/login", "https://other.com/login"},
+		{"relative to the root", "/login", "https://example.com/login"},
+		{"relative to the path", "login?next=a", "https://example.com/account/login?next=a"},
+		{"protocol-relative", "//cdn.example.org/file", "https://cdn.example.org/file"},
+		{"fragment dropped", "/login#form", "https://example.com/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := newRedirectItem(t, "https://example.com/account/settings", tt.location, 0)
+
+			postprocessItem(item)
+
+			redirection := redirectionOf(t, item)
+			if redirection == nil {
+				t.Fatalf("expected a redirection, got status %s", item.GetStatus())
+			}
+
+			if redirection.GetURL().Raw != tt.want {
+				t.Errorf("redirection URL = %q, want %q", redirection.GetURL().Raw, tt.want)
+			}
+
+			if redirection.GetURL().GetRedirects() != 1 {
+				t.Errorf("redirection count = %d, want 1", redirection.GetURL().GetRedirects())
+			}
+		})
+	}
+}
+
+func TestPostprocessRedirectLoop(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxRedirect int) {
+		config.Get().MaxRedirect = maxRedirect
+	}(config.Get().MaxRedirect)
+	config.Get().MaxRedirect = 20
+
+	// Self redirect
+	self := newRedirectItem(t, "http://example.com/a", "/a", 0)
+	postprocessItem(self)
+
+	if self.GetStatus() != models.ItemCompleted || len(self.GetChildren()) != 0 {
+		t.Errorf("expected the self redirect to stop the chain, got status %s", self.GetStatus())
+	}
+
+	// A → B → A
+	first := newRedirectItem(t, "http://example.com/a", "http://example.com/b", 0)
+	postprocessItem(first)
+
+	second := redirectionOf(t, first)
+	if second == nil {
+		t.Fatalf("expected a redirection, got status %s", first.GetStatus())
+	}
+
+	if err := second.GetURL().Parse(); err != nil {
+		t.Fatal(err)
+	}
+	second.GetURL().SetResponse(&http.Response{
+		StatusCode: http.StatusMovedPermanently,
+		Header:     http.Header{"Location": []string{"/a"}},
+	})
+	second.SetStatus(models.ItemArchived)
+
+	postprocessItem(second)
+
+	if second.GetStatus() != models.ItemCompleted || len(second.GetChildren()) != 0 {
+		t.Errorf("expected the loop to stop the chain, got status %s", second.GetStatus())
+	}
+}
+
+func TestPostprocessRedirectMaxRedirect(t *testing.T) {
+	config.InitConfig()
+
+	defer func(maxRedirect int) {
+		config.Get().MaxRedirect = maxRedirect
+	}(config.Get().MaxRedirect)
+	config.Get().MaxRedirect = 2
+
+	within := newRedirectItem(t, "http://example.com/1", "/2", 1)
+	postprocessItem(within)
+
+	if redirection := redirectionOf(t, within); redirection == nil || redirection.GetURL().GetRedirects() != 2 {
+		t.Fatalf("expected the redirection within the budget to be followed, got status %s", within.GetStatus())
+	}
+
+	exceeded := newRedirectItem(t, "http://example.com/2", "/3", 2)
+	postprocessItem(exceeded)
+
+	if exceeded.GetStatus() != models.ItemCompleted || len(exceeded.GetChildren()) != 0 {
+		t.Errorf("expected the chain to stop at --max-redirect, got status %s", exceeded.GetStatus())
+	}
+}
@@ -0,0 +1,362 @@
+package postprocessor
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// spillResumeRatio is the ratio of the memory threshold under which the outlinks are queued again,
+// so that the postprocessor doesn't switch in and out of the spill at every check
+const spillResumeRatio = 0.9
+
+// spilledOutlink is an outlink written in the spill, with what is needed to queue it as it would have been
+type spilledOutlink struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Hops    int    `json:"hops"`
+	Via     string `json:"via,omitempty"`      // URL of the page the outlink was found on
+	MaxHops *int   `json:"max_hops,omitempty"` // Max hops of the seed, nil if the global one applies
+}
+
+func newSpilledOutlink(item *models.Item) spilledOutlink {
+	outlink := spilledOutlink{
+		ID:   item.GetID(),
+		URL:  item.GetURL().Raw,
+		Hops: item.GetURL().GetHops(),
+		Via:  item.GetSeedVia(),
+	}
+
+	if maxHops := item.GetMaxHops(); maxHops >= 0 {
+		outlink.MaxHops = &maxHops
+	}
+
+	return outlink
+}
+
+func (o spilledOutlink) item() *models.Item {
+	item := models.NewItem(o.ID, &models.URL{Raw: o.URL, Hops: o.Hops}, o.Via)
+	if item != nil && o.MaxHops != nil {
+		item.SetMaxHops(*o.MaxHops)
+	}
+
+	return item
+}
+
+// spill is a FIFO of the outlinks found while the memory is under pressure, stored in the job directory so
+// that they survive a restart. Every batch is a file named <sequence>-<outlinks count>.json.
+type spill struct {
+	mu    sync.Mutex
+	dir   string
+	files []spillFile
+	items int64
+	next  uint64
+}
+
+type spillFile struct {
+	name  string
+	seq   uint64
+	items int64
+}
+
+// newSpill opens the spill in dir, the outlinks spilled by a previous run are kept
+func newSpill(dir string) (*spill, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spill{dir: dir}
+
+	for _, entry := range entries {
+		seq, items, ok := parseSpillName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		s.files = append(s.files, spillFile{name: entry.Name(), seq: seq, items: items})
+		s.items += items
+		s.next = max(s.next, seq+1)
+	}
+
+	slices.SortFunc(s.files, func(a, b spillFile) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	stats.OutlinksSpilledSet(s.items)
+
+	return s, nil
+}
+
+func parseSpillName(name string) (seq uint64, items int64, ok bool) {
+	name, found := strings.CutSuffix(name, ".json")
+	if !found {
+		return 0, 0, false
+	}
+
+	seqPart, itemsPart, found := strings.Cut(name, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	items, err = strconv.ParseInt(itemsPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return seq, items, true
+}
+
+// push appends the outlinks to the spill as one batch
+func (s *spill) push(outlinks []*models.Item) error {
+	batch := make([]spilledOutlink, 0, len(outlinks))
+	for _, outlink := range outlinks {
+		batch = append(batch, newSpilledOutlink(outlink))
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := spillFile{
+		name:  fmt.Sprintf("%020d-%d.json", s.next, len(batch)),
+		seq:   s.next,
+		items: int64(len(batch)),
+	}
+
+	// Written aside then renamed so that a crash never leaves a partial batch
+	tmp := filepath.Join(s.dir, file.name+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(s.dir, file.name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	s.next++
+	s.files = append(s.files, file)
+	s.items += file.items
+	stats.OutlinksSpilledSet(s.items)
+
+	return nil
+}
+
+// peek returns the outlinks of the oldest batch, false if the spill is empty
+func (s *spill) peek() (outlinks []*models.Item, found bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, s.files[0].name))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var batch []spilledOutlink
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, false, fmt.Errorf("corrupted outlinks spill file %s: %w", s.files[0].name, err)
+	}
+
+	for _, spilled := range batch {
+		if item := spilled.item(); item != nil {
+			outlinks = append(outlinks, item)
+		}
+	}
+
+	return outlinks, true, nil
+}
+
+// pop removes the oldest batch
+func (s *spill) pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, s.files[0].name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	s.items -= s.files[0].items
+	s.files = s.files[1:]
+	stats.OutlinksSpilledSet(s.items)
+
+	return nil
+}
+
+// count returns the number of outlinks spilled
+func (s *spill) count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.items
+}
+
+// memoryPressure tells if the memory used is over the threshold. Once over it, the pressure lasts until the
+// memory is back under spillResumeRatio of the threshold.
+type memoryPressure struct {
+	mu        sync.Mutex
+	threshold uint64
+	active    bool
+}
+
+// update records the memory used, it returns true if the pressure started or stopped with it
+func (m *memoryPressure) update(used uint64) (changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case !m.active && used >= m.threshold:
+		m.active = true
+	case m.active && float64(used) < float64(m.threshold)*spillResumeRatio:
+		m.active = false
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (m *memoryPressure) isActive() bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.active
+}
+
+// memoryUsed returns the memory obtained from the OS by the runtime that it didn't release
+func memoryUsed() uint64 {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return memStats.Sys - memStats.HeapReleased
+}
+
+// spillThreshold returns the memory threshold of --outlinks-spill-memory in bytes, 0 if there is none.
+// With -1 it's 90% of the memory limit of the runtime, GOMEMLIMIT, if it's set.
+func spillThreshold(megabytes int) uint64 {
+	switch {
+	case megabytes > 0:
+		return uint64(megabytes) * 1024 * 1024
+	case megabytes == -1:
+		limit := debug.SetMemoryLimit(-1)
+		if limit <= 0 || limit == math.MaxInt64 {
+			return 0
+		}
+		return uint64(limit) / 10 * 9
+	default:
+		return 0
+	}
+}
+
+// spillWatcher checks the memory used at every interval to switch the outlinks in and out of the spill,
+// the spilled outlinks are queued again while there is no pressure, the oldest first
+func (p *postprocessor) spillWatcher(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			if left := p.spill.count(); left > 0 {
+				logger.Info("outlinks left in the spill, they will be queued at the next start", "count", left)
+			}
+			return
+		case <-ticker.C:
+			p.checkMemory()
+
+			for !p.pressure.isActive() && p.ctx.Err() == nil {
+				if !p.reingest() {
+					break
+				}
+				p.checkMemory()
+			}
+		}
+	}
+}
+
+func (p *postprocessor) checkMemory() {
+	used := memoryUsed()
+	if !p.pressure.update(used) {
+		return
+	}
+
+	if p.pressure.isActive() {
+		logger.Warn("memory pressure, spilling the outlinks on disk", "used", used, "threshold", p.pressure.threshold)
+	} else {
+		logger.Info("memory pressure over, queueing the spilled outlinks", "used", used, "threshold", p.pressure.threshold, "spilled", p.spill.count())
+	}
+}
+
+// reingest queues the outlinks of the oldest batch of the spill, the batch is removed once they are all
+// queued. It returns false if there was nothing to queue or the postprocessor is stopped.
+func (p *postprocessor) reingest() bool {
+	outlinks, found, err := p.spill.peek()
+	if err != nil {
+		logger.Error("unable to read the outlinks spill, dropping the batch", "err", err.Error())
+		if err := p.spill.pop(); err != nil {
+			logger.Error("unable to remove the outlinks spill batch", "err", err.Error())
+			return false
+		}
+		return true
+	}
+
+	if !found {
+		return false
+	}
+
+	for _, outlink := range outlinks {
+		select {
+		case <-p.ctx.Done():
+			// The batch is kept, its outlinks already queued will be queued again at the next start
+			return false
+		case p.outputCh <- outlink:
+		}
+	}
+
+	if err := p.spill.pop(); err != nil {
+		logger.Error("unable to remove the outlinks spill batch", "err", err.Error())
+		return false
+	}
+
+	return true
+}
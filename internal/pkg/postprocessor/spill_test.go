@@ -0,0 +1,186 @@
+package postprocessor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newSpillOutlink(id, URL string, hops int, via string) *models.Item {
+	return models.NewItem(id, &models.URL{Raw: URL, Hops: hops}, via)
+}
+
+func TestSpillSurvivesRestart(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	dir := t.TempDir()
+
+	s, err := newSpill(dir)
+	if err != nil {
+		t.Fatalf("newSpill() error = %v", err)
+	}
+
+	if err := s.push([]*models.Item{
+		newSpillOutlink("a", "https://example.com/a", 2, "https://example.com/"),
+		newSpillOutlink("b", "https://example.com/b", 2, "https://example.com/"),
+	}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	deep := newSpillOutlink("c", "https://example.com/c", 3, "https://example.com/a")
+	deep.SetMaxHops(5)
+
+	if err := s.push([]*models.Item{deep}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	// A batch being written when the process died is ignored
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000005-1.json.tmp"), []byte("[{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = newSpill(dir)
+	if err != nil {
+		t.Fatalf("newSpill() error = %v", err)
+	}
+
+	if s.count() != 3 || stats.OutlinksSpilledGet() != 3 {
+		t.Fatalf("count() = %d, gauge = %d, want 3", s.count(), stats.OutlinksSpilledGet())
+	}
+
+	outlinks, found, err := s.peek()
+	if err != nil || !found {
+		t.Fatalf("peek() = %v, %v", found, err)
+	}
+
+	if len(outlinks) != 2 {
+		t.Fatalf("peek() returned %d outlinks, want 2", len(outlinks))
+	}
+
+	first := outlinks[0]
+	if first.GetID() != "a" || first.GetURL().Raw != "https://example.com/a" || first.GetURL().GetHops() != 2 || first.GetSeedVia() != "https://example.com/" {
+		t.Errorf("peek() first outlink = %s %s hops %d via %s", first.GetID(), first.GetURL().Raw, first.GetURL().GetHops(), first.GetSeedVia())
+	}
+
+	if first.GetMaxHops() != -1 {
+		t.Errorf("spilled outlink max hops = %d, want -1", first.GetMaxHops())
+	}
+
+	if first.GetStatus() != models.ItemFresh {
+		t.Errorf("spilled outlink status = %s, want fresh", first.GetStatus())
+	}
+
+	if err := s.pop(); err != nil {
+		t.Fatalf("pop() error = %v", err)
+	}
+
+	outlinks, _, _ = s.peek()
+	if len(outlinks) != 1 || outlinks[0].GetURL().GetHops() != 3 || s.count() != 1 {
+		t.Fatalf("second batch = %d outlinks, count %d", len(outlinks), s.count())
+	}
+
+	if outlinks[0].GetMaxHops() != 5 {
+		t.Errorf("spilled outlink max hops = %d, want 5", outlinks[0].GetMaxHops())
+	}
+
+	// New batches follow the ones of the previous run
+	if err := s.push([]*models.Item{newSpillOutlink("d", "https://example.com/d", 1, "")}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	s.pop()
+
+	outlinks, _, _ = s.peek()
+	if len(outlinks) != 1 || outlinks[0].GetID() != "d" {
+		t.Fatalf("batch after restart isn't the last one")
+	}
+
+	s.pop()
+
+	if _, found, _ := s.peek(); found || s.count() != 0 || stats.OutlinksSpilledGet() != 0 {
+		t.Errorf("spill isn't empty: count %d, gauge %d", s.count(), stats.OutlinksSpilledGet())
+	}
+}
+
+func TestMemoryPressureHysteresis(t *testing.T) {
+	pressure := &memoryPressure{threshold: 1000}
+
+	steps := []struct {
+		used    uint64
+		active  bool
+		changed bool
+	}{
+		{500, false, false},
+		{1000, true, true},
+		{950, true, false},
+		{900, true, false},
+		{899, false, true},
+		{999, false, false},
+	}
+
+	for _, step := range steps {
+		changed := pressure.update(step.used)
+		if changed != step.changed || pressure.isActive() != step.active {
+			t.Errorf("update(%d) = %v, active %v, want %v, active %v", step.used, changed, pressure.isActive(), step.changed, step.active)
+		}
+	}
+}
+
+func TestSpillReingest(t *testing.T) {
+	config.InitConfig()
+	stats.Init()
+
+	s, err := newSpill(t.TempDir())
+	if err != nil {
+		t.Fatalf("newSpill() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := &postprocessor{
+		ctx:      ctx,
+		cancel:   cancel,
+		outputCh: make(chan *models.Item, 10),
+		spill:    s,
+		pressure: &memoryPressure{threshold: 1000, active: true},
+	}
+
+	if err := p.spill.push([]*models.Item{newSpillOutlink("a", "https://example.com/a", 1, "https://example.com/")}); err != nil {
+		t.Fatal(err)
+	}
+
+	p.pressure.update(0)
+
+	if !p.reingest() {
+		t.Fatalf("reingest() = false, want true")
+	}
+
+	if p.reingest() {
+		t.Fatalf("reingest() of an empty spill = true")
+	}
+
+	outlink := <-p.outputCh
+	if outlink.GetURL().Raw != "https://example.com/a" || outlink.GetURL().GetHops() != 1 || outlink.GetSeedVia() != "https://example.com/" {
+		t.Errorf("reingested outlink = %s hops %d via %s", outlink.GetURL().Raw, outlink.GetURL().GetHops(), outlink.GetSeedVia())
+	}
+
+	// A batch interrupted by a stop is kept
+	p.spill.push([]*models.Item{newSpillOutlink("b", "https://example.com/b", 1, "")})
+	p.outputCh = make(chan *models.Item)
+	cancel()
+
+	if p.reingest() {
+		t.Fatalf("reingest() when stopped = true")
+	}
+
+	if p.spill.count() != 1 {
+		t.Errorf("count() = %d after an interrupted reingest, want 1", p.spill.count())
+	}
+}
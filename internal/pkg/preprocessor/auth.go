@@ -0,0 +1,44 @@
+package preprocessor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// setAuthorization sets the credentials of --host-auth matching the host of the request. Redirections
+// get the credentials of their own host, so they are kept along a redirection chain on the same host
+// and never sent to another host, nor in clear after a redirection from https to http. An Authorization
+// header already set, by the site-specific code for example, is kept.
+func setAuthorization(item *models.Item, req *http.Request) {
+	if len(config.Get().HostAuths) == 0 || req.Header.Get("Authorization") != "" || downgraded(item, req) {
+		return
+	}
+
+	authorization, found := config.Get().HostAuths[strings.ToLower(req.URL.Host)]
+	if !found {
+		authorization, found = config.Get().HostAuths[strings.ToLower(req.URL.Hostname())]
+	}
+
+	if found {
+		req.Header.Set("Authorization", authorization)
+	}
+}
+
+// downgraded returns true for the plain http redirections of a chain that went through https
+func downgraded(item *models.Item, req *http.Request) bool {
+	if !strings.EqualFold(req.URL.Scheme, "http") {
+		return false
+	}
+
+	for item.IsRedirection() {
+		item = item.GetParent()
+		if parentReq := item.GetURL().GetRequest(); parentReq != nil && strings.EqualFold(parentReq.URL.Scheme, "https") {
+			return true
+		}
+	}
+
+	return false
+}
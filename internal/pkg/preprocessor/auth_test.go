@@ -0,0 +1,96 @@
+package preprocessor
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestSetAuthorization(t *testing.T) {
+	config.InitConfig()
+
+	defer func(auths map[string]string) {
+		config.Get().HostAuths = auths
+	}(config.Get().HostAuths)
+
+	config.Get().HostAuths = map[string]string{
+		"staging.example.com":  "Basic dXNlcjpwYXNzd29yZA==",
+		"api.example.com:8443": "Bearer token",
+	}
+
+	tests := []struct {
+		URL      string
+		existing string
+		want     string
+	}{
+		{URL: "https://Staging.example.com/page", want: "Basic dXNlcjpwYXNzd29yZA=="},
+		{URL: "https://staging.example.com:8080/page", want: "Basic dXNlcjpwYXNzd29yZA=="},
+		{URL: "https://api.example.com:8443/v1", want: "Bearer token"},
+		// Only the exact host gets the credentials
+		{URL: "https://api.example.com/v1", want: ""},
+		{URL: "https://cdn.staging.example.com/app.js", want: ""},
+		{URL: "https://example.org/", want: ""},
+		{URL: "https://staging.example.com/", existing: "Bearer site-specific", want: "Bearer site-specific"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.URL, nil)
+		if tt.existing != "" {
+			req.Header.Set("Authorization", tt.existing)
+		}
+
+		setAuthorization(models.NewItem("item", &models.URL{Raw: tt.URL}, ""), req)
+
+		if got := req.Header.Get("Authorization"); got != tt.want {
+			t.Errorf("setAuthorization(%s) = %q, want %q", tt.URL, got, tt.want)
+		}
+	}
+}
+
+func TestSetAuthorizationDowngrade(t *testing.T) {
+	config.InitConfig()
+
+	defer func(auths map[string]string) {
+		config.Get().HostAuths = auths
+	}(config.Get().HostAuths)
+
+	config.Get().HostAuths = map[string]string{"staging.example.com": "Basic dXNlcjpwYXNzd29yZA=="}
+
+	// https://staging.example.com/a redirects to http://staging.example.com/b, then to http://staging.example.com/c
+	var (
+		chain    []*models.Item
+		requests []*http.Request
+	)
+	for i, raw := range []string{"https://staging.example.com/a", "http://staging.example.com/b", "http://staging.example.com/c"} {
+		item := models.NewItem(fmt.Sprintf("hop-%d", i), &models.URL{Raw: raw}, "")
+		if i > 0 {
+			if err := chain[i-1].AddChild(item, models.ItemGotRedirected); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, raw, nil)
+		setAuthorization(item, req)
+		item.GetURL().SetRequest(req)
+
+		chain = append(chain, item)
+		requests = append(requests, req)
+	}
+
+	// The credentials aren't sent in clear once the chain left https
+	for i, want := range []string{"Basic dXNlcjpwYXNzd29yZA==", "", ""} {
+		if got := requests[i].Header.Get("Authorization"); got != want {
+			t.Errorf("setAuthorization(%s) = %q, want %q", requests[i].URL, got, want)
+		}
+	}
+
+	// A plain http seed still gets them
+	req, _ := http.NewRequest(http.MethodGet, "http://staging.example.com/", nil)
+	setAuthorization(models.NewItem("seed", &models.URL{Raw: req.URL.String()}, ""), req)
+	if got := req.Header.Get("Authorization"); got == "" {
+		t.Errorf("setAuthorization(%s) = %q, want the credentials", req.URL, got)
+	}
+}
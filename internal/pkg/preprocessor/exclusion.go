@@ -2,12 +2,32 @@ package preprocessor
 
 import (
 	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
-func matchRegexExclusion(item *models.Item) bool {
+// Excluded tells if the normalized URL is left out of the crawl by the include filters, applied first if
+// any is defined, or by the exclusion filters. The reason says which filters excluded it.
+func Excluded(URL *models.URL) (excluded bool, reason string) {
+	if len(config.Get().IncludeHosts) > 0 || len(config.Get().IncludeString) > 0 {
+		if !utils.StringContainsSliceElements(URL.GetParsed().Host, config.Get().IncludeHosts) &&
+			!utils.StringContainsSliceElements(URL.String(), config.Get().IncludeString) {
+			return true, "does not match include filters"
+		}
+	}
+
+	if utils.StringContainsSliceElements(URL.GetParsed().Host, config.Get().ExcludeHosts) ||
+		utils.StringContainsSliceElements(URL.String(), config.Get().ExcludeString) ||
+		matchRegexExclusion(URL) {
+		return true, "matches exclusion filters"
+	}
+
+	return false, ""
+}
+
+func matchRegexExclusion(URL *models.URL) bool {
 	for _, exclusion := range config.Get().ExclusionRegexes {
-		if exclusion.MatchString(item.GetURL().String()) {
+		if exclusion.MatchString(URL.String()) {
 			return true
 		}
 	}
@@ -0,0 +1,42 @@
+package preprocessor
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// setConfiguredHeaders sets the headers of --header and of --host-header for the host of the request.
+// They replace the defaults of Zeno, User-Agent included, and a configured Referer is kept in place of the
+// one of --referer-policy. The host ones take precedence over the global ones. Like for --accept, the
+// headers of the seed list and the site-specific ones take precedence over both.
+func setConfiguredHeaders(item *models.Item, req *http.Request) {
+	hostHeaders, found := config.Get().HostHeaders[strings.ToLower(req.URL.Host)]
+	if !found {
+		hostHeaders = config.Get().HostHeaders[strings.ToLower(req.URL.Hostname())]
+	}
+
+	if len(config.Get().Headers) == 0 && len(hostHeaders) == 0 {
+		return
+	}
+
+	seedHeaders := make(map[string]bool)
+	if keepsSeedHeaders(item) {
+		for name := range item.GetHeaders() {
+			seedHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	for _, headers := range []http.Header{config.Get().Headers, hostHeaders} {
+		for name, values := range headers {
+			if seedHeaders[name] {
+				continue
+			}
+
+			req.Header[name] = slices.Clone(values)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package preprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestSetConfiguredHeaders(t *testing.T) {
+	config.InitConfig()
+
+	defer func(headers http.Header, hostHeaders map[string]http.Header) {
+		config.Get().Headers = headers
+		config.Get().HostHeaders = hostHeaders
+	}(config.Get().Headers, config.Get().HostHeaders)
+
+	config.Get().Headers = http.Header{
+		"User-Agent": {"custom-agent"},
+		"X-Archive":  {"zeno"},
+	}
+	config.Get().HostHeaders = map[string]http.Header{
+		"api.example.com": {"X-Api-Key": {"key"}, "X-Archive": {"api"}},
+	}
+
+	newSeed := func(rawURL string, headers http.Header) *models.Item {
+		u := &models.URL{Raw: rawURL}
+		if err := u.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		item := models.NewItem("seed", u, "")
+		if headers != nil {
+			if err := item.SetHeaders(headers); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return item
+	}
+
+	tests := []struct {
+		URL         string
+		seedHeaders http.Header
+		want        map[string]string
+	}{
+		{URL: "https://example.com/", want: map[string]string{"User-Agent": "custom-agent", "X-Archive": "zeno", "X-Api-Key": "", "Referer": "https://example.com/"}},
+		{URL: "https://API.example.com/v1", want: map[string]string{"User-Agent": "custom-agent", "X-Archive": "api", "X-Api-Key": "key"}},
+		{URL: "https://api.example.com:8443/v1", want: map[string]string{"X-Archive": "api", "X-Api-Key": "key"}},
+		// The headers of the seed list take precedence
+		{URL: "https://example.com/", seedHeaders: http.Header{"x-archive": {"seed"}}, want: map[string]string{"X-Archive": "seed", "User-Agent": "custom-agent"}},
+	}
+
+	for _, tt := range tests {
+		item := newSeed(tt.URL, tt.seedHeaders)
+
+		req, _ := http.NewRequest(http.MethodGet, tt.URL, nil)
+		req.Header.Set("User-Agent", "Zeno")
+		req.Header.Set("Referer", "https://example.com/")
+		for name, values := range tt.seedHeaders {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+
+		setConfiguredHeaders(item, req)
+
+		for name, want := range tt.want {
+			if got := req.Header.Get(name); got != want {
+				t.Errorf("setConfiguredHeaders(%s) %s = %q, want %q", tt.URL, name, got, want)
+			}
+		}
+	}
+}
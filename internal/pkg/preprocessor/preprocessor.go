@@ -14,8 +14,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/internetarchive/Zeno/internal/pkg/archiver"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/controler/pause"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
@@ -26,8 +28,8 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/preprocessor/sitespecific/tiktok"
 	"github.com/internetarchive/Zeno/internal/pkg/preprocessor/sitespecific/truthsocial"
 	"github.com/internetarchive/Zeno/internal/pkg/source/hq"
+	"github.com/internetarchive/Zeno/internal/pkg/source/redis"
 	"github.com/internetarchive/Zeno/internal/pkg/stats"
-	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/internetarchive/Zeno/pkg/models"
 )
 
@@ -94,7 +96,7 @@ func (p *preprocessor) worker(workerID string) {
 
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "preprocessor.worker",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	defer logger.Debug("worker stopped")
@@ -143,7 +145,7 @@ func (p *preprocessor) worker(workerID string) {
 func preprocess(workerID string, seed *models.Item) {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "preprocessor.preprocess",
-		"worker_id": workerID,
+		"worker":    workerID,
 	})
 
 	operatingDepth := seed.GetMaxDepth()
@@ -177,32 +179,9 @@ func preprocess(workerID string, seed *models.Item) {
 			}
 		}
 
-		// Apply include filters first, if any are defined
-		if len(config.Get().IncludeHosts) > 0 || len(config.Get().IncludeString) > 0 {
-			if !utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, config.Get().IncludeHosts) &&
-				!utils.StringContainsSliceElements(items[i].GetURL().String(), config.Get().IncludeString) {
-
-				logger.Debug("URL excluded (does not match include filters)",
-					"item_id", items[i].GetShortID(),
-					"seed_id", seed.GetShortID(),
-					"url", items[i].GetURL().String())
-
-				if items[i].IsChild() || items[i].IsRedirection() {
-					items[i].GetParent().RemoveChild(items[i])
-					continue
-				}
-
-				items[i].SetStatus(models.ItemCompleted)
-				return
-			}
-		}
-
-		// Apply exclusion filters even if it passed inclusion
-		if utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, config.Get().ExcludeHosts) ||
-			utils.StringContainsSliceElements(items[i].GetURL().String(), config.Get().ExcludeString) ||
-			matchRegexExclusion(items[i]) {
-
-			logger.Debug("URL excluded (matches exclusion filters)",
+		// Apply include filters first, if any are defined, then the exclusion filters
+		if excluded, reason := Excluded(items[i].GetURL()); excluded {
+			logger.Debug("URL excluded ("+reason+")",
 				"item_id", items[i].GetShortID(),
 				"seed_id", seed.GetShortID(),
 				"url", items[i].GetURL().String())
@@ -212,13 +191,15 @@ func preprocess(workerID string, seed *models.Item) {
 				continue
 			}
 
+			// The seed was queued to be captured, it's reported as a failure
+			archiver.ReportExcluded(items[i], reason)
 			items[i].SetStatus(models.ItemCompleted)
 			return
 		}
 
 		// If we are processing assets, then we need to remove childs that are just domains
-		// (which means that they are not assets, but false positives)
-		if items[i].IsChild() {
+		// (which means that they are not assets, but false positives). WebSocket endpoints often are.
+		if items[i].IsChild() && !items[i].GetURL().IsWebSocket() {
 			if items[i].GetURL().GetParsed().Path == "" || items[i].GetURL().GetParsed().Path == "/" {
 				logger.Debug("removing child with empty path", "item_id", items[i].GetShortID(), "url", items[i].GetURL().Raw)
 				items[i].GetParent().RemoveChild(items[i])
@@ -246,6 +227,11 @@ func preprocess(workerID string, seed *models.Item) {
 		if err != nil {
 			logger.Warn("unable to seencheck seed", "seed_id", seed.GetShortID(), "err", err.Error(), "func", "preprocessor.preprocess")
 		}
+	} else if config.Get().UseRedis && config.Get().RedisSeencheck {
+		err = redis.SeencheckItem(seed)
+		if err != nil {
+			logger.Warn("unable to seencheck seed", "seed_id", seed.GetShortID(), "err", err.Error(), "func", "preprocessor.preprocess")
+		}
 	} else {
 		err = seencheck.SeencheckItem(seed)
 		if err != nil {
@@ -274,34 +260,83 @@ func preprocess(workerID string, seed *models.Item) {
 
 	// Finally, we build the requests, applying any site-specific behavior needed
 	for i := range items {
-		req, err := http.NewRequest(http.MethodGet, items[i].GetURL().String(), nil)
+		req, err := NewRequest(items[i])
 		if err != nil {
 			logger.Error("unable to create request for URL", "item_id", items[i].GetShortID(), "seed_id", seed.GetShortID(), "url", items[i].GetURL().String(), "err", err.Error())
 			items[i].SetStatus(models.ItemFailed)
 			continue
 		}
 
-		// Apply configured User-Agent
-		req.Header.Set("User-Agent", config.Get().UserAgent)
-
-		switch {
-		case tiktok.IsTikTokURL(items[i].GetURL()):
-			tiktok.AddHeaders(req)
-		case npr.IsNPRURL(items[i].GetURL()):
-			npr.AddHeaders(req)
-		case reddit.IsRedditURL(items[i].GetURL()):
-			reddit.AddCookies(req)
-		case truthsocial.IsStatusAPIURL(items[i].GetURL()) ||
-			truthsocial.IsVideoAPIURL(items[i].GetURL()) ||
-			truthsocial.IsLookupURL(items[i].GetURL()):
-			truthsocial.AddStatusAPIHeaders(req)
-		case truthsocial.IsAccountsAPIURL(items[i].GetURL()):
-			truthsocial.AddAccountsAPIHeaders(req)
-		}
-
 		items[i].GetURL().SetRequest(req)
 		items[i].SetStatus(models.ItemPreProcessed)
 	}
 
 	return
 }
+
+// NewRequest builds the request of the item like the ones of the crawl: its User-Agent, the configured and
+// site-specific headers, the cookies and body kept from a redirection, its referrer and its credentials
+func NewRequest(item *models.Item) (*http.Request, error) {
+	req, err := http.NewRequest(requestMethod(item), item.GetURL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply configured User-Agent, or the one picked from the pool
+	req.Header.Set("User-Agent", pickUserAgent(item))
+
+	// Ask for the content types that will be downloaded and the configured language,
+	// site-specific headers take precedence
+	if config.Get().Accept != "" {
+		req.Header.Set("Accept", config.Get().Accept)
+	} else if len(config.Get().FetchContentTypeAllow) > 0 {
+		req.Header.Set("Accept", strings.Join(config.Get().FetchContentTypeAllow, ", "))
+	}
+
+	if config.Get().AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", config.Get().AcceptLanguage)
+	}
+
+	// The headers of the seed, from its seed list, override the defaults. They follow the
+	// redirections and the assets as long as they stay on the same host.
+	if keepsSeedHeaders(item) {
+		for name, values := range item.GetHeaders() {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+
+	setConfiguredHeaders(item, req)
+
+	switch {
+	case tiktok.IsTikTokURL(item.GetURL()):
+		tiktok.AddHeaders(req)
+	case npr.IsNPRURL(item.GetURL()):
+		npr.AddHeaders(req)
+	case reddit.IsRedditURL(item.GetURL()):
+		reddit.AddCookies(req)
+	case truthsocial.IsStatusAPIURL(item.GetURL()) ||
+		truthsocial.IsVideoAPIURL(item.GetURL()) ||
+		truthsocial.IsLookupURL(item.GetURL()):
+		truthsocial.AddStatusAPIHeaders(req)
+	case truthsocial.IsAccountsAPIURL(item.GetURL()):
+		truthsocial.AddAccountsAPIHeaders(req)
+	}
+
+	// Redirections on the same host keep the cookies of the request they come from, and
+	// the ones keeping the method, 307 and 308, keep its body too
+	copyRedirectCookies(item, req)
+
+	if err := copyRedirectBody(item, req); err != nil {
+		return nil, fmt.Errorf("unable to copy the body of the redirected request: %w", err)
+	}
+
+	setReferer(item, req)
+
+	if item.GetURL().IsWebSocket() {
+		setWebSocketHandshake(req)
+	}
+
+	setAuthorization(item, req)
+
+	return req, nil
+}
@@ -0,0 +1,122 @@
+package preprocessor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// requestMethod returns the method of the request of the item. Redirections follow the semantics of
+// the status code they come from: 307 and 308 keep the method of the redirected request, 303 switches
+// to GET (except for HEAD), and 301 and 302 switch POST to GET like browsers do.
+func requestMethod(item *models.Item) string {
+	if !item.IsRedirection() {
+		return http.MethodGet
+	}
+
+	parentURL := item.GetParent().GetURL()
+	if parentURL.GetRequest() == nil || parentURL.GetResponse() == nil {
+		return http.MethodGet
+	}
+
+	return redirectMethod(parentURL.GetRequest().Method, parentURL.GetResponse().StatusCode)
+}
+
+func redirectMethod(method string, statusCode int) string {
+	switch statusCode {
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return method
+	case http.StatusSeeOther:
+		if method == http.MethodHead {
+			return method
+		}
+		return http.MethodGet
+	case http.StatusMovedPermanently, http.StatusFound:
+		if method == http.MethodPost {
+			return http.MethodGet
+		}
+		return method
+	default:
+		return http.MethodGet
+	}
+}
+
+// copyRedirectCookies sends the cookies of the redirected request again when the redirection stays
+// on the same host, the cookies aren't leaked to other hosts. Cookies already set on the request,
+// by the site-specific code for example, take precedence.
+func copyRedirectCookies(item *models.Item, req *http.Request) {
+	if !item.IsRedirection() || req.Header.Get("Cookie") != "" {
+		return
+	}
+
+	parentReq := item.GetParent().GetURL().GetRequest()
+	if parentReq == nil || parentReq.Header.Get("Cookie") == "" {
+		return
+	}
+
+	if !strings.EqualFold(parentReq.URL.Hostname(), req.URL.Hostname()) {
+		return
+	}
+
+	req.Header.Set("Cookie", parentReq.Header.Get("Cookie"))
+}
+
+// keepsSeedHeaders returns false for the redirections and the assets that left the host of the request
+// they come from, at any hop from their seed: the headers of the seed aren't sent to another host.
+func keepsSeedHeaders(item *models.Item) bool {
+	for !item.IsSeed() {
+		parent := item.GetParent()
+		if !strings.EqualFold(hostname(parent.GetURL()), hostname(item.GetURL())) {
+			return false
+		}
+
+		item = parent
+	}
+
+	return true
+}
+
+func hostname(URL *models.URL) string {
+	if URL.GetParsed() != nil {
+		return URL.GetParsed().Hostname()
+	}
+
+	if URL.GetRequest() != nil {
+		return URL.GetRequest().URL.Hostname()
+	}
+
+	return ""
+}
+
+// copyRedirectBody sends the body of the redirected request again when the redirection keeps its
+// method, i.e. for the 307 and 308 redirections of the requests with a body
+func copyRedirectBody(item *models.Item, req *http.Request) error {
+	if !item.IsRedirection() || req.Body != nil {
+		return nil
+	}
+
+	parentReq := item.GetParent().GetURL().GetRequest()
+	if parentReq == nil || parentReq.GetBody == nil || parentReq.Method != req.Method {
+		return nil
+	}
+
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return nil
+	}
+
+	body, err := parentReq.GetBody()
+	if err != nil {
+		return err
+	}
+
+	req.Body = body
+	req.GetBody = parentReq.GetBody
+	req.ContentLength = parentReq.ContentLength
+
+	if req.Header.Get("Content-Type") == "" && parentReq.Header.Get("Content-Type") != "" {
+		req.Header.Set("Content-Type", parentReq.Header.Get("Content-Type"))
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package preprocessor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestRedirectMethod(t *testing.T) {
+	tests := []struct {
+		method     string
+		statusCode int
+		want       string
+	}{
+		{http.MethodGet, http.StatusMovedPermanently, http.MethodGet},
+		{http.MethodPost, http.StatusMovedPermanently, http.MethodGet},
+		{http.MethodPost, http.StatusFound, http.MethodGet},
+		{http.MethodPut, http.StatusFound, http.MethodPut},
+		{http.MethodPost, http.StatusSeeOther, http.MethodGet},
+		{http.MethodHead, http.StatusSeeOther, http.MethodHead},
+		{http.MethodPost, http.StatusTemporaryRedirect, http.MethodPost},
+		{http.MethodPut, http.StatusPermanentRedirect, http.MethodPut},
+	}
+
+	for _, tt := range tests {
+		if got := redirectMethod(tt.method, tt.statusCode); got != tt.want {
+			t.Errorf("redirectMethod(%s, %d) = %s, want %s", tt.method, tt.statusCode, got, tt.want)
+		}
+	}
+}
+
+func TestRedirectionRequest(t *testing.T) {
+	newRedirection := func(from, to string, statusCode int, cookie string) *models.Item {
+		parentURL := &models.URL{Raw: from}
+		if err := parentURL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest(http.MethodPost, from, nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		parentURL.SetRequest(req)
+		parentURL.SetResponse(&http.Response{StatusCode: statusCode})
+
+		URL := &models.URL{Raw: to}
+		if err := URL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		parent := models.NewItem("parent", parentURL, "")
+		redirection := models.NewItem("redirection", URL, "")
+		if err := parent.AddChild(redirection, models.ItemGotRedirected); err != nil {
+			t.Fatal(err)
+		}
+
+		return redirection
+	}
+
+	if got := requestMethod(newRedirection("http://example.com/a", "http://example.com/b", http.StatusTemporaryRedirect, "")); got != http.MethodPost {
+		t.Errorf("307 redirection method = %s, want POST", got)
+	}
+
+	if got := requestMethod(newRedirection("http://example.com/a", "http://example.com/b", http.StatusSeeOther, "")); got != http.MethodGet {
+		t.Errorf("303 redirection method = %s, want GET", got)
+	}
+
+	// Cookies follow the redirections on the same host only
+	sameHost := newRedirection("http://example.com/a", "http://EXAMPLE.com/b", http.StatusFound, "session=1")
+	req, _ := http.NewRequest(http.MethodGet, sameHost.GetURL().String(), nil)
+	copyRedirectCookies(sameHost, req)
+	if got := req.Header.Get("Cookie"); got != "session=1" {
+		t.Errorf("same host redirection Cookie = %q, want %q", got, "session=1")
+	}
+
+	otherHost := newRedirection("http://example.com/a", "http://other.com/b", http.StatusFound, "session=1")
+	req, _ = http.NewRequest(http.MethodGet, otherHost.GetURL().String(), nil)
+	copyRedirectCookies(otherHost, req)
+	if got := req.Header.Get("Cookie"); got != "" {
+		t.Errorf("cross host redirection Cookie = %q, want none", got)
+	}
+
+	// Cookies already set on the request are kept
+	req, _ = http.NewRequest(http.MethodGet, sameHost.GetURL().String(), nil)
+	req.Header.Set("Cookie", "site=specific")
+	copyRedirectCookies(sameHost, req)
+	if got := req.Header.Get("Cookie"); got != "site=specific" {
+		t.Errorf("Cookie = %q, want the one already set", got)
+	}
+}
+
+func TestRedirectionChainHeaders(t *testing.T) {
+	newURL := func(raw string) *models.URL {
+		URL := &models.URL{Raw: raw}
+		if err := URL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		return URL
+	}
+
+	seed := models.NewItem("seed", newURL("http://example.com/a"), "")
+	if err := seed.SetHeaders(http.Header{"X-Token": []string{"secret"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	chain := []*models.Item{seed}
+	for i, raw := range []string{"http://EXAMPLE.com/b", "http://other.com/c", "http://example.com/d"} {
+		redirection := models.NewItem(fmt.Sprintf("hop-%d", i), newURL(raw), "")
+		if err := chain[len(chain)-1].AddChild(redirection, models.ItemGotRedirected); err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, redirection)
+	}
+
+	// The headers follow the chain until it leaves the host, even if it comes back later
+	want := []bool{true, true, false, false}
+	for i, item := range chain {
+		if got := keepsSeedHeaders(item); got != want[i] {
+			t.Errorf("keepsSeedHeaders(%s) = %v, want %v", item.GetURL().Raw, got, want[i])
+		}
+
+		if got := item.GetHeaders().Get("X-Token"); got != "secret" {
+			t.Errorf("%s headers X-Token = %q, want the seed's", item.GetURL().Raw, got)
+		}
+	}
+
+	// The assets of the seed only keep them on its host
+	page := models.NewItem("page", newURL("http://example.com/page"), "")
+	if err := page.SetHeaders(http.Header{"X-Token": []string{"secret"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	sameHost := models.NewItem("same-host", newURL("http://example.com/style.css"), "")
+	otherHost := models.NewItem("other-host", newURL("http://cdn.other.com/script.js"), "")
+	for _, asset := range []*models.Item{sameHost, otherHost} {
+		if err := page.AddChild(asset, models.ItemGotChildren); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !keepsSeedHeaders(sameHost) {
+		t.Errorf("keepsSeedHeaders(%s) = false, want true", sameHost.GetURL().Raw)
+	}
+	if keepsSeedHeaders(otherHost) {
+		t.Errorf("keepsSeedHeaders(%s) = true, want false", otherHost.GetURL().Raw)
+	}
+}
+
+func TestRedirectionBody(t *testing.T) {
+	newRedirection := func(method string, statusCode int) (*models.Item, *http.Request) {
+		parentURL := &models.URL{Raw: "http://example.com/form"}
+		if err := parentURL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		parentReq, _ := http.NewRequest(method, parentURL.Raw, strings.NewReader("q=zeno"))
+		parentReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		parentURL.SetRequest(parentReq)
+		parentURL.SetResponse(&http.Response{StatusCode: statusCode})
+
+		URL := &models.URL{Raw: "http://example.com/submit"}
+		if err := URL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		parent := models.NewItem("parent", parentURL, "")
+		redirection := models.NewItem("redirection", URL, "")
+		if err := parent.AddChild(redirection, models.ItemGotRedirected); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest(requestMethod(redirection), URL.Raw, nil)
+
+		return redirection, req
+	}
+
+	redirection, req := newRedirection(http.MethodPost, http.StatusPermanentRedirect)
+	if err := copyRedirectBody(redirection, req); err != nil {
+		t.Fatalf("copyRedirectBody() error = %v", err)
+	}
+
+	if req.Method != http.MethodPost || req.Body == nil {
+		t.Fatalf("308 redirection = %s with body %v, want POST with the body", req.Method, req.Body != nil)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != "q=zeno" || req.ContentLength != int64(len("q=zeno")) {
+		t.Errorf("308 redirection body = %q (%d), want %q", body, req.ContentLength, "q=zeno")
+	}
+
+	if got := req.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("308 redirection Content-Type = %q", got)
+	}
+
+	// A POST redirected with a 302 switches to GET, without body
+	redirection, req = newRedirection(http.MethodPost, http.StatusFound)
+	if err := copyRedirectBody(redirection, req); err != nil {
+		t.Fatalf("copyRedirectBody() error = %v", err)
+	}
+
+	if req.Method != http.MethodGet || req.Body != nil {
+		t.Errorf("302 redirection = %s with body %v, want GET without body", req.Method, req.Body != nil)
+	}
+}
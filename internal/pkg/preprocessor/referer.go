@@ -0,0 +1,98 @@
+package preprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// setReferer sets the Referer of the request following --referer-policy. The referrer of a URL is the
+// page it was found on, redirections keep the referrer of the request they come from like browsers do.
+// The referrer of a seed is the page its source found it on, if any. A Referer already set, by the
+// site-specific code for example, is kept.
+func setReferer(item *models.Item, req *http.Request) {
+	if req.Header.Get("Referer") != "" {
+		return
+	}
+
+	var from *url.URL
+	if item.IsSeed() {
+		if item.GetSeedVia() == "" {
+			return
+		}
+
+		var err error
+		from, err = url.Parse(item.GetSeedVia())
+		if err != nil {
+			return
+		}
+	} else if item.IsRedirection() {
+		parentReq := item.GetParent().GetURL().GetRequest()
+		if parentReq == nil || parentReq.Header.Get("Referer") == "" {
+			return
+		}
+
+		var err error
+		from, err = url.Parse(parentReq.Header.Get("Referer"))
+		if err != nil {
+			return
+		}
+	} else {
+		from = item.GetParent().GetURL().GetParsed()
+	}
+
+	if referer := refererFor(config.Get().RefererPolicy, from, req.URL); referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+}
+
+// refererFor returns the Referer sent when requesting to from the page from with the given policy,
+// after the Referrer-Policy values of the browsers, or an empty string if none is sent
+func refererFor(policy string, from, to *url.URL) string {
+	if from == nil || to == nil || (from.Scheme != "http" && from.Scheme != "https") {
+		return ""
+	}
+
+	downgrade := from.Scheme == "https" && to.Scheme != "https"
+
+	switch policy {
+	case "unsafe-url":
+		return stripReferer(from)
+	case "origin":
+		return origin(from)
+	case "same-origin":
+		if !strings.EqualFold(from.Hostname(), to.Hostname()) {
+			return ""
+		}
+		return stripReferer(from)
+	case "strict-origin":
+		if downgrade {
+			return ""
+		}
+		return origin(from)
+	case "no-referrer-when-downgrade":
+		if downgrade {
+			return ""
+		}
+		return stripReferer(from)
+	default:
+		return ""
+	}
+}
+
+// stripReferer removes the parts of the URL that are never sent in a Referer: the credentials and the fragment
+func stripReferer(u *url.URL) string {
+	stripped := *u
+	stripped.User = nil
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+
+	return stripped.String()
+}
+
+func origin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + "/"
+}
@@ -0,0 +1,125 @@
+package seencheck
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// bloomSeencheck is a memory efficient seencheck backend that can return false positives,
+// meaning that a small fraction of the URLs never seen before will be considered as seen.
+// Two filters are used to keep the seed/asset distinction of the DB backend: a seed
+// that was previously seen as an asset must be crawled again.
+type bloomSeencheck struct {
+	sync.Mutex
+	path  string
+	seeds *bloom.BloomFilter // URLs seen as seeds
+	all   *bloom.BloomFilter // URLs seen either as seeds or assets
+}
+
+// StartBloom starts the seencheck in bloom filter mode, sized for expectedItems URLs with the given
+// false positive rate. If a filter was persisted in the job's directory by a previous run, it is reloaded.
+func StartBloom(jobPath string, expectedItems uint, falsePositiveRate float64) (err error) {
+	count := int64(0)
+	globalSeencheck = new(Seencheck)
+	globalSeencheck.Count = &count
+	globalSeencheck.Bloom = &bloomSeencheck{
+		path:  path.Join(jobPath, "seencheck.bloom"),
+		seeds: bloom.NewWithEstimates(expectedItems, falsePositiveRate),
+		all:   bloom.NewWithEstimates(expectedItems, falsePositiveRate),
+	}
+
+	return globalSeencheck.Bloom.load()
+}
+
+func (b *bloomSeencheck) isSeen(hash string) (found bool, value string) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.seeds.TestString(hash) {
+		return true, "seed"
+	}
+
+	if b.all.TestString(hash) {
+		return true, "asset"
+	}
+
+	return false, ""
+}
+
+func (b *bloomSeencheck) seen(hash, value string) {
+	b.Lock()
+	defer b.Unlock()
+
+	if value == "seed" {
+		b.seeds.AddString(hash)
+	}
+
+	b.all.AddString(hash)
+}
+
+// load reads the filters persisted by a previous run, if any
+func (b *bloomSeencheck) load() error {
+	f, err := os.Open(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+
+	seeds, all := new(bloom.BloomFilter), new(bloom.BloomFilter)
+	if _, err := seeds.ReadFrom(reader); err != nil {
+		return err
+	}
+
+	if _, err := all.ReadFrom(reader); err != nil {
+		return err
+	}
+
+	b.seeds, b.all = seeds, all
+
+	return nil
+}
+
+// save persists the filters in the job's directory so that they can be reloaded on the next run
+func (b *bloomSeencheck) save() error {
+	b.Lock()
+	defer b.Unlock()
+
+	// Write to a temporary file first to not lose the previous filters if we fail midway
+	tmpPath := b.path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(f)
+	if _, err := b.seeds.WriteTo(writer); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := b.all.WriteTo(writer); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, b.path)
+}
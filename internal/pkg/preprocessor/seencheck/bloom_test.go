@@ -0,0 +1,106 @@
+package seencheck
+
+import (
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestBloomSeencheck(t *testing.T) {
+	if err := StartBloom(t.TempDir(), 1000, 0.0001); err != nil {
+		t.Fatalf("StartBloom() error = %v", err)
+	}
+
+	if found, _ := isSeen("1"); found {
+		t.Fatal("expected hash to not be seen")
+	}
+
+	seen("1", "asset")
+	if found, value := isSeen("1"); !found || value != "asset" {
+		t.Errorf("expected hash to be seen as asset, got found=%v value=%q", found, value)
+	}
+
+	// Promotion of an asset to a seed
+	seen("1", "seed")
+	if found, value := isSeen("1"); !found || value != "seed" {
+		t.Errorf("expected hash to be seen as seed, got found=%v value=%q", found, value)
+	}
+
+	if *globalSeencheck.Count != 2 {
+		t.Errorf("expected count to be 2, got %d", *globalSeencheck.Count)
+	}
+}
+
+func TestBloomSeencheckPersistence(t *testing.T) {
+	jobPath := t.TempDir()
+
+	if err := StartBloom(jobPath, 1000, 0.0001); err != nil {
+		t.Fatalf("StartBloom() error = %v", err)
+	}
+
+	seen("seed-hash", "seed")
+	seen("asset-hash", "asset")
+
+	if err := Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Reload the filters from the job's directory
+	if err := StartBloom(jobPath, 1000, 0.0001); err != nil {
+		t.Fatalf("StartBloom() error = %v", err)
+	}
+
+	if found, value := isSeen("seed-hash"); !found || value != "seed" {
+		t.Errorf("expected seed to be reloaded, got found=%v value=%q", found, value)
+	}
+
+	if found, value := isSeen("asset-hash"); !found || value != "asset" {
+		t.Errorf("expected asset to be reloaded, got found=%v value=%q", found, value)
+	}
+
+	if found, _ := isSeen("never-seen"); found {
+		t.Error("expected hash to not be seen")
+	}
+}
+
+func TestIsSeen(t *testing.T) {
+	if err := StartBloom(t.TempDir(), 1000, 0.0001); err != nil {
+		t.Fatalf("StartBloom() error = %v", err)
+	}
+
+	newURL := func(raw string) *models.URL {
+		URL := &models.URL{Raw: raw}
+		if err := URL.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		return URL
+	}
+
+	seed := models.NewItem("seed", newURL("https://example.com/"), "")
+	if IsSeen("https://example.com/") {
+		t.Errorf("IsSeen() = true before the seencheck")
+	}
+
+	if err := SeencheckItem(seed); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+
+	// The assets of the seed are seencheck'd at the next level
+	asset := models.NewItem("asset", newURL("https://example.com/style.css"), "")
+	if err := seed.AddChild(asset, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SeencheckItem(seed); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+
+	if !IsSeen("https://example.com/") {
+		t.Errorf("IsSeen() = false for a seed seencheck'd")
+	}
+
+	// The assets are crawled again as seeds
+	if IsSeen("https://example.com/style.css") {
+		t.Errorf("IsSeen() = true for an asset")
+	}
+}
@@ -10,10 +10,11 @@ import (
 	"github.com/philippgille/gokv/leveldb"
 )
 
-// Seencheck holds the Seencheck database and the seen counter
+// Seencheck holds the Seencheck database (or bloom filters) and the seen counter
 type Seencheck struct {
 	Count *int64
 	DB    leveldb.Store
+	Bloom *bloomSeencheck
 }
 
 var (
@@ -28,11 +29,20 @@ func Start(jobPath string) (err error) {
 	return err
 }
 
-func Close() {
-	globalSeencheck.DB.Close()
+// Close closes the seencheck database, or persists the bloom filters in bloom filter mode
+func Close() error {
+	if globalSeencheck.Bloom != nil {
+		return globalSeencheck.Bloom.save()
+	}
+
+	return globalSeencheck.DB.Close()
 }
 
 func isSeen(hash string) (found bool, value string) {
+	if globalSeencheck.Bloom != nil {
+		return globalSeencheck.Bloom.isSeen(hash)
+	}
+
 	found, err := globalSeencheck.DB.Get(hash, &value)
 	if err != nil {
 		panic(err)
@@ -42,6 +52,12 @@ func isSeen(hash string) (found bool, value string) {
 }
 
 func seen(hash, value string) {
+	if globalSeencheck.Bloom != nil {
+		globalSeencheck.Bloom.seen(hash, value)
+		atomic.AddInt64(globalSeencheck.Count, 1)
+		return
+	}
+
 	globalSeencheck.DB.Set(hash, value)
 	atomic.AddInt64(globalSeencheck.Count, 1)
 }
@@ -69,6 +85,22 @@ func seen(hash, value string) {
 // 	return seencheckedURLs, nil
 // }
 
+// IsSeen tells if the URL was already crawled as a seed, without marking it as seen. It's false when the
+// local seencheck isn't started.
+func IsSeen(URL string) bool {
+	if globalSeencheck == nil {
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(URL))
+
+	// A URL seen as an asset is crawled again as a seed
+	found, URLType := isSeen(strconv.FormatUint(h.Sum64(), 10))
+
+	return found && URLType == "seed"
+}
+
 // SeencheckItem gets the MaxDepth children of the given item and seencheck them locally.
 // The items that were seen before will be marked as seen.
 // Different from the HQ seencheck, the local seencheck performs seencheck on top level seeds.
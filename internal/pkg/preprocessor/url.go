@@ -23,8 +23,8 @@ func NormalizeURL(URL *models.URL, parentURL *models.URL) (err error) {
 	}
 
 	if parentURL != nil && !parsedURL.IsAbs() {
-		// Determine the base with the following logic:
-		// - always with the <base> tag found in the HTML document, if it exists (TBI)
+		// Determine the base with the following logic (the URLs relative to the <base> of an
+		// HTML document are already resolved by the extractors):
 		// - if the URL starts with a slash, use the parent URL's scheme and host
 		// - if the URL does not start with a slash, use the parent URL's scheme, host, and path
 		baseURL := parentURL.GetParsed()
@@ -0,0 +1,41 @@
+package preprocessor
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// pickUserAgent returns the User-Agent to send for the item. Without a pool, the configured User-Agent is used.
+// With the per-host rotation a host always gets the same User-Agent of the pool, with the per-request
+// rotation a random one is picked for each request. Redirections keep the User-Agent of the request
+// they come from, so that a redirect chain is consistently fetched with the same one.
+func pickUserAgent(item *models.Item) string {
+	pool := config.Get().UserAgentPool
+	if len(pool) == 0 {
+		return config.Get().UserAgent
+	}
+
+	if item.IsRedirection() {
+		if req := item.GetParent().GetURL().GetRequest(); req != nil && req.Header.Get("User-Agent") != "" {
+			return req.Header.Get("User-Agent")
+		}
+	}
+
+	if config.Get().UserAgentRotation == "per-request" {
+		return pool[rand.IntN(len(pool))]
+	}
+
+	var host string
+	if item.GetURL().GetParsed() != nil {
+		host = strings.ToLower(item.GetURL().GetParsed().Hostname())
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(host))
+
+	return pool[hash.Sum32()%uint32(len(pool))]
+}
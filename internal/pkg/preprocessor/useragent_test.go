@@ -0,0 +1,74 @@
+package preprocessor
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func TestPickUserAgent(t *testing.T) {
+	config.InitConfig()
+
+	defer func(userAgent string, pool []string, rotation string) {
+		config.Get().UserAgent = userAgent
+		config.Get().UserAgentPool = pool
+		config.Get().UserAgentRotation = rotation
+	}(config.Get().UserAgent, config.Get().UserAgentPool, config.Get().UserAgentRotation)
+
+	newItem := func(rawURL string) *models.Item {
+		u := &models.URL{Raw: rawURL}
+		if err := u.Parse(); err != nil {
+			t.Fatalf("unable to parse URL: %v", err)
+		}
+
+		return models.NewItem(rawURL, u, "")
+	}
+
+	config.Get().UserAgent = "Zeno"
+	config.Get().UserAgentPool = nil
+	if got := pickUserAgent(newItem("http://example.com/")); got != "Zeno" {
+		t.Errorf("without a pool, got %q", got)
+	}
+
+	pool := []string{"UA 1", "UA 2", "UA 3", "UA 4"}
+	config.Get().UserAgentPool = pool
+
+	config.Get().UserAgentRotation = "per-host"
+	first := pickUserAgent(newItem("http://example.com/a"))
+	if !slices.Contains(pool, first) {
+		t.Fatalf("picked User-Agent %q isn't in the pool", first)
+	}
+
+	for _, rawURL := range []string{"http://example.com/b", "https://EXAMPLE.com/c"} {
+		if got := pickUserAgent(newItem(rawURL)); got != first {
+			t.Errorf("per-host rotation picked %q for %s, want %q", got, rawURL, first)
+		}
+	}
+
+	config.Get().UserAgentRotation = "per-request"
+	for range 20 {
+		if got := pickUserAgent(newItem("http://example.com/")); !slices.Contains(pool, got) {
+			t.Fatalf("picked User-Agent %q isn't in the pool", got)
+		}
+	}
+
+	// Redirections keep the User-Agent of the request they come from
+	parent := newItem("http://example.com/old")
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/old", nil)
+	req.Header.Set("User-Agent", "UA 3")
+	parent.GetURL().SetRequest(req)
+
+	redirection := newItem("http://other.com/new")
+	if err := parent.AddChild(redirection, models.ItemGotRedirected); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 20 {
+		if got := pickUserAgent(redirection); got != "UA 3" {
+			t.Fatalf("redirection picked %q, want %q", got, "UA 3")
+		}
+	}
+}
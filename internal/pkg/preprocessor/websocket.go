@@ -0,0 +1,19 @@
+package preprocessor
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+)
+
+// setWebSocketHandshake makes the request the opening handshake of a WebSocket connection (RFC 6455),
+// the response of the server is captured and the connection closed once it's upgraded
+func setWebSocketHandshake(req *http.Request) {
+	key := make([]byte, 16)
+	rand.Read(key)
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+}
@@ -0,0 +1,27 @@
+package preprocessor
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestSetWebSocketHandshake(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://live.example.com/socket", nil)
+	setWebSocketHandshake(req)
+
+	if req.Header.Get("Connection") != "Upgrade" || req.Header.Get("Upgrade") != "websocket" || req.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Errorf("unexpected handshake headers %v", req.Header)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(req.Header.Get("Sec-WebSocket-Key"))
+	if err != nil || len(key) != 16 {
+		t.Errorf("expected a 16 bytes base64 key, got %q", req.Header.Get("Sec-WebSocket-Key"))
+	}
+
+	other, _ := http.NewRequest(http.MethodGet, "https://live.example.com/socket", nil)
+	setWebSocketHandshake(other)
+	if other.Header.Get("Sec-WebSocket-Key") == req.Header.Get("Sec-WebSocket-Key") {
+		t.Error("expected a new key for each handshake")
+	}
+}
@@ -5,6 +5,10 @@ import "github.com/internetarchive/Zeno/pkg/models"
 // GetStateTable returns a slice of all the seeds UUIDs as string in the state table.
 func GetStateTable() []string {
 	keys := []string{}
+	if globalReactor == nil {
+		return keys
+	}
+
 	globalReactor.stateTable.Range(func(key, _ interface{}) bool {
 		keys = append(keys, key.(string))
 		return true
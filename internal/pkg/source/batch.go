@@ -0,0 +1,224 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// batcher accumulates the items received on a channel into batches and sends them to the source,
+// it is how both the finished and the discovered items are reported
+type batcher struct {
+	name         string
+	input        chan *models.Item
+	batchSize    int
+	senders      int
+	flushTimeout time.Duration
+	send         func(ctx context.Context, items []*models.Item) error
+
+	// Items sent and given up on once the source is stopped
+	flushed   atomic.Int64
+	abandoned atomic.Int64
+}
+
+func finisher() {
+	runBatcher(&batcher{
+		name:         "finisher",
+		input:        globalSource.finishCh,
+		batchSize:    globalSource.batching.Finished,
+		senders:      globalSource.batching.FinishSenders,
+		flushTimeout: globalSource.flushTimeout,
+		send:         globalSource.src.Finished,
+	})
+}
+
+func producer() {
+	runBatcher(&batcher{
+		name:         "producer",
+		input:        globalSource.produceCh,
+		batchSize:    globalSource.batching.Discovered,
+		senders:      globalSource.batching.DiscoverSenders,
+		flushTimeout: globalSource.flushTimeout,
+		send:         globalSource.src.Discovered,
+	})
+}
+
+// runBatcher starts the receiver and dispatcher processes of the batcher and waits for the source to be stopped,
+// then waits for the remaining items to be flushed to the source
+func runBatcher(b *batcher) {
+	defer globalSource.wg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source." + b.name,
+	})
+
+	// The receiver stops when the source is stopped, the senders keep retrying until the flush timeout
+	sendCtx, sendCancel := context.WithCancel(context.Background())
+	defer sendCancel()
+
+	batchCh := make(chan []*models.Item, b.senders)
+
+	var receiverWg, dispatcherWg sync.WaitGroup
+
+	receiverWg.Add(1)
+	go b.receiver(globalSource.ctx, &receiverWg, batchCh)
+
+	dispatcherWg.Add(1)
+	go b.dispatcher(globalSource.ctx, sendCtx, &dispatcherWg, batchCh)
+
+	<-globalSource.ctx.Done()
+	logger.Debug("received done signal")
+
+	flushTimer := time.AfterFunc(b.flushTimeout, sendCancel)
+	defer flushTimer.Stop()
+
+	// Wait for the receiver to hand over the items left, then close the batch channel
+	// to signal the dispatcher to finish once every batch is sent.
+	receiverWg.Wait()
+	close(batchCh)
+
+	logger.Debug("waiting for the batches to be flushed")
+	dispatcherWg.Wait()
+
+	if abandoned := b.abandoned.Load(); abandoned > 0 {
+		logger.Warn("flush timeout reached, items abandoned", "flushed", b.flushed.Load(), "abandoned", abandoned, "timeout", b.flushTimeout.String())
+	} else {
+		logger.Info("flushed", "flushed", b.flushed.Load())
+	}
+
+	logger.Debug("closed")
+}
+
+// receiver reads items from the input channel, accumulates them into batches, and sends the batches to batchCh.
+// When ctx is done, the items left in the input channel are sent too.
+func (b *batcher) receiver(ctx context.Context, wg *sync.WaitGroup, batchCh chan []*models.Item) {
+	defer wg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source." + b.name + "Receiver",
+	})
+
+	maxWaitTime := 5 * time.Second
+
+	batch := make([]*models.Item, 0, b.batchSize)
+	ticker := time.NewTicker(maxWaitTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.drain(batch, batchCh)
+			logger.Debug("closed")
+			return
+		case item := <-b.input:
+			logger.Debug("received item", "item", item.GetShortID())
+
+			batch = append(batch, item)
+			if len(batch) >= b.batchSize {
+				logger.Debug("sending batch to dispatcher", "size", len(batch))
+				batchCh <- batch // Blocks if batchCh is full.
+				batch = make([]*models.Item, 0, b.batchSize)
+				ticker.Reset(maxWaitTime)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				logger.Debug("sending non-full batch to dispatcher", "size", len(batch))
+				batchCh <- batch // Blocks if batchCh is full.
+				batch = make([]*models.Item, 0, b.batchSize)
+			}
+		}
+	}
+}
+
+// drain sends the pending batch and the items left in the input channel to batchCh,
+// nothing is sent on the input channel anymore once the finisher is stopped.
+func (b *batcher) drain(batch []*models.Item, batchCh chan []*models.Item) {
+	for {
+		select {
+		case item := <-b.input:
+			batch = append(batch, item)
+			if len(batch) >= b.batchSize {
+				batchCh <- batch
+				batch = make([]*models.Item, 0, b.batchSize)
+			}
+		default:
+			if len(batch) > 0 {
+				batchCh <- batch
+			}
+			return
+		}
+	}
+}
+
+// dispatcher receives batches from batchCh and dispatches them to sender routines until batchCh is closed.
+func (b *batcher) dispatcher(ctx, sendCtx context.Context, wg *sync.WaitGroup, batchCh chan []*models.Item) {
+	defer wg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source." + b.name + "Dispatcher",
+	})
+
+	senderSemaphore := make(chan struct{}, b.senders)
+	var senderWg sync.WaitGroup
+
+	for batch := range batchCh {
+		batchUUID := uuid.NewString()[:6]
+		senderSemaphore <- struct{}{} // Blocks if maxSenders reached.
+		senderWg.Add(1)
+		logger.Debug("dispatching batch to sender", "size", len(batch))
+		go func(batch []*models.Item, batchUUID string) {
+			defer senderWg.Done()
+			defer func() { <-senderSemaphore }()
+			b.sender(ctx, sendCtx, batch, batchUUID)
+		}(batch, batchUUID)
+	}
+
+	logger.Debug("waiting for sender routines to finish")
+	senderWg.Wait()
+	logger.Debug("closed")
+}
+
+// sender sends a batch of items to the source with retries and exponential backoff.
+// Once the source is stopped (ctx done), it keeps retrying until sendCtx is canceled by the flush timeout.
+func (b *batcher) sender(ctx, sendCtx context.Context, batch []*models.Item, batchUUID string) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": fmt.Sprintf("source.%sSender.%s", b.name, batchUUID),
+	})
+	defer logger.Debug("done")
+
+	backoff := time.Second
+	maxBackoff := 5 * time.Second
+
+	logger.Debug("sending batch to source", "size", len(batch))
+
+	for {
+		err := b.send(sendCtx, batch)
+		if err == nil {
+			if ctx.Err() != nil {
+				b.flushed.Add(int64(len(batch)))
+			}
+			return
+		}
+
+		logger.Error("error sending batch to source", "err", err)
+
+		select {
+		case <-sendCtx.Done():
+			logger.Error("flush timeout reached, abandoning batch", "size", len(batch))
+			b.abandoned.Add(int64(len(batch)))
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func consumer() {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source.consumer",
+	})
+
+	// Create a context to manage goroutines
+	ctx, cancel := context.WithCancel(globalSource.ctx)
+	defer cancel()
+
+	// Set the batch size for fetching URLs
+	batchSize := globalSource.batching.Pull
+
+	// Create a fixed-size buffer (channel) for items
+	itemBuffer := make(chan *models.Item, batchSize)
+
+	// WaitGroup to wait for goroutines to finish on shutdown
+	var wg sync.WaitGroup
+
+	// Start the consumerFetcher goroutine(s)
+	wg.Add(1)
+	go consumerFetcher(ctx, &wg, itemBuffer, batchSize)
+
+	// Start the consumerSender goroutine(s)
+	wg.Add(1)
+	go consumerSender(ctx, &wg, itemBuffer)
+
+	// Wait for shutdown signal
+	for {
+		select {
+		case <-globalSource.ctx.Done():
+			logger.Debug("received done signal")
+
+			// Cancel the context to stop all goroutines.
+			cancel()
+
+			logger.Debug("waiting for goroutines to finish")
+
+			// Wait for all goroutines to finish
+			wg.Wait()
+
+			// Close the itemBuffer to signal consumerSenders to finish
+			close(itemBuffer)
+
+			globalSource.wg.Done()
+
+			logger.Debug("closed")
+			return
+		}
+	}
+}
+
+func consumerFetcher(ctx context.Context, wg *sync.WaitGroup, itemBuffer chan<- *models.Item, batchSize int) {
+	defer wg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source.consumerFetcher",
+	})
+
+	for {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			logger.Debug("closed")
+			return
+		default:
+		}
+
+		// Pull items from the source
+		items, err := globalSource.src.Pull(ctx, batchSize)
+		if err != nil || len(items) == 0 {
+			if err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("error pulling URLs from source", "err", err.Error(), "func", "source.consumerFetcher")
+			} else {
+				logger.Debug("feed is empty, waiting for new URLs")
+			}
+			time.Sleep(250 * time.Millisecond)
+			continue
+		}
+
+		err = ensureAllIDsNotInReactor(items)
+		if err != nil {
+			spew.Dump(items)
+			panic(err)
+		}
+
+		// Enqueue items into the buffer
+		for i := range items {
+			select {
+			case <-ctx.Done():
+				logger.Debug("closed")
+				return
+			case itemBuffer <- items[i]:
+			}
+		}
+
+		// Empty the items slice
+		items = nil
+	}
+}
+
+func consumerSender(ctx context.Context, wg *sync.WaitGroup, itemBuffer <-chan *models.Item) {
+	defer wg.Done()
+
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "source.consumerSender",
+	})
+
+	var previousIDReceived string
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug("closed")
+			return
+		case item := <-itemBuffer:
+			// Debug check to troubleshoot a problem where the same seed is received twice by the reactor
+			if previousIDReceived != "" && previousIDReceived == item.GetID() {
+				spew.Dump(item)
+				panic("same seed received twice by source.consumerSender")
+			}
+			previousIDReceived = item.GetID()
+
+			if item.GetURL() == nil || item.GetURL().GetParsed() == nil {
+				logger.Debug("parsing failed, sending the item to finisher", "item", item.GetShortID())
+				select {
+				case <-ctx.Done():
+					logger.Debug("closed while sending to finisher")
+					return
+				case globalSource.finishCh <- item:
+				}
+				break
+			}
+
+			logger.Debug("sending new item to reactor", "item", item.GetShortID())
+
+			// Send the new Item to the reactor
+			err := reactor.ReceiveInsert(item)
+			if err != nil {
+				if err == reactor.ErrReactorFrozen {
+					select {
+					case <-ctx.Done():
+						logger.Debug("closed while sending to frozen reactor")
+						return
+					}
+				}
+				panic(err)
+			}
+		}
+	}
+}
+
+func ensureAllIDsNotInReactor(items []*models.Item) error {
+	reactorIDs := reactor.GetStateTable()
+	reactorIDMap := make(map[string]struct{})
+	for i := range reactorIDs {
+		reactorIDMap[reactorIDs[i]] = struct{}{}
+	}
+
+	for i := range items {
+		if _, ok := reactorIDMap[items[i].GetID()]; ok {
+			return fmt.Errorf("URL ID %s found in reactor", items[i].GetID())
+		}
+	}
+	return nil
+}
@@ -1,25 +1,38 @@
-// Package hq provides a way to interact with the HQv3 API and consumes, produces and mark items as finished asynchronusly.
+// Package hq provides a way to interact with the HQv3 API, it is the URL source of the crawls run with HQ.
 package hq
 
 import (
 	"context"
+	"path"
 	"sync"
+	"time"
 
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/reactor"
 	"github.com/internetarchive/Zeno/internal/pkg/stats"
-	"github.com/internetarchive/Zeno/pkg/models"
 	"github.com/internetarchive/gocrawlhq"
 )
 
 type hq struct {
-	wg        sync.WaitGroup
-	ctx       context.Context
-	cancel    context.CancelFunc
-	finishCh  chan *models.Item
-	produceCh chan *models.Item
-	client    *gocrawlhq.Client
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	client *gocrawlhq.Client
+
+	// batches is where the finished and discovered URLs are sent, the client outside of tests
+	batches batchSender
+	// spool keeps the batches while HQ is unreachable, nil if spooling is disabled
+	spool  *spool
+	health health
+
+	// pullBackoff is the pause after a failed pull
+	pullBackoff time.Duration
+}
+
+type batchSender interface {
+	Add(ctx context.Context, URLs []gocrawlhq.URL, bypassSeencheck bool) error
+	Delete(ctx context.Context, URLs []gocrawlhq.URL, localCrawls int) error
 }
 
 var (
@@ -28,8 +41,8 @@ var (
 	logger   *log.FieldedLogger
 )
 
-// Start initializes HQ async routines with the given input and output channels.
-func Start(finishChan, produceChan chan *models.Item) error {
+// Start initializes the HQ client and the websocket connection, the URLs are then exchanged through Source.
+func Start() error {
 	var done bool
 	var startErr error
 
@@ -51,21 +64,39 @@ func Start(finishChan, produceChan chan *models.Item) error {
 			return
 		}
 
+		var spool *spool
+		if config.Get().HQSpoolMaxSize > 0 {
+			spool, err = newSpool(path.Join(config.Get().JobPath, "hq-spool"), int64(config.Get().HQSpoolMaxSize)*1024*1024)
+			if err != nil {
+				logger.Error("error opening hq spool", "err", err.Error(), "func", "hq.Start")
+				cancel()
+				done = true
+				startErr = err
+				return
+			}
+
+			if spool.len() > 0 {
+				logger.Info("URLs spooled by a previous run are sent to crawl HQ first", "urls", spool.count())
+			}
+		}
+
 		globalHQ = &hq{
-			wg:        sync.WaitGroup{},
-			ctx:       ctx,
-			cancel:    cancel,
-			finishCh:  finishChan,
-			produceCh: produceChan,
-			client:    HQclient,
+			wg:      sync.WaitGroup{},
+			ctx:     ctx,
+			cancel:  cancel,
+			client:  HQclient,
+			batches: HQclient,
+			spool:   spool,
 		}
 
-		globalHQ.wg.Add(4)
-		go consumer()
-		go producer()
-		go finisher()
+		globalHQ.wg.Add(1)
 		go websocket()
 
+		if spool != nil {
+			globalHQ.wg.Add(1)
+			go globalHQ.drainer()
+		}
+
 		logger.Info("started")
 
 		done = true
@@ -78,11 +109,15 @@ func Start(finishChan, produceChan chan *models.Item) error {
 	return startErr
 }
 
-// Stop stops the global HQ and waits for all goroutines to finish. Finisher must be stopped first and Reactor must be frozen before stopping HQ.
+// Stop stops the global HQ and resets the seeds still in the reactor. The source must be stopped first and Reactor must be frozen before stopping HQ.
 func Stop() {
 	if globalHQ != nil {
 		globalHQ.cancel()
 		globalHQ.wg.Wait()
+
+		if globalHQ.spool != nil && globalHQ.spool.len() > 0 {
+			logger.Warn("URLs left in the hq spool, they are sent to crawl HQ at the next start", "urls", globalHQ.spool.count())
+		}
 		seedsToReset := reactor.GetStateTable()
 		for _, seed := range seedsToReset {
 			if err := globalHQ.client.ResetURL(context.TODO(), seed); err != nil {
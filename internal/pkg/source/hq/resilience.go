@@ -0,0 +1,141 @@
+package hq
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+const maxBackoff = time.Minute
+
+// health tracks whether HQ can be reached, a warning is logged when that changes
+type health struct {
+	mu               sync.Mutex
+	unreachableSince time.Time
+}
+
+func (h *health) failed(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.unreachableSince.IsZero() {
+		h.unreachableSince = time.Now()
+		logger.Warn("crawl HQ is unreachable, pulling is paused and outgoing URLs are spooled", "err", err.Error())
+	}
+}
+
+func (h *health) succeeded() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.unreachableSince.IsZero() {
+		logger.Warn("crawl HQ is reachable again", "unreachable_for", time.Since(h.unreachableSince).Round(time.Second).String())
+		h.unreachableSince = time.Time{}
+		stats.HQUnreachableSecondsSet(0)
+	}
+}
+
+// unreachableFor returns for how long HQ has been unreachable, 0 if it is reachable
+func (h *health) unreachableFor() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.unreachableSince.IsZero() {
+		return 0
+	}
+
+	return time.Since(h.unreachableSince)
+}
+
+// nextBackoff doubles the backoff, from 1s up to maxBackoff
+func nextBackoff(backoff time.Duration) time.Duration {
+	return min(max(2*backoff, time.Second), maxBackoff)
+}
+
+// send sends the batch to HQ. While batches are spooled, or if HQ can't be reached, the batch is
+// spooled to keep the order. An error is only returned if it can't be spooled either.
+func (h *hq) send(ctx context.Context, entry spoolEntry) error {
+	if h.spool == nil {
+		return h.deliver(ctx, entry)
+	}
+
+	if h.spool.len() == 0 {
+		err := h.deliver(ctx, entry)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return h.spool.push(entry)
+}
+
+// deliver sends the batch to HQ and keeps track of its health
+func (h *hq) deliver(ctx context.Context, entry spoolEntry) (err error) {
+	switch entry.Kind {
+	case spoolFinished:
+		err = h.batches.Delete(ctx, entry.URLs, entry.ChildsCaptured)
+	case spoolDiscovered:
+		err = h.batches.Add(ctx, entry.URLs, false) // Use bypassSeencheck = false
+	}
+
+	if err != nil {
+		h.health.failed(err)
+		return err
+	}
+
+	h.health.succeeded()
+
+	return nil
+}
+
+// drainer sends the spooled batches to HQ in order, backing off while it is unreachable
+func (h *hq) drainer() {
+	defer h.wg.Done()
+
+	backoff := time.Duration(0)
+	wait := time.Duration(0)
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			if h.spool.len() > 0 {
+				logger.Warn("URLs left in the hq spool, they are sent on the next run", "urls", h.spool.count())
+			}
+			return
+		case <-time.After(wait):
+		}
+
+		stats.HQUnreachableSecondsSet(int64(h.health.unreachableFor().Seconds()))
+
+		entry, found, err := h.spool.peek()
+		if err != nil {
+			logger.Error("unable to read the hq spool, dropping the batch", "err", err.Error())
+			h.spool.pop()
+			continue
+		}
+
+		if !found {
+			wait = time.Second
+			continue
+		}
+
+		if err := h.deliver(h.ctx, entry); err != nil {
+			backoff = nextBackoff(backoff)
+			wait = backoff
+			continue
+		}
+
+		backoff = 0
+		wait = 0
+
+		if err := h.spool.pop(); err != nil {
+			logger.Error("unable to remove the batch sent from the hq spool", "err", err.Error())
+		}
+
+		if h.spool.len() == 0 {
+			logger.Info("hq spool drained")
+		}
+	}
+}
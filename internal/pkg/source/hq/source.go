@@ -0,0 +1,209 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
+	"github.com/internetarchive/Zeno/pkg/models"
+	"github.com/internetarchive/gocrawlhq"
+)
+
+// hqSource exchanges the URLs of the crawl with Crawl HQ
+type hqSource struct{}
+
+// Source returns the URL source backed by Crawl HQ, HQ must be started
+func Source() source.URLSource {
+	return hqSource{}
+}
+
+// Batching returns the batch sizes and the number of concurrent senders used with HQ
+func (hqSource) Batching() source.Batching {
+	return source.Batching{
+		Pull:            config.Get().HQBatchSize,
+		Finished:        getBatchSize(),
+		Discovered:      getProducerBatchSize(),
+		FinishSenders:   getMaxSenders(),
+		DiscoverSenders: getMaxSenders(),
+	}
+}
+
+// Pull gets URLs from HQ. When HQ can't be reached, pulling is paused for longer and longer, up to a minute,
+// the crawl goes on with the URLs already pulled.
+func (hqSource) Pull(ctx context.Context, n int) ([]*models.Item, error) {
+	URLs, err := getURLs(n)
+	if err != nil && err.Error() != "gocrawlhq: feed is empty" {
+		globalHQ.health.failed(err)
+
+		globalHQ.pullBackoff = nextBackoff(globalHQ.pullBackoff)
+		select {
+		case <-ctx.Done():
+		case <-time.After(globalHQ.pullBackoff):
+		}
+
+		return nil, err
+	}
+
+	globalHQ.pullBackoff = 0
+	globalHQ.health.succeeded()
+
+	if err != nil {
+		return nil, nil
+	}
+
+	err = ensureAllURLsUnique(URLs)
+	if err != nil {
+		spew.Dump(URLs)
+		panic(err)
+	}
+
+	items := make([]*models.Item, 0, len(URLs))
+	for _, URL := range URLs {
+		// Items whose URL can't be parsed are sent to the finisher by the source
+		parsedURL := models.URL{
+			Raw:  URL.Value,
+			Hops: pathToHops(URL.Path),
+		}
+		parsedURL.Parse()
+
+		newItem := models.NewItem(URL.ID, &parsedURL, URL.Via)
+		newItem.SetStatus(models.ItemFresh)
+		newItem.SetSource(models.ItemSourceHQ)
+
+		items = append(items, newItem)
+	}
+
+	return items, nil
+}
+
+// Finished marks the items as done in HQ
+func (hqSource) Finished(ctx context.Context, items []*models.Item) error {
+	URLs := make([]gocrawlhq.URL, 0, len(items))
+	var childsCaptured int
+
+	for _, item := range items {
+		var value string
+		// If preprocessing failed, there will be nil values here
+		if item.GetURL() != nil && item.GetURL().GetParsed() != nil {
+			value = item.GetURL().String()
+		}
+
+		URLs = append(URLs, gocrawlhq.URL{
+			ID:    item.GetID(),
+			Value: value,
+			Type:  "seed",
+		})
+
+		item.Traverse(func(itemTraversed *models.Item) {
+			if itemTraversed.IsChild() {
+				childsCaptured++
+			}
+		})
+	}
+
+	return globalHQ.send(ctx, spoolEntry{Kind: spoolFinished, URLs: URLs, ChildsCaptured: childsCaptured})
+}
+
+// Discovered adds the items to HQ
+func (hqSource) Discovered(ctx context.Context, items []*models.Item) error {
+	URLs := make([]gocrawlhq.URL, 0, len(items))
+	for _, item := range items {
+		URLs = append(URLs, gocrawlhq.URL{
+			Value: item.GetURL().Raw,
+			Via:   item.GetSeedVia(),
+			Path:  hopsToPath(item.GetURL().GetHops()),
+		})
+	}
+
+	return globalHQ.send(ctx, spoolEntry{Kind: spoolDiscovered, URLs: URLs})
+}
+
+func getURLs(batchSize int) ([]gocrawlhq.URL, error) {
+	// Fetch URLs from CrawlHQ with optional concurrency
+	if config.Get().HQBatchConcurrency == 1 {
+		return globalHQ.client.Get(context.TODO(), batchSize)
+	}
+
+	var wg sync.WaitGroup
+	concurrency := config.Get().HQBatchConcurrency
+	subBatchSize := batchSize / concurrency
+	urlsChan := make(chan []gocrawlhq.URL, concurrency)
+	errsChan := make(chan error, concurrency)
+	var allURLs []gocrawlhq.URL
+
+	// Start concurrent fetches
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			URLs, err := globalHQ.client.Get(context.TODO(), subBatchSize)
+			if err != nil {
+				logger.Error("error fetching URLs from CrawlHQ", "err", err.Error(), "func", "hq.getURLs")
+				errsChan <- err
+				return
+			}
+			urlsChan <- URLs
+		}()
+	}
+
+	// Wait for all fetches to complete
+	wg.Wait()
+	close(urlsChan)
+	close(errsChan)
+
+	// HQ is only considered unreachable if all the fetches failed
+	if len(errsChan) == concurrency {
+		return nil, <-errsChan
+	}
+
+	// Collect URLs from all fetches
+	for URLs := range urlsChan {
+		if len(URLs) != 0 {
+			allURLs = append(allURLs, URLs...)
+		}
+	}
+
+	return allURLs, nil
+}
+
+func ensureAllURLsUnique(URLs []gocrawlhq.URL) error {
+	seen := make(map[string]struct{})
+	for _, URL := range URLs {
+		if _, ok := seen[URL.ID]; ok {
+			return errors.New("duplicate URL ID found")
+		}
+		seen[URL.ID] = struct{}{}
+	}
+	return nil
+}
+
+// getMaxSenders returns the maximum number of sender routines based on configuration.
+func getMaxSenders() int {
+	workersCount := config.Get().WorkersCount
+	if workersCount < 10 {
+		return 1
+	}
+	return workersCount / 10
+}
+
+// getBatchSize returns the batch size of the finished URLs based on configuration.
+func getBatchSize() int {
+	batchSize := config.Get().WorkersCount
+	if batchSize == 0 {
+		batchSize = 100 // Default batch size.
+	}
+	return batchSize
+}
+
+// getProducerBatchSize returns the batch size of the discovered URLs based on configuration.
+func getProducerBatchSize() int {
+	batchSize := config.Get().HQBatchSize
+	if batchSize == 0 {
+		batchSize = 100 // Default batch size.
+	}
+	return batchSize
+}
@@ -0,0 +1,215 @@
+package hq
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/gocrawlhq"
+)
+
+const (
+	spoolFinished   = "finished"
+	spoolDiscovered = "discovered"
+)
+
+var errSpoolFull = errors.New("hq spool is full")
+
+// spoolEntry is a batch of URLs to send to HQ
+type spoolEntry struct {
+	Kind           string          `json:"kind"`
+	URLs           []gocrawlhq.URL `json:"urls"`
+	ChildsCaptured int             `json:"childs_captured,omitempty"`
+}
+
+// spool is a bounded FIFO of the batches that couldn't be sent to HQ, stored in the job directory so that
+// they survive a restart. Every batch is a file named <sequence>-<URLs count>.json.
+type spool struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+	files   []spoolFile
+	size    int64
+	items   int64
+	next    uint64
+}
+
+type spoolFile struct {
+	name  string
+	seq   uint64
+	size  int64
+	items int64
+}
+
+// newSpool opens the spool in dir, the batches spooled by a previous run are kept
+func newSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+
+	for _, entry := range entries {
+		seq, items, ok := parseSpoolName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		s.files = append(s.files, spoolFile{name: entry.Name(), seq: seq, size: info.Size(), items: items})
+		s.size += info.Size()
+		s.items += items
+		s.next = max(s.next, seq+1)
+	}
+
+	slices.SortFunc(s.files, func(a, b spoolFile) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	stats.HQSpooledItemsSet(s.items)
+
+	return s, nil
+}
+
+func parseSpoolName(name string) (seq uint64, items int64, ok bool) {
+	name, found := strings.CutSuffix(name, ".json")
+	if !found {
+		return 0, 0, false
+	}
+
+	seqPart, itemsPart, found := strings.Cut(name, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	items, err = strconv.ParseInt(itemsPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return seq, items, true
+}
+
+// push appends the batch to the spool, errSpoolFull is returned if it would exceed the maximum size
+func (s *spool) push(entry spoolEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+int64(len(data)) > s.maxSize {
+		return errSpoolFull
+	}
+
+	file := spoolFile{
+		name:  fmt.Sprintf("%020d-%d.json", s.next, len(entry.URLs)),
+		seq:   s.next,
+		size:  int64(len(data)),
+		items: int64(len(entry.URLs)),
+	}
+
+	// Written aside then renamed so that a crash never leaves a partial batch
+	tmp := filepath.Join(s.dir, file.name+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(s.dir, file.name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	s.next++
+	s.files = append(s.files, file)
+	s.size += file.size
+	s.items += file.items
+	stats.HQSpooledItemsSet(s.items)
+
+	return nil
+}
+
+// peek returns the oldest batch, false if the spool is empty
+func (s *spool) peek() (entry spoolEntry, found bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return entry, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, s.files[0].name))
+	if err != nil {
+		return entry, false, err
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false, fmt.Errorf("corrupted hq spool file %s: %w", s.files[0].name, err)
+	}
+
+	return entry, true, nil
+}
+
+// pop removes the oldest batch
+func (s *spool) pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, s.files[0].name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	s.size -= s.files[0].size
+	s.items -= s.files[0].items
+	s.files = s.files[1:]
+	stats.HQSpooledItemsSet(s.items)
+
+	return nil
+}
+
+// len returns the number of batches spooled
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.files)
+}
+
+// count returns the number of URLs spooled
+func (s *spool) count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.items
+}
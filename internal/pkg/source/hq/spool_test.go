@@ -0,0 +1,206 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/gocrawlhq"
+)
+
+func initTestLogger() {
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "hq",
+	})
+	stats.Init()
+}
+
+func batch(kind string, values ...string) spoolEntry {
+	entry := spoolEntry{Kind: kind}
+	for _, value := range values {
+		entry.URLs = append(entry.URLs, gocrawlhq.URL{Value: value})
+	}
+	return entry
+}
+
+func TestSpool(t *testing.T) {
+	initTestLogger()
+
+	dir := filepath.Join(t.TempDir(), "hq-spool")
+
+	s, err := newSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+
+	if err := s.push(batch(spoolFinished, "a", "b")); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if err := s.push(batch(spoolDiscovered, "c")); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	// A partial write of a crashed run is ignored
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000009-1.json.tmp"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The batches survive a restart, in order
+	s, err = newSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+
+	if s.len() != 2 || s.count() != 3 {
+		t.Fatalf("expected 2 batches of 3 URLs, got %d batches of %d URLs", s.len(), s.count())
+	}
+	if got := stats.HQSpooledItemsGet(); got != 3 {
+		t.Fatalf("expected 3 spooled items in the stats, got %d", got)
+	}
+
+	for _, want := range []string{"a", "c"} {
+		entry, found, err := s.peek()
+		if err != nil || !found {
+			t.Fatalf("peek() = %v, %v", found, err)
+		}
+		if entry.URLs[0].Value != want {
+			t.Fatalf("expected the batch of %s, got %+v", want, entry)
+		}
+		if err := s.pop(); err != nil {
+			t.Fatalf("pop() error = %v", err)
+		}
+	}
+
+	if _, found, _ := s.peek(); found {
+		t.Fatalf("expected the spool to be empty")
+	}
+
+	// Batches keep their sequence after a restart with an empty spool
+	if err := s.push(batch(spoolFinished, "d")); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if s.files[0].seq != 2 {
+		t.Fatalf("expected sequence 2, got %d", s.files[0].seq)
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	initTestLogger()
+
+	s, err := newSpool(t.TempDir(), 200)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+
+	if err := s.push(batch(spoolDiscovered, "http://example.com/")); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	if err := s.push(batch(spoolDiscovered, "http://example.com/a", "http://example.com/b")); !errors.Is(err, errSpoolFull) {
+		t.Fatalf("expected errSpoolFull, got %v", err)
+	}
+}
+
+type fakeBatches struct {
+	mu    sync.Mutex
+	fail  bool
+	calls []string
+}
+
+func (f *fakeBatches) record(kind string, URLs []gocrawlhq.URL) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fail {
+		return errors.New("connection refused")
+	}
+
+	for _, URL := range URLs {
+		f.calls = append(f.calls, kind+":"+URL.Value)
+	}
+	return nil
+}
+
+func (f *fakeBatches) Add(_ context.Context, URLs []gocrawlhq.URL, _ bool) error {
+	return f.record(spoolDiscovered, URLs)
+}
+
+func (f *fakeBatches) Delete(_ context.Context, URLs []gocrawlhq.URL, _ int) error {
+	return f.record(spoolFinished, URLs)
+}
+
+func (f *fakeBatches) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func TestSendSpoolsWhileUnreachable(t *testing.T) {
+	initTestLogger()
+
+	s, err := newSpool(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+
+	fake := &fakeBatches{fail: true}
+	h := &hq{batches: fake, spool: s}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	defer h.cancel()
+
+	ctx := context.Background()
+	if err := h.send(ctx, batch(spoolFinished, "a")); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if err := h.send(ctx, batch(spoolDiscovered, "b")); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	if h.health.unreachableFor() == 0 {
+		t.Fatalf("expected HQ to be unreachable")
+	}
+
+	// HQ is back, but the new batch waits for the spooled ones to keep the order
+	fake.setFail(false)
+	if err := h.send(ctx, batch(spoolFinished, "c")); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if s.len() != 3 {
+		t.Fatalf("expected 3 spooled batches, got %d", s.len())
+	}
+
+	h.wg.Add(1)
+	go h.drainer()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.cancel()
+	h.wg.Wait()
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	want := []string{"finished:a", "discovered:b", "finished:c"}
+	if len(fake.calls) != len(want) {
+		t.Fatalf("expected %v to be sent, got %v", want, fake.calls)
+	}
+	for i := range want {
+		if fake.calls[i] != want[i] {
+			t.Fatalf("expected %v to be sent, got %v", want, fake.calls)
+		}
+	}
+
+	if h.health.unreachableFor() != 0 {
+		t.Fatalf("expected HQ to be reachable")
+	}
+}
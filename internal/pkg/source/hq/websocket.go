@@ -12,14 +12,15 @@ import (
 // websocket connects to HQ's websocket and listen for messages.
 // It also sends and "identify" message to the HQ to let it know that
 // Zeno is connected. This "identify" message is sent every second and
-// contains the crawler's stats and details.
+// contains the crawler's stats and details. When the connection is lost,
+// reconnecting is attempted with an exponential backoff, up to a minute.
 func websocket() {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "hq.websocket",
 	})
 
-	identifyTicker := time.NewTicker(time.Second)
-	defer identifyTicker.Stop()
+	var backoff time.Duration
+	wait := time.Duration(0)
 
 	for {
 		select {
@@ -27,30 +28,35 @@ func websocket() {
 			logger.Debug("received done signal")
 			globalHQ.wg.Done()
 			return
-		default:
-			sendIdentify(logger)
-			<-identifyTicker.C
+		case <-time.After(wait):
 		}
+
+		if err := sendIdentify(); err != nil {
+			if backoff == 0 {
+				logger.Warn("error sending identify payload to Crawl HQ, reconnecting", "err", err.Error())
+			}
+
+			if err := globalHQ.client.InitWebsocketConn(); err != nil {
+				backoff = nextBackoff(backoff)
+				wait = backoff
+				logger.Error("error initializing websocket connection to Crawl HQ", "err", err.Error(), "retry_in", backoff.String())
+				continue
+			}
+
+			logger.Warn("reconnected to Crawl HQ websocket")
+		}
+
+		backoff = 0
+		wait = time.Second
 	}
 }
 
-func sendIdentify(logger *log.FieldedLogger) {
-	err := globalHQ.client.Identify(&gocrawlhq.IdentifyMessage{
+func sendIdentify() error {
+	return globalHQ.client.Identify(&gocrawlhq.IdentifyMessage{
 		Project:   config.Get().HQProject,
 		Job:       config.Get().Job,
 		IP:        utils.GetOutboundIP().String(),
 		Hostname:  utils.GetHostname(),
 		GoVersion: utils.GetVersion().GoVersion,
 	})
-	if err != nil {
-		logger.Error("error sending identify payload to Crawl HQ, trying to reconnect", "err", err.Error())
-		reconnectWebsocket(logger)
-	}
-}
-
-func reconnectWebsocket(logger *log.FieldedLogger) {
-	err := globalHQ.client.InitWebsocketConn()
-	if err != nil {
-		logger.Error("error initializing websocket connection to Crawl HQ", "err", err.Error())
-	}
 }
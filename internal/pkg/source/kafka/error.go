@@ -0,0 +1,8 @@
+package kafka
+
+import "errors"
+
+var (
+	// ErrKafkaAlreadyInitialized is the error returned when the kafka source is already initialized
+	ErrKafkaAlreadyInitialized = errors.New("kafka client already initialized")
+)
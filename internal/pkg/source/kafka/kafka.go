@@ -0,0 +1,187 @@
+// Package kafka is the URL source of the crawls fed by Kafka: seeds are consumed from a topic with a
+// consumer group, the outlinks discovered are produced to another topic and the capture results to a third.
+// The offsets of the seeds are only committed once they are captured or failed for good.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+type kafka struct {
+	client  *kgo.Client
+	offsets *offsetTracker
+
+	outlinksTopic string
+	resultsTopic  string
+	maxInFlight   int
+}
+
+var (
+	globalKafka *kafka
+	once        sync.Once
+	logger      *log.FieldedLogger
+)
+
+// Start connects to the Kafka brokers and joins the consumer group, the URLs are then exchanged through Source.
+func Start() error {
+	var done bool
+	var startErr error
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "kafka",
+	})
+
+	stats.Init()
+
+	once.Do(func() {
+		done = true
+
+		k := &kafka{
+			offsets:       newOffsetTracker(),
+			outlinksTopic: config.Get().KafkaOutlinksTopic,
+			resultsTopic:  config.Get().KafkaResultsTopic,
+			maxInFlight:   config.Get().KafkaMaxInFlight,
+		}
+
+		opts, err := clientOptions(k)
+		if err != nil {
+			logger.Error("invalid kafka configuration", "err", err.Error(), "func", "kafka.Start")
+			startErr = err
+			return
+		}
+
+		k.client, err = kgo.NewClient(opts...)
+		if err != nil {
+			logger.Error("error initializing kafka client", "err", err.Error(), "func", "kafka.Start")
+			startErr = err
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := k.client.Ping(pingCtx); err != nil {
+			logger.Error("unable to reach kafka brokers", "err", err.Error(), "func", "kafka.Start")
+			k.client.Close()
+			startErr = err
+			return
+		}
+
+		globalKafka = k
+
+		logger.Info("started", "seeds_topic", config.Get().KafkaSeedsTopic, "consumer_group", config.Get().KafkaConsumerGroup)
+	})
+
+	if !done {
+		return ErrKafkaAlreadyInitialized
+	}
+
+	return startErr
+}
+
+// Stop commits the offsets of the seeds done and leaves the consumer group. The source must be stopped first,
+// the seeds still being crawled are consumed again by the next run.
+func Stop() {
+	if globalKafka != nil {
+		globalKafka.commit(context.Background())
+		globalKafka.client.Close()
+		globalKafka = nil
+		once = sync.Once{}
+		logger.Info("stopped")
+	}
+}
+
+func clientOptions(k *kafka) ([]kgo.Opt, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(config.Get().KafkaBrokers...),
+		kgo.ConsumerGroup(config.Get().KafkaConsumerGroup),
+		kgo.ConsumeTopics(config.Get().KafkaSeedsTopic),
+		kgo.DisableAutoCommit(),
+		kgo.OnPartitionsRevoked(func(ctx context.Context, _ *kgo.Client, revoked map[string][]int32) {
+			// Commit what's done before another consumer gets the partitions
+			k.commit(ctx)
+			k.offsets.revoke(revoked)
+		}),
+		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+			k.offsets.revoke(lost)
+		}),
+	}
+
+	if config.Get().KafkaTLS {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if config.Get().KafkaTLSCA != "" {
+			ca, err := os.ReadFile(config.Get().KafkaTLSCA)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read --kafka-tls-ca: %w", err)
+			}
+
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificate found in --kafka-tls-ca %s", config.Get().KafkaTLSCA)
+			}
+		}
+
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	if config.Get().KafkaSASLMechanism != "" {
+		mechanism, err := saslMechanism(config.Get().KafkaSASLMechanism, config.Get().KafkaSASLUsername, config.Get().KafkaSASLPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	return opts, nil
+}
+
+func saslMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch name {
+	case "plain":
+		return plain.Auth{User: username, Pass: password}.AsMechanism(), nil
+	case "scram-sha-256":
+		return scram.Auth{User: username, Pass: password}.AsSha256Mechanism(), nil
+	case "scram-sha-512":
+		return scram.Auth{User: username, Pass: password}.AsSha512Mechanism(), nil
+	default:
+		return nil, fmt.Errorf("invalid --kafka-sasl-mechanism %q, must be plain, scram-sha-256 or scram-sha-512", name)
+	}
+}
+
+// commit commits the offsets of the seeds done since the last commit
+func (k *kafka) commit(ctx context.Context) {
+	offsets := k.offsets.committable()
+	if len(offsets) == 0 {
+		return
+	}
+
+	var commitErr error
+	k.client.CommitOffsetsSync(ctx, offsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, _ *kmsg.OffsetCommitResponse, err error) {
+		commitErr = err
+	})
+
+	if commitErr != nil {
+		logger.Error("unable to commit offsets", "err", commitErr.Error())
+		return
+	}
+
+	k.offsets.committed(offsets)
+}
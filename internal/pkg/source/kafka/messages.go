@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// resultMessage is the message produced for each URL captured, or that failed for good
+type resultMessage struct {
+	URL       string    `json:"url"`
+	Seed      string    `json:"seed"`
+	Status    int       `json:"status,omitempty"`
+	Failed    bool      `json:"failed,omitempty"`
+	Digest    string    `json:"digest,omitempty"`
+	Hops      int       `json:"hops"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// results returns the result messages of the URLs of a finished seed, the URLs
+// that were skipped (e.g. excluded or already seen) have none
+func results(seed *models.Item) (messages []resultMessage) {
+	now := time.Now().UTC()
+
+	seed.Traverse(func(item *models.Item) {
+		URL := item.GetURL()
+		if URL == nil {
+			return
+		}
+
+		failed := item.GetStatus() == models.ItemFailed
+		if URL.GetResponse() == nil && !failed {
+			return
+		}
+
+		message := resultMessage{
+			URL:       URL.Raw,
+			Seed:      seed.GetURL().Raw,
+			Failed:    failed,
+			Digest:    URL.GetDigest(),
+			Hops:      URL.GetHops(),
+			Timestamp: now,
+		}
+
+		if URL.GetParsed() != nil {
+			message.URL = URL.String()
+		}
+
+		if URL.GetResponse() != nil {
+			message.Status = URL.GetResponse().StatusCode
+		}
+
+		messages = append(messages, message)
+	})
+
+	return messages
+}
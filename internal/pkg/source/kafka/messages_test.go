@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+func newTestItem(t *testing.T, id, raw string) *models.Item {
+	t.Helper()
+
+	URL := &models.URL{Raw: raw}
+	if err := URL.Parse(); err != nil {
+		t.Fatalf("unable to parse %s: %v", raw, err)
+	}
+
+	return models.NewItem(id, URL, "")
+}
+
+func TestResults(t *testing.T) {
+	seed := newTestItem(t, "seed", "https://example.com/")
+	seed.GetURL().SetResponse(&http.Response{StatusCode: 200})
+	seed.GetURL().SetDigest("sha1:ABC")
+
+	captured := newTestItem(t, "captured", "https://example.com/style.css")
+	captured.GetURL().SetResponse(&http.Response{StatusCode: 404})
+
+	failed := newTestItem(t, "failed", "https://example.com/failed.js")
+
+	// Never captured, e.g. excluded
+	skipped := newTestItem(t, "skipped", "https://example.com/skipped.png")
+
+	for _, child := range []*models.Item{captured, failed, skipped} {
+		if err := seed.AddChild(child, models.ItemGotChildren); err != nil {
+			t.Fatalf("AddChild() error = %v", err)
+		}
+	}
+	failed.SetStatus(models.ItemFailed)
+
+	messages := results(seed)
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 results, got %d: %+v", len(messages), messages)
+	}
+
+	byURL := make(map[string]resultMessage)
+	for _, message := range messages {
+		if message.Seed != "https://example.com/" {
+			t.Errorf("expected the seed of %s to be https://example.com/, got %s", message.URL, message.Seed)
+		}
+		byURL[message.URL] = message
+	}
+
+	if got := byURL["https://example.com/"]; got.Status != 200 || got.Digest != "sha1:ABC" || got.Failed {
+		t.Errorf("unexpected seed result: %+v", got)
+	}
+	if got := byURL["https://example.com/style.css"]; got.Status != 404 || got.Failed {
+		t.Errorf("unexpected captured result: %+v", got)
+	}
+	if got := byURL["https://example.com/failed.js"]; got.Status != 0 || !got.Failed {
+		t.Errorf("unexpected failed result: %+v", got)
+	}
+	if _, found := byURL["https://example.com/skipped.png"]; found {
+		t.Errorf("expected no result for the skipped URL")
+	}
+}
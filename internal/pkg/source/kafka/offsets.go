@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// offsetTracker keeps the offsets of the seeds being crawled. Seeds finish in any order, the offset
+// committed for a partition is the one of its oldest seed still being crawled, so that a crash
+// never skips a seed that wasn't done: at worst some seeds are consumed again.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[string]map[int32]*partitionOffsets
+	items      map[string]position
+	inFlight   int
+}
+
+type position struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+type partitionOffsets struct {
+	// pending are the offsets consumed and not finished yet
+	pending map[int64]struct{}
+	// next is the offset after the last one consumed
+	next int64
+	// committed is the last offset committed, -1 if none was
+	committed int64
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[string]map[int32]*partitionOffsets),
+		items:      make(map[string]position),
+	}
+}
+
+func (t *offsetTracker) partition(topic string, partition int32) *partitionOffsets {
+	partitions, found := t.partitions[topic]
+	if !found {
+		partitions = make(map[int32]*partitionOffsets)
+		t.partitions[topic] = partitions
+	}
+
+	p, found := partitions[partition]
+	if !found {
+		p = &partitionOffsets{
+			pending:   make(map[int64]struct{}),
+			committed: -1,
+		}
+		partitions[partition] = p
+	}
+
+	return p
+}
+
+// consumed records the offset of a consumed record, the seed created from it is finished with
+// finished(id). An empty id means that the record is done already, e.g. it couldn't be decoded.
+func (t *offsetTracker) consumed(id string, record *kgo.Record) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partition(record.Topic, record.Partition)
+	p.next = max(p.next, record.Offset+1)
+
+	if id == "" {
+		return
+	}
+
+	p.pending[record.Offset] = struct{}{}
+	t.items[id] = position{topic: record.Topic, partition: record.Partition, offset: record.Offset}
+	t.inFlight++
+}
+
+// finished records that the seed is done, captured or failed for good
+func (t *offsetTracker) finished(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pos, found := t.items[id]
+	if !found {
+		return
+	}
+	delete(t.items, id)
+	t.inFlight--
+
+	if p, found := t.partitions[pos.topic][pos.partition]; found {
+		delete(p.pending, pos.offset)
+	}
+}
+
+// committable returns the offsets to commit, the ones that moved since the last commit
+func (t *offsetTracker) committable() map[string]map[int32]kgo.EpochOffset {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for topic, partitions := range t.partitions {
+		for partition, p := range partitions {
+			offset := p.next
+			for pending := range p.pending {
+				offset = min(offset, pending)
+			}
+
+			if offset <= p.committed {
+				continue
+			}
+
+			if offsets[topic] == nil {
+				offsets[topic] = make(map[int32]kgo.EpochOffset)
+			}
+			offsets[topic][partition] = kgo.EpochOffset{Epoch: -1, Offset: offset}
+		}
+	}
+
+	return offsets
+}
+
+// committed records the offsets committed
+func (t *offsetTracker) committed(offsets map[string]map[int32]kgo.EpochOffset) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			if p, found := t.partitions[topic][partition]; found {
+				p.committed = max(p.committed, offset.Offset)
+			}
+		}
+	}
+}
+
+// revoke forgets the partitions assigned to another consumer, their seeds still being crawled
+// will be consumed again by it
+func (t *offsetTracker) revoke(revoked map[string][]int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for topic, partitions := range revoked {
+		for _, partition := range partitions {
+			delete(t.partitions[topic], partition)
+		}
+	}
+
+	for id, pos := range t.items {
+		if _, found := t.partitions[pos.topic][pos.partition]; !found {
+			delete(t.items, id)
+			t.inFlight--
+		}
+	}
+}
+
+// pending returns the number of seeds being crawled
+func (t *offsetTracker) pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.inFlight
+}
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func record(partition int32, offset int64) *kgo.Record {
+	return &kgo.Record{Topic: "seeds", Partition: partition, Offset: offset}
+}
+
+func committedOffset(t *testing.T, offsets map[string]map[int32]kgo.EpochOffset, partition int32) (int64, bool) {
+	t.Helper()
+
+	offset, found := offsets["seeds"][partition]
+	return offset.Offset, found
+}
+
+func TestOffsetTrackerOutOfOrder(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	tracker.consumed("a", record(0, 10))
+	tracker.consumed("b", record(0, 11))
+	tracker.consumed("c", record(0, 12))
+
+	if got := tracker.pending(); got != 3 {
+		t.Fatalf("expected 3 pending seeds, got %d", got)
+	}
+
+	// The oldest seed is still being crawled, nothing after it can be committed
+	tracker.finished("b")
+	tracker.finished("c")
+
+	offsets := tracker.committable()
+	if offset, _ := committedOffset(t, offsets, 0); offset != 10 {
+		t.Fatalf("expected offset 10 to be committable, got %d", offset)
+	}
+	tracker.committed(offsets)
+
+	// Nothing moved since the last commit
+	if offsets := tracker.committable(); len(offsets) != 0 {
+		t.Fatalf("expected nothing to commit, got %v", offsets)
+	}
+
+	tracker.finished("a")
+
+	offsets = tracker.committable()
+	if offset, _ := committedOffset(t, offsets, 0); offset != 13 {
+		t.Fatalf("expected offset 13 to be committable, got %d", offset)
+	}
+
+	if got := tracker.pending(); got != 0 {
+		t.Fatalf("expected no pending seeds, got %d", got)
+	}
+}
+
+func TestOffsetTrackerSkippedRecord(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	// A record that couldn't be decoded is done right away
+	tracker.consumed("", record(0, 5))
+
+	if got := tracker.pending(); got != 0 {
+		t.Fatalf("expected no pending seeds, got %d", got)
+	}
+
+	if offset, _ := committedOffset(t, tracker.committable(), 0); offset != 6 {
+		t.Fatalf("expected offset 6 to be committable, got %d", offset)
+	}
+}
+
+func TestOffsetTrackerRevoke(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	tracker.consumed("a", record(0, 1))
+	tracker.consumed("b", record(1, 1))
+
+	tracker.revoke(map[string][]int32{"seeds": {1}})
+
+	if got := tracker.pending(); got != 1 {
+		t.Fatalf("expected 1 pending seed, got %d", got)
+	}
+
+	// Finishing a seed of a revoked partition is a no-op
+	tracker.finished("b")
+	tracker.finished("a")
+
+	offsets := tracker.committable()
+	if _, found := committedOffset(t, offsets, 1); found {
+		t.Fatalf("expected no offset for the revoked partition, got %v", offsets)
+	}
+	if offset, _ := committedOffset(t, offsets, 0); offset != 2 {
+		t.Fatalf("expected offset 2 to be committable, got %d", offset)
+	}
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
+	"github.com/internetarchive/Zeno/pkg/models"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// kafkaSource exchanges the URLs of the crawl with Kafka
+type kafkaSource struct{}
+
+// Source returns the URL source backed by Kafka, Kafka must be started
+func Source() source.URLSource {
+	return kafkaSource{}
+}
+
+// Batching returns the batch sizes used with Kafka
+func (kafkaSource) Batching() source.Batching {
+	return source.Batching{
+		Pull:       config.Get().KafkaBatchSize,
+		Discovered: config.Get().KafkaProduceBatchSize,
+	}
+}
+
+// Pull consumes seeds. Nothing is consumed while --kafka-max-in-flight seeds are being crawled,
+// the records not consumed yet stay in Kafka.
+func (kafkaSource) Pull(ctx context.Context, n int) ([]*models.Item, error) {
+	k := globalKafka
+
+	if k.maxInFlight > 0 {
+		n = min(n, k.maxInFlight-k.offsets.pending())
+		if n <= 0 {
+			return nil, nil
+		}
+	}
+
+	fetches := k.client.PollRecords(ctx, n)
+	if fetches.IsClientClosed() {
+		return nil, context.Canceled
+	}
+
+	var fetchErr error
+	fetches.EachError(func(topic string, partition int32, err error) {
+		if errors.Is(err, context.Canceled) {
+			fetchErr = err
+			return
+		}
+		logger.Error("error consuming seeds", "err", err.Error(), "topic", topic, "partition", partition)
+		fetchErr = err
+	})
+
+	var items []*models.Item
+	fetches.EachRecord(func(record *kgo.Record) {
+		message, err := source.DecodeURLMessage(record.Value)
+		if err != nil {
+			logger.Warn("unable to decode seed message, skipping it", "err", err.Error(), "partition", record.Partition, "offset", record.Offset)
+			k.offsets.consumed("", record)
+			return
+		}
+
+		newItem := message.Item()
+		k.offsets.consumed(newItem.GetID(), record)
+		items = append(items, newItem)
+	})
+
+	if len(items) == 0 {
+		return nil, fetchErr
+	}
+
+	return items, nil
+}
+
+// Finished produces the results of the seeds, then commits their offsets
+func (kafkaSource) Finished(ctx context.Context, items []*models.Item) error {
+	k := globalKafka
+
+	if k.resultsTopic != "" {
+		var records []*kgo.Record
+		for _, item := range items {
+			for _, result := range results(item) {
+				value, err := json.Marshal(result)
+				if err != nil {
+					return err
+				}
+
+				records = append(records, &kgo.Record{
+					Topic: k.resultsTopic,
+					Key:   []byte(item.GetURL().Raw),
+					Value: value,
+				})
+			}
+		}
+
+		if err := k.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range items {
+		k.offsets.finished(item.GetID())
+	}
+
+	k.commit(ctx)
+
+	return nil
+}
+
+// Discovered produces the outlinks, keyed by host so that the URLs of a host end up in the same partition
+func (kafkaSource) Discovered(ctx context.Context, items []*models.Item) error {
+	k := globalKafka
+
+	records := make([]*kgo.Record, 0, len(items))
+	for _, item := range items {
+		value, err := source.EncodeURLMessage(item)
+		if err != nil {
+			return err
+		}
+
+		var key []byte
+		if item.GetURL().GetParsed() != nil {
+			key = []byte(item.GetURL().GetParsed().Hostname())
+		}
+
+		records = append(records, &kgo.Record{
+			Topic: k.outlinksTopic,
+			Key:   key,
+			Value: value,
+		})
+	}
+
+	return k.client.ProduceSync(ctx, records...).FirstErr()
+}
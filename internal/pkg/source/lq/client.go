@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	_ "embed"
 	"path"
+	"sync"
+	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -12,11 +14,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/internetarchive/Zeno/internal/pkg/config"
 	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
 )
 
 type LQClient struct {
 	dbWrite     *sql.DB
 	dbWriteSqlc *sqlc_model.Queries
+	rotation    *hostRotation
+	recent      *recentlyQueued
+
+	// Serializes the reads and writes of the queue with the updates of the rotation
+	queueMu sync.Mutex
 }
 
 //go:embed schema.sql
@@ -34,19 +42,103 @@ func Init(job string) (*LQClient, error) {
 		return nil, err
 	}
 
+	if err := migrateHostColumn(dbWrite); err != nil {
+		logger.Error("error migrating lq database schema", "err", err.Error(), "func", "lq.Init")
+		return nil, err
+	}
+
+	if err := migrateMaxHopsColumn(dbWrite); err != nil {
+		logger.Error("error migrating lq database schema", "err", err.Error(), "func", "lq.Init")
+		return nil, err
+	}
+
 	dbWriteSqlc := sqlc_model.New(dbWrite)
 
+	// Nothing is in flight yet: the URLs claimed when the previous run crashed are queued again
+	reset, err := dbWriteSqlc.ResetClaimedURLs(context.Background())
+	if err != nil {
+		logger.Error("error resetting the claimed URLs of the lq database", "err", err.Error(), "func", "lq.Init")
+		return nil, err
+	}
+	if reset > 0 {
+		logger.Info("queued again the URLs claimed by the previous run", "count", reset)
+	}
+
+	// The pending URLs are counted once, the counters are maintained from there
+	counts, err := dbWriteSqlc.CountFreshURLsByHost(context.Background(), -1)
+	if err != nil {
+		logger.Error("error counting the URLs of the lq database", "err", err.Error(), "func", "lq.Init")
+		return nil, err
+	}
+
+	hosts := make([]FrontierHost, 0, len(counts))
+	for _, count := range counts {
+		hosts = append(hosts, FrontierHost{Host: count.Host, Pending: count.Count})
+	}
+
 	return &LQClient{
 		dbWrite:     dbWrite,
 		dbWriteSqlc: dbWriteSqlc,
+		rotation:    newHostRotation(hosts, config.Get().FrontierHostMemory, config.Get().FrontierMaxMemory),
+		recent:      newRecentlyQueued(),
 	}, nil
 }
 
+// migrateHostColumn adds the host column to the databases created before it existed,
+// and the index used to queue the URLs host by host, by hops
+func migrateHostColumn(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('urls') WHERE name = 'host'").Scan(&found); err != nil {
+		return err
+	}
+
+	if found == 0 {
+		if _, err := db.Exec(`ALTER TABLE urls ADD COLUMN host TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+
+		_, err := db.Exec(`UPDATE urls SET host = lower(substr(substr(value, instr(value, '://') + 3), 1, instr(substr(value, instr(value, '://') + 3) || '/', '/') - 1))`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The URLs of a host are served by hops, the index replaces the former (status, host) one
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS urls_status_host_hops ON urls (status, host, hops)`); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`DROP INDEX IF EXISTS urls_status_host`)
+
+	return err
+}
+
+// migrateMaxHopsColumn adds the max_hops column to the databases created before it existed,
+// their URLs get the global max hops
+func migrateMaxHopsColumn(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('urls') WHERE name = 'max_hops'").Scan(&found); err != nil {
+		return err
+	}
+
+	if found > 0 {
+		return nil
+	}
+
+	_, err := db.Exec(`ALTER TABLE urls ADD COLUMN max_hops INTEGER NOT NULL DEFAULT -1`)
+
+	return err
+}
+
 func (c *LQClient) ResetURL(ctx context.Context, seed string) error {
 	return c.dbWriteSqlc.ResetURL(ctx, seed)
 }
 
+// Get claims up to limit fresh URLs following --frontier-strategy. The hosts that aren't ready are skipped.
 func (c *LQClient) Get(ctx context.Context, limit int) ([]sqlc_model.Url, error) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
 	tx, err := globalLQ.client.dbWrite.Begin()
 	if err != nil {
 		return nil, err
@@ -55,29 +147,168 @@ func (c *LQClient) Get(ctx context.Context, limit int) ([]sqlc_model.Url, error)
 
 	qtx := globalLQ.client.dbWriteSqlc.WithTx(tx)
 
-	freshUrls, err := qtx.GetFreshURLs(ctx, int64(limit))
+	var (
+		freshUrls  []sqlc_model.Url
+		exhausted  []string
+		lastServed string
+	)
+
+	switch config.Get().FrontierStrategy {
+	case "bfs":
+		freshUrls, err = c.getByHops(ctx, qtx, limit, false)
+	case "dfs":
+		freshUrls, err = c.getByHops(ctx, qtx, limit, true)
+	default:
+		freshUrls, exhausted, lastServed, err = c.getHostFair(ctx, qtx, limit)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	for _, record := range freshUrls {
-		if err = qtx.ClaimThisURL(ctx, record.ID); err != nil {
-			logger.Error("error claiming URL", "err", err.Error(), "func", "lq.getURLs", "id", record.ID)
-			return nil, err
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	c.rotation.claimed(freshUrls)
+	c.recent.claim(freshUrls)
+	stats.FrontierDequeuedAdd(uint64(len(freshUrls)))
+
+	if lastServed != "" {
+		c.rotation.served(lastServed)
+	}
+
+	// Claimed by hops, the hosts don't take turns: they leave the rotation with their last URL
+	if config.Get().FrontierStrategy == "bfs" || config.Get().FrontierStrategy == "dfs" {
+		for _, URL := range freshUrls {
+			if pending, _ := c.rotation.host(URL.Host); pending <= 0 {
+				exhausted = append(exhausted, URL.Host)
+			}
 		}
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, err
+	for _, host := range exhausted {
+		c.rotation.remove(host)
+	}
+
+	return freshUrls, nil
+}
+
+// getHostFair claims fresh URLs taking one URL per host in turn so that all the hosts of the queue
+// progress at the same pace, the URLs of a host are taken by hops. It returns the hosts found without
+// fresh URLs and the last host served.
+func (c *LQClient) getHostFair(ctx context.Context, qtx *sqlc_model.Queries, limit int) (freshUrls []sqlc_model.Url, exhausted []string, lastServed string, err error) {
+	hosts := c.rotation.turn()
+
+	for len(freshUrls) < limit && len(hosts) > 0 {
+		var remaining []string
+
+		for _, host := range hosts {
+			if len(freshUrls) >= limit {
+				break
+			}
+
+			if hostReady != nil && !hostReady(host) {
+				continue
+			}
+
+			URL, found := c.rotation.pop(host)
+			if !found {
+				// Read the next URLs of the host, the ones that fit are kept in memory for its next turns
+				records, err := qtx.GetFreshURLsOfHost(ctx, sqlc_model.GetFreshURLsOfHostParams{
+					Host:  host,
+					Limit: int64(1 + c.rotation.room()),
+				})
+				if err != nil {
+					return nil, nil, "", err
+				}
+
+				if len(records) == 0 {
+					exhausted = append(exhausted, host)
+					continue
+				}
+
+				URL = records[0]
+				c.rotation.keep(host, records[1:])
+			}
+
+			if err = qtx.ClaimThisURL(ctx, URL.ID); err != nil {
+				logger.Error("error claiming URL", "err", err.Error(), "func", "lq.getHostFair", "id", URL.ID)
+				return nil, nil, "", err
+			}
+
+			freshUrls = append(freshUrls, URL)
+			lastServed = host
+			remaining = append(remaining, host)
+		}
+
+		hosts = remaining
+	}
+
+	return freshUrls, exhausted, lastServed, nil
+}
+
+// maxHopsScan is the number of URLs read for each URL claimed by hops, the URLs of the hosts that
+// aren't ready are skipped up to it
+const maxHopsScan = 10
+
+// getByHops claims fresh URLs in the order of their hops, the lowest first (breadth-first) or the
+// highest first (depth-first), then in the order they were queued, or the reverse for depth-first
+func (c *LQClient) getByHops(ctx context.Context, qtx *sqlc_model.Queries, limit int, desc bool) (freshUrls []sqlc_model.Url, err error) {
+	var offset int64
+
+	for len(freshUrls) < limit && offset < int64(limit*maxHopsScan) {
+		var records []sqlc_model.Url
+
+		params := struct{ Limit, Offset int64 }{Limit: int64(limit), Offset: offset}
+		if desc {
+			records, err = qtx.GetFreshURLsByHopsDesc(ctx, sqlc_model.GetFreshURLsByHopsDescParams(params))
+		} else {
+			records, err = qtx.GetFreshURLsByHops(ctx, sqlc_model.GetFreshURLsByHopsParams(params))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, URL := range records {
+			if len(freshUrls) >= limit {
+				break
+			}
+
+			// Not claimed, it stays in place for the next reads
+			if hostReady != nil && !hostReady(URL.Host) {
+				offset++
+				continue
+			}
+
+			if err = qtx.ClaimThisURL(ctx, URL.ID); err != nil {
+				logger.Error("error claiming URL", "err", err.Error(), "func", "lq.getByHops", "id", URL.ID)
+				return nil, err
+			}
+
+			freshUrls = append(freshUrls, URL)
+		}
+
+		if len(records) < limit {
+			break
+		}
 	}
 
 	return freshUrls, nil
 }
 
 func (c *LQClient) Add(ctx context.Context, urls []sqlc_model.Url, bypassSeencheck bool) error {
+	_, err := c.add(ctx, urls)
+	return err
+}
+
+// add queues the URLs and returns the ones added, the URLs already in the queue are skipped
+func (c *LQClient) add(ctx context.Context, urls []sqlc_model.Url) (added []sqlc_model.Url, err error) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
 	tx, err := globalLQ.client.dbWrite.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
@@ -87,11 +318,14 @@ func (c *LQClient) Add(ctx context.Context, urls []sqlc_model.Url, bypassSeenche
 		if url.ID == "" {
 			url.ID = uuid.New().String()
 		}
+		url.Host = hostOf(url.Value)
 		err = qtx.AddURL(ctx, sqlc_model.AddURLParams{
-			ID:    url.ID,
-			Value: url.Value,
-			Via:   url.Via,
-			Hops:  int64(url.Hops),
+			ID:      url.ID,
+			Value:   url.Value,
+			Via:     url.Via,
+			Hops:    int64(url.Hops),
+			Host:    url.Host,
+			MaxHops: url.MaxHops,
 		})
 		if err != nil {
 			if err.Error() == "sqlite3: constraint failed: UNIQUE constraint failed: urls.value" {
@@ -99,15 +333,24 @@ func (c *LQClient) Add(ctx context.Context, urls []sqlc_model.Url, bypassSeenche
 				continue
 			}
 			logger.Error("error adding URL", "err", err.Error(), "func", "lq.Add", "value", url.Value, "via", url.Via)
-			return err
+			return nil, err
 		}
+
+		url.Status = "FRESH"
+		url.Timestamp = time.Now().Unix()
+		added = append(added, url)
 	}
 
 	if err = tx.Commit(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	for _, url := range added {
+		c.rotation.add(url.Host, 1)
+		c.recent.add(url)
+	}
+
+	return added, nil
 }
 
 func (c *LQClient) Delete(ctx context.Context, urls []sqlc_model.Url, bypassSeencheck bool) error {
@@ -0,0 +1,143 @@
+package lq
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+)
+
+// dumpBatchSize is the number of URLs read from or written to the database at once by Dump and Load
+const dumpBatchSize = 1000
+
+// Dump writes the pending URLs of the local queue of a job, claimed ones included, as JSON lines.
+// It reads the database of the job's directory and doesn't need the crawl to be running:
+// the crawl should be stopped for the dump to be consistent.
+func Dump(ctx context.Context, jobPath string, w io.Writer) (count int, err error) {
+	dbPath := path.Join(jobPath, "lq.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return 0, fmt.Errorf("no local queue in %s: %w", jobPath, err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	queries := sqlc_model.New(db)
+	encoder := json.NewEncoder(w)
+
+	var after int64
+	for {
+		rows, err := queries.ListPendingURLsAfter(ctx, sqlc_model.ListPendingURLsAfterParams{
+			Rowid: after,
+			Limit: dumpBatchSize,
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, row := range rows {
+			item := newFrontierItem(sqlc_model.Url{
+				ID:        row.ID,
+				Value:     row.Value,
+				Via:       row.Via,
+				Hops:      row.Hops,
+				Timestamp: row.Timestamp,
+				MaxHops:   row.MaxHops,
+			})
+
+			if err := encoder.Encode(item); err != nil {
+				return count, err
+			}
+
+			count++
+			after = row.Rowid
+		}
+
+		if len(rows) < dumpBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// load adds the URLs of a dump written by Dump to the local queue, keeping their ID, hops, max hops and via.
+// The URLs already in the queue are skipped.
+func load(ctx context.Context, client *LQClient, r io.Reader) (count int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	batch := make([]sqlc_model.Url, 0, dumpBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := client.Add(ctx, batch, false); err != nil {
+			return err
+		}
+
+		count += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var item FrontierItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return count, fmt.Errorf("invalid frontier dump line %d: %w", line, err)
+		}
+
+		if item.URL == "" {
+			return count, fmt.Errorf("invalid frontier dump line %d: no URL", line)
+		}
+
+		URL := sqlc_model.Url{
+			ID:      item.ID,
+			Value:   item.URL,
+			Via:     item.Via,
+			Hops:    item.Hops,
+			MaxHops: -1,
+		}
+		if item.MaxHops != nil {
+			URL.MaxHops = *item.MaxHops
+		}
+
+		batch = append(batch, URL)
+
+		if len(batch) == dumpBatchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+
+	return count, flush()
+}
+
+// loadFile adds the URLs of a dump file to the local queue
+func loadFile(ctx context.Context, client *LQClient, dumpPath string) (count int, err error) {
+	file, err := os.Open(dumpPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return load(ctx, client, file)
+}
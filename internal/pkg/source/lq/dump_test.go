@@ -0,0 +1,97 @@
+package lq
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+)
+
+func TestDumpLoad(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClient(t)
+	jobPath := config.Get().JobPath
+
+	URLs := []sqlc_model.Url{
+		{ID: "seed", Value: "http://example.com/", MaxHops: -1},
+		{ID: "outlink", Value: "http://example.com/page", Via: "seed", Hops: 1, MaxHops: 3},
+		{ID: "other", Value: "http://example.org/page", Via: "seed", Hops: 2, MaxHops: -1},
+	}
+	if err := client.Add(ctx, URLs, false); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Claimed URLs aren't done yet, they are dumped too
+	if _, err := client.Get(ctx, 1); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	var dump bytes.Buffer
+	count, err := Dump(ctx, jobPath, &dump)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	if count != len(URLs) {
+		t.Fatalf("expected %d URLs dumped, got %d:\n%s", len(URLs), count, dump.String())
+	}
+
+	// Load the dump in the queue of another job
+	client = newTestClient(t)
+
+	loaded, err := load(ctx, client, strings.NewReader(dump.String()))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	if loaded != len(URLs) {
+		t.Fatalf("expected %d URLs loaded, got %d", len(URLs), loaded)
+	}
+
+	items, err := FrontierPending(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("FrontierPending() error = %v", err)
+	}
+
+	got := make(map[string]FrontierItem)
+	for _, item := range items {
+		got[item.URL] = item
+	}
+
+	for _, URL := range URLs {
+		item, found := got[URL.Value]
+		if !found {
+			t.Errorf("%s not loaded", URL.Value)
+			continue
+		}
+
+		if item.ID != URL.ID || item.Via != URL.Via || item.Hops != URL.Hops {
+			t.Errorf("%s loaded as %+v, want id %q, via %q and hops %d", URL.Value, item, URL.ID, URL.Via, URL.Hops)
+		}
+
+		if (item.MaxHops == nil) != (URL.MaxHops < 0) || (item.MaxHops != nil && *item.MaxHops != URL.MaxHops) {
+			t.Errorf("%s loaded with max hops %v, want %d", URL.Value, item.MaxHops, URL.MaxHops)
+		}
+	}
+
+	// Loading the dump again doesn't duplicate the URLs
+	if _, err := load(ctx, client, strings.NewReader(dump.String())); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	if queued, _ := FrontierQueued(); queued != int64(len(URLs)) {
+		t.Errorf("expected %d URLs queued after loading twice, got %d", len(URLs), queued)
+	}
+
+	if _, err := load(ctx, client, strings.NewReader("not json\n")); err == nil {
+		t.Error("expected an error loading an invalid dump")
+	}
+
+	if _, err := Dump(ctx, t.TempDir(), &dump); err == nil {
+		t.Error("expected an error dumping a directory without local queue")
+	}
+}
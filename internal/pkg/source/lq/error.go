@@ -5,4 +5,6 @@ import "errors"
 var (
 	//  is the error returned when the postprocessor is already initialized
 	ErrLQAlreadyInitialized = errors.New("lq client already initialized")
+	// ErrLQNotStarted is the error returned when the local queue is queried before being started
+	ErrLQNotStarted = errors.New("lq not started")
 )
@@ -0,0 +1,190 @@
+package lq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// FrontierItem is a pending URL of the local queue
+type FrontierItem struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Via       string `json:"via,omitempty"`
+	Hops      int64  `json:"hops"`
+	MaxHops   *int64 `json:"max_hops,omitempty"` // Max hops of the seed, nil if the global one applies
+	Type      string `json:"type"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// FrontierHost is the number of pending URLs of a host
+type FrontierHost struct {
+	Host    string `json:"host"`
+	Pending int64  `json:"pending"`
+}
+
+// FrontierCounts returns the number of URLs in the local queue for each status (FRESH, CLAIMED, DONE)
+func FrontierCounts(ctx context.Context) (map[string]int64, error) {
+	if globalLQ == nil {
+		return nil, ErrLQNotStarted
+	}
+
+	rows, err := globalLQ.client.dbWriteSqlc.CountURLsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{
+		"FRESH":   0,
+		"CLAIMED": 0,
+		"DONE":    0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// FrontierPending returns a page of the pending URLs of the local queue, in insertion order.
+// The queue isn't modified: the URLs aren't claimed.
+func FrontierPending(ctx context.Context, limit, offset int) ([]FrontierItem, error) {
+	if globalLQ == nil {
+		return nil, ErrLQNotStarted
+	}
+
+	URLs, err := globalLQ.client.dbWriteSqlc.ListFreshURLs(ctx, sqlc_model.ListFreshURLsParams{
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]FrontierItem, 0, len(URLs))
+	for _, URL := range URLs {
+		items = append(items, newFrontierItem(URL))
+	}
+
+	return items, nil
+}
+
+func newFrontierItem(URL sqlc_model.Url) FrontierItem {
+	itemType := "seed"
+	if URL.Hops > 0 {
+		itemType = "outlink"
+	}
+
+	item := FrontierItem{
+		ID:        URL.ID,
+		URL:       URL.Value,
+		Via:       URL.Via,
+		Hops:      URL.Hops,
+		Type:      itemType,
+		Timestamp: URL.Timestamp,
+	}
+
+	if URL.MaxHops >= 0 {
+		item.MaxHops = &URL.MaxHops
+	}
+
+	return item
+}
+
+// FrontierAdd queues the seeds in the local queue, it returns the URLs of the seeds added: the ones
+// already queued are skipped
+func FrontierAdd(ctx context.Context, seeds []*models.Item) (added []string, err error) {
+	if globalLQ == nil {
+		return nil, ErrLQNotStarted
+	}
+
+	URLs := make([]sqlc_model.Url, 0, len(seeds))
+	for _, seed := range seeds {
+		URLs = append(URLs, queuedURL(seed))
+	}
+
+	addedURLs, err := globalLQ.client.add(ctx, URLs)
+	for _, URL := range addedURLs {
+		added = append(added, URL.Value)
+	}
+
+	return added, err
+}
+
+// FrontierHosts returns the hosts with the most pending URLs in the local queue,
+// read from the counters maintained by the queue
+func FrontierHosts(limit int) ([]FrontierHost, error) {
+	if globalLQ == nil {
+		return nil, ErrLQNotStarted
+	}
+
+	return globalLQ.client.rotation.largest(limit), nil
+}
+
+// FrontierHostQueues returns the number of hosts in the rotation of the local queue
+func FrontierHostQueues() (int, error) {
+	if globalLQ == nil {
+		return 0, ErrLQNotStarted
+	}
+
+	return globalLQ.client.rotation.len(), nil
+}
+
+// FrontierQueued returns the number of pending URLs of the local queue, read from its counters
+func FrontierQueued() (int64, error) {
+	if globalLQ == nil {
+		return 0, ErrLQNotStarted
+	}
+
+	return globalLQ.client.rotation.size(), nil
+}
+
+// FrontierOldestQueued returns the time the oldest pending URL of the local queue was queued at,
+// the zero time if the queue is empty
+func FrontierOldestQueued(ctx context.Context) (time.Time, error) {
+	if globalLQ == nil {
+		return time.Time{}, ErrLQNotStarted
+	}
+
+	timestamp, err := globalLQ.client.dbWriteSqlc.GetOldestFreshURLTimestamp(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(timestamp, 0), nil
+}
+
+// FrontierHostSample returns the number of pending URLs of a host and up to limit of them: the next
+// ones to be served if they are held in memory, then the last ones queued. The database isn't read.
+func FrontierHostSample(host string, limit int) (pending int64, items []FrontierItem, err error) {
+	if globalLQ == nil {
+		return 0, nil, ErrLQNotStarted
+	}
+
+	pending, next := globalLQ.client.rotation.host(host)
+	recent := globalLQ.client.recent.host(host, limit)
+
+	seen := make(map[string]struct{}, len(next))
+	items = make([]FrontierItem, 0, min(limit, len(next)+len(recent)))
+
+	for _, URL := range append(next, recent...) {
+		if len(items) >= limit {
+			break
+		}
+
+		if _, found := seen[URL.ID]; found {
+			continue
+		}
+		seen[URL.ID] = struct{}{}
+
+		items = append(items, newFrontierItem(URL))
+	}
+
+	return pending, items, nil
+}
@@ -0,0 +1,172 @@
+package lq
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+)
+
+func TestFrontier(t *testing.T) {
+	client := newTestClient(t)
+
+	ctx := context.Background()
+
+	err := client.Add(ctx, []sqlc_model.Url{
+		{Value: "https://a.example.com/"},
+		{Value: "https://a.example.com/1", Via: "https://a.example.com/", Hops: 1},
+		{Value: "https://a.example.com:8080/2?q=1", Via: "https://a.example.com/", Hops: 1},
+		{Value: "http://b.example.com"},
+		{Value: "http://c.example.com/c"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Claim the first URL of the queue
+	claimed, err := client.Get(ctx, 1)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Get() = %v, %v", claimed, err)
+	}
+
+	counts, err := FrontierCounts(ctx)
+	if err != nil {
+		t.Fatalf("FrontierCounts() error = %v", err)
+	}
+	if counts["FRESH"] != 4 || counts["CLAIMED"] != 1 || counts["DONE"] != 0 {
+		t.Errorf("FrontierCounts() = %v", counts)
+	}
+
+	items, err := FrontierPending(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("FrontierPending() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("FrontierPending() returned %d items, want 2", len(items))
+	}
+	if items[0].Hops != 1 || items[0].Type != "outlink" || items[0].Via != "https://a.example.com/" {
+		t.Errorf("unexpected pending item %+v", items[0])
+	}
+
+	// Reading the frontier must not claim anything
+	counts, err = FrontierCounts(ctx)
+	if err != nil {
+		t.Fatalf("FrontierCounts() error = %v", err)
+	}
+	if counts["FRESH"] != 4 {
+		t.Errorf("reading the frontier modified it: %v", counts)
+	}
+
+	hosts, err := FrontierHosts(10)
+	if err != nil {
+		t.Fatalf("FrontierHosts() error = %v", err)
+	}
+
+	pending := make(map[string]int64)
+	for _, host := range hosts {
+		pending[host.Host] = host.Pending
+	}
+
+	if len(hosts) == 0 || hosts[0].Pending < hosts[len(hosts)-1].Pending {
+		t.Errorf("hosts are not sorted by pending count: %+v", hosts)
+	}
+
+	for _, host := range []string{"a.example.com:8080", "b.example.com"} {
+		if pending[host] != 1 {
+			t.Errorf("expected 1 pending URL for %s, got %+v", host, hosts)
+		}
+	}
+
+	total := int64(0)
+	for _, count := range pending {
+		total += count
+	}
+	if total != 4 {
+		t.Errorf("expected 4 pending URLs across hosts, got %+v", hosts)
+	}
+}
+
+func TestFrontierCounters(t *testing.T) {
+	client := newTestClient(t)
+
+	ctx := context.Background()
+
+	if oldest, err := FrontierOldestQueued(ctx); err != nil || !oldest.IsZero() {
+		t.Errorf("FrontierOldestQueued() = %v, %v on an empty queue", oldest, err)
+	}
+
+	err := client.Add(ctx, []sqlc_model.Url{
+		{Value: "http://a.example.com/1"},
+		{Value: "http://a.example.com/2"},
+		{Value: "http://a.example.com/3"},
+		{Value: "http://b.example.com/1"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if oldest, err := FrontierOldestQueued(ctx); err != nil || time.Since(oldest) > time.Minute {
+		t.Errorf("FrontierOldestQueued() = %v, %v", oldest, err)
+	}
+
+	// Claims a.example.com/1 and b.example.com/1
+	if _, err := client.Get(ctx, 2); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if queued, err := FrontierQueued(); err != nil || queued != 2 {
+		t.Errorf("FrontierQueued() = %d, %v, want 2", queued, err)
+	}
+
+	hosts, err := FrontierHosts(10)
+	if err != nil {
+		t.Fatalf("FrontierHosts() error = %v", err)
+	}
+	if len(hosts) != 2 || hosts[0] != (FrontierHost{Host: "a.example.com", Pending: 2}) || hosts[1] != (FrontierHost{Host: "b.example.com", Pending: 0}) {
+		t.Errorf("FrontierHosts() = %+v", hosts)
+	}
+
+	pending, items, err := FrontierHostSample("a.example.com", 10)
+	if err != nil {
+		t.Fatalf("FrontierHostSample() error = %v", err)
+	}
+	if pending != 2 || len(items) != 2 {
+		t.Fatalf("FrontierHostSample() = %d, %+v, want the 2 pending URLs", pending, items)
+	}
+	for _, item := range items {
+		if item.URL == "http://a.example.com/1" {
+			t.Errorf("claimed URL %s found in the sample", item.URL)
+		}
+	}
+
+	if _, items, _ := FrontierHostSample("a.example.com", 1); len(items) != 1 {
+		t.Errorf("expected the sample to be limited to 1 URL, got %+v", items)
+	}
+}
+
+func TestRecentlyQueued(t *testing.T) {
+	r := newRecentlyQueued()
+
+	for i := 0; i < recentlyQueuedSize+10; i++ {
+		r.add(sqlc_model.Url{ID: strconv.Itoa(i), Host: "example.com"})
+	}
+
+	URLs := r.host("example.com", recentlyQueuedSize+10)
+	if len(URLs) != recentlyQueuedSize {
+		t.Fatalf("expected the ring to keep %d URLs, got %d", recentlyQueuedSize, len(URLs))
+	}
+	if URLs[0].ID != strconv.Itoa(recentlyQueuedSize+9) {
+		t.Errorf("expected the newest URL first, got %s", URLs[0].ID)
+	}
+
+	r.claim(URLs[:1])
+	if URLs := r.host("example.com", 1); URLs[0].ID != strconv.Itoa(recentlyQueuedSize+8) {
+		t.Errorf("expected the claimed URL to be skipped, got %s", URLs[0].ID)
+	}
+
+	if URLs := r.host("other.com", 10); len(URLs) != 0 {
+		t.Errorf("expected no URL for another host, got %+v", URLs)
+	}
+}
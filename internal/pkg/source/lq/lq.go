@@ -8,16 +8,12 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/reactor"
 	"github.com/internetarchive/Zeno/internal/pkg/stats"
-	"github.com/internetarchive/Zeno/pkg/models"
 )
 
 type lq struct {
-	wg        sync.WaitGroup
-	ctx       context.Context
-	cancel    context.CancelFunc
-	finishCh  chan *models.Item
-	produceCh chan *models.Item
-	client    *LQClient
+	ctx    context.Context
+	cancel context.CancelFunc
+	client *LQClient
 }
 
 var (
@@ -26,7 +22,8 @@ var (
 	logger   *log.FieldedLogger
 )
 
-func Start(finishChan, produceChan chan *models.Item) error {
+// Start opens the local queue of the job, the URLs are then exchanged through Source.
+func Start() error {
 	var done bool
 	var startErr error
 
@@ -49,18 +46,24 @@ func Start(finishChan, produceChan chan *models.Item) error {
 		}
 
 		globalLQ = &lq{
-			wg:        sync.WaitGroup{},
-			ctx:       ctx,
-			cancel:    cancel,
-			finishCh:  finishChan,
-			produceCh: produceChan,
-			client:    LQclient,
+			ctx:    ctx,
+			cancel: cancel,
+			client: LQclient,
 		}
 
-		globalLQ.wg.Add(3)
-		go consumer()
-		go producer()
-		go finisher()
+		if dumpPath := config.Get().LoadFrontier; dumpPath != "" {
+			loaded, err := loadFile(ctx, LQclient, dumpPath)
+			if err != nil {
+				logger.Error("error loading frontier dump", "err", err.Error(), "path", dumpPath, "func", "lq.Start")
+				cancel()
+				globalLQ = nil
+				done = true
+				startErr = err
+				return
+			}
+
+			logger.Info("loaded frontier dump", "path", dumpPath, "urls", loaded)
+		}
 
 		logger.Info("started")
 
@@ -74,10 +77,10 @@ func Start(finishChan, produceChan chan *models.Item) error {
 	return startErr
 }
 
+// Stop resets the seeds still in the reactor. The source must be stopped first and Reactor must be frozen before stopping LQ.
 func Stop() {
 	if globalLQ != nil {
 		globalLQ.cancel()
-		globalLQ.wg.Wait()
 		seedsToReset := reactor.GetStateTable()
 		for _, seed := range seedsToReset {
 			if err := globalLQ.client.ResetURL(context.TODO(), seed); err != nil {
@@ -1,8 +1,27 @@
--- name: GetFreshURLs :many
+-- name: GetFreshURLsOfHost :many
 SELECT * FROM urls
-WHERE status = 'FRESH'
+WHERE status = 'FRESH' AND host = ?
+ORDER BY hops, rowid
 LIMIT ?;
 
+-- name: GetFreshURLsByHops :many
+SELECT * FROM urls
+WHERE status = 'FRESH'
+ORDER BY hops, rowid
+LIMIT ? OFFSET ?;
+
+-- name: GetFreshURLsByHopsDesc :many
+SELECT * FROM urls
+WHERE status = 'FRESH'
+ORDER BY hops DESC, rowid DESC
+LIMIT ? OFFSET ?;
+
+-- name: GetOldestFreshURLTimestamp :one
+SELECT timestamp FROM urls
+WHERE status = 'FRESH'
+ORDER BY rowid
+LIMIT 1;
+
 -- name: ClaimThisURL :exec
 UPDATE urls
 SET status = 'CLAIMED', timestamp = strftime('%s', 'now')
@@ -13,9 +32,14 @@ UPDATE urls
 SET status = 'FRESH', timestamp = strftime('%s', 'now')
 WHERE id = ?;
 
+-- name: ResetClaimedURLs :execrows
+UPDATE urls
+SET status = 'FRESH', timestamp = strftime('%s', 'now')
+WHERE status = 'CLAIMED';
+
 -- name: AddURL :exec
-INSERT INTO urls (id, value, via, hops)
-VALUES (?, ?, ?, ?);
+INSERT INTO urls (id, value, via, hops, host, max_hops)
+VALUES (?, ?, ?, ?, ?, ?);
 
 -- name: DoneURL :exec
 UPDATE urls
@@ -25,3 +49,26 @@ WHERE id = ?;
 -- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = ?;
+
+-- name: ListFreshURLs :many
+SELECT * FROM urls
+WHERE status = 'FRESH'
+ORDER BY rowid
+LIMIT ? OFFSET ?;
+
+-- name: CountURLsByStatus :many
+SELECT status, COUNT(*) AS count FROM urls
+GROUP BY status;
+
+-- name: CountFreshURLsByHost :many
+SELECT host, COUNT(*) AS count FROM urls
+WHERE status = 'FRESH'
+GROUP BY host
+ORDER BY count DESC
+LIMIT ?;
+
+-- name: ListPendingURLsAfter :many
+SELECT rowid, id, value, via, hops, timestamp, max_hops FROM urls
+WHERE status != 'DONE' AND rowid > ?
+ORDER BY rowid
+LIMIT ?;
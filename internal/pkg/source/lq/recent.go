@@ -0,0 +1,74 @@
+package lq
+
+import (
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+)
+
+// recentlyQueuedSize is the number of URLs added to the queue that are remembered
+const recentlyQueuedSize = 1000
+
+// recentlyQueued is a ring buffer of the last URLs added to the queue, it gives a sample
+// of the pending URLs of a host without reading the database
+type recentlyQueued struct {
+	mu      sync.Mutex
+	URLs    [recentlyQueuedSize]sqlc_model.Url
+	claimed [recentlyQueuedSize]bool
+	next    int
+	count   int
+
+	// Position of the URLs in the ring by ID
+	positions map[string]int
+}
+
+func newRecentlyQueued() *recentlyQueued {
+	return &recentlyQueued{
+		positions: make(map[string]int, recentlyQueuedSize),
+	}
+}
+
+func (r *recentlyQueued) add(URL sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.count == recentlyQueuedSize {
+		delete(r.positions, r.URLs[r.next].ID)
+	} else {
+		r.count++
+	}
+
+	r.URLs[r.next] = URL
+	r.claimed[r.next] = false
+	r.positions[URL.ID] = r.next
+
+	r.next = (r.next + 1) % recentlyQueuedSize
+}
+
+// claim marks the URLs taken out of the queue, they aren't part of the samples anymore
+func (r *recentlyQueued) claim(URLs []sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, URL := range URLs {
+		if position, found := r.positions[URL.ID]; found {
+			r.claimed[position] = true
+		}
+	}
+}
+
+// host returns up to limit of the last URLs of the host added to the queue and still pending, newest first
+func (r *recentlyQueued) host(host string, limit int) (URLs []sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := 1; i <= r.count && len(URLs) < limit; i++ {
+		position := (r.next - i + recentlyQueuedSize) % recentlyQueuedSize
+
+		if !r.claimed[position] && r.URLs[position].Host == host {
+			URLs = append(URLs, r.URLs[position])
+		}
+	}
+
+	return URLs
+}
@@ -0,0 +1,256 @@
+package lq
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+// hostRotation is the list of the hosts with pending URLs. The queue is served host by host in
+// turn, like Heritrix's queue rotation, so that a huge host doesn't starve the small ones.
+//
+// The pending URLs are stored on disk, each host keeps up to hostMemory of its next URLs in memory
+// (and maxMemory across all hosts) so that they are served without reading the database each time.
+// A host's URLs are read again from the database once all the ones in memory are served.
+type hostRotation struct {
+	mu     sync.Mutex
+	hosts  []string
+	queues map[string]*hostQueue
+	cursor int
+
+	hostMemory int
+	maxMemory  int
+	inMemory   int
+	pending    int64
+}
+
+// hostQueue holds the number of pending URLs of a host and the next ones, they are still FRESH in the database
+type hostQueue struct {
+	pending int64
+	next    []sqlc_model.Url
+}
+
+// newHostRotation starts the rotation with the number of pending URLs of each host in the database
+func newHostRotation(hosts []FrontierHost, hostMemory, maxMemory int) *hostRotation {
+	r := &hostRotation{
+		queues:     make(map[string]*hostQueue),
+		hostMemory: hostMemory,
+		maxMemory:  maxMemory,
+	}
+
+	for _, host := range hosts {
+		r.add(host.Host, host.Pending)
+	}
+
+	return r
+}
+
+// add puts the host in the rotation if it isn't already, and counts the URLs added to its queue
+func (r *hostRotation) add(host string, added int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue, found := r.queues[host]
+	if !found {
+		queue = &hostQueue{}
+		r.queues[host] = queue
+		r.hosts = append(r.hosts, host)
+	}
+
+	queue.pending += added
+	r.pending += added
+
+	r.updateStats()
+}
+
+func (r *hostRotation) remove(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue, found := r.queues[host]
+	if !found {
+		return
+	}
+
+	r.inMemory -= len(queue.next)
+	r.pending -= queue.pending
+	delete(r.queues, host)
+
+	for i := range r.hosts {
+		if r.hosts[i] != host {
+			continue
+		}
+
+		r.hosts = append(r.hosts[:i], r.hosts[i+1:]...)
+		if r.cursor > i {
+			r.cursor--
+		}
+		break
+	}
+
+	if r.cursor >= len(r.hosts) {
+		r.cursor = 0
+	}
+
+	r.updateStats()
+}
+
+// pop returns the next URL of the host held in memory, if any
+func (r *hostRotation) pop(host string) (URL sqlc_model.Url, found bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queues[host]
+	if queue == nil || len(queue.next) == 0 {
+		return URL, false
+	}
+
+	URL = queue.next[0]
+	queue.next = queue.next[1:]
+	r.inMemory--
+
+	r.updateStats()
+
+	return URL, true
+}
+
+// room returns the number of URLs of the host that can be kept in memory
+func (r *hostRotation) room() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return max(min(r.hostMemory, r.maxMemory-r.inMemory), 0)
+}
+
+// keep holds the next URLs of the host in memory
+func (r *hostRotation) keep(host string, URLs []sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queues[host]
+	if queue == nil || len(URLs) == 0 {
+		return
+	}
+
+	queue.next = append(queue.next, URLs...)
+	r.inMemory += len(URLs)
+
+	r.updateStats()
+}
+
+// claimed counts the URLs taken out of the queue
+func (r *hostRotation) claimed(URLs []sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, URL := range URLs {
+		if queue := r.queues[URL.Host]; queue != nil {
+			queue.pending--
+		}
+	}
+
+	r.pending -= int64(len(URLs))
+
+	r.updateStats()
+}
+
+// largest returns the limit hosts with the most pending URLs
+func (r *hostRotation) largest(limit int) []FrontierHost {
+	r.mu.Lock()
+	hosts := make([]FrontierHost, 0, len(r.queues))
+	for host, queue := range r.queues {
+		hosts = append(hosts, FrontierHost{Host: host, Pending: queue.pending})
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(hosts, func(a, b FrontierHost) int {
+		if a.Pending != b.Pending {
+			return cmp.Compare(b.Pending, a.Pending)
+		}
+
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	return hosts[:min(limit, len(hosts))]
+}
+
+// host returns the number of pending URLs of the host and the ones held in memory
+func (r *hostRotation) host(host string) (pending int64, next []sqlc_model.Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queues[host]
+	if queue == nil {
+		return 0, nil
+	}
+
+	return queue.pending, slices.Clone(queue.next)
+}
+
+// size returns the number of pending URLs
+func (r *hostRotation) size() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pending
+}
+
+func (r *hostRotation) updateStats() {
+	stats.FrontierHostQueuesSet(int64(len(r.hosts)))
+	stats.FrontierInMemorySet(int64(r.inMemory))
+	stats.FrontierOnDiskSet(max(r.pending-int64(r.inMemory), 0))
+	stats.FrontierPendingSet(r.pending)
+}
+
+// turn returns the hosts in the order they are served, starting with the one whose turn it is
+func (r *hostRotation) turn() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append(append([]string{}, r.hosts[r.cursor:]...), r.hosts[:r.cursor]...)
+}
+
+// served moves the turn to the host after the given one
+func (r *hostRotation) served(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.hosts {
+		if r.hosts[i] == host {
+			r.cursor = (i + 1) % len(r.hosts)
+			return
+		}
+	}
+}
+
+func (r *hostRotation) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.hosts)
+}
+
+// hostReady tells if a host can be served now, the hosts that aren't ready are skipped
+// in the rotation instead of blocking it. All the hosts are ready if it's nil.
+var hostReady func(host string) bool
+
+// SetHostReadyFunc sets the function telling if a host can be served now, e.g. because
+// the host is already requested as much as allowed
+func SetHostReadyFunc(ready func(host string) bool) {
+	hostReady = ready
+}
+
+// hostOf returns the host of the rotation a URL belongs to
+func hostOf(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(parsed.Host)
+}
@@ -0,0 +1,378 @@
+package lq
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+)
+
+func newTestClient(t *testing.T) *LQClient {
+	t.Helper()
+
+	config.InitConfig()
+	config.Get().JobPath = t.TempDir()
+
+	stats.Init()
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "lq",
+	})
+
+	client, err := Init("test")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { client.dbWrite.Close() })
+
+	globalLQ = &lq{client: client}
+	t.Cleanup(func() { globalLQ = nil })
+
+	return client
+}
+
+func claimedHosts(t *testing.T, client *LQClient, limit int) []string {
+	t.Helper()
+
+	URLs, err := client.Get(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	hosts := make([]string, 0, len(URLs))
+	for _, URL := range URLs {
+		hosts = append(hosts, URL.Host)
+	}
+
+	return hosts
+}
+
+func TestGetRoundRobin(t *testing.T) {
+	client := newTestClient(t)
+
+	err := client.Add(context.Background(), []sqlc_model.Url{
+		{Value: "http://big.example.com/1"},
+		{Value: "http://big.example.com/2"},
+		{Value: "http://big.example.com/3"},
+		{Value: "http://big.example.com/4"},
+		{Value: "http://Small.example.com/1"},
+		{Value: "http://other.example.com/1"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if got := frontierHostQueues(t); got != 3 {
+		t.Errorf("expected 3 host queues, got %d", got)
+	}
+
+	// The small hosts are served before the second URL of the big one
+	hosts := claimedHosts(t, client, 3)
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		seen[host] = true
+	}
+	if len(hosts) != 3 || len(seen) != 3 || !seen["small.example.com"] {
+		t.Fatalf("Get(3) claimed %v, want one URL of each host", hosts)
+	}
+
+	// Only the big host is left
+	if hosts := claimedHosts(t, client, 5); len(hosts) != 3 {
+		t.Fatalf("Get(5) claimed %v, want the 3 URLs left", hosts)
+	}
+
+	if hosts := claimedHosts(t, client, 5); len(hosts) != 0 {
+		t.Fatalf("Get(5) claimed %v on an empty queue", hosts)
+	}
+
+	if got := frontierHostQueues(t); got != 0 {
+		t.Errorf("expected the exhausted host queues to be removed, got %d", got)
+	}
+}
+
+func TestGetSkipsHostsNotReady(t *testing.T) {
+	client := newTestClient(t)
+
+	SetHostReadyFunc(func(host string) bool { return host != "busy.example.com" })
+	defer SetHostReadyFunc(nil)
+
+	err := client.Add(context.Background(), []sqlc_model.Url{
+		{Value: "http://busy.example.com/1"},
+		{Value: "http://idle.example.com/1"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if hosts := claimedHosts(t, client, 2); len(hosts) != 1 || hosts[0] != "idle.example.com" {
+		t.Fatalf("Get(2) claimed %v, want only idle.example.com", hosts)
+	}
+
+	// The busy host keeps its place in the rotation
+	if got := frontierHostQueues(t); got != 1 {
+		t.Errorf("expected the busy host queue to be kept, got %d", got)
+	}
+}
+
+func frontierHostQueues(t *testing.T) int {
+	t.Helper()
+
+	queues, err := FrontierHostQueues()
+	if err != nil {
+		t.Fatalf("FrontierHostQueues() error = %v", err)
+	}
+
+	return queues
+}
+
+func TestInitMigratesHostColumn(t *testing.T) {
+	config.InitConfig()
+	config.Get().JobPath = t.TempDir()
+
+	stats.Init()
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "lq",
+	})
+
+	// Database created before the host column existed
+	db, err := sql.Open("sqlite3", "file:"+path.Join(config.Get().JobPath, "lq.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE urls (
+		id TEXT NOT NULL PRIMARY KEY,
+		value TEXT NOT NULL,
+		via TEXT DEFAULT '' NOT NULL,
+		hops INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'FRESH' CHECK (status IN ('FRESH', 'CLAIMED', 'DONE')),
+		timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	);
+	INSERT INTO urls (id, value) VALUES ('1', 'http://Example.com/a'), ('2', 'https://other.example.com');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := Init("test")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer client.dbWrite.Close()
+
+	if got := client.rotation.len(); got != 2 {
+		t.Errorf("expected the 2 hosts of the queue in the rotation, got %d", got)
+	}
+
+	globalLQ = &lq{client: client}
+	defer func() { globalLQ = nil }()
+
+	hosts, err := FrontierHosts(10)
+	if err != nil {
+		t.Fatalf("FrontierHosts() error = %v", err)
+	}
+
+	for _, host := range hosts {
+		if host.Host != "example.com" && host.Host != "other.example.com" {
+			t.Errorf("unexpected host %q after the migration", host.Host)
+		}
+	}
+
+	// The URLs queued before the max_hops column existed get the global max hops
+	var maxHops int64
+	if err := client.dbWrite.QueryRow(`SELECT max_hops FROM urls WHERE id = '1'`).Scan(&maxHops); err != nil || maxHops != -1 {
+		t.Errorf("max_hops = %d, %v after the migration, want -1", maxHops, err)
+	}
+}
+
+func TestGetKeepsBoundedURLsInMemory(t *testing.T) {
+	config.InitConfig()
+	config.Get().FrontierHostMemory = 2
+	config.Get().FrontierMaxMemory = 3
+	defer func() {
+		config.Get().FrontierHostMemory = 0
+		config.Get().FrontierMaxMemory = 0
+	}()
+
+	client := newTestClient(t)
+
+	var URLs []sqlc_model.Url
+	for _, host := range []string{"a.example.com", "b.example.com"} {
+		for _, path := range []string{"1", "2", "3", "4"} {
+			URLs = append(URLs, sqlc_model.Url{Value: "http://" + host + "/" + path})
+		}
+	}
+
+	if err := client.Add(context.Background(), URLs, false); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if got := stats.FrontierOnDiskGet(); got != 8 {
+		t.Errorf("expected 8 URLs on disk, got %d", got)
+	}
+
+	// a.example.com keeps its 2 next URLs in memory, b.example.com the 1 that is left room for
+	if hosts := claimedHosts(t, client, 2); len(hosts) != 2 {
+		t.Fatalf("Get(2) claimed %v", hosts)
+	}
+
+	if inMemory, onDisk := stats.FrontierInMemoryGet(), stats.FrontierOnDiskGet(); inMemory != 3 || onDisk != 3 {
+		t.Errorf("expected 3 URLs in memory and 3 on disk, got %d and %d", inMemory, onDisk)
+	}
+
+	// The URLs held in memory are served in order, and the rest is read from disk when they run out
+	URLs, err := client.Get(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, URL := range URLs {
+		if seen[URL.Value] {
+			t.Errorf("%s claimed twice", URL.Value)
+		}
+		seen[URL.Value] = true
+	}
+
+	if len(URLs) != 6 {
+		t.Errorf("Get(10) claimed %d URLs, want the 6 left", len(URLs))
+	}
+
+	if inMemory, onDisk := stats.FrontierInMemoryGet(), stats.FrontierOnDiskGet(); inMemory != 0 || onDisk != 0 {
+		t.Errorf("expected an empty frontier, got %d in memory and %d on disk", inMemory, onDisk)
+	}
+}
+
+func TestInitRequeuesClaimedURLs(t *testing.T) {
+	client := newTestClient(t)
+
+	err := client.Add(context.Background(), []sqlc_model.Url{
+		{Value: "http://example.com/1"},
+		{Value: "http://example.com/2"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if hosts := claimedHosts(t, client, 2); len(hosts) != 2 {
+		t.Fatalf("Get(2) claimed %v", hosts)
+	}
+
+	// The process crashes with the URLs claimed
+	client.dbWrite.Close()
+
+	client, err = Init("test")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer client.dbWrite.Close()
+
+	globalLQ = &lq{client: client}
+
+	if hosts := claimedHosts(t, client, 2); len(hosts) != 2 {
+		t.Fatalf("expected the claimed URLs to be queued again, Get(2) claimed %v", hosts)
+	}
+}
+
+func claimedValues(t *testing.T, client *LQClient, limit int) []string {
+	t.Helper()
+
+	URLs, err := client.Get(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	values := make([]string, 0, len(URLs))
+	for _, URL := range URLs {
+		values = append(values, URL.Value)
+	}
+
+	return values
+}
+
+func addHopsTestURLs(t *testing.T, client *LQClient) {
+	t.Helper()
+
+	err := client.Add(context.Background(), []sqlc_model.Url{
+		{Value: "http://a.example.com/2", Hops: 2},
+		{Value: "http://a.example.com/0", Hops: 0},
+		{Value: "http://b.example.com/1", Hops: 1},
+		{Value: "http://a.example.com/1", Hops: 1},
+	}, false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+}
+
+func TestGetStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     []string
+	}{
+		{"bfs", []string{"http://a.example.com/0", "http://b.example.com/1", "http://a.example.com/1", "http://a.example.com/2"}},
+		{"dfs", []string{"http://a.example.com/2", "http://a.example.com/1", "http://b.example.com/1", "http://a.example.com/0"}},
+		// Hosts in turn, the URLs of a.example.com by hops
+		{"host-fair", []string{"http://a.example.com/0", "http://b.example.com/1", "http://a.example.com/1", "http://a.example.com/2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			client := newTestClient(t)
+			config.Get().FrontierStrategy = tt.strategy
+			defer func() { config.Get().FrontierStrategy = "" }()
+
+			addHopsTestURLs(t, client)
+
+			var got []string
+			for range tt.want {
+				got = append(got, claimedValues(t, client, 1)...)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+
+			if got := claimedValues(t, client, 1); len(got) != 0 {
+				t.Fatalf("expected the queue to be empty, got %v", got)
+			}
+
+			if got := frontierHostQueues(t); got != 0 {
+				t.Errorf("expected no host queue left, got %d", got)
+			}
+		})
+	}
+}
+
+func TestGetByHopsSkipsHostsNotReady(t *testing.T) {
+	client := newTestClient(t)
+	config.Get().FrontierStrategy = "bfs"
+	defer func() { config.Get().FrontierStrategy = "" }()
+
+	addHopsTestURLs(t, client)
+
+	SetHostReadyFunc(func(host string) bool { return host != "a.example.com" })
+	defer SetHostReadyFunc(nil)
+
+	if got := claimedValues(t, client, 10); len(got) != 1 || got[0] != "http://b.example.com/1" {
+		t.Fatalf("expected only the URL of b.example.com, got %v", got)
+	}
+
+	SetHostReadyFunc(nil)
+
+	if got := claimedValues(t, client, 10); len(got) != 3 || got[0] != "http://a.example.com/0" {
+		t.Fatalf("expected the URLs of a.example.com by hops, got %v", got)
+	}
+}
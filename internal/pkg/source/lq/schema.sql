@@ -4,7 +4,10 @@ CREATE TABLE IF NOT EXISTS urls (
     via TEXT DEFAULT '' NOT NULL,
     hops INTEGER NOT NULL DEFAULT 0,
     status TEXT NOT NULL DEFAULT 'FRESH' CHECK (status IN ('FRESH', 'CLAIMED', 'DONE')),
-    timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    host TEXT NOT NULL DEFAULT '',
+    max_hops INTEGER NOT NULL DEFAULT -1 -- max hops of the seed, -1 if the global one applies
 );
 CREATE UNIQUE INDEX IF NOT EXISTS urls_value ON urls (value); -- for deduplication
 CREATE INDEX IF NOT EXISTS urls_status ON urls (status); -- for queueing
+CREATE INDEX IF NOT EXISTS urls_status_hops ON urls (status, hops); -- for queueing by hops
@@ -0,0 +1,99 @@
+package lq
+
+import (
+	"context"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
+	"github.com/internetarchive/Zeno/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// sqlite only accepts one write at a time, so hardcoding this to 2
+// allows one sender operation to be in progress while another is being prepared/blocking
+const maxFinishSenders = 2
+
+// lqSource exchanges the URLs of the crawl with the local queue
+type lqSource struct{}
+
+// Source returns the URL source backed by the local queue, LQ must be started
+func Source() source.URLSource {
+	return lqSource{}
+}
+
+// Batching returns the batch sizes and the number of concurrent senders used with the local queue
+func (lqSource) Batching() source.Batching {
+	return source.Batching{
+		Pull:            config.Get().WorkersCount,
+		Finished:        config.Get().WorkersCount,
+		Discovered:      100,
+		FinishSenders:   maxFinishSenders,
+		DiscoverSenders: 1,
+	}
+}
+
+// Pull claims fresh URLs of the local queue
+func (lqSource) Pull(ctx context.Context, n int) ([]*models.Item, error) {
+	URLs, err := globalLQ.client.Get(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.Item, 0, len(URLs))
+	for _, URL := range URLs {
+		// Items whose URL can't be parsed are sent to the finisher by the source
+		parsedURL := models.URL{
+			Raw:  URL.Value,
+			Hops: int(URL.Hops),
+		}
+		parsedURL.Parse()
+
+		newItem := models.NewItem(URL.ID, &parsedURL, URL.Via)
+		newItem.SetStatus(models.ItemFresh)
+		newItem.SetSource(models.ItemSourceQueue)
+		newItem.SetMaxHops(int(URL.MaxHops))
+
+		items = append(items, newItem)
+	}
+
+	return items, nil
+}
+
+// Finished marks the items as done in the local queue
+func (lqSource) Finished(ctx context.Context, items []*models.Item) error {
+	URLs := make([]sqlc_model.Url, 0, len(items))
+	for _, item := range items {
+		var value string
+		// If preprocessing failed, there will be nil values here
+		if item.GetURL() != nil && item.GetURL().GetParsed() != nil {
+			value = item.GetURL().String()
+		}
+
+		URLs = append(URLs, sqlc_model.Url{
+			ID:    item.GetID(),
+			Value: value,
+		})
+	}
+
+	return globalLQ.client.Delete(ctx, URLs, false)
+}
+
+// Discovered adds the items to the local queue
+func (lqSource) Discovered(ctx context.Context, items []*models.Item) error {
+	URLs := make([]sqlc_model.Url, 0, len(items))
+	for _, item := range items {
+		URLs = append(URLs, queuedURL(item))
+	}
+
+	return globalLQ.client.Add(ctx, URLs, false)
+}
+
+// queuedURL returns the URL of the local queue of a seed
+func queuedURL(item *models.Item) sqlc_model.Url {
+	return sqlc_model.Url{
+		Value:   item.GetURL().Raw,
+		Via:     item.GetSeedVia(),
+		Hops:    int64(item.GetURL().GetHops()),
+		MaxHops: int64(item.GetMaxHops()),
+	}
+}
@@ -10,15 +10,17 @@ import (
 )
 
 const addURL = `-- name: AddURL :exec
-INSERT INTO urls (id, value, via, hops)
-VALUES (?, ?, ?, ?)
+INSERT INTO urls (id, value, via, hops, host, max_hops)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 
 type AddURLParams struct {
-	ID    string
-	Value string
-	Via   string
-	Hops  int64
+	ID      string
+	Value   string
+	Via     string
+	Hops    int64
+	Host    string
+	MaxHops int64
 }
 
 func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) error {
@@ -27,6 +29,8 @@ func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) error {
 		arg.Value,
 		arg.Via,
 		arg.Hops,
+		arg.Host,
+		arg.MaxHops,
 	)
 	return err
 }
@@ -42,6 +46,75 @@ func (q *Queries) ClaimThisURL(ctx context.Context, id string) error {
 	return err
 }
 
+const countFreshURLsByHost = `-- name: CountFreshURLsByHost :many
+SELECT host, COUNT(*) AS count FROM urls
+WHERE status = 'FRESH'
+GROUP BY host
+ORDER BY count DESC
+LIMIT ?
+`
+
+type CountFreshURLsByHostRow struct {
+	Host  string
+	Count int64
+}
+
+func (q *Queries) CountFreshURLsByHost(ctx context.Context, limit int64) ([]CountFreshURLsByHostRow, error) {
+	rows, err := q.db.QueryContext(ctx, countFreshURLsByHost, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountFreshURLsByHostRow
+	for rows.Next() {
+		var i CountFreshURLsByHostRow
+		if err := rows.Scan(&i.Host, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countURLsByStatus = `-- name: CountURLsByStatus :many
+SELECT status, COUNT(*) AS count FROM urls
+GROUP BY status
+`
+
+type CountURLsByStatusRow struct {
+	Status string
+	Count  int64
+}
+
+func (q *Queries) CountURLsByStatus(ctx context.Context) ([]CountURLsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countURLsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountURLsByStatusRow
+	for rows.Next() {
+		var i CountURLsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteURL = `-- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = ?
@@ -63,14 +136,108 @@ func (q *Queries) DoneURL(ctx context.Context, id string) error {
 	return err
 }
 
-const getFreshURLs = `-- name: GetFreshURLs :many
-SELECT id, value, via, hops, status, timestamp FROM urls
+const getFreshURLsByHops = `-- name: GetFreshURLsByHops :many
+SELECT id, value, via, hops, status, timestamp, host, max_hops FROM urls
+WHERE status = 'FRESH'
+ORDER BY hops, rowid
+LIMIT ? OFFSET ?
+`
+
+type GetFreshURLsByHopsParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) GetFreshURLsByHops(ctx context.Context, arg GetFreshURLsByHopsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, getFreshURLsByHops, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Value,
+			&i.Via,
+			&i.Hops,
+			&i.Status,
+			&i.Timestamp,
+			&i.Host,
+			&i.MaxHops,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFreshURLsByHopsDesc = `-- name: GetFreshURLsByHopsDesc :many
+SELECT id, value, via, hops, status, timestamp, host, max_hops FROM urls
 WHERE status = 'FRESH'
+ORDER BY hops DESC, rowid DESC
+LIMIT ? OFFSET ?
+`
+
+type GetFreshURLsByHopsDescParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) GetFreshURLsByHopsDesc(ctx context.Context, arg GetFreshURLsByHopsDescParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, getFreshURLsByHopsDesc, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Value,
+			&i.Via,
+			&i.Hops,
+			&i.Status,
+			&i.Timestamp,
+			&i.Host,
+			&i.MaxHops,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFreshURLsOfHost = `-- name: GetFreshURLsOfHost :many
+SELECT id, value, via, hops, status, timestamp, host, max_hops FROM urls
+WHERE status = 'FRESH' AND host = ?
+ORDER BY hops, rowid
 LIMIT ?
 `
 
-func (q *Queries) GetFreshURLs(ctx context.Context, limit int64) ([]Url, error) {
-	rows, err := q.db.QueryContext(ctx, getFreshURLs, limit)
+type GetFreshURLsOfHostParams struct {
+	Host  string
+	Limit int64
+}
+
+func (q *Queries) GetFreshURLsOfHost(ctx context.Context, arg GetFreshURLsOfHostParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, getFreshURLsOfHost, arg.Host, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +252,8 @@ func (q *Queries) GetFreshURLs(ctx context.Context, limit int64) ([]Url, error)
 			&i.Hops,
 			&i.Status,
 			&i.Timestamp,
+			&i.Host,
+			&i.MaxHops,
 		); err != nil {
 			return nil, err
 		}
@@ -99,6 +268,131 @@ func (q *Queries) GetFreshURLs(ctx context.Context, limit int64) ([]Url, error)
 	return items, nil
 }
 
+const getOldestFreshURLTimestamp = `-- name: GetOldestFreshURLTimestamp :one
+SELECT timestamp FROM urls
+WHERE status = 'FRESH'
+ORDER BY rowid
+LIMIT 1
+`
+
+func (q *Queries) GetOldestFreshURLTimestamp(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOldestFreshURLTimestamp)
+	var timestamp int64
+	err := row.Scan(&timestamp)
+	return timestamp, err
+}
+
+const listFreshURLs = `-- name: ListFreshURLs :many
+SELECT id, value, via, hops, status, timestamp, host, max_hops FROM urls
+WHERE status = 'FRESH'
+ORDER BY rowid
+LIMIT ? OFFSET ?
+`
+
+type ListFreshURLsParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) ListFreshURLs(ctx context.Context, arg ListFreshURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, listFreshURLs, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Value,
+			&i.Via,
+			&i.Hops,
+			&i.Status,
+			&i.Timestamp,
+			&i.Host,
+			&i.MaxHops,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingURLsAfter = `-- name: ListPendingURLsAfter :many
+SELECT rowid, id, value, via, hops, timestamp, max_hops FROM urls
+WHERE status != 'DONE' AND rowid > ?
+ORDER BY rowid
+LIMIT ?
+`
+
+type ListPendingURLsAfterParams struct {
+	Rowid int64
+	Limit int64
+}
+
+type ListPendingURLsAfterRow struct {
+	Rowid     int64
+	ID        string
+	Value     string
+	Via       string
+	Hops      int64
+	Timestamp int64
+	MaxHops   int64
+}
+
+func (q *Queries) ListPendingURLsAfter(ctx context.Context, arg ListPendingURLsAfterParams) ([]ListPendingURLsAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingURLsAfter, arg.Rowid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingURLsAfterRow
+	for rows.Next() {
+		var i ListPendingURLsAfterRow
+		if err := rows.Scan(
+			&i.Rowid,
+			&i.ID,
+			&i.Value,
+			&i.Via,
+			&i.Hops,
+			&i.Timestamp,
+			&i.MaxHops,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetClaimedURLs = `-- name: ResetClaimedURLs :execrows
+UPDATE urls
+SET status = 'FRESH', timestamp = strftime('%s', 'now')
+WHERE status = 'CLAIMED'
+`
+
+func (q *Queries) ResetClaimedURLs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetClaimedURLs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resetURL = `-- name: ResetURL :exec
 UPDATE urls
 SET status = 'FRESH', timestamp = strftime('%s', 'now')
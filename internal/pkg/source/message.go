@@ -0,0 +1,79 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// URLMessage is the message of a URL to crawl exchanged with the message queues, it is the format of both
+// the seeds consumed and the outlinks produced so that the outlinks can be fed back as seeds.
+// Seeds can also be plain URLs.
+type URLMessage struct {
+	URL     string `json:"url"`
+	Via     string `json:"via,omitempty"`
+	Hops    int    `json:"hops,omitempty"`
+	MaxHops *int   `json:"max_hops,omitempty"` // Max hops of the seed, nil if the global one applies
+}
+
+// ErrEmptyMessage is returned when decoding a message without URL
+var ErrEmptyMessage = errors.New("empty message")
+
+// DecodeURLMessage reads a seed message, either a URLMessage or a plain URL
+func DecodeURLMessage(value []byte) (message URLMessage, err error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return message, ErrEmptyMessage
+	}
+
+	if value[0] != '{' {
+		return URLMessage{URL: string(value)}, nil
+	}
+
+	if err := json.Unmarshal(value, &message); err != nil {
+		return message, err
+	}
+
+	if message.URL == "" {
+		return message, ErrEmptyMessage
+	}
+
+	return message, nil
+}
+
+// EncodeURLMessage returns the message of a discovered item
+func EncodeURLMessage(item *models.Item) ([]byte, error) {
+	message := URLMessage{
+		URL:  item.GetURL().Raw,
+		Via:  item.GetSeedVia(),
+		Hops: item.GetURL().GetHops(),
+	}
+
+	if maxHops := item.GetMaxHops(); maxHops >= 0 {
+		message.MaxHops = &maxHops
+	}
+
+	return json.Marshal(message)
+}
+
+// Item returns a fresh seed for the message, with a new ID. Items whose URL can't be parsed are sent
+// to the finisher by the source.
+func (m URLMessage) Item() *models.Item {
+	parsedURL := models.URL{
+		Raw:  m.URL,
+		Hops: m.Hops,
+	}
+	parsedURL.Parse()
+
+	newItem := models.NewItem(uuid.New().String(), &parsedURL, m.Via)
+	newItem.SetStatus(models.ItemFresh)
+	newItem.SetSource(models.ItemSourceQueue)
+	if m.MaxHops != nil && *m.MaxHops >= 0 {
+		newItem.SetMaxHops(*m.MaxHops)
+	}
+
+	return newItem
+}
@@ -0,0 +1,64 @@
+package source
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeURLMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    URLMessage
+		wantErr bool
+	}{
+		{name: "plain URL", value: "https://example.com/\n", want: URLMessage{URL: "https://example.com/"}},
+		{name: "JSON", value: `{"url":"https://example.com/a","via":"https://example.com/","hops":2}`, want: URLMessage{URL: "https://example.com/a", Via: "https://example.com/", Hops: 2}},
+		{name: "empty", value: "  ", wantErr: true},
+		{name: "JSON without URL", value: `{"hops":1}`, wantErr: true},
+		{name: "invalid JSON", value: `{"url":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeURLMessage([]byte(tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeURLMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("DecodeURLMessage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DecodeURLMessage(nil); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("expected ErrEmptyMessage, got %v", err)
+	}
+}
+
+func TestURLMessageMaxHops(t *testing.T) {
+	message, err := DecodeURLMessage([]byte(`{"url":"https://example.com/a","hops":1,"max_hops":3}`))
+	if err != nil {
+		t.Fatalf("DecodeURLMessage() error = %v", err)
+	}
+
+	item := message.Item()
+	if item.GetMaxHops() != 3 {
+		t.Fatalf("GetMaxHops() = %d, want 3", item.GetMaxHops())
+	}
+
+	encoded, err := EncodeURLMessage(item)
+	if err != nil {
+		t.Fatalf("EncodeURLMessage() error = %v", err)
+	}
+
+	if string(encoded) != `{"url":"https://example.com/a","hops":1,"max_hops":3}` {
+		t.Errorf("EncodeURLMessage() = %s", encoded)
+	}
+
+	// Without max hops, the global one applies
+	message, _ = DecodeURLMessage([]byte("https://example.com/b"))
+	if item := message.Item(); item.GetMaxHops() != -1 {
+		t.Errorf("GetMaxHops() = %d, want -1", item.GetMaxHops())
+	}
+}
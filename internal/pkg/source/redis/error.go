@@ -0,0 +1,8 @@
+package redis
+
+import "errors"
+
+var (
+	// ErrRedisAlreadyInitialized is the error returned when the redis source is already initialized
+	ErrRedisAlreadyInitialized = errors.New("redis client already initialized")
+)
@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// listQueue exchanges the seeds through a list: seeds are moved to the processing list of the
+// instance that pulls them and removed from it once finished. Every instance keeps an alive key
+// with a TTL of --redis-claim-timeout, the processing lists of the instances without one are
+// moved back to the queue.
+type listQueue struct {
+	client       *goredis.Client
+	prefix       string
+	instance     string
+	claimTimeout time.Duration
+
+	aliveMu   sync.Mutex
+	lastAlive time.Time
+}
+
+func newListQueue(client *goredis.Client, prefix, instance string, claimTimeout time.Duration) *listQueue {
+	return &listQueue{
+		client:       client,
+		prefix:       prefix,
+		instance:     instance,
+		claimTimeout: claimTimeout,
+	}
+}
+
+func (q *listQueue) queueKey() string { return q.prefix + ":queue" }
+
+func (q *listQueue) processingKey(instance string) string {
+	return q.prefix + ":processing:" + instance
+}
+
+func (q *listQueue) aliveKey(instance string) string {
+	return q.prefix + ":alive:" + instance
+}
+
+// keepAlive sets the alive key of the instance, if it wasn't since a third of --redis-claim-timeout
+func (q *listQueue) keepAlive(ctx context.Context) error {
+	q.aliveMu.Lock()
+	defer q.aliveMu.Unlock()
+
+	if time.Since(q.lastAlive) < q.claimTimeout/3 {
+		return nil
+	}
+
+	if err := q.client.Set(ctx, q.aliveKey(q.instance), time.Now().UTC().Format(time.RFC3339), q.claimTimeout).Err(); err != nil {
+		return err
+	}
+	q.lastAlive = time.Now()
+
+	return nil
+}
+
+// pull waits up to a second for a seed, then takes the others available without waiting.
+// The instance is made alive first so that its claims are never seen as stale.
+func (q *listQueue) pull(ctx context.Context, n int) ([]claim, error) {
+	if err := q.keepAlive(ctx); err != nil {
+		return nil, err
+	}
+
+	value, err := q.client.BLMove(ctx, q.queueKey(), q.processingKey(q.instance), "LEFT", "RIGHT", time.Second).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	claims := []claim{{value: value}}
+	if n <= 1 {
+		return claims, nil
+	}
+
+	pipe := q.client.Pipeline()
+	cmds := make([]*goredis.StringCmd, n-1)
+	for i := range cmds {
+		cmds[i] = pipe.LMove(ctx, q.queueKey(), q.processingKey(q.instance), "LEFT", "RIGHT")
+	}
+	pipe.Exec(ctx)
+
+	// The seeds moved before a failure are kept, the others stay in the queue
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			if !errors.Is(cmd.Err(), goredis.Nil) {
+				logger.Warn("unable to pull seeds", "err", cmd.Err().Error())
+			}
+			break
+		}
+		claims = append(claims, claim{value: cmd.Val()})
+	}
+
+	return claims, nil
+}
+
+func (q *listQueue) ack(ctx context.Context, claims []claim) error {
+	pipe := q.client.Pipeline()
+	for _, c := range claims {
+		pipe.LRem(ctx, q.processingKey(q.instance), 1, c.value)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *listQueue) push(ctx context.Context, messages [][]byte) error {
+	values := make([]any, len(messages))
+	for i := range messages {
+		values[i] = messages[i]
+	}
+
+	return q.client.RPush(ctx, q.queueKey(), values...).Err()
+}
+
+func (q *listQueue) maintain(ctx context.Context) error {
+	if err := q.keepAlive(ctx); err != nil {
+		return err
+	}
+
+	processingPrefix := q.processingKey("")
+	iter := q.client.Scan(ctx, 0, processingPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		instance := strings.TrimPrefix(iter.Val(), processingPrefix)
+		if instance == q.instance {
+			continue
+		}
+
+		alive, err := q.client.Exists(ctx, q.aliveKey(instance)).Result()
+		if err != nil {
+			return err
+		}
+
+		if alive > 0 {
+			continue
+		}
+
+		requeued, err := q.requeue(ctx, instance)
+		if requeued > 0 {
+			logger.Info("requeued the stale claims of an instance", "instance", instance, "seeds", requeued)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
+
+func (q *listQueue) release(ctx context.Context) error {
+	requeued, err := q.requeue(ctx, q.instance)
+	if requeued > 0 {
+		logger.Info("requeued the seeds claimed by this instance", "seeds", requeued)
+	}
+	if err != nil {
+		return err
+	}
+
+	return q.client.Del(ctx, q.aliveKey(q.instance)).Err()
+}
+
+// requeue moves the processing list of the instance back to the head of the queue, in order
+func (q *listQueue) requeue(ctx context.Context, instance string) (requeued int, err error) {
+	for {
+		err := q.client.LMove(ctx, q.processingKey(instance), q.queueKey(), "RIGHT", "LEFT").Err()
+		if errors.Is(err, goredis.Nil) {
+			return requeued, nil
+		} else if err != nil {
+			return requeued, err
+		}
+
+		requeued++
+	}
+}
@@ -0,0 +1,216 @@
+// Package redis is the URL source of the small distributed crawls sharing a Redis queue, either a list
+// or a stream. Every instance claims the seeds it pulls so that a seed is crawled by a single instance:
+// the claims of an instance that stopped answering are requeued after --redis-claim-timeout.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// queue is the Redis data structure the seeds are exchanged through
+type queue interface {
+	// pull claims up to n seeds
+	pull(ctx context.Context, n int) ([]claim, error)
+	// ack releases the claims of the finished seeds
+	ack(ctx context.Context, claims []claim) error
+	// push adds the messages to the queue
+	push(ctx context.Context, messages [][]byte) error
+	// maintain keeps the claims of the instance alive and requeues the stale claims of the others
+	maintain(ctx context.Context) error
+	// release requeues the claims of the instance, if the queue allows it
+	release(ctx context.Context) error
+}
+
+// claim is a seed claimed by the instance
+type claim struct {
+	// id is the ID of the stream entry, empty with a list
+	id    string
+	value string
+}
+
+type redis struct {
+	client *goredis.Client
+	queue  queue
+
+	// claims are the seeds being crawled, by item ID
+	claims   map[string]claim
+	claimsMu sync.Mutex
+
+	// pullBackoff is the pause after a failed pull, to not hammer an unreachable Redis
+	pullBackoff time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+const maxPullBackoff = 30 * time.Second
+
+var (
+	globalRedis *redis
+	once        sync.Once
+	logger      *log.FieldedLogger
+)
+
+// Start connects to Redis and starts maintaining the claims of the instance, the URLs are then exchanged
+// through Source. Redis being unreachable isn't fatal: pulling is paused until it is back.
+func Start() error {
+	var done bool
+	var startErr error
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "redis",
+	})
+
+	stats.Init()
+
+	once.Do(func() {
+		done = true
+
+		r, err := newRedis()
+		if err != nil {
+			logger.Error("invalid redis configuration", "err", err.Error(), "func", "redis.Start")
+			startErr = err
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := r.client.Ping(pingCtx).Err(); err != nil {
+			logger.Warn("unable to reach redis, pulling is paused until it is reachable", "err", err.Error(), "func", "redis.Start")
+		} else if config.Get().RedisInstanceID != "" {
+			// The claims left by a previous run of this instance are crawled again
+			if err := r.queue.release(pingCtx); err != nil {
+				logger.Warn("unable to requeue the claims of the previous run", "err", err.Error(), "func", "redis.Start")
+			}
+		}
+
+		r.wg.Add(1)
+		go r.maintainer()
+
+		globalRedis = r
+
+		logger.Info("started", "address", r.client.Options().Addr, "mode", config.Get().RedisMode, "key_prefix", config.Get().RedisKeyPrefix)
+	})
+
+	if !done {
+		return ErrRedisAlreadyInitialized
+	}
+
+	return startErr
+}
+
+// Stop requeues the seeds still being crawled and closes the connection. The source must be stopped first.
+func Stop() {
+	if globalRedis != nil {
+		globalRedis.cancel()
+		globalRedis.wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := globalRedis.queue.release(ctx); err != nil {
+			logger.Error("unable to requeue the seeds being crawled", "err", err.Error())
+		}
+		cancel()
+
+		globalRedis.client.Close()
+		globalRedis = nil
+		once = sync.Once{}
+		logger.Info("stopped")
+	}
+}
+
+func newRedis() (*redis, error) {
+	if config.Get().RedisClaimTimeout <= 0 {
+		return nil, fmt.Errorf("invalid --redis-claim-timeout %s, must be positive", config.Get().RedisClaimTimeout)
+	}
+
+	options, err := goredis.ParseURL(config.Get().RedisAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --redis-address: %w", err)
+	}
+
+	r := &redis{
+		client: goredis.NewClient(options),
+		claims: make(map[string]claim),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	r.queue, err = newQueue(r, config.Get().RedisMode, config.Get().RedisKeyPrefix, instanceID(), config.Get().RedisClaimTimeout)
+	if err != nil {
+		r.client.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func newQueue(r *redis, mode, prefix, instance string, claimTimeout time.Duration) (queue, error) {
+	switch mode {
+	case "list":
+		return newListQueue(r.client, prefix, instance, claimTimeout), nil
+	case "stream":
+		return newStreamQueue(r.client, prefix, instance, claimTimeout, r.claimIDs), nil
+	default:
+		return nil, fmt.Errorf("invalid --redis-mode %q, must be list or stream", mode)
+	}
+}
+
+// instanceID returns the name of the instance, --redis-instance-id or a new one for each run
+func instanceID() string {
+	if config.Get().RedisInstanceID != "" {
+		return config.Get().RedisInstanceID
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "zeno"
+	}
+
+	return hostname + "-" + uuid.New().String()[:8]
+}
+
+// maintainer keeps the claims of the instance alive, often enough for them to never be seen as stale
+func (r *redis) maintainer() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(config.Get().RedisClaimTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := r.queue.maintain(r.ctx); err != nil && r.ctx.Err() == nil {
+			logger.Warn("unable to maintain the claims", "err", err.Error())
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimIDs returns the stream IDs of the seeds being crawled
+func (r *redis) claimIDs() (IDs []string) {
+	r.claimsMu.Lock()
+	defer r.claimsMu.Unlock()
+
+	for _, c := range r.claims {
+		if c.id != "" {
+			IDs = append(IDs, c.id)
+		}
+	}
+
+	return IDs
+}
@@ -0,0 +1,268 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/pkg/models"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *goredis.Client) {
+	t.Helper()
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "redis",
+	})
+
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func values(claims []claim) (values []string) {
+	for _, c := range claims {
+		values = append(values, c.value)
+	}
+	return values
+}
+
+func TestListQueueClaims(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	first := newListQueue(client, "zeno", "first", time.Minute)
+	second := newListQueue(client, "zeno", "second", time.Minute)
+
+	if err := first.push(ctx, [][]byte{[]byte("a"), []byte("b"), []byte("c")}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	claims, err := first.pull(ctx, 2)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("expected a and b to be claimed, got %v", got)
+	}
+
+	// The seeds claimed by the first instance aren't pulled by the second
+	claims, err = second.pull(ctx, 10)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("expected c to be claimed, got %v", got)
+	}
+
+	if err := first.ack(ctx, []claim{{value: "a"}}); err != nil {
+		t.Fatalf("ack() error = %v", err)
+	}
+
+	if err := first.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+	if err := second.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+
+	// The first instance is alive, its claims are kept
+	if got, _ := server.List("zeno:queue"); len(got) != 0 {
+		t.Fatalf("expected the queue to be empty, got %v", got)
+	}
+
+	// The first instance stops answering, its claims are requeued by the second
+	server.FastForward(2 * time.Minute)
+	if err := second.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+
+	if got, _ := server.List("zeno:queue"); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("expected b to be requeued, got %v", got)
+	}
+	if got, _ := server.List("zeno:processing:second"); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("expected the claims of the second instance to be kept, got %v", got)
+	}
+}
+
+func TestListQueueRelease(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	q := newListQueue(client, "zeno", "instance", time.Minute)
+
+	if err := q.push(ctx, [][]byte{[]byte("a"), []byte("b"), []byte("c")}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if _, err := q.pull(ctx, 2); err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if err := q.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+
+	if err := q.release(ctx); err != nil {
+		t.Fatalf("release() error = %v", err)
+	}
+
+	// The claims are back at the head of the queue, in order
+	got, _ := server.List("zeno:queue")
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("expected a, b and c in the queue, got %v", got)
+	}
+
+	if server.Exists("zeno:alive:instance") {
+		t.Fatalf("expected the alive key to be deleted")
+	}
+}
+
+func TestStreamQueueClaims(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	var firstIDs, secondIDs []string
+	first := newStreamQueue(client, "zeno", "first", time.Minute, func() []string { return firstIDs })
+	second := newStreamQueue(client, "zeno", "second", time.Minute, func() []string { return secondIDs })
+
+	if err := first.push(ctx, [][]byte{[]byte("a"), []byte("b"), []byte("c")}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+
+	claims, err := first.pull(ctx, 2)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("expected a and b to be claimed, got %v", got)
+	}
+	firstIDs = []string{claims[0].id, claims[1].id}
+
+	if err := first.ack(ctx, claims[:1]); err != nil {
+		t.Fatalf("ack() error = %v", err)
+	}
+	firstIDs = firstIDs[1:]
+
+	claims, err = second.pull(ctx, 10)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("expected c to be claimed, got %v", got)
+	}
+	secondIDs = []string{claims[0].id}
+
+	// The first instance keeps its claims alive, they aren't stale
+	server.SetTime(time.Now().Add(45 * time.Second))
+	if err := first.maintain(ctx); err != nil {
+		t.Fatalf("maintain() error = %v", err)
+	}
+	server.SetTime(time.Now().Add(90 * time.Second))
+
+	second.lastAutoClaim = time.Time{}
+	claims, err = second.pull(ctx, 10)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if len(claims) != 0 {
+		t.Fatalf("expected nothing to claim, got %v", values(claims))
+	}
+
+	// The first instance stops answering, the second claims its seeds but not its own
+	server.SetTime(time.Now().Add(3 * time.Minute))
+
+	second.lastAutoClaim = time.Time{}
+	claims, err = second.pull(ctx, 10)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("expected b to be claimed from the first instance, got %v", got)
+	}
+}
+
+func TestStreamQueueRelease(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	q := newStreamQueue(client, "zeno", "instance", time.Minute, func() []string { return nil })
+	if err := q.push(ctx, [][]byte{[]byte("a"), []byte("b")}); err != nil {
+		t.Fatalf("push() error = %v", err)
+	}
+	if _, err := q.pull(ctx, 10); err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+
+	// A new run of the same instance gets the seeds it claimed before
+	restarted := newStreamQueue(client, "zeno", "instance", time.Minute, func() []string { return nil })
+	if err := restarted.release(ctx); err != nil {
+		t.Fatalf("release() error = %v", err)
+	}
+
+	restarted.lastAutoClaim = time.Now()
+	claims, err := restarted.pull(ctx, 10)
+	if err != nil {
+		t.Fatalf("pull() error = %v", err)
+	}
+	if got := values(claims); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("expected a and b to be claimed again, got %v", got)
+	}
+}
+
+func TestSeencheckItem(t *testing.T) {
+	config.InitConfig()
+
+	_, client := newTestClient(t)
+	globalRedis = &redis{client: client, claims: make(map[string]claim)}
+	defer func() { globalRedis = nil }()
+
+	newItem := func(id, rawURL string) *models.Item {
+		u := &models.URL{Raw: rawURL}
+		if err := u.Parse(); err != nil {
+			t.Fatalf("unable to parse URL: %v", err)
+		}
+		return models.NewItem(id, u, "")
+	}
+
+	seed := newItem("seed", "https://example.com/")
+	if err := SeencheckItem(seed); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+	if seed.GetStatus() == models.ItemSeen {
+		t.Fatalf("expected the seed not to be seen")
+	}
+
+	asset := newItem("asset", "https://example.com/page")
+	if err := seed.AddChild(asset, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+	if err := SeencheckItem(seed); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+	if asset.GetStatus() == models.ItemSeen {
+		t.Fatalf("expected the asset not to be seen")
+	}
+
+	// Seen by another instance as an asset, it can still be crawled as a seed, once
+	again := newItem("again", "https://example.com/page")
+	if err := SeencheckItem(again); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+	if again.GetStatus() == models.ItemSeen {
+		t.Fatalf("expected the asset to be crawled again as a seed")
+	}
+
+	once := newItem("once", "https://example.com/page")
+	if err := SeencheckItem(once); err != nil {
+		t.Fatalf("SeencheckItem() error = %v", err)
+	}
+	if once.GetStatus() != models.ItemSeen {
+		t.Fatalf("expected the seed to be seen")
+	}
+}
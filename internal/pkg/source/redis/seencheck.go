@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/pkg/models"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// SeencheckItem gets the MaxDepth children of the given item and seencheck them against the hash
+// shared by the instances, like the local seencheck does: a URL seen as an asset can be crawled again as a seed.
+// The items that were seen before will be marked as seen.
+func SeencheckItem(item *models.Item) error {
+	ctx := context.TODO()
+	key := config.Get().RedisKeyPrefix + ":seen"
+	h := fnv.New64a()
+
+	items, err := item.GetNodesAtLevel(item.GetMaxDepth())
+	if err != nil {
+		panic(err)
+	}
+
+	hashes := make([]string, len(items))
+	URLTypes := make([]string, len(items))
+	cmds := make([]*goredis.BoolCmd, len(items))
+
+	pipe := globalRedis.client.Pipeline()
+	for i := range items {
+		h.Reset()
+		h.Write([]byte(items[i].GetURL().String()))
+		hashes[i] = strconv.FormatUint(h.Sum64(), 10)
+
+		if items[i].IsChild() {
+			URLTypes[i] = "asset"
+		} else {
+			URLTypes[i] = "seed"
+		}
+
+		cmds[i] = pipe.HSetNX(ctx, key, hashes[i], URLTypes[i])
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	for i := range items {
+		if cmds[i].Val() {
+			// First time seen
+			continue
+		}
+
+		if URLTypes[i] == "seed" {
+			foundType, err := globalRedis.client.HGet(ctx, key, hashes[i]).Result()
+			if err != nil {
+				return err
+			}
+
+			if foundType == "asset" {
+				// Promotion: allow processing again as seed
+				if err := globalRedis.client.HSet(ctx, key, hashes[i], "seed").Err(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		// All other cases: already seen, skip
+		items[i].SetStatus(models.ItemSeen)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/source"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// redisSource exchanges the URLs of the crawl with Redis
+type redisSource struct{}
+
+// Source returns the URL source backed by Redis, Redis must be started
+func Source() source.URLSource {
+	return redisSource{}
+}
+
+// Batching returns the batch sizes used with Redis
+func (redisSource) Batching() source.Batching {
+	return source.Batching{
+		Pull:       config.Get().RedisBatchSize,
+		Discovered: config.Get().RedisBatchSize,
+	}
+}
+
+// Pull claims seeds. When Redis can't be reached, pulling is paused for longer and longer, up to 30s.
+func (redisSource) Pull(ctx context.Context, n int) ([]*models.Item, error) {
+	r := globalRedis
+
+	claims, err := r.queue.pull(ctx, n)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		r.pullBackoff = min(max(2*r.pullBackoff, time.Second), maxPullBackoff)
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.pullBackoff):
+		}
+
+		return nil, err
+	}
+	r.pullBackoff = 0
+
+	var items []*models.Item
+	var invalid []claim
+
+	r.claimsMu.Lock()
+	for _, c := range claims {
+		message, err := source.DecodeURLMessage([]byte(c.value))
+		if err != nil {
+			logger.Warn("unable to decode seed message, skipping it", "err", err.Error(), "message", c.value)
+			invalid = append(invalid, c)
+			continue
+		}
+
+		newItem := message.Item()
+		r.claims[newItem.GetID()] = c
+		items = append(items, newItem)
+	}
+	r.claimsMu.Unlock()
+
+	if len(invalid) > 0 {
+		if err := r.queue.ack(ctx, invalid); err != nil {
+			logger.Warn("unable to acknowledge invalid seed messages", "err", err.Error())
+		}
+	}
+
+	return items, nil
+}
+
+// Finished acknowledges the seeds, they are released from the claims of the instance
+func (redisSource) Finished(ctx context.Context, items []*models.Item) error {
+	r := globalRedis
+
+	r.claimsMu.Lock()
+	claims := make([]claim, 0, len(items))
+	for _, item := range items {
+		if c, found := r.claims[item.GetID()]; found {
+			claims = append(claims, c)
+		}
+	}
+	r.claimsMu.Unlock()
+
+	if len(claims) == 0 {
+		return nil
+	}
+
+	if err := r.queue.ack(ctx, claims); err != nil {
+		return err
+	}
+
+	r.claimsMu.Lock()
+	for _, item := range items {
+		delete(r.claims, item.GetID())
+	}
+	r.claimsMu.Unlock()
+
+	return nil
+}
+
+// Discovered pushes the outlinks to the queue
+func (redisSource) Discovered(ctx context.Context, items []*models.Item) error {
+	messages := make([][]byte, 0, len(items))
+	for _, item := range items {
+		message, err := source.EncodeURLMessage(item)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	}
+
+	return globalRedis.queue.push(ctx, messages)
+}
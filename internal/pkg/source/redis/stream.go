@@ -0,0 +1,227 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	streamGroup = "zeno"
+	// streamField is the field of the stream entries holding the message
+	streamField = "message"
+)
+
+// streamQueue exchanges the seeds through a stream read by a consumer group: the entries pulled are
+// pending for the instance until acknowledged. The pending entries of the instance are claimed again
+// regularly so that their idle time stays under --redis-claim-timeout, the entries idle for longer
+// belong to an instance that stopped answering and are claimed by the next instance that pulls.
+type streamQueue struct {
+	client       *goredis.Client
+	key          string
+	consumer     string
+	claimTimeout time.Duration
+	claimIDs     func() []string
+
+	mu            sync.Mutex
+	groupReady    bool
+	lastAutoClaim time.Time
+	// historyFrom is the ID after which the entries pending for the instance are read again, if not empty
+	historyFrom string
+}
+
+func newStreamQueue(client *goredis.Client, prefix, instance string, claimTimeout time.Duration, claimIDs func() []string) *streamQueue {
+	return &streamQueue{
+		client:       client,
+		key:          prefix + ":queue",
+		consumer:     instance,
+		claimTimeout: claimTimeout,
+		claimIDs:     claimIDs,
+	}
+}
+
+// ensureGroup creates the consumer group, and the stream, if needed
+func (q *streamQueue) ensureGroup(ctx context.Context) error {
+	if q.groupReady {
+		return nil
+	}
+
+	err := q.client.XGroupCreateMkStream(ctx, q.key, streamGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	q.groupReady = true
+
+	return nil
+}
+
+func (q *streamQueue) pull(ctx context.Context, n int) ([]claim, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	if q.historyFrom != "" {
+		return q.pullHistory(ctx, n)
+	}
+
+	if time.Since(q.lastAutoClaim) >= q.claimTimeout/3 {
+		messages, _, err := q.client.XAutoClaim(ctx, &goredis.XAutoClaimArgs{
+			Stream:   q.key,
+			Group:    streamGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.claimTimeout,
+			Start:    "0-0",
+			Count:    int64(n),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		q.lastAutoClaim = time.Now()
+
+		// The seeds of the instance are stale if their claims couldn't be kept alive, they are still being crawled
+		claimed := make(map[string]struct{})
+		for _, ID := range q.claimIDs() {
+			claimed[ID] = struct{}{}
+		}
+
+		stale := messages[:0]
+		for _, message := range messages {
+			if _, found := claimed[message.ID]; !found {
+				stale = append(stale, message)
+			}
+		}
+
+		if len(stale) > 0 {
+			logger.Info("claimed the stale seeds of other instances", "seeds", len(stale))
+			return toClaims(stale), nil
+		}
+	}
+
+	streams, err := q.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Streams:  []string{q.key, ">"},
+		Count:    int64(n),
+		Block:    time.Second,
+	}).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var claims []claim
+	for _, stream := range streams {
+		claims = append(claims, toClaims(stream.Messages)...)
+	}
+
+	return claims, nil
+}
+
+// pullHistory reads the entries that were pending for the instance when it started
+func (q *streamQueue) pullHistory(ctx context.Context, n int) ([]claim, error) {
+	streams, err := q.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Streams:  []string{q.key, q.historyFrom},
+		Count:    int64(n),
+		Block:    -1,
+	}).Result()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, err
+	}
+
+	var messages []goredis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+
+	if len(messages) < n {
+		q.historyFrom = ""
+	} else {
+		q.historyFrom = messages[len(messages)-1].ID
+	}
+
+	return toClaims(messages), nil
+}
+
+func toClaims(messages []goredis.XMessage) []claim {
+	claims := make([]claim, 0, len(messages))
+	for _, message := range messages {
+		// Entries deleted while pending have no values, they are acknowledged as invalid messages
+		value, _ := message.Values[streamField].(string)
+		claims = append(claims, claim{id: message.ID, value: value})
+	}
+
+	return claims
+}
+
+func (q *streamQueue) ack(ctx context.Context, claims []claim) error {
+	IDs := make([]string, len(claims))
+	for i := range claims {
+		IDs[i] = claims[i].id
+	}
+
+	pipe := q.client.Pipeline()
+	pipe.XAck(ctx, q.key, streamGroup, IDs...)
+	pipe.XDel(ctx, q.key, IDs...)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *streamQueue) push(ctx context.Context, messages [][]byte) error {
+	pipe := q.client.Pipeline()
+	for _, message := range messages {
+		pipe.XAdd(ctx, &goredis.XAddArgs{
+			Stream: q.key,
+			Values: map[string]any{streamField: message},
+		})
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *streamQueue) maintain(ctx context.Context) error {
+	q.mu.Lock()
+	err := q.ensureGroup(ctx)
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	IDs := q.claimIDs()
+	for start := 0; start < len(IDs); start += 100 {
+		err := q.client.XClaimJustID(ctx, &goredis.XClaimArgs{
+			Stream:   q.key,
+			Group:    streamGroup,
+			Consumer: q.consumer,
+			Messages: IDs[start:min(start+100, len(IDs))],
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// release makes the next pull read the entries pending for the instance first, entries can't be
+// given back to the group: the ones of a stopped instance are claimed by the others once stale
+func (q *streamQueue) release(_ context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.historyFrom = "0"
+
+	return nil
+}
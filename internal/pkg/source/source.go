@@ -1 +1,153 @@
+// Package source runs the exchanges between the crawl and the backend it gets its URLs from:
+// URLs are pulled from the backend and sent to the reactor, finished items are reported back
+// and the URLs discovered while crawling are submitted, all asynchronously and in batches.
 package source
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+	"github.com/internetarchive/Zeno/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// URLSource is a backend the crawl gets its URLs from, e.g. Crawl HQ or the local queue
+type URLSource interface {
+	// Pull returns up to n fresh items to crawl, none if there is nothing to crawl for now
+	Pull(ctx context.Context, n int) ([]*models.Item, error)
+	// Finished reports the items that are done, children included
+	Finished(ctx context.Context, items []*models.Item) error
+	// Discovered submits the items discovered while crawling, to be pulled later
+	Discovered(ctx context.Context, items []*models.Item) error
+}
+
+// Batching is the size of the batches exchanged with a source and the number of batches
+// reported or submitted at the same time. Sources implementing `Batching() Batching` set their own,
+// the zero values are replaced by the defaults.
+type Batching struct {
+	Pull            int
+	Finished        int
+	Discovered      int
+	FinishSenders   int
+	DiscoverSenders int
+}
+
+func batchingOf(src URLSource) (batching Batching) {
+	if b, ok := src.(interface{ Batching() Batching }); ok {
+		batching = b.Batching()
+	}
+
+	workers := max(config.Get().WorkersCount, 1)
+
+	if batching.Pull <= 0 {
+		batching.Pull = workers
+	}
+
+	if batching.Finished <= 0 {
+		batching.Finished = workers
+	}
+
+	if batching.Discovered <= 0 {
+		batching.Discovered = 100
+	}
+
+	if batching.FinishSenders <= 0 {
+		batching.FinishSenders = 1
+	}
+
+	if batching.DiscoverSenders <= 0 {
+		batching.DiscoverSenders = 1
+	}
+
+	return batching
+}
+
+type runner struct {
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	src       URLSource
+	batching  Batching
+	finishCh  chan *models.Item
+	produceCh chan *models.Item
+
+	// flushTimeout bounds the time spent reporting the remaining items once the source is stopped
+	flushTimeout time.Duration
+}
+
+var (
+	globalSource *runner
+	once         sync.Once
+	logger       *log.FieldedLogger
+	started      atomic.Bool
+
+	// ErrSourceAlreadyInitialized is the error returned when the source is already started
+	ErrSourceAlreadyInitialized = errors.New("source already initialized")
+)
+
+// Start pulls URLs from src and reports the items received on finishChan and produceChan to it
+func Start(src URLSource, finishChan, produceChan chan *models.Item) error {
+	var done bool
+
+	log.Start()
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "source",
+	})
+
+	stats.Init()
+
+	once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		globalSource = &runner{
+			ctx:       ctx,
+			cancel:    cancel,
+			src:       src,
+			batching:  batchingOf(src),
+			finishCh:  finishChan,
+			produceCh: produceChan,
+
+			flushTimeout: config.Get().SourceFlushTimeout,
+		}
+
+		globalSource.wg.Add(3)
+		go consumer()
+		go producer()
+		go finisher()
+
+		started.Store(true)
+		logger.Info("started")
+
+		done = true
+	})
+
+	if !done {
+		return ErrSourceAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Started returns true once the source is started and the URLs flow to the crawl, until it is stopped
+func Started() bool {
+	return started.Load()
+}
+
+// Stop stops the exchanges with the source and flushes the finished and discovered items left to it,
+// for up to --source-flush-timeout, the items still not sent then are abandoned.
+// Finisher must be stopped first and Reactor must be frozen before stopping the source.
+func Stop() {
+	if globalSource != nil {
+		started.Store(false)
+		globalSource.cancel()
+		globalSource.wg.Wait()
+		globalSource = nil
+		once = sync.Once{}
+		logger.Info("stopped")
+	}
+}
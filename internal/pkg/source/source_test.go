@@ -0,0 +1,230 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/pkg/models"
+)
+
+// memorySource is an in-memory URLSource, the discovered URLs are pulled after the seeds
+type memorySource struct {
+	mu         sync.Mutex
+	queue      []string
+	pulled     int
+	finished   []string
+	discovered []string
+}
+
+func (s *memorySource) Batching() Batching {
+	return Batching{Pull: 2, Finished: 1, Discovered: 1}
+}
+
+func (s *memorySource) Pull(_ context.Context, n int) ([]*models.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*models.Item
+	for ; n > 0 && s.pulled < len(s.queue); n-- {
+		URL := &models.URL{Raw: s.queue[s.pulled]}
+		URL.Parse()
+
+		item := models.NewItem(fmt.Sprintf("item-%d", s.pulled), URL, "")
+		item.SetStatus(models.ItemFresh)
+		item.SetSource(models.ItemSourceQueue)
+
+		items = append(items, item)
+		s.pulled++
+	}
+
+	return items, nil
+}
+
+func (s *memorySource) Finished(_ context.Context, items []*models.Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		s.finished = append(s.finished, item.GetID())
+	}
+
+	return nil
+}
+
+func (s *memorySource) Discovered(_ context.Context, items []*models.Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		s.discovered = append(s.discovered, item.GetURL().Raw)
+		s.queue = append(s.queue, item.GetURL().Raw)
+	}
+
+	return nil
+}
+
+func (s *memorySource) done(expected int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.finished) == expected
+}
+
+func TestSourceE2E(t *testing.T) {
+	config.InitConfig()
+
+	// The second URL can't be parsed, it's finished without being crawled
+	src := &memorySource{queue: []string{"http://example.com/", "http://exa mple.com/", "http://example.org/"}}
+
+	outputChan := make(chan *models.Item)
+	if err := reactor.Start(4, outputChan); err != nil {
+		t.Fatalf("reactor.Start() error = %v", err)
+	}
+	defer reactor.Stop()
+
+	finishChan := make(chan *models.Item)
+	produceChan := make(chan *models.Item)
+	if err := Start(src, finishChan, produceChan); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := Start(src, finishChan, produceChan); err != ErrSourceAlreadyInitialized {
+		t.Errorf("expected ErrSourceAlreadyInitialized starting twice, got %v", err)
+	}
+
+	// Stand in for the rest of the pipeline: example.com/ discovers example.com/page
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item := <-outputChan:
+				if item.GetURL().Raw == "http://example.com/" {
+					discovered := models.NewItem("discovered", &models.URL{Raw: "http://example.com/page", Hops: 1}, "")
+					produceChan <- discovered
+				}
+
+				if err := reactor.MarkAsFinished(item); err != nil {
+					t.Errorf("MarkAsFinished() error = %v", err)
+				}
+
+				finishChan <- item
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !src.done(4) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reactor.Freeze()
+	cancel()
+	wg.Wait()
+	Stop()
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	slices.Sort(src.finished)
+	if want := []string{"item-0", "item-1", "item-2", "item-3"}; !slices.Equal(src.finished, want) {
+		t.Errorf("expected %v finished, got %v", want, src.finished)
+	}
+
+	if want := []string{"http://example.com/page"}; !slices.Equal(src.discovered, want) {
+		t.Errorf("expected %v discovered, got %v", want, src.discovered)
+	}
+
+	if len(reactor.GetStateTable()) != 0 {
+		t.Errorf("expected the reactor to be empty, got %v", reactor.GetStateTable())
+	}
+}
+
+// flakySource fails to take the finished items the first failures times
+type flakySource struct {
+	memorySource
+	failures int
+}
+
+func (s *flakySource) Batching() Batching {
+	return Batching{Finished: 10}
+}
+
+func (s *flakySource) Finished(ctx context.Context, items []*models.Item) error {
+	s.mu.Lock()
+	if s.failures != 0 {
+		s.failures--
+		s.mu.Unlock()
+		return fmt.Errorf("source unreachable")
+	}
+	s.mu.Unlock()
+
+	return s.memorySource.Finished(ctx, items)
+}
+
+func TestStopFlushes(t *testing.T) {
+	config.InitConfig()
+	config.Get().SourceFlushTimeout = 10 * time.Second
+
+	src := &flakySource{failures: 1}
+
+	// The items are buffered, less than a batch: they are only sent because the source is stopped
+	finishChan := make(chan *models.Item, 5)
+	produceChan := make(chan *models.Item)
+	if err := Start(src, finishChan, produceChan); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for i := range 5 {
+		finishChan <- models.NewItem(fmt.Sprintf("item-%d", i), &models.URL{Raw: "http://example.com/"}, "")
+	}
+
+	Stop()
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	slices.Sort(src.finished)
+	if want := []string{"item-0", "item-1", "item-2", "item-3", "item-4"}; !slices.Equal(src.finished, want) {
+		t.Errorf("expected %v finished, got %v", want, src.finished)
+	}
+}
+
+func TestStopAbandonsAfterFlushTimeout(t *testing.T) {
+	config.InitConfig()
+	config.Get().SourceFlushTimeout = 100 * time.Millisecond
+
+	src := &flakySource{failures: -1}
+
+	finishChan := make(chan *models.Item, 1)
+	produceChan := make(chan *models.Item)
+	if err := Start(src, finishChan, produceChan); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	finishChan <- models.NewItem("item-0", &models.URL{Raw: "http://example.com/"}, "")
+
+	start := time.Now()
+	Stop()
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected Stop() to give up after the flush timeout, took %s", elapsed)
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if len(src.finished) != 0 {
+		t.Errorf("expected nothing finished, got %v", src.finished)
+	}
+}
@@ -0,0 +1,48 @@
+package stats
+
+import "sync"
+
+// otherHost is the label of the hosts counted once the cap of the per-host metrics is reached
+const otherHost = "other"
+
+// hostLabels caps the cardinality of the per-host metrics: the first max hosts seen get their own label,
+// the others share the otherHost one. A broad crawl would otherwise create a time series per host.
+type hostLabels struct {
+	mu    sync.Mutex
+	max   int
+	hosts map[string]struct{}
+}
+
+func newHostLabels(max int) *hostLabels {
+	return &hostLabels{
+		max:   max,
+		hosts: make(map[string]struct{}, max),
+	}
+}
+
+// label returns the label the host is counted under
+func (h *hostLabels) label(host string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.hosts[host]; ok {
+		return host
+	}
+
+	if len(h.hosts) >= h.max {
+		return otherHost
+	}
+
+	h.hosts[host] = struct{}{}
+
+	return host
+}
+
+// statusClass returns the class of an HTTP status code, e.g. 2xx for 204
+func statusClass(code string) string {
+	if len(code) != 3 || code[0] < '1' || code[0] > '5' {
+		return "other"
+	}
+
+	return code[:1] + "xx"
+}
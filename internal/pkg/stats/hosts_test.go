@@ -0,0 +1,42 @@
+package stats
+
+import "testing"
+
+func TestHostLabels_Cap(t *testing.T) {
+	h := newHostLabels(2)
+
+	if got := h.label("a.com"); got != "a.com" {
+		t.Errorf("expected a.com, got %s", got)
+	}
+
+	if got := h.label("b.com"); got != "b.com" {
+		t.Errorf("expected b.com, got %s", got)
+	}
+
+	if got := h.label("c.com"); got != otherHost {
+		t.Errorf("expected %s once the cap is reached, got %s", otherHost, got)
+	}
+
+	// The hosts admitted keep their label
+	if got := h.label("a.com"); got != "a.com" {
+		t.Errorf("expected a.com, got %s", got)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[string]string{
+		"200": "2xx",
+		"204": "2xx",
+		"301": "3xx",
+		"404": "4xx",
+		"503": "5xx",
+		"999": "other",
+		"42":  "other",
+	}
+
+	for code, want := range tests {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencyBuckets are the upper bounds in seconds of the request duration buckets, from 10ms to 60s
+var latencyBuckets = prometheus.ExponentialBucketsRange(0.01, 60, 20)
+
+// latency is a histogram of the request durations with the same buckets as the Prometheus one,
+// it gives the percentiles shown in the TUI
+type latency struct {
+	counts []atomic.Uint64 // The last one counts the durations over the last bucket
+	total  atomic.Uint64
+}
+
+func newLatency() *latency {
+	return &latency{counts: make([]atomic.Uint64, len(latencyBuckets)+1)}
+}
+
+func (l *latency) observe(d time.Duration) {
+	seconds := d.Seconds()
+
+	i := 0
+	for i < len(latencyBuckets) && seconds > latencyBuckets[i] {
+		i++
+	}
+
+	l.counts[i].Add(1)
+	l.total.Add(1)
+}
+
+// percentile returns the duration under which p (0 to 1) of the requests are, interpolated within its bucket
+func (l *latency) percentile(p float64) time.Duration {
+	total := l.total.Load()
+	if total == 0 {
+		return 0
+	}
+
+	rank := p * float64(total)
+
+	var cumulated float64
+	for i := range l.counts {
+		count := float64(l.counts[i].Load())
+		if count == 0 || cumulated+count < rank {
+			cumulated += count
+			continue
+		}
+
+		// The durations over the last bucket are reported as the last bucket's bound
+		if i == len(latencyBuckets) {
+			return secondsToDuration(latencyBuckets[i-1])
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBuckets[i-1]
+		}
+
+		return secondsToDuration(lower + (latencyBuckets[i]-lower)*(rank-cumulated)/count)
+	}
+
+	return secondsToDuration(latencyBuckets[len(latencyBuckets)-1])
+}
+
+func (l *latency) reset() {
+	for i := range l.counts {
+		l.counts[i].Store(0)
+	}
+	l.total.Store(0)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+}
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatency_Percentile(t *testing.T) {
+	l := newLatency()
+
+	if got := l.percentile(0.5); got != 0 {
+		t.Errorf("expected 0 without requests, got %s", got)
+	}
+
+	// 90 fast requests and 10 slow ones
+	for range 90 {
+		l.observe(20 * time.Millisecond)
+	}
+	for range 10 {
+		l.observe(5 * time.Second)
+	}
+
+	p50 := l.percentile(0.5)
+	if p50 < 10*time.Millisecond || p50 > 40*time.Millisecond {
+		t.Errorf("expected p50 around 20ms, got %s", p50)
+	}
+
+	p99 := l.percentile(0.99)
+	if p99 < 3*time.Second || p99 > 8*time.Second {
+		t.Errorf("expected p99 around 5s, got %s", p99)
+	}
+
+	if p95 := l.percentile(0.95); p95 < p50 || p95 > p99 {
+		t.Errorf("expected p95 between p50 and p99, got %s", p95)
+	}
+
+	l.reset()
+	if got := l.percentile(0.99); got != 0 {
+		t.Errorf("expected 0 after reset, got %s", got)
+	}
+}
+
+func TestLatency_OverLastBucket(t *testing.T) {
+	l := newLatency()
+	l.observe(2 * time.Minute)
+
+	if got := l.percentile(0.5); got != time.Minute {
+		t.Errorf("expected the last bucket bound, got %s", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 // URLsCrawledIncr increments the URLsCrawled counter by 1.
 func URLsCrawledIncr() {
 	globalStats.URLsCrawled.incr(1)
+	globalStats.URLsCrawledWindow.incr(1)
 	if globalPromStats != nil {
 		globalPromStats.urlCrawled.WithLabelValues(config.Get().Job, hostname, version).Inc()
 	}
@@ -22,9 +23,55 @@ func URLsCrawledIncr() {
 // URLsCrawledGet returns the current value of the URLsCrawled counter.
 func URLsCrawledGet() uint64 { return globalStats.URLsCrawled.get() }
 
+// URLsCrawledTotal returns the total number of URLs crawled since the start.
+func URLsCrawledTotal() uint64 { return globalStats.URLsCrawled.getTotal() }
+
 // URLsCrawledReset resets the URLsCrawled counter to 0.
 func URLsCrawledReset() { globalStats.URLsCrawled.reset() }
 
+// URLsCrawledPerSecondLastMinute returns the mean number of URLs crawled per second over the last minute.
+func URLsCrawledPerSecondLastMinute() float64 { return globalStats.URLsCrawledWindow.perSecond() }
+
+//////////////////
+// ItemsCrawled //
+//////////////////
+
+// ItemsCrawledIncr increments the counter of the crawled items of the given type, seed or asset.
+func ItemsCrawledIncr(itemType string) {
+	if itemType == "asset" {
+		globalStats.AssetsCrawled.incr(1)
+	} else {
+		globalStats.SeedsCrawled.incr(1)
+	}
+}
+
+// SeedsCrawledTotal returns the total number of seeds crawled.
+func SeedsCrawledTotal() uint64 { return globalStats.SeedsCrawled.getTotal() }
+
+// AssetsCrawledTotal returns the total number of assets crawled.
+func AssetsCrawledTotal() uint64 { return globalStats.AssetsCrawled.getTotal() }
+
+/////////////////////////
+//      URLsFailed     //
+/////////////////////////
+
+// URLsFailedIncr increments the URLsFailed counter by 1.
+func URLsFailedIncr() {
+	globalStats.URLsFailed.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.urlFailed.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// URLsFailedGet returns the current value of the URLsFailed counter.
+func URLsFailedGet() uint64 { return globalStats.URLsFailed.get() }
+
+// URLsFailedTotal returns the total number of URLs that failed since the start.
+func URLsFailedTotal() uint64 { return globalStats.URLsFailed.getTotal() }
+
+// URLsFailedReset resets the URLsFailed counter to 0.
+func URLsFailedReset() { globalStats.URLsFailed.reset() }
+
 /////////////////////////
 //    SeedsFinished    //
 /////////////////////////
@@ -40,9 +87,138 @@ func SeedsFinishedIncr() {
 // SeedsFinishedGet returns the current value of the SeedsFinished counter.
 func SeedsFinishedGet() uint64 { return globalStats.SeedsFinished.get() }
 
+// SeedsFinishedTotal returns the total number of seeds finished since the start.
+func SeedsFinishedTotal() uint64 { return globalStats.SeedsFinished.getTotal() }
+
 // SeedsFinishedReset resets the SeedsFinished counter to 0.
 func SeedsFinishedReset() { globalStats.SeedsFinished.reset() }
 
+/////////////////////////
+//   ScreenshotsTaken  //
+/////////////////////////
+
+// ScreenshotsTakenIncr increments the ScreenshotsTaken counter by 1.
+func ScreenshotsTakenIncr() {
+	globalStats.ScreenshotsTaken.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.screenshotsTaken.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// ScreenshotsTakenGet returns the current value of the ScreenshotsTaken counter.
+func ScreenshotsTakenGet() uint64 { return globalStats.ScreenshotsTaken.get() }
+
+// ScreenshotsTakenTotal returns the total number of screenshots taken since the start.
+func ScreenshotsTakenTotal() uint64 { return globalStats.ScreenshotsTaken.getTotal() }
+
+// ScreenshotsTakenReset resets the ScreenshotsTaken counter to 0.
+func ScreenshotsTakenReset() { globalStats.ScreenshotsTaken.reset() }
+
+/////////////////////////
+//       Soft404s      //
+/////////////////////////
+
+// Soft404sIncr increments the Soft404s counter by 1.
+func Soft404sIncr() {
+	globalStats.Soft404s.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.soft404s.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// Soft404sGet returns the current value of the Soft404s counter.
+func Soft404sGet() uint64 { return globalStats.Soft404s.get() }
+
+// Soft404sTotal returns the total number of captures detected as soft 404s since the start.
+func Soft404sTotal() uint64 { return globalStats.Soft404s.getTotal() }
+
+// Soft404sReset resets the Soft404s counter to 0.
+func Soft404sReset() { globalStats.Soft404s.reset() }
+
+///////////////////////////////
+//     TruncatedResponses    //
+///////////////////////////////
+
+// TruncatedResponsesIncr increments the TruncatedResponses counter by 1.
+func TruncatedResponsesIncr() {
+	globalStats.TruncatedResponses.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.truncatedResponses.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// TruncatedResponsesGet returns the current value of the TruncatedResponses counter.
+func TruncatedResponsesGet() uint64 { return globalStats.TruncatedResponses.get() }
+
+// TruncatedResponsesTotal returns the total number of responses truncated since the start.
+func TruncatedResponsesTotal() uint64 { return globalStats.TruncatedResponses.getTotal() }
+
+// TruncatedResponsesReset resets the TruncatedResponses counter to 0.
+func TruncatedResponsesReset() { globalStats.TruncatedResponses.reset() }
+
+///////////////////////////////
+//      ChangedCaptures      //
+///////////////////////////////
+
+// ChangedCapturesIncr increments the ChangedCaptures counter by 1.
+func ChangedCapturesIncr() {
+	globalStats.ChangedCaptures.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.changedCaptures.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// ChangedCapturesGet returns the current value of the ChangedCaptures counter.
+func ChangedCapturesGet() uint64 { return globalStats.ChangedCaptures.get() }
+
+// ChangedCapturesTotal returns the total number of captures whose payload changed since the start.
+func ChangedCapturesTotal() uint64 { return globalStats.ChangedCaptures.getTotal() }
+
+// ChangedCapturesReset resets the ChangedCaptures counter to 0.
+func ChangedCapturesReset() { globalStats.ChangedCaptures.reset() }
+
+///////////////////////////////
+//       URLsRequeued        //
+///////////////////////////////
+
+// URLsRequeuedIncr increments the URLsRequeued counter by 1.
+func URLsRequeuedIncr() {
+	globalStats.URLsRequeued.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.urlsRequeued.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// URLsRequeuedGet returns the current value of the URLsRequeued counter.
+func URLsRequeuedGet() uint64 { return globalStats.URLsRequeued.get() }
+
+// URLsRequeuedTotal returns the total number of URLs requeued after a transient network failure since the start.
+func URLsRequeuedTotal() uint64 { return globalStats.URLsRequeued.getTotal() }
+
+// URLsRequeuedReset resets the URLsRequeued counter to 0.
+func URLsRequeuedReset() { globalStats.URLsRequeued.reset() }
+
+///////////////////////////////
+//      FrontierDequeued     //
+///////////////////////////////
+
+// FrontierDequeuedAdd increments the FrontierDequeued counter by the given value.
+func FrontierDequeuedAdd(value uint64) {
+	globalStats.FrontierDequeued.incr(value)
+	if globalPromStats != nil {
+		globalPromStats.frontierDequeued.WithLabelValues(config.Get().Job, hostname, version).Add(float64(value))
+	}
+}
+
+// FrontierDequeuedGet returns the number of URLs taken out of the local queue in the last second.
+func FrontierDequeuedGet() uint64 { return globalStats.FrontierDequeued.get() }
+
+// FrontierDequeuedTotal returns the total number of URLs taken out of the local queue since the start.
+func FrontierDequeuedTotal() uint64 { return globalStats.FrontierDequeued.getTotal() }
+
+// FrontierDequeuedReset resets the FrontierDequeued counter to 0.
+func FrontierDequeuedReset() { globalStats.FrontierDequeued.reset() }
+
 //////////////////////////
 // PreprocessorRoutines //
 //////////////////////////
@@ -177,6 +353,16 @@ func PausedGet() bool { return globalStats.Paused.Load() }
 // PausedReset resets the Paused flag to false.
 func PausedReset() { globalStats.Paused.Store(false) }
 
+//////////////////////////
+//        Stopping      //
+//////////////////////////
+
+// StoppingSet sets the Stopping flag to true, the crawl is stopping.
+func StoppingSet() { globalStats.Stopping.Store(true) }
+
+// StoppingGet returns the current value of the Stopping flag.
+func StoppingGet() bool { return globalStats.Stopping.Load() }
+
 //////////////////////////
 //   HTTPReturnCodes    //
 //////////////////////////
@@ -195,6 +381,31 @@ func HTTPReturnCodesIncr(key string) {
 		case strings.HasPrefix(key, "5"):
 			globalPromStats.http5xx.WithLabelValues(config.Get().Job, hostname, version).Inc()
 		}
+
+		globalPromStats.httpResponses.WithLabelValues(config.Get().Job, hostname, version, statusClass(key)).Inc()
+		globalPromStats.httpStatusCodes.WithLabelValues(config.Get().Job, hostname, version, key).Inc()
+	}
+}
+
+// HTTPHostResponsesIncr counts a response of the host with the given status code in the per-host metrics, if enabled.
+func HTTPHostResponsesIncr(host, key string) {
+	if globalPromStats == nil || config.Get().PrometheusHosts <= 0 {
+		return
+	}
+
+	globalPromStats.httpHostResponses.WithLabelValues(config.Get().Job, hostname, version, globalPromStats.hostLabels.label(host), statusClass(key)).Inc()
+}
+
+// ProxyRequestIncr counts a request made through a proxy of the pool in the per-proxy metrics, and its failure if it failed.
+// The proxy must be given without its credentials.
+func ProxyRequestIncr(proxy string, failed bool) {
+	if globalPromStats == nil {
+		return
+	}
+
+	globalPromStats.proxyRequests.WithLabelValues(config.Get().Job, hostname, version, proxy).Inc()
+	if failed {
+		globalPromStats.proxyFailures.WithLabelValues(config.Get().Job, hostname, version, proxy).Inc()
 	}
 }
 
@@ -225,6 +436,51 @@ func WarcWritingQueueSizeGet() int64 { return globalStats.WARCWritingQueueSize.L
 // WarcWritingQueueSizeReset resets the WarcWritingQueueSize to 0.
 func WarcWritingQueueSizeReset() { globalStats.WARCWritingQueueSize.Store(0) }
 
+//////////////////////////
+//  FrontierHostQueues  //
+//////////////////////////
+
+// FrontierHostQueuesSet sets the FrontierHostQueues to the given value.
+func FrontierHostQueuesSet(value int64) {
+	globalStats.FrontierHostQueues.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.frontierHostQueues.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// FrontierHostQueuesGet returns the current value of the FrontierHostQueues.
+func FrontierHostQueuesGet() int64 { return globalStats.FrontierHostQueues.Load() }
+
+//////////////////////////
+//   FrontierInMemory   //
+//////////////////////////
+
+// FrontierInMemorySet sets the FrontierInMemory to the given value.
+func FrontierInMemorySet(value int64) {
+	globalStats.FrontierInMemory.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.frontierInMemory.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// FrontierInMemoryGet returns the current value of the FrontierInMemory.
+func FrontierInMemoryGet() int64 { return globalStats.FrontierInMemory.Load() }
+
+//////////////////////////
+//    FrontierOnDisk    //
+//////////////////////////
+
+// FrontierOnDiskSet sets the FrontierOnDisk to the given value.
+func FrontierOnDiskSet(value int64) {
+	globalStats.FrontierOnDisk.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.frontierOnDisk.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// FrontierOnDiskGet returns the current value of the FrontierOnDisk.
+func FrontierOnDiskGet() int64 { return globalStats.FrontierOnDisk.Load() }
+
 //////////////////////////
 //   MeanHTTPRespTime   //
 //////////////////////////
@@ -243,6 +499,23 @@ func MeanHTTPRespTimeGet() float64 { return globalStats.MeanHTTPResponseTime.get
 // MeanHTTPRespTimeReset resets the MeanHTTPRespTime to 0.
 func MeanHTTPRespTimeReset() { globalStats.MeanHTTPResponseTime.reset() }
 
+/////////////////////////
+// HTTPRequestDuration //
+/////////////////////////
+
+// HTTPRequestDurationObserve records the duration of a request of the given item type (seed or asset).
+func HTTPRequestDurationObserve(value time.Duration, itemType string) {
+	globalStats.HTTPRequestDuration.observe(value)
+	if globalPromStats != nil {
+		globalPromStats.httpRequestDuration.WithLabelValues(config.Get().Job, hostname, version, itemType).Observe(value.Seconds())
+	}
+}
+
+// HTTPRequestDurationPercentile returns the duration under which p (0 to 1) of the requests are.
+func HTTPRequestDurationPercentile(p float64) time.Duration {
+	return globalStats.HTTPRequestDuration.percentile(p)
+}
+
 //////////////////////////
 // MeanProcessBodyTime  //
 //////////////////////////
@@ -278,3 +551,245 @@ func MeanWaitOnFeedbackTimeGet() float64 { return globalStats.MeanWaitOnFeedback
 
 // MeanWaitOnFeedbackTimeReset resets the MeanWaitOnFeedbackTime to 0.
 func MeanWaitOnFeedbackTimeReset() { globalStats.MeanWaitOnFeedbackTime.reset() }
+
+////////////////////////////
+// GlobalRateLimitWaiting //
+////////////////////////////
+
+// GlobalRateLimitWaitingIncr increments the GlobalRateLimitWaiting counter by 1.
+func GlobalRateLimitWaitingIncr() {
+	globalStats.GlobalRateLimitWaiting.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.globalRateLimitWaiting.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// GlobalRateLimitWaitingDecr decrements the GlobalRateLimitWaiting counter by 1.
+func GlobalRateLimitWaitingDecr() {
+	globalStats.GlobalRateLimitWaiting.decr(1)
+	if globalPromStats != nil {
+		globalPromStats.globalRateLimitWaiting.WithLabelValues(config.Get().Job, hostname, version).Dec()
+	}
+}
+
+// GlobalRateLimitWaitingGet returns the current value of the GlobalRateLimitWaiting counter.
+func GlobalRateLimitWaitingGet() uint64 { return globalStats.GlobalRateLimitWaiting.get() }
+
+// GlobalRateLimitWaitingReset resets the GlobalRateLimitWaiting counter to 0.
+func GlobalRateLimitWaitingReset() { globalStats.GlobalRateLimitWaiting.reset() }
+
+////////////////////
+// AssetsInFlight //
+////////////////////
+
+// AssetsInFlightIncr increments the AssetsInFlight counter by 1.
+func AssetsInFlightIncr() {
+	globalStats.AssetsInFlight.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.assetsInFlight.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// AssetsInFlightDecr decrements the AssetsInFlight counter by 1.
+func AssetsInFlightDecr() {
+	globalStats.AssetsInFlight.decr(1)
+	if globalPromStats != nil {
+		globalPromStats.assetsInFlight.WithLabelValues(config.Get().Job, hostname, version).Dec()
+	}
+}
+
+// AssetsInFlightGet returns the current value of the AssetsInFlight counter.
+func AssetsInFlightGet() uint64 { return globalStats.AssetsInFlight.get() }
+
+// AssetsInFlightReset resets the AssetsInFlight counter to 0.
+func AssetsInFlightReset() { globalStats.AssetsInFlight.reset() }
+
+//////////////////////////
+// HQUnreachableSeconds //
+//////////////////////////
+
+// HQUnreachableSecondsSet sets the HQUnreachableSeconds to the given value.
+func HQUnreachableSecondsSet(value int64) {
+	globalStats.HQUnreachableSeconds.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.hqUnreachableSeconds.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// HQUnreachableSecondsGet returns the current value of the HQUnreachableSeconds.
+func HQUnreachableSecondsGet() int64 { return globalStats.HQUnreachableSeconds.Load() }
+
+////////////////////
+// HQSpooledItems //
+////////////////////
+
+// HQSpooledItemsSet sets the HQSpooledItems to the given value.
+func HQSpooledItemsSet(value int64) {
+	globalStats.HQSpooledItems.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.hqSpooledItems.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// HQSpooledItemsGet returns the current value of the HQSpooledItems.
+func HQSpooledItemsGet() int64 { return globalStats.HQSpooledItems.Load() }
+
+/////////////////////
+// OutlinksSpilled //
+/////////////////////
+
+// OutlinksSpilledSet sets the OutlinksSpilled to the given value.
+func OutlinksSpilledSet(value int64) {
+	globalStats.OutlinksSpilled.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.outlinksSpilled.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// OutlinksSpilledGet returns the current value of the OutlinksSpilled.
+func OutlinksSpilledGet() int64 { return globalStats.OutlinksSpilled.Load() }
+
+/////////////////////
+// CrawlLimitItems //
+/////////////////////
+
+// CrawlLimitItemsSet sets the CrawlLimitItems to the given value.
+func CrawlLimitItemsSet(value int64) {
+	globalStats.CrawlLimitItems.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.crawlLimitItems.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// CrawlLimitItemsGet returns the current value of the CrawlLimitItems.
+func CrawlLimitItemsGet() int64 { return globalStats.CrawlLimitItems.Load() }
+
+/////////////////////
+// FrontierPending //
+/////////////////////
+
+// FrontierPendingSet sets the FrontierPending to the given value.
+func FrontierPendingSet(value int64) {
+	globalStats.FrontierPending.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.frontierPending.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// FrontierPendingGet returns the current value of the FrontierPending.
+func FrontierPendingGet() int64 { return globalStats.FrontierPending.Load() }
+
+///////////////////
+// ActiveWorkers //
+///////////////////
+
+// ActiveWorkersIncr increments the ActiveWorkers counter by 1.
+func ActiveWorkersIncr() {
+	globalStats.ActiveWorkers.incr(1)
+	if globalPromStats != nil {
+		globalPromStats.activeWorkers.WithLabelValues(config.Get().Job, hostname, version).Inc()
+	}
+}
+
+// ActiveWorkersDecr decrements the ActiveWorkers counter by 1.
+func ActiveWorkersDecr() {
+	globalStats.ActiveWorkers.decr(1)
+	if globalPromStats != nil {
+		globalPromStats.activeWorkers.WithLabelValues(config.Get().Job, hostname, version).Dec()
+	}
+}
+
+// ActiveWorkersGet returns the current value of the ActiveWorkers counter.
+func ActiveWorkersGet() uint64 { return globalStats.ActiveWorkers.get() }
+
+//////////////////
+// StuckWorkers //
+//////////////////
+
+// StuckWorkersSet sets the StuckWorkers to the given value.
+func StuckWorkersSet(value int64) {
+	globalStats.StuckWorkers.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.stuckWorkers.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// StuckWorkersGet returns the current value of the StuckWorkers.
+func StuckWorkersGet() int64 { return globalStats.StuckWorkers.Load() }
+
+//////////////////////
+// WARCBytesWritten //
+//////////////////////
+
+// WARCBytesWrittenSet sets the WARCBytesWritten to the given value.
+func WARCBytesWrittenSet(value int64) {
+	globalStats.WARCBytesWritten.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.warcBytesWritten.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// WARCBytesWrittenGet returns the current value of the WARCBytesWritten.
+func WARCBytesWrittenGet() int64 { return globalStats.WARCBytesWritten.Load() }
+
+/////////////////////
+// WARCBytesOnDisk //
+/////////////////////
+
+// WARCBytesOnDiskSet sets the WARCBytesOnDisk to the given value.
+func WARCBytesOnDiskSet(value int64) {
+	globalStats.WARCBytesOnDisk.Store(value)
+	if globalPromStats != nil {
+		globalPromStats.warcBytesOnDisk.WithLabelValues(config.Get().Job, hostname, version).Set(float64(value))
+	}
+}
+
+// WARCBytesOnDiskGet returns the current value of the WARCBytesOnDisk.
+func WARCBytesOnDiskGet() int64 { return globalStats.WARCBytesOnDisk.Load() }
+
+///////////////
+// DiskSpace //
+///////////////
+
+// DiskSpaceSet sets the total and free space in bytes of the disk of the job.
+func DiskSpaceSet(total, free uint64) {
+	globalStats.DiskTotal.Store(int64(total))
+	globalStats.DiskFree.Store(int64(free))
+}
+
+// DiskFreeSet sets the free space in bytes of the disk of a path watched by the disk watcher.
+// It is only exported to Prometheus.
+func DiskFreeSet(path string, free uint64) {
+	if globalPromStats != nil {
+		globalPromStats.diskFree.WithLabelValues(config.Get().Job, hostname, version, path).Set(float64(free))
+	}
+}
+
+// DiskSpaceGet returns the last known total and free space in bytes of the disk of the job.
+func DiskSpaceGet() (total, free int64) {
+	return globalStats.DiskTotal.Load(), globalStats.DiskFree.Load()
+}
+
+////////////////////////
+// LogLinesSuppressed //
+////////////////////////
+
+// LogLinesSuppressedIncr increments the number of log lines of the level suppressed by the log sampling.
+// It is only exported to Prometheus, the logging system starts before the stats.
+func LogLinesSuppressedIncr(level string) {
+	if globalPromStats != nil {
+		globalPromStats.logLinesSuppressed.WithLabelValues(config.Get().Job, hostname, version, level).Inc()
+	}
+}
+
+///////////////////////
+// LogRecordsDropped //
+///////////////////////
+
+// LogRecordsDroppedIncr increments the number of log records of the level that couldn't be indexed in Elasticsearch.
+// It is only exported to Prometheus, the logging system starts before the stats.
+func LogRecordsDroppedIncr(level string) {
+	if globalPromStats != nil {
+		globalPromStats.logRecordsDropped.WithLabelValues(config.Get().Job, hostname, version, level).Inc()
+	}
+}
@@ -11,7 +11,14 @@ import (
 
 type prometheusStats struct {
 	urlCrawled             *prometheus.CounterVec
+	urlFailed              *prometheus.CounterVec
 	finishedSeeds          *prometheus.CounterVec
+	screenshotsTaken       *prometheus.CounterVec
+	soft404s               *prometheus.CounterVec
+	truncatedResponses     *prometheus.CounterVec
+	changedCaptures        *prometheus.CounterVec
+	urlsRequeued           *prometheus.CounterVec
+	frontierDequeued       *prometheus.CounterVec
 	preprocessorRoutines   *prometheus.GaugeVec
 	archiverRoutines       *prometheus.GaugeVec
 	postprocessorRoutines  *prometheus.GaugeVec
@@ -24,7 +31,31 @@ type prometheusStats struct {
 	meanHTTPRespTime       *prometheus.HistogramVec // in ns
 	meanProcessBodyTime    *prometheus.HistogramVec // in ns
 	meanWaitOnFeedbackTime *prometheus.HistogramVec // in ns
+	httpRequestDuration    *prometheus.HistogramVec // in s
 	warcWritingQueueSize   *prometheus.GaugeVec
+	frontierHostQueues     *prometheus.GaugeVec
+	frontierInMemory       *prometheus.GaugeVec
+	frontierOnDisk         *prometheus.GaugeVec
+	globalRateLimitWaiting *prometheus.GaugeVec
+	assetsInFlight         *prometheus.GaugeVec
+	hqUnreachableSeconds   *prometheus.GaugeVec
+	hqSpooledItems         *prometheus.GaugeVec
+	outlinksSpilled        *prometheus.GaugeVec
+	crawlLimitItems        *prometheus.GaugeVec
+	httpResponses          *prometheus.CounterVec
+	httpStatusCodes        *prometheus.CounterVec
+	httpHostResponses      *prometheus.CounterVec
+	proxyRequests          *prometheus.CounterVec
+	proxyFailures          *prometheus.CounterVec
+	hostLabels             *hostLabels
+	frontierPending        *prometheus.GaugeVec
+	activeWorkers          *prometheus.GaugeVec
+	stuckWorkers           *prometheus.GaugeVec
+	warcBytesWritten       *prometheus.GaugeVec
+	warcBytesOnDisk        *prometheus.GaugeVec
+	diskFree               *prometheus.GaugeVec
+	logLinesSuppressed     *prometheus.CounterVec
+	logRecordsDropped      *prometheus.CounterVec
 }
 
 func newPrometheusStats() *prometheusStats {
@@ -33,10 +64,38 @@ func newPrometheusStats() *prometheusStats {
 			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "url_crawled", Help: "Total number of URLs crawled"},
 			[]string{"project", "hostname", "version"},
 		),
+		urlFailed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "url_failed", Help: "Total number of URLs that failed to be archived"},
+			[]string{"project", "hostname", "version"},
+		),
 		finishedSeeds: prometheus.NewCounterVec(
 			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "finished_seeds", Help: "Total number of finished seeds"},
 			[]string{"project", "hostname", "version"},
 		),
+		screenshotsTaken: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "screenshots_taken", Help: "Total number of screenshots taken"},
+			[]string{"project", "hostname", "version"},
+		),
+		soft404s: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "soft_404", Help: "Total number of captures detected as soft 404s"},
+			[]string{"project", "hostname", "version"},
+		),
+		truncatedResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "truncated_responses", Help: "Total number of responses truncated at --max-response-body-size"},
+			[]string{"project", "hostname", "version"},
+		),
+		changedCaptures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "changed_captures", Help: "Total number of captures whose payload changed since the previous run, with --track-changes"},
+			[]string{"project", "hostname", "version"},
+		),
+		urlsRequeued: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "urls_requeued", Help: "Total number of URLs requeued after a transient network failure"},
+			[]string{"project", "hostname", "version"},
+		),
+		frontierDequeued: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "frontier_dequeued", Help: "Total number of URLs taken out of the local queue"},
+			[]string{"project", "hostname", "version"},
+		),
 		preprocessorRoutines: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "preprocessor_routines", Help: "Number of preprocessor routines"},
 			[]string{"project", "hostname", "version"},
@@ -85,16 +144,116 @@ func newPrometheusStats() *prometheusStats {
 			prometheus.HistogramOpts{Name: config.Get().PrometheusPrefix + "mean_wait_on_feedback_time", Help: "Mean time in ns to wait on WARC writing feedback signal", Buckets: prometheus.ExponentialBucketsRange(float64(time.Microsecond), float64(10*time.Second), 50)},
 			[]string{"project", "hostname", "version"},
 		),
+		httpRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: config.Get().PrometheusPrefix + "http_request_duration_seconds", Help: "Duration in seconds of the requests, from sending them to the end of their body, politeness delays excluded", Buckets: latencyBuckets},
+			[]string{"project", "hostname", "version", "type"},
+		),
 		warcWritingQueueSize: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "warc_writing_queue_size", Help: "Size of the WARC writing queue"},
 			[]string{"project", "hostname", "version"},
 		),
+		frontierHostQueues: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "frontier_host_queues", Help: "Number of hosts with pending URLs in the local queue rotation"},
+			[]string{"project", "hostname", "version"},
+		),
+		frontierInMemory: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "frontier_in_memory", Help: "Number of pending URLs of the local queue held in memory"},
+			[]string{"project", "hostname", "version"},
+		),
+		frontierOnDisk: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "frontier_on_disk", Help: "Number of pending URLs of the local queue only stored on disk"},
+			[]string{"project", "hostname", "version"},
+		),
+		globalRateLimitWaiting: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "global_rate_limit_waiting", Help: "Number of requests waiting on the global rate limit"},
+			[]string{"project", "hostname", "version"},
+		),
+		assetsInFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "assets_in_flight", Help: "Number of assets being captured"},
+			[]string{"project", "hostname", "version"},
+		),
+		hqUnreachableSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "hq_unreachable_seconds", Help: "Number of seconds crawl HQ has been unreachable for, 0 if it is reachable"},
+			[]string{"project", "hostname", "version"},
+		),
+		hqSpooledItems: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "hq_spooled_items", Help: "Number of URLs spooled on disk waiting for crawl HQ to be reachable"},
+			[]string{"project", "hostname", "version"},
+		),
+		outlinksSpilled: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "outlinks_spilled", Help: "Number of outlinks spilled on disk until the memory pressure drops"},
+			[]string{"project", "hostname", "version"},
+		),
+		crawlLimitItems: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "crawl_limit_items", Help: "Number of URLs crawled counted against --max-crawled-items"},
+			[]string{"project", "hostname", "version"},
+		),
+		httpResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "http_responses", Help: "Number of HTTP responses by status class"},
+			[]string{"project", "hostname", "version", "class"},
+		),
+		httpStatusCodes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "http_status_codes", Help: "Number of HTTP responses by status code"},
+			[]string{"project", "hostname", "version", "code"},
+		),
+		httpHostResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "http_host_responses", Help: "Number of HTTP responses by host and status class, the hosts over --prometheus-hosts are counted under \"other\""},
+			[]string{"project", "hostname", "version", "host", "class"},
+		),
+		hostLabels: newHostLabels(config.Get().PrometheusHosts),
+		proxyRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "proxy_requests", Help: "Number of requests made through each proxy of the pool"},
+			[]string{"project", "hostname", "version", "proxy"},
+		),
+		proxyFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "proxy_failures", Help: "Number of requests that failed through each proxy of the pool"},
+			[]string{"project", "hostname", "version", "proxy"},
+		),
+		frontierPending: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "frontier_pending", Help: "Number of pending URLs in the local queue"},
+			[]string{"project", "hostname", "version"},
+		),
+		activeWorkers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "active_workers", Help: "Number of archiver workers archiving a seed"},
+			[]string{"project", "hostname", "version"},
+		),
+		stuckWorkers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "stuck_workers", Help: "Number of archiver workers in the same state for longer than --worker-stuck-threshold"},
+			[]string{"project", "hostname", "version"},
+		),
+		warcBytesWritten: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "warc_bytes_written", Help: "Number of bytes written to the WARC files"},
+			[]string{"project", "hostname", "version"},
+		),
+		warcBytesOnDisk: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "warc_bytes_on_disk", Help: "Size in bytes on disk of the WARC files of the job, counted against --max-total-warc-size"},
+			[]string{"project", "hostname", "version"},
+		),
+		diskFree: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: config.Get().PrometheusPrefix + "disk_free_bytes", Help: "Free space in bytes of the disks watched for --min-space-required, by path"},
+			[]string{"project", "hostname", "version", "path"},
+		),
+		logLinesSuppressed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "log_lines_suppressed", Help: "Total number of log lines suppressed by the log sampling, by level"},
+			[]string{"project", "hostname", "version", "level"},
+		),
+		logRecordsDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "log_elasticsearch_dropped", Help: "Total number of log records not indexed in Elasticsearch, by level"},
+			[]string{"project", "hostname", "version", "level"},
+		),
 	}
 }
 
 func registerPrometheusMetrics() {
 	prometheus.MustRegister(globalPromStats.urlCrawled)
+	prometheus.MustRegister(globalPromStats.urlFailed)
 	prometheus.MustRegister(globalPromStats.finishedSeeds)
+	prometheus.MustRegister(globalPromStats.screenshotsTaken)
+	prometheus.MustRegister(globalPromStats.soft404s)
+	prometheus.MustRegister(globalPromStats.truncatedResponses)
+	prometheus.MustRegister(globalPromStats.changedCaptures)
+	prometheus.MustRegister(globalPromStats.urlsRequeued)
+	prometheus.MustRegister(globalPromStats.frontierDequeued)
 	prometheus.MustRegister(globalPromStats.preprocessorRoutines)
 	prometheus.MustRegister(globalPromStats.archiverRoutines)
 	prometheus.MustRegister(globalPromStats.postprocessorRoutines)
@@ -107,7 +266,32 @@ func registerPrometheusMetrics() {
 	prometheus.MustRegister(globalPromStats.meanHTTPRespTime)
 	prometheus.MustRegister(globalPromStats.meanProcessBodyTime)
 	prometheus.MustRegister(globalPromStats.warcWritingQueueSize)
+	prometheus.MustRegister(globalPromStats.frontierHostQueues)
+	prometheus.MustRegister(globalPromStats.frontierInMemory)
+	prometheus.MustRegister(globalPromStats.frontierOnDisk)
 	prometheus.MustRegister(globalPromStats.meanWaitOnFeedbackTime)
+	prometheus.MustRegister(globalPromStats.httpRequestDuration)
+	prometheus.MustRegister(globalPromStats.globalRateLimitWaiting)
+	prometheus.MustRegister(globalPromStats.assetsInFlight)
+	prometheus.MustRegister(globalPromStats.hqUnreachableSeconds)
+	prometheus.MustRegister(globalPromStats.hqSpooledItems)
+	prometheus.MustRegister(globalPromStats.outlinksSpilled)
+	prometheus.MustRegister(globalPromStats.crawlLimitItems)
+	prometheus.MustRegister(globalPromStats.httpResponses)
+	prometheus.MustRegister(globalPromStats.httpStatusCodes)
+	if config.Get().PrometheusHosts > 0 {
+		prometheus.MustRegister(globalPromStats.httpHostResponses)
+	}
+	prometheus.MustRegister(globalPromStats.proxyRequests)
+	prometheus.MustRegister(globalPromStats.proxyFailures)
+	prometheus.MustRegister(globalPromStats.frontierPending)
+	prometheus.MustRegister(globalPromStats.activeWorkers)
+	prometheus.MustRegister(globalPromStats.stuckWorkers)
+	prometheus.MustRegister(globalPromStats.warcBytesWritten)
+	prometheus.MustRegister(globalPromStats.warcBytesOnDisk)
+	prometheus.MustRegister(globalPromStats.diskFree)
+	prometheus.MustRegister(globalPromStats.logLinesSuppressed)
+	prometheus.MustRegister(globalPromStats.logRecordsDropped)
 }
 
 func PrometheusHandler() http.Handler {
@@ -12,17 +12,45 @@ import (
 
 type stats struct {
 	URLsCrawled            *rate
+	URLsCrawledWindow      *window
+	SeedsCrawled           *rate
+	AssetsCrawled          *rate
+	URLsFailed             *rate
 	SeedsFinished          *rate
+	ScreenshotsTaken       *rate
+	Soft404s               *rate
+	TruncatedResponses     *rate
+	ChangedCaptures        *rate
+	URLsRequeued           *rate
+	FrontierDequeued       *rate
 	PreprocessorRoutines   *counter
 	ArchiverRoutines       *counter
 	PostprocessorRoutines  *counter
 	FinisherRoutines       *counter
 	Paused                 atomic.Bool
+	Stopping               atomic.Bool
 	HTTPReturnCodes        *rateBucket
 	MeanHTTPResponseTime   *mean // in ms
 	MeanProcessBodyTime    *mean // in ms
 	MeanWaitOnFeedbackTime *mean // in ms
+	HTTPRequestDuration    *latency
 	WARCWritingQueueSize   atomic.Int64
+	FrontierHostQueues     atomic.Int64
+	FrontierInMemory       atomic.Int64
+	FrontierOnDisk         atomic.Int64
+	GlobalRateLimitWaiting *counter
+	AssetsInFlight         *counter
+	HQUnreachableSeconds   atomic.Int64
+	HQSpooledItems         atomic.Int64
+	OutlinksSpilled        atomic.Int64
+	CrawlLimitItems        atomic.Int64
+	FrontierPending        atomic.Int64
+	ActiveWorkers          *counter
+	StuckWorkers           atomic.Int64
+	WARCBytesWritten       atomic.Int64
+	WARCBytesOnDisk        atomic.Int64
+	DiskTotal              atomic.Int64
+	DiskFree               atomic.Int64
 }
 
 var (
@@ -40,7 +68,17 @@ func Init() error {
 	doOnce.Do(func() {
 		globalStats = &stats{
 			URLsCrawled:            &rate{},
+			URLsCrawledWindow:      &window{},
+			SeedsCrawled:           &rate{},
+			AssetsCrawled:          &rate{},
+			URLsFailed:             &rate{},
 			SeedsFinished:          &rate{},
+			ScreenshotsTaken:       &rate{},
+			Soft404s:               &rate{},
+			TruncatedResponses:     &rate{},
+			ChangedCaptures:        &rate{},
+			URLsRequeued:           &rate{},
+			FrontierDequeued:       &rate{},
 			PreprocessorRoutines:   &counter{},
 			ArchiverRoutines:       &counter{},
 			PostprocessorRoutines:  &counter{},
@@ -49,6 +87,10 @@ func Init() error {
 			MeanHTTPResponseTime:   &mean{},
 			MeanProcessBodyTime:    &mean{},
 			MeanWaitOnFeedbackTime: &mean{},
+			HTTPRequestDuration:    newLatency(),
+			GlobalRateLimitWaiting: &counter{},
+			AssetsInFlight:         &counter{},
+			ActiveWorkers:          &counter{},
 		}
 
 		if config.Get() != nil && config.Get().Prometheus {
@@ -83,6 +125,9 @@ func Init() error {
 
 func Reset() {
 	globalStats.URLsCrawled.reset()
+	globalStats.URLsCrawledWindow.reset()
+	globalStats.SeedsCrawled.reset()
+	globalStats.AssetsCrawled.reset()
 	globalStats.SeedsFinished.reset()
 	globalStats.PreprocessorRoutines.reset()
 	globalStats.ArchiverRoutines.reset()
@@ -92,6 +137,10 @@ func Reset() {
 	globalStats.MeanHTTPResponseTime.reset()
 	globalStats.MeanProcessBodyTime.reset()
 	globalStats.MeanWaitOnFeedbackTime.reset()
+	globalStats.HTTPRequestDuration.reset()
+	globalStats.GlobalRateLimitWaiting.reset()
+	globalStats.AssetsInFlight.reset()
+	globalStats.ActiveWorkers.reset()
 }
 
 // GetMapTUI returns a map of the current stats.
@@ -100,6 +149,7 @@ func GetMapTUI() map[string]interface{} {
 	return map[string]interface{}{
 		"URL/s":                   globalStats.URLsCrawled.get(),
 		"Total URL crawled":       globalStats.URLsCrawled.getTotal(),
+		"URL/s over 1m":           globalStats.URLsCrawledWindow.perSecond(),
 		"Finished seeds":          globalStats.SeedsFinished.getTotal(),
 		"Preprocessor routines":   globalStats.PreprocessorRoutines.get(),
 		"Archiver routines":       globalStats.ArchiverRoutines.get(),
@@ -111,6 +161,29 @@ func GetMapTUI() map[string]interface{} {
 		"HTTP 4xx/s":              bucketSum(globalStats.HTTPReturnCodes.getFiltered("4*")),
 		"HTTP 5xx/s":              bucketSum(globalStats.HTTPReturnCodes.getFiltered("5*")),
 		"Mean HTTP response time": globalStats.MeanHTTPResponseTime.get(),
+		"Request time p50":        globalStats.HTTPRequestDuration.percentile(0.50),
+		"Request time p95":        globalStats.HTTPRequestDuration.percentile(0.95),
+		"Request time p99":        globalStats.HTTPRequestDuration.percentile(0.99),
 		"WARC writing queue size": globalStats.WARCWritingQueueSize.Load(),
+		"Frontier host queues":    globalStats.FrontierHostQueues.Load(),
+		"Frontier in memory":      globalStats.FrontierInMemory.Load(),
+		"Frontier on disk":        globalStats.FrontierOnDisk.Load(),
+		"Frontier pending":        globalStats.FrontierPending.Load(),
+		"Active workers":          globalStats.ActiveWorkers.get(),
+		"Stuck workers":           globalStats.StuckWorkers.Load(),
+		"WARC bytes written":      globalStats.WARCBytesWritten.Load(),
+		"WARC bytes on disk":      globalStats.WARCBytesOnDisk.Load(),
+		"Disk free":               globalStats.DiskFree.Load(),
+		"Frontier dequeued/s":     globalStats.FrontierDequeued.get(),
+		"Global rate limited":     globalStats.GlobalRateLimitWaiting.get(),
+		"Assets in flight":        globalStats.AssetsInFlight.get(),
+		"Soft 404s":               globalStats.Soft404s.getTotal(),
+		"Truncated responses":     globalStats.TruncatedResponses.getTotal(),
+		"Changed captures":        globalStats.ChangedCaptures.getTotal(),
+		"URLs requeued":           globalStats.URLsRequeued.getTotal(),
+		"HQ unreachable seconds":  globalStats.HQUnreachableSeconds.Load(),
+		"HQ spooled items":        globalStats.HQSpooledItems.Load(),
+		"Outlinks spilled":        globalStats.OutlinksSpilled.Load(),
+		"Crawl limit items":       globalStats.CrawlLimitItems.Load(),
 	}
 }
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+const windowSeconds = 60
+
+// window counts events over the last minute with one bucket per second, a bucket is
+// recycled when its second comes around again.
+type window struct {
+	mu      sync.Mutex
+	counts  [windowSeconds]uint64
+	seconds [windowSeconds]int64
+}
+
+func (w *window) incr(step uint64) {
+	w.incrAt(time.Now(), step)
+}
+
+func (w *window) incrAt(now time.Time, step uint64) {
+	second := now.Unix()
+	i := second % windowSeconds
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seconds[i] != second {
+		w.seconds[i] = second
+		w.counts[i] = 0
+	}
+	w.counts[i] += step
+}
+
+// perSecond returns the mean number of events per second over the last minute
+func (w *window) perSecond() float64 {
+	return w.perSecondAt(time.Now())
+}
+
+func (w *window) perSecondAt(now time.Time) float64 {
+	second := now.Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var sum uint64
+	for i := range w.counts {
+		if age := second - w.seconds[i]; age >= 0 && age < windowSeconds {
+			sum += w.counts[i]
+		}
+	}
+
+	return float64(sum) / windowSeconds
+}
+
+func (w *window) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.counts = [windowSeconds]uint64{}
+	w.seconds = [windowSeconds]int64{}
+}
@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowPerSecond(t *testing.T) {
+	w := &window{}
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 30; i++ {
+		w.incrAt(start.Add(time.Duration(i)*time.Second), 4)
+	}
+
+	if got := w.perSecondAt(start.Add(29 * time.Second)); got != 2 {
+		t.Errorf("expected 2 per second over the last minute, got %v", got)
+	}
+
+	// The first 20 seconds are out of the window
+	if got := w.perSecondAt(start.Add(79 * time.Second)); got != 40.0/60 {
+		t.Errorf("expected %v per second over the last minute, got %v", 40.0/60, got)
+	}
+
+	if got := w.perSecondAt(start.Add(5 * time.Minute)); got != 0 {
+		t.Errorf("expected 0 per second once the window is over, got %v", got)
+	}
+}
+
+func TestWindowRecyclesBuckets(t *testing.T) {
+	w := &window{}
+	start := time.Unix(1000, 0)
+
+	w.incrAt(start, 10)
+	w.incrAt(start.Add(windowSeconds*time.Second), 1)
+
+	if got := w.perSecondAt(start.Add(windowSeconds * time.Second)); got != 1.0/60 {
+		t.Errorf("expected the recycled bucket to only count the new events, got %v", got)
+	}
+
+	w.reset()
+	if got := w.perSecondAt(start.Add(windowSeconds * time.Second)); got != 0 {
+		t.Errorf("expected 0 after reset, got %v", got)
+	}
+}
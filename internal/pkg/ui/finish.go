@@ -0,0 +1,15 @@
+package ui
+
+import "github.com/internetarchive/Zeno/internal/pkg/controler"
+
+// finishMonitor stops the pipeline and the TUI when the crawl is over by itself or a stop
+// is requested through the API. It isn't tracked by the wait group as stop waits for it.
+func (ui *UI) finishMonitor() {
+	select {
+	case <-ui.ctx.Done():
+	case <-controler.Finished():
+		ui.stop()
+	case mode := <-controler.StopRequested():
+		ui.stopWithMode(mode)
+	}
+}
@@ -10,7 +10,8 @@ import (
 var menuModalPageName = "menuModal"
 
 func (ui *UI) showMenuModal() {
-	isPaused := pause.IsPaused()
+	// The pauses of the watchers are only released by them
+	isPaused := pause.PausedByUser()
 	pauseButtonLabel := "Pause"
 	if isPaused {
 		pauseButtonLabel = "Unpause"
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/internetarchive/Zeno/internal/pkg/api"
 	"github.com/internetarchive/Zeno/internal/pkg/controler"
 	"github.com/internetarchive/Zeno/internal/pkg/log"
 	"github.com/internetarchive/Zeno/internal/pkg/log/ringbuffer"
@@ -144,6 +145,7 @@ func (ui *UI) Start() error {
 	go ui.updateStatsLoop()
 	go ui.readLogsLoop()
 	go ui.pauseMonitor()
+	go ui.finishMonitor()
 
 	// Run tview (blocking).
 	return ui.app.Run()
@@ -151,13 +153,18 @@ func (ui *UI) Start() error {
 
 // stop is called from Ctrl+C or Stop button: stops pipeline + TUI.
 func (ui *UI) stop() {
+	ui.stopWithMode(api.StopGraceful)
+}
+
+// stopWithMode stops the pipeline in the given mode, graceful or now, and the TUI.
+func (ui *UI) stopWithMode(mode string) {
 	// Show a "Stopping..." modal
 	stoppingModal := tview.NewModal().SetText("Stopping...")
 	ui.pages.AddPage("stoppingModal", stoppingModal, true, true)
 	ui.app.Draw()
 
 	// Stop the pipeline
-	controler.Stop()
+	controler.StopWithMode(mode)
 
 	// Cancel all UI loops
 	ui.cancel()
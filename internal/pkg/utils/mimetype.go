@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/gabriel-vasile/mimetype"
+import (
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
 
 // IsMIMETypeInHierarchy recursively checks if the MIME type and its parents match the expected MIME type
 func IsMIMETypeInHierarchy(m *mimetype.MIME, expectedMIME string) bool {
@@ -15,3 +19,39 @@ func IsMIMETypeInHierarchy(m *mimetype.MIME, expectedMIME string) bool {
 
 	return IsMIMETypeInHierarchy(parent, expectedMIME)
 }
+
+// MatchMIMEType returns true if the MIME type, or one of its parents, matches one of the patterns.
+// Patterns are MIME types (e.g. image/svg+xml) or wildcards on the subtype (e.g. text/*).
+func MatchMIMEType(m *mimetype.MIME, patterns []string) bool {
+	for ; m != nil; m = m.Parent() {
+		if MatchContentType(m.String(), patterns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchContentType returns true if the Content-Type (parameters are ignored) matches one of the patterns.
+// Patterns are MIME types (e.g. image/svg+xml) or wildcards on the subtype (e.g. text/*).
+func MatchContentType(contentType string, patterns []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+		if prefix, found := strings.CutSuffix(pattern, "/*"); found {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+
+	return false
+}
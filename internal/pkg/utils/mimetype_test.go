@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+func TestMatchContentType(t *testing.T) {
+	patterns := []string{"text/*", "image/svg+xml", "Application/XHTML+XML"}
+
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"text/html", true},
+		{"text/css; charset=utf-8", true},
+		{"TEXT/PLAIN", true},
+		{"image/svg+xml", true},
+		{"application/xhtml+xml; charset=utf-8", true},
+		{"image/png", false},
+		{"application/json", false},
+		{"texts/html", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchContentType(tt.contentType, patterns); got != tt.want {
+			t.Errorf("MatchContentType(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMIMEType(t *testing.T) {
+	// JSON is detected as application/json, which has text/plain as parent
+	m := mimetype.Detect([]byte(`{"url": "https://example.com"}`))
+
+	if !MatchMIMEType(m, []string{"text/*"}) {
+		t.Errorf("expected %s to match text/* through its parents", m.String())
+	}
+
+	if MatchMIMEType(m, []string{"image/*"}) {
+		t.Errorf("expected %s to not match image/*", m.String())
+	}
+
+	png := mimetype.Detect([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"))
+	if MatchMIMEType(png, []string{"text/*", "application/xml"}) {
+		t.Errorf("expected %s to not match", png.String())
+	}
+}
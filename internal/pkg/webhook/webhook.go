@@ -0,0 +1,96 @@
+// Package webhook notifies an external service when the crawl finishes.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+	"github.com/internetarchive/Zeno/internal/pkg/log"
+)
+
+const maxAttempts = 3
+
+var (
+	// retryBackoff is the wait before the second attempt, it doubles after each failed attempt
+	retryBackoff = 2 * time.Second
+	// requestTimeout is the timeout of a single attempt
+	requestTimeout = 30 * time.Second
+)
+
+// Summary is the JSON payload POSTed to the webhook when the crawl finishes
+type Summary struct {
+	Job           string    `json:"job"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	SeedsCrawled  uint64    `json:"seeds_crawled"`
+	AssetsCrawled uint64    `json:"assets_crawled"`
+	URLsCrawled   uint64    `json:"urls_crawled"`
+	BytesWritten  int64     `json:"bytes_written"`
+	Errors        uint64    `json:"errors"`
+	WARCFiles     []string  `json:"warc_files"`
+}
+
+// Send POSTs the summary to the configured webhook URL, retrying with an exponential backoff on failure
+func Send(summary *Summary) error {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "webhook.Send",
+	})
+
+	payload, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	backoff := retryBackoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = post(config.Get().WebhookURL, config.Get().WebhookAuth, payload)
+		if err == nil {
+			logger.Info("crawl summary sent to webhook", "url", config.Get().WebhookURL, "attempt", attempt)
+			return nil
+		}
+
+		if attempt < maxAttempts {
+			logger.Warn("unable to send crawl summary to webhook, retrying", "err", err.Error(), "url", config.Get().WebhookURL, "attempt", attempt, "sleep_time", backoff.String())
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	return fmt.Errorf("webhook failed after %d attempts: %w", maxAttempts, err)
+}
+
+func post(URL, auth string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", config.Get().UserAgent)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/config"
+)
+
+func setupConfig(t *testing.T, URL, auth string) {
+	t.Helper()
+
+	if err := config.InitConfig(); err != nil {
+		t.Fatalf("unable to init config: %v", err)
+	}
+
+	config.Get().WebhookURL = URL
+	config.Get().WebhookAuth = auth
+
+	retryBackoff = time.Millisecond
+}
+
+func TestSendRetriesUntilSuccess(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected Authorization header: %q", r.Header.Get("Authorization"))
+		}
+
+		var summary Summary
+		if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+			t.Errorf("unable to decode payload: %v", err)
+		}
+
+		if summary.Job != "test-job" || summary.SeedsCrawled != 2 || len(summary.WARCFiles) != 1 {
+			t.Errorf("unexpected payload: %+v", summary)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	setupConfig(t, server.URL, "Bearer secret")
+
+	err := Send(&Summary{
+		Job:          "test-job",
+		SeedsCrawled: 2,
+		WARCFiles:    []string{"jobs/test-job/warcs/ZENO-00000.warc.gz"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attempts.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	setupConfig(t, server.URL, "")
+
+	if err := Send(&Summary{Job: "test-job"}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if attempts.Load() != maxAttempts {
+		t.Errorf("expected %d attempts, got %d", maxAttempts, attempts.Load())
+	}
+}
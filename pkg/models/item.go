@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -22,6 +23,9 @@ type Item struct {
 	children   []*Item      // Children is a slice of Item created from this item
 	parent     *Item        // Parent is the parent of the item (will be nil if the item is a seed)
 	err        error        // Error message of the seed
+	headers    http.Header  // Headers are the extra request headers of the seed, its children inherit them
+	maxHops    int          // MaxHops is the max hops of the seed, -1 if the global one applies
+	attempts   int          // Attempts is the number of times the item was requeued after a transient network failure
 }
 
 // ItemState qualifies the state of a item in the pipeline
@@ -176,6 +180,21 @@ func (i *Item) GetSource() ItemSource { return i.source }
 // GetBase returns the base URL of the item
 func (i *Item) GetBase() string { return i.base }
 
+// GetHeaders returns the extra request headers of the item, the ones of its seed
+func (i *Item) GetHeaders() http.Header { return i.GetSeed().headers }
+
+// GetMaxHops returns the max hops of the item, the one of its seed, -1 if the global one applies
+func (i *Item) GetMaxHops() int { return i.GetSeed().maxHops }
+
+// EffectiveMaxHops returns the max hops of the seed of the item, global if it has none
+func (i *Item) EffectiveMaxHops(global int) int {
+	if maxHops := i.GetMaxHops(); maxHops >= 0 {
+		return maxHops
+	}
+
+	return global
+}
+
 // GetMaxDepth returns the maxDepth of the item by traversing the tree
 func (i *Item) GetMaxDepth() int64 {
 	if len(i.GetChildren()) == 0 {
@@ -236,6 +255,9 @@ func (i *Item) GetParent() *Item { return i.parent }
 // GetError returns the error of the item
 func (i *Item) GetError() error { return i.err }
 
+// GetAttempts returns the number of times the item was requeued after a transient network failure
+func (i *Item) GetAttempts() int { return i.attempts }
+
 // GetSeed returns the seed (topmost parent) of any given item
 func (i *Item) GetSeed() *Item {
 	if i.IsSeed() {
@@ -295,9 +317,33 @@ func (i *Item) SetSource(source ItemSource) error {
 // SetBase sets the base URL of the item
 func (i *Item) SetBase(base string) { i.base = base }
 
+// SetHeaders sets the extra request headers of a seed
+func (i *Item) SetHeaders(headers http.Header) error {
+	if !i.IsSeed() {
+		return fmt.Errorf("headers are set on the seeds only")
+	}
+	i.headers = headers
+	return nil
+}
+
+// SetMaxHops sets the max hops of a seed, -1 to apply the global one
+func (i *Item) SetMaxHops(maxHops int) error {
+	if !i.IsSeed() {
+		return fmt.Errorf("max hops are set on the seeds only")
+	}
+	i.maxHops = maxHops
+	return nil
+}
+
 // SetError sets the error of the item
 func (i *Item) SetError(err error) { i.err = err }
 
+// IncrAttempts increments the number of times the item was requeued and returns it
+func (i *Item) IncrAttempts() int {
+	i.attempts++
+	return i.attempts
+}
+
 // NewItem creates a new item with the given ID, URL and seedVia
 func NewItem(ID string, URL *URL, seedVia string) *Item {
 	if ID == "" || URL == nil {
@@ -310,6 +356,7 @@ func NewItem(ID string, URL *URL, seedVia string) *Item {
 		parent:  nil,
 		seedVia: seedVia,
 		status:  ItemFresh,
+		maxHops: -1,
 	}
 }
 
@@ -187,6 +187,22 @@ func TestItem_GetError(t *testing.T) {
 	}
 }
 
+func TestItem_IncrAttempts(t *testing.T) {
+	item := createTestItem("testID", nil)
+	if got := item.GetAttempts(); got != 0 {
+		t.Errorf("GetAttempts() = %d, want 0", got)
+	}
+
+	item.IncrAttempts()
+	if got := item.IncrAttempts(); got != 2 {
+		t.Errorf("IncrAttempts() = %d, want 2", got)
+	}
+
+	if got := item.GetAttempts(); got != 2 {
+		t.Errorf("GetAttempts() = %d, want 2", got)
+	}
+}
+
 func TestItem_CheckConsistency(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1108,6 +1124,36 @@ func TestItem_SetError(t *testing.T) {
 	}
 }
 
+func TestItem_MaxHops(t *testing.T) {
+	seed := NewItem("seed", &URL{Raw: "http://example.com"}, "")
+	if seed.GetMaxHops() != -1 {
+		t.Errorf("GetMaxHops() = %d for a new seed, want -1", seed.GetMaxHops())
+	}
+	if seed.EffectiveMaxHops(2) != 2 {
+		t.Errorf("EffectiveMaxHops() = %d for a new seed, want the global max hops", seed.EffectiveMaxHops(2))
+	}
+
+	if err := seed.SetMaxHops(3); err != nil {
+		t.Fatalf("SetMaxHops() error = %v", err)
+	}
+
+	child := NewItem("child", &URL{Raw: "http://example.com/child"}, "")
+	if err := seed.AddChild(child, ItemGotChildren); err != nil {
+		t.Fatalf("AddChild() error = %v", err)
+	}
+
+	if child.GetMaxHops() != 3 {
+		t.Errorf("GetMaxHops() = %d for a child, want the max hops of its seed", child.GetMaxHops())
+	}
+	if child.EffectiveMaxHops(2) != 3 {
+		t.Errorf("EffectiveMaxHops() = %d for a child, want the max hops of its seed", child.EffectiveMaxHops(2))
+	}
+
+	if err := child.SetMaxHops(1); err == nil {
+		t.Errorf("SetMaxHops() on a child, want an error")
+	}
+}
+
 func TestNewItem(t *testing.T) {
 	tests := []struct {
 		name     string
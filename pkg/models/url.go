@@ -26,6 +26,11 @@ type URL struct {
 	Hops      int // This determines the number of hops this item is the result of, a hop is a "jump" from 1 page to another page
 	Redirects int
 
+	headless    bool   // true if the URL was captured by the headless browser
+	soft404     bool   // true if the response matches the error page its host returns for missing URLs
+	webSocket   bool   // true if the URL is the HTTP handshake of a WebSocket endpoint
+	digest      string // payload digest of the captured body, e.g. sha1:<base32>
+	recordID    string // WARC-Record-ID of the response record, e.g. <urn:uuid:...>
 	stringCache string
 	once        sync.Once
 }
@@ -111,6 +116,46 @@ func (u *URL) GetHops() int {
 	return u.Hops
 }
 
+func (u *URL) SetHeadless(headless bool) {
+	u.headless = headless
+}
+
+func (u *URL) IsHeadless() bool {
+	return u.headless
+}
+
+func (u *URL) SetSoft404(soft404 bool) {
+	u.soft404 = soft404
+}
+
+func (u *URL) IsSoft404() bool {
+	return u.soft404
+}
+
+func (u *URL) SetWebSocket(webSocket bool) {
+	u.webSocket = webSocket
+}
+
+func (u *URL) IsWebSocket() bool {
+	return u.webSocket
+}
+
+func (u *URL) SetDigest(digest string) {
+	u.digest = digest
+}
+
+func (u *URL) GetDigest() string {
+	return u.digest
+}
+
+func (u *URL) SetRecordID(recordID string) {
+	u.recordID = recordID
+}
+
+func (u *URL) GetRecordID() string {
+	return u.recordID
+}
+
 func (u *URL) String() string {
 	u.once.Do(func() {
 		u.stringCache = URLToString(u.parsed)
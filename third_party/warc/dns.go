@@ -0,0 +1,196 @@
+package warc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const maxFallbackDNSServers = 3
+
+// archiveDNS resolves the host of address and writes the DNS records, it returns the addresses to connect to
+// in order: the IPv6 one first, or only the preferred version if the host has an address of it.
+func (d *customDialer) archiveDNS(ctx context.Context, address string) (resolvedIPs []net.IP, cached bool, err error) {
+	// The static addresses aren't resolved
+	if d.client.staticAddress != nil {
+		if resolvedIP := d.client.staticAddress(address); resolvedIP != nil {
+			return []net.IP{resolvedIP}, false, nil
+		}
+	}
+
+	// Get the address without the port if there is one
+	address, _, err = net.SplitHostPort(address)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Check if the address is already an IP
+	if resolvedIP := net.ParseIP(address); resolvedIP != nil {
+		return []net.IP{resolvedIP}, false, nil
+	}
+
+	uncached := d.client.uncachedHost != nil && d.client.uncachedHost(address)
+
+	// Check cache first, the stale addresses are used while the host is resolved again
+	if cached, ok := d.DNSRecords.Get(address); ok && !uncached {
+		switch age := time.Since(cached.resolved); {
+		case age < d.DNSRecordsTTL:
+			return cached.IPs, true, nil
+		case age < d.DNSRecordsTTL+d.DNSRecordsStale:
+			d.revalidate(address)
+			return cached.IPs, true, nil
+		}
+	}
+
+	resolvedIPs, err = d.resolve(ctx, address)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !uncached {
+		d.cacheIPs(address, resolvedIPs)
+	}
+
+	return resolvedIPs, false, nil
+}
+
+// cacheIPs caches the addresses of the host for DNSRecordsTTL, they're kept DNSRecordsStale longer to be
+// used while the host is resolved again
+func (d *customDialer) cacheIPs(host string, IPs []net.IP) {
+	d.DNSRecords.Set(host, cachedIPs{IPs: IPs, resolved: time.Now()})
+}
+
+// revalidate resolves the host again in the background, once at a time, and caches its new addresses.
+// The addresses are kept if the resolution fails, until they're evicted.
+func (d *customDialer) revalidate(host string) {
+	if _, revalidating := d.revalidating.LoadOrStore(host, struct{}{}); revalidating {
+		return
+	}
+
+	// The DNS records are written before the client closes
+	d.client.WaitGroup.Add(1)
+
+	go func() {
+		defer d.client.WaitGroup.Done()
+		defer d.revalidating.Delete(host)
+
+		ctx, cancel := context.WithTimeout(context.Background(), d.DNSClient.Timeout*(maxFallbackDNSServers+1))
+		defer cancel()
+
+		if IPs, err := d.resolve(ctx, host); err == nil {
+			d.cacheIPs(host, IPs)
+		}
+	}()
+}
+
+// resolve resolves the host with the DNS servers, the next ones are tried if both lookups fail, and writes
+// the DNS records
+func (d *customDialer) resolve(ctx context.Context, address string) (resolvedIPs []net.IP, err error) {
+	var wg sync.WaitGroup
+	var ipv4, ipv6 net.IP
+	var errA, errAAAA error
+
+	if len(d.DNSConfig.Servers) == 0 {
+		return nil, fmt.Errorf("no DNS servers configured")
+	}
+
+	fallbackServers := min(maxFallbackDNSServers, len(d.DNSConfig.Servers)-1)
+
+	for DNSServer := 0; DNSServer <= fallbackServers; DNSServer++ {
+		if !d.disableIPv4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ipv4, errA = d.lookupIP(ctx, address, dns.TypeA, DNSServer)
+			}()
+		}
+
+		if !d.disableIPv6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ipv6, errAAAA = d.lookupIP(ctx, address, dns.TypeAAAA, DNSServer)
+			}()
+		}
+
+		wg.Wait()
+
+		if errA == nil || errAAAA == nil {
+			break
+		}
+	}
+	if errA != nil && errAAAA != nil {
+		return nil, fmt.Errorf("failed to resolve DNS: A error: %v, AAAA error: %v", errA, errAAAA)
+	}
+
+	resolvedIPs = d.orderIPs(ipv4, ipv6)
+	if len(resolvedIPs) == 0 {
+		return nil, fmt.Errorf("no suitable IP address found for %s", address)
+	}
+
+	return resolvedIPs, nil
+}
+
+// orderIPs returns the addresses of the enabled IP versions to connect to, in order
+func (d *customDialer) orderIPs(ipv4, ipv6 net.IP) (IPs []net.IP) {
+	if d.disableIPv4 {
+		ipv4 = nil
+	}
+
+	if d.disableIPv6 {
+		ipv6 = nil
+	}
+
+	switch {
+	case d.preferIPv4 && ipv4 != nil:
+		return []net.IP{ipv4}
+	case d.preferIPv6 && ipv6 != nil:
+		return []net.IP{ipv6}
+	}
+
+	// Prioritize IPv6 if both are available and enabled
+	for _, IP := range []net.IP{ipv6, ipv4} {
+		if IP != nil {
+			IPs = append(IPs, IP)
+		}
+	}
+
+	return IPs
+}
+
+func (d *customDialer) lookupIP(ctx context.Context, address string, recordType uint16, DNSServer int) (net.IP, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(address), recordType)
+
+	r, _, err := d.DNSClient.ExchangeContext(ctx, m, net.JoinHostPort(d.DNSConfig.Servers[DNSServer], d.DNSConfig.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the DNS response
+	recordTypeStr := "TYPE=A"
+	if recordType == dns.TypeAAAA {
+		recordTypeStr = "TYPE=AAAA"
+	}
+
+	d.client.WriteRecord(fmt.Sprintf("dns:%s?%s", address, recordTypeStr), "resource", "text/dns", r.String(), nil)
+
+	for _, answer := range r.Answer {
+		switch recordType {
+		case dns.TypeA:
+			if a, ok := answer.(*dns.A); ok {
+				return a.A, nil
+			}
+		case dns.TypeAAAA:
+			if aaaa, ok := answer.(*dns.AAAA); ok {
+				return aaaa.AAAA, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no %s record found", recordTypeStr)
+}
@@ -0,0 +1,83 @@
+package warc
+
+import (
+	"crypto/tls"
+	"hash"
+	"io"
+	"net/http"
+	"time"
+
+	gzip "github.com/klauspost/compress/gzip"
+)
+
+type customTransport struct {
+	t              http.Transport
+	decompressBody bool
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err = t.t.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	// The body can be hashed as received through the request context, before its decompression
+	if payloadHash, ok := req.Context().Value("payloadHash").(hash.Hash); ok {
+		resp.Body = &hashingBody{ReadCloser: resp.Body, hash: payloadHash}
+	}
+
+	// if the client have been created with decompressBody = true,
+	// we decompress the resp.Body if we received a compressed body
+	if t.decompressBody {
+		switch resp.Header.Get("Content-Encoding") {
+		case "gzip":
+			resp.Body, err = gzip.NewReader(resp.Body)
+		}
+	}
+
+	return
+}
+
+// hashingBody writes the bytes read from the body to a hash
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (b *hashingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+
+	return n, err
+}
+
+func newCustomTransport(dialer *customDialer, decompressBody bool, TLSHandshakeTimeout time.Duration) (t *customTransport, err error) {
+	t = new(customTransport)
+
+	t.t = http.Transport{
+		// configure HTTP transport
+		Dial:           dialer.CustomDial,
+		DialContext:    dialer.CustomDialContext,
+		DialTLS:        dialer.CustomDialTLS,
+		DialTLSContext: dialer.CustomDialTLSContext,
+
+		// disable keep alive
+		MaxConnsPerHost:       0,
+		IdleConnTimeout:       -1,
+		TLSHandshakeTimeout:   TLSHandshakeTimeout,
+		ExpectContinueTimeout: 5 * time.Second,
+		TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+		DisableCompression:    true,
+		ForceAttemptHTTP2:     false,
+		MaxIdleConns:          -1,
+		MaxIdleConnsPerHost:   -1,
+		DisableKeepAlives:     true,
+	}
+
+	t.decompressBody = decompressBody
+
+	return t, nil
+}